@@ -13,8 +13,15 @@
 package external
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"math/big"
+	"net/http"
+	"net/url"
+	"os"
 	"sync"
 
 	"github.com/ethereum/go-ethereum"
@@ -37,7 +44,13 @@ func (eb *ExternalBackend) Wallets() []accounts.Wallet {
 }
 
 func NewExternalBackend(endpoint string) (*ExternalBackend, error) {
-	signer, err := NewExternalSigner(endpoint)
+	return NewExternalBackendWithAuth(endpoint, Auth{})
+}
+
+// NewExternalBackendWithAuth creates a backend of the remote signer served at
+// the endpoint, authenticating with the given credentials.
+func NewExternalBackendWithAuth(endpoint string, auth Auth) (*ExternalBackend, error) {
+	signer, err := NewExternalSignerWithAuth(endpoint, auth)
 	if err != nil {
 		return nil, err
 	}
@@ -65,7 +78,29 @@ type ExternalSigner struct {
 }
 
 func NewExternalSigner(endpoint string) (*ExternalSigner, error) {
-	client, err := rpc.Dial(endpoint)
+	return NewExternalSignerWithAuth(endpoint, Auth{})
+}
+
+// Auth are the credentials authenticating the node to a remote signer, such as
+// clef or an HSM fronted by the clef protocol, served over HTTPS. This keeps the
+// signing keys, e.g. pool payout ones, off the node entirely.
+type Auth struct {
+	CertFile string // Client certificate presented to the signer
+	KeyFile  string // Key of the client certificate
+	CAFile   string // Authority to verify the signer's certificate against, the system ones if empty
+	Token    string // Bearer token sent along every request
+}
+
+// empty returns whether no credentials are set.
+func (a Auth) empty() bool {
+	return a == Auth{}
+}
+
+// NewExternalSignerWithAuth creates a signer of the remote signer served at the
+// endpoint, authenticating with the given credentials. Credentials are only
+// accepted for HTTPS endpoints, they'd be leaked otherwise.
+func NewExternalSignerWithAuth(endpoint string, auth Auth) (*ExternalSigner, error) {
+	client, err := dial(endpoint, auth)
 	if err != nil {
 		return nil, err
 	}
@@ -82,6 +117,42 @@ func NewExternalSigner(endpoint string) (*ExternalSigner, error) {
 	return extsigner, nil
 }
 
+// dial connects to the remote signer, over a mutually authenticated TLS
+// connection if credentials are set.
+func dial(endpoint string, auth Auth) (*rpc.Client, error) {
+	if auth.empty() {
+		return rpc.Dial(endpoint)
+	}
+	if u, err := url.Parse(endpoint); err != nil || u.Scheme != "https" {
+		return nil, errors.New("signer credentials require an https endpoint")
+	}
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if auth.CertFile != "" || auth.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(auth.CertFile, auth.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load signer client certificate: %v", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	if auth.CAFile != "" {
+		blob, err := os.ReadFile(auth.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load signer authority: %v", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(blob) {
+			return nil, fmt.Errorf("no certificates in signer authority %s", auth.CAFile)
+		}
+	}
+	options := []rpc.ClientOption{
+		rpc.WithHTTPClient(&http.Client{Transport: &http.Transport{TLSClientConfig: config}}),
+	}
+	if auth.Token != "" {
+		options = append(options, rpc.WithHeader("Authorization", "Bearer "+auth.Token))
+	}
+	return rpc.DialOptions(context.Background(), endpoint, options...)
+}
+
 func (api *ExternalSigner) URL() accounts.URL {
 	return accounts.URL{
 		Scheme: "extapi",
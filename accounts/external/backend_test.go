@@ -0,0 +1,70 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package external
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// testSigner serves the parts of the clef API the signer needs to connect.
+type testSigner struct{}
+
+func (testSigner) Version() string        { return "6.0.0" }
+func (testSigner) List() []common.Address { return []common.Address{{0x01}} }
+
+// Tests that a remote signer is reached over HTTPS with the configured authority
+// and token, and that credentials are never sent to plain endpoints.
+func TestAuthenticatedSigner(t *testing.T) {
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("account", testSigner{}); err != nil {
+		t.Fatalf("failed to register signer: %v", err)
+	}
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		server.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	ca := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(ca, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}), 0600); err != nil {
+		t.Fatalf("failed to write authority: %v", err)
+	}
+	signer, err := NewExternalSignerWithAuth(ts.URL, Auth{CAFile: ca, Token: "secret"})
+	if err != nil {
+		t.Fatalf("failed to connect to signer: %v", err)
+	}
+	if accounts := signer.Accounts(); len(accounts) != 1 || accounts[0].Address != (common.Address{0x01}) {
+		t.Errorf("accounts mismatch: have %v", accounts)
+	}
+	if _, err := NewExternalSignerWithAuth(ts.URL, Auth{CAFile: ca, Token: "wrong"}); err == nil {
+		t.Errorf("connected with a wrong token")
+	}
+	if _, err := NewExternalSignerWithAuth(ts.URL, Auth{Token: "secret"}); err == nil {
+		t.Errorf("connected to a signer of an untrusted authority")
+	}
+	if _, err := NewExternalSignerWithAuth("http"+ts.URL[len("https"):], Auth{Token: "secret"}); err == nil {
+		t.Errorf("credentials sent to a plain endpoint")
+	}
+}
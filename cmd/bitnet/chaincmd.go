@@ -35,6 +35,7 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/trie"
 	"github.com/urfave/cli/v2"
 )
@@ -113,6 +114,39 @@ Optional second and third arguments control the first and
 last block to write. In this mode, the file will be appended
 if already existing. If the file ends with .gz, the output will
 be gzipped.`,
+	}
+	importEraCommand = &cli.Command{
+		Action:    importEra,
+		Name:      "import-era",
+		Usage:     "Import blockchain history from era1 archives",
+		ArgsUsage: "<dir>",
+		Flags: flags.Merge([]cli.Flag{
+			utils.CacheFlag,
+			utils.SyncModeFlag,
+			utils.TxLookupLimitFlag,
+		}, utils.DatabasePathFlags, utils.NetworkFlags),
+		Description: `
+The import-era command imports the blocks and receipts of the era1 archives of the
+selected network in the given directory, as written by export-era. Every archive
+is verified against its accumulator, and against the checksums.txt file in the
+directory if present. The history is inserted without execution, so the state
+has to be synced afterwards, starting from the imported head.`,
+	}
+	exportEraCommand = &cli.Command{
+		Action:    exportEra,
+		Name:      "export-era",
+		Usage:     "Export blockchain history into era1 archives",
+		ArgsUsage: "<dir> [<blockNumFirst> <blockNumLast>]",
+		Flags: flags.Merge([]cli.Flag{
+			utils.CacheFlag,
+			utils.SyncModeFlag,
+		}, utils.DatabasePathFlags, utils.NetworkFlags),
+		Description: `
+Requires a first argument of the directory to write to. Optional second and third
+arguments control the first and last block to write, the first being rounded down
+to an epoch boundary. The history is written in era1 archives of 8192 blocks each,
+named after the network, the epoch and their accumulator root, along with a
+checksums.txt file, ready to be distributed to bootstrap new nodes.`,
 	}
 	importPreimagesCommand = &cli.Command{
 		Action:    importPreimages,
@@ -358,6 +392,64 @@ func exportChain(ctx *cli.Context) error {
 	return nil
 }
 
+// importEra imports the blockchain history from the era1 archives in the
+// specified directory.
+func importEra(ctx *cli.Context) error {
+	if ctx.Args().Len() != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chain, db := utils.MakeChain(ctx, stack, false)
+	defer db.Close()
+
+	start := time.Now()
+	if err := utils.ImportHistory(chain, ctx.Args().First(), eraNetwork(chain)); err != nil {
+		utils.Fatalf("Import error: %v\n", err)
+	}
+	fmt.Printf("Import done in %v\n", time.Since(start))
+	return nil
+}
+
+// exportEra exports the blockchain history into era1 archives in the specified
+// directory.
+func exportEra(ctx *cli.Context) error {
+	if ctx.Args().Len() != 1 && ctx.Args().Len() != 3 {
+		utils.Fatalf("This command requires one or three arguments.")
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chain, _ := utils.MakeChain(ctx, stack, true)
+
+	first, last := uint64(0), chain.CurrentSnapBlock().Number.Uint64()
+	if ctx.Args().Len() == 3 {
+		var ferr, lerr error
+		first, ferr = strconv.ParseUint(ctx.Args().Get(1), 10, 64)
+		last, lerr = strconv.ParseUint(ctx.Args().Get(2), 10, 64)
+		if ferr != nil || lerr != nil {
+			utils.Fatalf("Export error in parsing parameters: block number not an integer\n")
+		}
+	}
+	start := time.Now()
+	if err := utils.ExportHistory(chain, ctx.Args().First(), eraNetwork(chain), first, last); err != nil {
+		utils.Fatalf("Export error: %v\n", err)
+	}
+	fmt.Printf("Export done in %v\n", time.Since(start))
+	return nil
+}
+
+// eraNetwork returns the network name the era1 archives of a chain are named
+// after.
+func eraNetwork(chain *core.BlockChain) string {
+	id := chain.Config().ChainID.String()
+	if name, ok := params.NetworkNames[id]; ok {
+		return name
+	}
+	return "chain" + id
+}
+
 // importPreimages imports preimage data from the specified file.
 func importPreimages(ctx *cli.Context) error {
 	if ctx.Args().Len() < 1 {
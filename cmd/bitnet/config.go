@@ -29,6 +29,7 @@ import (
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/grpcgw"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/internal/flags"
 	"github.com/ethereum/go-ethereum/internal/version"
@@ -163,6 +164,42 @@ func makeFullNode(ctx *cli.Context) (*node.Node, ethapi.Backend) {
 	// Configure log filter RPC API.
 	filterSystem := utils.RegisterFilterAPI(stack, backend, &cfg.Eth)
 
+	// Configure the gRPC gateway if requested.
+	if addr := ctx.String(utils.GRPCAddrFlag.Name); addr != "" {
+		utils.RegisterGRPCService(stack, grpcgw.Config{
+			Addr:    addr,
+			TLSCert: ctx.String(utils.GRPCTLSCertFlag.Name),
+			TLSKey:  ctx.String(utils.GRPCTLSKeyFlag.Name),
+		})
+	}
+	// Configure the internal call trace indexer if requested.
+	if ctx.IsSet(utils.TraceIndexFlag.Name) {
+		utils.RegisterTraceIndexService(stack, eth, &cfg.Eth)
+	}
+	// Configure the token transfer indexer if requested.
+	if ctx.IsSet(utils.TransferIndexFlag.Name) {
+		utils.RegisterTransferIndexService(stack, eth, &cfg.Eth, ctx.Uint64(utils.TransferIndexFromFlag.Name))
+	}
+	// Configure the rebroadcaster of unmined local transactions if requested.
+	if ctx.Uint64(utils.TxPoolRebroadcastFlag.Name) > 0 {
+		utils.RegisterRebroadcastService(stack, eth, utils.MakeRebroadcastConfig(ctx))
+	}
+	// Configure the developer chain API if requested.
+	if ctx.Bool(utils.DeveloperBitnetFlag.Name) {
+		utils.RegisterDevService(stack, eth, cfg.Eth.Miner.Etherbase)
+	}
+	// Configure the chain security alert monitor if requested.
+	if ctx.Bool(utils.AlertsEnabledFlag.Name) {
+		utils.RegisterAlertService(stack, eth, utils.MakeAlertConfig(ctx))
+	}
+	// Configure the sweeper of the deposit addresses if requested.
+	if ctx.IsSet(utils.SweeperDepositsFlag.Name) {
+		utils.RegisterSweeperService(stack, eth, utils.MakeSweeperConfig(ctx))
+	}
+	// Configure the health and readiness checks if requested.
+	if ctx.Bool(utils.HealthEnabledFlag.Name) {
+		utils.RegisterHealthService(stack, eth, utils.MakeHealthConfig(ctx))
+	}
 	// Configure GraphQL if requested.
 	if ctx.IsSet(utils.GraphQLEnabledFlag.Name) {
 		utils.RegisterGraphQLService(stack, backend, filterSystem, &cfg.Node)
@@ -279,7 +316,13 @@ func setAccountManagerBackends(stack *node.Node) error {
 	// Assemble the supported backends
 	if len(conf.ExternalSigner) > 0 {
 		log.Info("Using external signer", "url", conf.ExternalSigner)
-		if extapi, err := external.NewExternalBackend(conf.ExternalSigner); err == nil {
+		auth := external.Auth{
+			CertFile: conf.ExternalSignerCert,
+			KeyFile:  conf.ExternalSignerKey,
+			CAFile:   conf.ExternalSignerCA,
+			Token:    conf.ExternalSignerToken,
+		}
+		if extapi, err := external.NewExternalBackendWithAuth(conf.ExternalSigner, auth); err == nil {
 			am.AddBackend(extapi)
 			return nil
 		} else {
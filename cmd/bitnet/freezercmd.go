@@ -0,0 +1,65 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/internal/flags"
+	cli "github.com/urfave/cli/v2"
+)
+
+var (
+	freezerCompressionFlag = &cli.StringFlag{
+		Name:  "compression",
+		Usage: "Compression to convert the ancient store tables to ('snappy' or 'zstd')",
+		Value: "zstd",
+	}
+	freezerCommand = &cli.Command{
+		Name:  "freezer",
+		Usage: "A set of commands managing the ancient store",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "compress",
+				Usage:  "Convert the compressed ancient store tables to another compression",
+				Action: compressFreezer,
+				Flags:  flags.Merge([]cli.Flag{freezerCompressionFlag}, utils.NetworkFlags, utils.DatabasePathFlags),
+				Description: `
+bitnet freezer compress [--compression zstd]
+This command rewrites the block header, body and receipt tables of the ancient
+store with the given compression, cutting the disk usage of archive nodes with
+zstd. Tables already using it are skipped. The node must be stopped, and an
+interrupted conversion may corrupt the ancient store. Run the node with
+--db.ancient.compression as well for tables created later on to match.
+`,
+			},
+		},
+	}
+)
+
+func compressFreezer(ctx *cli.Context) error {
+	compression, err := rawdb.ParseFreezerCompression(ctx.String(freezerCompressionFlag.Name))
+	if err != nil {
+		return err
+	}
+	stack, _ := makeConfigNode(ctx)
+	ancient := stack.ResolveAncient("chaindata", ctx.String(utils.AncientFlag.Name))
+	stack.Close()
+
+	if err := rawdb.CompressChainFreezer(ancient, compression); err != nil {
+		return fmt.Errorf("failed to compress ancient store: %w", err)
+	}
+	return nil
+}
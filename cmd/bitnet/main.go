@@ -57,6 +57,10 @@ var (
 		utils.MinFreeDiskSpaceFlag,
 		utils.KeyStoreDirFlag,
 		utils.ExternalSignerFlag,
+		utils.ExternalSignerCertFlag,
+		utils.ExternalSignerKeyFlag,
+		utils.ExternalSignerCAFlag,
+		utils.ExternalSignerTokenFlag,
 		utils.NoUSBFlag,
 		utils.USBFlag,
 		utils.SmartCardDaemonPathFlag,
@@ -73,9 +77,15 @@ var (
 		utils.TxPoolLocalsFlag,
 		utils.TxPoolNoLocalsFlag,
 		utils.TxPoolJournalFlag,
+		utils.TxPoolPersistFlag,
+		utils.TxPoolPolicyFlag,
+		utils.TxPoolRebroadcastFlag,
+		utils.TxPoolRebroadcastBumpAfterFlag,
+		utils.TxPoolRebroadcastPriceBumpFlag,
 		utils.TxPoolRejournalFlag,
 		utils.TxPoolPriceLimitFlag,
 		utils.TxPoolPriceBumpFlag,
+		utils.TxPoolPriceBumpsFlag,
 		utils.TxPoolAccountSlotsFlag,
 		utils.TxPoolGlobalSlotsFlag,
 		utils.TxPoolAccountQueueFlag,
@@ -87,6 +97,14 @@ var (
 		utils.GCModeFlag,
 		utils.SnapshotFlag,
 		utils.TxLookupLimitFlag,
+		utils.StateHistoryFlag,
+		utils.FinalityDepthFlag,
+		utils.ForkScheduleFileFlag,
+		utils.ForkScheduleSignersFlag,
+		utils.OnlinePruneIntervalFlag,
+		utils.DataDirReadOnlyFlag,
+		utils.DataDirReadOnlyIPCFlag,
+		utils.DBShareFlag,
 		utils.LightServeFlag,
 		utils.LightIngressFlag,
 		utils.LightEgressFlag,
@@ -97,7 +115,9 @@ var (
 		utils.UltraLightFractionFlag,
 		utils.UltraLightOnlyAnnounceFlag,
 		utils.LightNoSyncServeFlag,
+		utils.LightSealGuardFlag,
 		utils.EthRequiredBlocksFlag,
+		utils.SyncCheckpointFlag,
 		utils.LegacyWhitelistFlag,
 		utils.BloomFilterSizeFlag,
 		utils.CacheFlag,
@@ -117,14 +137,22 @@ var (
 		utils.MaxPendingPeersFlag,
 		utils.MiningEnabledFlag,
 		utils.MinerThreadsFlag,
+		utils.MinerCPUsFlag,
+		utils.MinerAffinityFlag,
+		utils.MinerNUMADAGFlag,
 		utils.MinerNotifyFlag,
 		utils.MinerGasLimitFlag,
 		utils.MinerGasPriceFlag,
 		utils.MinerEtherbaseFlag,
 		utils.MinerExtraDataFlag,
+		utils.MinerWorkerIDFlag,
+		utils.MinerPoolTagFlag,
 		utils.MinerRecommitIntervalFlag,
 		utils.MinerNoVerifyFlag,
 		utils.MinerNewPayloadTimeout,
+		utils.MinerUnclesFlag,
+		utils.MinerMaxUnclesFlag,
+		utils.MinerUpstreamFlag,
 		utils.NATFlag,
 		utils.NoDiscoverFlag,
 		utils.DiscoveryV5Flag,
@@ -132,9 +160,17 @@ var (
 		utils.NodeKeyFileFlag,
 		utils.NodeKeyHexFlag,
 		utils.DNSDiscoveryFlag,
+		utils.AutoPruneFlag,
+		utils.FastAnnounceAddrFlag,
+		utils.FastAnnouncePeersFlag,
+		utils.FastAnnounceSecretFlag,
+		utils.BlockNotifyURLsFlag,
+		utils.BlockNotifySecretFlag,
 		utils.DeveloperFlag,
 		utils.DeveloperPeriodFlag,
 		utils.DeveloperGasLimitFlag,
+		utils.DeveloperBitnetFlag,
+		utils.DeveloperAllocFlag,
 		utils.VMEnableDebugFlag,
 		utils.NetworkIdFlag,
 		utils.EthStatsURLFlag,
@@ -144,7 +180,15 @@ var (
 		utils.GpoPercentileFlag,
 		utils.GpoMaxGasPriceFlag,
 		utils.GpoIgnoreGasPriceFlag,
+		utils.GpoPoolFlag,
+		utils.GpoPoolPercentileFlag,
+		utils.GpoPoolDepthFlag,
 		utils.MinerNotifyFullFlag,
+		utils.MinerNotifyStructuredFlag,
+		utils.MinerNotifySecretFlag,
+		utils.StratumV2Flag,
+		utils.MinerUpstreamsFlag,
+		utils.MinerStaleWindowFlag,
 		configFileFlag,
 	}, utils.NetworkFlags, utils.DatabasePathFlags)
 
@@ -158,6 +202,12 @@ var (
 		utils.AuthVirtualHostsFlag,
 		utils.JWTSecretFlag,
 		utils.HTTPVirtualHostsFlag,
+		utils.GRPCAddrFlag,
+		utils.GRPCTLSCertFlag,
+		utils.GRPCTLSKeyFlag,
+		utils.TraceIndexFlag,
+		utils.TransferIndexFlag,
+		utils.TransferIndexFromFlag,
 		utils.GraphQLEnabledFlag,
 		utils.GraphQLCORSDomainFlag,
 		utils.GraphQLVirtualHostsFlag,
@@ -172,10 +222,28 @@ var (
 		utils.IPCDisabledFlag,
 		utils.IPCPathFlag,
 		utils.InsecureUnlockAllowedFlag,
+		utils.SweeperDepositsFlag,
+		utils.SweeperColdFlag,
+		utils.SweeperThresholdFlag,
+		utils.SweeperReserveFlag,
+		utils.SweeperIntervalFlag,
 		utils.RPCGlobalGasCapFlag,
 		utils.RPCGlobalEVMTimeoutFlag,
 		utils.RPCGlobalTxFeeCapFlag,
+		utils.RPCTracerCPUTimeFlag,
+		utils.RPCTracerMemoryFlag,
+		utils.RPCTracerOutputFlag,
+		utils.RPCTracersFlag,
+		utils.RPCGetLogsMaxResultsFlag,
+		utils.RPCGetLogsMaxBlocksFlag,
 		utils.AllowUnprotectedTxs,
+		utils.RPCRateLimitFlag,
+		utils.RPCAuthKeysFlag,
+		utils.RPCBatchBudgetFlag,
+		utils.RPCBatchWeightsFlag,
+		utils.RPCBatchPartialFlag,
+		utils.RPCTraceFlag,
+		utils.RPCSlowLogFlag,
 	}
 
 	metricsFlags = []cli.Flag{
@@ -193,6 +261,17 @@ var (
 		utils.MetricsInfluxDBTokenFlag,
 		utils.MetricsInfluxDBBucketFlag,
 		utils.MetricsInfluxDBOrganizationFlag,
+		utils.AlertsEnabledFlag,
+		utils.AlertsReorgDepthFlag,
+		utils.AlertsDifficultySwingFlag,
+		utils.AlertsDifficultyJumpFlag,
+		utils.AlertsWebhookFlag,
+		utils.HealthEnabledFlag,
+		utils.HealthMinPeersFlag,
+		utils.HealthMaxBlockAgeFlag,
+		utils.HealthMaxBlockLagFlag,
+		utils.HealthDAGFlag,
+		utils.HealthSealerFlag,
 	}
 )
 
@@ -207,6 +286,8 @@ func init() {
 		initCommand,
 		importCommand,
 		exportCommand,
+		importEraCommand,
+		exportEraCommand,
 		importPreimagesCommand,
 		exportPreimagesCommand,
 		removedbCommand,
@@ -235,6 +316,8 @@ func init() {
 		snapshotCommand,
 		// See verkle.go
 		verkleCommand,
+		// See freezercmd.go
+		freezerCommand,
 	}
 	sort.Sort(cli.CommandsByName(app.Commands))
 
@@ -267,6 +350,10 @@ func main() {
 // prepare manipulates memory cache allowance and setups metric system.
 // This function should be called before launching devp2p stack.
 func prepare(ctx *cli.Context) {
+	// The Bitnet developer mode is a flavour of the developer mode
+	if ctx.Bool(utils.DeveloperBitnetFlag.Name) && !ctx.IsSet(utils.DeveloperFlag.Name) {
+		ctx.Set(utils.DeveloperFlag.Name, "true")
+	}
 	// If we're running a known preset, log it for convenience.
 	switch {
 	case ctx.IsSet(utils.RinkebyFlag.Name):
@@ -312,7 +399,7 @@ func prepare(ctx *cli.Context) {
 		}
 	}
 	// If we're running a light client on any network, drop the cache to some meaningfully low amount
-	if ctx.String(utils.SyncModeFlag.Name) == "light" && !ctx.IsSet(utils.CacheFlag.Name) {
+	if utils.IsLightClient(ctx) && !ctx.IsSet(utils.CacheFlag.Name) {
 		log.Info("Dropping default light client cache", "provided", ctx.Int(utils.CacheFlag.Name), "updated", 128)
 		ctx.Set(utils.CacheFlag.Name, strconv.Itoa(128))
 	}
@@ -424,7 +511,7 @@ func startNode(ctx *cli.Context, stack *node.Node, backend ethapi.Backend, isCon
 	// Start auxiliary services if enabled
 	if ctx.Bool(utils.MiningEnabledFlag.Name) || ctx.Bool(utils.DeveloperFlag.Name) {
 		// Mining only makes sense if a full node is running
-		if ctx.String(utils.SyncModeFlag.Name) == "light" {
+		if utils.IsLightClient(ctx) {
 			utils.Fatalf("Light clients do not support mining")
 		}
 		ethBackend, ok := backend.(*eth.EthAPIBackend)
@@ -16,11 +16,13 @@ package utils
 import (
 	"bufio"
 	"compress/gzip"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"syscall"
@@ -34,8 +36,10 @@ import (
 	"github.com/ethereum/go-ethereum/eth/ethconfig"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/internal/debug"
+	"github.com/ethereum/go-ethereum/internal/era"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/urfave/cli/v2"
 )
@@ -564,3 +568,229 @@ func ExportChaindata(fn string, kind string, iter ChainDataIterator, interrupt c
 		"elapsed", common.PrettyDuration(time.Since(start)))
 	return nil
 }
+
+// ExportHistory exports the blocks and receipts in the given range into era1
+// files in the given directory, one file per epoch of era.MaxEra1Size blocks.
+// The range is widened to start at an epoch boundary. A checksums.txt file with
+// the SHA256 of every file is written alongside, in epoch order.
+func ExportHistory(bc *core.BlockChain, dir, network string, first, last uint64) error {
+	log.Info("Exporting blockchain history", "dir", dir)
+	if head := bc.CurrentSnapBlock().Number.Uint64(); head < last {
+		log.Warn("Last block beyond head, setting last = head", "head", head, "last", last)
+		last = head
+	}
+	if first > last {
+		return fmt.Errorf("invalid block range: first %d, last %d", first, last)
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return fmt.Errorf("error creating output directory: %w", err)
+	}
+	var (
+		start     = time.Now()
+		reported  = time.Now()
+		checksums []string
+	)
+	for epoch := first / era.MaxEra1Size; epoch <= last/era.MaxEra1Size; epoch++ {
+		var (
+			from = epoch * era.MaxEra1Size
+			to   = from + era.MaxEra1Size - 1
+		)
+		if to > last {
+			to = last
+		}
+		root, checksum, err := exportEpoch(bc, dir, network, epoch, from, to)
+		if err != nil {
+			return err
+		}
+		checksums = append(checksums, checksum)
+
+		if time.Since(reported) >= 8*time.Second {
+			log.Info("Exporting blocks", "exported", to, "epoch", epoch, "root", root, "elapsed", common.PrettyDuration(time.Since(start)))
+			reported = time.Now()
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "checksums.txt"), []byte(strings.Join(checksums, "\n")+"\n"), os.ModePerm); err != nil {
+		return err
+	}
+	log.Info("Exported blockchain history", "dir", dir, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// exportEpoch writes the blocks in the given range into the era1 file of the
+// epoch, returning the accumulator root and the checksum of the file.
+func exportEpoch(bc *core.BlockChain, dir, network string, epoch, from, to uint64) (common.Hash, string, error) {
+	// The filename depends on the accumulator, so write into a temporary file
+	f, err := os.CreateTemp(dir, "era1-*.tmp")
+	if err != nil {
+		return common.Hash{}, "", err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	var (
+		hasher  = sha256.New()
+		builder = era.NewBuilder(io.MultiWriter(f, hasher))
+	)
+	for n := from; n <= to; n++ {
+		block := bc.GetBlockByNumber(n)
+		if block == nil {
+			return common.Hash{}, "", fmt.Errorf("export failed on #%d: not found", n)
+		}
+		receipts := bc.GetReceiptsByHash(block.Hash())
+		if receipts == nil {
+			return common.Hash{}, "", fmt.Errorf("export failed on #%d: receipts not found", n)
+		}
+		td := bc.GetTd(block.Hash(), n)
+		if td == nil {
+			return common.Hash{}, "", fmt.Errorf("export failed on #%d: total difficulty not found", n)
+		}
+		if err := builder.Add(block, receipts, td); err != nil {
+			return common.Hash{}, "", err
+		}
+	}
+	root, err := builder.Finalize()
+	if err != nil {
+		return common.Hash{}, "", err
+	}
+	if err := f.Close(); err != nil {
+		return common.Hash{}, "", err
+	}
+	if err := os.Rename(f.Name(), filepath.Join(dir, era.Filename(network, int(epoch), root))); err != nil {
+		return common.Hash{}, "", err
+	}
+	return root, common.Bytes2Hex(hasher.Sum(nil)), nil
+}
+
+// ImportHistory imports the blocks and receipts of the era1 files of a network
+// in the given directory. Each file is verified against the checksums.txt file
+// if present, and against its own accumulator. Blocks already present in the
+// chain are skipped.
+func ImportHistory(chain *core.BlockChain, dir, network string) error {
+	// Watch for Ctrl-C while the import is running.
+	// If a signal is received, the import will stop at the next file.
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+
+	files, err := era.ReadDir(dir, network)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no era1 files of network %q in %s", network, dir)
+	}
+	var checksums []string
+	if blob, err := os.ReadFile(filepath.Join(dir, "checksums.txt")); err == nil {
+		checksums = strings.Fields(string(blob))
+		if len(checksums) != len(files) {
+			return fmt.Errorf("checksum count mismatch: have %d, want %d", len(checksums), len(files))
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	} else {
+		log.Warn("No era1 checksums found, relying on the accumulators only", "dir", dir)
+	}
+	start := time.Now()
+	for i, file := range files {
+		select {
+		case <-interrupt:
+			return fmt.Errorf("interrupted")
+		default:
+		}
+		path := filepath.Join(dir, file)
+		if checksums != nil {
+			checksum, err := fileChecksum(path)
+			if err != nil {
+				return err
+			}
+			if checksum != checksums[i] {
+				return fmt.Errorf("checksum mismatch of %s: have %s, want %s", file, checksum, checksums[i])
+			}
+		}
+		if err := importEpoch(chain, path); err != nil {
+			return fmt.Errorf("error importing %s: %w", file, err)
+		}
+		log.Info("Imported era1 file", "file", file, "head", chain.CurrentSnapBlock().Number, "elapsed", common.PrettyDuration(time.Since(start)))
+	}
+	return nil
+}
+
+// importEpoch verifies and imports the blocks of a single era1 file, headers
+// first, then the bodies and receipts.
+func importEpoch(chain *core.BlockChain, path string) error {
+	e, err := era.Open(path)
+	if err != nil {
+		return err
+	}
+	defer e.Close()
+
+	if _, err := e.Verify(); err != nil {
+		return err
+	}
+	var (
+		head     = chain.CurrentSnapBlock().Number.Uint64()
+		last     = e.Start() + e.Count() - 1
+		blocks   types.Blocks
+		receipts []types.Receipts
+	)
+	if last <= head {
+		return nil
+	}
+	for n := e.Start(); n <= last; n++ {
+		block, err := e.GetBlockByNumber(n)
+		if err != nil {
+			return err
+		}
+		// The genesis is never imported, but must match the local one
+		if n == 0 {
+			if block.Hash() != chain.Genesis().Hash() {
+				return fmt.Errorf("genesis mismatch: have %x, want %x", block.Hash(), chain.Genesis().Hash())
+			}
+			continue
+		}
+		if n <= head {
+			continue
+		}
+		rcpts, err := e.GetReceiptsByNumber(n)
+		if err != nil {
+			return err
+		}
+		blocks, receipts = append(blocks, block), append(receipts, rcpts)
+	}
+	headers := make([]*types.Header, len(blocks))
+	for i, block := range blocks {
+		headers[i] = block.Header()
+	}
+	if _, err := chain.InsertHeaderChain(headers, 100); err != nil {
+		return err
+	}
+	// The recorded total difficulty must match the one of the local chain
+	want, err := e.GetTotalDifficulty(last)
+	if err != nil {
+		return err
+	}
+	if td := chain.GetTd(blocks[len(blocks)-1].Hash(), last); td == nil || td.Cmp(want) != 0 {
+		return fmt.Errorf("total difficulty mismatch at #%d: have %v, want %v", last, td, want)
+	}
+	var ancientLimit uint64
+	if last > params.FullImmutabilityThreshold {
+		ancientLimit = last - params.FullImmutabilityThreshold
+	}
+	_, err = chain.InsertReceiptChain(blocks, receipts, ancientLimit)
+	return err
+}
+
+// fileChecksum returns the hex encoded SHA256 of a file.
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return "", err
+	}
+	return common.Bytes2Hex(hasher.Sum(nil)), nil
+}
@@ -43,16 +43,24 @@ import (
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/eth/alerts"
 	ethcatalyst "github.com/ethereum/go-ethereum/eth/catalyst"
+	"github.com/ethereum/go-ethereum/eth/devnet"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/eth/ethconfig"
 	"github.com/ethereum/go-ethereum/eth/filters"
 	"github.com/ethereum/go-ethereum/eth/gasprice"
+	"github.com/ethereum/go-ethereum/eth/health"
+	"github.com/ethereum/go-ethereum/eth/rebroadcast"
+	"github.com/ethereum/go-ethereum/eth/sweeper"
+	"github.com/ethereum/go-ethereum/eth/traceindex"
 	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/eth/transferindex"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/ethdb/remotedb"
 	"github.com/ethereum/go-ethereum/ethstats"
 	"github.com/ethereum/go-ethereum/graphql"
+	"github.com/ethereum/go-ethereum/grpcgw"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/internal/flags"
 	"github.com/ethereum/go-ethereum/les"
@@ -62,6 +70,7 @@ import (
 	"github.com/ethereum/go-ethereum/metrics/exp"
 	"github.com/ethereum/go-ethereum/metrics/influxdb"
 	"github.com/ethereum/go-ethereum/miner"
+	"github.com/ethereum/go-ethereum/miner/workproxy"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/enode"
@@ -97,10 +106,36 @@ var (
 	}
 	DBEngineFlag = &cli.StringFlag{
 		Name:     "db.engine",
-		Usage:    "Backing database implementation to use ('leveldb' or 'pebble')",
+		Usage:    "Backing database implementation to use ('leveldb', 'pebble' or 'remote')",
 		Value:    "leveldb",
 		Category: flags.EthCategory,
 	}
+	DBAncientCompressionFlag = &cli.StringFlag{
+		Name:     "db.ancient.compression",
+		Usage:    "Compression of newly created ancient store tables ('snappy' or 'zstd'), existing tables are converted by 'freezer compress'",
+		Value:    "snappy",
+		Category: flags.EthCategory,
+	}
+	DBRemoteFlag = &cli.StringFlag{
+		Name:     "db.remote",
+		Usage:    "URL of the node sharing its chain database via the 'kv' RPC namespace, for the experimental 'remote' db.engine",
+		Category: flags.EthCategory,
+	}
+	DBShareFlag = &cli.BoolFlag{
+		Name:     "db.share",
+		Usage:    "Share the chain database read-only via the 'kv' RPC namespace with nodes using the experimental 'remote' db.engine (IPC, HTTP and WS only if listed)",
+		Category: flags.EthCategory,
+	}
+	DataDirReadOnlyFlag = &cli.BoolFlag{
+		Name:     "datadir.readonly",
+		Usage:    "Share the datadir of a running node read-only, serving RPC requests without networking or mining (recent state requires an archive owner)",
+		Category: flags.EthCategory,
+	}
+	DataDirReadOnlyIPCFlag = &cli.StringFlag{
+		Name:     "datadir.readonly.ipc",
+		Usage:    "IPC endpoint of the node owning the read-only datadir, announcing its new heads (default = its default IPC endpoint)",
+		Category: flags.EthCategory,
+	}
 	AncientFlag = &flags.DirectoryFlag{
 		Name:     "datadir.ancient",
 		Usage:    "Root directory for ancient data (default = inside chaindata)",
@@ -171,6 +206,16 @@ var (
 		Value:    11500000,
 		Category: flags.DevCategory,
 	}
+	DeveloperBitnetFlag = &cli.BoolFlag{
+		Name:     "dev.bitnet",
+		Usage:    "Developer mode following the Bitnet mainnet rules, with the dev RPC API (implies --dev)",
+		Category: flags.DevCategory,
+	}
+	DeveloperAllocFlag = &cli.StringFlag{
+		Name:     "dev.alloc",
+		Usage:    "Comma separated accounts to prefund in developer mode, as address or address=wei (default 10000 ether)",
+		Category: flags.DevCategory,
+	}
 
 	IdentityFlag = &cli.StringFlag{
 		Name:     "identity",
@@ -221,10 +266,15 @@ var (
 	defaultSyncMode = ethconfig.Defaults.SyncMode
 	SyncModeFlag    = &flags.TextMarshalerFlag{
 		Name:     "syncmode",
-		Usage:    `Blockchain sync mode ("snap", "full" or "light")`,
+		Usage:    `Blockchain sync mode ("snap", "full", "light" or "headers")`,
 		Value:    &defaultSyncMode,
 		Category: flags.EthCategory,
 	}
+	SyncCheckpointFlag = &cli.StringFlag{
+		Name:     "sync.checkpoint",
+		Usage:    "Trusted block to require from peers and snap sync up to without seal verification (<hash>:<number>)",
+		Category: flags.EthCategory,
+	}
 	GCModeFlag = &cli.StringFlag{
 		Name:     "gcmode",
 		Usage:    `Blockchain garbage collection mode ("full", "archive")`,
@@ -243,6 +293,32 @@ var (
 		Value:    ethconfig.Defaults.TxLookupLimit,
 		Category: flags.EthCategory,
 	}
+	StateHistoryFlag = &cli.Uint64Flag{
+		Name:     "history.state",
+		Usage:    "Number of recent blocks to retain the state of (default = 128 blocks, ignored in archive mode)",
+		Value:    ethconfig.Defaults.StateHistory,
+		Category: flags.EthCategory,
+	}
+	FinalityDepthFlag = &cli.Uint64Flag{
+		Name:     "finality.depth",
+		Usage:    "Number of blocks below the head considered final, deeper reorgs are refused (0 = disabled)",
+		Category: flags.EthCategory,
+	}
+	ForkScheduleFileFlag = &cli.StringFlag{
+		Name:     "forkschedule.file",
+		Usage:    "Signed fork schedule applied on startup and reloaded by admin_reloadChainConfig",
+		Category: flags.EthCategory,
+	}
+	ForkScheduleSignersFlag = &cli.StringFlag{
+		Name:     "forkschedule.signers",
+		Usage:    "Comma separated addresses trusted to sign fork schedules",
+		Category: flags.EthCategory,
+	}
+	OnlinePruneIntervalFlag = &cli.DurationFlag{
+		Name:     "pruning.interval",
+		Usage:    "Time between two online state prunings running in the background (0 = disabled)",
+		Category: flags.EthCategory,
+	}
 	LightKDFFlag = &cli.BoolFlag{
 		Name:     "lightkdf",
 		Usage:    "Reduce key-derivation RAM & CPU usage at some expense of KDF strength",
@@ -321,6 +397,12 @@ var (
 		Usage:    "Enables serving light clients before syncing",
 		Category: flags.LightCategory,
 	}
+	LightSealGuardFlag = &cli.DurationFlag{
+		Name:     "light.sealguard",
+		Usage:    "Time around the broadcast of locally sealed blocks to back off serving light clients for (0 = disabled)",
+		Value:    ethconfig.Defaults.LightSealGuard,
+		Category: flags.LightCategory,
+	}
 
 	// Ethash settings
 	EthashCacheDirFlag = &flags.DirectoryFlag{
@@ -368,6 +450,21 @@ var (
 		Usage:    "Lock memory maps for recent ethash mining DAGs",
 		Category: flags.EthashCategory,
 	}
+	StratumV2Flag = &cli.StringFlag{
+		Name:     "stratum.v2",
+		Usage:    "Listen address of the unencrypted stratum v2 server for remote miners",
+		Category: flags.MinerCategory,
+	}
+	MinerUpstreamsFlag = &cli.StringFlag{
+		Name:     "mine.upstreams",
+		Usage:    "Comma separated RPC URLs of upstream nodes to mirror work from and submit solutions to (ethash only)",
+		Category: flags.MinerCategory,
+	}
+	MinerStaleWindowFlag = &cli.DurationFlag{
+		Name:     "mine.stalewindow",
+		Usage:    "Grace period after a new head accepting solutions to the previous work as potential uncles (ethash only, 0 = accept within the staleness depth)",
+		Category: flags.MinerCategory,
+	}
 
 	// Transaction pool settings
 	TxPoolLocalsFlag = &cli.StringFlag{
@@ -386,6 +483,32 @@ var (
 		Value:    txpool.DefaultConfig.Journal,
 		Category: flags.TxPoolCategory,
 	}
+	TxPoolPersistFlag = &cli.BoolFlag{
+		Name:     "txpool.persist",
+		Usage:    "Persist all pending and queued transactions to survive node restarts",
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolPolicyFlag = &cli.StringFlag{
+		Name:     "txpool.policy",
+		Usage:    "Admission and eviction policy of the pool, a Go plugin (.so) or a JSON rules file",
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolRebroadcastFlag = &cli.Uint64Flag{
+		Name:     "txpool.rebroadcast",
+		Usage:    "Blocks a local transaction may stay unmined before it's rebroadcast to fresh peers (0 = disabled)",
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolRebroadcastBumpAfterFlag = &cli.Uint64Flag{
+		Name:     "txpool.rebroadcast.bumpafter",
+		Usage:    "Rebroadcasts before an unmined local transaction is replaced with a fee bump signed by its unlocked account (0 = never)",
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolRebroadcastPriceBumpFlag = &cli.Uint64Flag{
+		Name:     "txpool.rebroadcast.pricebump",
+		Usage:    "Percentage to bump the fees of rebroadcast replacements by",
+		Value:    rebroadcast.DefaultConfig.PriceBump,
+		Category: flags.TxPoolCategory,
+	}
 	TxPoolRejournalFlag = &cli.DurationFlag{
 		Name:     "txpool.rejournal",
 		Usage:    "Time interval to regenerate the local transaction journal",
@@ -404,6 +527,11 @@ var (
 		Value:    ethconfig.Defaults.TxPool.PriceBump,
 		Category: flags.TxPoolCategory,
 	}
+	TxPoolPriceBumpsFlag = &cli.StringFlag{
+		Name:     "txpool.pricebumps",
+		Usage:    "Comma separated type=percent price bumps overriding --txpool.pricebump per transaction type (e.g. 0=10,2=25)",
+		Category: flags.TxPoolCategory,
+	}
 	TxPoolAccountSlotsFlag = &cli.Uint64Flag{
 		Name:     "txpool.accountslots",
 		Usage:    "Minimum number of executable transaction slots guaranteed per account",
@@ -512,6 +640,21 @@ var (
 		Value:    0,
 		Category: flags.MinerCategory,
 	}
+	MinerCPUsFlag = &cli.StringFlag{
+		Name:     "miner.cpus",
+		Usage:    "CPU list to pin the mining threads to, one thread per CPU (e.g. 0-3,8), overriding the thread count",
+		Category: flags.MinerCategory,
+	}
+	MinerAffinityFlag = &cli.BoolFlag{
+		Name:     "miner.affinity",
+		Usage:    "Pin the mining threads to CPUs spread over the NUMA nodes",
+		Category: flags.MinerCategory,
+	}
+	MinerNUMADAGFlag = &cli.BoolFlag{
+		Name:     "miner.numadag",
+		Usage:    "Keep a copy of the mining DAG in the local memory of every NUMA node running pinned threads (test networks only)",
+		Category: flags.MinerCategory,
+	}
 	MinerNotifyFlag = &cli.StringFlag{
 		Name:     "miner.notify",
 		Usage:    "Comma separated HTTP URL list to notify of new work packages",
@@ -522,6 +665,16 @@ var (
 		Usage:    "Notify with pending block headers instead of work packages",
 		Category: flags.MinerCategory,
 	}
+	MinerNotifyStructuredFlag = &cli.BoolFlag{
+		Name:     "miner.notify.structured",
+		Usage:    "Notify with versioned JSON envelopes of the typed work package and pending block header",
+		Category: flags.MinerCategory,
+	}
+	MinerNotifySecretFlag = &cli.StringFlag{
+		Name:     "miner.notify.secret",
+		Usage:    "Secret to sign the work notifications with (HMAC-SHA256 in the X-Bitnet-Signature header)",
+		Category: flags.MinerCategory,
+	}
 	MinerGasLimitFlag = &cli.Uint64Flag{
 		Name:     "miner.gaslimit",
 		Usage:    "Target gas ceiling for mined blocks",
@@ -541,7 +694,17 @@ var (
 	}
 	MinerExtraDataFlag = &cli.StringFlag{
 		Name:     "miner.extradata",
-		Usage:    "Block extra data set by the miner (default = client version)",
+		Usage:    "Block extra data set by the miner, may contain {{.WorkerID}}, {{.PoolTag}} and {{.Height}} (default = client version)",
+		Category: flags.MinerCategory,
+	}
+	MinerWorkerIDFlag = &cli.StringFlag{
+		Name:     "miner.workerid",
+		Usage:    "Worker identifier resolved in extra data templates",
+		Category: flags.MinerCategory,
+	}
+	MinerPoolTagFlag = &cli.StringFlag{
+		Name:     "miner.pooltag",
+		Usage:    "Pool tag resolved in extra data templates",
 		Category: flags.MinerCategory,
 	}
 	MinerRecommitIntervalFlag = &cli.DurationFlag{
@@ -555,6 +718,22 @@ var (
 		Usage:    "Disable remote sealing verification",
 		Category: flags.MinerCategory,
 	}
+	MinerUnclesFlag = &cli.StringFlag{
+		Name:     "miner.uncles",
+		Usage:    "Uncle inclusion strategy (own-first, own-only, none)",
+		Value:    miner.UnclesOwnFirst,
+		Category: flags.MinerCategory,
+	}
+	MinerMaxUnclesFlag = &cli.IntFlag{
+		Name:     "miner.maxuncles",
+		Usage:    "Maximum number of uncles included per mined block (0 = protocol maximum)",
+		Category: flags.MinerCategory,
+	}
+	MinerUpstreamFlag = &cli.StringFlag{
+		Name:     "miner.upstream",
+		Usage:    "Comma separated list of full node RPC endpoints getwork is proxied to (--syncmode=headers only)",
+		Category: flags.MinerCategory,
+	}
 	MinerNewPayloadTimeout = &cli.DurationFlag{
 		Name:     "miner.newpayload-timeout",
 		Usage:    "Specify the maximum time allowance for creating a new payload",
@@ -581,11 +760,62 @@ var (
 		Value:    "",
 		Category: flags.AccountCategory,
 	}
+	ExternalSignerCertFlag = &cli.StringFlag{
+		Name:      "signer.tlscert",
+		Usage:     "Client certificate to authenticate to an https external signer with",
+		TakesFile: true,
+		Category:  flags.AccountCategory,
+	}
+	ExternalSignerKeyFlag = &cli.StringFlag{
+		Name:      "signer.tlskey",
+		Usage:     "Key of the external signer client certificate",
+		TakesFile: true,
+		Category:  flags.AccountCategory,
+	}
+	ExternalSignerCAFlag = &cli.StringFlag{
+		Name:      "signer.tlsca",
+		Usage:     "Certificate authority to verify an https external signer against (default = system authorities)",
+		TakesFile: true,
+		Category:  flags.AccountCategory,
+	}
+	ExternalSignerTokenFlag = &cli.StringFlag{
+		Name:     "signer.token",
+		Usage:    "Bearer token to authenticate to an https external signer with",
+		Category: flags.AccountCategory,
+	}
 	InsecureUnlockAllowedFlag = &cli.BoolFlag{
 		Name:     "allow-insecure-unlock",
 		Usage:    "Allow insecure account unlocking when account-related RPCs are exposed by http",
 		Category: flags.AccountCategory,
 	}
+	SweeperDepositsFlag = &cli.StringFlag{
+		Name:     "sweeper.deposits",
+		Usage:    "Comma separated deposit addresses to sweep to the cold address on a schedule",
+		Category: flags.AccountCategory,
+	}
+	SweeperColdFlag = &cli.StringFlag{
+		Name:     "sweeper.cold",
+		Usage:    "Cold address the deposit addresses are swept to",
+		Category: flags.AccountCategory,
+	}
+	SweeperThresholdFlag = &flags.BigFlag{
+		Name:     "sweeper.threshold",
+		Usage:    "Balance in wei above which a deposit address is swept",
+		Value:    sweeper.DefaultConfig.Threshold,
+		Category: flags.AccountCategory,
+	}
+	SweeperReserveFlag = &flags.BigFlag{
+		Name:     "sweeper.reserve",
+		Usage:    "Balance in wei left on a swept deposit address, on top of the fees",
+		Value:    sweeper.DefaultConfig.Reserve,
+		Category: flags.AccountCategory,
+	}
+	SweeperIntervalFlag = &cli.DurationFlag{
+		Name:     "sweeper.interval",
+		Usage:    "Time between scheduled sweeps of the deposit addresses",
+		Value:    sweeper.DefaultConfig.Interval,
+		Category: flags.AccountCategory,
+	}
 
 	// EVM settings
 	VMEnableDebugFlag = &cli.BoolFlag{
@@ -607,6 +837,36 @@ var (
 		Value:    ethconfig.Defaults.RPCEVMTimeout,
 		Category: flags.APICategory,
 	}
+	RPCTracerCPUTimeFlag = &cli.DurationFlag{
+		Name:     "rpc.tracer.cputime",
+		Usage:    "Sets a limit on the time a tracer invoked over RPC may spend executing (0=infinite)",
+		Category: flags.APICategory,
+	}
+	RPCTracerMemoryFlag = &cli.Uint64Flag{
+		Name:     "rpc.tracer.memory",
+		Usage:    "Sets a limit in bytes on the state a JS tracer invoked over RPC may retain (0=infinite)",
+		Category: flags.APICategory,
+	}
+	RPCTracerOutputFlag = &cli.Uint64Flag{
+		Name:     "rpc.tracer.output",
+		Usage:    "Sets a limit in bytes on the size of trace results returned over RPC (0=infinite)",
+		Category: flags.APICategory,
+	}
+	RPCTracersFlag = &cli.StringFlag{
+		Name:     "rpc.tracers",
+		Usage:    "Comma separated list of named tracers usable over RPC, custom JS tracers are rejected if set",
+		Category: flags.APICategory,
+	}
+	RPCGetLogsMaxResultsFlag = &cli.IntFlag{
+		Name:     "rpc.getlogs.maxresults",
+		Usage:    "Sets a limit on the number of logs returned by a log query, eth_getLogsPage pages through larger results (0=infinite)",
+		Category: flags.APICategory,
+	}
+	RPCGetLogsMaxBlocksFlag = &cli.Uint64Flag{
+		Name:     "rpc.getlogs.maxblocks",
+		Usage:    "Sets a limit on the number of blocks searched by a log query, eth_getLogsPage pages through larger ranges (0=infinite)",
+		Category: flags.APICategory,
+	}
 	RPCGlobalTxFeeCapFlag = &cli.Float64Flag{
 		Name:     "rpc.txfeecap",
 		Usage:    "Sets a cap on transaction fee (in ether) that can be sent via the RPC APIs (0 = no cap)",
@@ -715,6 +975,36 @@ var (
 		Value:    "",
 		Category: flags.APICategory,
 	}
+	GRPCAddrFlag = &cli.StringFlag{
+		Name:     "grpc.addr",
+		Usage:    "Listening address of the gRPC gateway to the eth and txpool pool mining methods and newHeads, no ethash methods (disabled if empty), subject to --http.api, --rpc.authkeys and --rpc.ratelimit",
+		Category: flags.APICategory,
+	}
+	GRPCTLSCertFlag = &cli.StringFlag{
+		Name:     "grpc.tlscert",
+		Usage:    "TLS certificate file of the gRPC gateway (plaintext if empty)",
+		Category: flags.APICategory,
+	}
+	GRPCTLSKeyFlag = &cli.StringFlag{
+		Name:     "grpc.tlskey",
+		Usage:    "TLS private key file of the gRPC gateway certificate",
+		Category: flags.APICategory,
+	}
+	TraceIndexFlag = &cli.BoolFlag{
+		Name:     "traceindex",
+		Usage:    "Enable indexing the value transfers of internal calls from the chain head onward, served by trace_filter",
+		Category: flags.APICategory,
+	}
+	TransferIndexFlag = &cli.BoolFlag{
+		Name:     "transferindex",
+		Usage:    "Enable indexing the ERC-20 token transfers of the chain, served by the transfer namespace",
+		Category: flags.APICategory,
+	}
+	TransferIndexFromFlag = &cli.Uint64Flag{
+		Name:     "transferindex.from",
+		Usage:    "Block number to start indexing token transfers from when the index is created",
+		Category: flags.APICategory,
+	}
 	GraphQLEnabledFlag = &cli.BoolFlag{
 		Name:     "graphql",
 		Usage:    "Enable GraphQL on the HTTP-RPC server. Note that GraphQL can only be started if an HTTP server is started as well.",
@@ -787,6 +1077,41 @@ var (
 		Usage:    "Enables the (deprecated) personal namespace",
 		Category: flags.APICategory,
 	}
+	RPCAuthKeysFlag = &cli.PathFlag{
+		Name:     "rpc.authkeys",
+		Usage:    "JSON file mapping API keys (sent in the X-Api-Key header) to the namespaces or methods they may call over HTTP and WS",
+		Category: flags.APICategory,
+	}
+	RPCBatchBudgetFlag = &cli.Uint64Flag{
+		Name:     "rpc.batchbudget",
+		Usage:    "Maximum total weight of the calls of a batch request over HTTP and WS (0 = unlimited)",
+		Category: flags.APICategory,
+	}
+	RPCBatchWeightsFlag = &cli.StringFlag{
+		Name:     "rpc.batchweights",
+		Usage:    "Comma separated method=weight costs of calls in batch requests, others weigh 1, e.g. debug_traceBlockByNumber=100,debug_*=20",
+		Category: flags.APICategory,
+	}
+	RPCBatchPartialFlag = &cli.BoolFlag{
+		Name:     "rpc.batchpartial",
+		Usage:    "Execute the calls of over budget batches up to the budget instead of rejecting the batch",
+		Category: flags.APICategory,
+	}
+	RPCTraceFlag = &cli.BoolFlag{
+		Name:     "rpc.trace",
+		Usage:    "Log every RPC call served over HTTP, WS and IPC with its duration, payload size and client",
+		Category: flags.APICategory,
+	}
+	RPCSlowLogFlag = &cli.DurationFlag{
+		Name:     "rpc.slowlog",
+		Usage:    "Log the RPC calls taking longer than the given duration (0 = disabled)",
+		Category: flags.APICategory,
+	}
+	RPCRateLimitFlag = &cli.StringFlag{
+		Name:     "rpc.ratelimit",
+		Usage:    "Comma separated method=rate[:burst] limits of calls per second and client IP over HTTP and WS, e.g. eth_getWork=10:20,ethash_*=5",
+		Category: flags.APICategory,
+	}
 
 	// Network Settings
 	MaxPeersFlag = &cli.IntFlag{
@@ -855,6 +1180,37 @@ var (
 		Value:    30303,
 		Category: flags.NetworkingCategory,
 	}
+	AutoPruneFlag = &cli.BoolFlag{
+		Name:     "p2p.autoprune",
+		Usage:    "Periodically disconnects the peer slowest to propagate blocks, if below the usefulness threshold (see admin.peerStats)",
+		Category: flags.NetworkingCategory,
+	}
+	FastAnnounceAddrFlag = &cli.StringFlag{
+		Name:     "fastannounce.addr",
+		Usage:    "UDP listening address of the sidechannel announcing sealed blocks to pool nodes (disabled if empty)",
+		Category: flags.NetworkingCategory,
+	}
+	FastAnnouncePeersFlag = &cli.StringFlag{
+		Name:     "fastannounce.peers",
+		Usage:    "Comma separated UDP addresses of the pool nodes to exchange fast block announcements with",
+		Category: flags.NetworkingCategory,
+	}
+	FastAnnounceSecretFlag = &cli.StringFlag{
+		Name:     "fastannounce.secret",
+		Usage:    "Shared secret authenticating the fast block announcements between pool nodes",
+		Category: flags.NetworkingCategory,
+	}
+
+	BlockNotifyURLsFlag = &cli.StringFlag{
+		Name:     "notify.blockfound",
+		Usage:    "Comma separated webhook URLs to post signed notifications of sealed and reorged out blocks to",
+		Category: flags.MinerCategory,
+	}
+	BlockNotifySecretFlag = &cli.StringFlag{
+		Name:     "notify.secret",
+		Usage:    "Secret signing the block notifications posted to the webhooks",
+		Category: flags.MinerCategory,
+	}
 
 	// Console
 	JSpathFlag = &flags.DirectoryFlag{
@@ -895,6 +1251,23 @@ var (
 		Value:    ethconfig.Defaults.GPO.IgnorePrice.Int64(),
 		Category: flags.GasPriceCategory,
 	}
+	GpoPoolFlag = &cli.BoolFlag{
+		Name:     "gpo.pool",
+		Usage:    "Take the pending transaction pool and the mining template into account for gas price suggestions",
+		Category: flags.GasPriceCategory,
+	}
+	GpoPoolPercentileFlag = &cli.IntFlag{
+		Name:     "gpo.pool.percentile",
+		Usage:    "Percentile of the tips of the pending transactions fitting the next block to sample",
+		Value:    ethconfig.Defaults.GPO.PoolPercentile,
+		Category: flags.GasPriceCategory,
+	}
+	GpoPoolDepthFlag = &cli.IntFlag{
+		Name:     "gpo.pool.depth",
+		Usage:    "Blocks worth of pending gas at which the pool sample fully replaces the recent blocks one",
+		Value:    ethconfig.Defaults.GPO.PoolDepth,
+		Category: flags.GasPriceCategory,
+	}
 
 	// Metrics flags
 	MetricsEnabledFlag = &cli.BoolFlag{
@@ -990,6 +1363,69 @@ Please note that --` + MetricsHTTPFlag.Name + ` must be set to start the server.
 		Value:    metrics.DefaultConfig.InfluxDBOrganization,
 		Category: flags.MetricsCategory,
 	}
+
+	// Chain security alert settings
+	AlertsEnabledFlag = &cli.BoolFlag{
+		Name:     "alerts",
+		Usage:    "Enable the monitor alerting on deep reorgs and difficulty anomalies",
+		Category: flags.MetricsCategory,
+	}
+	AlertsReorgDepthFlag = &cli.Uint64Flag{
+		Name:     "alerts.reorgdepth",
+		Usage:    "Number of dropped blocks of a reorg raising an alert (0 = disabled)",
+		Value:    alerts.DefaultConfig.ReorgDepth,
+		Category: flags.MetricsCategory,
+	}
+	AlertsDifficultySwingFlag = &cli.Uint64Flag{
+		Name:     "alerts.swing",
+		Usage:    "Cumulative difficulty gap of reorged branches raising an alert, in average blocks (0 = disabled)",
+		Value:    alerts.DefaultConfig.DifficultySwing,
+		Category: flags.MetricsCategory,
+	}
+	AlertsDifficultyJumpFlag = &cli.Uint64Flag{
+		Name:     "alerts.difficulty",
+		Usage:    "Percentage a block difficulty may deviate from the recent average before raising an alert (0 = disabled)",
+		Value:    alerts.DefaultConfig.DifficultyJump,
+		Category: flags.MetricsCategory,
+	}
+	AlertsWebhookFlag = &cli.StringFlag{
+		Name:     "alerts.webhook",
+		Usage:    "URL the security alerts are posted to as JSON",
+		Category: flags.MetricsCategory,
+	}
+	HealthEnabledFlag = &cli.BoolFlag{
+		Name:     "health",
+		Usage:    "Enable the /health and /ready endpoints on the HTTP-RPC server",
+		Category: flags.MetricsCategory,
+	}
+	HealthMinPeersFlag = &cli.IntFlag{
+		Name:     "health.minpeers",
+		Usage:    "Number of peers required for the node to be healthy",
+		Value:    health.DefaultConfig.MinPeers,
+		Category: flags.MetricsCategory,
+	}
+	HealthMaxBlockAgeFlag = &cli.DurationFlag{
+		Name:     "health.maxblockage",
+		Usage:    "Age of the head block beyond which the node is unhealthy (0 = disabled)",
+		Value:    health.DefaultConfig.MaxBlockAge,
+		Category: flags.MetricsCategory,
+	}
+	HealthMaxBlockLagFlag = &cli.Uint64Flag{
+		Name:     "health.maxblocklag",
+		Usage:    "Number of blocks the head may lag behind the highest known one for the node to be ready",
+		Value:    health.DefaultConfig.MaxBlockLag,
+		Category: flags.MetricsCategory,
+	}
+	HealthDAGFlag = &cli.BoolFlag{
+		Name:     "health.dag",
+		Usage:    "Require the mining dataset of the next block to be generated for the node to be ready",
+		Category: flags.MetricsCategory,
+	}
+	HealthSealerFlag = &cli.BoolFlag{
+		Name:     "health.sealer",
+		Usage:    "Require the node to be sealing for it to be ready",
+		Category: flags.MetricsCategory,
+	}
 )
 
 var (
@@ -1008,6 +1444,8 @@ var (
 		AncientFlag,
 		RemoteDBFlag,
 		HttpHeaderFlag,
+		DBRemoteFlag,
+		DBAncientCompressionFlag,
 	}
 )
 
@@ -1205,6 +1643,27 @@ func setHTTP(ctx *cli.Context, cfg *node.Config) {
 	if ctx.IsSet(AllowUnprotectedTxs.Name) {
 		cfg.AllowUnprotectedTxs = ctx.Bool(AllowUnprotectedTxs.Name)
 	}
+	if ctx.IsSet(RPCRateLimitFlag.Name) {
+		cfg.RPCRateLimits = ctx.String(RPCRateLimitFlag.Name)
+	}
+	if ctx.IsSet(RPCAuthKeysFlag.Name) {
+		cfg.RPCAuthKeys = ctx.Path(RPCAuthKeysFlag.Name)
+	}
+	if ctx.IsSet(RPCBatchBudgetFlag.Name) {
+		cfg.RPCBatchBudget = ctx.Uint64(RPCBatchBudgetFlag.Name)
+	}
+	if ctx.IsSet(RPCBatchWeightsFlag.Name) {
+		cfg.RPCBatchWeights = ctx.String(RPCBatchWeightsFlag.Name)
+	}
+	if ctx.IsSet(RPCBatchPartialFlag.Name) {
+		cfg.RPCBatchPartial = ctx.Bool(RPCBatchPartialFlag.Name)
+	}
+	if ctx.IsSet(RPCTraceFlag.Name) {
+		cfg.RPCTraceCalls = ctx.Bool(RPCTraceFlag.Name)
+	}
+	if ctx.IsSet(RPCSlowLogFlag.Name) {
+		cfg.RPCSlowCallThreshold = ctx.Duration(RPCSlowLogFlag.Name)
+	}
 }
 
 // setGraphQL creates the GraphQL listener interface string from the set
@@ -1289,6 +1748,9 @@ func setLes(ctx *cli.Context, cfg *ethconfig.Config) {
 	if ctx.IsSet(LightNoSyncServeFlag.Name) {
 		cfg.LightNoSyncServe = ctx.Bool(LightNoSyncServeFlag.Name)
 	}
+	if ctx.IsSet(LightSealGuardFlag.Name) {
+		cfg.LightSealGuard = ctx.Duration(LightSealGuardFlag.Name)
+	}
 }
 
 // MakeDatabaseHandles raises out the number of allowed file handles per process
@@ -1385,7 +1847,7 @@ func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config) {
 	setBootstrapNodes(ctx, cfg)
 	setBootstrapNodesV5(ctx, cfg)
 
-	lightClient := ctx.String(SyncModeFlag.Name) == "light"
+	lightClient := IsLightClient(ctx)
 	lightServer := (ctx.Int(LightServeFlag.Name) != 0)
 
 	lightPeers := ctx.Int(LightMaxPeersFlag.Name)
@@ -1473,6 +1935,18 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	if ctx.IsSet(ExternalSignerFlag.Name) {
 		cfg.ExternalSigner = ctx.String(ExternalSignerFlag.Name)
 	}
+	if ctx.IsSet(ExternalSignerCertFlag.Name) {
+		cfg.ExternalSignerCert = ctx.String(ExternalSignerCertFlag.Name)
+	}
+	if ctx.IsSet(ExternalSignerKeyFlag.Name) {
+		cfg.ExternalSignerKey = ctx.String(ExternalSignerKeyFlag.Name)
+	}
+	if ctx.IsSet(ExternalSignerCAFlag.Name) {
+		cfg.ExternalSignerCA = ctx.String(ExternalSignerCAFlag.Name)
+	}
+	if ctx.IsSet(ExternalSignerTokenFlag.Name) {
+		cfg.ExternalSignerToken = ctx.String(ExternalSignerTokenFlag.Name)
+	}
 
 	if ctx.IsSet(KeyStoreDirFlag.Name) {
 		cfg.KeyStoreDir = ctx.String(KeyStoreDirFlag.Name)
@@ -1494,12 +1968,38 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	}
 	if ctx.IsSet(DBEngineFlag.Name) {
 		dbEngine := ctx.String(DBEngineFlag.Name)
-		if dbEngine != "leveldb" && dbEngine != "pebble" {
-			Fatalf("Invalid choice for db.engine '%s', allowed 'leveldb' or 'pebble'", dbEngine)
+		if dbEngine != "leveldb" && dbEngine != "pebble" && dbEngine != "remote" {
+			Fatalf("Invalid choice for db.engine '%s', allowed 'leveldb', 'pebble' or 'remote'", dbEngine)
 		}
 		log.Info(fmt.Sprintf("Using %s as db engine", dbEngine))
 		cfg.DBEngine = dbEngine
 	}
+	if ctx.IsSet(DBRemoteFlag.Name) {
+		cfg.DBRemote = ctx.String(DBRemoteFlag.Name)
+	}
+	if ctx.IsSet(DBAncientCompressionFlag.Name) {
+		compression := ctx.String(DBAncientCompressionFlag.Name)
+		if _, err := rawdb.ParseFreezerCompression(compression); err != nil {
+			Fatalf("Invalid choice for %s: %v", DBAncientCompressionFlag.Name, err)
+		}
+		cfg.AncientCompression = compression
+	}
+	if cfg.DBEngine == "remote" && cfg.DBRemote == "" {
+		Fatalf("Option --%s is required for the 'remote' db.engine", DBRemoteFlag.Name)
+	}
+	if ctx.Bool(DataDirReadOnlyFlag.Name) {
+		cfg.DataDirReadOnly = true
+
+		// Networking is left to the node owning the datadir, as is its default
+		// IPC endpoint
+		cfg.P2P.MaxPeers = 0
+		cfg.P2P.ListenAddr = ""
+		cfg.P2P.NoDiscovery = true
+		cfg.P2P.DiscoveryV5 = false
+		if !ctx.IsSet(IPCPathFlag.Name) {
+			cfg.IPCPath = ""
+		}
+	}
 }
 
 func setSmartCard(ctx *cli.Context, cfg *node.Config) {
@@ -1555,6 +2055,15 @@ func setGPO(ctx *cli.Context, cfg *gasprice.Config, light bool) {
 	if ctx.IsSet(GpoIgnoreGasPriceFlag.Name) {
 		cfg.IgnorePrice = big.NewInt(ctx.Int64(GpoIgnoreGasPriceFlag.Name))
 	}
+	if ctx.IsSet(GpoPoolFlag.Name) {
+		cfg.PoolAware = ctx.Bool(GpoPoolFlag.Name)
+	}
+	if ctx.IsSet(GpoPoolPercentileFlag.Name) {
+		cfg.PoolPercentile = ctx.Int(GpoPoolPercentileFlag.Name)
+	}
+	if ctx.IsSet(GpoPoolDepthFlag.Name) {
+		cfg.PoolDepth = ctx.Int(GpoPoolDepthFlag.Name)
+	}
 }
 
 func setTxPool(ctx *cli.Context, cfg *txpool.Config) {
@@ -1574,6 +2083,12 @@ func setTxPool(ctx *cli.Context, cfg *txpool.Config) {
 	if ctx.IsSet(TxPoolJournalFlag.Name) {
 		cfg.Journal = ctx.String(TxPoolJournalFlag.Name)
 	}
+	if ctx.IsSet(TxPoolPersistFlag.Name) {
+		cfg.Persist = ctx.Bool(TxPoolPersistFlag.Name)
+	}
+	if ctx.IsSet(TxPoolPolicyFlag.Name) {
+		cfg.Policy = ctx.String(TxPoolPolicyFlag.Name)
+	}
 	if ctx.IsSet(TxPoolRejournalFlag.Name) {
 		cfg.Rejournal = ctx.Duration(TxPoolRejournalFlag.Name)
 	}
@@ -1583,6 +2098,13 @@ func setTxPool(ctx *cli.Context, cfg *txpool.Config) {
 	if ctx.IsSet(TxPoolPriceBumpFlag.Name) {
 		cfg.PriceBump = ctx.Uint64(TxPoolPriceBumpFlag.Name)
 	}
+	if ctx.IsSet(TxPoolPriceBumpsFlag.Name) {
+		bumps, err := txpool.ParsePriceBumps(ctx.String(TxPoolPriceBumpsFlag.Name))
+		if err != nil {
+			Fatalf("Invalid --%s: %v", TxPoolPriceBumpsFlag.Name, err)
+		}
+		cfg.PriceBumps = bumps
+	}
 	if ctx.IsSet(TxPoolAccountSlotsFlag.Name) {
 		cfg.AccountSlots = ctx.Uint64(TxPoolAccountSlotsFlag.Name)
 	}
@@ -1625,6 +2147,28 @@ func setEthash(ctx *cli.Context, cfg *ethconfig.Config) {
 	if ctx.IsSet(EthashDatasetsLockMmapFlag.Name) {
 		cfg.Ethash.DatasetsLockMmap = ctx.Bool(EthashDatasetsLockMmapFlag.Name)
 	}
+	if ctx.IsSet(MinerCPUsFlag.Name) {
+		cpus, err := ethash.ParseCPUList(ctx.String(MinerCPUsFlag.Name))
+		if err != nil {
+			Fatalf("Invalid --%s: %v", MinerCPUsFlag.Name, err)
+		}
+		cfg.Ethash.MinerCPUs = cpus
+	}
+	if ctx.IsSet(MinerAffinityFlag.Name) {
+		cfg.Ethash.ThreadAffinity = ctx.Bool(MinerAffinityFlag.Name)
+	}
+	if ctx.IsSet(MinerNUMADAGFlag.Name) {
+		cfg.Ethash.NUMALocalDAG = ctx.Bool(MinerNUMADAGFlag.Name)
+	}
+	if ctx.IsSet(StratumV2Flag.Name) {
+		cfg.Ethash.StratumV2Addr = ctx.String(StratumV2Flag.Name)
+	}
+	if ctx.IsSet(MinerUpstreamsFlag.Name) {
+		cfg.Ethash.Upstreams = strings.Split(ctx.String(MinerUpstreamsFlag.Name), ",")
+	}
+	if ctx.IsSet(MinerStaleWindowFlag.Name) {
+		cfg.Ethash.StaleWindow = ctx.Duration(MinerStaleWindowFlag.Name)
+	}
 }
 
 func setMiner(ctx *cli.Context, cfg *miner.Config) {
@@ -1632,9 +2176,19 @@ func setMiner(ctx *cli.Context, cfg *miner.Config) {
 		cfg.Notify = strings.Split(ctx.String(MinerNotifyFlag.Name), ",")
 	}
 	cfg.NotifyFull = ctx.Bool(MinerNotifyFullFlag.Name)
+	cfg.NotifyStructured = ctx.Bool(MinerNotifyStructuredFlag.Name)
+	if ctx.IsSet(MinerNotifySecretFlag.Name) {
+		cfg.NotifySecret = ctx.String(MinerNotifySecretFlag.Name)
+	}
 	if ctx.IsSet(MinerExtraDataFlag.Name) {
 		cfg.ExtraData = []byte(ctx.String(MinerExtraDataFlag.Name))
 	}
+	if ctx.IsSet(MinerWorkerIDFlag.Name) {
+		cfg.WorkerID = ctx.String(MinerWorkerIDFlag.Name)
+	}
+	if ctx.IsSet(MinerPoolTagFlag.Name) {
+		cfg.PoolTag = ctx.String(MinerPoolTagFlag.Name)
+	}
 	if ctx.IsSet(MinerGasLimitFlag.Name) {
 		cfg.GasCeil = ctx.Uint64(MinerGasLimitFlag.Name)
 	}
@@ -1650,6 +2204,15 @@ func setMiner(ctx *cli.Context, cfg *miner.Config) {
 	if ctx.IsSet(MinerNewPayloadTimeout.Name) {
 		cfg.NewPayloadTimeout = ctx.Duration(MinerNewPayloadTimeout.Name)
 	}
+	if ctx.IsSet(MinerUnclesFlag.Name) {
+		cfg.UnclePolicy.Strategy = ctx.String(MinerUnclesFlag.Name)
+	}
+	if ctx.IsSet(MinerMaxUnclesFlag.Name) {
+		cfg.UnclePolicy.MaxUncles = ctx.Int(MinerMaxUnclesFlag.Name)
+	}
+	if ctx.IsSet(MinerUpstreamFlag.Name) {
+		cfg.GetWorkUpstreams = SplitAndTrim(ctx.String(MinerUpstreamFlag.Name))
+	}
 }
 
 func setRequiredBlocks(ctx *cli.Context, cfg *ethconfig.Config) {
@@ -1680,6 +2243,34 @@ func setRequiredBlocks(ctx *cli.Context, cfg *ethconfig.Config) {
 	}
 }
 
+// setSyncCheckpoint configures the operator trusted sync checkpoint.
+func setSyncCheckpoint(ctx *cli.Context, cfg *ethconfig.Config) {
+	if !ctx.IsSet(SyncCheckpointFlag.Name) {
+		return
+	}
+	checkpoint := ctx.String(SyncCheckpointFlag.Name)
+	parts := strings.Split(checkpoint, ":")
+	if len(parts) != 2 {
+		Fatalf("Invalid sync checkpoint: %s", checkpoint)
+	}
+	var hash common.Hash
+	if err := hash.UnmarshalText([]byte(parts[0])); err != nil {
+		Fatalf("Invalid sync checkpoint hash %s: %v", parts[0], err)
+	}
+	number, err := strconv.ParseUint(parts[1], 0, 64)
+	if err != nil {
+		Fatalf("Invalid sync checkpoint number %s: %v", parts[1], err)
+	}
+	cfg.SyncCheckpoint = &downloader.Checkpoint{Number: number, Hash: hash}
+}
+
+// IsLightClient returns whether the configured sync mode runs a light client,
+// maintaining only the header chain.
+func IsLightClient(ctx *cli.Context) bool {
+	mode := ctx.String(SyncModeFlag.Name)
+	return mode == "light" || mode == "headers"
+}
+
 // CheckExclusive verifies that only a single instance of the provided flags was
 // set by the user. Each flag might optionally be followed by a string type to
 // specialize it further.
@@ -1721,11 +2312,36 @@ func CheckExclusive(ctx *cli.Context, args ...interface{}) {
 	}
 }
 
+// setFastAnnounce configures the sidechannel announcing sealed blocks to the
+// other nodes of the pool.
+func setFastAnnounce(ctx *cli.Context, cfg *ethconfig.Config) {
+	if ctx.IsSet(FastAnnounceAddrFlag.Name) {
+		cfg.FastAnnounce.Addr = ctx.String(FastAnnounceAddrFlag.Name)
+	}
+	if ctx.IsSet(FastAnnouncePeersFlag.Name) {
+		cfg.FastAnnounce.Peers = SplitAndTrim(ctx.String(FastAnnouncePeersFlag.Name))
+	}
+	if ctx.IsSet(FastAnnounceSecretFlag.Name) {
+		cfg.FastAnnounce.Secret = ctx.String(FastAnnounceSecretFlag.Name)
+	}
+}
+
+// setBlockNotify configures the webhooks notified of the sealed blocks.
+func setBlockNotify(ctx *cli.Context, cfg *ethconfig.Config) {
+	if ctx.IsSet(BlockNotifyURLsFlag.Name) {
+		cfg.BlockNotify.URLs = SplitAndTrim(ctx.String(BlockNotifyURLsFlag.Name))
+	}
+	if ctx.IsSet(BlockNotifySecretFlag.Name) {
+		cfg.BlockNotify.Secret = ctx.String(BlockNotifySecretFlag.Name)
+	}
+}
+
 // SetEthConfig applies eth-related command line flags to the config.
 func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	// Avoid conflicting network flags
 	CheckExclusive(ctx, MainnetFlag, DeveloperFlag, RinkebyFlag, GoerliFlag, SepoliaFlag)
 	CheckExclusive(ctx, LightServeFlag, SyncModeFlag, "light")
+	CheckExclusive(ctx, LightServeFlag, SyncModeFlag, "headers")
 	CheckExclusive(ctx, DeveloperFlag, ExternalSignerFlag) // Can't use both ephemeral unlocked and external signer
 	if ctx.String(GCModeFlag.Name) == "archive" && ctx.Uint64(TxLookupLimitFlag.Name) != 0 {
 		ctx.Set(TxLookupLimitFlag.Name, "0")
@@ -1735,11 +2351,14 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 		log.Warn("LES server cannot serve old transaction status and cannot connect below les/4 protocol version if transaction lookup index is limited")
 	}
 	setEtherbase(ctx, cfg)
-	setGPO(ctx, &cfg.GPO, ctx.String(SyncModeFlag.Name) == "light")
+	setGPO(ctx, &cfg.GPO, IsLightClient(ctx))
 	setTxPool(ctx, &cfg.TxPool)
 	setEthash(ctx, cfg)
 	setMiner(ctx, &cfg.Miner)
 	setRequiredBlocks(ctx, cfg)
+	setSyncCheckpoint(ctx, cfg)
+	setFastAnnounce(ctx, cfg)
+	setBlockNotify(ctx, cfg)
 	setLes(ctx, cfg)
 
 	// Cap the cache allowance and tune the garbage collector
@@ -1765,6 +2384,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(SyncModeFlag.Name) {
 		cfg.SyncMode = *flags.GlobalTextMarshaler(ctx, SyncModeFlag.Name).(*downloader.SyncMode)
 	}
+	if cfg.SyncMode == downloader.HeadersSync && len(cfg.Miner.GetWorkUpstreams) == 0 {
+		Fatalf("Header sync mode requires getwork upstreams (--%s)", MinerUpstreamFlag.Name)
+	}
 	if ctx.IsSet(NetworkIdFlag.Name) {
 		cfg.NetworkId = ctx.Uint64(NetworkIdFlag.Name)
 	}
@@ -1782,6 +2404,36 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(GCModeFlag.Name) {
 		cfg.NoPruning = ctx.String(GCModeFlag.Name) == "archive"
 	}
+	if ctx.IsSet(StateHistoryFlag.Name) {
+		cfg.StateHistory = ctx.Uint64(StateHistoryFlag.Name)
+		if cfg.NoPruning {
+			log.Warn("State history is ignored for archive node", "blocks", cfg.StateHistory)
+		}
+	}
+	if ctx.IsSet(FinalityDepthFlag.Name) {
+		cfg.FinalityDepth = ctx.Uint64(FinalityDepthFlag.Name)
+	}
+	if ctx.IsSet(ForkScheduleFileFlag.Name) {
+		cfg.ForkScheduleFile = ctx.String(ForkScheduleFileFlag.Name)
+	}
+	if ctx.IsSet(ForkScheduleSignersFlag.Name) {
+		for _, signer := range strings.Split(ctx.String(ForkScheduleSignersFlag.Name), ",") {
+			if trimmed := strings.TrimSpace(signer); !common.IsHexAddress(trimmed) {
+				Fatalf("Invalid signer in --%s: %s", ForkScheduleSignersFlag.Name, trimmed)
+			} else {
+				cfg.ForkScheduleSigners = append(cfg.ForkScheduleSigners, common.HexToAddress(trimmed))
+			}
+		}
+	}
+	if ctx.IsSet(OnlinePruneIntervalFlag.Name) {
+		cfg.OnlinePruneInterval = ctx.Duration(OnlinePruneIntervalFlag.Name)
+		if cfg.NoPruning {
+			log.Warn("Online pruning is ignored for archive node", "interval", cfg.OnlinePruneInterval)
+		}
+	}
+	if ctx.IsSet(BloomFilterSizeFlag.Name) {
+		cfg.OnlinePruneBloomSize = ctx.Uint64(BloomFilterSizeFlag.Name)
+	}
 	if ctx.IsSet(CacheNoPrefetchFlag.Name) {
 		cfg.NoPrefetch = ctx.Bool(CacheNoPrefetchFlag.Name)
 	}
@@ -1794,6 +2446,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(TxLookupLimitFlag.Name) {
 		cfg.TxLookupLimit = ctx.Uint64(TxLookupLimitFlag.Name)
 	}
+	if ctx.IsSet(AutoPruneFlag.Name) {
+		cfg.AutoPrune = ctx.Bool(AutoPruneFlag.Name)
+	}
 	if ctx.IsSet(CacheFlag.Name) || ctx.IsSet(CacheTrieFlag.Name) {
 		cfg.TrieCleanCache = ctx.Int(CacheFlag.Name) * ctx.Int(CacheTrieFlag.Name) / 100
 	}
@@ -1821,6 +2476,33 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 			cfg.SnapshotCache = 0 // Disabled
 		}
 	}
+	if ctx.IsSet(DBShareFlag.Name) {
+		cfg.ShareDatabase = ctx.Bool(DBShareFlag.Name)
+	}
+	if stack.Config().DBEngine == "remote" {
+		CheckExclusive(ctx, DBRemoteFlag, MiningEnabledFlag)
+
+		// The remote node maintains the shared database, its new heads are
+		// followed over the RPC endpoint sharing it
+		cfg.TrieCleanCache += cfg.SnapshotCache
+		cfg.SnapshotCache = 0
+		cfg.OnlinePruneInterval = 0
+		cfg.ReplicaOf = stack.Config().DBRemote
+	}
+	if stack.Config().DataDirReadOnly {
+		CheckExclusive(ctx, DataDirReadOnlyFlag, MiningEnabledFlag)
+
+		// The snapshot and the background maintenance of the database are left
+		// to the node owning it, whose new heads are followed over IPC
+		cfg.TrieCleanCache += cfg.SnapshotCache
+		cfg.SnapshotCache = 0
+		cfg.OnlinePruneInterval = 0
+		cfg.ReplicaOf = ctx.String(DataDirReadOnlyIPCFlag.Name)
+		if cfg.ReplicaOf == "" {
+			owner := node.Config{DataDir: stack.DataDir(), IPCPath: stack.Config().Name}
+			cfg.ReplicaOf = owner.IPCEndpoint()
+		}
+	}
 	if ctx.IsSet(DocRootFlag.Name) {
 		cfg.DocRoot = ctx.String(DocRootFlag.Name)
 	}
@@ -1840,6 +2522,24 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(RPCGlobalEVMTimeoutFlag.Name) {
 		cfg.RPCEVMTimeout = ctx.Duration(RPCGlobalEVMTimeoutFlag.Name)
 	}
+	if ctx.IsSet(RPCTracerCPUTimeFlag.Name) {
+		cfg.RPCTracerCPUTime = ctx.Duration(RPCTracerCPUTimeFlag.Name)
+	}
+	if ctx.IsSet(RPCTracerMemoryFlag.Name) {
+		cfg.RPCTracerMemory = ctx.Uint64(RPCTracerMemoryFlag.Name)
+	}
+	if ctx.IsSet(RPCTracerOutputFlag.Name) {
+		cfg.RPCTracerOutput = ctx.Uint64(RPCTracerOutputFlag.Name)
+	}
+	if ctx.IsSet(RPCTracersFlag.Name) {
+		cfg.RPCTracers = SplitAndTrim(ctx.String(RPCTracersFlag.Name))
+	}
+	if ctx.IsSet(RPCGetLogsMaxResultsFlag.Name) {
+		cfg.FilterMaxResults = ctx.Int(RPCGetLogsMaxResultsFlag.Name)
+	}
+	if ctx.IsSet(RPCGetLogsMaxBlocksFlag.Name) {
+		cfg.FilterMaxBlocks = ctx.Uint64(RPCGetLogsMaxBlocksFlag.Name)
+	}
 	if ctx.IsSet(RPCGlobalTxFeeCapFlag.Name) {
 		cfg.RPCTxFeeCap = ctx.Float64(RPCGlobalTxFeeCapFlag.Name)
 	}
@@ -1939,7 +2639,20 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 		log.Info("Using developer account", "address", developer.Address)
 
 		// Create a new developer genesis block or reuse existing one
-		cfg.Genesis = core.DeveloperGenesisBlock(uint64(ctx.Int(DeveloperPeriodFlag.Name)), ctx.Uint64(DeveloperGasLimitFlag.Name), developer.Address)
+		if ctx.Bool(DeveloperBitnetFlag.Name) {
+			cfg.Genesis = core.DeveloperBitnetGenesisBlock(uint64(ctx.Int(DeveloperPeriodFlag.Name)), ctx.Uint64(DeveloperGasLimitFlag.Name), developer.Address)
+		} else {
+			cfg.Genesis = core.DeveloperGenesisBlock(uint64(ctx.Int(DeveloperPeriodFlag.Name)), ctx.Uint64(DeveloperGasLimitFlag.Name), developer.Address)
+		}
+		if ctx.IsSet(DeveloperAllocFlag.Name) {
+			alloc, err := parseDeveloperAlloc(ctx.String(DeveloperAllocFlag.Name))
+			if err != nil {
+				Fatalf("Option %q: %v", DeveloperAllocFlag.Name, err)
+			}
+			for addr, account := range alloc {
+				cfg.Genesis.Alloc[addr] = account
+			}
+		}
 		if ctx.IsSet(DataDirFlag.Name) {
 			// If datadir doesn't exist we need to open db in write-mode
 			// so leveldb can create files.
@@ -1972,7 +2685,7 @@ func SetDNSDiscoveryDefaults(cfg *ethconfig.Config, genesis common.Hash) {
 		return // already set through flags/config
 	}
 	protocol := "all"
-	if cfg.SyncMode == downloader.LightSync {
+	if cfg.SyncMode.IsLight() {
 		protocol = "les"
 	}
 	if url := params.KnownDNSNetwork(genesis, protocol); url != "" {
@@ -1985,7 +2698,7 @@ func SetDNSDiscoveryDefaults(cfg *ethconfig.Config, genesis common.Hash) {
 // The second return value is the full node instance, which may be nil if the
 // node is running as a light client.
 func RegisterEthService(stack *node.Node, cfg *ethconfig.Config) (ethapi.Backend, *eth.Ethereum) {
-	if cfg.SyncMode == downloader.LightSync {
+	if cfg.SyncMode.IsLight() {
 		backend, err := les.New(stack, cfg)
 		if err != nil {
 			Fatalf("Failed to register the Ethereum service: %v", err)
@@ -1994,6 +2707,15 @@ func RegisterEthService(stack *node.Node, cfg *ethconfig.Config) (ethapi.Backend
 		if err := lescatalyst.Register(stack, backend); err != nil {
 			Fatalf("Failed to register the Engine API service: %v", err)
 		}
+		if cfg.SyncMode == downloader.HeadersSync {
+			// Registered after the light client, superseding the getwork API of its engine
+			proxy, err := workproxy.New(backend.BlockChain(), cfg.Miner.GetWorkUpstreams)
+			if err != nil {
+				Fatalf("Failed to create the getwork proxy: %v", err)
+			}
+			stack.RegisterAPIs(proxy.APIs())
+			stack.RegisterLifecycle(proxy)
+		}
 		return backend.ApiBackend, nil
 	}
 	backend, err := eth.New(stack, cfg)
@@ -2028,11 +2750,198 @@ func RegisterGraphQLService(stack *node.Node, backend ethapi.Backend, filterSyst
 	}
 }
 
+// RegisterGRPCService adds the gRPC gateway to the node.
+func RegisterGRPCService(stack *node.Node, config grpcgw.Config) {
+	if _, err := grpcgw.New(stack, config); err != nil {
+		Fatalf("Failed to register the gRPC gateway: %v", err)
+	}
+}
+
+// RegisterTraceIndexService adds the internal call trace indexer to the node.
+func RegisterTraceIndexService(stack *node.Node, eth *eth.Ethereum, cfg *ethconfig.Config) {
+	if eth == nil {
+		Fatalf("The trace indexer requires a full node")
+	}
+	db, err := stack.OpenDatabase("traceindex", cfg.DatabaseCache/16, cfg.DatabaseHandles/16, "eth/db/traceindex/", false)
+	if err != nil {
+		Fatalf("Failed to open the trace index database: %v", err)
+	}
+	indexer := traceindex.New(eth.APIBackend, db)
+	stack.RegisterAPIs(indexer.APIs())
+	stack.RegisterLifecycle(indexer)
+}
+
+// RegisterTransferIndexService adds the token transfer indexer to the node.
+func RegisterTransferIndexService(stack *node.Node, eth *eth.Ethereum, cfg *ethconfig.Config, start uint64) {
+	if eth == nil {
+		Fatalf("The transfer indexer requires a full node")
+	}
+	db, err := stack.OpenDatabase("transferindex", cfg.DatabaseCache/16, cfg.DatabaseHandles/16, "eth/db/transferindex/", false)
+	if err != nil {
+		Fatalf("Failed to open the transfer index database: %v", err)
+	}
+	indexer := transferindex.New(eth.APIBackend, db, start)
+	stack.RegisterAPIs(indexer.APIs())
+	stack.RegisterLifecycle(indexer)
+}
+
+// RegisterRebroadcastService adds the rebroadcaster of unmined local
+// transactions to the node.
+func RegisterRebroadcastService(stack *node.Node, eth *eth.Ethereum, cfg rebroadcast.Config) {
+	if eth == nil {
+		Fatalf("The transaction rebroadcaster requires a full node")
+	}
+	stack.RegisterLifecycle(rebroadcast.New(eth.APIBackend, cfg))
+}
+
+// MakeRebroadcastConfig creates the rebroadcaster settings from the command line
+// flags.
+func MakeRebroadcastConfig(ctx *cli.Context) rebroadcast.Config {
+	return rebroadcast.Config{
+		Blocks:    ctx.Uint64(TxPoolRebroadcastFlag.Name),
+		BumpAfter: ctx.Uint64(TxPoolRebroadcastBumpAfterFlag.Name),
+		PriceBump: ctx.Uint64(TxPoolRebroadcastPriceBumpFlag.Name),
+	}
+}
+
+// RegisterDevService adds the developer chain API, paying from the developer
+// account, to the node.
+func RegisterDevService(stack *node.Node, eth *eth.Ethereum, faucet common.Address) {
+	if eth == nil {
+		Fatalf("The developer API requires a full node")
+	}
+	stack.RegisterAPIs(devnet.New(eth.APIBackend, faucet).APIs())
+}
+
+// parseDeveloperAlloc parses the accounts to prefund in developer mode, given
+// as a comma separated list of address or address=wei entries.
+func parseDeveloperAlloc(spec string) (core.GenesisAlloc, error) {
+	var (
+		alloc   = make(core.GenesisAlloc)
+		balance = new(big.Int).Mul(big.NewInt(10000), big.NewInt(params.Ether))
+	)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		account := core.GenesisAccount{Balance: balance}
+		addr, amount, funded := strings.Cut(entry, "=")
+		if !common.IsHexAddress(addr) {
+			return nil, fmt.Errorf("invalid address %q", addr)
+		}
+		if funded {
+			value, ok := new(big.Int).SetString(amount, 0)
+			if !ok || value.Sign() < 0 {
+				return nil, fmt.Errorf("invalid balance %q", amount)
+			}
+			account.Balance = value
+		}
+		alloc[common.HexToAddress(addr)] = account
+	}
+	return alloc, nil
+}
+
+// RegisterAlertService adds the monitor raising chain security alerts to the
+// node.
+func RegisterAlertService(stack *node.Node, eth *eth.Ethereum, cfg alerts.Config) {
+	if eth == nil {
+		Fatalf("The security alert monitor requires a full node")
+	}
+	monitor := alerts.New(eth.BlockChain(), cfg)
+	stack.RegisterAPIs(monitor.APIs())
+	stack.RegisterLifecycle(monitor)
+}
+
+// MakeAlertConfig creates the security alert monitor settings from the command
+// line flags.
+func MakeAlertConfig(ctx *cli.Context) alerts.Config {
+	return alerts.Config{
+		ReorgDepth:      ctx.Uint64(AlertsReorgDepthFlag.Name),
+		DifficultySwing: ctx.Uint64(AlertsDifficultySwingFlag.Name),
+		DifficultyJump:  ctx.Uint64(AlertsDifficultyJumpFlag.Name),
+		Window:          alerts.DefaultConfig.Window,
+		Webhook:         ctx.String(AlertsWebhookFlag.Name),
+	}
+}
+
+// RegisterHealthService adds the health and readiness checks to the node, served
+// on the HTTP-RPC server and over the admin RPC API.
+func RegisterHealthService(stack *node.Node, eth *eth.Ethereum, cfg health.Config) {
+	if eth == nil {
+		Fatalf("The health checks require a full node")
+	}
+	checker := health.New(health.NewBackend(eth, stack.Server()), cfg)
+	stack.RegisterAPIs(checker.APIs())
+	stack.RegisterHandler("Health check", "/health", checker.HealthHandler())
+	stack.RegisterHandler("Readiness check", "/ready", checker.ReadyHandler())
+}
+
+// MakeHealthConfig creates the health check thresholds from the command line
+// flags.
+func MakeHealthConfig(ctx *cli.Context) health.Config {
+	return health.Config{
+		MinPeers:      ctx.Int(HealthMinPeersFlag.Name),
+		MaxBlockAge:   ctx.Duration(HealthMaxBlockAgeFlag.Name),
+		MaxBlockLag:   ctx.Uint64(HealthMaxBlockLagFlag.Name),
+		RequireDAG:    ctx.Bool(HealthDAGFlag.Name),
+		RequireSealer: ctx.Bool(HealthSealerFlag.Name),
+	}
+}
+
+// RegisterSweeperService adds the sweeper of the deposit addresses to the node.
+func RegisterSweeperService(stack *node.Node, eth *eth.Ethereum, cfg sweeper.Config) {
+	if eth == nil {
+		Fatalf("The deposit sweeper requires a full node")
+	}
+	if cfg.Journal != "" {
+		cfg.Journal = stack.ResolvePath(cfg.Journal)
+	}
+	s, err := sweeper.New(eth.APIBackend, cfg)
+	if err != nil {
+		Fatalf("Failed to create the deposit sweeper: %v", err)
+	}
+	stack.RegisterAPIs(s.APIs())
+	stack.RegisterLifecycle(s)
+}
+
+// MakeSweeperConfig creates the deposit sweeper settings from the command line
+// flags.
+func MakeSweeperConfig(ctx *cli.Context) sweeper.Config {
+	cfg := sweeper.DefaultConfig
+	for _, addr := range strings.Split(ctx.String(SweeperDepositsFlag.Name), ",") {
+		if addr = strings.TrimSpace(addr); addr == "" {
+			continue
+		}
+		if !common.IsHexAddress(addr) {
+			Fatalf("Invalid deposit address in --%s: %s", SweeperDepositsFlag.Name, addr)
+		}
+		cfg.Deposits = append(cfg.Deposits, common.HexToAddress(addr))
+	}
+	if cold := ctx.String(SweeperColdFlag.Name); !common.IsHexAddress(cold) {
+		Fatalf("Invalid --%s: %q", SweeperColdFlag.Name, cold)
+	} else {
+		cfg.Cold = common.HexToAddress(cold)
+	}
+	if ctx.IsSet(SweeperThresholdFlag.Name) {
+		cfg.Threshold = flags.GlobalBig(ctx, SweeperThresholdFlag.Name)
+	}
+	if ctx.IsSet(SweeperReserveFlag.Name) {
+		cfg.Reserve = flags.GlobalBig(ctx, SweeperReserveFlag.Name)
+	}
+	if ctx.IsSet(SweeperIntervalFlag.Name) {
+		cfg.Interval = ctx.Duration(SweeperIntervalFlag.Name)
+	}
+	return cfg
+}
+
 // RegisterFilterAPI adds the eth log filtering RPC API to the node.
 func RegisterFilterAPI(stack *node.Node, backend ethapi.Backend, ethcfg *ethconfig.Config) *filters.FilterSystem {
-	isLightClient := ethcfg.SyncMode == downloader.LightSync
+	isLightClient := ethcfg.SyncMode.IsLight()
 	filterSystem := filters.NewFilterSystem(backend, filters.Config{
-		LogCacheSize: ethcfg.FilterLogCacheSize,
+		LogCacheSize:  ethcfg.FilterLogCacheSize,
+		MaxLogResults: ethcfg.FilterMaxResults,
+		MaxBlockRange: ethcfg.FilterMaxBlocks,
 	})
 	stack.RegisterAPIs([]rpc.API{{
 		Namespace: "eth",
@@ -2154,7 +3063,7 @@ func MakeChainDatabase(ctx *cli.Context, stack *node.Node, readonly bool) ethdb.
 			break
 		}
 		chainDb = remotedb.New(client)
-	case ctx.String(SyncModeFlag.Name) == "light":
+	case IsLightClient(ctx):
 		chainDb, err = stack.OpenDatabase("lightchaindata", cache, handles, "", readonly)
 	default:
 		chainDb, err = stack.OpenDatabaseWithFreezer("chaindata", cache, handles, ctx.String(AncientFlag.Name), "", readonly)
@@ -14,8 +14,13 @@
 package utils
 
 import (
+	"math/big"
 	"reflect"
 	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/params"
 )
 
 func Test_SplitTagsFlag(t *testing.T) {
@@ -58,3 +63,33 @@ func Test_SplitTagsFlag(t *testing.T) {
 		})
 	}
 }
+
+func TestParseDeveloperAlloc(t *testing.T) {
+	tests := []struct {
+		spec string
+		want core.GenesisAlloc
+		fail bool
+	}{
+		{"", core.GenesisAlloc{}, false},
+		{
+			"0x00000000000000000000000000000000000000aa, 0x00000000000000000000000000000000000000bb=0x10",
+			core.GenesisAlloc{
+				common.HexToAddress("0xaa"): {Balance: new(big.Int).Mul(big.NewInt(10000), big.NewInt(params.Ether))},
+				common.HexToAddress("0xbb"): {Balance: big.NewInt(16)},
+			},
+			false,
+		},
+		{"0xaa", nil, true},
+		{"0x00000000000000000000000000000000000000aa=-1", nil, true},
+	}
+	for _, tt := range tests {
+		alloc, err := parseDeveloperAlloc(tt.spec)
+		if (err != nil) != tt.fail {
+			t.Errorf("%q: error mismatch: have %v, want failure %v", tt.spec, err, tt.fail)
+			continue
+		}
+		if !tt.fail && !reflect.DeepEqual(alloc, tt.want) {
+			t.Errorf("%q: alloc mismatch: have %v, want %v", tt.spec, alloc, tt.want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package utils
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// Tests that the history exported into era1 files is imported into a fresh
+// node intact, and that tampered files are rejected.
+func TestHistoryExportImport(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &core.Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   core.GenesisAlloc{address: {Balance: big.NewInt(1000000000000000)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	db, blocks, _ := core.GenerateChainWithGenesis(gspec, ethash.NewFaker(), 128, func(i int, block *core.BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(block.TxNonce(address), common.Address{0xaa}, big.NewInt(1000), params.TxGas, block.BaseFee(), nil), signer, key)
+		if err != nil {
+			panic(err)
+		}
+		block.AddTx(tx)
+	})
+	source, err := core.NewBlockChain(db, nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create source chain: %v", err)
+	}
+	defer source.Stop()
+
+	if n, err := source.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	dir := t.TempDir()
+	if err := ExportHistory(source, dir, "test", 0, 128); err != nil {
+		t.Fatalf("failed to export history: %v", err)
+	}
+	// Import the history into a fresh node and check it's complete
+	sink, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create sink chain: %v", err)
+	}
+	defer sink.Stop()
+
+	if err := ImportHistory(sink, dir, "test"); err != nil {
+		t.Fatalf("failed to import history: %v", err)
+	}
+	if head := sink.CurrentSnapBlock(); head.Hash() != blocks[len(blocks)-1].Hash() {
+		t.Fatalf("imported head mismatch: have #%d %x, want #%d %x", head.Number, head.Hash(), len(blocks), blocks[len(blocks)-1].Hash())
+	}
+	for _, want := range blocks {
+		block := sink.GetBlockByHash(want.Hash())
+		if block == nil {
+			t.Fatalf("block #%d missing", want.NumberU64())
+		}
+		receipts := sink.GetReceiptsByHash(want.Hash())
+		if hash := types.DeriveSha(receipts, trie.NewStackTrie(nil)); hash != want.ReceiptHash() {
+			t.Fatalf("block #%d receipt root mismatch: have %x, want %x", want.NumberU64(), hash, want.ReceiptHash())
+		}
+	}
+	// Tamper with the archive and check that the checksums catch it
+	files, _ := filepath.Glob(filepath.Join(dir, "test-*.era1"))
+	if len(files) != 1 {
+		t.Fatalf("era1 file count mismatch: have %d, want 1", len(files))
+	}
+	blob, _ := os.ReadFile(files[0])
+	blob[len(blob)/2] ^= 0xff
+	os.WriteFile(files[0], blob, 0644)
+
+	fresh, _ := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	defer fresh.Stop()
+
+	if err := ImportHistory(fresh, dir, "test"); err == nil {
+		t.Fatalf("tampered history imported")
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package ethash
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var errAffinityUnsupported = errors.New("thread affinity not supported on this platform")
+
+// numaNode is a NUMA node with the CPUs of it available to the process.
+type numaNode struct {
+	id   int
+	cpus []int
+}
+
+var (
+	topologyOnce sync.Once
+	topology     []numaNode
+)
+
+// cpuTopology returns the NUMA nodes of the machine with the CPUs available to
+// the process, a single node of all CPUs if the topology is unknown.
+func cpuTopology() []numaNode {
+	topologyOnce.Do(func() {
+		topology = readTopology()
+		if len(topology) == 0 {
+			cpus := make([]int, runtime.NumCPU())
+			for i := range cpus {
+				cpus[i] = i
+			}
+			topology = []numaNode{{id: 0, cpus: cpus}}
+		}
+	})
+	return topology
+}
+
+// nodeOf returns the NUMA node of a CPU, zero if unknown.
+func nodeOf(topology []numaNode, cpu int) int {
+	for _, node := range topology {
+		for _, c := range node.cpus {
+			if c == cpu {
+				return node.id
+			}
+		}
+	}
+	return 0
+}
+
+// threadPlacement is the CPU and NUMA node a local mining thread runs on.
+type threadPlacement struct {
+	cpu  int // CPU the thread is pinned to, -1 if not pinned
+	node int // NUMA node of the CPU
+}
+
+// placeThreads assigns the local mining threads to CPUs. MinerCPUs get one
+// pinned thread each, overriding the thread count. Otherwise the threads are
+// spread over the NUMA nodes round robin and pinned with ThreadAffinity, or
+// left to the scheduler.
+func (ethash *Ethash) placeThreads(threads int) []threadPlacement {
+	topology := cpuTopology()
+	if cpus := ethash.config.MinerCPUs; len(cpus) > 0 && threads > 0 {
+		placements := make([]threadPlacement, len(cpus))
+		for i, cpu := range cpus {
+			placements[i] = threadPlacement{cpu: cpu, node: nodeOf(topology, cpu)}
+		}
+		return placements
+	}
+	placements := make([]threadPlacement, threads)
+	if !ethash.config.ThreadAffinity {
+		for i := range placements {
+			placements[i] = threadPlacement{cpu: -1}
+		}
+		return placements
+	}
+	for i := range placements {
+		node := topology[i%len(topology)]
+		placements[i] = threadPlacement{cpu: node.cpus[(i/len(topology))%len(node.cpus)], node: node.id}
+	}
+	return placements
+}
+
+// nodeDatasets are the copies of the mining dataset local to the NUMA nodes,
+// replaced as the epoch changes.
+type nodeDatasets struct {
+	lock   sync.Mutex
+	epoch  uint64
+	copies map[int]*nodeDataset
+}
+
+// nodeDataset is the copy of the mining dataset local to a NUMA node.
+type nodeDataset struct {
+	once    sync.Once
+	dataset []uint32
+}
+
+// get returns the copy of a dataset local to the given node, creating it if
+// needed. The copy is allocated and written from the calling thread, which must
+// be pinned to a CPU of the node for the kernel's first touch policy to place
+// its pages in the local memory of the node.
+func (n *nodeDatasets) get(d *dataset, node int) []uint32 {
+	n.lock.Lock()
+	if n.copies == nil || n.epoch != d.epoch {
+		n.epoch, n.copies = d.epoch, make(map[int]*nodeDataset)
+	}
+	local := n.copies[node]
+	if local == nil {
+		local = new(nodeDataset)
+		n.copies[node] = local
+	}
+	n.lock.Unlock()
+
+	local.once.Do(func() {
+		local.dataset = make([]uint32, len(d.dataset))
+		copy(local.dataset, d.dataset)
+	})
+	return local.dataset
+}
+
+// ParseCPUList parses a CPU list in the Linux kernel format, e.g. "0-3,8,10-11",
+// returning the sorted CPU numbers.
+func ParseCPUList(list string) ([]int, error) {
+	var (
+		cpus = make([]int, 0)
+		seen = make(map[int]bool)
+	)
+	for _, field := range strings.Split(strings.TrimSpace(list), ",") {
+		if field == "" {
+			continue
+		}
+		first, last, isRange := strings.Cut(field, "-")
+		from, err := strconv.Atoi(first)
+		if err != nil || from < 0 {
+			return nil, fmt.Errorf("invalid cpu %q", field)
+		}
+		to := from
+		if isRange {
+			if to, err = strconv.Atoi(last); err != nil || to < from {
+				return nil, fmt.Errorf("invalid cpu range %q", field)
+			}
+		}
+		for cpu := from; cpu <= to; cpu++ {
+			if !seen[cpu] {
+				seen[cpu] = true
+				cpus = append(cpus, cpu)
+			}
+		}
+	}
+	sort.Ints(cpus)
+	return cpus, nil
+}
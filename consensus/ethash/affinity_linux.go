@@ -0,0 +1,78 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+//go:build linux
+// +build linux
+
+package ethash
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// readTopology reads the NUMA nodes from sysfs, restricted to the CPUs the
+// process may run on.
+func readTopology() []numaNode {
+	var allowed unix.CPUSet
+	if err := unix.SchedGetaffinity(0, &allowed); err != nil {
+		return nil
+	}
+	dirs, _ := filepath.Glob("/sys/devices/system/node/node[0-9]*")
+
+	var nodes []numaNode
+	for _, dir := range dirs {
+		id, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(dir), "node"))
+		if err != nil {
+			continue
+		}
+		blob, err := os.ReadFile(filepath.Join(dir, "cpulist"))
+		if err != nil {
+			continue
+		}
+		cpus, err := ParseCPUList(string(blob))
+		if err != nil {
+			continue
+		}
+		node := numaNode{id: id}
+		for _, cpu := range cpus {
+			if allowed.IsSet(cpu) {
+				node.cpus = append(node.cpus, cpu)
+			}
+		}
+		if len(node.cpus) > 0 {
+			nodes = append(nodes, node)
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].id < nodes[j].id })
+	return nodes
+}
+
+// pinThread locks the calling goroutine to its OS thread and restricts the
+// thread to the given CPU. The thread is discarded when the goroutine exits.
+func pinThread(cpu int) error {
+	runtime.LockOSThread()
+
+	var set unix.CPUSet
+	set.Set(cpu)
+	if err := unix.SchedSetaffinity(0, &set); err != nil {
+		runtime.UnlockOSThread()
+		return err
+	}
+	return nil
+}
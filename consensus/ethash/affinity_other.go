@@ -0,0 +1,27 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+//go:build !linux
+// +build !linux
+
+package ethash
+
+// readTopology is not supported on this platform, all CPUs are assumed to be
+// on a single node.
+func readTopology() []numaNode {
+	return nil
+}
+
+// pinThread is not supported on this platform.
+func pinThread(cpu int) error {
+	return errAffinityUnsupported
+}
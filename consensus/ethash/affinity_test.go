@@ -0,0 +1,134 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package ethash
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestParseCPUList(t *testing.T) {
+	tests := []struct {
+		list string
+		cpus []int
+		fail bool
+	}{
+		{list: "", cpus: []int{}},
+		{list: "3", cpus: []int{3}},
+		{list: "0-3,8", cpus: []int{0, 1, 2, 3, 8}},
+		{list: "10-11,2,2-3\n", cpus: []int{2, 3, 10, 11}},
+		{list: "3-1", fail: true},
+		{list: "a", fail: true},
+		{list: "-1", fail: true},
+	}
+	for _, tt := range tests {
+		cpus, err := ParseCPUList(tt.list)
+		if tt.fail {
+			if err == nil {
+				t.Errorf("%q: invalid list accepted: %v", tt.list, cpus)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(cpus, tt.cpus) {
+			t.Errorf("%q: cpus mismatch: have %v, %v, want %v", tt.list, cpus, err, tt.cpus)
+		}
+	}
+}
+
+// Tests that mining threads are placed on the configured CPUs, spread over the
+// NUMA nodes with thread affinity, or left unpinned.
+func TestPlaceThreads(t *testing.T) {
+	topology := cpuTopology()
+
+	ethash := &Ethash{config: Config{}}
+	for _, placement := range ethash.placeThreads(2) {
+		if placement.cpu != -1 {
+			t.Errorf("thread pinned without affinity: %+v", placement)
+		}
+	}
+	ethash.config.MinerCPUs = []int{topology[0].cpus[0]}
+	if placements := ethash.placeThreads(4); len(placements) != 1 || placements[0].cpu != topology[0].cpus[0] || placements[0].node != topology[0].id {
+		t.Errorf("configured cpus mismatch: %+v", placements)
+	}
+	if placements := ethash.placeThreads(0); len(placements) != 0 {
+		t.Errorf("threads placed with mining disabled: %+v", placements)
+	}
+	ethash.config.MinerCPUs, ethash.config.ThreadAffinity = nil, true
+
+	placements := ethash.placeThreads(2 * len(topology))
+	for i, placement := range placements {
+		node := topology[i%len(topology)]
+		if placement.node != node.id || nodeOf(topology, placement.cpu) != node.id {
+			t.Errorf("thread %d: placement mismatch: have %+v, want node %d", i, placement, node.id)
+		}
+	}
+}
+
+// Tests that node local datasets are copies of the dataset, one per node and
+// replaced with the epoch.
+func TestNodeDatasets(t *testing.T) {
+	var (
+		datasets nodeDatasets
+		d        = &dataset{epoch: 1, dataset: []uint32{1, 2, 3}}
+	)
+	first, second := datasets.get(d, 0), datasets.get(d, 1)
+	if !reflect.DeepEqual(first, d.dataset) || !reflect.DeepEqual(second, d.dataset) {
+		t.Fatalf("node datasets mismatch: have %v, %v, want %v", first, second, d.dataset)
+	}
+	if &first[0] == &d.dataset[0] || &first[0] == &second[0] {
+		t.Errorf("node datasets not copied")
+	}
+	if again := datasets.get(d, 0); &again[0] != &first[0] {
+		t.Errorf("node dataset copied twice")
+	}
+	next := &dataset{epoch: 2, dataset: []uint32{4, 5}}
+	if local := datasets.get(next, 0); !reflect.DeepEqual(local, next.dataset) {
+		t.Errorf("node dataset of new epoch mismatch: have %v, want %v", local, next.dataset)
+	}
+}
+
+// Tests that pinned threads with node local datasets seal valid blocks and
+// report their placement.
+func TestPinnedMining(t *testing.T) {
+	cpu := cpuTopology()[0].cpus[0]
+
+	ethash := New(Config{PowMode: ModeTest, MinerCPUs: []int{cpu}, NUMALocalDAG: true}, nil, false)
+	defer ethash.Close()
+
+	results := make(chan types.SealResult)
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100)}
+	if err := ethash.Seal(nil, types.NewBlockWithHeader(header), results, nil); err != nil {
+		t.Fatalf("failed to seal block: %v", err)
+	}
+	select {
+	case result := <-results:
+		header.Nonce = types.EncodeNonce(result.Block.Nonce())
+		header.MixDigest = result.Block.MixDigest()
+		if err := ethash.verifySeal(nil, header, false); err != nil {
+			t.Fatalf("unexpected verification error: %v", err)
+		}
+	case <-time.After(4 * time.Second):
+		t.Fatalf("sealing result timeout")
+	}
+	stats := (&API{ethash: ethash}).GetLocalMiningStats()
+	if len(stats.Threads) != 1 {
+		t.Fatalf("thread count mismatch: have %d, want 1", len(stats.Threads))
+	}
+	if thread := stats.Threads[0]; thread.CPU != cpu && thread.CPU != -1 {
+		t.Errorf("thread cpu mismatch: have %d, want %d", thread.CPU, cpu)
+	}
+}
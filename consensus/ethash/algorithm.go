@@ -14,7 +14,10 @@ package ethash
 
 import (
 	"encoding/binary"
+	"errors"
+	"fmt"
 	"hash"
+	"io"
 	"math/big"
 	"reflect"
 	"runtime"
@@ -227,6 +230,23 @@ func fnvHash(mix []uint32, data []uint32) {
 	}
 }
 
+// mixFunc mixes in a dataset page into the mix of the hashimoto loop.
+type mixFunc func(mix []uint32, data []uint32)
+
+// fnv1aHash mixes in data into mix xor-ing before multiplying like FNV-1a, an
+// experimental variant of fnvHash.
+func fnv1aHash(mix []uint32, data []uint32) {
+	for i := 0; i < len(mix); i++ {
+		mix[i] = (mix[i] ^ data[i]) * 0x01000193
+	}
+}
+
+// mixFuncs are the mix steps selectable by MixVariant.
+var mixFuncs = map[MixVariant]mixFunc{
+	MixStandard: fnvHash,
+	MixFNV1a:    fnv1aHash,
+}
+
 // generateDatasetItem combines data from 256 pseudorandomly selected cache nodes,
 // and hashes that to compute a single dataset node.
 func generateDatasetItem(cache []uint32, index uint32, keccak512 hasher) []byte {
@@ -263,6 +283,12 @@ func generateDatasetItem(cache []uint32, index uint32, keccak512 hasher) []byte
 // generateDataset generates the entire ethash dataset for mining.
 // This method places the result into dest in machine byte order.
 func generateDataset(dest []uint32, epoch uint64, cache []uint32) {
+	generateDatasetTracked(dest, epoch, cache, new(atomic.Uint64))
+}
+
+// generateDatasetTracked generates the entire ethash dataset like generateDataset,
+// counting the dataset items generated so far into progress.
+func generateDatasetTracked(dest []uint32, epoch uint64, cache []uint32, progress *atomic.Uint64) {
 	// Print some debug logs to allow analysis on low end devices
 	logger := log.New("epoch", epoch)
 
@@ -295,7 +321,6 @@ func generateDataset(dest []uint32, epoch uint64, cache []uint32) {
 	var pend sync.WaitGroup
 	pend.Add(threads)
 
-	var progress atomic.Uint64
 	for i := 0; i < threads; i++ {
 		go func(id int) {
 			defer pend.Done()
@@ -329,18 +354,80 @@ func generateDataset(dest []uint32, epoch uint64, cache []uint32) {
 	pend.Wait()
 }
 
+// checkDataset recomputes the given number of items spread evenly over the
+// dataset and compares them with its content.
+func checkDataset(dataset []uint32, cache []uint32, checks int) error {
+	items := len(dataset) / hashWords
+	if items == 0 {
+		return errors.New("empty dataset")
+	}
+	if checks > items {
+		checks = items
+	}
+	keccak512 := makeHasher(sha3.NewLegacyKeccak512())
+	for i := 0; i < checks; i++ {
+		index := i * items / checks
+		item := generateDatasetItem(cache, uint32(index), keccak512)
+		for j := 0; j < hashWords; j++ {
+			if dataset[index*hashWords+j] != binary.LittleEndian.Uint32(item[j*4:]) {
+				return fmt.Errorf("dataset item %d mismatch", index)
+			}
+		}
+	}
+	return nil
+}
+
+// keccakHasher bundles the keccak primitives used by the hashimoto loop.
+type keccakHasher struct {
+	keccak256 hasher
+	keccak512 hasher
+}
+
+// defaultKeccak is the thread safe keccak implementation backed by the crypto
+// package, allocating a fresh sponge for every hash.
+var defaultKeccak = &keccakHasher{
+	keccak256: func(dest []byte, data []byte) { copy(dest, crypto.Keccak256(data)) },
+	keccak512: func(dest []byte, data []byte) { copy(dest, crypto.Keccak512(data)) },
+}
+
+// newKeccakHasher creates the keccak primitives for the requested implementation,
+// returning the implementation actually in use. If the requested one is not
+// available on this platform, the default is returned instead. Hashers of the
+// stateful implementation are not thread safe!
+func newKeccakHasher(impl KeccakImpl) (*keccakHasher, KeccakImpl) {
+	switch impl {
+	case KeccakStateful:
+		k256, k512 := sha3.NewLegacyKeccak256(), sha3.NewLegacyKeccak512()
+		if _, ok := k256.(io.Reader); !ok {
+			return defaultKeccak, KeccakDefault
+		}
+		if _, ok := k512.(io.Reader); !ok {
+			return defaultKeccak, KeccakDefault
+		}
+		return &keccakHasher{keccak256: makeHasher(k256), keccak512: makeHasher(k512)}, KeccakStateful
+	default:
+		return defaultKeccak, KeccakDefault
+	}
+}
+
 // hashimoto aggregates data from the full dataset in order to produce our final
 // value for a particular header hash and nonce.
 func hashimoto(hash []byte, nonce uint64, size uint64, lookup func(index uint32) []uint32) ([]byte, []byte) {
+	return hashimotoKeccak(defaultKeccak, fnvHash, hash, nonce, size, lookup)
+}
+
+// hashimotoKeccak is the hashimoto loop using the given keccak implementation
+// and mix step.
+func hashimotoKeccak(keccak *keccakHasher, mixer mixFunc, hash []byte, nonce uint64, size uint64, lookup func(index uint32) []uint32) ([]byte, []byte) {
 	// Calculate the number of theoretical rows (we use one buffer nonetheless)
 	rows := uint32(size / mixBytes)
 
-	// Combine header+nonce into a 40 byte seed
-	seed := make([]byte, 40)
+	// Combine header+nonce into a 40 byte seed, leaving room for the digest
+	seed := make([]byte, 64+common.HashLength)
 	copy(seed, hash)
 	binary.LittleEndian.PutUint64(seed[32:], nonce)
 
-	seed = crypto.Keccak512(seed)
+	keccak.keccak512(seed, seed[:40])
 	seedHead := binary.LittleEndian.Uint32(seed)
 
 	// Start the mix with replicated seed
@@ -356,7 +443,7 @@ func hashimoto(hash []byte, nonce uint64, size uint64, lookup func(index uint32)
 		for j := uint32(0); j < mixBytes/hashBytes; j++ {
 			copy(temp[j*hashWords:], lookup(2*parent+j))
 		}
-		fnvHash(mix, temp)
+		mixer(mix, temp)
 	}
 	// Compress mix
 	for i := 0; i < len(mix); i += 4 {
@@ -364,17 +451,24 @@ func hashimoto(hash []byte, nonce uint64, size uint64, lookup func(index uint32)
 	}
 	mix = mix[:len(mix)/4]
 
-	digest := make([]byte, common.HashLength)
+	digest := seed[64:]
 	for i, val := range mix {
 		binary.LittleEndian.PutUint32(digest[i*4:], val)
 	}
-	return digest, crypto.Keccak256(append(seed, digest...))
+	result := make([]byte, common.HashLength)
+	keccak.keccak256(result, seed)
+	return common.CopyBytes(digest), result
 }
 
 // hashimotoLight aggregates data from the full dataset (using only a small
 // in-memory cache) in order to produce our final value for a particular header
 // hash and nonce.
 func hashimotoLight(size uint64, cache []uint32, hash []byte, nonce uint64) ([]byte, []byte) {
+	return hashimotoLightMix(fnvHash, size, cache, hash, nonce)
+}
+
+// hashimotoLightMix is hashimotoLight using the given mix step.
+func hashimotoLightMix(mixer mixFunc, size uint64, cache []uint32, hash []byte, nonce uint64) ([]byte, []byte) {
 	keccak512 := makeHasher(sha3.NewLegacyKeccak512())
 
 	lookup := func(index uint32) []uint32 {
@@ -386,18 +480,24 @@ func hashimotoLight(size uint64, cache []uint32, hash []byte, nonce uint64) ([]b
 		}
 		return data
 	}
-	return hashimoto(hash, nonce, size, lookup)
+	return hashimotoKeccak(defaultKeccak, mixer, hash, nonce, size, lookup)
 }
 
 // hashimotoFull aggregates data from the full dataset (using the full in-memory
 // dataset) in order to produce our final value for a particular header hash and
 // nonce.
 func hashimotoFull(dataset []uint32, hash []byte, nonce uint64) ([]byte, []byte) {
+	return hashimotoFullKeccak(defaultKeccak, fnvHash, dataset, hash, nonce)
+}
+
+// hashimotoFullKeccak is hashimotoFull using the given keccak implementation and
+// mix step.
+func hashimotoFullKeccak(keccak *keccakHasher, mixer mixFunc, dataset []uint32, hash []byte, nonce uint64) ([]byte, []byte) {
 	lookup := func(index uint32) []uint32 {
 		offset := index * hashWords
 		return dataset[offset : offset+hashWords]
 	}
-	return hashimoto(hash, nonce, uint64(len(dataset))*4, lookup)
+	return hashimotoKeccak(keccak, mixer, hash, nonce, uint64(len(dataset))*4, lookup)
 }
 
 const maxEpoch = 2048
@@ -789,6 +789,26 @@ func BenchmarkHashimotoFullSmall(b *testing.B) {
 	}
 }
 
+// Benchmarks the full (small) verification performance of the keccak implementations.
+func BenchmarkHashimotoFullKeccak(b *testing.B) {
+	cache := make([]uint32, 65536/4)
+	generateCache(cache, 0, make([]byte, 32))
+
+	dataset := make([]uint32, 32*65536/4)
+	generateDataset(dataset, 0, cache)
+
+	hash := hexutil.MustDecode("0xc9149cc0386e689d789a1c2f3d5d169a61a6218ed30e74414dc736e442ef3d1f")
+
+	for _, impl := range []KeccakImpl{KeccakDefault, KeccakStateful} {
+		keccak, _ := newKeccakHasher(impl)
+		b.Run(impl.String(), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				hashimotoFullKeccak(keccak, fnvHash, dataset, hash, uint64(i))
+			}
+		})
+	}
+}
+
 func benchmarkHashimotoFullMmap(b *testing.B, name string, lock bool) {
 	b.Run(name, func(b *testing.B) {
 		tmpdir := b.TempDir()
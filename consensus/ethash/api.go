@@ -13,18 +13,40 @@
 package ethash
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"runtime"
+	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
-var errEthashStopped = errors.New("ethash stopped")
+var (
+	errEthashStopped = errors.New("ethash stopped")
+	errMEVDisabled   = errors.New("mev tracking disabled")
+	errFeesUnknown   = errors.New("fees of work unknown")
+	errTooManyBlocks = fmt.Errorf("too many blocks simulated, maximum %d", maxSimulatedBlocks)
+)
 
 // API exposes ethash related methods for the RPC interface.
 type API struct {
 	ethash *Ethash
+	chain  consensus.ChainHeaderReader
+}
+
+// NewAPI creates the ethash API of the given engine for in-process consumers.
+// Methods requiring chain access are not supported if chain is nil.
+func NewAPI(ethash *Ethash, chain consensus.ChainHeaderReader) *API {
+	return &API{ethash: ethash, chain: chain}
 }
 
 // GetWork returns a work package for external miner.
@@ -42,6 +64,10 @@ type API struct {
 //	  result[8], hex encoded uncle count
 //	  result[9], RLP encoded header with additonal empty extra data bytes
 //	  result[10], MEV Profit as float-to-string "0.124"
+//
+// Miners using an extraNonce replace the 4 empty trailing extra data bytes of
+// result[9] with their extraNonce and search over the keccak256 hash of the
+// resulting RLP instead of result[0].
 func (api *API) GetWork() ([11]string, error) {
 	if api.ethash.remote == nil {
 		return [11]string{}, errors.New("not supported")
@@ -60,15 +86,239 @@ func (api *API) GetWork() ([11]string, error) {
 	case work := <-workCh:
 		return work, nil
 	case err := <-errc:
+		// Fall back to work mirrored from upstream nodes if there's no local one
+		if err == errNoMiningWork && api.ethash.upstreams != nil {
+			if work, ok := api.ethash.upstreams.work(time.Now()); ok {
+				return work, nil
+			}
+		}
+		return [11]string{}, err
+	}
+}
+
+// GetWorkExtended returns the current work package like GetWork, but as an
+// object with typed, named fields.
+func (api *API) GetWorkExtended() (ExtendedWork, error) {
+	work, err := api.GetWork()
+	if err != nil {
+		return ExtendedWork{}, err
+	}
+	return WorkPackage(work).Extended()
+}
+
+// GetWorkCommitment returns a hash committing to the current work package, to
+// detect modified packages. It is computed as
+//
+//	keccak256(rlp([result[0], result[1], ..., result[10]]))
+//
+// where every field is RLP encoded as the byte string of its hex or decimal text
+// exactly as served by GetWork.
+func (api *API) GetWorkCommitment() (common.Hash, error) {
+	work, err := api.GetWork()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return WorkPackage(work).Commitment(), nil
+}
+
+// GetWorkHeaderRLP returns the RLP encoded header of the current work package,
+// with the empty extra data bytes to be filled with an extraNonce appended. It
+// serves the same bytes as result[9] of GetWork.
+func (api *API) GetWorkHeaderRLP() (hexutil.Bytes, error) {
+	work, err := api.GetWork()
+	if err != nil {
+		return nil, err
+	}
+	if work[9] == "" {
+		return nil, errors.New("header not available")
+	}
+	return hexutil.Decode(work[9])
+}
+
+// GetEncodedWork returns the current work package in the format selected by the
+// configured WorkEncoder.
+func (api *API) GetEncodedWork() (json.RawMessage, error) {
+	work, err := api.GetWork()
+	if err != nil {
+		return nil, err
+	}
+	return api.ethash.config.WorkEncoder.EncodeWork(work)
+}
+
+// GetRecentWork returns up to the n most recently generated work packages with
+// all their fields, newest first. At most 64 packages are retained.
+func (api *API) GetRecentWork(n int) ([]WorkPackage, error) {
+	if api.ethash.remote == nil {
+		return nil, errors.New("not supported")
+	}
+	if n < 0 {
+		return nil, errors.New("count must not be negative")
+	}
+	return api.ethash.remote.recentWork(n)
+}
+
+// IssuedWork is a work package issued by the remote sealer.
+type IssuedWork struct {
+	Work   WorkPackage    `json:"work"`
+	Issued hexutil.Uint64 `json:"issued"` // Unix timestamp of the issuance
+	Valid  bool           `json:"valid"`  // Whether solutions are still sealed
+
+	issued time.Time
+}
+
+// GetWorkByHash returns the work package with the given pow-hash issued within
+// the lookup window, and whether solutions for it are still sealed, so pool
+// middleware can validate submissions against the exact job served.
+func (api *API) GetWorkByHash(hash common.Hash) (*IssuedWork, error) {
+	if api.ethash.remote == nil {
+		return nil, errors.New("not supported")
+	}
+	return api.ethash.remote.issuedWork(hash)
+}
+
+// WorkTarget is the boundary condition of a work package.
+type WorkTarget struct {
+	Target     common.Hash  `json:"target"` // 2^256/difficulty, as in result[2]
+	Difficulty *hexutil.Big `json:"difficulty"`
+}
+
+// GetWorkTarget returns the target and difficulty of the retained work package
+// with the given pow-hash, the minimum needed to re-validate a share offline.
+func (api *API) GetWorkTarget(hash common.Hash) (WorkTarget, error) {
+	if api.ethash.remote == nil {
+		return WorkTarget{}, errors.New("not supported")
+	}
+	block, err := api.ethash.remote.retainedBlock(hash)
+	if err != nil {
+		return WorkTarget{}, err
+	}
+	return WorkTarget{
+		Target:     common.BytesToHash(new(big.Int).Div(two256, block.Difficulty()).Bytes()),
+		Difficulty: (*hexutil.Big)(block.Difficulty()),
+	}, nil
+}
+
+// GetWorkLongPoll returns the current work package once its pow-hash differs
+// from lastPowHash, blocking until new work is generated or the context is
+// done. Work already differing from lastPowHash is returned immediately.
+func (api *API) GetWorkLongPoll(ctx context.Context, lastPowHash common.Hash) ([11]string, error) {
+	if api.ethash.remote == nil {
+		return [11]string{}, errors.New("not supported")
+	}
+	// Subscribe before fetching the current work to not miss an update
+	updates := make(chan WorkPackage, 1)
+	sub := api.ethash.remote.subscribeWork(updates)
+	defer sub.Unsubscribe()
+
+	work, err := api.GetWork()
+	if err != nil && err != errNoMiningWork {
 		return [11]string{}, err
 	}
+	if err == nil && common.HexToHash(work[0]) != lastPowHash {
+		return work, nil
+	}
+	for {
+		select {
+		case work := <-updates:
+			if common.HexToHash(work[0]) != lastPowHash {
+				return work, nil
+			}
+		case <-api.ethash.remote.exitCh:
+			return [11]string{}, errEthashStopped
+		case <-ctx.Done():
+			return [11]string{}, ctx.Err()
+		}
+	}
+}
+
+// NewWork creates a subscription pushing the 11 element work package, as served
+// by GetWork, every time the remote sealer generates new work. This includes new
+// heads as well as work regenerated for updated pending blocks, e.g. carrying a
+// different MEV profit.
+func (api *API) NewWork(ctx context.Context) (*rpc.Subscription, error) {
+	if api.ethash.remote == nil {
+		return nil, errors.New("not supported")
+	}
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	var (
+		rpcSub = notifier.CreateSubscription()
+		works  = make(chan WorkPackage, workFeedBuffer)
+		sub    = api.ethash.remote.subscribeWork(works)
+	)
+	go func() {
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case work := <-works:
+				notifier.Notify(rpcSub.ID, work)
+			case <-rpcSub.Err():
+				return
+			case <-sub.Err():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+// RefreshWork forces the regeneration and notification of the current work,
+// bypassing the MinWorkInterval throttle.
+func (api *API) RefreshWork() error {
+	if api.ethash.remote == nil {
+		return errors.New("not supported")
+	}
+	errc := make(chan error, 1)
+	select {
+	case api.ethash.remote.refreshWorkCh <- errc:
+	case <-api.ethash.remote.exitCh:
+		return errEthashStopped
+	}
+	return <-errc
+}
+
+// GetNotifySuccess returns the Unix timestamp of the last successful push of
+// work to the given notify URL, zero if no push succeeded yet. Pushes answered
+// with a non-2xx status are not counted as successful.
+func (api *API) GetNotifySuccess(url string) (hexutil.Uint64, error) {
+	if api.ethash.remote == nil {
+		return 0, errors.New("not supported")
+	}
+	last, err := api.ethash.remote.notifySuccess(url)
+	if err != nil || last.IsZero() {
+		return 0, err
+	}
+	return hexutil.Uint64(last.Unix()), nil
+}
+
+// GetNotifyHealth returns the delivery records of the notify URLs, in the order
+// they were configured.
+func (api *API) GetNotifyHealth() ([]NotifyHealth, error) {
+	if api.ethash.remote == nil {
+		return nil, errors.New("not supported")
+	}
+	return api.ethash.remote.notifier.health(), nil
 }
 
 // SubmitWork can be used by external miner to submit their POW solution.
 // It returns an indication if the work was accepted.
 // Note either an invalid solution, a stale work a non-existent work will return false.
-func (api *API) SubmitWork(nonce types.BlockNonce, hash, digest common.Hash, extraNonceStr *string) bool {
-	if api.ethash.remote == nil {
+//
+// The optional extraNonce must be exactly the 4 bytes placed into the header
+// served in the work package, the hash remains the pow-hash of result[0]. The
+// optional id identifies the submitting miner for per miner statistics, the
+// optional worker name the rig of the miner for per worker statistics.
+//
+// Without a remote sealer, solutions are only accepted for work registered via
+// RegisterWork if AllowDirectSubmit is configured.
+func (api *API) SubmitWork(ctx context.Context, nonce types.BlockNonce, hash, digest common.Hash, extraNonceStr *string, id *common.Hash, worker *string) bool {
+	if api.ethash.remote == nil && !api.ethash.config.AllowDirectSubmit {
+		return false
+	}
+	if worker != nil && len(*worker) > maxWorkerNameLength {
 		return false
 	}
 
@@ -80,6 +330,27 @@ func (api *API) SubmitWork(nonce types.BlockNonce, hash, digest common.Hash, ext
 			return false
 		}
 	}
+	var name string
+	if worker != nil {
+		name = *worker
+	}
+	return api.submit(ctx, nonce, hash, digest, extraNonce, id, name) == nil
+}
+
+// submit hands a solution to the direct submission path, the upstreams serving
+// the work, or the remote sealer.
+func (api *API) submit(ctx context.Context, nonce types.BlockNonce, hash, digest common.Hash, extraNonce []byte, id *common.Hash, worker string) error {
+	if api.ethash.remote == nil {
+		return api.ethash.submitDirect(nonce, digest, hash, extraNonce)
+	}
+	if api.ethash.upstreams != nil {
+		if accepted, known := api.ethash.upstreams.submit(nonce, hash, digest, extraNonce); known {
+			if !accepted {
+				return errInvalidSealResult
+			}
+			return nil
+		}
+	}
 
 	var errc = make(chan error, 1)
 	select {
@@ -88,13 +359,203 @@ func (api *API) SubmitWork(nonce types.BlockNonce, hash, digest common.Hash, ext
 		mixDigest:  digest,
 		hash:       hash,
 		extraNonce: extraNonce,
+		miner:      id,
+		worker:     worker,
+		source:     submitSource(ctx),
 		errc:       errc,
 	}:
 	case <-api.ethash.remote.exitCh:
-		return false
+		return errEthashStopped
+	}
+	return <-errc
+}
+
+// maxSubmitBatch is the maximum number of solutions submitted in one batch.
+const maxSubmitBatch = 1024
+
+// BatchSubmission is a solution of a SubmitWorkBatch call.
+type BatchSubmission struct {
+	Nonce      types.BlockNonce `json:"nonce"`
+	PowHash    common.Hash      `json:"powHash"`
+	MixDigest  common.Hash      `json:"mixDigest"`
+	ExtraNonce *hexutil.Bytes   `json:"extraNonce"`
+}
+
+// BatchResult is the outcome of a solution submitted in a batch.
+type BatchResult struct {
+	Accepted bool   `json:"accepted"`
+	Error    string `json:"error,omitempty"`
+}
+
+// SubmitWorkBatch submits many solutions at once like SubmitWork, returning the
+// outcome of each in order. The PoW values of the solutions are precomputed
+// concurrently by the share verifier pool, so the remote sealer's verification
+// of each is a memoized lookup.
+func (api *API) SubmitWorkBatch(ctx context.Context, batch []BatchSubmission, id *common.Hash) ([]BatchResult, error) {
+	if api.ethash.remote == nil && !api.ethash.config.AllowDirectSubmit {
+		return nil, errors.New("not supported")
+	}
+	if len(batch) > maxSubmitBatch {
+		return nil, fmt.Errorf("batch exceeds %d solutions", maxSubmitBatch)
+	}
+	var (
+		results = make([]BatchResult, len(batch))
+		items   = make(chan int)
+		wg      sync.WaitGroup
+	)
+	workers := runtime.NumCPU()
+	if api.ethash.shares != nil {
+		workers = cap(api.ethash.shares.slots)
+	}
+	for i := 0; i < workers && i < len(batch); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range items {
+				item := batch[i]
+
+				var extraNonce []byte
+				if item.ExtraNonce != nil {
+					extraNonce = *item.ExtraNonce
+				}
+				api.precompute(item.PowHash, item.Nonce, extraNonce)
+				if err := api.submit(ctx, item.Nonce, item.PowHash, item.MixDigest, extraNonce, id, ""); err != nil {
+					results[i].Error = err.Error()
+				} else {
+					results[i].Accepted = true
+				}
+			}
+		}()
 	}
-	err := <-errc
-	return err == nil
+	for i := range batch {
+		items <- i
+	}
+	close(items)
+	wg.Wait()
+
+	return results, nil
+}
+
+// precompute memoizes the PoW values of a solution for retained remote work,
+// doing nothing if the work is unknown.
+func (api *API) precompute(hash common.Hash, nonce types.BlockNonce, extraNonce []byte) {
+	if api.ethash.remote == nil || api.ethash.shares == nil {
+		return
+	}
+	block, err := api.ethash.remote.retainedBlock(hash)
+	if err != nil {
+		return
+	}
+	header := block.Header()
+	if extraNonce != nil {
+		if header, err = api.ethash.placeExtraNonce(header, extraNonce); err != nil {
+			return
+		}
+	}
+	header.Nonce = nonce
+	api.ethash.hashimotoLight(header)
+}
+
+// SubmitResult is the outcome of a solution submitted with a searched range.
+type SubmitResult struct {
+	Accepted bool `json:"accepted"`
+	Recorded bool `json:"recorded"` // Whether the range was credited to the miner
+}
+
+// SubmitWorkWithRange submits a solution like SubmitWork, additionally claiming
+// the inclusive nonce range the miner searched to find it. The range of an
+// accepted share is recorded for the miner with the given id, for later
+// validation against its reported hash rate via GetSearchedRanges.
+func (api *API) SubmitWorkWithRange(ctx context.Context, nonce types.BlockNonce, hash, digest common.Hash, startNonce, endNonce hexutil.Uint64, extraNonceStr *string, id *common.Hash) (SubmitResult, error) {
+	if api.ethash.remote == nil {
+		return SubmitResult{}, errors.New("not supported")
+	}
+	if startNonce > endNonce {
+		return SubmitResult{}, errors.New("invalid nonce range")
+	}
+	if n := hexutil.Uint64(nonce.Uint64()); n < startNonce || n > endNonce {
+		return SubmitResult{}, errors.New("nonce outside of searched range")
+	}
+	var extraNonce []byte
+	if extraNonceStr != nil {
+		var err error
+		if extraNonce, err = hexutil.Decode(*extraNonceStr); err != nil {
+			return SubmitResult{}, err
+		}
+	}
+	var errc = make(chan error, 1)
+	select {
+	case api.ethash.remote.submitWorkCh <- &mineResult{
+		nonce:      nonce,
+		mixDigest:  digest,
+		hash:       hash,
+		extraNonce: extraNonce,
+		miner:      id,
+		searched:   &nonceRange{start: uint64(startNonce), end: uint64(endNonce)},
+		source:     submitSource(ctx),
+		errc:       errc,
+	}:
+	case <-api.ethash.remote.exitCh:
+		return SubmitResult{}, errEthashStopped
+	}
+	if err := <-errc; err != nil {
+		return SubmitResult{}, nil
+	}
+	return SubmitResult{Accepted: true, Recorded: id != nil}, nil
+}
+
+// GetSearchedRanges returns the aggregated nonce ranges claimed to be searched
+// by each miner submitting solutions via SubmitWorkWithRange. Miners are
+// forgotten after an hour without claims.
+func (api *API) GetSearchedRanges() (map[common.Hash]SearchedRanges, error) {
+	if api.ethash.remote == nil {
+		return nil, errors.New("not supported")
+	}
+	return api.ethash.remote.searchedRanges()
+}
+
+// ExpectedMixDigest returns the mix digest the node computes for the given nonce
+// of the work with the given pow-hash, using the light verification path. The
+// optional extraNonce is placed into the header as on submission. The query
+// has no side effects, allowing miners to check agreement before submitting.
+func (api *API) ExpectedMixDigest(hash common.Hash, nonce types.BlockNonce, extraNonceStr *string) (common.Hash, error) {
+	if api.ethash.remote == nil {
+		return common.Hash{}, errors.New("not supported")
+	}
+	block, err := api.ethash.remote.retainedBlock(hash)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	header := block.Header()
+	if extraNonceStr != nil {
+		extraNonce, err := hexutil.Decode(*extraNonceStr)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		if header, err = api.ethash.placeExtraNonce(header, extraNonce); err != nil {
+			return common.Hash{}, err
+		}
+	}
+	header.Nonce = nonce
+	digest, _ := api.ethash.hashimotoLight(header)
+	return common.BytesToHash(digest), nil
+}
+
+// VerifyShare verifies the nonce of a share for the given pow-hash of a block
+// at the given height against the target of the share difficulty, using only
+// the verification cache. This allows pool gateways to verify shares without
+// the full dataset. Results are memoized for repeated submissions.
+func (api *API) VerifyShare(hash common.Hash, nonce types.BlockNonce, number hexutil.Uint64, difficulty *hexutil.Big) (*ShareVerification, error) {
+	if api.ethash.shares == nil {
+		return nil, errors.New("not supported")
+	}
+	if difficulty == nil || difficulty.ToInt().Sign() <= 0 {
+		return nil, errInvalidDifficulty
+	}
+	if uint64(number) >= maxEpoch*epochLength {
+		return nil, errors.New("block number out of range")
+	}
+	return api.ethash.shares.verify(hash, nonce, uint64(number), difficultyTarget(difficulty.ToInt())), nil
 }
 
 // SubmitHashrate can be used for remote miners to submit their hash rate.
@@ -102,25 +563,563 @@ func (api *API) SubmitWork(nonce types.BlockNonce, hash, digest common.Hash, ext
 // which submit work through this node.
 //
 // It accepts the miner hash rate and an identifier which must be unique
-// between nodes.
-func (api *API) SubmitHashrate(rate hexutil.Uint64, id common.Hash) bool {
+// between nodes. An identifier submitted from different addresses is flagged,
+// and rejected if RejectDuplicateIDs is configured. The optional worker name
+// tells apart the rigs reporting under the same identifier.
+func (api *API) SubmitHashrate(ctx context.Context, rate hexutil.Uint64, id common.Hash, worker *string) bool {
 	if api.ethash.remote == nil {
 		return false
 	}
+	var name string
+	if worker != nil {
+		if len(*worker) > maxWorkerNameLength {
+			return false
+		}
+		name = *worker
+	}
+	source := submitSource(ctx)
 
-	var done = make(chan struct{}, 1)
+	var done = make(chan bool, 1)
 	select {
-	case api.ethash.remote.submitRateCh <- &hashrate{done: done, rate: uint64(rate), id: id}:
+	case api.ethash.remote.submitRateCh <- &hashrate{done: done, rate: uint64(rate), id: id, source: source, worker: name}:
 	case <-api.ethash.remote.exitCh:
 		return false
 	}
 
 	// Block until hash rate submitted successfully.
-	<-done
-	return true
+	return <-done
 }
 
 // GetHashrate returns the current hashrate for local CPU miner and remote miner.
 func (api *API) GetHashrate() uint64 {
 	return uint64(api.ethash.Hashrate())
 }
+
+// MinerActivity reports the liveness of a single remote miner.
+type MinerActivity struct {
+	Active   bool           `json:"active"`
+	LastSeen hexutil.Uint64 `json:"lastSeen"` // Unix timestamp, zero if never seen
+}
+
+// IsMinerActive returns whether the remote miner with the given id submitted its
+// hash rate within the idle timeout, along with the time it was last seen. As
+// work submissions carry no miner id, only hash rate submissions are tracked.
+func (api *API) IsMinerActive(id common.Hash) (MinerActivity, error) {
+	if api.ethash.remote == nil {
+		return MinerActivity{}, errors.New("not supported")
+	}
+	ping, err := api.ethash.remote.minerLastSeen(id)
+	if err != nil || ping.IsZero() {
+		return MinerActivity{}, err
+	}
+	return MinerActivity{
+		Active:   time.Since(ping) <= minerIdleTimeout,
+		LastSeen: hexutil.Uint64(ping.Unix()),
+	}, nil
+}
+
+// UncleCandidate describes a side block considered as an uncle of the pending
+// block along with its eligibility and reward at the pending height.
+type UncleCandidate struct {
+	Header   *types.Header  `json:"header"`
+	Local    bool           `json:"local"`            // Whether the block was mined locally
+	Depth    hexutil.Uint64 `json:"depth"`            // Pending height minus uncle height
+	Included bool           `json:"included"`         // Whether the pending block includes the uncle
+	Eligible bool           `json:"eligible"`         // Whether the uncle is or may be included
+	Reason   string         `json:"reason,omitempty"` // Why the uncle is ineligible
+	Reward   *hexutil.Big   `json:"reward"`           // Uncle reward if included, nil if ineligible
+}
+
+// RewardBreakdown itemizes the coinbase credit of sealing a work package. The
+// net coinbase credit is the subsidy and uncle inclusion rewards plus the
+// transaction fees minus the burnt base fee, plus the MEV profit.
+type RewardBreakdown struct {
+	Subsidy      *hexutil.Big `json:"subsidy"`      // Static block reward
+	Fees         *hexutil.Big `json:"fees"`         // Total fees paid by the transactions
+	Burnt        *hexutil.Big `json:"burnt"`        // Base fee burnt, part of the fees
+	MEV          *hexutil.Big `json:"mev"`          // MEV profit, as reported in result[10]
+	UncleRewards *hexutil.Big `json:"uncleRewards"` // Rewards for including uncles
+	Net          *hexutil.Big `json:"net"`          // Net coinbase credit
+}
+
+// GetWorkRewardBreakdown returns the itemized coinbase credit of the current work
+// package. The fees are only known for blocks assembled by this engine.
+func (api *API) GetWorkRewardBreakdown() (RewardBreakdown, error) {
+	if api.ethash.remote == nil || api.chain == nil || api.ethash.fees == nil {
+		return RewardBreakdown{}, errors.New("not supported")
+	}
+	work, err := api.GetWork()
+	if err != nil {
+		return RewardBreakdown{}, err
+	}
+	hash := common.HexToHash(work[0])
+	block, err := api.ethash.remote.retainedBlock(hash)
+	if err != nil {
+		return RewardBreakdown{}, err
+	}
+	fees, ok := api.ethash.fees.Get(hash)
+	if !ok {
+		return RewardBreakdown{}, errFeesUnknown
+	}
+	tips := fees.tips
+	var (
+		subsidy = blockReward(api.chain.Config(), block.Number())
+		uncles  = new(big.Int).Mul(new(big.Int).Div(subsidy, big32), big.NewInt(int64(len(block.Uncles()))))
+		burnt   = new(big.Int)
+		mev     = new(big.Int) // MEV profit is not tracked yet, result[10] is always zero
+	)
+	if baseFee := block.BaseFee(); baseFee != nil {
+		burnt.Mul(baseFee, new(big.Int).SetUint64(block.GasUsed()))
+	}
+	net := new(big.Int).Add(subsidy, uncles)
+	net.Add(net, tips)
+	net.Add(net, mev)
+
+	return RewardBreakdown{
+		Subsidy:      (*hexutil.Big)(subsidy),
+		Fees:         (*hexutil.Big)(new(big.Int).Add(tips, burnt)),
+		Burnt:        (*hexutil.Big)(burnt),
+		MEV:          (*hexutil.Big)(mev),
+		UncleRewards: (*hexutil.Big)(uncles),
+		Net:          (*hexutil.Big)(net),
+	}, nil
+}
+
+// WorkProfit itemizes the transaction fees and transfers a work package's block
+// credits the coinbase with, besides the block and uncle rewards.
+type WorkProfit struct {
+	BaseFeeBurned     *hexutil.Big   `json:"baseFeeBurned"`
+	PriorityFees      *hexutil.Big   `json:"priorityFees"`
+	CoinbaseTransfers *hexutil.Big   `json:"coinbaseTransfers"` // Nil if the parent state is unavailable
+	Bundles           []BundleProfit `json:"bundles"`           // Profit attributed to included bundles
+}
+
+// BundleProfit is the coinbase profit attributed to a transaction bundle.
+type BundleProfit struct {
+	Hash   common.Hash  `json:"hash"`
+	Profit *hexutil.Big `json:"profit"`
+}
+
+// GetWorkProfit returns the profit breakdown of the block of the current work
+// package: the burnt base fee, the priority fees and the value transferred to
+// the coinbase directly by transactions. It's only known for blocks assembled
+// by this engine. Bundles included by the miner are not attributed by the
+// engine, the list is empty until it learns of them.
+func (api *API) GetWorkProfit() (WorkProfit, error) {
+	if api.ethash.remote == nil || api.ethash.fees == nil {
+		return WorkProfit{}, errors.New("not supported")
+	}
+	work, err := api.GetWork()
+	if err != nil {
+		return WorkProfit{}, err
+	}
+	hash := common.HexToHash(work[0])
+	block, err := api.ethash.remote.retainedBlock(hash)
+	if err != nil {
+		return WorkProfit{}, err
+	}
+	fees, ok := api.ethash.fees.Get(hash)
+	if !ok {
+		return WorkProfit{}, errFeesUnknown
+	}
+	burnt := new(big.Int)
+	if baseFee := block.BaseFee(); baseFee != nil {
+		burnt.Mul(baseFee, new(big.Int).SetUint64(block.GasUsed()))
+	}
+	return WorkProfit{
+		BaseFeeBurned:     (*hexutil.Big)(burnt),
+		PriorityFees:      (*hexutil.Big)(new(big.Int).Set(fees.tips)),
+		CoinbaseTransfers: (*hexutil.Big)(fees.transfers),
+		Bundles:           []BundleProfit{},
+	}, nil
+}
+
+// maxUncleDepth is the maximum distance between an including block and an
+// uncle, as the uncle's parent must be among the last seven ancestors.
+const maxUncleDepth = 6
+
+// GetEligibleUncles returns the local and remote side blocks the miner considers
+// as uncles of the pending block, newest first, with their eligibility and
+// reward at the pending height. An empty list is returned if there are none.
+func (api *API) GetEligibleUncles() ([]UncleCandidate, error) {
+	api.ethash.lock.Lock()
+	source := api.ethash.uncleSource
+	api.ethash.lock.Unlock()
+
+	if source == nil || api.chain == nil {
+		return nil, errors.New("not supported")
+	}
+	pending, uncles, err := source()
+	if err != nil {
+		return nil, err
+	}
+	var (
+		number     = pending.Number
+		reward     = blockReward(api.chain.Config(), number)
+		candidates = make([]UncleCandidate, 0, len(uncles))
+	)
+	for _, uncle := range uncles {
+		candidate := UncleCandidate{
+			Header:   uncle.Header,
+			Local:    uncle.Local,
+			Included: uncle.Included,
+		}
+		var depth uint64
+		if uncle.Header.Number.Cmp(number) < 0 {
+			depth = new(big.Int).Sub(number, uncle.Header.Number).Uint64()
+			candidate.Depth = hexutil.Uint64(depth)
+		}
+		switch {
+		case depth == 0 || depth > maxUncleDepth:
+			candidate.Reason = fmt.Sprintf("depth %d outside [1, %d]", depth, maxUncleDepth)
+		case uncle.Err != nil:
+			candidate.Reason = uncle.Err.Error()
+		default:
+			candidate.Eligible = true
+			candidate.Reward = (*hexutil.Big)(uncleReward(reward, number, uncle.Header.Number))
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates, nil
+}
+
+// GetOrphanStats returns, per coinbase, how many of the blocks this node sealed
+// within the given number of blocks up to the chain head became canonical,
+// were included as uncles, or got orphaned.
+func (api *API) GetOrphanStats(window hexutil.Uint64) (map[common.Address]*OrphanStats, error) {
+	if api.chain == nil {
+		return nil, errors.New("not supported")
+	}
+	return api.ethash.orphanStats(api.chain, uint64(window)), nil
+}
+
+// GetMinerStats returns the share ledger of the miner with the given id: its
+// accepted, stale and invalid shares, the time of its last submission and the
+// hash rate estimated from the difficulty of its accepted shares. Only shares
+// submitted with an id are accounted, miners are forgotten after an hour of
+// inactivity.
+func (api *API) GetMinerStats(id common.Hash) (MinerStats, error) {
+	if api.ethash.remote == nil {
+		return MinerStats{}, errors.New("not supported")
+	}
+	return api.ethash.remote.minerStats(id)
+}
+
+// GetWorkers returns the statistics of all remote workers seen within the last
+// hour, identified by the miner id and the worker name they submit with.
+func (api *API) GetWorkers() ([]WorkerStats, error) {
+	if api.ethash.remote == nil {
+		return nil, errors.New("not supported")
+	}
+	return api.ethash.remote.workerList()
+}
+
+// GetNonceDistribution returns the spread of the low bits of the nonces
+// submitted by the miner with the given id. Miners actually searching spread
+// evenly across the buckets, while replayed nonces pile up in a single one.
+func (api *API) GetNonceDistribution(id common.Hash) (NonceHistogram, error) {
+	if api.ethash.remote == nil {
+		return NonceHistogram{}, errors.New("not supported")
+	}
+	return api.ethash.remote.nonceDistribution(id)
+}
+
+// GetLocalMiningStats returns the search effort of the local CPU miner: the
+// total nonces tried, blocks found, current search nonce and the time spent on
+// the current search since the last seal request, along with the CPU placement
+// and hash rate of every search thread.
+func (api *API) GetLocalMiningStats() LocalMiningStats {
+	if api.ethash.shared != nil {
+		return api.ethash.shared.localStats.snapshot()
+	}
+	return api.ethash.localStats.snapshot()
+}
+
+// GetBlocksFoundCount returns the number of blocks sealed since startup, either
+// by the local miner or from accepted remote solutions. Shares not solving the
+// block difficulty are not counted.
+func (api *API) GetBlocksFoundCount() uint64 {
+	if api.ethash.shared != nil {
+		return api.ethash.shared.blocksFound.Load()
+	}
+	return api.ethash.blocksFound.Load()
+}
+
+// ValidateBlockBody checks that a block body assembled by an external party
+// matches the transaction root, uncle hash, gas used and transaction count
+// committed to by the work package with the given pow-hash. The first mismatch
+// found is returned as an error.
+func (api *API) ValidateBlockBody(workHash common.Hash, body *types.Body) error {
+	if api.ethash.remote == nil {
+		return errors.New("not supported")
+	}
+	block, err := api.ethash.remote.retainedBlock(workHash)
+	if err != nil {
+		return err
+	}
+	if have, want := len(body.Transactions), len(block.Transactions()); have != want {
+		return fmt.Errorf("transaction count mismatch: have %d, want %d", have, want)
+	}
+	return validateBody(block.Header(), body)
+}
+
+// GetStatsSnapshot returns the aggregate and per miner hash rates, the submitted
+// solutions by classification, the last block found, the current difficulty and
+// the dataset status, all captured at the same point in time.
+func (api *API) GetStatsSnapshot() StatsSnapshot {
+	if api.ethash.remote == nil {
+		return StatsSnapshot{Hashrate: hexutil.Uint64(api.ethash.Hashrate())}
+	}
+	res := make(chan StatsSnapshot, 1)
+	select {
+	case api.ethash.remote.fetchStatsCh <- res:
+	case <-api.ethash.remote.exitCh:
+		return StatsSnapshot{Hashrate: hexutil.Uint64(api.ethash.hashrate.Rate1())}
+	}
+	return <-res
+}
+
+// GetNextBlockMEVEstimate returns the node's forecast of the MEV available to the
+// upcoming block based on the current transaction pool. Unlike the realized MEV
+// profit of the current work in result[10], this is only an estimate and may
+// change with every transaction entering or leaving the pool.
+func (api *API) GetNextBlockMEVEstimate() (*hexutil.Big, error) {
+	api.ethash.lock.Lock()
+	estimator := api.ethash.mevEstimator
+	api.ethash.lock.Unlock()
+
+	if estimator == nil {
+		return nil, errMEVDisabled
+	}
+	mev, err := estimator()
+	if err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(mev), nil
+}
+
+// GenerateWorkFromHeader assembles the work package a miner would be served for
+// the given header, without requiring it to be the node's pending block. As no
+// body is available, the transaction and uncle counts are reported as zero.
+func (api *API) GenerateWorkFromHeader(header *types.Header) (WorkPackage, error) {
+	if header.Number == nil {
+		return WorkPackage{}, errors.New("header number missing")
+	}
+	if header.Difficulty == nil || header.Difficulty.Sign() <= 0 {
+		return WorkPackage{}, errInvalidDifficulty
+	}
+	return api.ethash.makeWorkPackage(types.NewBlockWithHeader(header))
+}
+
+// ExpectedSharesPerBlock returns the number of shares at the given share
+// difficulty expected to be found before one of them also solves the block
+// difficulty, as each hash meets a difficulty D with probability 1/D.
+func (api *API) ExpectedSharesPerBlock(shareDifficulty, blockDifficulty *hexutil.Big) (float64, error) {
+	if shareDifficulty == nil || shareDifficulty.ToInt().Sign() <= 0 {
+		return 0, errors.New("share difficulty must be positive")
+	}
+	if blockDifficulty == nil || blockDifficulty.ToInt().Sign() <= 0 {
+		return 0, errInvalidDifficulty
+	}
+	if shareDifficulty.ToInt().Cmp(blockDifficulty.ToInt()) > 0 {
+		return 0, fmt.Errorf("share difficulty %v above block difficulty %v", shareDifficulty.ToInt(), blockDifficulty.ToInt())
+	}
+	shares, _ := new(big.Float).Quo(new(big.Float).SetInt(blockDifficulty.ToInt()), new(big.Float).SetInt(shareDifficulty.ToInt())).Float64()
+	return shares, nil
+}
+
+// GetClockSkew returns how far the local clock is ahead of the chain based on
+// the timestamps of the recent headers, negative if it's behind. Both the
+// difficulty and the future block rejection depend on the local clock.
+func (api *API) GetClockSkew() (time.Duration, error) {
+	if api.chain == nil {
+		return 0, errors.New("not supported")
+	}
+	return clockSkew(api.chain, time.Now())
+}
+
+// GetRecentVerifyFailures returns the most recent headers failing seal
+// verification, oldest first. Failures spread across many peers hint at a
+// corrupted local cache rather than a single misbehaving peer.
+func (api *API) GetRecentVerifyFailures() []VerifyFailure {
+	return api.ethash.verifyFailures.list()
+}
+
+// maxSimulatedBlocks is the maximum number of blocks SimulateDifficulty
+// simulates.
+const maxSimulatedBlocks = 1024
+
+// SimulateDifficulty applies the difficulty adjustment to a scenario of blocks
+// following the current head, starting from the given difficulty. Block i is
+// mined blockTimes[i] seconds after its parent, and includes uncles if
+// uncleFlags[i] is set, which affects the difficulty of its child. The
+// difficulty of every simulated block is returned. Scenarios longer than 1024
+// blocks are rejected.
+func (api *API) SimulateDifficulty(startDifficulty *hexutil.Big, blockTimes []hexutil.Uint64, uncleFlags []bool) ([]*hexutil.Big, error) {
+	if api.chain == nil {
+		return nil, errors.New("not supported")
+	}
+	if startDifficulty == nil || startDifficulty.ToInt().Sign() <= 0 {
+		return nil, errInvalidDifficulty
+	}
+	if len(blockTimes) != len(uncleFlags) {
+		return nil, fmt.Errorf("scenario length mismatch: %d block times, %d uncle flags", len(blockTimes), len(uncleFlags))
+	}
+	if len(blockTimes) > maxSimulatedBlocks {
+		return nil, errTooManyBlocks
+	}
+	head := api.chain.CurrentHeader()
+	parent := &types.Header{
+		Number:     head.Number,
+		Time:       head.Time,
+		Difficulty: startDifficulty.ToInt(),
+		UncleHash:  types.EmptyUncleHash,
+	}
+	difficulties := make([]*hexutil.Big, len(blockTimes))
+	for i, blockTime := range blockTimes {
+		header := &types.Header{
+			Number:    new(big.Int).Add(parent.Number, big1),
+			Time:      parent.Time + uint64(blockTime),
+			UncleHash: types.EmptyUncleHash,
+		}
+		if uncleFlags[i] {
+			header.UncleHash = common.Hash{0x01} // Any non-empty uncle hash
+		}
+		header.Difficulty = api.ethash.CalcDifficulty(api.chain, header.Time, parent)
+		difficulties[i] = (*hexutil.Big)(header.Difficulty)
+		parent = header
+	}
+	return difficulties, nil
+}
+
+// EpochBoundary describes the first block of an upcoming epoch, at which a new
+// cache and dataset are needed.
+type EpochBoundary struct {
+	Epoch  hexutil.Uint64 `json:"epoch"`
+	Number hexutil.Uint64 `json:"number"`
+	Time   hexutil.Uint64 `json:"time"` // Estimated Unix timestamp of the block
+}
+
+// maxEpochSchedule is the maximum number of epoch boundaries GetEpochSchedule
+// returns.
+const maxEpochSchedule = 64
+
+// GetEpochSchedule returns the next count epoch boundaries following the current
+// head, with their timestamps estimated from the target block time. The count
+// is capped at 64.
+func (api *API) GetEpochSchedule(count int) ([]EpochBoundary, error) {
+	if api.chain == nil {
+		return nil, errors.New("not supported")
+	}
+	if count <= 0 {
+		return nil, errors.New("count must be positive")
+	}
+	if count > maxEpochSchedule {
+		count = maxEpochSchedule
+	}
+	var (
+		head     = api.chain.CurrentHeader()
+		number   = head.Number.Uint64()
+		interval = uint64(api.GetDifficultyParams().TargetBlockTime)
+		schedule = make([]EpochBoundary, count)
+	)
+	for i := range schedule {
+		epoch := number/epochLength + 1 + uint64(i)
+		schedule[i] = EpochBoundary{
+			Epoch:  hexutil.Uint64(epoch),
+			Number: hexutil.Uint64(epoch * epochLength),
+			Time:   hexutil.Uint64(head.Time + (epoch*epochLength-number)*interval),
+		}
+	}
+	return schedule, nil
+}
+
+// GetDifficultyParams returns the parameters of the difficulty adjustment in
+// effect for the next block: the algorithm variant, target block time, bound
+// divisor, difficulty floor and the bomb parameters.
+func (api *API) GetDifficultyParams() DifficultyParams {
+	if api.chain == nil {
+		return DifficultyParams{Algorithm: "unknown"}
+	}
+	next := new(big.Int).Add(api.chain.CurrentHeader().Number, big1)
+	return difficultyParams(api.chain.Config(), next)
+}
+
+// DAGGenerationStatus describes the mining datasets around the chain head.
+type DAGGenerationStatus struct {
+	Epoch        hexutil.Uint64 `json:"epoch"`        // Epoch of the next block to mine
+	NextEpoch    hexutil.Uint64 `json:"nextEpoch"`    // Epoch following it
+	Progress     float64        `json:"progress"`     // Generation progress of the epoch's dataset in percents
+	NextProgress float64        `json:"nextProgress"` // Generation progress of the next epoch's dataset in percents
+	DiskUsage    hexutil.Uint64 `json:"diskUsage"`    // Bytes taken up by datasets stored on disk
+}
+
+// GetDAGStatus returns the generation progress of the mining datasets of the
+// epoch of the next block to mine and the one after it, along with the disk
+// usage of the stored datasets.
+func (api *API) GetDAGStatus() (DAGGenerationStatus, error) {
+	if api.chain == nil {
+		return DAGGenerationStatus{}, errors.New("not supported")
+	}
+	epoch := (api.chain.CurrentHeader().Number.Uint64() + 1) / epochLength
+	return DAGGenerationStatus{
+		Epoch:        hexutil.Uint64(epoch),
+		NextEpoch:    hexutil.Uint64(epoch + 1),
+		Progress:     api.ethash.datasetProgress(epoch),
+		NextProgress: api.ethash.datasetProgress(epoch + 1),
+		DiskUsage:    hexutil.Uint64(api.ethash.datasetDiskUsage()),
+	}, nil
+}
+
+// GenerateDAG starts building the mining dataset of the given epoch in the
+// background, so mining doesn't stall on it at the epoch transition. Only the
+// datasets of the epoch of the next block to mine and the one after it can be
+// generated.
+func (api *API) GenerateDAG(epoch hexutil.Uint64) (bool, error) {
+	if api.chain == nil {
+		return false, errors.New("not supported")
+	}
+	current := (api.chain.CurrentHeader().Number.Uint64() + 1) / epochLength
+	if uint64(epoch) != current && uint64(epoch) != current+1 {
+		return false, fmt.Errorf("epoch %d outside of current epoch %d and the next", epoch, current)
+	}
+	api.ethash.pregenerateDataset(uint64(epoch))
+	return true, nil
+}
+
+// submitSource returns the remote host of the RPC client submitting to the
+// remote sealer, empty if unknown.
+func submitSource(ctx context.Context) string {
+	source := rpc.PeerInfoFromContext(ctx).RemoteAddr
+	if host, _, err := net.SplitHostPort(source); err == nil {
+		source = host // Connections from the same host differ in port
+	}
+	return source
+}
+
+// SubmissionPage is a page of the submission audit log.
+type SubmissionPage struct {
+	Records []SubmissionRecord `json:"records"`
+	Next    hexutil.Uint64     `json:"next"` // Sequence number to continue from
+}
+
+// GetSubmissionLog returns up to count records of the submission audit log,
+// starting at the given sequence number. Paging continues from the returned
+// next sequence number.
+func (api *API) GetSubmissionLog(start, count hexutil.Uint64) (SubmissionPage, error) {
+	if api.ethash.submissions == nil {
+		return SubmissionPage{}, errors.New("not supported")
+	}
+	if count > maxSubmissionPage {
+		count = maxSubmissionPage
+	}
+	records, err := api.ethash.submissions.page(uint64(start), uint64(count))
+	if err != nil {
+		return SubmissionPage{}, err
+	}
+	next := start
+	if len(records) > 0 {
+		next = records[len(records)-1].Seq + 1
+	}
+	return SubmissionPage{Records: records, Next: next}, nil
+}
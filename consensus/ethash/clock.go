@@ -0,0 +1,92 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package ethash
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+)
+
+const (
+	clockSkewHeaders       = 11          // Number of recent headers to estimate the clock skew from
+	clockSkewCheckInterval = time.Minute // Interval between periodic clock skew checks
+)
+
+var errClockSkewUnknown = errors.New("not enough headers to estimate clock skew")
+
+// clockSkew estimates how far the local clock is ahead of the chain, negative if
+// it's behind. Each of the recent headers projects the head's timestamp using
+// the median block interval, the median of which is compared to the local
+// time. A node in sync thus sees a skew of up to one block interval.
+func clockSkew(chain consensus.ChainHeaderReader, now time.Time) (time.Duration, error) {
+	head := chain.CurrentHeader()
+	if head == nil || head.Number.Uint64() < clockSkewHeaders {
+		return 0, errClockSkewUnknown
+	}
+	headers := make([]uint64, 0, clockSkewHeaders)
+	for header := head; header != nil && len(headers) < clockSkewHeaders; header = chain.GetHeader(header.ParentHash, header.Number.Uint64()-1) {
+		headers = append(headers, header.Time)
+	}
+	if len(headers) < clockSkewHeaders {
+		return 0, errClockSkewUnknown
+	}
+	// Headers are collected from the head backwards, so the i-th one is i
+	// intervals behind the head
+	intervals := make([]float64, len(headers)-1)
+	for i := range intervals {
+		intervals[i] = float64(headers[i]) - float64(headers[i+1])
+	}
+	sort.Float64s(intervals)
+	interval := intervals[len(intervals)/2]
+
+	projected := make([]float64, len(headers))
+	for i, timestamp := range headers {
+		projected[i] = float64(timestamp) + float64(i)*interval
+	}
+	sort.Float64s(projected)
+	median := projected[len(projected)/2]
+
+	return now.Sub(time.Unix(0, int64(median*float64(time.Second)))), nil
+}
+
+// MonitorClockSkew starts periodically estimating the local clock skew against
+// the given chain, warning if it exceeds ClockSkewThreshold. The monitor stops
+// when the engine is closed.
+func (ethash *Ethash) MonitorClockSkew(chain consensus.ChainHeaderReader) {
+	if ethash.remote == nil {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(clockSkewCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				skew, err := clockSkew(chain, time.Now())
+				if err != nil {
+					continue
+				}
+				if skew > ethash.config.ClockSkewThreshold || -skew > ethash.config.ClockSkewThreshold {
+					ethash.config.Log.Warn("Local clock skewed from chain", "skew", common.PrettyDuration(skew), "threshold", ethash.config.ClockSkewThreshold)
+				}
+			case <-ethash.remote.exitCh:
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,75 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package ethash
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// headerChain is a chain header reader serving a linear chain of headers.
+type headerChain struct {
+	consensus.ChainHeaderReader
+	config  *params.ChainConfig
+	headers map[common.Hash]*types.Header
+	head    *types.Header
+}
+
+func newHeaderChain(n int, start, interval uint64) *headerChain {
+	chain := &headerChain{config: params.TestChainConfig, headers: make(map[common.Hash]*types.Header)}
+	var parent common.Hash
+	for i := 0; i < n; i++ {
+		header := &types.Header{ParentHash: parent, Number: big.NewInt(int64(i)), Time: start + uint64(i)*interval}
+		chain.headers[header.Hash()] = header
+		chain.head, parent = header, header.Hash()
+	}
+	return chain
+}
+
+func (c *headerChain) Config() *params.ChainConfig { return c.config }
+
+func (c *headerChain) CurrentHeader() *types.Header { return c.head }
+
+func (c *headerChain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	return c.headers[hash]
+}
+
+// Tests that the clock skew is estimated from the recent header timestamps.
+func TestClockSkew(t *testing.T) {
+	chain := newHeaderChain(20, 1_000_000, 10)
+	head := time.Unix(int64(chain.head.Time), 0)
+
+	for _, want := range []time.Duration{0, time.Minute, -time.Minute} {
+		skew, err := clockSkew(chain, head.Add(want))
+		if err != nil {
+			t.Fatalf("failed to estimate skew: %v", err)
+		}
+		if skew != want {
+			t.Errorf("skew mismatch: have %v, want %v", skew, want)
+		}
+	}
+	// An outlier timestamp doesn't move the median
+	chain.head.Time += 600
+	if skew, _ := clockSkew(chain, head); skew < -20*time.Second || skew > 20*time.Second {
+		t.Errorf("outlier skewed estimate: have %v", skew)
+	}
+	if _, err := clockSkew(newHeaderChain(5, 0, 10), head); err != errClockSkewUnknown {
+		t.Errorf("short chain error mismatch: have %v, want %v", err, errClockSkewUnknown)
+	}
+}
@@ -14,6 +14,7 @@ package ethash
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"math/big"
@@ -39,6 +40,8 @@ var (
 	ByzantiumBlockReward          = big.NewInt(1e+18) // Block reward in wei for successfully mining a block upward from Byzantium
 	ConstantinopleBlockReward     = big.NewInt(1e+18) // Block reward in wei for successfully mining a block upward from Constantinople
 	maxUncles                     = 2                 // Maximum number of uncles allowed in a single block
+	feesRetained                  = 64                // Number of assembled blocks to retain the fees of
+	verifiedSealsRetained         = 8192              // Number of verified headers to retain the PoW values of
 	allowedFutureBlockTimeSeconds = int64(15)         // Max seconds from current time allowed for blocks, before they're considered future blocks
 
 	// calcDifficultyEip5133 is the difficulty adjustment algorithm as specified by EIP 5133.
@@ -88,6 +91,7 @@ var (
 	errInvalidDifficulty = errors.New("non-positive difficulty")
 	errInvalidMixDigest  = errors.New("invalid mix digest")
 	errInvalidPoW        = errors.New("invalid proof-of-work")
+	errVerifyTimeout     = errors.New("proof-of-work verification timed out")
 )
 
 // Author implements consensus.Engine, returning the header's coinbase as the
@@ -129,10 +133,14 @@ func (ethash *Ethash) VerifyHeaders(chain consensus.ChainHeaderReader, headers [
 		return abort, results
 	}
 
-	// Spawn as many workers as allowed threads
-	workers := runtime.GOMAXPROCS(0)
-	if len(headers) < workers {
-		workers = len(headers)
+	// Spawn as many workers as tuned, handing them batches of headers
+	var (
+		workers = ethash.verifyWorkers()
+		batch   = ethash.verifyBatchSize(len(headers), workers)
+		tasks   = (len(headers) + batch - 1) / batch
+	)
+	if tasks < workers {
+		workers = tasks
 	}
 
 	// Create a task channel and spawn the verifiers
@@ -145,9 +153,19 @@ func (ethash *Ethash) VerifyHeaders(chain consensus.ChainHeaderReader, headers [
 	)
 	for i := 0; i < workers; i++ {
 		go func() {
-			for index := range inputs {
-				errors[index] = ethash.verifyHeaderWorker(chain, headers, seals, index, unixNow)
-				done <- index
+			for start := range inputs {
+				end := start + batch
+				if end > len(headers) {
+					end = len(headers)
+				}
+				for index := start; index < end; index++ {
+					errors[index] = ethash.verifyHeaderWorker(chain, headers, seals, index, unixNow)
+					select {
+					case done <- index:
+					case <-abort:
+						return
+					}
+				}
 			}
 		}()
 	}
@@ -163,7 +181,7 @@ func (ethash *Ethash) VerifyHeaders(chain consensus.ChainHeaderReader, headers [
 		for {
 			select {
 			case inputs <- in:
-				if in++; in == len(headers) {
+				if in += batch; in >= len(headers) {
 					// Reached end of headers. Stop sending to workers.
 					inputs = nil
 				}
@@ -315,6 +333,7 @@ func (ethash *Ethash) verifyHeader(chain consensus.ChainHeaderReader, header, pa
 	// Verify the engine specific seal securing the block
 	if seal {
 		if err := ethash.verifySeal(chain, header, false); err != nil {
+			ethash.recordVerifyFailure(header, err)
 			return err
 		}
 	}
@@ -332,6 +351,27 @@ func (ethash *Ethash) CalcDifficulty(chain consensus.ChainHeaderReader, time uin
 	return CalcDifficulty(chain.Config(), time, parent)
 }
 
+// VerifyDifficultyChain checks that the difficulty of each header follows from
+// its predecessor, starting with the explicitly given parent of the first one.
+// The seals are not verified. The returned slice holds the result of each
+// header, while the error reports headers not forming a contiguous chain.
+func (ethash *Ethash) VerifyDifficultyChain(chain consensus.ChainHeaderReader, parent *types.Header, headers []*types.Header) ([]error, error) {
+	if parent == nil {
+		return nil, consensus.ErrUnknownAncestor
+	}
+	results := make([]error, len(headers))
+	for i, header := range headers {
+		if header.ParentHash != parent.Hash() || header.Number.Cmp(new(big.Int).Add(parent.Number, big1)) != 0 {
+			return nil, fmt.Errorf("non-contiguous header %d: number %v, parent %x", i, header.Number, header.ParentHash)
+		}
+		if expected := ethash.CalcDifficulty(chain, header.Time, parent); expected.Cmp(header.Difficulty) != 0 {
+			results[i] = fmt.Errorf("invalid difficulty: have %v, want %v", header.Difficulty, expected)
+		}
+		parent = header
+	}
+	return results, nil
+}
+
 // CalcDifficulty is the difficulty adjustment algorithm. It returns
 // the difficulty that a new block should have when created at time
 // given the parent block's time and difficulty.
@@ -503,6 +543,12 @@ var DynamicDifficultyCalculator = makeDifficultyCalculator
 // either using the usual ethash cache for it, or alternatively using a full DAG
 // to make remote mining fast.
 func (ethash *Ethash) verifySeal(chain consensus.ChainHeaderReader, header *types.Header, fulldag bool) error {
+	return ethash.verifySealTarget(chain, header, fulldag, nil)
+}
+
+// verifySealTarget checks whether a block's PoW meets the given target, or the
+// target of the block's difficulty if nil.
+func (ethash *Ethash) verifySealTarget(chain consensus.ChainHeaderReader, header *types.Header, fulldag bool, target *big.Int) error {
 	// If we're running a fake PoW, accept any seal as valid
 	if ethash.config.PowMode == ModeFake || ethash.config.PowMode == ModeFullFake {
 		time.Sleep(ethash.fakeDelay)
@@ -513,13 +559,56 @@ func (ethash *Ethash) verifySeal(chain consensus.ChainHeaderReader, header *type
 	}
 	// If we're running a shared PoW, delegate verification to it
 	if ethash.shared != nil {
-		return ethash.shared.verifySeal(chain, header, fulldag)
+		return ethash.shared.verifySealTarget(chain, header, fulldag, target)
 	}
 	// Ensure that we have a valid difficulty for the block
 	if header.Difficulty.Sign() <= 0 {
 		return errInvalidDifficulty
 	}
-	// Recompute the digest and PoW values
+	if target == nil {
+		target = new(big.Int).Div(two256, header.Difficulty)
+	}
+	// Headers verified before are checked against the memoized PoW values, which
+	// serve any target, so blocks and shares share them
+	hash := header.Hash()
+	if ethash.verified != nil {
+		if values, ok := ethash.verified.Get(hash); ok {
+			verifyCacheHitMeter.Mark(1)
+			return checkPoW(header, values.digest[:], values.result[:], target)
+		}
+		verifyCacheMissMeter.Mark(1)
+	}
+	// Recompute the digest and PoW values, aborting if over the time budget
+	if ethash.config.VerifyTimeout <= 0 {
+		digest, result := ethash.powValues(header, fulldag)
+		ethash.memoizeSeal(hash, digest, result)
+		return checkPoW(header, digest, result, target)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ethash.config.VerifyTimeout)
+	defer cancel()
+
+	var (
+		digest []byte
+		result []byte
+		done   = make(chan struct{})
+		sealed = types.CopyHeader(header) // The computation may outlive the call
+	)
+	go func() {
+		digest, result = ethash.powValues(sealed, fulldag)
+		ethash.memoizeSeal(hash, digest, result)
+		close(done)
+	}()
+	select {
+	case <-done:
+		return checkPoW(header, digest, result, target)
+	case <-ctx.Done():
+		return errVerifyTimeout
+	}
+}
+
+// powValues recomputes the mix digest and PoW result of a header, using a full
+// dataset if requested and already generated.
+func (ethash *Ethash) powValues(header *types.Header, fulldag bool) ([]byte, []byte) {
 	number := header.Number.Uint64()
 
 	var (
@@ -530,10 +619,10 @@ func (ethash *Ethash) verifySeal(chain consensus.ChainHeaderReader, header *type
 	if fulldag {
 		dataset := ethash.dataset(number, true)
 		if dataset.generated() {
-			digest, result = hashimotoFull(dataset.dataset, ethash.SealHash(header).Bytes(), header.Nonce.Uint64())
+			digest, result = hashimotoFullKeccak(defaultKeccak, ethash.mixer(), dataset.dataset, ethash.SealHash(header).Bytes(), header.Nonce.Uint64())
 
 			// Datasets are unmapped in a finalizer. Ensure that the dataset stays alive
-			// until after the call to hashimotoFull so it's not unmapped while being used.
+			// until after the call to hashimotoFullKeccak so it's not unmapped while being used.
 			runtime.KeepAlive(dataset)
 		} else {
 			// Dataset not yet generated, don't hang, use a cache instead
@@ -542,20 +631,21 @@ func (ethash *Ethash) verifySeal(chain consensus.ChainHeaderReader, header *type
 	}
 	// If slow-but-light PoW verification was requested (or DAG not yet ready), use an ethash cache
 	if !fulldag {
-		cache := ethash.cache(number)
-
-		size := datasetSize(number)
-		if ethash.config.PowMode == ModeTest {
-			size = 32 * 1024
-		}
-		digest, result = hashimotoLight(size, cache.cache, ethash.SealHash(header).Bytes(), header.Nonce.Uint64())
+		digest, result = ethash.hashimotoLight(header)
+	}
+	return digest, result
+}
 
-		// Caches are unmapped in a finalizer. Ensure that the cache stays alive
-		// until after the call to hashimotoLight so it's not unmapped while being used.
-		runtime.KeepAlive(cache)
+// memoizeSeal records the PoW values of a verified header.
+func (ethash *Ethash) memoizeSeal(hash common.Hash, digest, result []byte) {
+	if ethash.verified != nil {
+		ethash.verified.Add(hash, shareValues{digest: common.BytesToHash(digest), result: common.BytesToHash(result)})
 	}
+}
 
-	target := new(big.Int).Div(two256, header.Difficulty)
+// checkPoW checks the recomputed PoW result of a header against the target,
+// fixing the mix digest if the PoW is valid.
+func checkPoW(header *types.Header, digest, result []byte, target *big.Int) error {
 	if new(big.Int).SetBytes(result).Cmp(target) > 0 {
 		return errInvalidPoW
 	}
@@ -566,6 +656,37 @@ func (ethash *Ethash) verifySeal(chain consensus.ChainHeaderReader, header *type
 	return nil
 }
 
+// hashimotoLight computes the mix digest and PoW result of the header's nonce
+// using the verification cache of the header's epoch, memoized by the share
+// verifier if available.
+func (ethash *Ethash) hashimotoLight(header *types.Header) ([]byte, []byte) {
+	if ethash.shares != nil {
+		values := ethash.shares.values(ethash.SealHash(header), header.Nonce, header.Number.Uint64())
+		return values.digest.Bytes(), values.result.Bytes()
+	}
+	return ethash.hashimotoLightHash(header.Number.Uint64(), ethash.SealHash(header), header.Nonce.Uint64())
+}
+
+// hashimotoLightHash computes the mix digest and PoW result of a nonce for the
+// pow-hash of a block at the given height using the verification cache.
+func (ethash *Ethash) hashimotoLightHash(number uint64, hash common.Hash, nonce uint64) ([]byte, []byte) {
+	if ethash.shared != nil {
+		return ethash.shared.hashimotoLightHash(number, hash, nonce)
+	}
+	cache := ethash.cache(number)
+
+	size := datasetSize(number)
+	if ethash.config.PowMode == ModeTest {
+		size = 32 * 1024
+	}
+	digest, result := hashimotoLightMix(ethash.mixer(), size, cache.cache, hash.Bytes(), nonce)
+
+	// Caches are unmapped in a finalizer. Ensure that the cache stays alive
+	// until after the call to hashimotoLightMix so it's not unmapped while being used.
+	runtime.KeepAlive(cache)
+	return digest, result
+}
+
 // Prepare implements consensus.Engine, initializing the difficulty field of a
 // header to conform to the ethash protocol. The changes are done inline.
 func (ethash *Ethash) Prepare(chain consensus.ChainHeaderReader, header *types.Header) error {
@@ -578,9 +699,13 @@ func (ethash *Ethash) Prepare(chain consensus.ChainHeaderReader, header *types.H
 }
 
 // Finalize implements consensus.Engine, accumulating the block and uncle rewards.
-func (ethash *Ethash) Finalize(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, uncles []*types.Header, withdrawals []*types.Withdrawal) {
-	// Accumulate any block and uncle rewards
-	accumulateRewards(chain.Config(), state, header, uncles)
+func (ethash *Ethash) Finalize(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, uncles []*types.Header, receipts []*types.Receipt, withdrawals []*types.Withdrawal) {
+	// Accumulate any block and uncle rewards and split them, together with the
+	// priority fees, among the payout recipients if configured by the chain
+	reward := accumulateRewards(chain.Config(), state, header, uncles)
+	if chain.Config().IsPayoutSplit(header.Number) {
+		splitPayout(chain.Config(), state, header, reward.Add(reward, priorityFees(header, txs, receipts)))
+	}
 }
 
 // FinalizeAndAssemble implements consensus.Engine, accumulating the block and
@@ -589,14 +714,62 @@ func (ethash *Ethash) FinalizeAndAssemble(chain consensus.ChainHeaderReader, hea
 	if len(withdrawals) > 0 {
 		return nil, errors.New("ethash does not support withdrawals")
 	}
+	// Capture the fees credited by the transactions before adding the rewards
+	var fees *assembledFees
+	if ethash.fees != nil {
+		fees = &assembledFees{tips: priorityFees(header, txs, receipts)}
+		fees.transfers = coinbaseTransfers(chain, header, state, fees.tips)
+	}
 	// Finalize block
-	ethash.Finalize(chain, header, state, txs, uncles, nil)
+	ethash.Finalize(chain, header, state, txs, uncles, receipts, nil)
 
 	// Assign the final state root to header.
 	header.Root = state.IntermediateRoot(chain.Config().IsEIP158(header.Number))
 
 	// Header seems complete, assemble into a block and return
-	return types.NewBlock(header, txs, uncles, receipts, trie.NewStackTrie(nil)), nil
+	block := types.NewBlock(header, txs, uncles, receipts, trie.NewStackTrie(nil))
+	if fees != nil {
+		ethash.fees.Add(ethash.SealHash(block.Header()), fees)
+	}
+	return block, nil
+}
+
+// assembledFees are the fees credited to the coinbase by the transactions of a
+// block assembled by this engine.
+type assembledFees struct {
+	tips      *big.Int // Priority fees
+	transfers *big.Int // Direct coinbase transfers, nil if unknown
+}
+
+// coinbaseTransfers returns the value transferred to the coinbase by the block's
+// transactions besides the priority fees, from the coinbase balance change
+// against the parent state. Transactions sent by the coinbase itself reduce it.
+// Nil is returned if the parent state is unavailable.
+func coinbaseTransfers(chain consensus.ChainHeaderReader, header *types.Header, statedb *state.StateDB, tips *big.Int) *big.Int {
+	parent := chain.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	if parent == nil {
+		return nil
+	}
+	parentState, err := state.New(parent.Root, statedb.Database(), nil)
+	if err != nil {
+		return nil
+	}
+	transfers := new(big.Int).Sub(statedb.GetBalance(header.Coinbase), parentState.GetBalance(header.Coinbase))
+	return transfers.Sub(transfers, tips)
+}
+
+// priorityFees sums the fees credited to the coinbase by the transactions of a
+// block, excluding the burnt base fee.
+func priorityFees(header *types.Header, txs []*types.Transaction, receipts []*types.Receipt) *big.Int {
+	fees := new(big.Int)
+	for i, tx := range txs {
+		if i >= len(receipts) {
+			break
+		}
+		tip := tx.EffectiveGasTipValue(header.BaseFee)
+		fees.Add(fees, tip.Mul(tip, new(big.Int).SetUint64(receipts[i].GasUsed)))
+	}
+	return fees
 }
 
 // SealHash returns the hash of a block prior to it being sealed.
@@ -638,27 +811,54 @@ var (
 // AccumulateRewards credits the coinbase of the given block with the mining
 // reward. The total reward consists of the static block reward and rewards for
 // included uncles. The coinbase of each uncle block is also rewarded.
-func accumulateRewards(config *params.ChainConfig, state *state.StateDB, header *types.Header, uncles []*types.Header) {
+// The reward credited to the coinbase of the block is returned.
+func accumulateRewards(config *params.ChainConfig, state *state.StateDB, header *types.Header, uncles []*types.Header) *big.Int {
 	// Select the correct block reward based on chain progression
-	blockReward := FrontierBlockReward
-	if config.IsByzantium(header.Number) {
-		blockReward = ByzantiumBlockReward
-	}
-	if config.IsConstantinople(header.Number) {
-		blockReward = ConstantinopleBlockReward
-	}
+	blockReward := blockReward(config, header.Number)
+
 	// Accumulate the rewards for the miner and any included uncles
 	reward := new(big.Int).Set(blockReward)
 	r := new(big.Int)
 	for _, uncle := range uncles {
-		r.Add(uncle.Number, big8)
-		r.Sub(r, header.Number)
-		r.Mul(r, blockReward)
-		r.Div(r, big8)
-		state.AddBalance(uncle.Coinbase, r)
+		state.AddBalance(uncle.Coinbase, uncleReward(blockReward, header.Number, uncle.Number))
 
 		r.Div(blockReward, big32)
 		reward.Add(reward, r)
 	}
 	state.AddBalance(header.Coinbase, reward)
+	return reward
+}
+
+// UncleInclusionReward returns the reward the miner of the block at the given
+// number earns for each uncle included.
+func UncleInclusionReward(config *params.ChainConfig, number *big.Int) *big.Int {
+	return new(big.Int).Div(blockReward(config, number), big32)
+}
+
+// BlockReward returns the static reward of sealing the block at the given
+// number, excluding uncle inclusion rewards and fees.
+func BlockReward(config *params.ChainConfig, number *big.Int) *big.Int {
+	return new(big.Int).Set(blockReward(config, number))
+}
+
+// blockReward returns the static block reward at the given block number.
+func blockReward(config *params.ChainConfig, number *big.Int) *big.Int {
+	switch {
+	case config.IsConstantinople(number):
+		return ConstantinopleBlockReward
+	case config.IsByzantium(number):
+		return ByzantiumBlockReward
+	default:
+		return FrontierBlockReward
+	}
+}
+
+// uncleReward returns the reward of an uncle at height uncle included in the
+// block at height number, decreasing by an eighth of the block reward for each
+// block of depth.
+func uncleReward(blockReward *big.Int, number, uncle *big.Int) *big.Int {
+	r := new(big.Int).Add(uncle, big8)
+	r.Sub(r, number)
+	r.Mul(r, blockReward)
+	return r.Div(r, big8)
 }
@@ -20,10 +20,17 @@ import (
 	"math/rand"
 	"os"
 	"path/filepath"
+	"reflect"
+	"runtime"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/params"
 )
@@ -181,3 +188,342 @@ func BenchmarkDifficultyCalculator(b *testing.B) {
 		}
 	})
 }
+
+// Tests that the reported difficulty parameters follow the fork rules.
+func TestDifficultyParams(t *testing.T) {
+	frontier := difficultyParams(&params.ChainConfig{}, big.NewInt(1))
+	if frontier.Algorithm != "frontier" || !frontier.Bomb || frontier.TargetBlockTime != 13 {
+		t.Errorf("frontier params mismatch: have %+v", frontier)
+	}
+	byzantium := difficultyParams(&params.ChainConfig{HomesteadBlock: common.Big0, ByzantiumBlock: common.Big0}, big.NewInt(1))
+	if byzantium.Algorithm != "byzantium" || byzantium.Bomb || byzantium.TargetBlockTime != 9 {
+		t.Errorf("byzantium params mismatch: have %+v", byzantium)
+	}
+}
+
+// Tests that seal verifications exceeding the time budget are aborted.
+func TestVerifyTimeout(t *testing.T) {
+	// Generating a full size verification cache takes far longer than the budget
+	ethash := New(Config{PowMode: ModeNormal, CachesInMem: 1, VerifyTimeout: time.Millisecond}, nil, true)
+	defer ethash.Close()
+
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100)}
+	start := time.Now()
+	if err := ethash.verifySeal(nil, header, false); err != errVerifyTimeout {
+		t.Fatalf("slow verification error mismatch: have %v, want %v", err, errVerifyTimeout)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("verification not aborted in time: took %v", elapsed)
+	}
+	// Verifications within the budget complete as usual
+	ethash = New(Config{PowMode: ModeTest, VerifyTimeout: time.Minute}, nil, true)
+	defer ethash.Close()
+
+	if err := ethash.verifySeal(nil, header, false); err == errVerifyTimeout {
+		t.Fatalf("fast verification timed out")
+	}
+}
+
+// Tests that the PoW values of verified headers are memoized by header hash and
+// serve later verifications of blocks and shares alike.
+func TestVerifiedSealCache(t *testing.T) {
+	ethash := NewTester(nil, true)
+	defer ethash.Close()
+
+	results := make(chan types.SealResult, 1)
+	if err := ethash.Seal(nil, types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100)}), results, nil); err != nil {
+		t.Fatalf("failed to seal block: %v", err)
+	}
+	var header *types.Header
+	select {
+	case result := <-results:
+		header = result.Block.Header()
+	case <-time.After(4 * time.Second):
+		t.Fatalf("sealing result timeout")
+	}
+	if err := ethash.verifySeal(nil, header, false); err != nil {
+		t.Fatalf("failed to verify sealed header: %v", err)
+	}
+	values, ok := ethash.verified.Get(header.Hash())
+	if !ok || values.digest != header.MixDigest {
+		t.Fatalf("verified seal not memoized: %+v", values)
+	}
+	// Invalid seals are memoized too
+	invalid := types.CopyHeader(header)
+	invalid.Nonce = types.EncodeNonce(header.Nonce.Uint64() + 1)
+	invalid.Difficulty = new(big.Int).Lsh(common.Big1, 255)
+	if err := ethash.verifySeal(nil, invalid, false); err != errInvalidPoW {
+		t.Fatalf("invalid seal error mismatch: have %v, want %v", err, errInvalidPoW)
+	}
+	if ethash.verified.Len() != 2 {
+		t.Fatalf("memoized seals mismatch: have %d, want 2", ethash.verified.Len())
+	}
+	// Later verifications are served from the memoized values, at any target
+	values.result = common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+	ethash.verified.Add(header.Hash(), values)
+	if err := ethash.verifySeal(nil, header, false); err != errInvalidPoW {
+		t.Errorf("verification not served from memoized values: have %v, want %v", err, errInvalidPoW)
+	}
+	if err := ethash.verifySealTarget(nil, header, false, values.result.Big()); err != nil {
+		t.Errorf("share verification not served from memoized values: %v", err)
+	}
+}
+
+// Tests that the header verification concurrency is validated and auto-tuned
+// around sealing, and that batched verification reports results in order.
+func TestVerifyConcurrency(t *testing.T) {
+	ethash := NewFaker()
+	if err := ethash.SetVerifyConcurrency(-1, 0); err != errInvalidVerifyWorkers {
+		t.Errorf("negative workers error mismatch: have %v, want %v", err, errInvalidVerifyWorkers)
+	}
+	if err := ethash.SetVerifyConcurrency(0, maxVerifyBatchSize+1); err != errInvalidVerifyBatchSize {
+		t.Errorf("oversized batch error mismatch: have %v, want %v", err, errInvalidVerifyBatchSize)
+	}
+	// Auto-tuning leaves the cores of the local threads and the remote sealer
+	cores := runtime.GOMAXPROCS(0)
+	if workers := ethash.verifyWorkers(); workers != cores {
+		t.Errorf("idle workers mismatch: have %d, want %d", workers, cores)
+	}
+	ethash.localStats.running.Store(2)
+	ethash.remoteWork.Store(time.Now().UnixNano())
+	want := cores - 3
+	if want < 1 {
+		want = 1
+	}
+	if status := ethash.VerifyConcurrency(); status.EffectiveWorkers != want || status.MiningThreads != 2 || !status.RemoteSealing {
+		t.Errorf("sealing concurrency mismatch: have %+v, want %d workers", status, want)
+	}
+	ethash.localStats.running.Store(0)
+	ethash.remoteWork.Store(time.Now().Add(-sealingActiveWindow).UnixNano())
+	if workers := ethash.verifyWorkers(); workers != cores {
+		t.Errorf("workers not restored after sealing: have %d, want %d", workers, cores)
+	}
+	if batch := ethash.verifyBatchSize(400, 2); batch != 400/(2*verifyBatchesPerWorker) {
+		t.Errorf("auto batch size mismatch: have %d", batch)
+	}
+	if batch := ethash.verifyBatchSize(1<<20, 1); batch != maxAutoVerifyBatchSize {
+		t.Errorf("auto batch size not capped: have %d", batch)
+	}
+	// Verify a header chain broken in the middle with various settings
+	var (
+		chain   = &fakeChainReader{config: &params.ChainConfig{ChainID: big1}, headers: make(map[common.Hash]*types.Header)}
+		parent  = &types.Header{Number: big.NewInt(100), Time: 1000, Difficulty: big.NewInt(1_000_000), GasLimit: params.GenesisGasLimit}
+		headers []*types.Header
+		seals   []bool
+	)
+	chain.headers[parent.Hash()] = parent
+	for i, prev := 0, parent; i < 50; i++ {
+		header := &types.Header{ParentHash: prev.Hash(), Number: new(big.Int).Add(prev.Number, big1), Time: prev.Time + 10, GasLimit: prev.GasLimit}
+		header.Difficulty = ethash.CalcDifficulty(chain, header.Time, prev)
+		if i == 37 {
+			header.ParentHash = common.Hash{0x01}
+		}
+		headers, seals = append(headers, header), append(seals, true)
+		prev = header
+	}
+	for _, setting := range [][2]int{{1, 1}, {3, 7}, {4, 64}, {0, 0}} {
+		if err := ethash.SetVerifyConcurrency(setting[0], setting[1]); err != nil {
+			t.Fatalf("failed to set concurrency %v: %v", setting, err)
+		}
+		abort, results := ethash.VerifyHeaders(chain, headers, seals)
+		for i := range headers {
+			select {
+			case err := <-results:
+				if (err != nil) != (i == 37) {
+					t.Errorf("setting %v, header %d: unexpected result %v", setting, i, err)
+				}
+			case <-time.After(4 * time.Second):
+				t.Fatalf("setting %v, header %d: verification timeout", setting, i)
+			}
+		}
+		close(abort)
+	}
+}
+
+// Tests that the difficulty of a header chain is checked against each parent.
+func TestVerifyDifficultyChain(t *testing.T) {
+	var (
+		ethash  = NewFaker()
+		chain   = &fakeChainReader{config: params.TestChainConfig}
+		parent  = &types.Header{Number: big.NewInt(100), Time: 1000, Difficulty: big.NewInt(1_000_000)}
+		headers []*types.Header
+	)
+	for i, prev := 0, parent; i < 3; i++ {
+		header := &types.Header{ParentHash: prev.Hash(), Number: new(big.Int).Add(prev.Number, big1), Time: prev.Time + 10}
+		header.Difficulty = ethash.CalcDifficulty(chain, header.Time, prev)
+		if i == 1 {
+			header.Difficulty = new(big.Int).Add(header.Difficulty, big1)
+		}
+		headers = append(headers, header)
+		prev = header
+	}
+	results, err := ethash.VerifyDifficultyChain(chain, parent, headers)
+	if err != nil {
+		t.Fatalf("failed to verify chain: %v", err)
+	}
+	for i, err := range results {
+		if (err != nil) != (i == 1) {
+			t.Errorf("header %d: unexpected result %v", i, err)
+		}
+	}
+	if _, err := ethash.VerifyDifficultyChain(chain, headers[0], headers); err == nil {
+		t.Error("non-contiguous chain accepted")
+	}
+}
+
+// Tests the expected share count projection and its difficulty validation.
+func TestExpectedSharesPerBlock(t *testing.T) {
+	api := &API{ethash: NewFaker()}
+
+	shares, err := api.ExpectedSharesPerBlock((*hexutil.Big)(big.NewInt(4_000)), (*hexutil.Big)(big.NewInt(1_000_000)))
+	if err != nil || shares != 250 {
+		t.Fatalf("expected shares mismatch: have %v, %v, want 250", shares, err)
+	}
+	if _, err := api.ExpectedSharesPerBlock((*hexutil.Big)(big.NewInt(2)), (*hexutil.Big)(big.NewInt(1))); err == nil {
+		t.Error("share difficulty above block difficulty accepted")
+	}
+	if _, err := api.ExpectedSharesPerBlock((*hexutil.Big)(new(big.Int)), (*hexutil.Big)(big.NewInt(1))); err == nil {
+		t.Error("zero share difficulty accepted")
+	}
+}
+
+// Tests that difficulty simulations follow the retargeting of each block time
+// and uncle inclusion.
+func TestSimulateDifficulty(t *testing.T) {
+	api := &API{ethash: NewFaker(), chain: newHeaderChain(2, 1000, 10)}
+
+	start := big.NewInt(1 << 30)
+	diffs, err := api.SimulateDifficulty((*hexutil.Big)(start), []hexutil.Uint64{9, 0, 9, 9}, []bool{false, true, false, false})
+	if err != nil {
+		t.Fatalf("failed to simulate: %v", err)
+	}
+	increase := func(d *big.Int) *big.Int {
+		return new(big.Int).Add(d, new(big.Int).Div(d, params.DifficultyBoundDivisor))
+	}
+	want := []*big.Int{start}                      // On target time, unchanged
+	want = append(want, increase(want[0]))         // Fast block, increased
+	want = append(want, increase(want[1]))         // Parent with uncles on target time, increased
+	want = append(want, new(big.Int).Set(want[2])) // On target time, unchanged
+
+	for i, diff := range diffs {
+		if diff.ToInt().Cmp(want[i]) != 0 {
+			t.Errorf("block %d: difficulty mismatch: have %v, want %v", i, diff, want[i])
+		}
+	}
+	if _, err := api.SimulateDifficulty((*hexutil.Big)(start), []hexutil.Uint64{9}, nil); err == nil {
+		t.Error("misaligned scenario accepted")
+	}
+	times, uncles := make([]hexutil.Uint64, maxSimulatedBlocks), make([]bool, maxSimulatedBlocks)
+	if diffs, err := api.SimulateDifficulty((*hexutil.Big)(start), times, uncles); err != nil || len(diffs) != maxSimulatedBlocks {
+		t.Errorf("maximum scenario not simulated: have %d blocks, want %d: %v", len(diffs), maxSimulatedBlocks, err)
+	}
+	times, uncles = append(times, 0), append(uncles, false)
+	if _, err := api.SimulateDifficulty((*hexutil.Big)(start), times, uncles); err != errTooManyBlocks {
+		t.Errorf("oversized scenario error mismatch: have %v, want %v", err, errTooManyBlocks)
+	}
+}
+
+// Tests that upcoming epoch boundaries are estimated from the target block time.
+func TestGetEpochSchedule(t *testing.T) {
+	api := &API{ethash: NewFaker(), chain: newHeaderChain(20, 1000, 10)}
+
+	schedule, err := api.GetEpochSchedule(2)
+	if err != nil {
+		t.Fatalf("failed to get schedule: %v", err)
+	}
+	want := []EpochBoundary{
+		{Epoch: 1, Number: epochLength, Time: hexutil.Uint64(1000 + 19*10 + (epochLength-19)*9)},
+		{Epoch: 2, Number: 2 * epochLength, Time: hexutil.Uint64(1000 + 19*10 + (2*epochLength-19)*9)},
+	}
+	if !reflect.DeepEqual(schedule, want) {
+		t.Fatalf("schedule mismatch: have %+v, want %+v", schedule, want)
+	}
+	if schedule, _ := api.GetEpochSchedule(1 << 20); len(schedule) != maxEpochSchedule {
+		t.Errorf("schedule not capped: have %d boundaries", len(schedule))
+	}
+}
+
+// fakeChainReader is a chain header reader only serving a chain config, an
+// optional set of headers and the chain head.
+type fakeChainReader struct {
+	consensus.ChainHeaderReader
+	config  *params.ChainConfig
+	headers map[common.Hash]*types.Header
+	head    *types.Header
+}
+
+func (c *fakeChainReader) Config() *params.ChainConfig { return c.config }
+
+func (c *fakeChainReader) CurrentHeader() *types.Header { return c.head }
+
+func (c *fakeChainReader) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if header := c.headers[hash]; header != nil && header.Number.Uint64() == number {
+		return header
+	}
+	return nil
+}
+
+// Tests that the payout split configured by the chain transfers the shares of
+// the block rewards and priority fees, but not of direct transfers, when
+// finalizing blocks of the payout coinbase from the activation block on only.
+func TestPayoutSplit(t *testing.T) {
+	var (
+		coinbase = common.Address{0xcb}
+		alice    = common.Address{0xaa}
+		bob      = common.Address{0xbb}
+	)
+	config := *params.TestChainConfig
+	config.PayoutSplit = &params.PayoutSplitConfig{
+		Block:    big.NewInt(2),
+		Coinbase: coinbase,
+		Shares:   []params.PayoutShare{{Address: alice, Percent: 70}, {Address: bob, Percent: 20}},
+	}
+	var (
+		ethash = NewFaker()
+		chain  = &fakeChainReader{config: &config}
+		db     = state.NewDatabase(rawdb.NewMemoryDatabase())
+
+		txs      = []*types.Transaction{types.NewTx(&types.LegacyTx{GasPrice: big.NewInt(10), Gas: 21000})}
+		receipts = []*types.Receipt{{GasUsed: 100}}
+		fees     = big.NewInt(1000)
+		transfer = big.NewInt(500)
+	)
+	for _, test := range []struct {
+		number int64
+		miner  common.Address
+		split  bool
+	}{
+		{1, coinbase, false},
+		{2, coinbase, true},
+		{2, common.Address{0x01}, false},
+	} {
+		statedb, _ := state.New(types.EmptyRootHash, db, nil)
+		statedb.AddBalance(test.miner, new(big.Int).Add(fees, transfer)) // Credited by the transactions
+
+		header := &types.Header{Number: big.NewInt(test.number), Coinbase: test.miner}
+		ethash.Finalize(chain, header, statedb, txs, nil, receipts, nil)
+
+		reward := new(big.Int).Add(ConstantinopleBlockReward, fees)
+		share := func(percent int64) *big.Int {
+			share := new(big.Int).Mul(reward, big.NewInt(percent))
+			return share.Div(share, big.NewInt(100))
+		}
+		if !test.split {
+			if statedb.GetBalance(alice).Sign() != 0 || statedb.GetBalance(test.miner).Cmp(new(big.Int).Add(reward, transfer)) != 0 {
+				t.Errorf("block %d of %x: unexpected split", test.number, test.miner)
+			}
+			continue
+		}
+		if have := statedb.GetBalance(alice); have.Cmp(share(70)) != 0 {
+			t.Errorf("first share mismatch: have %v, want %v", have, share(70))
+		}
+		if have := statedb.GetBalance(bob); have.Cmp(share(20)) != 0 {
+			t.Errorf("second share mismatch: have %v, want %v", have, share(20))
+		}
+		want := new(big.Int).Sub(reward, share(70))
+		want.Sub(want, share(20)).Add(want, transfer)
+		if have := statedb.GetBalance(coinbase); have.Cmp(want) != 0 {
+			t.Errorf("coinbase balance mismatch: have %v, want %v", have, want)
+		}
+	}
+}
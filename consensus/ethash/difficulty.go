@@ -15,7 +15,9 @@ package ethash
 import (
 	"math/big"
 
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/holiman/uint256"
 )
 
@@ -185,3 +187,62 @@ func MakeDifficultyCalculatorU256(bombDelay *big.Int) func(time uint64, parent *
 		return y.ToBig()
 	}
 }
+
+// DifficultyParams describes the difficulty adjustment algorithm in effect.
+type DifficultyParams struct {
+	Algorithm         string         `json:"algorithm"`         // Fork rules the adjustment follows
+	TargetBlockTime   hexutil.Uint64 `json:"targetBlockTime"`   // Block time in seconds keeping the difficulty stable
+	BoundDivisor      *hexutil.Big   `json:"boundDivisor"`      // Divisor of the parent difficulty per adjustment step
+	MaxDownwardSteps  hexutil.Uint64 `json:"maxDownwardSteps"`  // Maximum number of downward steps per block
+	MinimumDifficulty *hexutil.Big   `json:"minimumDifficulty"` // Difficulty floor before the bomb is applied
+	Bomb              bool           `json:"bomb"`              // Whether the exponential bomb term is applied
+	BombPeriod        hexutil.Uint64 `json:"bombPeriod"`        // Blocks per doubling of the bomb term
+}
+
+// difficultyAlgorithm returns the name of the difficulty adjustment rules that
+// CalcDifficulty applies to the block with the given number.
+func difficultyAlgorithm(config *params.ChainConfig, number *big.Int) string {
+	switch {
+	case config.IsGrayGlacier(number):
+		return "grayGlacier"
+	case config.IsArrowGlacier(number):
+		return "arrowGlacier"
+	case config.IsLondon(number):
+		return "london"
+	case config.IsMuirGlacier(number):
+		return "muirGlacier"
+	case config.IsConstantinople(number):
+		return "constantinople"
+	case config.IsByzantium(number):
+		return "byzantium"
+	case config.IsHomestead(number):
+		return "homestead"
+	default:
+		return "frontier"
+	}
+}
+
+// difficultyParams returns the parameters of the difficulty adjustment applied
+// to the block with the given number.
+func difficultyParams(config *params.ChainConfig, number *big.Int) DifficultyParams {
+	p := DifficultyParams{
+		Algorithm:         difficultyAlgorithm(config, number),
+		BoundDivisor:      (*hexutil.Big)(new(big.Int).Set(params.DifficultyBoundDivisor)),
+		MaxDownwardSteps:  99,
+		MinimumDifficulty: (*hexutil.Big)(new(big.Int).Set(params.MinimumDifficulty)),
+	}
+	switch p.Algorithm {
+	case "frontier":
+		// Frontier moves a single step in either direction around the duration limit
+		p.TargetBlockTime = hexutil.Uint64(params.DurationLimit.Uint64())
+		p.MaxDownwardSteps = 1
+		p.Bomb, p.BombPeriod = true, expDiffPeriodUint
+	case "homestead":
+		p.TargetBlockTime = 10
+		p.Bomb, p.BombPeriod = true, expDiffPeriodUint
+	default:
+		// The Byzantium based Bitnet calculators do not apply a bomb
+		p.TargetBlockTime = 9
+	}
+	return p
+}
@@ -0,0 +1,116 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package ethash
+
+import (
+	"encoding/json"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// WorkEncoder serializes work packages into the wire format expected by a miner
+// ecosystem. It's used for work notifications and the encoded work RPC.
+type WorkEncoder interface {
+	// EncodeWork returns the JSON encoding of the work package.
+	EncodeWork(work WorkPackage) (json.RawMessage, error)
+}
+
+// ArrayWorkEncoder encodes work packages as the 11 element getWork string array.
+// It's the default encoder.
+type ArrayWorkEncoder struct{}
+
+// EncodeWork implements WorkEncoder.
+func (ArrayWorkEncoder) EncodeWork(work WorkPackage) (json.RawMessage, error) {
+	return json.Marshal(work)
+}
+
+// workObject is the JSON object form of a work package.
+type workObject struct {
+	PowHash    string `json:"powHash"`
+	SeedHash   string `json:"seedHash"`
+	Target     string `json:"target"`
+	Number     string `json:"number"`
+	ParentHash string `json:"parentHash"`
+	GasLimit   string `json:"gasLimit"`
+	GasUsed    string `json:"gasUsed"`
+	TxCount    string `json:"txCount"`
+	UncleCount string `json:"uncleCount"`
+	HeaderRLP  string `json:"headerRLP"`
+	MEVProfit  string `json:"mevProfit"`
+}
+
+// ObjectWorkEncoder encodes work packages as a JSON object with named fields.
+type ObjectWorkEncoder struct{}
+
+// EncodeWork implements WorkEncoder.
+func (ObjectWorkEncoder) EncodeWork(work WorkPackage) (json.RawMessage, error) {
+	return json.Marshal(workObject{
+		PowHash:    work[0],
+		SeedHash:   work[1],
+		Target:     work[2],
+		Number:     work[3],
+		ParentHash: work[4],
+		GasLimit:   work[5],
+		GasUsed:    work[6],
+		TxCount:    work[7],
+		UncleCount: work[8],
+		HeaderRLP:  work[9],
+		MEVProfit:  work[10],
+	})
+}
+
+// ExtendedWork is a work package with typed, named fields instead of positional
+// strings.
+type ExtendedWork struct {
+	PowHash     common.Hash    `json:"powHash"`
+	SeedHash    common.Hash    `json:"seedHash"`
+	Target      common.Hash    `json:"target"` // 2^256/difficulty
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	ParentHash  common.Hash    `json:"parentHash"`
+	GasLimit    hexutil.Uint64 `json:"gasLimit"`
+	GasUsed     hexutil.Uint64 `json:"gasUsed"`
+	TxCount     hexutil.Uint64 `json:"txCount"`
+	UncleCount  hexutil.Uint64 `json:"uncleCount"`
+	HeaderRLP   hexutil.Bytes  `json:"headerRLP"` // With the empty extra data bytes for an extraNonce
+	MEVProfit   string         `json:"mevProfit"`
+}
+
+// Extended converts the work package into its typed form.
+func (work WorkPackage) Extended() (ExtendedWork, error) {
+	ext := ExtendedWork{
+		PowHash:    common.HexToHash(work[0]),
+		SeedHash:   common.HexToHash(work[1]),
+		Target:     common.HexToHash(work[2]),
+		ParentHash: common.HexToHash(work[4]),
+		MEVProfit:  work[10],
+	}
+	for _, field := range []struct {
+		index int
+		value *hexutil.Uint64
+	}{{3, &ext.BlockNumber}, {5, &ext.GasLimit}, {6, &ext.GasUsed}, {7, &ext.TxCount}, {8, &ext.UncleCount}} {
+		value, err := hexutil.DecodeUint64(work[field.index])
+		if err != nil {
+			return ExtendedWork{}, err
+		}
+		*field.value = hexutil.Uint64(value)
+	}
+	if work[9] != "" {
+		blob, err := hexutil.Decode(work[9])
+		if err != nil {
+			return ExtendedWork{}, err
+		}
+		ext.HeaderRLP = blob
+	}
+	return ext, nil
+}
@@ -23,6 +23,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"sort"
 	"strconv"
 	"sync"
 	"sync/atomic"
@@ -30,8 +31,12 @@ import (
 	"unsafe"
 
 	"github.com/edsrzf/mmap-go"
+	"github.com/ethereum/go-ethereum/common"
 	lrupkg "github.com/ethereum/go-ethereum/common/lru"
 	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/rpc"
@@ -224,6 +229,68 @@ func (lru *lru[T]) get(epoch uint64) (item, future T) {
 	return item, future
 }
 
+// prefetch retrieves or creates the item for the given epoch ahead of its use.
+// Items beyond the highest seen epoch take the place of the future item to not
+// evict any item in use, with the replaced future item moving into the cache.
+func (lru *lru[T]) prefetch(epoch uint64) T {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+
+	if item, ok := lru.cache.Peek(epoch); ok {
+		return item
+	}
+	if lru.future > 0 && lru.future == epoch {
+		return lru.futureItem
+	}
+	item := lru.new(epoch)
+	if epoch > lru.future {
+		if lru.future > 0 {
+			lru.cache.Add(lru.future, lru.futureItem)
+		}
+		lru.future, lru.futureItem = epoch, item
+	} else {
+		lru.cache.Add(epoch, item)
+	}
+	return item
+}
+
+// peek retrieves the item for the given epoch without creating it or updating
+// its recency.
+func (lru *lru[T]) peek(epoch uint64) (T, bool) {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+
+	if item, ok := lru.cache.Peek(epoch); ok {
+		return item, true
+	}
+	if lru.future > 0 && lru.future == epoch {
+		return lru.futureItem, true
+	}
+	return nil, false
+}
+
+// forEach calls fn for every tracked item, including the future item, in order
+// of increasing epoch until fn returns false. The lru lock is held throughout.
+func (lru *lru[T]) forEach(fn func(epoch uint64, item T) bool) {
+	lru.mu.Lock()
+	defer lru.mu.Unlock()
+
+	epochs := lru.cache.Keys()
+	if lru.future > 0 && !lru.cache.Contains(lru.future) {
+		epochs = append(epochs, lru.future)
+	}
+	sort.Slice(epochs, func(i, j int) bool { return epochs[i] < epochs[j] })
+	for _, epoch := range epochs {
+		item, ok := lru.cache.Peek(epoch)
+		if !ok {
+			item = lru.futureItem
+		}
+		if !fn(epoch, item) {
+			return
+		}
+	}
+}
+
 // cache wraps an ethash cache with some metadata to allow easier concurrent use.
 type cache struct {
 	epoch uint64    // Epoch for which this cache is relevant
@@ -310,6 +377,70 @@ type dataset struct {
 	dataset []uint32    // The actual cache data content
 	once    sync.Once   // Ensures the cache is generated only once
 	done    atomic.Bool // Atomic flag to determine generation status
+
+	items    atomic.Uint64 // Number of items in the dataset, known once generation starts
+	progress atomic.Uint64 // Number of items generated so far
+
+	generator DatasetGenerator // External generator replacing the CPU one, if any
+}
+
+// datasetSpotChecks is the number of items of an externally generated dataset
+// recomputed on the CPU to validate it.
+const datasetSpotChecks = 64
+
+// DatasetGenerator generates the full mining dataset of an epoch from its
+// verification cache into dest, in machine byte order, counting the generated
+// items into progress. It is the hook for accelerated implementations, e.g. an
+// OpenCL or CUDA one linked in by the node operator; none ships with the engine.
+type DatasetGenerator func(dest []uint32, epoch uint64, cache []uint32, progress *atomic.Uint64) error
+
+var (
+	datasetGenCounter = metrics.NewRegisteredCounter("ethash/dataset/generations", nil)
+	datasetGenGauge   = metrics.NewRegisteredGaugeFloat64("ethash/dataset/generations/perepoch", nil)
+)
+
+// datasetGens counts the mining datasets generated from scratch, as opposed to
+// loaded from disk. More than one generation per epoch signals datasets being
+// evicted or failing to persist.
+var datasetGens = &generationCounter{epochs: make(map[uint64]uint64)}
+
+// generationCounter tracks the number of dataset generations per epoch.
+type generationCounter struct {
+	lock   sync.Mutex
+	epochs map[uint64]uint64 // Generations per epoch
+	total  uint64
+}
+
+// add records a fresh generation of the dataset of the given epoch.
+func (c *generationCounter) add(epoch uint64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.epochs[epoch]++
+	c.total++
+
+	datasetGenCounter.Inc(1)
+	datasetGenGauge.Update(float64(c.total) / float64(len(c.epochs)))
+}
+
+// generations returns the number of generations of the given epoch.
+func (c *generationCounter) generations(epoch uint64) uint64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.epochs[epoch]
+}
+
+// summary returns the total generations and the average generations per
+// generated epoch.
+func (c *generationCounter) summary() (uint64, float64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.total == 0 {
+		return 0, 0
+	}
+	return c.total, float64(c.total) / float64(len(c.epochs))
 }
 
 // newDataset creates a new ethash mining dataset and returns it as a plain Go
@@ -331,13 +462,16 @@ func (d *dataset) generate(dir string, limit int, lock bool, test bool) {
 			csize = 1024
 			dsize = 32 * 1024
 		}
+		d.items.Store(dsize / hashBytes)
+
 		// If we don't store anything on disk, generate and return
 		if dir == "" || limit <= 0 {
 			cache := make([]uint32, csize/4)
 			generateCache(cache, d.epoch, seed)
 
 			d.dataset = make([]uint32, dsize/4)
-			generateDataset(d.dataset, d.epoch, cache)
+			d.fill(d.dataset, cache)
+			datasetGens.add(d.epoch)
 
 			return
 		}
@@ -363,15 +497,17 @@ func (d *dataset) generate(dir string, limit int, lock bool, test bool) {
 		logger.Debug("Failed to load old ethash dataset", "err", err)
 
 		// No previous dataset available, create a new dataset file to fill
+		datasetGens.add(d.epoch)
 		cache := make([]uint32, csize/4)
 		generateCache(cache, d.epoch, seed)
 
-		d.dump, d.mmap, d.dataset, err = memoryMapAndGenerate(path, dsize, lock, func(buffer []uint32) { generateDataset(buffer, d.epoch, cache) })
+		d.dump, d.mmap, d.dataset, err = memoryMapAndGenerate(path, dsize, lock, func(buffer []uint32) { d.fill(buffer, cache) })
 		if err != nil {
 			logger.Error("Failed to generate mapped ethash dataset", "err", err)
 
+			d.progress.Store(0)
 			d.dataset = make([]uint32, dsize/4)
-			generateDataset(d.dataset, d.epoch, cache)
+			generateDatasetTracked(d.dataset, d.epoch, cache, &d.progress)
 		}
 		// Iterate over all previous instances and delete old ones
 		for ep := int(d.epoch) - limit; ep >= 0; ep-- {
@@ -389,6 +525,36 @@ func (d *dataset) generated() bool {
 	return d.done.Load()
 }
 
+// fill generates the dataset content into dest with the external generator if
+// one is configured. Its output is spot checked against the CPU algorithm and
+// the dataset generated on the CPU if the generator fails or the checks do.
+func (d *dataset) fill(dest []uint32, cache []uint32) {
+	if d.generator != nil {
+		err := d.generator(dest, d.epoch, cache, &d.progress)
+		if err == nil {
+			err = checkDataset(dest, cache, datasetSpotChecks)
+		}
+		if err == nil {
+			return
+		}
+		log.Warn("External ethash dataset generation failed, generating on CPU", "epoch", d.epoch, "err", err)
+		d.progress.Store(0)
+	}
+	generateDatasetTracked(dest, d.epoch, cache, &d.progress)
+}
+
+// percent returns the share of the dataset generated so far, in percents.
+func (d *dataset) percent() float64 {
+	if d.generated() {
+		return 100
+	}
+	items := d.items.Load()
+	if items == 0 {
+		return 0
+	}
+	return float64(d.progress.Load()) * 100 / float64(items)
+}
+
 // finalizer closes any file handlers and memory maps open.
 func (d *dataset) finalizer() {
 	if d.mmap != nil {
@@ -421,6 +587,49 @@ const (
 	ModeFullFake
 )
 
+// MixVariant selects the mix step of the hashimoto loop. Variants other than
+// MixStandard produce seals incompatible with ethash and are only honored in
+// test mode, for research forks experimenting with the algorithm.
+type MixVariant uint
+
+const (
+	MixStandard MixVariant = iota // Ethash fnv mix step
+	MixFNV1a                      // FNV-1a style xor-then-multiply mix step
+)
+
+// String implements fmt.Stringer.
+func (variant MixVariant) String() string {
+	switch variant {
+	case MixStandard:
+		return "standard"
+	case MixFNV1a:
+		return "fnv1a"
+	default:
+		return "unknown"
+	}
+}
+
+// KeccakImpl selects the keccak implementation used by the hashimoto loop of
+// the local miner.
+type KeccakImpl uint
+
+const (
+	KeccakDefault  KeccakImpl = iota // Fresh sponge from the crypto package per hash
+	KeccakStateful                   // Per-thread reused sponge with the assembly-backed read path
+)
+
+// String implements fmt.Stringer.
+func (impl KeccakImpl) String() string {
+	switch impl {
+	case KeccakDefault:
+		return "default"
+	case KeccakStateful:
+		return "stateful"
+	default:
+		return "unknown"
+	}
+}
+
 // Config are the configuration parameters of the ethash.
 type Config struct {
 	CacheDir         string
@@ -437,6 +646,160 @@ type Config struct {
 	// be block header JSON objects instead of work package arrays.
 	NotifyFull bool
 
+	// NotifyStructured makes the remote sealer notify with a versioned JSON
+	// envelope of the typed work package and the pending block header. It takes
+	// precedence over NotifyFull.
+	NotifyStructured bool
+
+	// NotifySecret, if set, keys the HMAC-SHA256 signature sent along every
+	// work notification for receivers to authenticate them.
+	NotifySecret string
+
+	// DedupWindow is how long submitted solutions are remembered to reject
+	// duplicates. Too short lets duplicate shares be double counted, too long
+	// wastes memory. Defaults to roughly the work staleness window.
+	DedupWindow time.Duration
+
+	// WorkLookupWindow is how long issued work packages can be looked up by
+	// their pow-hash. Defaults to roughly the work staleness window.
+	WorkLookupWindow time.Duration
+
+	// MixVariant selects the mix step used for both sealing and verification.
+	// It is reset to the standard mix outside of test mode.
+	MixVariant MixVariant
+
+	// KeccakImpl selects the keccak implementation used by the local miner.
+	// Unavailable implementations fall back to the default one.
+	KeccakImpl KeccakImpl
+
+	// MinerCPUs are the CPUs the local mining threads are pinned to, one thread
+	// per CPU, overriding the thread count while mining is enabled.
+	MinerCPUs []int
+
+	// ThreadAffinity pins the local mining threads to CPUs spread round robin
+	// over the NUMA nodes, if MinerCPUs is not set.
+	ThreadAffinity bool
+
+	// NUMALocalDAG gives every NUMA node running pinned mining threads its own
+	// copy of the mining dataset in node local memory. This multiplies the
+	// memory use by the number of nodes, so it's meant for the small datasets
+	// of test networks and low difficulty chains.
+	NUMALocalDAG bool
+
+	// LogUnknownWork enables debug logs detailing solutions submitted for work
+	// that is not retained, which usually hints at misrouted miners.
+	LogUnknownWork bool
+
+	// MinWorkInterval is the minimum time between two work regenerations of
+	// the remote sealer, serving the latest pending block on each interval.
+	// Zero regenerates work on every pending block change.
+	MinWorkInterval time.Duration
+
+	// VerifyWorkers is the number of workers verifying batches of headers
+	// concurrently. Zero auto-tunes it to the cores not taken by sealing.
+	VerifyWorkers int
+
+	// VerifyBatchSize is the number of consecutive headers handed to a header
+	// verification worker at once. Zero auto-tunes it to the batch length.
+	VerifyBatchSize int
+
+	// CacheGenThreads is the number of verification caches of different epochs
+	// generated concurrently by PregenerateCaches. As a single cache can only
+	// be generated sequentially, parallelism is across epochs. Zero uses all
+	// available cores.
+	CacheGenThreads int
+
+	// StatusLogInterval is the interval at which a summary of the mining status
+	// is logged, zero disables the summary.
+	StatusLogInterval time.Duration
+
+	// AllowDirectSubmit replaces the remote sealer with a verification only
+	// submission path: SubmitWork checks solutions against the work registered
+	// via RegisterWork and hands valid ones to its callback. Work packages are
+	// neither served nor pushed to notification URLs.
+	AllowDirectSubmit bool
+
+	// RejectDuplicateIDs rejects hash rate submissions of a miner id already
+	// reporting from another address, instead of only flagging the id.
+	RejectDuplicateIDs bool
+
+	// VerifyFailuresRetained is the number of recent seal verification failures
+	// retained for GetRecentVerifyFailures, defaulting to 32.
+	VerifyFailuresRetained int
+
+	// VerifyTimeout is the time budget of a single seal verification, after
+	// which it is aborted with a timeout error, e.g. if loading the verification
+	// cache hangs. The abandoned computation still runs to completion in the
+	// background. Zero waits indefinitely.
+	VerifyTimeout time.Duration
+
+	// ShareVerifiers is the number of light share verifications running at
+	// once, defaulting to the number of CPUs.
+	ShareVerifiers int
+
+	// StratumAddr is the TCP listen address of the stratum server serving the
+	// remote sealer's work to persistently connected miners. Empty disables it.
+	StratumAddr string
+
+	// StratumMaxConns is the maximum number of concurrent stratum connections,
+	// defaulting to 1024. Each connection searches a distinct nonce prefix, so
+	// at most 65536 connections are served.
+	StratumMaxConns int
+
+	// ShareDifficulty is the initial and minimum share difficulty of stratum
+	// connections, retuned per connection to submit a share about every
+	// ShareTargetTime. Solutions meeting only the share target are accounted
+	// as shares. Zero disables share targets, miners submit at the block target.
+	ShareDifficulty uint64
+
+	// ShareTargetTime is the desired interval between the shares of a stratum
+	// connection, defaulting to 10 seconds.
+	ShareTargetTime time.Duration
+
+	// StratumV2Addr is the TCP listen address of the stratum v2 server, serving
+	// the remote sealer's work over unencrypted binary framed channels. Empty
+	// disables it.
+	StratumV2Addr string
+
+	// Upstreams are the RPC endpoints of upstream nodes to mirror work from if
+	// no local work is available, submitting the solutions of mirrored work to
+	// all upstreams serving it.
+	Upstreams []string
+
+	// StaleWindow is the grace period after a new head in which solutions to the
+	// replaced work of the previous height are still sealed and broadcast as
+	// potential uncles, accounted as stale-accepted. Older solutions are rejected
+	// as stale. Zero accepts all solutions within the staleness threshold.
+	StaleWindow time.Duration
+
+	// ClockSkewThreshold is the skew of the local clock against the chain above
+	// which a warning is logged, defaulting to the allowed future block time.
+	ClockSkewThreshold time.Duration
+
+	// WorkEncoder selects the format of work notifications and encoded work
+	// RPC responses, nil uses the 11 element getWork array.
+	WorkEncoder WorkEncoder `toml:"-"`
+
+	// CollectSolutions is the number of valid nonces the local miner collects
+	// for a block before stopping, each delivered as a separate seal result.
+	// Zero or one stops at the first solution.
+	CollectSolutions int
+
+	// OnSealed is invoked synchronously with every block sealed by the local
+	// miner, before it is delivered to the results channel. The callback can't
+	// prevent the block from being delivered and imported; blocking in it delays
+	// both.
+	OnSealed func(block *types.Block) `toml:"-"`
+
+	// DatasetGenerator replaces the CPU generation of mining datasets, e.g. with
+	// a GPU accelerated one. It can only be set by programs embedding the engine,
+	// no generator ships with it. Nil generates datasets on the CPU.
+	DatasetGenerator DatasetGenerator `toml:"-"`
+
+	// SubmissionDB is the database the audit log of the solutions submitted to
+	// the remote sealer is persisted in, nil disables the log.
+	SubmissionDB ethdb.KeyValueStore `toml:"-"`
+
 	Log log.Logger `toml:"-"`
 }
 
@@ -449,11 +812,33 @@ type Ethash struct {
 	datasets *lru[*dataset] // In memory datasets to avoid regenerating too often
 
 	// Mining related fields
-	rand     *rand.Rand    // Properly seeded random source for nonces
-	threads  int           // Number of threads to mine on if mining
-	update   chan struct{} // Notification channel to update mining parameters
-	hashrate metrics.Meter // Meter tracking the average hashrate
-	remote   *remoteSealer
+	rand      *rand.Rand    // Properly seeded random source for nonces
+	threads   int           // Number of threads to mine on if mining
+	update    chan struct{} // Notification channel to update mining parameters
+	hashrate  metrics.Meter // Meter tracking the average hashrate
+	remote    *remoteSealer
+	stratum   *stratumServer   // Stratum server of the remote sealer, nil if disabled
+	stratumV2 *stratumV2Server // Stratum v2 server of the remote sealer, nil if disabled
+	upstreams *upstreamPool    // Upstream nodes work is mirrored from, nil if disabled
+
+	submissions *submissionLog // Audit log of submitted solutions, nil if disabled
+	sealed      sealedTracker  // Recently sealed blocks for the orphan statistics
+
+	localStats   localMiningStats // Search effort statistics of the local miner
+	nodeDatasets nodeDatasets     // NUMA node local copies of the mining dataset
+	blocksFound  atomic.Uint64    // Blocks sealed locally or remotely since startup
+	mevEstimator MEVEstimator     // Forecaster of the next block's MEV, nil if disabled
+	uncleSource  UncleSource      // Lister of the miner's uncle candidates, nil if not mining
+
+	fees     *lrupkg.Cache[common.Hash, *assembledFees] // Fees of recently assembled blocks by seal hash
+	verified *lrupkg.Cache[common.Hash, shareValues]    // PoW values of recently verified headers by hash
+
+	verifyFailures verifyFailureRing // Recent seal verification failures
+	verifySettings verifySettings    // Concurrency of batch header verification
+	remoteWork     atomic.Int64      // Unix nano timestamp of the last work made for remote miners
+	direct         directWorks       // Work registered for direct submission
+	shares         *shareVerifier    // Light verifier of shares, nil if not supported
+	shareFeed      event.Feed        // Feed of the valid solutions submitted remotely
 
 	// The fields below are hooks for testing
 	shared    *Ethash       // Shared PoW verifier to avoid cache regeneration
@@ -481,17 +866,80 @@ func New(config Config, notify []string, noverify bool) *Ethash {
 	if config.DatasetDir != "" && config.DatasetsOnDisk > 0 {
 		config.Log.Info("Disk storage enabled for ethash DAGs", "dir", config.DatasetDir, "count", config.DatasetsOnDisk)
 	}
+	if config.ShareTargetTime <= 0 {
+		config.ShareTargetTime = defaultShareTargetTime
+	}
+	if config.StratumMaxConns <= 0 {
+		config.StratumMaxConns = defaultStratumMaxConns
+	}
+	if config.StratumMaxConns > stratumMaxPrefixes {
+		config.StratumMaxConns = stratumMaxPrefixes
+	}
+	if config.ClockSkewThreshold <= 0 {
+		config.ClockSkewThreshold = time.Duration(allowedFutureBlockTimeSeconds) * time.Second
+	}
+	if config.DedupWindow <= 0 {
+		config.DedupWindow = defaultDedupWindow
+	}
+	if config.WorkLookupWindow <= 0 {
+		config.WorkLookupWindow = defaultWorkLookupWindow
+	}
+	if _, ok := mixFuncs[config.MixVariant]; !ok || (config.MixVariant != MixStandard && config.PowMode != ModeTest) {
+		config.Log.Error("Mix variant unavailable outside of test mode, using standard", "requested", config.MixVariant)
+		config.MixVariant = MixStandard
+	}
+	if _, impl := newKeccakHasher(config.KeccakImpl); impl != config.KeccakImpl {
+		config.Log.Warn("Keccak implementation unavailable, using default", "requested", config.KeccakImpl, "using", impl)
+		config.KeccakImpl = impl
+	}
+	newGeneratedDataset := func(epoch uint64) *dataset {
+		d := newDataset(epoch)
+		d.generator = config.DatasetGenerator
+		return d
+	}
 	ethash := &Ethash{
 		config:   config,
 		caches:   newlru(config.CachesInMem, newCache),
-		datasets: newlru(config.DatasetsInMem, newDataset),
+		datasets: newlru(config.DatasetsInMem, newGeneratedDataset),
 		update:   make(chan struct{}),
 		hashrate: metrics.NewMeterForced(),
+		fees:     lrupkg.NewCache[common.Hash, *assembledFees](feesRetained),
+		verified: lrupkg.NewCache[common.Hash, shareValues](verifiedSealsRetained),
+	}
+	if config.WorkEncoder == nil {
+		ethash.config.WorkEncoder = ArrayWorkEncoder{}
+	}
+	if err := ethash.SetVerifyConcurrency(config.VerifyWorkers, config.VerifyBatchSize); err != nil {
+		config.Log.Warn("Invalid header verification concurrency, auto-tuning", "workers", config.VerifyWorkers, "batch", config.VerifyBatchSize, "err", err)
 	}
+	ethash.shares = newShareVerifier(ethash, config.ShareVerifiers)
 	if config.PowMode == ModeShared {
 		ethash.shared = sharedEthash
 	}
-	ethash.remote = startRemoteSealer(ethash, notify, noverify)
+	if config.SubmissionDB != nil {
+		ethash.submissions = newSubmissionLog(config.SubmissionDB)
+	}
+	if !config.AllowDirectSubmit {
+		ethash.remote = startRemoteSealer(ethash, notify, noverify)
+		if config.StratumAddr != "" {
+			var err error
+			if ethash.stratum, err = startStratum(ethash, config.StratumAddr); err != nil {
+				config.Log.Error("Failed to start stratum server", "addr", config.StratumAddr, "err", err)
+			}
+		}
+		if config.StratumV2Addr != "" {
+			var err error
+			if ethash.stratumV2, err = startStratumV2(ethash, config.StratumV2Addr); err != nil {
+				config.Log.Error("Failed to start stratum v2 server", "addr", config.StratumV2Addr, "err", err)
+			}
+		}
+		if len(config.Upstreams) > 0 {
+			var err error
+			if ethash.upstreams, err = startUpstreams(ethash, config.Upstreams); err != nil {
+				config.Log.Error("Failed to connect to upstream nodes", "err", err)
+			}
+		}
+	}
 	return ethash
 }
 
@@ -568,6 +1016,15 @@ func (ethash *Ethash) StopRemoteSealer() error {
 		if ethash.remote == nil {
 			return
 		}
+		if ethash.stratum != nil {
+			ethash.stratum.close()
+		}
+		if ethash.stratumV2 != nil {
+			ethash.stratumV2.close()
+		}
+		if ethash.upstreams != nil {
+			ethash.upstreams.close()
+		}
 		close(ethash.remote.requestExit)
 		<-ethash.remote.exitCh
 	})
@@ -591,6 +1048,42 @@ func (ethash *Ethash) cache(block uint64) *cache {
 	return current
 }
 
+// PregenerateCaches generates the verification caches of the given epochs with
+// up to CacheGenThreads concurrent workers, blocking until all are done. This
+// speeds up header verification warmup across many epochs, but caches beyond
+// CachesInMem are only retained if disk storage is enabled.
+func (ethash *Ethash) PregenerateCaches(epochs []uint64) {
+	if ethash.shared != nil {
+		ethash.shared.PregenerateCaches(epochs)
+		return
+	}
+	threads := ethash.config.CacheGenThreads
+	if threads <= 0 {
+		threads = runtime.NumCPU()
+	}
+	if threads > len(epochs) {
+		threads = len(epochs)
+	}
+	var (
+		tasks = make(chan uint64)
+		pend  sync.WaitGroup
+	)
+	for i := 0; i < threads; i++ {
+		pend.Add(1)
+		go func() {
+			defer pend.Done()
+			for epoch := range tasks {
+				ethash.cache(epoch * epochLength)
+			}
+		}()
+	}
+	for _, epoch := range epochs {
+		tasks <- epoch
+	}
+	close(tasks)
+	pend.Wait()
+}
+
 // dataset tries to retrieve a mining dataset for the specified block number
 // by first checking against a list of in-memory datasets, then against DAGs
 // stored on disk, and finally generating one if none can be found.
@@ -620,6 +1113,91 @@ func (ethash *Ethash) dataset(block uint64, async bool) *dataset {
 	return current
 }
 
+// pregenerateDataset starts generating the mining dataset of the given epoch on
+// a background thread, unless it's already generated or being generated.
+func (ethash *Ethash) pregenerateDataset(epoch uint64) {
+	if ethash.shared != nil {
+		ethash.shared.pregenerateDataset(epoch)
+		return
+	}
+	d := ethash.datasets.prefetch(epoch)
+	if !d.generated() {
+		go d.generate(ethash.config.DatasetDir, ethash.config.DatasetsOnDisk, ethash.config.DatasetsLockMmap, ethash.config.PowMode == ModeTest)
+	}
+}
+
+// datasetProgress returns the generation progress of the mining dataset of the
+// given epoch in percents, zero if it's not tracked.
+func (ethash *Ethash) datasetProgress(epoch uint64) float64 {
+	if ethash.shared != nil {
+		return ethash.shared.datasetProgress(epoch)
+	}
+	if d, ok := ethash.datasets.peek(epoch); ok {
+		return d.percent()
+	}
+	return 0
+}
+
+// datasetDiskUsage returns the number of bytes the mining datasets stored on
+// disk take up.
+func (ethash *Ethash) datasetDiskUsage() uint64 {
+	if ethash.shared != nil {
+		return ethash.shared.datasetDiskUsage()
+	}
+	if ethash.config.DatasetDir == "" {
+		return 0
+	}
+	files, _ := filepath.Glob(filepath.Join(ethash.config.DatasetDir, fmt.Sprintf("full-R%d-*", algorithmRevision)))
+
+	var usage uint64
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil {
+			usage += uint64(info.Size())
+		}
+	}
+	return usage
+}
+
+// ForEachCachedDataset calls fn with the epoch and size of every fully generated
+// in-memory mining dataset, in order of increasing epoch, until fn returns false.
+// The dataset inventory is locked during iteration, so fn must not call back
+// into the engine.
+func (ethash *Ethash) ForEachCachedDataset(fn func(epoch uint64, sizeBytes uint64) bool) error {
+	if ethash.shared != nil {
+		return ethash.shared.ForEachCachedDataset(fn)
+	}
+	if ethash.datasets == nil {
+		return errors.New("no datasets maintained")
+	}
+	ethash.datasets.forEach(func(epoch uint64, d *dataset) bool {
+		if !d.generated() {
+			return true
+		}
+		return fn(epoch, uint64(len(d.dataset))*4)
+	})
+	return nil
+}
+
+// DatasetReady reports whether the mining dataset of the given block is fully
+// generated in memory, local sealing running at full speed. Engines not mining
+// on real datasets are always ready.
+func (ethash *Ethash) DatasetReady(block uint64) bool {
+	if ethash.shared != nil {
+		return ethash.shared.DatasetReady(block)
+	}
+	if ethash.config.PowMode == ModeFake || ethash.config.PowMode == ModeFullFake {
+		return true
+	}
+	epoch := block / epochLength
+
+	var ready bool
+	ethash.ForEachCachedDataset(func(generated uint64, size uint64) bool {
+		ready = generated == epoch
+		return generated < epoch
+	})
+	return ready
+}
+
 // Threads returns the number of mining threads currently enabled. This doesn't
 // necessarily mean that mining is running!
 func (ethash *Ethash) Threads() int {
@@ -657,7 +1235,7 @@ func (ethash *Ethash) SetThreads(threads int) {
 // hashrate of all remote miner.
 func (ethash *Ethash) Hashrate() float64 {
 	// Short circuit if we are run the ethash in normal/test mode.
-	if ethash.config.PowMode != ModeNormal && ethash.config.PowMode != ModeTest {
+	if (ethash.config.PowMode != ModeNormal && ethash.config.PowMode != ModeTest) || ethash.remote == nil {
 		return ethash.hashrate.Rate1()
 	}
 	var res = make(chan uint64, 1)
@@ -673,6 +1251,41 @@ func (ethash *Ethash) Hashrate() float64 {
 	return ethash.hashrate.Rate1() + float64(<-res)
 }
 
+// MEVEstimator forecasts the MEV available to the next block, typically from the
+// contents of the transaction pool.
+type MEVEstimator func() (*big.Int, error)
+
+// SetMEVEstimator installs the forecaster used to estimate the MEV available to
+// the next block. Setting nil disables MEV forecasting.
+func (ethash *Ethash) SetMEVEstimator(estimator MEVEstimator) {
+	ethash.lock.Lock()
+	defer ethash.lock.Unlock()
+
+	ethash.mevEstimator = estimator
+}
+
+// PendingUncle is a side block the miner considers as an uncle of the block it
+// is sealing.
+type PendingUncle struct {
+	Header   *types.Header
+	Local    bool  // Whether the block was mined locally
+	Included bool  // Whether the sealing block includes the uncle
+	Err      error // Why the sealing block can't include the uncle, nil if it can
+}
+
+// UncleSource lists the header of the block the miner is sealing along with the
+// side blocks it considers as its uncles.
+type UncleSource func() (*types.Header, []PendingUncle, error)
+
+// SetUncleSource installs the lister of the miner's uncle candidates. Setting
+// nil disables reporting them.
+func (ethash *Ethash) SetUncleSource(source UncleSource) {
+	ethash.lock.Lock()
+	defer ethash.lock.Unlock()
+
+	ethash.uncleSource = source
+}
+
 // APIs implements consensus.Engine, returning the user facing RPC APIs.
 func (ethash *Ethash) APIs(chain consensus.ChainHeaderReader) []rpc.API {
 	// In order to ensure backward compatibility, we exposes ethash RPC APIs
@@ -680,11 +1293,11 @@ func (ethash *Ethash) APIs(chain consensus.ChainHeaderReader) []rpc.API {
 	return []rpc.API{
 		{
 			Namespace: "eth",
-			Service:   &API{ethash},
+			Service:   &API{ethash: ethash, chain: chain},
 		},
 		{
 			Namespace: "ethash",
-			Service:   &API{ethash},
+			Service:   &API{ethash: ethash, chain: chain},
 		},
 	}
 }
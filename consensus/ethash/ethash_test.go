@@ -13,10 +13,15 @@
 package ethash
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"math/big"
 	"math/rand"
 	"os"
+	"reflect"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -45,11 +50,104 @@ func TestTestMode(t *testing.T) {
 		if err := ethash.verifySeal(nil, header, false); err != nil {
 			t.Fatalf("unexpected verification error: %v", err)
 		}
+		if stats := (&API{ethash: ethash}).GetLocalMiningStats(); stats.BlocksFound != 1 {
+			t.Fatalf("local blocks found mismatch: have %d, want 1", stats.BlocksFound)
+		}
 	case <-time.NewTimer(4 * time.Second).C:
 		t.Error("sealing result timeout")
 	}
 }
 
+// Tests that blocks sealed by the local miner are passed to the OnSealed
+// callback before being delivered.
+func TestOnSealed(t *testing.T) {
+	sealed := make(chan *types.Block, 1)
+	ethash := New(Config{PowMode: ModeTest, OnSealed: func(block *types.Block) { sealed <- block }}, nil, false)
+	defer ethash.Close()
+
+	results := make(chan types.SealResult, 1)
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100)}
+	if err := ethash.Seal(nil, types.NewBlockWithHeader(header), results, nil); err != nil {
+		t.Fatalf("failed to seal block: %v", err)
+	}
+	select {
+	case result := <-results:
+		select {
+		case block := <-sealed:
+			if block.Hash() != result.Block.Hash() {
+				t.Fatalf("callback block mismatch: have %x, want %x", block.Hash(), result.Block.Hash())
+			}
+		default:
+			t.Fatal("callback not invoked before delivery")
+		}
+	case <-time.NewTimer(4 * time.Second).C:
+		t.Error("sealing result timeout")
+	}
+}
+
+// Tests that the local miner keeps searching until the configured number of
+// solutions are collected.
+func TestCollectSolutions(t *testing.T) {
+	ethash := New(Config{PowMode: ModeTest, CollectSolutions: 3}, nil, false)
+	defer ethash.Close()
+
+	results := make(chan types.SealResult, 4)
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100)}
+	if err := ethash.Seal(nil, types.NewBlockWithHeader(header), results, nil); err != nil {
+		t.Fatalf("failed to seal block: %v", err)
+	}
+	nonces := make(map[uint64]bool)
+	for len(nonces) < 3 {
+		select {
+		case result := <-results:
+			if nonces[result.Block.Nonce()] {
+				t.Fatalf("duplicate solution %d", result.Block.Nonce())
+			}
+			nonces[result.Block.Nonce()] = true
+		case <-time.After(4 * time.Second):
+			t.Fatalf("sealing result timeout after %d solutions", len(nonces))
+		}
+	}
+	select {
+	case <-results:
+		t.Fatal("more solutions than requested")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// Tests that blocks sealed with a non-default mix variant verify under the same
+// variant only, and that the variant is refused outside of test mode.
+func TestMixVariant(t *testing.T) {
+	ethash := New(Config{PowMode: ModeTest, MixVariant: MixFNV1a}, nil, false)
+	defer ethash.Close()
+
+	results := make(chan types.SealResult, 1)
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100)}
+	if err := ethash.Seal(nil, types.NewBlockWithHeader(header), results, nil); err != nil {
+		t.Fatalf("failed to seal block: %v", err)
+	}
+	var sealed *types.Header
+	select {
+	case result := <-results:
+		sealed = result.Block.Header()
+	case <-time.NewTimer(4 * time.Second).C:
+		t.Fatal("sealing result timeout")
+	}
+	if err := ethash.verifySeal(nil, types.CopyHeader(sealed), false); err != nil {
+		t.Fatalf("variant seal rejected by variant verifier: %v", err)
+	}
+	standard := NewTester(nil, false)
+	defer standard.Close()
+	if digest, _ := standard.hashimotoLight(sealed); common.BytesToHash(digest) == sealed.MixDigest {
+		t.Fatal("variant seal produced standard mix digest")
+	}
+	mainnet := New(Config{PowMode: ModeNormal, MixVariant: MixFNV1a}, nil, false)
+	defer mainnet.Close()
+	if mainnet.config.MixVariant != MixStandard {
+		t.Fatalf("mix variant enabled outside of test mode: %v", mainnet.config.MixVariant)
+	}
+}
+
 // This test checks that cache lru logic doesn't crash under load.
 // It reproduces https://github.com/ethereum/go-ethereum/issues/14943
 func TestCacheFileEvict(t *testing.T) {
@@ -99,7 +197,7 @@ func TestRemoteSealer(t *testing.T) {
 	ethash := NewTester(nil, false)
 	defer ethash.Close()
 
-	api := &API{ethash}
+	api := &API{ethash: ethash}
 	if _, err := api.GetWork(); err != errNoMiningWork {
 		t.Error("expect to return an error indicate there is no mining work")
 	}
@@ -119,7 +217,7 @@ func TestRemoteSealer(t *testing.T) {
 		t.Error("expect to return a mining work has same hash")
 	}
 
-	if res := api.SubmitWork(types.BlockNonce{}, sealhash, common.Hash{}, nil); res {
+	if res := api.SubmitWork(context.Background(), types.BlockNonce{}, sealhash, common.Hash{}, nil, nil, nil); res {
 		t.Error("expect to return false when submit a fake solution")
 	}
 	// Push new block with same block number to replace the original one.
@@ -146,9 +244,9 @@ func TestHashrate(t *testing.T) {
 		t.Error("expect the result should be zero")
 	}
 
-	api := &API{ethash}
+	api := &API{ethash: ethash}
 	for i := 0; i < len(hashrate); i += 1 {
-		if res := api.SubmitHashrate(hashrate[i], ids[i]); !res {
+		if res := api.SubmitHashrate(context.Background(), hashrate[i], ids[i], nil); !res {
 			t.Error("remote miner submit hashrate failed")
 		}
 		expect += uint64(hashrate[i])
@@ -158,17 +256,224 @@ func TestHashrate(t *testing.T) {
 	}
 }
 
+// Tests that individual miners are reported active after submitting hash rate.
+func TestIsMinerActive(t *testing.T) {
+	ethash := NewTester(nil, false)
+	defer ethash.Close()
+
+	api := &API{ethash: ethash}
+	if activity, err := api.IsMinerActive(common.HexToHash("a")); err != nil || activity.Active || activity.LastSeen != 0 {
+		t.Errorf("unknown miner reported: %+v, %v", activity, err)
+	}
+	api.SubmitHashrate(context.Background(), 100, common.HexToHash("a"), nil)
+	activity, err := api.IsMinerActive(common.HexToHash("a"))
+	if err != nil {
+		t.Fatalf("failed to query miner: %v", err)
+	}
+	if !activity.Active || time.Since(time.Unix(int64(activity.LastSeen), 0)) > time.Minute {
+		t.Errorf("active miner misreported: %+v", activity)
+	}
+}
+
 func TestClosedRemoteSealer(t *testing.T) {
 	ethash := NewTester(nil, false)
 	time.Sleep(1 * time.Second) // ensure exit channel is listening
 	ethash.Close()
 
-	api := &API{ethash}
+	api := &API{ethash: ethash}
 	if _, err := api.GetWork(); err != errEthashStopped {
 		t.Error("expect to return an error to indicate ethash is stopped")
 	}
 
-	if res := api.SubmitHashrate(hexutil.Uint64(100), common.HexToHash("a")); res {
+	if res := api.SubmitHashrate(context.Background(), hexutil.Uint64(100), common.HexToHash("a"), nil); res {
 		t.Error("expect to return false when submit hashrate to a stopped ethash")
 	}
 }
+
+// Tests that the generated datasets can be iterated and iteration stopped early.
+func TestForEachCachedDataset(t *testing.T) {
+	ethash := NewTester(nil, false)
+	defer ethash.Close()
+
+	ethash.dataset(1, false)
+
+	var epochs []uint64
+	err := ethash.ForEachCachedDataset(func(epoch uint64, size uint64) bool {
+		if size != 32*1024 {
+			t.Errorf("epoch %d size mismatch: have %d, want %d", epoch, size, 32*1024)
+		}
+		epochs = append(epochs, epoch)
+		return false
+	})
+	if err != nil {
+		t.Fatalf("failed to iterate datasets: %v", err)
+	}
+	if len(epochs) != 1 || epochs[0] != 0 {
+		t.Fatalf("iterated epochs mismatch: have %v, want [0]", epochs)
+	}
+}
+
+// Tests that dataset readiness is reported for the epoch of the queried block.
+func TestDatasetReady(t *testing.T) {
+	ethash := NewTester(nil, false)
+	defer ethash.Close()
+
+	if ethash.DatasetReady(1) {
+		t.Fatalf("dataset ready before generation")
+	}
+	ethash.dataset(1, false)
+	waitFutureDataset(ethash, 1)
+
+	if !ethash.DatasetReady(epochLength - 1) {
+		t.Errorf("generated dataset not ready")
+	}
+	if ethash.DatasetReady(2 * epochLength) {
+		t.Errorf("dataset of a later epoch ready")
+	}
+	fake := NewFaker()
+	defer fake.Close()
+	if !fake.DatasetReady(1) {
+		t.Errorf("fake engine dataset not ready")
+	}
+}
+
+// Tests that prefetching beyond the future item keeps the replaced one cached.
+func TestLRUPrefetch(t *testing.T) {
+	lru := newlru(3, newCache)
+
+	first := lru.prefetch(5)
+	if lru.prefetch(5) != first {
+		t.Fatalf("prefetched future item recreated")
+	}
+	lru.prefetch(6)
+	if item, ok := lru.peek(5); !ok || item != first {
+		t.Fatalf("replaced future item dropped")
+	}
+	if item, _ := lru.get(5); item != first {
+		t.Fatalf("replaced future item recreated")
+	}
+}
+
+// Tests that caches of multiple epochs can be pregenerated concurrently.
+func TestPregenerateCaches(t *testing.T) {
+	ethash := New(Config{PowMode: ModeTest, CachesInMem: 4, CacheGenThreads: 2}, nil, false)
+	defer ethash.Close()
+
+	ethash.PregenerateCaches([]uint64{0, 1, 2})
+	for epoch := uint64(0); epoch < 3; epoch++ {
+		if c, ok := ethash.caches.peek(epoch); !ok || len(c.cache) == 0 {
+			t.Errorf("epoch %d cache not generated", epoch)
+		}
+	}
+}
+
+// Benchmarks the generation of multiple epochs' verification caches with
+// different worker counts.
+func BenchmarkPregenerateCaches(b *testing.B) {
+	epochs := []uint64{0, 1, 2, 3}
+	for _, threads := range []int{1, len(epochs)} {
+		b.Run(fmt.Sprintf("threads-%d", threads), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				ethash := New(Config{CachesInMem: len(epochs), CacheGenThreads: threads}, nil, false)
+				ethash.PregenerateCaches(epochs)
+				ethash.Close()
+			}
+		})
+	}
+}
+
+// Tests that the datasets of the current and next epoch can be generated ahead
+// of use, with their progress and disk usage reported.
+func TestGenerateDAG(t *testing.T) {
+	ethash := New(Config{PowMode: ModeTest, DatasetDir: t.TempDir(), DatasetsInMem: 1, DatasetsOnDisk: 2}, nil, false)
+	defer ethash.Close()
+
+	chain := &fakeChainReader{head: &types.Header{Number: big.NewInt(10)}}
+	api := &API{ethash: ethash, chain: chain}
+
+	status, err := api.GetDAGStatus()
+	if err != nil {
+		t.Fatalf("failed to get dag status: %v", err)
+	}
+	if status.Epoch != 0 || status.NextEpoch != 1 || status.Progress != 0 || status.DiskUsage != 0 {
+		t.Fatalf("initial status mismatch: %+v", status)
+	}
+	if _, err := api.GenerateDAG(2); err == nil {
+		t.Fatal("dataset beyond the next epoch generated")
+	}
+	gens := datasetGens.generations(1)
+	if ok, err := api.GenerateDAG(1); !ok || err != nil {
+		t.Fatalf("failed to generate next dataset: %v", err)
+	}
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		if status, _ = api.GetDAGStatus(); status.NextProgress == 100 {
+			break
+		}
+		if time.Since(start) > 10*time.Second {
+			t.Fatalf("next dataset not generated: %+v", status)
+		}
+	}
+	if status.Progress != 0 {
+		t.Errorf("current dataset generated along: %+v", status)
+	}
+	if status.DiskUsage == 0 {
+		t.Errorf("disk usage of the generated dataset missing: %+v", status)
+	}
+	// The pregenerated dataset is the one used once mining reaches the epoch
+	d := ethash.dataset(epochLength, false)
+	waitFutureDataset(ethash, epochLength)
+
+	if !d.generated() || datasetGens.generations(1) != gens+1 {
+		t.Errorf("pregenerated dataset not reused: generations %d, want %d", datasetGens.generations(1), gens+1)
+	}
+}
+
+// waitFutureDataset blocks until the dataset of the epoch following the block,
+// generated in the background on retrieving the block's dataset, is done, so
+// that its generation is not counted by other tests.
+func waitFutureDataset(ethash *Ethash, block uint64) {
+	if future, ok := ethash.datasets.peek(block/epochLength + 1); ok {
+		future.generate(ethash.config.DatasetDir, ethash.config.DatasetsOnDisk, ethash.config.DatasetsLockMmap, ethash.config.PowMode == ModeTest)
+	}
+}
+
+// Tests that an external dataset generator is used if its output passes the
+// spot checks, and replaced by the CPU generation otherwise.
+func TestDatasetGenerator(t *testing.T) {
+	reference := newDataset(0)
+	reference.generate("", 0, false, true)
+
+	var calls int
+	valid := func(dest []uint32, epoch uint64, cache []uint32, progress *atomic.Uint64) error {
+		calls++
+		generateDatasetTracked(dest, epoch, cache, progress)
+		return nil
+	}
+	corrupt := func(dest []uint32, epoch uint64, cache []uint32, progress *atomic.Uint64) error {
+		calls++
+		generateDatasetTracked(dest, epoch, cache, progress)
+		dest[len(dest)/2] ^= 1
+		return nil
+	}
+	failing := func(dest []uint32, epoch uint64, cache []uint32, progress *atomic.Uint64) error {
+		calls++
+		return errors.New("no device")
+	}
+	for i, generator := range []DatasetGenerator{valid, corrupt, failing} {
+		calls = 0
+
+		d := newDataset(0)
+		d.generator = generator
+		d.generate("", 0, false, true)
+
+		if calls != 1 {
+			t.Errorf("test %d: generator calls mismatch: have %d, want 1", i, calls)
+		}
+		if !reflect.DeepEqual(d.dataset, reference.dataset) {
+			t.Errorf("test %d: dataset mismatch", i)
+		}
+		if d.percent() != 100 {
+			t.Errorf("test %d: progress mismatch: have %v, want 100", i, d.percent())
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package ethash
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Metrics of the remote sealer, exported alongside all others by the metrics
+// server, e.g. in the Prometheus format on /debug/metrics/prometheus.
+var (
+	workIssuedCounter = metrics.NewRegisteredCounter("ethash/work/issued", nil)
+	workEpochGauge    = metrics.NewRegisteredGauge("ethash/work/epoch", nil)
+	remoteMinersGauge = metrics.NewRegisteredGauge("ethash/remote/miners", nil) // Miners reporting their hash rate
+	sealLatencyTimer  = metrics.NewRegisteredTimer("ethash/seal/latency", nil)  // Time from issuing work to its solution
+	sealedProfitGauge = metrics.NewRegisteredGauge("ethash/seal/profit", nil)   // Fees and transfers of the last sealed block in gwei
+	sealTimeMeter     = metrics.NewRegisteredMeter("ethash/seal/time", nil)     // Nanoseconds spent searching nonces by the local threads
+
+	verifyCacheHitMeter  = metrics.NewRegisteredMeter("ethash/verify/cache/hit", nil)  // Seal verifications served from memoized PoW values
+	verifyCacheMissMeter = metrics.NewRegisteredMeter("ethash/verify/cache/miss", nil) // Seal verifications recomputing the PoW values
+	submitStatusCounts   = func() (counters [numSubmitStatus]metrics.Counter) {
+		for status := range counters {
+			name := strings.ReplaceAll(submitStatus(status).String(), "-", "_")
+			counters[status] = metrics.NewRegisteredCounter("ethash/submit/"+name, nil)
+		}
+		return counters
+	}()
+)
+
+// recordSeal updates the seal metrics of a remotely sealed block by pow-hash,
+// from the time its work was issued and the fees captured when assembling it.
+func (s *remoteSealer) recordSeal(hash common.Hash) {
+	if work := s.issued[hash]; work != nil {
+		sealLatencyTimer.UpdateSince(work.issued)
+	}
+	if fees, ok := s.ethash.fees.Get(hash); ok {
+		profit := new(big.Int).Set(fees.tips)
+		if fees.transfers != nil {
+			profit.Add(profit, fees.transfers)
+		}
+		sealedProfitGauge.Update(profit.Div(profit, big.NewInt(params.GWei)).Int64())
+	}
+}
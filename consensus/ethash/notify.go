@@ -0,0 +1,309 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package ethash
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	// notifyQueueSize is the number of notifications queued for a slow URL
+	// before the oldest ones are dropped.
+	notifyQueueSize = 128
+
+	// notifyAttempts is the number of times a work notification is pushed to
+	// a failing URL before giving up on it, unless superseded by newer work.
+	notifyAttempts = 4
+
+	// notifyMinBackoff and notifyMaxBackoff bound the exponential backoff
+	// between the attempts of pushing to a failing URL.
+	notifyMinBackoff = 250 * time.Millisecond
+	notifyMaxBackoff = 8 * time.Second
+
+	// NotifySignatureHeader is the HTTP header carrying the hex encoded
+	// HMAC-SHA256 of a work notification, keyed with the configured secret and
+	// prefixed with "sha256=". It's only set if a secret is configured.
+	NotifySignatureHeader = "X-Bitnet-Signature"
+
+	// workNotificationVersion is the version of the structured notification
+	// schema, bumped on incompatible changes.
+	workNotificationVersion = 1
+)
+
+// WorkNotification is the structured work notification pushed to the notify
+// URLs if NotifyStructured is configured.
+type WorkNotification struct {
+	Version  int            `json:"version"`
+	Sequence hexutil.Uint64 `json:"sequence"` // Increasing with every notified work package
+	Sent     hexutil.Uint64 `json:"sent"`     // Unix timestamp of the notification
+	Work     ExtendedWork   `json:"work"`     // Work package with typed fields
+	Header   *types.Header  `json:"header"`   // Full header of the pending block
+}
+
+// NotifyHealth is the delivery record of a notify URL.
+type NotifyHealth struct {
+	URL         string         `json:"url"`
+	Delivered   hexutil.Uint64 `json:"delivered"`   // Notifications accepted with a 2xx status
+	Failed      hexutil.Uint64 `json:"failed"`      // Failed delivery attempts, including retries
+	Abandoned   hexutil.Uint64 `json:"abandoned"`   // Notifications given up after all attempts
+	Superseded  hexutil.Uint64 `json:"superseded"`  // Failing notifications given up for newer work
+	Dropped     hexutil.Uint64 `json:"dropped"`     // Notifications dropped from a full queue
+	Failing     hexutil.Uint64 `json:"failing"`     // Consecutive failed attempts, zero if healthy
+	LastSuccess hexutil.Uint64 `json:"lastSuccess"` // Unix timestamp, zero if none succeeded yet
+	LastFailure hexutil.Uint64 `json:"lastFailure"` // Unix timestamp, zero if none failed yet
+	LastError   string         `json:"lastError,omitempty"`
+	Latency     hexutil.Uint64 `json:"latency"` // Milliseconds of the last successful delivery
+}
+
+// notifyEndpoint delivers the work notifications to a single URL, in order.
+// A failing notification is retried until delivered, out of attempts, or newer
+// work is queued, as miners have no use for the stale one anymore.
+type notifyEndpoint struct {
+	url string
+
+	lock    sync.Mutex
+	queue   [][]byte      // Undelivered notifications, oldest first
+	wake    chan struct{} // Signals a newly queued notification
+	health  NotifyHealth
+	success time.Time // Time of the last successful delivery
+
+	deliveredMeter metrics.Meter
+	failedMeter    metrics.Meter
+	latencyTimer   metrics.Timer
+}
+
+// workNotifier pushes the work packages of the remote sealer to the notify URLs.
+type workNotifier struct {
+	ethash    *Ethash
+	endpoints []*notifyEndpoint
+	client    *http.Client
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// newWorkNotifier starts delivering work notifications to the given URLs. The
+// health of each URL is metered under ethash/notify/<index>/.
+func newWorkNotifier(ethash *Ethash, urls []string) *workNotifier {
+	ctx, cancel := context.WithCancel(context.Background())
+	n := &workNotifier{
+		ethash: ethash,
+		client: &http.Client{Timeout: remoteSealerTimeout},
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	for i, url := range urls {
+		prefix := fmt.Sprintf("ethash/notify/%d/", i)
+		endpoint := &notifyEndpoint{
+			url:            url,
+			wake:           make(chan struct{}, 1),
+			health:         NotifyHealth{URL: url},
+			deliveredMeter: metrics.NewRegisteredMeter(prefix+"delivered", nil),
+			failedMeter:    metrics.NewRegisteredMeter(prefix+"failed", nil),
+			latencyTimer:   metrics.NewRegisteredTimer(prefix+"latency", nil),
+		}
+		n.endpoints = append(n.endpoints, endpoint)
+
+		n.wg.Add(1)
+		go n.loop(endpoint)
+	}
+	return n
+}
+
+// close aborts all pending deliveries and waits for the delivery loops to exit.
+func (n *workNotifier) close() {
+	n.cancel()
+	n.wg.Wait()
+}
+
+// notify queues a notification for delivery to all URLs.
+func (n *workNotifier) notify(blob []byte) {
+	for _, endpoint := range n.endpoints {
+		endpoint.lock.Lock()
+		if len(endpoint.queue) >= notifyQueueSize {
+			endpoint.queue = endpoint.queue[1:]
+			endpoint.health.Dropped++
+		}
+		endpoint.queue = append(endpoint.queue, blob)
+		endpoint.lock.Unlock()
+
+		select {
+		case endpoint.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// next returns the notification to deliver, which is the failing current one
+// unless newer notifications are queued.
+func (endpoint *notifyEndpoint) next(current []byte) ([]byte, bool) {
+	endpoint.lock.Lock()
+	defer endpoint.lock.Unlock()
+
+	if len(endpoint.queue) == 0 {
+		return current, false
+	}
+	if current != nil {
+		endpoint.health.Superseded++
+	}
+	blob := endpoint.queue[0]
+	endpoint.queue[0] = nil
+	endpoint.queue = endpoint.queue[1:]
+	return blob, true
+}
+
+// endpoint returns the endpoint of the given URL, nil if not configured.
+func (n *workNotifier) endpoint(url string) *notifyEndpoint {
+	for _, endpoint := range n.endpoints {
+		if endpoint.url == url {
+			return endpoint
+		}
+	}
+	return nil
+}
+
+// health returns the delivery records of all URLs.
+func (n *workNotifier) health() []NotifyHealth {
+	health := make([]NotifyHealth, 0, len(n.endpoints))
+	for _, endpoint := range n.endpoints {
+		endpoint.lock.Lock()
+		health = append(health, endpoint.health)
+		endpoint.lock.Unlock()
+	}
+	return health
+}
+
+// loop delivers the notifications of an endpoint, retrying failed attempts
+// with exponential backoff.
+func (n *workNotifier) loop(endpoint *notifyEndpoint) {
+	defer n.wg.Done()
+
+	var (
+		blob     []byte
+		fresh    bool
+		attempts int
+		backoff  = notifyMinBackoff
+		retry    = time.NewTimer(0)
+		retryC   <-chan time.Time
+	)
+	<-retry.C
+	defer retry.Stop()
+
+	for {
+		// Deliver the queued notifications unless backing off a failing URL
+		if retryC == nil {
+			if blob, fresh = endpoint.next(blob); fresh {
+				attempts = 0
+			}
+			if blob != nil {
+				attempts++
+				if err := n.deliver(endpoint, blob); err == nil {
+					blob, backoff = nil, notifyMinBackoff
+					continue
+				}
+				if attempts >= notifyAttempts {
+					n.ethash.config.Log.Warn("Giving up remote miner notification", "url", endpoint.url, "attempts", attempts)
+					endpoint.lock.Lock()
+					endpoint.health.Abandoned++
+					endpoint.lock.Unlock()
+					blob = nil
+				}
+				// Back off even if given up, not to hammer the URL with newer work
+				retry.Reset(backoff)
+				retryC = retry.C
+				if backoff *= 2; backoff > notifyMaxBackoff {
+					backoff = notifyMaxBackoff
+				}
+			}
+		}
+		select {
+		case <-endpoint.wake:
+		case <-retryC:
+			retryC = nil
+		case <-n.ctx.Done():
+			return
+		}
+	}
+}
+
+// deliver pushes a notification to an endpoint once, recording the outcome.
+func (n *workNotifier) deliver(endpoint *notifyEndpoint, blob []byte) error {
+	start := time.Now()
+	err := n.post(endpoint.url, blob)
+
+	endpoint.lock.Lock()
+	defer endpoint.lock.Unlock()
+
+	now := time.Now()
+	if err != nil {
+		n.ethash.config.Log.Debug("Failed to notify remote miner", "url", endpoint.url, "err", err)
+		endpoint.health.Failed++
+		endpoint.health.Failing++
+		endpoint.health.LastFailure = hexutil.Uint64(now.Unix())
+		endpoint.health.LastError = err.Error()
+		endpoint.failedMeter.Mark(1)
+		return err
+	}
+	n.ethash.config.Log.Trace("Notified remote miner", "url", endpoint.url)
+	endpoint.health.Delivered++
+	endpoint.health.Failing = 0
+	endpoint.health.LastSuccess = hexutil.Uint64(now.Unix())
+	endpoint.health.Latency = hexutil.Uint64(now.Sub(start).Milliseconds())
+	endpoint.success = now
+	endpoint.deliveredMeter.Mark(1)
+	endpoint.latencyTimer.UpdateSince(start)
+	return nil
+}
+
+// post sends a notification, signing it if a secret is configured.
+func (n *workNotifier) post(url string, blob []byte) error {
+	req, err := http.NewRequestWithContext(n.ctx, http.MethodPost, url, bytes.NewReader(blob))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret := n.ethash.config.NotifySecret; secret != "" {
+		req.Header.Set(NotifySignatureHeader, SignNotification(secret, blob))
+	}
+	res, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("notify url responded %s", res.Status)
+	}
+	return nil
+}
+
+// SignNotification returns the signature of a work notification, as sent in
+// the NotifySignatureHeader for the receivers to authenticate it with.
+func SignNotification(secret string, blob []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(blob)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,156 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package ethash
+
+import (
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Tests that failed notifications are retried, signed with the configured
+// secret, and accounted for in the health of the URL.
+func TestNotifyRetry(t *testing.T) {
+	var (
+		secret   = "s3cret"
+		calls    = make(chan string, notifyAttempts)
+		attempts atomic.Int32
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		blob, _ := io.ReadAll(req.Body)
+		if have, want := req.Header.Get(NotifySignatureHeader), SignNotification(secret, blob); have != want {
+			t.Errorf("signature mismatch: have %s, want %s", have, want)
+		}
+		if attempts.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		calls <- string(blob)
+	}))
+	defer server.Close()
+
+	ethash := New(Config{PowMode: ModeTest, NotifySecret: secret}, []string{server.URL}, true)
+	defer ethash.Close()
+	api := &API{ethash: ethash}
+
+	ethash.Seal(nil, types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100)}), nil, nil)
+	var first string
+	for i := 0; i < 3; i++ {
+		select {
+		case blob := <-calls:
+			if i == 0 {
+				first = blob
+			} else if blob != first {
+				t.Errorf("attempt %d: payload changed", i)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("attempt %d timed out", i)
+		}
+	}
+	if h := waitDelivered(func() []NotifyHealth { health, _ := api.GetNotifyHealth(); return health }); h.URL != server.URL || h.Delivered != 1 || h.Failed != 2 || h.Failing != 0 || h.LastError == "" {
+		t.Errorf("health mismatch: %+v", h)
+	}
+}
+
+// Tests that a URL failing with newer work queued gives up on the stale work.
+func TestNotifySuperseded(t *testing.T) {
+	var (
+		ethash = New(Config{PowMode: ModeTest}, nil, true)
+		calls  = make(chan string, 16)
+	)
+	defer ethash.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		blob, _ := io.ReadAll(req.Body)
+		if string(blob) == "old" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		calls <- string(blob)
+	}))
+	defer server.Close()
+
+	n := newWorkNotifier(ethash, []string{server.URL})
+	defer n.close()
+
+	n.notify([]byte("old"))
+	if blob := <-calls; blob != "old" {
+		t.Fatalf("first delivery mismatch: have %s", blob)
+	}
+	n.notify([]byte("new"))
+	select {
+	case blob := <-calls:
+		if blob != "new" {
+			t.Fatalf("stale work retried: have %s", blob)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("newer work not delivered")
+	}
+	if h := waitDelivered(n.health); h.Superseded != 1 || h.Delivered != 1 || h.Failed != 1 {
+		t.Errorf("health mismatch: %+v", h)
+	}
+}
+
+// Tests that structured notifications carry the typed work package and header.
+func TestRemoteNotifyStructured(t *testing.T) {
+	sink := make(chan *WorkNotification, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		notification := new(WorkNotification)
+		if err := json.NewDecoder(req.Body).Decode(notification); err != nil {
+			t.Errorf("failed to decode notification: %v", err)
+		}
+		sink <- notification
+	}))
+	defer server.Close()
+
+	ethash := New(Config{PowMode: ModeTest, NotifyStructured: true, NotifyFull: true}, []string{server.URL}, true)
+	defer ethash.Close()
+
+	for i := int64(1); i <= 2; i++ {
+		header := &types.Header{Number: big.NewInt(i), Difficulty: big.NewInt(100), GasLimit: 8000000}
+		ethash.Seal(nil, types.NewBlockWithHeader(header), nil, nil)
+
+		select {
+		case notification := <-sink:
+			if notification.Version != workNotificationVersion || uint64(notification.Sequence) != uint64(i) {
+				t.Errorf("notification %d: envelope mismatch: %+v", i, notification)
+			}
+			if now := time.Now().Unix(); int64(notification.Sent) < now-5 || int64(notification.Sent) > now {
+				t.Errorf("notification %d: send time mismatch: have %d, want around %d", i, notification.Sent, now)
+			}
+			if uint64(notification.Work.BlockNumber) != uint64(i) || uint64(notification.Work.GasLimit) != header.GasLimit {
+				t.Errorf("notification %d: work mismatch: %+v", i, notification.Work)
+			}
+			if notification.Header == nil || notification.Header.Number.Int64() != i {
+				t.Errorf("notification %d: header mismatch: %+v", i, notification.Header)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatalf("notification %d timed out", i)
+		}
+	}
+}
+
+// waitDelivered polls the health of the first URL until a notification is
+// delivered, as the handler returning does not mean the response was processed.
+func waitDelivered(poll func() []NotifyHealth) NotifyHealth {
+	health := poll()
+	for deadline := time.Now().Add(3 * time.Second); health[0].Delivered == 0 && time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		health = poll()
+	}
+	return health[0]
+}
@@ -0,0 +1,136 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package ethash
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// sealedRetained is the number of recently sealed blocks tracked for the
+// orphan statistics.
+const sealedRetained = 4096
+
+// sealedBlock is a block sealed by this node, locally or by a remote miner.
+type sealedBlock struct {
+	number   uint64
+	hash     common.Hash
+	coinbase common.Address
+}
+
+// sealedTracker records the blocks sealed by this node to later classify them
+// as included, uncled or orphaned.
+type sealedTracker struct {
+	lock   sync.Mutex
+	blocks []sealedBlock // Sealed blocks in sealing order
+}
+
+// add records a freshly sealed block.
+func (t *sealedTracker) add(block *types.Block) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.blocks = append(t.blocks, sealedBlock{number: block.NumberU64(), hash: block.Hash(), coinbase: block.Coinbase()})
+	if len(t.blocks) > sealedRetained {
+		t.blocks = append(t.blocks[:0], t.blocks[len(t.blocks)-sealedRetained:]...)
+	}
+}
+
+// since returns the tracked blocks sealed at the given height or above.
+func (t *sealedTracker) since(number uint64) []sealedBlock {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	var blocks []sealedBlock
+	for _, block := range t.blocks {
+		if block.number >= number {
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks
+}
+
+// OrphanStats classifies the blocks sealed for a coinbase by their fate.
+type OrphanStats struct {
+	Sealed   hexutil.Uint64 `json:"sealed"`
+	Included hexutil.Uint64 `json:"included"` // Became canonical blocks
+	Uncled   hexutil.Uint64 `json:"uncled"`   // Included as uncles by canonical blocks
+	Orphaned hexutil.Uint64 `json:"orphaned"` // Neither, and too deep to still become uncles
+	Pending  hexutil.Uint64 `json:"pending"`  // Neither yet, but may still become uncles
+}
+
+// orphanStats classifies the blocks sealed within the given number of blocks
+// below and including the chain head, per coinbase. Uncles are only detected
+// if the chain can serve block bodies, otherwise they count as orphaned.
+func (ethash *Ethash) orphanStats(chain consensus.ChainHeaderReader, window uint64) map[common.Address]*OrphanStats {
+	head := chain.CurrentHeader().Number.Uint64()
+	from := uint64(0)
+	if window <= head {
+		from = head - window + 1
+	}
+	reader, bodies := chain.(consensus.ChainReader)
+
+	stats := make(map[common.Address]*OrphanStats)
+	for _, block := range ethash.sealed.since(from) {
+		stat := stats[block.coinbase]
+		if stat == nil {
+			stat = new(OrphanStats)
+			stats[block.coinbase] = stat
+		}
+		stat.Sealed++
+
+		if block.number > head {
+			stat.Pending++
+			continue
+		}
+		if canonical := chain.GetHeaderByNumber(block.number); canonical != nil && canonical.Hash() == block.hash {
+			stat.Included++
+			continue
+		}
+		if bodies && uncled(reader, block, head) {
+			stat.Uncled++
+			continue
+		}
+		if block.number+maxUncleDepth <= head {
+			stat.Orphaned++
+		} else {
+			stat.Pending++
+		}
+	}
+	return stats
+}
+
+// uncled returns whether the given block was included as an uncle by any of
+// the canonical blocks that could include it.
+func uncled(chain consensus.ChainReader, block sealedBlock, head uint64) bool {
+	for number := block.number + 1; number <= block.number+maxUncleDepth && number <= head; number++ {
+		header := chain.GetHeaderByNumber(number)
+		if header == nil || header.UncleHash == types.EmptyUncleHash {
+			continue
+		}
+		including := chain.GetBlock(header.Hash(), number)
+		if including == nil {
+			continue
+		}
+		for _, uncle := range including.Uncles() {
+			if uncle.Hash() == block.hash {
+				return true
+			}
+		}
+	}
+	return false
+}
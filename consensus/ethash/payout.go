@@ -0,0 +1,38 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package ethash
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// splitPayout transfers the shares of the rewards and priority fees credited to
+// the coinbase of a block configured by the chain's payout split to the
+// recipients. Blocks before the activation block and of other coinbases are left
+// untouched.
+func splitPayout(config *params.ChainConfig, state *state.StateDB, header *types.Header, reward *big.Int) {
+	if !config.IsPayoutSplit(header.Number) || header.Coinbase != config.PayoutSplit.Coinbase {
+		return
+	}
+	for _, share := range config.PayoutSplit.Shares {
+		amount := new(big.Int).Mul(reward, new(big.Int).SetUint64(share.Percent))
+		amount.Div(amount, big.NewInt(100))
+
+		state.SubBalance(header.Coinbase, amount)
+		state.AddBalance(share.Address, amount)
+	}
+}
@@ -13,35 +13,55 @@
 package ethash
 
 import (
-	"bytes"
-	"context"
 	crand "crypto/rand"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"math"
 	"math/big"
 	"math/rand"
-	"net/http"
 	"runtime"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
 )
 
 const (
 	// staleThreshold is the maximum depth of the acceptable stale but valid ethash solution.
 	staleThreshold = 7
+
+	// defaultDedupWindow is the default time a submitted solution is remembered
+	// for duplicate detection. It roughly spans the staleThreshold blocks a work
+	// package remains acceptable for, assuming ~15 second blocks.
+	defaultDedupWindow = staleThreshold * 15 * time.Second
+
+	// defaultWorkLookupWindow is the default time issued work packages can be
+	// looked up by their pow-hash, spanning the same staleness window.
+	defaultWorkLookupWindow = staleThreshold * 15 * time.Second
+
+	// extraNonceSize is the number of bytes reserved at the end of the header
+	// extra-data of the served work for the miner chosen extraNonce.
+	extraNonceSize = 4
 )
 
 var (
 	errNoMiningWork      = errors.New("no mining work available yet")
 	errInvalidSealResult = errors.New("invalid or stale proof-of-work solution")
+	errWorkNotRetained   = errors.New("work not retained")
+	errWorkNotIssued     = errors.New("work not issued within the lookup window")
+	errInvalidExtraNonce = errors.New("invalid extraNonce placement")
+	errNotifyURLUnknown  = errors.New("notify url not configured")
 )
 
 // Seal implements consensus.Engine, attempting to find a nonce that satisfies
@@ -64,6 +84,7 @@ func (ethash *Ethash) Seal(chain consensus.ChainHeaderReader, block *types.Block
 	}
 	// Create a runner and the multiple search threads it directs
 	abort := make(chan struct{})
+	ethash.localStats.started.Store(time.Now().UnixNano())
 
 	ethash.lock.Lock()
 	threads := ethash.threads
@@ -87,36 +108,57 @@ func (ethash *Ethash) Seal(chain consensus.ChainHeaderReader, block *types.Block
 		ethash.remote.workCh <- &sealTask{block: block, results: results}
 	}
 	var (
-		pend   sync.WaitGroup
-		locals = make(chan *types.Block)
+		pend       sync.WaitGroup
+		locals     = make(chan *types.Block)
+		placements = ethash.placeThreads(threads)
+		stats      = ethash.localStats.resetThreads(placements)
 	)
-	for i := 0; i < threads; i++ {
+	for i, placement := range placements {
 		pend.Add(1)
-		go func(id int, nonce uint64) {
+		go func(id int, placement threadPlacement, nonce uint64) {
 			defer pend.Done()
-			ethash.mine(block, id, nonce, abort, locals)
-		}(i, uint64(ethash.rand.Int63()))
+			ethash.localStats.running.Add(1)
+			defer ethash.localStats.running.Add(-1)
+			ethash.mine(block, id, placement, nonce, abort, locals, stats[id])
+		}(i, placement, uint64(ethash.rand.Int63()))
+	}
+	// Wait until sealing is terminated or enough nonces are found
+	wanted := ethash.config.CollectSolutions
+	if wanted < 1 {
+		wanted = 1
 	}
-	// Wait until sealing is terminated or a nonce is found
 	go func() {
-		var result *types.Block
-		select {
-		case <-stop:
-			// Outside abort, stop all miner threads
-			close(abort)
-		case result = <-locals:
-			// One of the threads found a block, abort all others
+		for found, done := 0, false; !done; {
 			select {
-			case results <- types.SealResult{Block: result}:
-			default:
-				ethash.config.Log.Warn("Sealing result is not read by miner", "mode", "local", "sealhash", ethash.SealHash(block.Header()))
-			}
-			close(abort)
-		case <-ethash.update:
-			// Thread count was changed on user request, restart
-			close(abort)
-			if err := ethash.Seal(chain, block, results, stop); err != nil {
-				ethash.config.Log.Error("Failed to restart sealing after update", "err", err)
+			case <-stop:
+				// Outside abort, stop all miner threads
+				close(abort)
+				done = true
+			case result := <-locals:
+				// One of the threads found a block, abort all others if enough were collected
+				ethash.localStats.found.Add(1)
+				ethash.blocksFound.Add(1)
+				ethash.sealed.add(result)
+				ethash.localStats.lastFound.Store(&BlockFound{Number: hexutil.Uint64(result.NumberU64()), Hash: result.Hash(), Time: hexutil.Uint64(time.Now().Unix())})
+				if ethash.config.OnSealed != nil {
+					ethash.config.OnSealed(result)
+				}
+				select {
+				case results <- types.SealResult{Block: result}:
+				default:
+					ethash.config.Log.Warn("Sealing result is not read by miner", "mode", "local", "sealhash", ethash.SealHash(block.Header()))
+				}
+				if found++; found >= wanted {
+					close(abort)
+					done = true
+				}
+			case <-ethash.update:
+				// Thread count was changed on user request, restart
+				close(abort)
+				if err := ethash.Seal(chain, block, results, stop); err != nil {
+					ethash.config.Log.Error("Failed to restart sealing after update", "err", err)
+				}
+				done = true
 			}
 		}
 		// Wait for all miners to terminate and return the block
@@ -127,7 +169,18 @@ func (ethash *Ethash) Seal(chain consensus.ChainHeaderReader, block *types.Block
 
 // mine is the actual proof-of-work miner that searches for a nonce starting from
 // seed that results in correct final block difficulty.
-func (ethash *Ethash) mine(block *types.Block, id int, seed uint64, abort chan struct{}, found chan *types.Block) {
+func (ethash *Ethash) mine(block *types.Block, id int, placement threadPlacement, seed uint64, abort chan struct{}, found chan *types.Block, stats *threadStats) {
+	logger := ethash.config.Log.New("miner", id)
+
+	// Pin the thread before touching the dataset, for a node local copy to be
+	// allocated on the right node
+	if placement.cpu >= 0 {
+		if err := pinThread(placement.cpu); err != nil {
+			logger.Warn("Failed to pin mining thread", "cpu", placement.cpu, "err", err)
+			placement.cpu = -1
+			stats.cpu.Store(-1)
+		}
+	}
 	// Extract some data from the header
 	var (
 		header  = block.Header()
@@ -135,15 +188,21 @@ func (ethash *Ethash) mine(block *types.Block, id int, seed uint64, abort chan s
 		target  = new(big.Int).Div(two256, header.Difficulty)
 		number  = header.Number.Uint64()
 		dataset = ethash.dataset(number, false)
+		items   = dataset.dataset
+		keccak  = ethash.keccakHasher()
+		mixer   = ethash.mixer()
 	)
+	if ethash.config.NUMALocalDAG && placement.cpu >= 0 && len(cpuTopology()) > 1 {
+		items = ethash.nodeDatasets.get(dataset, placement.node)
+	}
 	// Start generating random nonces until we abort or find a good one
 	var (
 		attempts  = int64(0)
 		nonce     = seed
 		powBuffer = new(big.Int)
 	)
-	logger := ethash.config.Log.New("miner", id)
-	logger.Trace("Started ethash search for new nonces", "seed", seed)
+	logger.Trace("Started ethash search for new nonces", "seed", seed, "cpu", placement.cpu, "node", placement.node)
+	defer func(start time.Time) { sealTimeMeter.Mark(int64(time.Since(start))) }(time.Now())
 search:
 	for {
 		select {
@@ -151,6 +210,9 @@ search:
 			// Mining terminated, update stats and abort
 			logger.Trace("Ethash nonce search aborted", "attempts", nonce-seed)
 			ethash.hashrate.Mark(attempts)
+			ethash.localStats.attempts.Add(uint64(attempts))
+			ethash.localStats.nonce.Store(nonce)
+			stats.attempts.Add(uint64(attempts))
 			break search
 
 		default:
@@ -158,10 +220,13 @@ search:
 			attempts++
 			if (attempts % (1 << 15)) == 0 {
 				ethash.hashrate.Mark(attempts)
+				ethash.localStats.attempts.Add(uint64(attempts))
+				ethash.localStats.nonce.Store(nonce)
+				stats.attempts.Add(uint64(attempts))
 				attempts = 0
 			}
 			// Compute the PoW value of this nonce
-			digest, result := hashimotoFull(dataset.dataset, hash, nonce)
+			digest, result := hashimotoFullKeccak(keccak, mixer, items, hash, nonce)
 			if powBuffer.SetBytes(result).Cmp(target) <= 0 {
 				// Correct nonce found, create a new header with it
 				header = types.CopyHeader(header)
@@ -174,8 +239,12 @@ search:
 					logger.Trace("Ethash nonce found and reported", "attempts", nonce-seed, "nonce", nonce)
 				case <-abort:
 					logger.Trace("Ethash nonce found but discarded", "attempts", nonce-seed, "nonce", nonce)
+					break search
+				}
+				// Keep searching if multiple solutions are collected
+				if ethash.config.CollectSolutions <= 1 {
+					break search
 				}
-				break search
 			}
 			nonce++
 		}
@@ -185,29 +254,242 @@ search:
 	runtime.KeepAlive(dataset)
 }
 
+// keccakHasher creates the keccak primitives for a single search thread based
+// on the configured implementation.
+func (ethash *Ethash) keccakHasher() *keccakHasher {
+	keccak, _ := newKeccakHasher(ethash.config.KeccakImpl)
+	return keccak
+}
+
+// mixer returns the mix step of the hashimoto loop selected by MixVariant.
+func (ethash *Ethash) mixer() mixFunc {
+	return mixFuncs[ethash.config.MixVariant]
+}
+
+// localMiningStats tracks the search effort of the local CPU miner.
+type localMiningStats struct {
+	attempts atomic.Uint64 // Total number of nonces tried since startup
+	found    atomic.Uint64 // Number of blocks sealed by the local miner
+	nonce    atomic.Uint64 // Most recent nonce reported by a search thread
+	started  atomic.Int64  // Unix nano timestamp of the last Seal call
+	running  atomic.Int32  // Number of search threads currently running
+
+	lastFound atomic.Pointer[BlockFound] // Last block sealed by the local miner
+
+	lock    sync.Mutex     // Protects the thread list, replaced on every Seal call
+	threads []*threadStats // Search effort of the threads of the current search
+}
+
+// threadStats tracks the search effort of a single local mining thread.
+type threadStats struct {
+	cpu      atomic.Int32 // CPU the thread is pinned to, -1 if not pinned
+	node     int
+	started  time.Time
+	attempts atomic.Uint64
+}
+
+// LocalMiningStats is a snapshot of the local CPU miner's search effort.
+type LocalMiningStats struct {
+	Attempts    hexutil.Uint64      `json:"attempts"`    // Total number of nonces tried
+	BlocksFound hexutil.Uint64      `json:"blocksFound"` // Number of blocks sealed locally
+	Nonce       hexutil.Uint64      `json:"nonce"`       // Current search nonce
+	Uptime      hexutil.Uint64      `json:"uptime"`      // Seconds since the last Seal call
+	Threads     []ThreadMiningStats `json:"threads"`     // Search effort of the threads of the current search
+}
+
+// ThreadMiningStats is a snapshot of a local mining thread's search effort.
+type ThreadMiningStats struct {
+	ID       int            `json:"id"`
+	CPU      int            `json:"cpu"`  // CPU the thread is pinned to, -1 if not pinned
+	Node     int            `json:"node"` // NUMA node of the CPU
+	Attempts hexutil.Uint64 `json:"attempts"`
+	Hashrate hexutil.Uint64 `json:"hashrate"` // Average hashes per second of the current search
+}
+
+// resetThreads replaces the tracked threads with the ones of a new search.
+func (s *localMiningStats) resetThreads(placements []threadPlacement) []*threadStats {
+	threads := make([]*threadStats, len(placements))
+	for i, placement := range placements {
+		threads[i] = &threadStats{node: placement.node, started: time.Now()}
+		threads[i].cpu.Store(int32(placement.cpu))
+	}
+	s.lock.Lock()
+	s.threads = threads
+	s.lock.Unlock()
+
+	return threads
+}
+
+// snapshot returns the current local mining statistics.
+func (s *localMiningStats) snapshot() LocalMiningStats {
+	stats := LocalMiningStats{
+		Attempts:    hexutil.Uint64(s.attempts.Load()),
+		BlocksFound: hexutil.Uint64(s.found.Load()),
+		Nonce:       hexutil.Uint64(s.nonce.Load()),
+	}
+	if started := s.started.Load(); started != 0 {
+		stats.Uptime = hexutil.Uint64(time.Since(time.Unix(0, started)) / time.Second)
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	stats.Threads = make([]ThreadMiningStats, len(s.threads))
+	for i, thread := range s.threads {
+		attempts := thread.attempts.Load()
+		stats.Threads[i] = ThreadMiningStats{
+			ID:       i,
+			CPU:      int(thread.cpu.Load()),
+			Node:     thread.node,
+			Attempts: hexutil.Uint64(attempts),
+		}
+		if elapsed := time.Since(thread.started); elapsed > 0 {
+			stats.Threads[i].Hashrate = hexutil.Uint64(float64(attempts) / elapsed.Seconds())
+		}
+	}
+	return stats
+}
+
+// directWorks is the set of work registered for direct submission, used instead
+// of the remote sealer with AllowDirectSubmit.
+type directWorks struct {
+	works map[common.Hash]*directWork
+	lock  sync.Mutex
+}
+
+// directWork is a block registered for direct submission with the callback
+// receiving its verified solution.
+type directWork struct {
+	block  *types.Block
+	result func(*types.Block)
+}
+
+// RegisterWork registers a block for direct submission, returning the pow-hash
+// solutions must be submitted for. Verified solutions are passed to result,
+// after which the work is unregistered. It requires AllowDirectSubmit.
+func (ethash *Ethash) RegisterWork(block *types.Block, result func(*types.Block)) (common.Hash, error) {
+	if !ethash.config.AllowDirectSubmit {
+		return common.Hash{}, errors.New("direct submission disabled")
+	}
+	hash := ethash.SealHash(block.Header())
+
+	ethash.direct.lock.Lock()
+	defer ethash.direct.lock.Unlock()
+
+	if ethash.direct.works == nil {
+		ethash.direct.works = make(map[common.Hash]*directWork)
+	}
+	ethash.direct.works[hash] = &directWork{block: block, result: result}
+	return hash, nil
+}
+
+// UnregisterWork drops the work registered for direct submission with the given
+// pow-hash, if any.
+func (ethash *Ethash) UnregisterWork(hash common.Hash) {
+	ethash.direct.lock.Lock()
+	defer ethash.direct.lock.Unlock()
+
+	delete(ethash.direct.works, hash)
+}
+
+// submitDirect verifies a solution against the work registered for direct
+// submission, handing the sealed block to the work's callback if valid.
+func (ethash *Ethash) submitDirect(nonce types.BlockNonce, mixDigest common.Hash, sealhash common.Hash, extraNonce []byte) error {
+	ethash.direct.lock.Lock()
+	work := ethash.direct.works[sealhash]
+	ethash.direct.lock.Unlock()
+
+	if work == nil {
+		return errWorkNotRetained
+	}
+	header := work.block.Header()
+	if extraNonce != nil {
+		placed, err := ethash.placeExtraNonce(header, extraNonce)
+		if err != nil {
+			return err
+		}
+		header = placed
+	}
+	header.Nonce = nonce
+	header.MixDigest = mixDigest
+
+	if err := ethash.verifySeal(nil, header, false); err != nil {
+		return err
+	}
+	// Only deliver the first valid solution of the work
+	ethash.direct.lock.Lock()
+	if ethash.direct.works[sealhash] != work {
+		ethash.direct.lock.Unlock()
+		return errWorkNotRetained
+	}
+	delete(ethash.direct.works, sealhash)
+	ethash.direct.lock.Unlock()
+
+	ethash.blocksFound.Add(1)
+	sealed := work.block.WithSeal(header)
+	ethash.sealed.add(sealed)
+	if work.result != nil {
+		work.result(sealed)
+	}
+	return nil
+}
+
 // This is the timeout for HTTP requests to notify external miners.
 const remoteSealerTimeout = 1 * time.Second
 
+// workHistorySize is the number of recently generated work packages retained
+// for diagnostics.
+const workHistorySize = 64
+
+// workFeedBuffer is the number of regenerated work packages buffered for RPC
+// subscribers, for slow ones not to stall the remote sealer.
+const workFeedBuffer = 16
+
+// minerIdleTimeout is the time after which a remote miner not submitting its
+// hash rate is considered inactive and forgotten.
+const minerIdleTimeout = 10 * time.Second
+
 type remoteSealer struct {
 	works        map[common.Hash]*types.Block
+	issued       map[common.Hash]*IssuedWork // Work packages issued within the lookup window
 	rates        map[common.Hash]hashrate
+	submitted    map[submitKey]time.Time         // Recently accepted solutions for duplicate detection
+	submits      [numSubmitStatus]uint64         // Number of submitted solutions by classification
+	nonces       map[common.Hash]*nonceBuckets   // Low bit distribution of the nonces submitted per miner
+	searched     map[common.Hash]*searchedRanges // Nonce ranges claimed to be searched per miner
+	shares       map[common.Hash]*shareLedger    // Submitted shares per miner
+	workers      map[workerKey]*workerLedger     // Submissions per miner and worker name
+	flaggedIDs   map[common.Hash]*FlaggedID      // Miner ids submitted from multiple addresses
+	lastFound    *BlockFound                     // Last block sealed from a remote submission
+	replaced     time.Time                       // Time the work of the previous height was replaced
 	currentBlock *types.Block
-	currentWork  [11]string
-	notifyCtx    context.Context
-	cancelNotify context.CancelFunc // cancels all notification requests
-	reqWG        sync.WaitGroup     // tracks notification request goroutines
-
-	ethash       *Ethash
-	noverify     bool
-	notifyURLs   []string
-	results      chan<- types.SealResult
-	workCh       chan *sealTask   // Notification channel to push new work and relative result channel to remote sealer
-	fetchWorkCh  chan *sealWork   // Channel used for remote sealer to fetch mining work
-	submitWorkCh chan *mineResult // Channel used for remote sealer to submit their mining result
-	fetchRateCh  chan chan uint64 // Channel used to gather submitted hash rate for local or remote sealer.
-	submitRateCh chan *hashrate   // Channel used for remote sealer to submit their mining hashrate
-	requestExit  chan struct{}
-	exitCh       chan struct{}
+	currentWork  WorkPackage
+	history      []WorkPackage // Recently generated work packages, oldest first
+	notifier     *workNotifier // Delivery of the work notifications to the notify URLs
+	notifySeq    uint64        // Sequence number of the last structured work notification
+	workFeed     event.Feed    // feed of regenerated work packages
+	extraNonces  atomic.Uint32 // last extraNonce assigned to a stratum channel
+
+	ethash        *Ethash
+	noverify      bool
+	notifyURLs    []string
+	results       chan<- types.SealResult
+	workCh        chan *sealTask          // Notification channel to push new work and relative result channel to remote sealer
+	fetchWorkCh   chan *sealWork          // Channel used for remote sealer to fetch mining work
+	fetchBlockCh  chan *sealBlock         // Channel used to look up retained work blocks by pow-hash
+	fetchMinerCh  chan *sealMiner         // Channel used to look up the last activity of a remote miner
+	fetchNonceCh  chan *sealNonces        // Channel used to gather the nonce distribution of a remote miner
+	fetchRecentCh chan *sealRecent        // Channel used to gather the recently generated work packages
+	fetchIssuedCh chan *sealIssued        // Channel used to look up issued work packages by pow-hash
+	fetchRangeCh  chan *sealRanges        // Channel used to gather the nonce ranges searched by remote miners
+	fetchShareCh  chan *sealShares        // Channel used to gather the share ledger of a remote miner
+	fetchWorkers  chan chan []WorkerStats // Channel used to gather the statistics of all remote workers
+	refreshWorkCh chan chan error         // Channel used to force work regeneration bypassing the throttle
+	submitWorkCh  chan *mineResult        // Channel used for remote sealer to submit their mining result
+	fetchRateCh   chan chan uint64        // Channel used to gather submitted hash rate for local or remote sealer.
+	fetchStatsCh  chan chan StatsSnapshot // Channel used to gather a consistent snapshot of the sealer stats
+	submitRateCh  chan *hashrate          // Channel used for remote sealer to submit their mining hashrate
+	requestExit   chan struct{}
+	exitCh        chan struct{}
 }
 
 // sealTask wraps a seal block with relative result channel for remote sealer thread.
@@ -222,17 +504,72 @@ type mineResult struct {
 	mixDigest  common.Hash
 	hash       common.Hash
 	extraNonce []byte
+	miner      *common.Hash // Submitting miner, nil if anonymous
+	worker     string       // Name of the submitting worker, empty if unnamed
+	searched   *nonceRange  // Nonce range claimed to be searched, nil if unclaimed
+	source     string       // Remote address of the submitter, empty if unknown
+
+	shareDifficulty *big.Int // Difficulty of the submitter's share target, nil if at the block target
 
 	errc chan error
 }
 
+// submitStatus classifies the outcome of a submitted solution.
+type submitStatus int
+
+const (
+	submitAccepted  submitStatus = iota // Valid solution delivered to the miner
+	submitStale                         // Valid solution for work that is too old
+	submitInvalid                       // Solution failing the proof-of-work check
+	submitDuplicate                     // Solution already accepted within the dedup window
+	submitUnknown                       // Solution for work that is not retained
+	submitDropped                       // Valid solution that could not be delivered
+	submitShare                         // Solution meeting the share target only
+	submitLate                          // Valid solution for replaced work, sealed as a potential uncle
+
+	numSubmitStatus
+)
+
+// String implements fmt.Stringer, naming the status in the submission log.
+func (s submitStatus) String() string {
+	switch s {
+	case submitAccepted:
+		return "accepted"
+	case submitStale:
+		return "stale"
+	case submitInvalid:
+		return "invalid"
+	case submitDuplicate:
+		return "duplicate"
+	case submitUnknown:
+		return "unknown"
+	case submitDropped:
+		return "dropped"
+	case submitShare:
+		return "share"
+	case submitLate:
+		return "stale-accepted"
+	default:
+		return "undefined"
+	}
+}
+
+// submitKey identifies a submitted solution for duplicate detection.
+type submitKey struct {
+	hash       common.Hash
+	nonce      types.BlockNonce
+	extraNonce string
+}
+
 // hashrate wraps the hash rate submitted by the remote sealer.
 type hashrate struct {
-	id   common.Hash
-	ping time.Time
-	rate uint64
+	id     common.Hash
+	ping   time.Time
+	rate   uint64
+	source string // Address the hash rate was submitted from, empty if unknown
+	worker string // Name of the submitting worker, empty if unnamed
 
-	done chan struct{}
+	done chan bool
 }
 
 // sealWork wraps a seal work package for remote sealer.
@@ -241,23 +578,79 @@ type sealWork struct {
 	res  chan [11]string
 }
 
+// sealBlock wraps a lookup of a retained work block by its pow-hash.
+type sealBlock struct {
+	hash common.Hash
+	res  chan *types.Block
+}
+
+// sealMiner wraps a lookup of the last time a remote miner was seen.
+type sealMiner struct {
+	id  common.Hash
+	res chan time.Time
+}
+
+// sealNonces wraps a lookup of the nonce distribution of a remote miner.
+type sealNonces struct {
+	id  common.Hash
+	res chan NonceHistogram
+}
+
+// sealShares wraps a lookup of the share ledger of a remote miner.
+type sealShares struct {
+	id  common.Hash
+	res chan MinerStats
+}
+
+// sealRanges wraps a lookup of the nonce ranges searched by all remote miners.
+type sealRanges struct {
+	res chan map[common.Hash]SearchedRanges
+}
+
+// sealIssued wraps a lookup of an issued work package by pow-hash.
+type sealIssued struct {
+	hash common.Hash
+	res  chan *IssuedWork
+}
+
+// sealRecent wraps a lookup of the n most recently generated work packages.
+type sealRecent struct {
+	n   int
+	res chan []WorkPackage
+}
+
 func startRemoteSealer(ethash *Ethash, urls []string, noverify bool) *remoteSealer {
-	ctx, cancel := context.WithCancel(context.Background())
 	s := &remoteSealer{
-		ethash:       ethash,
-		noverify:     noverify,
-		notifyURLs:   urls,
-		notifyCtx:    ctx,
-		cancelNotify: cancel,
-		works:        make(map[common.Hash]*types.Block),
-		rates:        make(map[common.Hash]hashrate),
-		workCh:       make(chan *sealTask),
-		fetchWorkCh:  make(chan *sealWork),
-		submitWorkCh: make(chan *mineResult),
-		fetchRateCh:  make(chan chan uint64),
-		submitRateCh: make(chan *hashrate),
-		requestExit:  make(chan struct{}),
-		exitCh:       make(chan struct{}),
+		ethash:        ethash,
+		noverify:      noverify,
+		notifyURLs:    urls,
+		notifier:      newWorkNotifier(ethash, urls),
+		works:         make(map[common.Hash]*types.Block),
+		issued:        make(map[common.Hash]*IssuedWork),
+		rates:         make(map[common.Hash]hashrate),
+		nonces:        make(map[common.Hash]*nonceBuckets),
+		searched:      make(map[common.Hash]*searchedRanges),
+		shares:        make(map[common.Hash]*shareLedger),
+		workers:       make(map[workerKey]*workerLedger),
+		flaggedIDs:    make(map[common.Hash]*FlaggedID),
+		submitted:     make(map[submitKey]time.Time),
+		workCh:        make(chan *sealTask),
+		fetchWorkCh:   make(chan *sealWork),
+		fetchBlockCh:  make(chan *sealBlock),
+		refreshWorkCh: make(chan chan error),
+		submitWorkCh:  make(chan *mineResult),
+		fetchRateCh:   make(chan chan uint64),
+		fetchMinerCh:  make(chan *sealMiner),
+		fetchNonceCh:  make(chan *sealNonces),
+		fetchRecentCh: make(chan *sealRecent),
+		fetchIssuedCh: make(chan *sealIssued),
+		fetchRangeCh:  make(chan *sealRanges),
+		fetchShareCh:  make(chan *sealShares),
+		fetchWorkers:  make(chan chan []WorkerStats),
+		fetchStatsCh:  make(chan chan StatsSnapshot),
+		submitRateCh:  make(chan *hashrate),
+		requestExit:   make(chan struct{}),
+		exitCh:        make(chan struct{}),
 	}
 	go s.loop()
 	return s
@@ -266,22 +659,82 @@ func startRemoteSealer(ethash *Ethash, urls []string, noverify bool) *remoteSeal
 func (s *remoteSealer) loop() {
 	defer func() {
 		s.ethash.config.Log.Trace("Ethash remote sealer is exiting")
-		s.cancelNotify()
-		s.reqWG.Wait()
+		s.notifier.close()
 		close(s.exitCh)
 	}()
 
 	ticker := time.NewTicker(5 * time.Second)
 	defer ticker.Stop()
 
+	// Periodic status summaries are only logged if enabled
+	var (
+		statusC     <-chan time.Time
+		lastSubmits = s.submits
+	)
+	if interval := s.ethash.config.StatusLogInterval; interval > 0 {
+		status := time.NewTicker(interval)
+		defer status.Stop()
+		statusC = status.C
+	}
+
+	// Work regeneration is throttled to MinWorkInterval, deferring the latest
+	// received block until the interval has passed.
+	var (
+		lastWork  time.Time
+		deferred  *types.Block
+		throttle  *time.Timer
+		throttleC <-chan time.Time
+	)
+	defer func() {
+		if throttle != nil {
+			throttle.Stop()
+		}
+	}()
+	regenerate := func(block *types.Block) {
+		s.makeWork(block)
+		s.notifyWork()
+		s.workFeed.Send(s.currentWork)
+		lastWork, deferred = time.Now(), nil
+	}
 	for {
 		select {
 		case work := <-s.workCh:
 			// Update current work with new received block.
 			// Note same work can be past twice, happens when changing CPU threads.
 			s.results = work.results
-			s.makeWork(work.block)
-			s.notifyWork()
+			if wait := s.ethash.config.MinWorkInterval - time.Since(lastWork); wait > 0 {
+				if deferred == nil {
+					throttle = time.NewTimer(wait)
+					throttleC = throttle.C
+				}
+				deferred = work.block
+				continue
+			}
+			regenerate(work.block)
+
+		case <-throttleC:
+			// Throttle interval passed, serve the latest deferred block.
+			throttle, throttleC = nil, nil
+			if deferred != nil {
+				regenerate(deferred)
+			}
+
+		case req := <-s.refreshWorkCh:
+			// Forced refresh, bypass the throttle with the latest known block.
+			block := deferred
+			if block == nil {
+				block = s.currentBlock
+			}
+			if block == nil {
+				req <- errNoMiningWork
+				continue
+			}
+			if throttle != nil {
+				throttle.Stop()
+				throttle, throttleC = nil, nil
+			}
+			regenerate(block)
+			req <- nil
 
 		case work := <-s.fetchWorkCh:
 			// Return current mining work to remote miner.
@@ -291,9 +744,41 @@ func (s *remoteSealer) loop() {
 				work.res <- s.currentWork
 			}
 
+		case req := <-s.fetchBlockCh:
+			// Return the retained block for the requested pow-hash, if any.
+			req.res <- s.works[req.hash]
+
 		case result := <-s.submitWorkCh:
 			// Verify submitted PoW solution based on maintained mining blocks.
-			if s.submitWork(result.nonce, result.mixDigest, result.hash, result.extraNonce) {
+			var difficulty *big.Int
+			block := s.works[result.hash]
+			if block != nil {
+				difficulty = block.Difficulty()
+			}
+			status := s.submitWork(result.nonce, result.mixDigest, result.hash, result.extraNonce, result.shareDifficulty)
+			s.submits[status]++
+			submitStatusCounts[status].Inc(1)
+			if status == submitAccepted || status == submitLate {
+				s.recordSeal(result.hash)
+			}
+			if s.ethash.submissions != nil {
+				s.logSubmission(result, status)
+			}
+			if status == submitShare {
+				difficulty = result.shareDifficulty
+			}
+			if result.miner != nil || result.worker != "" {
+				s.trackWorker(newWorkerKey(result.miner, result.worker), status, difficulty)
+			}
+			if result.miner != nil {
+				s.trackNonce(*result.miner, result.nonce)
+				s.trackShare(*result.miner, status, difficulty)
+				if status == submitAccepted && result.searched != nil {
+					s.trackRange(*result.miner, *result.searched)
+				}
+			}
+			if status == submitAccepted || status == submitShare || status == submitLate {
+				s.postShare(result, block, status)
 				result.errc <- nil
 			} else {
 				result.errc <- errInvalidSealResult
@@ -301,25 +786,139 @@ func (s *remoteSealer) loop() {
 
 		case result := <-s.submitRateCh:
 			// Trace remote sealer's hash rate by submitted value.
-			s.rates[result.id] = hashrate{rate: result.rate, ping: time.Now()}
-			close(result.done)
+			if prev, ok := s.rates[result.id]; ok && prev.source != result.source && prev.source != "" && result.source != "" {
+				// Same id from another address within the idle timeout
+				s.flagDuplicateID(result.id, prev.source, result.source)
+				if s.ethash.config.RejectDuplicateIDs {
+					result.done <- false
+					continue
+				}
+			}
+			s.rates[result.id] = hashrate{rate: result.rate, ping: time.Now(), source: result.source}
+			s.reportWorkerRate(newWorkerKey(&result.id, result.worker), result.rate)
+			result.done <- true
+
+		case req := <-s.fetchMinerCh:
+			// Return the last ping of the requested miner, zero if unknown.
+			req.res <- s.rates[req.id].ping
+
+		case req := <-s.fetchRecentCh:
+			// Return the requested number of recent work packages, newest first.
+			n := req.n
+			if n > len(s.history) {
+				n = len(s.history)
+			}
+			recent := make([]WorkPackage, n)
+			for i := range recent {
+				recent[i] = s.history[len(s.history)-1-i]
+			}
+			req.res <- recent
+
+		case req := <-s.fetchIssuedCh:
+			// Return a copy of the issued work, reporting whether it's still valid.
+			var issued *IssuedWork
+			if work := s.issued[req.hash]; work != nil {
+				cpy := *work
+				if block := s.works[req.hash]; block != nil {
+					cpy.Valid = s.acceptable(block.NumberU64())
+				}
+				issued = &cpy
+			}
+			req.res <- issued
+
+		case req := <-s.fetchNonceCh:
+			// Return the nonce distribution of the requested miner, if any.
+			var hist NonceHistogram
+			if buckets := s.nonces[req.id]; buckets != nil {
+				hist = buckets.histogram()
+			}
+			req.res <- hist
+
+		case req := <-s.fetchShareCh:
+			// Return the share ledger of the requested miner, if any.
+			var stats MinerStats
+			if ledger := s.shares[req.id]; ledger != nil {
+				stats = ledger.stats(time.Now())
+			}
+			req.res <- stats
+
+		case req := <-s.fetchWorkers:
+			// Return the statistics of all tracked workers.
+			req <- s.workerStats(time.Now())
+
+		case req := <-s.fetchRangeCh:
+			// Return the aggregated searched ranges of all tracked miners.
+			ranges := make(map[common.Hash]SearchedRanges, len(s.searched))
+			for id, searched := range s.searched {
+				ranges[id] = searched.summary()
+			}
+			req.res <- ranges
+
+		case req := <-s.fetchStatsCh:
+			// Capture all remote sealer statistics in one consistent view.
+			req <- s.statsSnapshot()
 
 		case req := <-s.fetchRateCh:
 			// Gather all hash rate submitted by remote sealer.
 			var total uint64
-			for _, rate := range s.rates {
+			for _, rate := range s.activeRates(time.Now()) {
 				// this could overflow
-				total += rate.rate
+				total += rate
 			}
 			req <- total
 
+		case <-statusC:
+			// Summarize the mining health since the last status log.
+			s.logStatus(lastSubmits)
+			lastSubmits = s.submits
+
 		case <-ticker.C:
 			// Clear stale submitted hash rate.
 			for id, rate := range s.rates {
-				if time.Since(rate.ping) > 10*time.Second {
+				if time.Since(rate.ping) > minerIdleTimeout {
 					delete(s.rates, id)
 				}
 			}
+			remoteMinersGauge.Update(int64(len(s.rates)))
+			// Forget ids no longer seen from multiple addresses
+			for id, flagged := range s.flaggedIDs {
+				if time.Since(time.Unix(int64(flagged.Time), 0)) > flaggedIDTimeout {
+					delete(s.flaggedIDs, id)
+				}
+			}
+			// Forget the nonce distribution of miners gone quiet
+			for id, buckets := range s.nonces {
+				if time.Since(buckets.seen) > nonceDistributionTimeout {
+					delete(s.nonces, id)
+				}
+			}
+			for id, searched := range s.searched {
+				if time.Since(searched.last) > nonceDistributionTimeout {
+					delete(s.searched, id)
+				}
+			}
+			for id, ledger := range s.shares {
+				if time.Since(ledger.last) > nonceDistributionTimeout {
+					delete(s.shares, id)
+				}
+			}
+			for key, ledger := range s.workers {
+				if time.Since(ledger.seen) > workerTimeout {
+					delete(s.workers, key)
+				}
+			}
+			// Forget work issued outside the lookup window
+			for hash, work := range s.issued {
+				if time.Since(work.issued) > s.ethash.config.WorkLookupWindow {
+					delete(s.issued, hash)
+				}
+			}
+			// Forget solutions submitted outside the deduplication window
+			for key, seen := range s.submitted {
+				if time.Since(seen) >= s.ethash.config.DedupWindow {
+					delete(s.submitted, key)
+				}
+			}
 			// Clear stale pending blocks
 			if s.currentBlock != nil {
 				for hash, block := range s.works {
@@ -335,9 +934,9 @@ func (s *remoteSealer) loop() {
 	}
 }
 
-// makeWork creates a work package for external miner.
+// WorkPackage is a work package served to external miners.
 //
-// The work package consists of 3 strings:
+// The work package consists of 11 strings:
 //
 //		result[0], 32 bytes hex encoded current block header pow-hash
 //		result[1], 32 bytes hex encoded seed hash used for DAG
@@ -348,20 +947,210 @@ func (s *remoteSealer) loop() {
 //	  result[6], hex encoded gas used
 //	  result[7], hex encoded transaction count
 //	  result[8], hex encoded uncle count
-func (s *remoteSealer) makeWork(block *types.Block) {
+//	  result[9], RLP encoded header with extraNonceSize empty extra data bytes
+//	  result[10], MEV Profit as float-to-string "0.124"
+type WorkPackage [11]string
+
+// makeWorkPackage assembles the work package sealing the given block. The
+// package is returned even if the header RLP could not be encoded, leaving
+// result[9] empty.
+func (ethash *Ethash) makeWorkPackage(block *types.Block) (WorkPackage, error) {
+	var work WorkPackage
 	header := block.Header()
-	hash := s.ethash.SealHash(header)
-	s.currentWork[0] = hash.Hex()
-	s.currentWork[1] = common.BytesToHash(SeedHash(block.NumberU64())).Hex()
-	s.currentWork[2] = common.BytesToHash(new(big.Int).Div(two256, block.Difficulty()).Bytes()).Hex()
-	s.currentWork[3] = hexutil.EncodeBig(block.Number())
-	s.currentWork[4] = block.ParentHash().Hex()
-	s.currentWork[5] = hexutil.EncodeUint64(block.GasLimit())
-	s.currentWork[6] = hexutil.EncodeUint64(block.GasUsed())
-	s.currentWork[7] = hexutil.EncodeUint64(uint64(len(block.Transactions())))
-	s.currentWork[8] = hexutil.EncodeUint64(uint64(len(block.Uncles())))
-
-	extra := append(header.Extra, make([]byte, 4)...)
+	work[0] = ethash.SealHash(header).Hex()
+	work[1] = common.BytesToHash(SeedHash(block.NumberU64())).Hex()
+	work[2] = common.BytesToHash(new(big.Int).Div(two256, block.Difficulty()).Bytes()).Hex()
+	work[3] = hexutil.EncodeBig(block.Number())
+	work[4] = block.ParentHash().Hex()
+	work[5] = hexutil.EncodeUint64(block.GasLimit())
+	work[6] = hexutil.EncodeUint64(block.GasUsed())
+	work[7] = hexutil.EncodeUint64(uint64(len(block.Transactions())))
+	work[8] = hexutil.EncodeUint64(uint64(len(block.Uncles())))
+
+	encoded, err := workHeaderRLP(header, make([]byte, extraNonceSize))
+	if err == nil {
+		work[9] = hexutil.Encode(encoded)
+	}
+	work[10] = strconv.FormatFloat(0.00, 'g', 10, 64)
+	return work, err
+}
+
+// makeWork creates a work package for external miner.
+func (s *remoteSealer) makeWork(block *types.Block) {
+	s.currentWork, _ = s.ethash.makeWorkPackage(block)
+	if len(s.history) == workHistorySize {
+		s.history = append(s.history[:0], s.history[1:]...)
+	}
+	s.history = append(s.history, s.currentWork)
+
+	// Trace the seal work fetched by remote sealer.
+	if s.currentBlock == nil || block.NumberU64() > s.currentBlock.NumberU64() {
+		s.replaced = time.Now()
+	}
+	s.currentBlock = block
+	s.works[s.ethash.SealHash(block.Header())] = block
+	now := time.Now()
+	s.ethash.remoteWork.Store(now.UnixNano())
+	s.issued[common.HexToHash(s.currentWork[0])] = &IssuedWork{Work: s.currentWork, Issued: hexutil.Uint64(now.Unix()), issued: now}
+
+	workIssuedCounter.Inc(1)
+	workEpochGauge.Update(int64(block.NumberU64() / epochLength))
+}
+
+// acceptable returns whether solutions for work at the given height are still
+// sealed, honouring the stale window if configured.
+func (s *remoteSealer) acceptable(number uint64) bool {
+	current := s.currentBlock.NumberU64()
+	if window := s.ethash.config.StaleWindow; window > 0 && number < current {
+		return number+1 == current && time.Since(s.replaced) <= window
+	}
+	return number+staleThreshold > current
+}
+
+// issuedWork looks up a work package issued within the lookup window.
+func (s *remoteSealer) issuedWork(hash common.Hash) (*IssuedWork, error) {
+	res := make(chan *IssuedWork, 1)
+	select {
+	case s.fetchIssuedCh <- &sealIssued{hash: hash, res: res}:
+	case <-s.exitCh:
+		return nil, errEthashStopped
+	}
+	if work := <-res; work != nil {
+		return work, nil
+	}
+	return nil, errWorkNotIssued
+}
+
+// retainedBlock retrieves the block of a retained work package by its pow-hash.
+func (s *remoteSealer) retainedBlock(hash common.Hash) (*types.Block, error) {
+	res := make(chan *types.Block, 1)
+	select {
+	case s.fetchBlockCh <- &sealBlock{hash: hash, res: res}:
+	case <-s.exitCh:
+		return nil, errEthashStopped
+	}
+	if block := <-res; block != nil {
+		return block, nil
+	}
+	return nil, errWorkNotRetained
+}
+
+// assignExtraNonce returns an extraNonce distinct from all others assigned to
+// stratum channels, so their miners search disjoint pow-hashes.
+func (s *remoteSealer) assignExtraNonce() []byte {
+	extraNonce := make([]byte, extraNonceSize)
+	binary.BigEndian.PutUint32(extraNonce, s.extraNonces.Add(1))
+	return extraNonce
+}
+
+// subscribeWork subscribes to the work packages regenerated by the remote
+// sealer. The channel should be buffered as it's fed from the sealer loop.
+func (s *remoteSealer) subscribeWork(ch chan<- WorkPackage) event.Subscription {
+	return s.workFeed.Subscribe(ch)
+}
+
+// recentWork retrieves up to n of the most recently generated work packages,
+// newest first.
+func (s *remoteSealer) recentWork(n int) ([]WorkPackage, error) {
+	res := make(chan []WorkPackage, 1)
+	select {
+	case s.fetchRecentCh <- &sealRecent{n: n, res: res}:
+	case <-s.exitCh:
+		return nil, errEthashStopped
+	}
+	return <-res, nil
+}
+
+// nonceDistribution retrieves the nonce distribution of the given miner.
+func (s *remoteSealer) nonceDistribution(id common.Hash) (NonceHistogram, error) {
+	res := make(chan NonceHistogram, 1)
+	select {
+	case s.fetchNonceCh <- &sealNonces{id: id, res: res}:
+	case <-s.exitCh:
+		return NonceHistogram{}, errEthashStopped
+	}
+	return <-res, nil
+}
+
+// minerStats retrieves the share ledger of the given miner.
+func (s *remoteSealer) minerStats(id common.Hash) (MinerStats, error) {
+	res := make(chan MinerStats, 1)
+	select {
+	case s.fetchShareCh <- &sealShares{id: id, res: res}:
+	case <-s.exitCh:
+		return MinerStats{}, errEthashStopped
+	}
+	return <-res, nil
+}
+
+// workerList retrieves the statistics of all tracked remote workers.
+func (s *remoteSealer) workerList() ([]WorkerStats, error) {
+	res := make(chan []WorkerStats, 1)
+	select {
+	case s.fetchWorkers <- res:
+	case <-s.exitCh:
+		return nil, errEthashStopped
+	}
+	return <-res, nil
+}
+
+// searchedRanges retrieves the aggregated nonce ranges searched by all miners.
+func (s *remoteSealer) searchedRanges() (map[common.Hash]SearchedRanges, error) {
+	res := make(chan map[common.Hash]SearchedRanges, 1)
+	select {
+	case s.fetchRangeCh <- &sealRanges{res: res}:
+	case <-s.exitCh:
+		return nil, errEthashStopped
+	}
+	return <-res, nil
+}
+
+// minerLastSeen returns the last time the remote miner with the given id was
+// seen, or the zero time if it is not tracked.
+func (s *remoteSealer) minerLastSeen(id common.Hash) (time.Time, error) {
+	res := make(chan time.Time, 1)
+	select {
+	case s.fetchMinerCh <- &sealMiner{id: id, res: res}:
+	case <-s.exitCh:
+		return time.Time{}, errEthashStopped
+	}
+	return <-res, nil
+}
+
+// validateBody checks that an externally assembled block body matches the
+// commitments made by the header of a work package. As the gas used can only
+// be recomputed by executing the transactions, the body is only checked to be
+// able to cover it with the gas limits of its transactions.
+func validateBody(header *types.Header, body *types.Body) error {
+	if hash := types.DeriveSha(types.Transactions(body.Transactions), trie.NewStackTrie(nil)); hash != header.TxHash {
+		return fmt.Errorf("transaction root mismatch: have %x, want %x", hash, header.TxHash)
+	}
+	if hash := types.CalcUncleHash(body.Uncles); hash != header.UncleHash {
+		return fmt.Errorf("uncle hash mismatch: have %x, want %x", hash, header.UncleHash)
+	}
+	var gas uint64
+	for _, tx := range body.Transactions {
+		gas += tx.Gas()
+	}
+	if gas < header.GasUsed {
+		return fmt.Errorf("gas used mismatch: transactions allow %d, work uses %d", gas, header.GasUsed)
+	}
+	return nil
+}
+
+// Commitment returns a hash committing to all fields of the work package: the
+// keccak256 hash of the RLP list of the 11 fields in result order, each encoded
+// as the byte string of its exact textual form.
+func (work WorkPackage) Commitment() common.Hash {
+	enc, _ := rlp.EncodeToBytes(work[:]) // Encoding strings can't fail
+	return crypto.Keccak256Hash(enc)
+}
+
+// workHeaderRLP encodes the sealing fields of a header the same way SealHash
+// does, with the given extraNonce appended to the extra-data.
+func workHeaderRLP(header *types.Header, extraNonce []byte) ([]byte, error) {
+	extra := make([]byte, 0, len(header.Extra)+len(extraNonce))
+	extra = append(append(extra, header.Extra...), extraNonce...)
 	enc := []interface{}{
 		header.ParentHash,
 		header.UncleHash,
@@ -380,93 +1169,147 @@ func (s *remoteSealer) makeWork(block *types.Block) {
 	if header.BaseFee != nil {
 		enc = append(enc, header.BaseFee)
 	}
-	encoded, err := rlp.EncodeToBytes(enc)
-	if err == nil {
-		s.currentWork[9] = hexutil.Encode(encoded)
-	}
+	return rlp.EncodeToBytes(enc)
+}
 
-	s.currentWork[10] = strconv.FormatFloat(0.00, 'g', 10, 64)
+// placeExtraNonce places a miner chosen extraNonce into the reserved trailing
+// bytes of the work header's extra-data, and ensures the reconstructed header
+// hashes to the pow-hash the miner derived from the served header RLP.
+func (ethash *Ethash) placeExtraNonce(header *types.Header, extraNonce []byte) (*types.Header, error) {
+	if len(extraNonce) != extraNonceSize {
+		return nil, fmt.Errorf("%w: invalid length %d, want %d", errInvalidExtraNonce, len(extraNonce), extraNonceSize)
+	}
+	blob, err := workHeaderRLP(header, extraNonce)
+	if err != nil {
+		return nil, err
+	}
+	header = types.CopyHeader(header)
+	header.Extra = append(header.Extra, extraNonce...)
 
-	// Trace the seal work fetched by remote sealer.
-	s.currentBlock = block
-	s.works[hash] = block
+	if have, want := ethash.SealHash(header), crypto.Keccak256Hash(blob); have != want {
+		return nil, fmt.Errorf("%w: header hashes to %x, work to %x", errInvalidExtraNonce, have, want)
+	}
+	return header, nil
 }
 
 // notifyWork notifies all the specified mining endpoints of the availability of
 // new work to be processed.
 func (s *remoteSealer) notifyWork() {
-	work := s.currentWork
-
-	// Encode the JSON payload of the notification. When NotifyFull is set,
-	// this is the complete block header, otherwise it is a JSON array.
-	var blob []byte
-	if s.ethash.config.NotifyFull {
-		blob, _ = json.Marshal(s.currentBlock.Header())
-	} else {
-		blob, _ = json.Marshal(work)
+	if len(s.notifyURLs) == 0 {
+		return
 	}
-
-	s.reqWG.Add(len(s.notifyURLs))
-	for _, url := range s.notifyURLs {
-		go s.sendNotification(s.notifyCtx, url, blob, work)
+	// Encode the JSON payload of the notification. When NotifyStructured is
+	// set, this is the versioned envelope of the typed work package and the
+	// header, when NotifyFull is set the complete block header, otherwise the
+	// configured work format.
+	var (
+		blob []byte
+		err  error
+	)
+	switch {
+	case s.ethash.config.NotifyStructured:
+		var ext ExtendedWork
+		if ext, err = s.currentWork.Extended(); err != nil {
+			break
+		}
+		s.notifySeq++
+		blob, err = json.Marshal(&WorkNotification{
+			Version:  workNotificationVersion,
+			Sequence: hexutil.Uint64(s.notifySeq),
+			Sent:     hexutil.Uint64(time.Now().Unix()),
+			Work:     ext,
+			Header:   s.currentBlock.Header(),
+		})
+	case s.ethash.config.NotifyFull:
+		blob, err = json.Marshal(s.currentBlock.Header())
+	default:
+		blob, err = s.ethash.config.WorkEncoder.EncodeWork(s.currentWork)
 	}
-}
-
-func (s *remoteSealer) sendNotification(ctx context.Context, url string, json []byte, work [11]string) {
-	defer s.reqWG.Done()
-
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(json))
 	if err != nil {
-		s.ethash.config.Log.Warn("Can't create remote miner notification", "err", err)
+		s.ethash.config.Log.Warn("Failed to encode work notification", "err", err)
 		return
 	}
-	ctx, cancel := context.WithTimeout(ctx, remoteSealerTimeout)
-	defer cancel()
-	req = req.WithContext(ctx)
-	req.Header.Set("Content-Type", "application/json")
+	s.notifier.notify(blob)
+}
 
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		s.ethash.config.Log.Warn("Failed to notify remote miner", "err", err)
-	} else {
-		s.ethash.config.Log.Trace("Notified remote miner", "miner", url, "hash", work[0], "target", work[2])
-		resp.Body.Close()
+// notifySuccess returns the time of the last successful notification of the
+// given URL, or the zero time if none succeeded yet.
+func (s *remoteSealer) notifySuccess(url string) (time.Time, error) {
+	endpoint := s.notifier.endpoint(url)
+	if endpoint == nil {
+		return time.Time{}, errNotifyURLUnknown
 	}
+	endpoint.lock.Lock()
+	defer endpoint.lock.Unlock()
+
+	return endpoint.success, nil
 }
 
-// submitWork verifies the submitted pow solution, returning
-// whether the solution was accepted or not (not can be both a bad pow as well as
-// any other error, like no pending work or stale mining result).
-func (s *remoteSealer) submitWork(nonce types.BlockNonce, mixDigest common.Hash, sealhash common.Hash, extraNonce []byte) bool {
+// submitWork verifies the submitted pow solution, returning how the solution was
+// classified (accepted, or rejected as a bad pow, a duplicate, an unknown or a
+// stale mining result).
+func (s *remoteSealer) submitWork(nonce types.BlockNonce, mixDigest common.Hash, sealhash common.Hash, extraNonce []byte, shareDifficulty *big.Int) submitStatus {
 	if s.currentBlock == nil {
 		s.ethash.config.Log.Error("Pending work without block", "sealhash", sealhash)
-		return false
+		return submitUnknown
 	}
 	// Make sure the work submitted is present
 	block := s.works[sealhash]
 	if block == nil {
 		s.ethash.config.Log.Warn("Work submitted but none pending", "sealhash", sealhash, "curnumber", s.currentBlock.NumberU64())
-		return false
+		if s.ethash.config.LogUnknownWork {
+			// Unknown work spikes usually mean misrouted miners, log what they are mining on
+			s.ethash.config.Log.Debug("Unknown work submitted", "sealhash", sealhash, "nonce", nonce, "extranonce", hexutil.Bytes(extraNonce),
+				"current", s.currentWork[0], "retained", len(s.works))
+		}
+		return submitUnknown
 	}
 	// Verify the correctness of submitted result.
 	header := block.Header()
-	header.Nonce = nonce
-	header.MixDigest = mixDigest
 	if extraNonce != nil {
-		header.Extra = append(header.Extra, extraNonce...)
+		placed, err := s.ethash.placeExtraNonce(header, extraNonce)
+		if err != nil {
+			s.ethash.config.Log.Warn("Invalid extraNonce submitted", "sealhash", sealhash, "err", err)
+			return submitInvalid
+		}
+		header = placed
 	}
+	header.Nonce = nonce
+	header.MixDigest = mixDigest
 
 	start := time.Now()
+	var share bool
 	if !s.noverify {
 		if err := s.ethash.verifySeal(nil, header, false); err != nil {
-			s.ethash.config.Log.Warn("Invalid proof-of-work submitted", "sealhash", sealhash, "elapsed", common.PrettyDuration(time.Since(start)), "err", err)
-			return false
+			// Solutions missing the block target may still meet the share target
+			if err == errInvalidPoW && shareDifficulty != nil && shareDifficulty.Cmp(header.Difficulty) < 0 {
+				share = s.ethash.verifySealTarget(nil, header, false, difficultyTarget(shareDifficulty)) == nil
+			}
+			if !share {
+				s.ethash.config.Log.Warn("Invalid proof-of-work submitted", "sealhash", sealhash, "elapsed", common.PrettyDuration(time.Since(start)), "err", err)
+				return submitInvalid
+			}
 		}
 	}
+	// Reject solutions already accepted within the deduplication window
+	key := submitKey{hash: sealhash, nonce: nonce, extraNonce: string(extraNonce)}
+	if seen, ok := s.submitted[key]; ok && time.Since(seen) < s.ethash.config.DedupWindow {
+		s.ethash.config.Log.Warn("Duplicate proof-of-work submitted", "sealhash", sealhash, "nonce", nonce)
+		return submitDuplicate
+	}
+	if s.ethash.submissions != nil && s.ethash.submissions.replayed(sealhash, nonce, extraNonce) {
+		s.ethash.config.Log.Warn("Replayed proof-of-work submitted", "sealhash", sealhash, "nonce", nonce)
+		return submitDuplicate
+	}
+	s.submitted[key] = time.Now()
+	if share {
+		return submitShare
+	}
+
 	// Make sure the result channel is assigned.
 	if s.results == nil {
 		s.ethash.config.Log.Warn("Ethash result channel is empty, submitted mining result is rejected")
-		return false
+		return submitDropped
 	}
 	s.ethash.config.Log.Info("Verified correct proof-of-work", "sealhash", sealhash, "elapsed", common.PrettyDuration(time.Since(start)))
 
@@ -477,18 +1320,55 @@ func (s *remoteSealer) submitWork(nonce types.BlockNonce, mixDigest common.Hash,
 		SealHash: &sealhash,
 	}
 
+	// Within the stale window, only the replaced work of the previous height is
+	// still accepted, as a potential uncle.
+	status := submitAccepted
+	if window := s.ethash.config.StaleWindow; window > 0 && solution.NumberU64() < s.currentBlock.NumberU64() {
+		if solution.NumberU64()+1 != s.currentBlock.NumberU64() || time.Since(s.replaced) > window {
+			s.ethash.config.Log.Warn("Work submitted after the stale window", "number", solution.NumberU64(), "sealhash", sealhash, "hash", solution.Hash())
+			return submitStale
+		}
+		status = submitLate
+	}
 	// The submitted solution is within the scope of acceptance.
 	if solution.NumberU64()+staleThreshold > s.currentBlock.NumberU64() {
 		select {
 		case s.results <- result:
 			s.ethash.config.Log.Debug("Work submitted is acceptable", "number", solution.NumberU64(), "sealhash", sealhash, "hash", solution.Hash())
-			return true
+			s.ethash.blocksFound.Add(1)
+			s.ethash.sealed.add(solution)
+			if status == submitAccepted {
+				s.lastFound = &BlockFound{Number: hexutil.Uint64(solution.NumberU64()), Hash: solution.Hash(), Time: hexutil.Uint64(time.Now().Unix())}
+			}
+			return status
 		default:
 			s.ethash.config.Log.Warn("Sealing result is not read by miner", "mode", "remote", "sealhash", sealhash)
-			return false
+			return submitDropped
 		}
 	}
 	// The submitted block is too old to accept, drop it.
 	s.ethash.config.Log.Warn("Work submitted is too old", "number", solution.NumberU64(), "sealhash", sealhash, "hash", solution.Hash())
-	return false
+	return submitStale
+}
+
+// logSubmission appends a submitted solution and its outcome to the submission
+// log. Solutions passing verification are indexed for replay protection.
+func (s *remoteSealer) logSubmission(result *mineResult, status submitStatus) {
+	var valid bool
+	switch status {
+	case submitAccepted, submitStale, submitDropped, submitShare, submitLate:
+		valid = true
+	}
+	record := &SubmissionRecord{
+		Nonce:      result.nonce,
+		MixDigest:  result.mixDigest,
+		PowHash:    result.hash,
+		ExtraNonce: result.extraNonce,
+		Source:     result.source,
+		Result:     status.String(),
+		Time:       hexutil.Uint64(time.Now().Unix()),
+	}
+	if err := s.ethash.submissions.append(record, valid); err != nil {
+		s.ethash.config.Log.Warn("Failed to log submitted solution", "sealhash", result.hash, "err", err)
+	}
 }
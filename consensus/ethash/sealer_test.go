@@ -13,19 +13,31 @@
 package ethash
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"math/big"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strconv"
 	"testing"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/internal/testlog"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
 )
 
 // Tests whether remote HTTP servers are correctly notified of new work.
@@ -71,6 +83,45 @@ func TestRemoteNotify(t *testing.T) {
 	}
 }
 
+// Tests that notifications and encoded work use the configured work encoder.
+func TestRemoteNotifyWorkEncoder(t *testing.T) {
+	sink := make(chan map[string]string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var work map[string]string
+		if err := json.NewDecoder(req.Body).Decode(&work); err != nil {
+			t.Errorf("failed to unmarshal miner notification: %v", err)
+		}
+		sink <- work
+	}))
+	defer server.Close()
+
+	ethash := New(Config{PowMode: ModeTest, WorkEncoder: ObjectWorkEncoder{}}, []string{server.URL}, false)
+	defer ethash.Close()
+	ethash.SetThreads(-1)
+
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100)}
+	ethash.Seal(nil, types.NewBlockWithHeader(header), nil, nil)
+	select {
+	case work := <-sink:
+		if want := ethash.SealHash(header).Hex(); work["powHash"] != want {
+			t.Errorf("work packet hash mismatch: have %s, want %s", work["powHash"], want)
+		}
+		if want := hexutil.EncodeBig(header.Number); work["number"] != want {
+			t.Errorf("work packet number mismatch: have %s, want %s", work["number"], want)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("notification timed out")
+	}
+	encoded, err := (&API{ethash: ethash}).GetEncodedWork()
+	if err != nil {
+		t.Fatalf("failed to get encoded work: %v", err)
+	}
+	var work map[string]string
+	if err := json.Unmarshal(encoded, &work); err != nil || work["powHash"] != ethash.SealHash(header).Hex() {
+		t.Fatalf("encoded work mismatch: %s, %v", encoded, err)
+	}
+}
+
 // Tests whether remote HTTP servers are correctly notified of new work. (Full pending block body / --miner.notify.full)
 func TestRemoteNotifyFull(t *testing.T) {
 	// Start a simple web server to capture notifications.
@@ -209,11 +260,223 @@ func TestRemoteMultiNotifyFull(t *testing.T) {
 	}
 }
 
+// Tests that the last successful notification is tracked per URL.
+func TestNotifySuccess(t *testing.T) {
+	pushed := make(chan struct{}, 2)
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		pushed <- struct{}{}
+	}))
+	defer ok.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		select {
+		case pushed <- struct{}{}:
+		default: // Retried
+		}
+	}))
+	defer failing.Close()
+
+	ethash := NewTester([]string{ok.URL, failing.URL}, true)
+	defer ethash.Close()
+	api := &API{ethash: ethash}
+
+	if last, err := api.GetNotifySuccess(ok.URL); err != nil || last != 0 {
+		t.Fatalf("success before notification: have %d, %v", last, err)
+	}
+	ethash.Seal(nil, types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100)}), nil, nil)
+	for i := 0; i < 2; i++ {
+		select {
+		case <-pushed:
+		case <-time.After(3 * time.Second):
+			t.Fatalf("notification %d timed out", i)
+		}
+	}
+	// The handler returning does not mean the response was processed, wait for it
+	var last hexutil.Uint64
+	for deadline := time.Now().Add(3 * time.Second); last == 0 && time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		last, _ = api.GetNotifySuccess(ok.URL)
+	}
+	if now := time.Now().Unix(); int64(last) < now-5 || int64(last) > now {
+		t.Errorf("last success mismatch: have %d, want around %d", last, now)
+	}
+	if last, err := api.GetNotifySuccess(failing.URL); err != nil || last != 0 {
+		t.Errorf("failing url reported success: have %d, %v", last, err)
+	}
+	if _, err := api.GetNotifySuccess("http://unknown"); err == nil {
+		t.Errorf("unconfigured url accepted")
+	}
+}
+
+// Tests that the header RLP accessor serves the bytes of result[9].
+func TestGetWorkHeaderRLP(t *testing.T) {
+	ethash := NewTester(nil, true)
+	defer ethash.Close()
+	ethash.SetThreads(-1)
+	api := &API{ethash: ethash}
+
+	if _, err := api.GetWorkHeaderRLP(); err == nil {
+		t.Fatalf("header served without work")
+	}
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100000000)}
+	ethash.Seal(nil, types.NewBlockWithHeader(header), nil, nil)
+
+	work, _ := api.GetWork()
+	blob, err := api.GetWorkHeaderRLP()
+	if err != nil {
+		t.Fatalf("failed to get header rlp: %v", err)
+	}
+	if blob.String() != work[9] {
+		t.Errorf("header rlp mismatch: have %s, want %s", blob, work[9])
+	}
+	// The served bytes hash to the pow-hash of the header with empty extra data bytes
+	padded := types.CopyHeader(header)
+	padded.Extra = make([]byte, extraNonceSize)
+	if have, want := crypto.Keccak256Hash(blob), ethash.SealHash(padded); have != want {
+		t.Errorf("header rlp hash mismatch: have %x, want %x", have, want)
+	}
+}
+
+// Tests that solutions meeting only the share target are accounted as shares
+// without being delivered as blocks.
+func TestShareSubmission(t *testing.T) {
+	ethash := NewTester(nil, false)
+	defer ethash.Close()
+	ethash.SetThreads(-1)
+
+	shares := make(chan ShareEvent, 4)
+	sub := ethash.SubscribeShareEvent(shares)
+	defer sub.Unsubscribe()
+
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100000000), Coinbase: common.Address{0xc0}}
+	results := make(chan types.SealResult, 1)
+	ethash.Seal(nil, types.NewBlockWithHeader(header), results, nil)
+	sealhash := ethash.SealHash(header)
+
+	// Find a nonce meeting a share difficulty of 2 but not the block target
+	shareTarget, blockTarget := difficultyTarget(big.NewInt(2)), difficultyTarget(header.Difficulty)
+	var nonce types.BlockNonce
+	for i := uint64(0); ; i++ {
+		header.Nonce = types.EncodeNonce(i)
+		if _, result := ethash.hashimotoLight(header); new(big.Int).SetBytes(result).Cmp(shareTarget) <= 0 && new(big.Int).SetBytes(result).Cmp(blockTarget) > 0 {
+			nonce = header.Nonce
+			break
+		}
+	}
+	submit := func(shareDifficulty *big.Int) error {
+		errc := make(chan error, 1)
+		ethash.remote.submitWorkCh <- &mineResult{nonce: nonce, hash: sealhash, shareDifficulty: shareDifficulty, errc: errc}
+		return <-errc
+	}
+	if err := submit(nil); err == nil {
+		t.Fatalf("share accepted at the block target")
+	}
+	if err := submit(big.NewInt(2)); err != nil {
+		t.Fatalf("share rejected: %v", err)
+	}
+	if err := submit(big.NewInt(2)); err == nil {
+		t.Fatalf("duplicate share accepted")
+	}
+	submits := (&API{ethash: ethash}).GetStatsSnapshot().Submits
+	if submits.Shares != 1 || submits.Accepted != 0 || submits.Invalid != 1 || submits.Duplicate != 1 {
+		t.Errorf("submit stats mismatch: have %+v", submits)
+	}
+	select {
+	case result := <-results:
+		t.Fatalf("share delivered as block %d", result.Block.NumberU64())
+	default:
+	}
+	// Only the accepted share should have been announced
+	select {
+	case ev := <-shares:
+		if ev.SealHash != sealhash || ev.Coinbase != header.Coinbase || ev.Difficulty.Int64() != 2 || ev.Block {
+			t.Errorf("share event mismatch: have %+v", ev)
+		}
+	default:
+		t.Fatalf("no share event posted")
+	}
+	if len(shares) != 0 {
+		t.Errorf("rejected solutions announced: %d", len(shares))
+	}
+}
+
+// Tests that newWork subscribers are pushed every regenerated work package.
+func TestNewWorkSubscription(t *testing.T) {
+	ethash := NewTester(nil, true)
+	defer ethash.Close()
+	ethash.SetThreads(-1)
+
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("eth", &API{ethash: ethash}); err != nil {
+		t.Fatalf("failed to register api: %v", err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	works := make(chan [11]string)
+	sub, err := client.Subscribe(context.Background(), "eth", works, "newWork")
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	for i := 1; i <= 2; i++ {
+		header := &types.Header{Number: big.NewInt(int64(i)), Difficulty: big.NewInt(100000000)}
+		ethash.Seal(nil, types.NewBlockWithHeader(header), nil, nil)
+		select {
+		case work := <-works:
+			if want := ethash.SealHash(header).Hex(); work[0] != want {
+				t.Errorf("work %d: hash mismatch: have %s, want %s", i, work[0], want)
+			}
+		case err := <-sub.Err():
+			t.Fatalf("subscription failed: %v", err)
+		case <-time.After(3 * time.Second):
+			t.Fatalf("work %d not pushed", i)
+		}
+	}
+	// Refreshed work is pushed as well
+	if err := (&API{ethash: ethash}).RefreshWork(); err != nil {
+		t.Fatalf("failed to refresh work: %v", err)
+	}
+	select {
+	case <-works:
+	case <-time.After(3 * time.Second):
+		t.Fatalf("refreshed work not pushed")
+	}
+}
+
+// Tests that the extended work package carries the fields of the legacy array.
+func TestGetWorkExtended(t *testing.T) {
+	ethash := NewTester(nil, true)
+	defer ethash.Close()
+	ethash.SetThreads(-1)
+	api := &API{ethash: ethash}
+
+	header := &types.Header{Number: big.NewInt(5), Difficulty: big.NewInt(100000000), GasLimit: 8000000, ParentHash: common.Hash{1}}
+	uncles := []*types.Header{{Number: big.NewInt(4)}}
+	ethash.Seal(nil, types.NewBlock(header, nil, uncles, nil, trie.NewStackTrie(nil)), nil, nil)
+
+	work, _ := api.GetWork()
+	ext, err := api.GetWorkExtended()
+	if err != nil {
+		t.Fatalf("failed to get extended work: %v", err)
+	}
+	if ext.PowHash.Hex() != work[0] || ext.SeedHash.Hex() != work[1] || ext.Target.Hex() != work[2] || ext.ParentHash.Hex() != work[4] {
+		t.Errorf("hash fields mismatch: have %+v, want %v", ext, work)
+	}
+	if ext.BlockNumber != 5 || ext.GasLimit != 8000000 || ext.GasUsed != 0 || ext.TxCount != 0 || ext.UncleCount != 1 {
+		t.Errorf("numeric fields mismatch: have %+v", ext)
+	}
+	if ext.HeaderRLP.String() != work[9] || ext.MEVProfit != work[10] {
+		t.Errorf("header and profit mismatch: have %s, %s, want %s, %s", ext.HeaderRLP, ext.MEVProfit, work[9], work[10])
+	}
+}
+
 // Tests whether stale solutions are correctly processed.
 func TestStaleSubmission(t *testing.T) {
 	ethash := NewTester(nil, true)
 	defer ethash.Close()
-	api := &API{ethash}
+	api := &API{ethash: ethash}
 
 	fakeNonce, fakeDigest := types.BlockNonce{0x01, 0x02, 0x03}, common.HexToHash("deadbeef")
 
@@ -264,7 +527,7 @@ func TestStaleSubmission(t *testing.T) {
 		for _, h := range c.headers {
 			ethash.Seal(nil, types.NewBlockWithHeader(h), results, nil)
 		}
-		if res := api.SubmitWork(fakeNonce, ethash.SealHash(c.headers[c.submitIndex]), fakeDigest, nil); res != c.submitRes {
+		if res := api.SubmitWork(context.Background(), fakeNonce, ethash.SealHash(c.headers[c.submitIndex]), fakeDigest, nil, nil, nil); res != c.submitRes {
 			t.Errorf("case %d submit result mismatch, want %t, get %t", id+1, c.submitRes, res)
 		}
 		if !c.submitRes {
@@ -293,3 +556,841 @@ func TestStaleSubmission(t *testing.T) {
 		}
 	}
 }
+
+// Tests that duplicate solutions are rejected within the deduplication window
+// and accepted again once the window has passed.
+func TestDuplicateSubmission(t *testing.T) {
+	config := Config{
+		PowMode:     ModeTest,
+		DedupWindow: 200 * time.Millisecond,
+		Log:         testlog.Logger(t, log.LvlError),
+	}
+	ethash := New(config, nil, true)
+	defer ethash.Close()
+	api := &API{ethash: ethash}
+
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100000000)}
+	results := make(chan types.SealResult, 4)
+	ethash.Seal(nil, types.NewBlockWithHeader(header), results, nil)
+
+	var (
+		sealhash   = ethash.SealHash(header)
+		fakeNonce  = types.BlockNonce{0x01, 0x02, 0x03}
+		fakeDigest = common.HexToHash("deadbeef")
+	)
+	if !api.SubmitWork(context.Background(), fakeNonce, sealhash, fakeDigest, nil, nil, nil) {
+		t.Fatal("first submission rejected")
+	}
+	if api.SubmitWork(context.Background(), fakeNonce, sealhash, fakeDigest, nil, nil, nil) {
+		t.Fatal("duplicate submission accepted within window")
+	}
+	time.Sleep(config.DedupWindow)
+	if !api.SubmitWork(context.Background(), fakeNonce, sealhash, fakeDigest, nil, nil, nil) {
+		t.Fatal("submission rejected after window expiry")
+	}
+}
+
+// Tests that within the stale window only solutions to the replaced work of the
+// previous height are sealed, and accounted as stale-accepted.
+func TestStaleWindow(t *testing.T) {
+	config := Config{
+		PowMode:     ModeTest,
+		StaleWindow: 200 * time.Millisecond,
+		Log:         testlog.Logger(t, log.LvlError),
+	}
+	ethash := New(config, nil, true)
+	defer ethash.Close()
+	api := &API{ethash: ethash}
+
+	var (
+		headers = []*types.Header{
+			{Number: big.NewInt(1), Difficulty: big.NewInt(100000000)},
+			{Number: big.NewInt(2), Difficulty: big.NewInt(100000000)},
+			{Number: big.NewInt(3), Difficulty: big.NewInt(100000000)},
+		}
+		results    = make(chan types.SealResult, 4)
+		fakeDigest = common.HexToHash("deadbeef")
+		miner      = common.Hash{0x01}
+	)
+	submit := func(header *types.Header, nonce byte) bool {
+		return api.SubmitWork(context.Background(), types.BlockNonce{nonce}, ethash.SealHash(header), fakeDigest, nil, &miner, nil)
+	}
+	ethash.Seal(nil, types.NewBlockWithHeader(headers[0]), results, nil)
+	ethash.Seal(nil, types.NewBlockWithHeader(headers[1]), results, nil)
+	if !submit(headers[0], 1) {
+		t.Fatal("solution to the replaced work rejected within the window")
+	}
+	select {
+	case res := <-results:
+		if res.Block.NumberU64() != 1 {
+			t.Errorf("sealed block number mismatch: have %d, want 1", res.Block.NumberU64())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("solution to the replaced work not sealed")
+	}
+	ethash.Seal(nil, types.NewBlockWithHeader(headers[2]), results, nil)
+	if submit(headers[0], 2) {
+		t.Error("solution to work two heights back accepted")
+	}
+	time.Sleep(config.StaleWindow)
+	if submit(headers[1], 3) {
+		t.Error("solution to the replaced work accepted after the window")
+	}
+	if !submit(headers[2], 4) {
+		t.Error("solution to the current work rejected")
+	}
+	stats, err := api.GetMinerStats(miner)
+	if err != nil {
+		t.Fatalf("failed to get miner stats: %v", err)
+	}
+	if stats.StaleAccepted != 1 || stats.Stale != 2 || stats.Accepted != 1 {
+		t.Errorf("miner stats mismatch: have %+v", stats)
+	}
+	if submits := api.GetStatsSnapshot().Submits; submits.StaleAccepted != 1 {
+		t.Errorf("stale-accepted submissions mismatch: have %d, want 1", submits.StaleAccepted)
+	}
+}
+
+// Tests that issued work can be looked up by pow-hash, reporting whether it is
+// still valid once replaced by newer work.
+func TestGetWorkByHash(t *testing.T) {
+	ethash := NewTester(nil, true)
+	defer ethash.Close()
+	api := &API{ethash: ethash}
+
+	headers := make([]*types.Header, staleThreshold+1)
+	for i := range headers {
+		headers[i] = &types.Header{Number: big.NewInt(int64(i + 1)), Difficulty: big.NewInt(100)}
+	}
+	ethash.Seal(nil, types.NewBlockWithHeader(headers[0]), nil, nil)
+	work, err := api.GetWork()
+	if err != nil {
+		t.Fatalf("failed to get work: %v", err)
+	}
+	issued, err := api.GetWorkByHash(ethash.SealHash(headers[0]))
+	if err != nil {
+		t.Fatalf("failed to look up issued work: %v", err)
+	}
+	if issued.Work != work || !issued.Valid {
+		t.Errorf("issued work mismatch: have %+v, want %v valid", issued, work)
+	}
+	for _, header := range headers[1:] {
+		ethash.Seal(nil, types.NewBlockWithHeader(header), nil, nil)
+	}
+	if issued, err = api.GetWorkByHash(ethash.SealHash(headers[0])); err != nil {
+		t.Fatalf("failed to look up replaced work: %v", err)
+	}
+	if issued.Valid {
+		t.Error("stale work reported valid")
+	}
+	if issued, err = api.GetWorkByHash(ethash.SealHash(headers[1])); err != nil || !issued.Valid {
+		t.Errorf("recent work not valid: %+v, %v", issued, err)
+	}
+	if _, err := api.GetWorkByHash(common.Hash{0x01}); err != errWorkNotIssued {
+		t.Errorf("unknown work lookup error mismatch: have %v, want %v", err, errWorkNotIssued)
+	}
+}
+
+// Tests that solutions submitted in a batch report their outcome in order, with
+// the PoW values memoized by the share verifier.
+func TestSubmitWorkBatch(t *testing.T) {
+	ethash := NewTester(nil, false)
+	defer ethash.Close()
+	api := &API{ethash: ethash}
+
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(1)}
+	ethash.Seal(nil, types.NewBlockWithHeader(header), make(chan types.SealResult, 4), nil)
+	if _, err := api.GetWork(); err != nil {
+		t.Fatalf("failed to get work: %v", err)
+	}
+	hash := ethash.SealHash(header)
+	batch := []BatchSubmission{
+		{Nonce: types.EncodeNonce(1), PowHash: hash},
+		{Nonce: types.EncodeNonce(2), PowHash: hash},
+		{Nonce: types.EncodeNonce(1), PowHash: hash},
+		{Nonce: types.EncodeNonce(3), PowHash: common.Hash{0x01}},
+	}
+	results, err := api.SubmitWorkBatch(context.Background(), batch, nil)
+	if err != nil {
+		t.Fatalf("failed to submit batch: %v", err)
+	}
+	accepted := 0
+	for i, result := range results[:3] {
+		if result.Accepted {
+			accepted++
+		} else if result.Error != errInvalidSealResult.Error() {
+			t.Errorf("result %d: error mismatch: have %q, want %q", i, result.Error, errInvalidSealResult)
+		}
+	}
+	if accepted != 2 {
+		t.Errorf("accepted solutions mismatch: have %d, want 2 of the distinct nonces", accepted)
+	}
+	if results[3].Accepted {
+		t.Error("solution to unknown work accepted")
+	}
+	if n := ethash.shares.results.Len(); n != 2 {
+		t.Errorf("memoized results mismatch: have %d, want 2", n)
+	}
+	if _, err := api.SubmitWorkBatch(context.Background(), make([]BatchSubmission, maxSubmitBatch+1), nil); err == nil {
+		t.Error("oversized batch accepted")
+	}
+}
+
+// Tests that submissions are persisted in the audit log, and that logged valid
+// solutions are rejected as replays after the dedup window and restarts.
+func TestSubmissionLog(t *testing.T) {
+	var (
+		db         = rawdb.NewMemoryDatabase()
+		header     = &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100000000)}
+		fakeNonce  = types.BlockNonce{0x01, 0x02, 0x03}
+		fakeDigest = common.HexToHash("deadbeef")
+	)
+	submit := func(want ...bool) {
+		ethash := New(Config{PowMode: ModeTest, SubmissionDB: db, Log: testlog.Logger(t, log.LvlError)}, nil, true)
+		defer ethash.Close()
+		api := &API{ethash: ethash}
+
+		ethash.Seal(nil, types.NewBlockWithHeader(header), make(chan types.SealResult, 4), nil)
+		sealhash := ethash.SealHash(header)
+		for i, want := range want {
+			if have := api.SubmitWork(context.Background(), fakeNonce, sealhash, fakeDigest, nil, nil, nil); have != want {
+				t.Fatalf("submission %d mismatch: have %v, want %v", i, have, want)
+			}
+		}
+		api.SubmitWork(context.Background(), fakeNonce, common.Hash{0x01}, fakeDigest, nil, nil, nil)
+	}
+	submit(true, false)
+	submit(false) // Replayed across restarts
+
+	ethash := New(Config{PowMode: ModeTest, SubmissionDB: db}, nil, true)
+	defer ethash.Close()
+	api := &API{ethash: ethash}
+
+	page, err := api.GetSubmissionLog(0, 2)
+	if err != nil {
+		t.Fatalf("failed to get submission log: %v", err)
+	}
+	if len(page.Records) != 2 || page.Next != 2 {
+		t.Fatalf("first page mismatch: have %d records, next %d", len(page.Records), page.Next)
+	}
+	rest, err := api.GetSubmissionLog(page.Next, 10)
+	if err != nil {
+		t.Fatalf("failed to get submission log: %v", err)
+	}
+	var results []string
+	for i, record := range append(page.Records, rest.Records...) {
+		if record.Seq != hexutil.Uint64(i) || record.Nonce != fakeNonce || record.MixDigest != fakeDigest {
+			t.Errorf("record %d mismatch: %+v", i, record)
+		}
+		results = append(results, record.Result)
+	}
+	want := []string{"accepted", "duplicate", "unknown", "duplicate", "unknown"}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("results mismatch: have %v, want %v", results, want)
+	}
+	if rest.Next != 5 {
+		t.Errorf("next sequence mismatch: have %d, want 5", rest.Next)
+	}
+}
+
+// Tests that externally assembled bodies are checked against the work package.
+func TestValidateBlockBody(t *testing.T) {
+	ethash := NewTester(nil, true)
+	defer ethash.Close()
+	api := &API{ethash: ethash}
+
+	var (
+		tx     = types.NewTransaction(0, common.Address{0x1}, big.NewInt(1), 21000, big.NewInt(1), nil)
+		header = &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100000000), GasLimit: 30000, GasUsed: 21000}
+		block  = types.NewBlock(header, []*types.Transaction{tx}, nil, nil, trie.NewStackTrie(nil))
+	)
+	ethash.Seal(nil, block, make(chan types.SealResult, 1), nil)
+
+	sealhash := ethash.SealHash(block.Header())
+	if err := api.ValidateBlockBody(sealhash, block.Body()); err != nil {
+		t.Fatalf("valid body rejected: %v", err)
+	}
+	if err := api.ValidateBlockBody(sealhash, &types.Body{}); err == nil {
+		t.Fatal("tampered body accepted")
+	}
+	if err := api.ValidateBlockBody(common.Hash{0x1}, block.Body()); err != errWorkNotRetained {
+		t.Fatalf("unknown work error mismatch: have %v, want %v", err, errWorkNotRetained)
+	}
+}
+
+// Tests that extraNonces are placed into the reserved trailing extra-data bytes
+// of the served header and that other placements are rejected.
+func TestExtraNoncePlacement(t *testing.T) {
+	ethash := NewTester(nil, true)
+	defer ethash.Close()
+	api := &API{ethash: ethash}
+
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100000000), Extra: []byte("bitnet")}
+	results := make(chan types.SealResult, 1)
+	ethash.Seal(nil, types.NewBlockWithHeader(header), results, nil)
+
+	// The pow-hash a miner derives from the served RLP must match the placed header
+	extraNonce := []byte{0xde, 0xad, 0xbe, 0xef}
+	blob, err := workHeaderRLP(header, extraNonce)
+	if err != nil {
+		t.Fatalf("failed to encode work header: %v", err)
+	}
+	placed, err := ethash.placeExtraNonce(header, extraNonce)
+	if err != nil {
+		t.Fatalf("failed to place extraNonce: %v", err)
+	}
+	if have, want := ethash.SealHash(placed), crypto.Keccak256Hash(blob); have != want {
+		t.Fatalf("placed header hash mismatch: have %x, want %x", have, want)
+	}
+	// Placing the extraNonce anywhere else must not reproduce the pow-hash
+	wrong := types.CopyHeader(header)
+	wrong.Extra = append(append([]byte{}, extraNonce...), header.Extra...)
+	if ethash.SealHash(wrong) == crypto.Keccak256Hash(blob) {
+		t.Fatal("misplaced extraNonce reproduced the pow-hash")
+	}
+	// Submissions must only be accepted with a correctly sized extraNonce
+	sealhash := ethash.SealHash(header)
+	if api.SubmitWork(context.Background(), types.BlockNonce{0x01}, sealhash, common.Hash{}, stringPtr("0xdeadbeef00"), nil, nil) {
+		t.Fatal("oversized extraNonce accepted")
+	}
+	if !api.SubmitWork(context.Background(), types.BlockNonce{0x01}, sealhash, common.Hash{}, stringPtr("0xdeadbeef"), nil, nil) {
+		t.Fatal("valid extraNonce rejected")
+	}
+	result := <-results
+	if extra := result.Block.Extra(); !bytes.Equal(extra, append([]byte("bitnet"), extraNonce...)) {
+		t.Fatalf("sealed extra-data mismatch: have %x", extra)
+	}
+}
+
+func stringPtr(s string) *string { return &s }
+
+// Tests that work generated from a header matches the work served for it.
+func TestGenerateWorkFromHeader(t *testing.T) {
+	ethash := NewTester(nil, true)
+	defer ethash.Close()
+	api := &API{ethash: ethash}
+
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100000000), GasLimit: 8000000}
+	ethash.Seal(nil, types.NewBlockWithHeader(header), make(chan types.SealResult, 1), nil)
+
+	served, err := api.GetWork()
+	if err != nil {
+		t.Fatalf("failed to get work: %v", err)
+	}
+	generated, err := api.GenerateWorkFromHeader(header)
+	if err != nil {
+		t.Fatalf("failed to generate work: %v", err)
+	}
+	if generated != WorkPackage(served) {
+		t.Fatalf("generated work mismatch: have %v, want %v", generated, served)
+	}
+	if _, err := api.GenerateWorkFromHeader(&types.Header{Number: big.NewInt(1)}); err == nil {
+		t.Fatal("header without difficulty accepted")
+	}
+}
+
+// Tests that the uncles of the pending block are reported with their reward
+// eligibility at the pending height.
+func TestGetEligibleUncles(t *testing.T) {
+	ethash := NewTester(nil, true)
+	defer ethash.Close()
+	api := &API{ethash: ethash, chain: &fakeChainReader{config: params.TestChainConfig}}
+
+	if _, err := api.GetEligibleUncles(); err == nil {
+		t.Fatalf("uncles reported without a miner")
+	}
+	var (
+		pending = &types.Header{Number: big.NewInt(10)}
+		uncles  []PendingUncle
+	)
+	ethash.SetUncleSource(func() (*types.Header, []PendingUncle, error) {
+		return pending, uncles, nil
+	})
+	if candidates, err := api.GetEligibleUncles(); err != nil || candidates == nil || len(candidates) != 0 {
+		t.Fatalf("uncles reported without candidates: %v, %v", candidates, err)
+	}
+	uncles = []PendingUncle{
+		{Header: &types.Header{Number: big.NewInt(9), Extra: []byte{1}}, Included: true},
+		{Header: &types.Header{Number: big.NewInt(9), Extra: []byte{2}}, Local: true, Err: errors.New("uncle is sibling")},
+		{Header: &types.Header{Number: big.NewInt(4), Extra: []byte{3}}},
+		{Header: &types.Header{Number: big.NewInt(3), Extra: []byte{4}}},
+	}
+	candidates, err := api.GetEligibleUncles()
+	if err != nil {
+		t.Fatalf("failed to get uncles: %v", err)
+	}
+	want := []struct {
+		depth    uint64
+		eligible bool
+		eighths  int64
+		reason   string
+	}{
+		{1, true, 7, ""},
+		{1, false, 0, "uncle is sibling"},
+		{6, true, 2, ""},
+		{7, false, 0, "depth 7 outside [1, 6]"},
+	}
+	if len(candidates) != len(want) {
+		t.Fatalf("candidate count mismatch: have %d, want %d", len(candidates), len(want))
+	}
+	for i, c := range candidates {
+		if c.Header.Hash() != uncles[i].Header.Hash() || c.Local != uncles[i].Local || c.Included != uncles[i].Included {
+			t.Errorf("candidate %d: header or origin mismatch", i)
+		}
+		if uint64(c.Depth) != want[i].depth || c.Eligible != want[i].eligible || c.Reason != want[i].reason {
+			t.Errorf("candidate %d: have depth %d eligible %v reason %q, want %d %v %q", i, c.Depth, c.Eligible, c.Reason, want[i].depth, want[i].eligible, want[i].reason)
+		}
+		if !c.Eligible {
+			if c.Reward != nil {
+				t.Errorf("candidate %d: ineligible uncle rewarded", i)
+			}
+			continue
+		}
+		reward := new(big.Int).Div(new(big.Int).Mul(ConstantinopleBlockReward, big.NewInt(want[i].eighths)), big8)
+		if c.Reward.ToInt().Cmp(reward) != 0 {
+			t.Errorf("candidate %d: reward mismatch: have %v, want %v", i, c.Reward, reward)
+		}
+	}
+	// Errors of the miner are surfaced
+	ethash.SetUncleSource(func() (*types.Header, []PendingUncle, error) {
+		return nil, nil, errors.New("no sealing block")
+	})
+	if _, err := api.GetEligibleUncles(); err == nil {
+		t.Errorf("miner error not surfaced")
+	}
+}
+
+// Tests that the expected mix digest matches the one of a sealed block.
+func TestExpectedMixDigest(t *testing.T) {
+	ethash := NewTester(nil, false)
+	defer ethash.Close()
+	api := &API{ethash: ethash}
+
+	results := make(chan types.SealResult, 1)
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100)}
+	ethash.Seal(nil, types.NewBlockWithHeader(header), results, nil)
+
+	var sealed *types.Block
+	select {
+	case result := <-results:
+		sealed = result.Block
+	case <-time.After(4 * time.Second):
+		t.Fatal("sealing result timeout")
+	}
+	digest, err := api.ExpectedMixDigest(ethash.SealHash(header), types.EncodeNonce(sealed.Nonce()), nil)
+	if err != nil {
+		t.Fatalf("failed to compute mix digest: %v", err)
+	}
+	if digest != sealed.MixDigest() {
+		t.Fatalf("mix digest mismatch: have %x, want %x", digest, sealed.MixDigest())
+	}
+	if _, err := api.ExpectedMixDigest(common.Hash{1}, types.BlockNonce{}, nil); err != errWorkNotRetained {
+		t.Fatalf("unknown work error mismatch: have %v, want %v", err, errWorkNotRetained)
+	}
+}
+
+// Tests that the reward breakdown of the current work sums up to the net
+// coinbase credit.
+func TestGetWorkRewardBreakdown(t *testing.T) {
+	ethash := NewTester(nil, true)
+	defer ethash.Close()
+	ethash.SetThreads(-1)
+	chain := &fakeChainReader{config: params.TestChainConfig}
+	api := &API{ethash: ethash, chain: chain}
+
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	var (
+		header = &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100000000), GasLimit: 8000000, GasUsed: 63000, BaseFee: big.NewInt(5)}
+		uncles = []*types.Header{{Number: big.NewInt(0), Extra: []byte{1}}}
+		txs    []*types.Transaction
+		rcpts  []*types.Receipt
+	)
+	for i := 0; i < 3; i++ {
+		txs = append(txs, types.NewTx(&types.DynamicFeeTx{Nonce: uint64(i), Gas: 21000, GasTipCap: big.NewInt(int64(i + 1)), GasFeeCap: big.NewInt(7)}))
+		rcpts = append(rcpts, &types.Receipt{GasUsed: 21000})
+	}
+	block, err := ethash.FinalizeAndAssemble(chain, header, statedb, txs, uncles, rcpts, nil)
+	if err != nil {
+		t.Fatalf("failed to assemble block: %v", err)
+	}
+	ethash.Seal(nil, block, make(chan types.SealResult, 1), nil)
+
+	breakdown, err := api.GetWorkRewardBreakdown()
+	if err != nil {
+		t.Fatalf("failed to get reward breakdown: %v", err)
+	}
+	// Tips are capped by the fee cap: 1, 2 and 2 wei per gas above the base fee
+	tips := big.NewInt(5 * 21000)
+	if have := new(big.Int).Sub(breakdown.Fees.ToInt(), breakdown.Burnt.ToInt()); have.Cmp(tips) != 0 {
+		t.Errorf("priority fees mismatch: have %v, want %v", have, tips)
+	}
+	if burnt := big.NewInt(5 * 63000); breakdown.Burnt.ToInt().Cmp(burnt) != 0 {
+		t.Errorf("burnt fees mismatch: have %v, want %v", breakdown.Burnt, burnt)
+	}
+	sum := new(big.Int).Add(breakdown.Subsidy.ToInt(), breakdown.Fees.ToInt())
+	sum.Sub(sum, breakdown.Burnt.ToInt())
+	sum.Add(sum, breakdown.MEV.ToInt())
+	sum.Add(sum, breakdown.UncleRewards.ToInt())
+	if sum.Cmp(breakdown.Net.ToInt()) != 0 {
+		t.Fatalf("components don't sum to net credit: have %v, want %v", sum, breakdown.Net)
+	}
+	if breakdown.UncleRewards.ToInt().Sign() == 0 {
+		t.Error("uncle inclusion reward missing")
+	}
+}
+
+// Tests that the profit breakdown of the current work separates the priority
+// fees from the value transferred to the coinbase directly.
+func TestGetWorkProfit(t *testing.T) {
+	ethash := NewTester(nil, true)
+	defer ethash.Close()
+	ethash.SetThreads(-1)
+
+	var (
+		coinbase = common.Address{0xcb}
+		db       = state.NewDatabase(rawdb.NewMemoryDatabase())
+	)
+	parentState, _ := state.New(types.EmptyRootHash, db, nil)
+	parentState.AddBalance(coinbase, big.NewInt(1000))
+	root, _ := parentState.Commit(false)
+
+	parent := &types.Header{Number: big.NewInt(0), Root: root}
+	chain := &fakeChainReader{config: params.TestChainConfig, headers: map[common.Hash]*types.Header{parent.Hash(): parent}}
+	api := &API{ethash: ethash, chain: chain}
+
+	statedb, _ := state.New(root, db, nil)
+	var (
+		header = &types.Header{ParentHash: parent.Hash(), Number: big.NewInt(1), Coinbase: coinbase, Difficulty: big.NewInt(100000000), GasLimit: 8000000, GasUsed: 42000, BaseFee: big.NewInt(5)}
+		txs    []*types.Transaction
+		rcpts  []*types.Receipt
+	)
+	for i := 0; i < 2; i++ {
+		txs = append(txs, types.NewTx(&types.DynamicFeeTx{Nonce: uint64(i), Gas: 21000, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(7)}))
+		rcpts = append(rcpts, &types.Receipt{GasUsed: 21000})
+	}
+	// Credit the coinbase as transaction execution would: tips plus a transfer
+	tips := big.NewInt(2 * 21000)
+	statedb.AddBalance(coinbase, tips)
+	statedb.AddBalance(coinbase, big.NewInt(777))
+
+	block, err := ethash.FinalizeAndAssemble(chain, header, statedb, txs, nil, rcpts, nil)
+	if err != nil {
+		t.Fatalf("failed to assemble block: %v", err)
+	}
+	ethash.Seal(nil, block, make(chan types.SealResult, 1), nil)
+
+	profit, err := api.GetWorkProfit()
+	if err != nil {
+		t.Fatalf("failed to get work profit: %v", err)
+	}
+	if profit.PriorityFees.ToInt().Cmp(tips) != 0 {
+		t.Errorf("priority fees mismatch: have %v, want %v", profit.PriorityFees, tips)
+	}
+	if burnt := big.NewInt(5 * 42000); profit.BaseFeeBurned.ToInt().Cmp(burnt) != 0 {
+		t.Errorf("burnt fees mismatch: have %v, want %v", profit.BaseFeeBurned, burnt)
+	}
+	if profit.CoinbaseTransfers == nil || profit.CoinbaseTransfers.ToInt().Cmp(big.NewInt(777)) != 0 {
+		t.Errorf("coinbase transfers mismatch: have %v, want %v", profit.CoinbaseTransfers, 777)
+	}
+	if len(profit.Bundles) != 0 {
+		t.Errorf("unexpected bundle attribution: %v", profit.Bundles)
+	}
+}
+
+// Tests that the work commitment covers every field of the work package.
+func TestGetWorkCommitment(t *testing.T) {
+	ethash := NewTester(nil, true)
+	defer ethash.Close()
+	api := &API{ethash: ethash}
+
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100000000)}
+	ethash.Seal(nil, types.NewBlockWithHeader(header), make(chan types.SealResult, 1), nil)
+
+	work, err := api.GetWork()
+	if err != nil {
+		t.Fatalf("failed to get work: %v", err)
+	}
+	commitment, err := api.GetWorkCommitment()
+	if err != nil {
+		t.Fatalf("failed to get commitment: %v", err)
+	}
+	enc, _ := rlp.EncodeToBytes([]string(work[:]))
+	if want := crypto.Keccak256Hash(enc); commitment != want {
+		t.Fatalf("commitment mismatch: have %x, want %x", commitment, want)
+	}
+	for i := range work {
+		tampered := WorkPackage(work)
+		tampered[i] += "0"
+		if tampered.Commitment() == commitment {
+			t.Errorf("field %d not covered by commitment", i)
+		}
+	}
+}
+
+// Tests that long-polling for work blocks until the work changes.
+func TestGetWorkLongPoll(t *testing.T) {
+	ethash := NewTester(nil, true)
+	defer ethash.Close()
+	ethash.SetThreads(-1)
+	api := &API{ethash: ethash}
+
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100000000)}
+	ethash.Seal(nil, types.NewBlockWithHeader(header), make(chan types.SealResult, 1), nil)
+	current := ethash.SealHash(header)
+
+	// Work differing from the client's is returned immediately
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if work, err := api.GetWorkLongPoll(ctx, common.Hash{}); err != nil || work[0] != current.Hex() {
+		t.Fatalf("current work not returned: %v, %v", work[0], err)
+	}
+	// Work matching the client's blocks until new work arrives or timeout
+	short, cancelShort := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancelShort()
+	if _, err := api.GetWorkLongPoll(short, current); err != context.DeadlineExceeded {
+		t.Fatalf("unchanged work error mismatch: have %v, want %v", err, context.DeadlineExceeded)
+	}
+	next := &types.Header{Number: big.NewInt(2), Difficulty: big.NewInt(100000000)}
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		ethash.Seal(nil, types.NewBlockWithHeader(next), make(chan types.SealResult, 1), nil)
+	}()
+	work, err := api.GetWorkLongPoll(ctx, current)
+	if err != nil {
+		t.Fatalf("failed to long-poll work: %v", err)
+	}
+	if want := ethash.SealHash(next).Hex(); work[0] != want {
+		t.Fatalf("long-polled work mismatch: have %s, want %s", work[0], want)
+	}
+}
+
+// Tests that the recently generated work packages are listed newest first.
+func TestGetRecentWork(t *testing.T) {
+	ethash := NewTester(nil, true)
+	defer ethash.Close()
+	ethash.SetThreads(-1)
+	api := &API{ethash: ethash}
+
+	var hashes []string
+	for i := 1; i <= workHistorySize+2; i++ {
+		header := &types.Header{Number: big.NewInt(int64(i)), Difficulty: big.NewInt(100000000)}
+		ethash.Seal(nil, types.NewBlockWithHeader(header), make(chan types.SealResult, 1), nil)
+		hashes = append(hashes, ethash.SealHash(header).Hex())
+	}
+	recent, err := api.GetRecentWork(2)
+	if err != nil {
+		t.Fatalf("failed to get recent work: %v", err)
+	}
+	if len(recent) != 2 || recent[0][0] != hashes[len(hashes)-1] || recent[1][0] != hashes[len(hashes)-2] {
+		t.Fatalf("recent work mismatch: have %v", recent)
+	}
+	if all, _ := api.GetRecentWork(1000); len(all) != workHistorySize || all[len(all)-1][0] != hashes[2] {
+		t.Fatalf("recent work not capped to retention: have %d packages", len(all))
+	}
+}
+
+// Tests that solutions can be submitted directly against registered work when
+// running without a remote sealer.
+func TestDirectSubmit(t *testing.T) {
+	ethash := New(Config{PowMode: ModeTest, AllowDirectSubmit: true}, nil, false)
+	defer ethash.Close()
+	api := &API{ethash: ethash}
+
+	if ethash.remote != nil {
+		t.Fatal("remote sealer started with direct submission")
+	}
+	// Find a valid solution with the local miner
+	results := make(chan types.SealResult, 1)
+	block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100)})
+	ethash.Seal(nil, block, results, nil)
+
+	var sealed *types.Block
+	select {
+	case result := <-results:
+		sealed = result.Block
+	case <-time.After(4 * time.Second):
+		t.Fatal("sealing result timeout")
+	}
+	nonce := types.EncodeNonce(sealed.Nonce())
+
+	// Solutions for unregistered work are rejected, registered ones delivered
+	hash := ethash.SealHash(block.Header())
+	if api.SubmitWork(context.Background(), nonce, hash, sealed.MixDigest(), nil, nil, nil) {
+		t.Fatal("solution for unregistered work accepted")
+	}
+	delivered := make(chan *types.Block, 1)
+	if registered, err := ethash.RegisterWork(block, func(b *types.Block) { delivered <- b }); err != nil || registered != hash {
+		t.Fatalf("failed to register work: %x, %v", registered, err)
+	}
+	if api.SubmitWork(context.Background(), types.EncodeNonce(sealed.Nonce()+1), hash, common.Hash{}, nil, nil, nil) {
+		t.Fatal("invalid solution accepted")
+	}
+	if !api.SubmitWork(context.Background(), nonce, hash, sealed.MixDigest(), nil, nil, nil) {
+		t.Fatal("valid solution rejected")
+	}
+	select {
+	case b := <-delivered:
+		if b.Hash() != sealed.Hash() {
+			t.Fatalf("delivered block mismatch: have %x, want %x", b.Hash(), sealed.Hash())
+		}
+	default:
+		t.Fatal("solution not delivered")
+	}
+	if api.SubmitWork(context.Background(), nonce, hash, sealed.MixDigest(), nil, nil, nil) {
+		t.Fatal("solution delivered twice")
+	}
+	if _, err := NewTester(nil, true).RegisterWork(block, nil); err == nil {
+		t.Fatal("work registered without direct submission")
+	}
+}
+
+// Tests that the target of retained work matches the served work package.
+func TestGetWorkTarget(t *testing.T) {
+	ethash := NewTester(nil, true)
+	defer ethash.Close()
+	ethash.SetThreads(-1)
+	api := &API{ethash: ethash}
+
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100000000)}
+	ethash.Seal(nil, types.NewBlockWithHeader(header), make(chan types.SealResult, 1), nil)
+
+	work, _ := api.GetWork()
+	target, err := api.GetWorkTarget(ethash.SealHash(header))
+	if err != nil {
+		t.Fatalf("failed to get target: %v", err)
+	}
+	if target.Target.Hex() != work[2] || target.Difficulty.ToInt().Cmp(header.Difficulty) != 0 {
+		t.Fatalf("target mismatch: have %+v, want %s, %v", target, work[2], header.Difficulty)
+	}
+	if _, err := api.GetWorkTarget(common.Hash{1}); err != errWorkNotRetained {
+		t.Fatalf("unknown work error mismatch: have %v, want %v", err, errWorkNotRetained)
+	}
+}
+
+// Tests that rapid pending block changes only regenerate work once per
+// MinWorkInterval, while forced refreshes bypass the throttle.
+func TestWorkRegenerationThrottle(t *testing.T) {
+	var (
+		notified = make(chan struct{}, 64)
+		server   = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			notified <- struct{}{}
+		}))
+	)
+	defer server.Close()
+
+	config := Config{
+		PowMode:         ModeTest,
+		MinWorkInterval: 300 * time.Millisecond,
+		Log:             testlog.Logger(t, log.LvlWarn),
+	}
+	ethash := New(config, []string{server.URL}, true)
+	defer ethash.Close()
+	ethash.SetThreads(-1)
+	api := &API{ethash: ethash}
+
+	waitNotified := func(n int) {
+		for deadline := time.Now().Add(3 * time.Second); len(notified) < n; time.Sleep(10 * time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatalf("notification %d timed out", n)
+			}
+		}
+	}
+	var last *types.Header
+	for i := 1; i <= 20; i++ {
+		last = &types.Header{Number: big.NewInt(int64(i)), Difficulty: big.NewInt(100000000)}
+		ethash.Seal(nil, types.NewBlockWithHeader(last), nil, nil)
+	}
+	waitNotified(2)
+	time.Sleep(2 * config.MinWorkInterval)
+	if n := len(notified); n != 2 {
+		t.Fatalf("regeneration count mismatch: have %d, want 2", n)
+	}
+	if work, err := api.GetWork(); err != nil || work[0] != ethash.SealHash(last).Hex() {
+		t.Fatalf("latest work not served: %v", err)
+	}
+	if err := api.RefreshWork(); err != nil {
+		t.Fatalf("failed to refresh work: %v", err)
+	}
+	waitNotified(3)
+}
+
+// fakeBlockChain is a canonical chain of blocks serving headers and bodies.
+type fakeBlockChain struct {
+	fakeChainReader
+	blocks []*types.Block
+}
+
+func (c *fakeBlockChain) CurrentHeader() *types.Header { return c.blocks[len(c.blocks)-1].Header() }
+
+func (c *fakeBlockChain) GetHeaderByNumber(number uint64) *types.Header {
+	if number >= uint64(len(c.blocks)) {
+		return nil
+	}
+	return c.blocks[number].Header()
+}
+
+func (c *fakeBlockChain) GetBlock(hash common.Hash, number uint64) *types.Block {
+	if number >= uint64(len(c.blocks)) || c.blocks[number].Hash() != hash {
+		return nil
+	}
+	return c.blocks[number]
+}
+
+// Tests that sealed blocks are classified as included, uncled, orphaned or
+// pending against the canonical chain.
+func TestGetOrphanStats(t *testing.T) {
+	ethash := NewTester(nil, true)
+	defer ethash.Close()
+
+	var (
+		local  = common.Address{0x01}
+		remote = common.Address{0x02}
+		sealed = func(number int64, coinbase common.Address) *types.Block {
+			return types.NewBlockWithHeader(&types.Header{Number: big.NewInt(number), Coinbase: coinbase, Extra: []byte("sibling")})
+		}
+		uncle    = sealed(4, local)
+		orphan   = sealed(2, remote)
+		pending  = sealed(9, remote)
+		chain    = new(fakeBlockChain)
+		included *types.Block
+	)
+	for i := int64(0); i <= 10; i++ {
+		header := &types.Header{Number: big.NewInt(i), Coinbase: remote}
+		var uncles []*types.Header
+		if i == 5 {
+			uncles = append(uncles, uncle.Header())
+		}
+		block := types.NewBlock(header, nil, uncles, nil, trie.NewStackTrie(nil))
+		if i == 3 {
+			block = types.NewBlock(&types.Header{Number: big.NewInt(i), Coinbase: local}, nil, nil, nil, trie.NewStackTrie(nil))
+			included = block
+		}
+		chain.blocks = append(chain.blocks, block)
+	}
+	for _, block := range []*types.Block{orphan, included, uncle, pending} {
+		ethash.sealed.add(block)
+	}
+	api := &API{ethash: ethash, chain: chain}
+
+	stats, err := api.GetOrphanStats(100)
+	if err != nil {
+		t.Fatalf("failed to get orphan stats: %v", err)
+	}
+	if have, want := *stats[local], (OrphanStats{Sealed: 2, Included: 1, Uncled: 1}); have != want {
+		t.Errorf("local stats mismatch: have %+v, want %+v", have, want)
+	}
+	if have, want := *stats[remote], (OrphanStats{Sealed: 2, Orphaned: 1, Pending: 1}); have != want {
+		t.Errorf("remote stats mismatch: have %+v, want %+v", have, want)
+	}
+	// Only blocks within the window are considered
+	stats, err = api.GetOrphanStats(3)
+	if err != nil {
+		t.Fatalf("failed to get orphan stats: %v", err)
+	}
+	if _, ok := stats[local]; ok {
+		t.Errorf("blocks below the window counted: %+v", *stats[local])
+	}
+	if have, want := *stats[remote], (OrphanStats{Sealed: 1, Pending: 1}); have != want {
+		t.Errorf("windowed stats mismatch: have %+v, want %+v", have, want)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package ethash
+
+import (
+	"math/big"
+	"runtime"
+
+	"github.com/ethereum/go-ethereum/common"
+	lrupkg "github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// shareResultsRetained is the number of share verification results memoized.
+const shareResultsRetained = 16384
+
+// ShareVerification is the outcome of a light share verification.
+type ShareVerification struct {
+	MixDigest common.Hash `json:"mixDigest"`
+	Result    common.Hash `json:"result"`
+	Valid     bool        `json:"valid"` // Whether the result meets the share target
+}
+
+// shareKey identifies a share by the pow-hash and nonce searched, and the block
+// height selecting the verification cache.
+type shareKey struct {
+	hash   common.Hash
+	nonce  types.BlockNonce
+	number uint64
+}
+
+// shareValues are the memoized PoW values of a share or a verified header.
+type shareValues struct {
+	digest common.Hash
+	result common.Hash
+}
+
+// shareVerifier computes the PoW values of shares using only the verification
+// caches, bounding the concurrently running computations and memoizing their
+// results so duplicate shares don't cost a recomputation.
+type shareVerifier struct {
+	ethash  *Ethash
+	slots   chan struct{} // Semaphore bounding the running computations
+	results *lrupkg.Cache[shareKey, shareValues]
+}
+
+// newShareVerifier creates a share verifier running at most the given number
+// of computations at once, defaulting to the number of CPUs.
+func newShareVerifier(ethash *Ethash, workers int) *shareVerifier {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	return &shareVerifier{
+		ethash:  ethash,
+		slots:   make(chan struct{}, workers),
+		results: lrupkg.NewCache[shareKey, shareValues](shareResultsRetained),
+	}
+}
+
+// verify computes the PoW values of the nonce for the given pow-hash of a block
+// at the given height, and checks the result against the target.
+func (v *shareVerifier) verify(hash common.Hash, nonce types.BlockNonce, number uint64, target *big.Int) *ShareVerification {
+	values := v.values(hash, nonce, number)
+	return &ShareVerification{
+		MixDigest: values.digest,
+		Result:    values.result,
+		Valid:     values.result.Big().Cmp(target) <= 0,
+	}
+}
+
+// values returns the memoized PoW values of a nonce for the pow-hash of a block
+// at the given height, computing them if unknown.
+func (v *shareVerifier) values(hash common.Hash, nonce types.BlockNonce, number uint64) shareValues {
+	key := shareKey{hash: hash, nonce: nonce, number: number}
+	if values, ok := v.results.Get(key); ok {
+		return values
+	}
+	v.slots <- struct{}{}
+	digest, result := v.ethash.hashimotoLightHash(number, hash, nonce.Uint64())
+	<-v.slots
+
+	values := shareValues{digest: common.BytesToHash(digest), result: common.BytesToHash(result)}
+	v.results.Add(key, values)
+	return values
+}
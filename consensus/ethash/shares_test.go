@@ -0,0 +1,51 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package ethash
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Tests that shares are verified against the share target using the light
+// verification path, memoizing the results.
+func TestVerifyShare(t *testing.T) {
+	ethash := NewTester(nil, true)
+	defer ethash.Close()
+	api := &API{ethash: ethash}
+
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100), Nonce: types.EncodeNonce(7)}
+	digest, result := ethash.hashimotoLight(header)
+
+	hash := ethash.SealHash(header)
+	share, err := api.VerifyShare(hash, header.Nonce, 1, (*hexutil.Big)(big.NewInt(1)))
+	if err != nil {
+		t.Fatalf("failed to verify share: %v", err)
+	}
+	if share.MixDigest != common.BytesToHash(digest) || share.Result != common.BytesToHash(result) || !share.Valid {
+		t.Errorf("share verification mismatch: have %+v, want digest %x result %x valid", share, digest, result)
+	}
+	if share, err = api.VerifyShare(hash, header.Nonce, 1, (*hexutil.Big)(new(big.Int).Set(two256))); err != nil || share.Valid {
+		t.Errorf("share above the target accepted: %+v, %v", share, err)
+	}
+	if n := ethash.shares.results.Len(); n != 1 {
+		t.Errorf("memoized results mismatch: have %d, want 1", n)
+	}
+	if _, err := api.VerifyShare(hash, header.Nonce, 1, nil); err != errInvalidDifficulty {
+		t.Errorf("missing difficulty error mismatch: have %v, want %v", err, errInvalidDifficulty)
+	}
+}
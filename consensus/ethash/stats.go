@@ -0,0 +1,548 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package ethash
+
+import (
+	"bytes"
+	"math"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+const (
+	nonceBucketBits          = 4         // Number of low nonce bits bucketed in the nonce distribution
+	nonceDistributionTimeout = time.Hour // Time after which the nonces of a quiet miner are forgotten
+	flaggedIDTimeout         = time.Hour // Time after which a miner id stops being flagged as duplicate
+	workerTimeout            = time.Hour // Time after which the statistics of a quiet worker are forgotten
+)
+
+// defaultVerifyFailuresRetained is the number of seal verification failures
+// retained if not configured otherwise.
+const defaultVerifyFailuresRetained = 32
+
+// SubmitStats counts submitted solutions by their classification.
+type SubmitStats struct {
+	Accepted  hexutil.Uint64 `json:"accepted"`
+	Stale     hexutil.Uint64 `json:"stale"`
+	Invalid   hexutil.Uint64 `json:"invalid"`
+	Duplicate hexutil.Uint64 `json:"duplicate"`
+	Unknown   hexutil.Uint64 `json:"unknown"`
+	Dropped   hexutil.Uint64 `json:"dropped"`
+	Shares    hexutil.Uint64 `json:"shares"` // Solutions meeting a share target only
+
+	StaleAccepted hexutil.Uint64 `json:"staleAccepted"` // Solutions for replaced work sealed as potential uncles
+}
+
+// BlockFound describes a block sealed by this node.
+type BlockFound struct {
+	Number hexutil.Uint64 `json:"number"`
+	Hash   common.Hash    `json:"hash"`
+	Time   hexutil.Uint64 `json:"time"` // Unix timestamp of the seal
+}
+
+// DAGStatus describes the mining dataset of the current work.
+type DAGStatus struct {
+	Epoch       hexutil.Uint64 `json:"epoch"`
+	Generated   bool           `json:"generated"`
+	Generations hexutil.Uint64 `json:"generations"` // Times the epoch's dataset was generated from scratch
+}
+
+// DAGGenerations counts the mining datasets generated from scratch instead of
+// loaded from disk, across all epochs. A rate above 1 per epoch signals
+// datasets being evicted or failing to persist.
+type DAGGenerations struct {
+	Total    hexutil.Uint64 `json:"total"`
+	PerEpoch float64        `json:"perEpoch"` // Average generations per generated epoch
+}
+
+// FlaggedID describes a miner id submitted from multiple addresses, usually
+// miners misconfigured to share an id.
+type FlaggedID struct {
+	ID      common.Hash    `json:"id"`
+	Sources []string       `json:"sources"` // Addresses the id was submitted from
+	Time    hexutil.Uint64 `json:"time"`    // Unix timestamp of the last collision
+}
+
+// StatsSnapshot is a consistent view of the mining statistics, captured at a
+// single point in time by the remote sealer.
+type StatsSnapshot struct {
+	Hashrate   hexutil.Uint64                 `json:"hashrate"`   // Local plus remote hash rate
+	Miners     map[common.Hash]hexutil.Uint64 `json:"miners"`     // Hash rate per remote miner id
+	Submits    SubmitStats                    `json:"submits"`    // Submitted solutions by classification
+	LastBlock  *BlockFound                    `json:"lastBlock"`  // Last block sealed, nil if none yet
+	Difficulty *hexutil.Big                   `json:"difficulty"` // Difficulty of the current work
+	DAG        *DAGStatus                     `json:"dag"`        // Dataset of the current work
+	FlaggedIDs []FlaggedID                    `json:"flaggedIds"` // Miner ids submitted from multiple addresses
+	DAGGens    DAGGenerations                 `json:"dagGenerations"`
+}
+
+// submitStats converts the per classification submit counters.
+func (s *remoteSealer) submitStats() SubmitStats {
+	return SubmitStats{
+		Accepted:  hexutil.Uint64(s.submits[submitAccepted]),
+		Stale:     hexutil.Uint64(s.submits[submitStale]),
+		Invalid:   hexutil.Uint64(s.submits[submitInvalid]),
+		Duplicate: hexutil.Uint64(s.submits[submitDuplicate]),
+		Unknown:   hexutil.Uint64(s.submits[submitUnknown]),
+		Dropped:   hexutil.Uint64(s.submits[submitDropped]),
+		Shares:    hexutil.Uint64(s.submits[submitShare]),
+
+		StaleAccepted: hexutil.Uint64(s.submits[submitLate]),
+	}
+}
+
+// activeRates returns the hash rates of the remote miners seen within the idle
+// timeout at the given time. Expired rates are only pruned periodically, so all
+// reporting paths must use this to agree on the set of active miners.
+func (s *remoteSealer) activeRates(now time.Time) map[common.Hash]uint64 {
+	rates := make(map[common.Hash]uint64, len(s.rates))
+	for id, rate := range s.rates {
+		if now.Sub(rate.ping) <= minerIdleTimeout {
+			rates[id] = rate.rate
+		}
+	}
+	return rates
+}
+
+// statsSnapshot assembles the mining statistics. It must be called from the
+// remote sealer loop to ensure all values are consistent with each other.
+func (s *remoteSealer) statsSnapshot() StatsSnapshot {
+	snapshot := StatsSnapshot{
+		Miners:    make(map[common.Hash]hexutil.Uint64, len(s.rates)),
+		Submits:   s.submitStats(),
+		LastBlock: s.lastFound,
+	}
+	total := uint64(s.ethash.hashrate.Rate1())
+	for id, rate := range s.activeRates(time.Now()) {
+		snapshot.Miners[id] = hexutil.Uint64(rate)
+		total += rate
+	}
+	snapshot.Hashrate = hexutil.Uint64(total)
+
+	if local := s.ethash.localStats.lastFound.Load(); local != nil {
+		if snapshot.LastBlock == nil || local.Time > snapshot.LastBlock.Time {
+			snapshot.LastBlock = local
+		}
+	}
+	for _, flagged := range s.flaggedIDs {
+		snapshot.FlaggedIDs = append(snapshot.FlaggedIDs, FlaggedID{ID: flagged.ID, Sources: append([]string(nil), flagged.Sources...), Time: flagged.Time})
+	}
+	if s.currentBlock != nil {
+		snapshot.Difficulty = (*hexutil.Big)(s.currentBlock.Difficulty())
+
+		epoch := s.currentBlock.NumberU64() / epochLength
+		snapshot.DAG = &DAGStatus{Epoch: hexutil.Uint64(epoch)}
+		if dataset, ok := s.ethash.datasets.peek(epoch); ok {
+			snapshot.DAG.Generated = dataset.generated()
+		}
+		snapshot.DAG.Generations = hexutil.Uint64(datasetGens.generations(epoch))
+	}
+	total, perEpoch := datasetGens.summary()
+	snapshot.DAGGens = DAGGenerations{Total: hexutil.Uint64(total), PerEpoch: perEpoch}
+	return snapshot
+}
+
+// logStatus logs a summary of the mining status, counting the solutions
+// submitted since the given counters were captured. It must be called from the
+// remote sealer loop.
+func (s *remoteSealer) logStatus(since [numSubmitStatus]uint64) {
+	snapshot := s.statsSnapshot()
+
+	var accepted, rejected uint64
+	for status, count := range s.submits {
+		if submitStatus(status) == submitAccepted || submitStatus(status) == submitShare || submitStatus(status) == submitLate {
+			accepted += count - since[status]
+		} else {
+			rejected += count - since[status]
+		}
+	}
+	ctx := []interface{}{"hashrate", uint64(snapshot.Hashrate), "miners", len(snapshot.Miners), "accepted", accepted, "rejected", rejected}
+	if snapshot.Difficulty != nil {
+		ctx = append(ctx, "difficulty", snapshot.Difficulty.ToInt())
+	}
+	if snapshot.DAG != nil {
+		ctx = append(ctx, "epoch", uint64(snapshot.DAG.Epoch), "dag", snapshot.DAG.Generated)
+	}
+	s.ethash.config.Log.Info("Mining status", ctx...)
+}
+
+// NonceHistogram is the distribution of the low bits of the nonces submitted
+// by a miner, bucket i counting the nonces whose lowest bits equal i.
+type NonceHistogram struct {
+	Buckets [1 << nonceBucketBits]hexutil.Uint64 `json:"buckets"`
+	Total   hexutil.Uint64                       `json:"total"`
+}
+
+// nonceBuckets tracks the nonce distribution of a single miner.
+type nonceBuckets struct {
+	counts [1 << nonceBucketBits]uint64
+	seen   time.Time // Time of the last submission
+}
+
+// histogram converts the tracked nonce counts.
+func (b *nonceBuckets) histogram() NonceHistogram {
+	var hist NonceHistogram
+	for i, count := range b.counts {
+		hist.Buckets[i] = hexutil.Uint64(count)
+		hist.Total += hexutil.Uint64(count)
+	}
+	return hist
+}
+
+// flagDuplicateID records that the given miner id was submitted from multiple
+// addresses. It must be called from the remote sealer loop.
+func (s *remoteSealer) flagDuplicateID(id common.Hash, sources ...string) {
+	flagged := s.flaggedIDs[id]
+	if flagged == nil {
+		flagged = &FlaggedID{ID: id}
+		s.flaggedIDs[id] = flagged
+	}
+	for _, source := range sources {
+		known := false
+		for _, seen := range flagged.Sources {
+			known = known || seen == source
+		}
+		if !known {
+			flagged.Sources = append(flagged.Sources, source)
+		}
+	}
+	flagged.Time = hexutil.Uint64(time.Now().Unix())
+	s.ethash.config.Log.Warn("Miner id submitted from multiple addresses", "id", id, "sources", flagged.Sources)
+}
+
+// trackNonce adds a nonce submitted by the given miner to its distribution. It
+// must be called from the remote sealer loop.
+func (s *remoteSealer) trackNonce(id common.Hash, nonce types.BlockNonce) {
+	buckets := s.nonces[id]
+	if buckets == nil {
+		buckets = new(nonceBuckets)
+		s.nonces[id] = buckets
+	}
+	buckets.counts[nonce.Uint64()&(1<<nonceBucketBits-1)]++
+	buckets.seen = time.Now()
+}
+
+// nonceRange is an inclusive range of nonces.
+type nonceRange struct {
+	start, end uint64
+}
+
+// size returns the number of nonces in the range, saturating for the full range.
+func (r nonceRange) size() uint64 {
+	if r.end-r.start == math.MaxUint64 {
+		return math.MaxUint64
+	}
+	return r.end - r.start + 1
+}
+
+// SearchedRanges aggregates the nonce ranges a miner claimed to have searched
+// for its accepted shares. Over the reported period, the searched nonces are
+// expected to match the reported hash rate.
+type SearchedRanges struct {
+	Shares hexutil.Uint64 `json:"shares"` // Accepted shares with a claimed range
+	Nonces hexutil.Uint64 `json:"nonces"` // Total claimed nonces, saturating
+	First  hexutil.Uint64 `json:"first"`  // Unix timestamp of the first claim
+	Last   hexutil.Uint64 `json:"last"`   // Unix timestamp of the last claim
+}
+
+// searchedRanges tracks the nonce ranges searched by a single miner.
+type searchedRanges struct {
+	shares      uint64
+	nonces      uint64
+	first, last time.Time
+}
+
+// summary converts the tracked searched ranges.
+func (r *searchedRanges) summary() SearchedRanges {
+	return SearchedRanges{
+		Shares: hexutil.Uint64(r.shares),
+		Nonces: hexutil.Uint64(r.nonces),
+		First:  hexutil.Uint64(r.first.Unix()),
+		Last:   hexutil.Uint64(r.last.Unix()),
+	}
+}
+
+// trackRange records the nonce range claimed to be searched for an accepted
+// share of the given miner.
+func (s *remoteSealer) trackRange(id common.Hash, searched nonceRange) {
+	ranges := s.searched[id]
+	if ranges == nil {
+		ranges = &searchedRanges{first: time.Now()}
+		s.searched[id] = ranges
+	}
+	ranges.shares++
+	if size := searched.size(); ranges.nonces > math.MaxUint64-size {
+		ranges.nonces = math.MaxUint64
+	} else {
+		ranges.nonces += size
+	}
+	ranges.last = time.Now()
+}
+
+// MinerStats is the share ledger of a single remote miner.
+type MinerStats struct {
+	Accepted   hexutil.Uint64 `json:"accepted"`
+	Stale      hexutil.Uint64 `json:"stale"`
+	Invalid    hexutil.Uint64 `json:"invalid"`    // Invalid, duplicate and unknown work shares
+	LastSubmit hexutil.Uint64 `json:"lastSubmit"` // Unix timestamp, zero if never seen
+	Hashrate   hexutil.Uint64 `json:"hashrate"`   // Estimated from the accepted share difficulty
+
+	StaleAccepted hexutil.Uint64 `json:"staleAccepted"` // Shares for replaced work sealed as potential uncles
+}
+
+// shareLedger tracks the shares submitted by a single miner.
+type shareLedger struct {
+	accepted, stale, invalid, staleAccepted uint64
+
+	work        *big.Int // Summed difficulty of the accepted shares
+	first, last time.Time
+}
+
+// stats converts the tracked shares, estimating the hash rate as the accepted
+// difficulty per second since the first share.
+func (l *shareLedger) stats(now time.Time) MinerStats {
+	stats := MinerStats{
+		Accepted:   hexutil.Uint64(l.accepted),
+		Stale:      hexutil.Uint64(l.stale),
+		Invalid:    hexutil.Uint64(l.invalid),
+		LastSubmit: hexutil.Uint64(l.last.Unix()),
+
+		StaleAccepted: hexutil.Uint64(l.staleAccepted),
+	}
+	elapsed := int64(now.Sub(l.first) / time.Second)
+	if elapsed < 1 {
+		elapsed = 1
+	}
+	if rate := new(big.Int).Div(l.work, big.NewInt(elapsed)); rate.IsUint64() {
+		stats.Hashrate = hexutil.Uint64(rate.Uint64())
+	} else {
+		stats.Hashrate = math.MaxUint64
+	}
+	return stats
+}
+
+// trackShare records a share of the given miner for work of the given
+// difficulty, nil if the work is unknown.
+func (s *remoteSealer) trackShare(id common.Hash, status submitStatus, difficulty *big.Int) {
+	ledger := s.shares[id]
+	if ledger == nil {
+		ledger = &shareLedger{work: new(big.Int), first: time.Now()}
+		s.shares[id] = ledger
+	}
+	ledger.track(status, difficulty)
+}
+
+// track records a share for work of the given difficulty, nil if the work is
+// unknown.
+func (l *shareLedger) track(status submitStatus, difficulty *big.Int) {
+	switch status {
+	case submitAccepted, submitShare, submitDropped:
+		// Dropped shares are valid, they just didn't make it into a block
+		l.accepted++
+		l.work.Add(l.work, difficulty)
+	case submitLate:
+		l.staleAccepted++
+		l.work.Add(l.work, difficulty)
+	case submitStale:
+		l.stale++
+	default:
+		l.invalid++
+	}
+	l.last = time.Now()
+}
+
+// maxWorkerNameLength is the maximum length of the worker names accepted along
+// with submissions.
+const maxWorkerNameLength = 64
+
+// WorkerStats is the submission ledger of a single remote worker, identified by
+// the miner id and the worker name it submits with.
+type WorkerStats struct {
+	ID   common.Hash `json:"id"`   // Zero if submitting by name only
+	Name string      `json:"name"` // Empty if submitting by id only
+	MinerStats
+
+	ReportedHashrate hexutil.Uint64 `json:"reportedHashrate"` // Last hash rate submitted by the worker
+	LastSeen         hexutil.Uint64 `json:"lastSeen"`         // Unix timestamp of the last submission of any kind
+}
+
+// workerKey identifies a remote worker.
+type workerKey struct {
+	id   common.Hash
+	name string
+}
+
+// newWorkerKey creates the key of the worker submitting with the given optional
+// id and name.
+func newWorkerKey(id *common.Hash, name string) workerKey {
+	key := workerKey{name: name}
+	if id != nil {
+		key.id = *id
+	}
+	return key
+}
+
+// workerLedger tracks the submissions of a single remote worker.
+type workerLedger struct {
+	shares   *shareLedger // Submitted shares, nil until the first share
+	reported uint64       // Last submitted hash rate
+	seen     time.Time    // Last submission of any kind
+}
+
+// worker returns the ledger of a worker, creating it if unknown.
+func (s *remoteSealer) worker(key workerKey) *workerLedger {
+	ledger := s.workers[key]
+	if ledger == nil {
+		ledger = new(workerLedger)
+		s.workers[key] = ledger
+	}
+	ledger.seen = time.Now()
+	return ledger
+}
+
+// trackWorker records a share submitted by a worker, like trackShare does for
+// the miner it belongs to.
+func (s *remoteSealer) trackWorker(key workerKey, status submitStatus, difficulty *big.Int) {
+	ledger := s.worker(key)
+	if ledger.shares == nil {
+		ledger.shares = &shareLedger{work: new(big.Int), first: time.Now()}
+	}
+	ledger.shares.track(status, difficulty)
+}
+
+// reportWorkerRate records the hash rate submitted by a worker.
+func (s *remoteSealer) reportWorkerRate(key workerKey, rate uint64) {
+	s.worker(key).reported = rate
+}
+
+// workerStats converts the ledgers of all tracked workers, ordered by name and
+// then id.
+func (s *remoteSealer) workerStats(now time.Time) []WorkerStats {
+	stats := make([]WorkerStats, 0, len(s.workers))
+	for key, ledger := range s.workers {
+		worker := WorkerStats{
+			ID:               key.id,
+			Name:             key.name,
+			ReportedHashrate: hexutil.Uint64(ledger.reported),
+			LastSeen:         hexutil.Uint64(ledger.seen.Unix()),
+		}
+		if ledger.shares != nil {
+			worker.MinerStats = ledger.shares.stats(now)
+		}
+		stats = append(stats, worker)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Name != stats[j].Name {
+			return stats[i].Name < stats[j].Name
+		}
+		return bytes.Compare(stats[i].ID[:], stats[j].ID[:]) < 0
+	})
+	return stats
+}
+
+// ShareEvent is posted for every valid solution submitted to the remote sealer,
+// whether it sealed a block or only met the share target.
+type ShareEvent struct {
+	Miner      common.Hash    // Id of the submitting miner, zero if anonymous
+	SealHash   common.Hash    // Pow-hash of the solved work
+	Number     uint64         // Number of the solved block
+	Coinbase   common.Address // Coinbase of the solved block
+	Difficulty *big.Int       // Difficulty proven by the solution
+	Block      bool           // Whether the solution sealed the block
+}
+
+// postShare announces a valid solution to the share event subscribers.
+func (s *remoteSealer) postShare(result *mineResult, block *types.Block, status submitStatus) {
+	if block == nil {
+		return
+	}
+	ev := ShareEvent{
+		SealHash:   result.hash,
+		Number:     block.NumberU64(),
+		Coinbase:   block.Coinbase(),
+		Difficulty: block.Difficulty(),
+		Block:      status != submitShare,
+	}
+	if result.miner != nil {
+		ev.Miner = *result.miner
+	}
+	if status == submitShare {
+		ev.Difficulty = result.shareDifficulty
+	}
+	s.ethash.shareFeed.Send(ev)
+}
+
+// SubscribeShareEvent subscribes to the valid solutions submitted to the remote
+// sealer. The channel should be buffered as it's fed from the sealer loop.
+func (ethash *Ethash) SubscribeShareEvent(ch chan<- ShareEvent) event.Subscription {
+	return ethash.shareFeed.Subscribe(ch)
+}
+
+// VerifyFailure describes a header failing seal verification.
+type VerifyFailure struct {
+	Hash   common.Hash    `json:"hash"`
+	Number hexutil.Uint64 `json:"number"`
+	Reason string         `json:"reason"`
+	Time   hexutil.Uint64 `json:"time"` // Unix timestamp of the failure
+}
+
+// verifyFailureRing is a bounded ring buffer of recent verification failures.
+type verifyFailureRing struct {
+	failures []VerifyFailure
+	next     int // Index of the slot to overwrite once the ring is full
+	lock     sync.Mutex
+}
+
+// add records a failure, evicting the oldest one if limit is reached.
+func (r *verifyFailureRing) add(limit int, failure VerifyFailure) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if len(r.failures) < limit {
+		r.failures = append(r.failures, failure)
+		return
+	}
+	r.failures[r.next] = failure
+	r.next = (r.next + 1) % len(r.failures)
+}
+
+// list returns the retained failures, oldest first.
+func (r *verifyFailureRing) list() []VerifyFailure {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	failures := make([]VerifyFailure, 0, len(r.failures))
+	failures = append(failures, r.failures[r.next:]...)
+	return append(failures, r.failures[:r.next]...)
+}
+
+// recordVerifyFailure retains the failure of the given header's seal check.
+func (ethash *Ethash) recordVerifyFailure(header *types.Header, err error) {
+	limit := ethash.config.VerifyFailuresRetained
+	if limit <= 0 {
+		limit = defaultVerifyFailuresRetained
+	}
+	ethash.verifyFailures.add(limit, VerifyFailure{
+		Hash:   header.Hash(),
+		Number: hexutil.Uint64(header.Number.Uint64()),
+		Reason: err.Error(),
+		Time:   hexutil.Uint64(time.Now().Unix()),
+	})
+}
@@ -0,0 +1,415 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package ethash
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// Tests that the stats snapshot reflects hash rates and submissions.
+func TestStatsSnapshot(t *testing.T) {
+	ethash := NewTester(nil, true)
+	defer ethash.Close()
+	api := &API{ethash: ethash}
+
+	api.SubmitHashrate(context.Background(), 100, common.HexToHash("a"), nil)
+	api.SubmitHashrate(context.Background(), 200, common.HexToHash("b"), nil)
+
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100000000)}
+	ethash.Seal(nil, types.NewBlockWithHeader(header), make(chan types.SealResult, 1), nil)
+
+	api.SubmitWork(context.Background(), types.BlockNonce{0x01}, ethash.SealHash(header), common.Hash{}, nil, nil, nil)
+	api.SubmitWork(context.Background(), types.BlockNonce{0x01}, common.HexToHash("c"), common.Hash{}, nil, nil, nil)
+
+	snapshot := api.GetStatsSnapshot()
+	if len(snapshot.Miners) != 2 || snapshot.Miners[common.HexToHash("b")] != 200 {
+		t.Errorf("miner breakdown mismatch: have %v", snapshot.Miners)
+	}
+	if snapshot.Hashrate < 300 {
+		t.Errorf("aggregate hashrate too low: have %d, want >= 300", snapshot.Hashrate)
+	}
+	if snapshot.Submits.Accepted != 1 || snapshot.Submits.Unknown != 1 {
+		t.Errorf("submit counts mismatch: have %+v", snapshot.Submits)
+	}
+	if count := api.GetBlocksFoundCount(); count != 1 {
+		t.Errorf("blocks found mismatch: have %d, want 1", count)
+	}
+	if snapshot.LastBlock == nil || uint64(snapshot.LastBlock.Number) != 1 {
+		t.Errorf("last block mismatch: have %+v", snapshot.LastBlock)
+	}
+	if snapshot.Difficulty == nil || snapshot.Difficulty.ToInt().Cmp(header.Difficulty) != 0 {
+		t.Errorf("difficulty mismatch: have %v, want %v", snapshot.Difficulty, header.Difficulty)
+	}
+	if snapshot.DAG == nil || snapshot.DAG.Epoch != 0 {
+		t.Errorf("dag status mismatch: have %+v", snapshot.DAG)
+	}
+}
+
+// Tests that the periodic status summary counts the submissions of the last
+// interval only.
+func TestStatusLog(t *testing.T) {
+	records := make(chan *log.Record, 16)
+	logger := log.New()
+	logger.SetHandler(log.FuncHandler(func(r *log.Record) error {
+		if r.Msg == "Mining status" {
+			select {
+			case records <- r:
+			default:
+			}
+		}
+		return nil
+	}))
+	ethash := New(Config{PowMode: ModeTest, StatusLogInterval: 100 * time.Millisecond, Log: logger}, nil, true)
+	defer ethash.Close()
+	api := &API{ethash: ethash}
+
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100000000)}
+	ethash.SetThreads(-1)
+	ethash.Seal(nil, types.NewBlockWithHeader(header), make(chan types.SealResult, 1), nil)
+	api.SubmitWork(context.Background(), types.BlockNonce{0x01}, common.HexToHash("c"), common.Hash{}, nil, nil, nil)
+
+	// Wait for a summary including the rejected submission, followed by one
+	// with an empty interval
+	deadline := time.After(3 * time.Second)
+	for seen := false; ; {
+		select {
+		case r := <-records:
+			rejected := recordValue(r, "rejected")
+			if !seen {
+				seen = rejected == uint64(1)
+				continue
+			}
+			if rejected != uint64(0) {
+				t.Fatalf("rejected count not reset: have %v", rejected)
+			}
+			if recordValue(r, "difficulty") == nil {
+				t.Fatal("difficulty missing from status")
+			}
+			return
+		case <-deadline:
+			t.Fatal("status summary timeout")
+		}
+	}
+}
+
+// recordValue returns the value logged for the given key, nil if missing.
+func recordValue(r *log.Record, key string) interface{} {
+	for i := 0; i+1 < len(r.Ctx); i += 2 {
+		if r.Ctx[i] == key {
+			return r.Ctx[i+1]
+		}
+	}
+	return nil
+}
+
+// Tests that only the most recent verification failures are retained, oldest
+// first.
+func TestRecentVerifyFailures(t *testing.T) {
+	ethash := NewFaker()
+	ethash.config.VerifyFailuresRetained = 3
+	api := &API{ethash: ethash}
+
+	if failures := api.GetRecentVerifyFailures(); len(failures) != 0 {
+		t.Fatalf("failures reported before any: %v", failures)
+	}
+	for i := 1; i <= 5; i++ {
+		ethash.recordVerifyFailure(&types.Header{Number: big.NewInt(int64(i))}, errInvalidPoW)
+	}
+	failures := api.GetRecentVerifyFailures()
+	if len(failures) != 3 {
+		t.Fatalf("retained failure count mismatch: have %d, want 3", len(failures))
+	}
+	for i, failure := range failures {
+		if want := uint64(i + 3); uint64(failure.Number) != want {
+			t.Errorf("failure %d: number mismatch: have %d, want %d", i, failure.Number, want)
+		}
+		if failure.Reason != errInvalidPoW.Error() {
+			t.Errorf("failure %d: reason mismatch: have %q, want %q", i, failure.Reason, errInvalidPoW)
+		}
+	}
+}
+
+// Tests that the low bits of the nonces submitted by a miner are bucketed.
+func TestNonceDistribution(t *testing.T) {
+	ethash := NewTester(nil, true)
+	defer ethash.Close()
+	api := &API{ethash: ethash}
+
+	var (
+		honest  = common.HexToHash("a")
+		replay  = common.HexToHash("b")
+		unknown = common.HexToHash("c")
+	)
+	for i := uint64(0); i < 32; i++ {
+		api.SubmitWork(context.Background(), types.EncodeNonce(i), common.Hash{}, common.Hash{}, nil, &honest, nil)
+		api.SubmitWork(context.Background(), types.EncodeNonce(0x10), common.Hash{}, common.Hash{}, nil, &replay, nil)
+	}
+	hist, err := api.GetNonceDistribution(honest)
+	if err != nil {
+		t.Fatalf("failed to get distribution: %v", err)
+	}
+	for i, count := range hist.Buckets {
+		if count != 2 {
+			t.Errorf("honest bucket %d mismatch: have %d, want 2", i, count)
+		}
+	}
+	if hist, _ := api.GetNonceDistribution(replay); hist.Buckets[0] != 32 || hist.Total != 32 {
+		t.Errorf("replayed distribution mismatch: have %+v", hist)
+	}
+	if hist, _ := api.GetNonceDistribution(unknown); hist.Total != 0 {
+		t.Errorf("unknown miner distribution reported: %+v", hist)
+	}
+}
+
+// Tests that shares are accounted per miner.
+func TestMinerStats(t *testing.T) {
+	ethash := NewTester(nil, true)
+	defer ethash.Close()
+	ethash.SetThreads(-1)
+	api := &API{ethash: ethash}
+
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100000000)}
+	ethash.Seal(nil, types.NewBlockWithHeader(header), make(chan types.SealResult, 4), nil)
+	sealhash := ethash.SealHash(header)
+
+	var (
+		miner = common.HexToHash("a")
+		other = common.HexToHash("b")
+	)
+	api.SubmitWork(context.Background(), types.EncodeNonce(1), sealhash, common.Hash{}, nil, &miner, nil)
+	api.SubmitWork(context.Background(), types.EncodeNonce(2), sealhash, common.Hash{}, nil, &miner, nil)
+	api.SubmitWork(context.Background(), types.EncodeNonce(2), sealhash, common.Hash{}, nil, &miner, nil)       // Duplicate
+	api.SubmitWork(context.Background(), types.EncodeNonce(3), common.Hash{1}, common.Hash{}, nil, &miner, nil) // Unknown work
+	api.SubmitWork(context.Background(), types.EncodeNonce(4), sealhash, common.Hash{}, nil, nil, nil)          // Anonymous
+
+	stats, err := api.GetMinerStats(miner)
+	if err != nil {
+		t.Fatalf("failed to get miner stats: %v", err)
+	}
+	if stats.Accepted != 2 || stats.Stale != 0 || stats.Invalid != 2 {
+		t.Errorf("share counts mismatch: have %+v, want 2 accepted, 2 invalid", stats)
+	}
+	if now := time.Now().Unix(); int64(stats.LastSubmit) < now-5 || int64(stats.LastSubmit) > now {
+		t.Errorf("last submit mismatch: have %d, want around %d", stats.LastSubmit, now)
+	}
+	if stats.Hashrate != 200000000 {
+		t.Errorf("estimated hashrate mismatch: have %d, want %d", stats.Hashrate, 200000000)
+	}
+	if stats, _ := api.GetMinerStats(other); stats != (MinerStats{}) {
+		t.Errorf("unknown miner reported: %+v", stats)
+	}
+}
+
+// Tests that submissions are accounted per named worker.
+func TestWorkerStats(t *testing.T) {
+	ethash := NewTester(nil, true)
+	defer ethash.Close()
+	ethash.SetThreads(-1)
+	api := &API{ethash: ethash}
+
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100000000)}
+	ethash.Seal(nil, types.NewBlockWithHeader(header), make(chan types.SealResult, 4), nil)
+	sealhash := ethash.SealHash(header)
+
+	var (
+		miner   = common.HexToHash("a")
+		rig1    = "rig1"
+		rig2    = "rig2"
+		tooLong = strings.Repeat("x", maxWorkerNameLength+1)
+	)
+	api.SubmitWork(context.Background(), types.EncodeNonce(1), sealhash, common.Hash{}, nil, &miner, &rig1)
+	api.SubmitWork(context.Background(), types.EncodeNonce(2), sealhash, common.Hash{}, nil, &miner, &rig1)
+	api.SubmitWork(context.Background(), types.EncodeNonce(3), common.Hash{1}, common.Hash{}, nil, &miner, &rig2) // Unknown work
+	api.SubmitWork(context.Background(), types.EncodeNonce(4), sealhash, common.Hash{}, nil, nil, &rig2)          // Name only
+	api.SubmitWork(context.Background(), types.EncodeNonce(5), sealhash, common.Hash{}, nil, nil, nil)            // Anonymous
+	if api.SubmitWork(context.Background(), types.EncodeNonce(6), sealhash, common.Hash{}, nil, &miner, &tooLong) {
+		t.Errorf("overlong worker name accepted")
+	}
+	api.SubmitHashrate(context.Background(), 500, miner, &rig1)
+	api.SubmitHashrate(context.Background(), 700, miner, nil)
+
+	workers, err := api.GetWorkers()
+	if err != nil {
+		t.Fatalf("failed to list workers: %v", err)
+	}
+	type want struct {
+		id                          common.Hash
+		name                        string
+		accepted, invalid, reported uint64
+	}
+	wants := []want{
+		{miner, "", 0, 0, 700},
+		{miner, rig1, 2, 0, 500},
+		{common.Hash{}, rig2, 1, 0, 0},
+		{miner, rig2, 0, 1, 0},
+	}
+	if len(workers) != len(wants) {
+		t.Fatalf("worker count mismatch: have %d, want %d: %+v", len(workers), len(wants), workers)
+	}
+	for i, w := range wants {
+		have := workers[i]
+		if have.ID != w.id || have.Name != w.name || uint64(have.Accepted) != w.accepted || uint64(have.Invalid) != w.invalid || uint64(have.ReportedHashrate) != w.reported {
+			t.Errorf("worker %d mismatch: have %+v, want %+v", i, have, w)
+		}
+		if now := time.Now().Unix(); int64(have.LastSeen) < now-5 || int64(have.LastSeen) > now {
+			t.Errorf("worker %d: last seen mismatch: have %d, want around %d", i, have.LastSeen, now)
+		}
+	}
+	// Per worker submissions still add up in the miner's ledger
+	if stats, _ := api.GetMinerStats(miner); stats.Accepted != 2 || stats.Invalid != 1 {
+		t.Errorf("miner stats mismatch: have %+v", stats)
+	}
+}
+
+// Tests that the nonce ranges claimed for accepted shares are aggregated per miner.
+func TestSearchedRanges(t *testing.T) {
+	ethash := NewTester(nil, true)
+	defer ethash.Close()
+	ethash.SetThreads(-1)
+	api := &API{ethash: ethash}
+
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100000000)}
+	ethash.Seal(nil, types.NewBlockWithHeader(header), make(chan types.SealResult, 4), nil)
+	sealhash := ethash.SealHash(header)
+
+	var (
+		miner   = common.HexToHash("a")
+		unknown = common.HexToHash("b")
+	)
+	for i, r := range [][2]hexutil.Uint64{{0, 99}, {100, 149}, {150, 150}} {
+		res, err := api.SubmitWorkWithRange(context.Background(), types.EncodeNonce(uint64(r[1])), sealhash, common.Hash{}, r[0], r[1], nil, &miner)
+		if err != nil || !res.Accepted || !res.Recorded {
+			t.Fatalf("share %d: result mismatch: have %+v, %v", i, res, err)
+		}
+	}
+	// Rejected shares and invalid ranges must not be credited
+	if res, err := api.SubmitWorkWithRange(context.Background(), types.EncodeNonce(99), common.Hash{1}, common.Hash{}, 0, 99, nil, &miner); err != nil || res.Accepted {
+		t.Errorf("unknown work result mismatch: have %+v, %v", res, err)
+	}
+	if _, err := api.SubmitWorkWithRange(context.Background(), types.EncodeNonce(200), sealhash, common.Hash{}, 0, 99, nil, &miner); err == nil {
+		t.Errorf("nonce outside of range accepted")
+	}
+	if _, err := api.SubmitWorkWithRange(context.Background(), types.EncodeNonce(5), sealhash, common.Hash{}, 10, 0, nil, &miner); err == nil {
+		t.Errorf("inverted range accepted")
+	}
+	ranges, err := api.GetSearchedRanges()
+	if err != nil {
+		t.Fatalf("failed to get searched ranges: %v", err)
+	}
+	if have := ranges[miner]; have.Shares != 3 || have.Nonces != 151 || have.First == 0 || have.Last < have.First {
+		t.Errorf("searched ranges mismatch: have %+v, want 3 shares of 151 nonces", have)
+	}
+	if _, ok := ranges[unknown]; ok || len(ranges) != 1 {
+		t.Errorf("unexpected miners tracked: %v", ranges)
+	}
+}
+
+// Tests that only datasets generated from scratch are counted as generations.
+func TestDAGGenerations(t *testing.T) {
+	const epoch = 100
+	var (
+		dir      = t.TempDir()
+		before   = datasetGens.generations(epoch)
+		total, _ = datasetGens.summary()
+	)
+	newDataset(epoch).generate(dir, 1, false, true)
+	newDataset(epoch).generate(dir, 1, false, true) // Loaded from disk
+	if have := datasetGens.generations(epoch) - before; have != 1 {
+		t.Fatalf("generations after disk load mismatch: have %d, want 1", have)
+	}
+	newDataset(epoch).generate("", 0, false, true) // Nothing stored, regenerated
+	if have := datasetGens.generations(epoch) - before; have != 2 {
+		t.Fatalf("generations after regeneration mismatch: have %d, want 2", have)
+	}
+	after, perEpoch := datasetGens.summary()
+	if after-total != 2 {
+		t.Errorf("total generations mismatch: have %d, want %d", after-total, 2)
+	}
+	if perEpoch < 1 {
+		t.Errorf("generations per epoch below one: %v", perEpoch)
+	}
+}
+
+// Tests that miner ids reporting from multiple addresses are flagged, and
+// rejected if configured.
+func TestDuplicateMinerIDs(t *testing.T) {
+	for _, reject := range []bool{false, true} {
+		ethash := New(Config{PowMode: ModeTest, RejectDuplicateIDs: reject}, nil, true)
+		api := &API{ethash: ethash}
+
+		submit := func(source string) bool {
+			done := make(chan bool, 1)
+			ethash.remote.submitRateCh <- &hashrate{id: common.HexToHash("a"), rate: 100, source: source, done: done}
+			return <-done
+		}
+		if !submit("10.0.0.1") || !submit("10.0.0.1") {
+			t.Fatalf("reject %v: resubmission from same address rejected", reject)
+		}
+		if accepted := submit("10.0.0.2"); accepted == reject {
+			t.Errorf("reject %v: duplicate id accepted %v", reject, accepted)
+		}
+		snapshot := api.GetStatsSnapshot()
+		if len(snapshot.FlaggedIDs) != 1 || len(snapshot.FlaggedIDs[0].Sources) != 2 {
+			t.Errorf("reject %v: flagged ids mismatch: have %+v", reject, snapshot.FlaggedIDs)
+		}
+		ethash.Close()
+	}
+}
+
+// Tests that the aggregate hash rate always equals the sum of the currently
+// active contributors, also while expired rates await pruning.
+func TestHashrateConsistency(t *testing.T) {
+	ethash := NewTester(nil, true)
+	defer ethash.Close()
+	ethash.SetThreads(-1)
+	api := &API{ethash: ethash}
+
+	for i, rate := range []hexutil.Uint64{100, 200, 300} {
+		api.SubmitHashrate(context.Background(), rate, common.BigToHash(big.NewInt(int64(i))), nil)
+	}
+	api.SubmitHashrate(context.Background(), 400, common.BigToHash(big.NewInt(0)), nil) // Replaces the first rate
+
+	snapshot := api.GetStatsSnapshot()
+	var sum uint64
+	for _, rate := range snapshot.Miners {
+		sum += uint64(rate)
+	}
+	if have := api.GetHashrate(); have != sum || uint64(snapshot.Hashrate) != sum {
+		t.Fatalf("aggregate mismatch: GetHashrate %d, snapshot %d, miner sum %d", have, snapshot.Hashrate, sum)
+	}
+	// Rates past the idle timeout but not yet pruned must not be counted
+	now := time.Now()
+	sealer := &remoteSealer{
+		ethash: ethash,
+		rates: map[common.Hash]hashrate{
+			common.HexToHash("a"): {rate: 100, ping: now},
+			common.HexToHash("b"): {rate: 200, ping: now.Add(-minerIdleTimeout - time.Second)},
+		},
+	}
+	active := sealer.activeRates(now)
+	if len(active) != 1 || active[common.HexToHash("a")] != 100 {
+		t.Errorf("active rates mismatch: have %v", active)
+	}
+	if snapshot := sealer.statsSnapshot(); snapshot.Hashrate != 100 || len(snapshot.Miners) != 1 {
+		t.Errorf("expired rate counted: hashrate %d, miners %v", snapshot.Hashrate, snapshot.Miners)
+	}
+}
@@ -0,0 +1,458 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package ethash
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+const (
+	// stratumMaxLine is the maximum length of a single stratum request line.
+	stratumMaxLine = 4096
+
+	// stratumWriteTimeout is the time allowed for a single message to be
+	// written to a stratum client before it is dropped.
+	stratumWriteTimeout = 10 * time.Second
+
+	// stratumNoncePrefixSize is the number of leading nonce bytes fixed by the
+	// server for each connection, the rest are searched by the miner.
+	stratumNoncePrefixSize = 2
+
+	// stratumMaxPrefixes is the number of distinct nonce prefixes, bounding the
+	// number of concurrent stratum connections.
+	stratumMaxPrefixes = 1 << (8 * stratumNoncePrefixSize)
+
+	// defaultStratumMaxConns is the number of concurrent stratum connections
+	// served if not configured.
+	defaultStratumMaxConns = 1024
+
+	// stratumProtocol is the protocol version announced to subscribing miners.
+	stratumProtocol = "EthereumStratum/1.0.0"
+)
+
+var (
+	errStratumNotSubscribed = errors.New("not subscribed")
+	errStratumBadParams     = errors.New("invalid params")
+	errStratumUnknownMethod = errors.New("unknown method")
+)
+
+// pow32 is 2^32, the hashes per unit of stratum share difficulty.
+var pow32 = new(big.Float).SetInt(new(big.Int).Lsh(big.NewInt(1), 32))
+
+// stratumRequest is a newline delimited JSON request of a stratum client.
+type stratumRequest struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+// stratumResponse is the reply to a stratum request, or a notification pushed
+// by the server if the id is null.
+type stratumResponse struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method,omitempty"`
+	Params interface{}     `json:"params,omitempty"`
+	Result interface{}     `json:"result"`
+	Error  *string         `json:"error"`
+}
+
+// stratumServer serves work of the remote sealer over persistent TCP stratum
+// connections, speaking EthereumStratum/1.0.0 as newline delimited JSON:
+//
+//	-> {"id":1,"method":"mining.subscribe","params":["<agent>","EthereumStratum/1.0.0"]}
+//	<- {"id":1,"result":[["mining.notify","<subscription>","EthereumStratum/1.0.0"],"<extranonce>"],"error":null}
+//	-> {"id":2,"method":"mining.authorize","params":["<worker>","<password>"]}
+//	<- {"id":2,"result":true,"error":null}
+//	<- {"id":null,"method":"mining.set_difficulty","params":[<difficulty>]}
+//	<- {"id":null,"method":"mining.notify","params":["<job>","<seed>","<powhash>",true]}
+//	-> {"id":3,"method":"mining.submit","params":["<worker>","<job>","<nonce>"]}
+//	<- {"id":3,"result":true,"error":null}
+//
+// All hex values are sent without 0x prefix. Each connection is assigned a
+// distinct extranonce, fixing the leading bytes of the nonce, with the miner
+// submitting only the remaining nonce bytes it searched. The job id is the
+// pow-hash of the work, which all connections search over. A new job is pushed
+// whenever the remote sealer regenerates work.
+//
+// The difficulty is in units of 2^32 hashes. It is the block difficulty, or
+// if a ShareDifficulty is configured, the connection's share difficulty,
+// retuned for the miner to submit a share about every ShareTargetTime. It is
+// set again whenever it changes.
+type stratumServer struct {
+	ethash   *Ethash
+	listener net.Listener
+
+	lock       sync.Mutex
+	conns      map[*stratumConn]struct{}
+	prefixes   map[uint16]struct{} // Nonce prefixes assigned to connected clients
+	nextPrefix uint16              // Next nonce prefix to try assigning
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// stratumConn is a single stratum client connection.
+type stratumConn struct {
+	conn   net.Conn
+	prefix []byte           // Leading nonce bytes fixed for the connection
+	jobs   chan WorkPackage // Latest work to push, holding at most one
+	closed chan struct{}    // Closed when the client disconnected
+
+	lock       sync.Mutex   // Serializes writes to the connection
+	subscribed atomic.Bool  // Whether the client subscribed to jobs
+	miner      *common.Hash // Id derived from the authorized worker name
+	worker     string       // Authorized worker name
+	vardiff    *vardiff     // Share difficulty controller, nil if shares are at the block target
+
+	job        string   // Id of the last pushed job, only accessed by the pusher
+	difficulty *big.Int // Last difficulty set, only accessed by the pusher
+}
+
+// startStratum starts serving the remote sealer's work on the given address.
+func startStratum(ethash *Ethash, addr string) (*stratumServer, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &stratumServer{
+		ethash:   ethash,
+		listener: listener,
+		conns:    make(map[*stratumConn]struct{}),
+		prefixes: make(map[uint16]struct{}),
+		quit:     make(chan struct{}),
+	}
+	works := make(chan WorkPackage, 1)
+	sub := ethash.remote.subscribeWork(works)
+
+	s.wg.Add(2)
+	go s.accept()
+	go s.dispatch(works, sub)
+	ethash.config.Log.Info("Started stratum server", "addr", listener.Addr(), "maxconns", ethash.config.StratumMaxConns)
+	return s, nil
+}
+
+// close stops accepting clients and drops all connected ones.
+func (s *stratumServer) close() {
+	close(s.quit)
+	s.listener.Close()
+
+	s.lock.Lock()
+	for c := range s.conns {
+		c.conn.Close()
+	}
+	s.lock.Unlock()
+
+	s.wg.Wait()
+}
+
+// accept serves incoming stratum connections until the listener is closed.
+func (s *stratumServer) accept() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			select {
+			case <-s.quit:
+			default:
+				s.ethash.config.Log.Warn("Stratum listener failed", "err", err)
+			}
+			return
+		}
+		c := &stratumConn{
+			conn:   conn,
+			jobs:   make(chan WorkPackage, 1),
+			closed: make(chan struct{}),
+		}
+		if config := s.ethash.config; config.ShareDifficulty > 0 {
+			c.vardiff = newVardiff(new(big.Int).SetUint64(config.ShareDifficulty), config.ShareTargetTime, time.Now())
+		}
+		if !s.register(c) {
+			s.ethash.config.Log.Debug("Rejected stratum connection, too many clients", "remote", conn.RemoteAddr())
+			conn.Close()
+			continue
+		}
+		s.wg.Add(2)
+		go s.serve(c)
+		go s.push(c)
+	}
+}
+
+// register tracks a new connection and assigns it a free nonce prefix. False
+// is returned if the connection limit is reached.
+func (s *stratumServer) register(c *stratumConn) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.conns) >= s.ethash.config.StratumMaxConns {
+		return false
+	}
+	// The limit never exceeds the prefix space, so a free prefix exists
+	for {
+		prefix := s.nextPrefix
+		s.nextPrefix++
+		if _, ok := s.prefixes[prefix]; ok {
+			continue
+		}
+		s.prefixes[prefix] = struct{}{}
+		c.prefix = binary.BigEndian.AppendUint16(nil, prefix)
+		break
+	}
+	s.conns[c] = struct{}{}
+	return true
+}
+
+// unregister drops a disconnected connection, releasing its nonce prefix.
+func (s *stratumServer) unregister(c *stratumConn) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.conns, c)
+	delete(s.prefixes, binary.BigEndian.Uint16(c.prefix))
+}
+
+// dispatch hands regenerated work to all connections. Connections not keeping
+// up only receive the latest work, never blocking the remote sealer.
+func (s *stratumServer) dispatch(works chan WorkPackage, sub event.Subscription) {
+	defer s.wg.Done()
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case work := <-works:
+			s.lock.Lock()
+			for c := range s.conns {
+				if c.subscribed.Load() {
+					c.offer(work)
+				}
+			}
+			s.lock.Unlock()
+
+		case <-sub.Err():
+			return
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// offer replaces any pending work of the connection with the given one.
+func (c *stratumConn) offer(work WorkPackage) {
+	for {
+		select {
+		case c.jobs <- work:
+			return
+		default:
+		}
+		select {
+		case <-c.jobs:
+		default:
+		}
+	}
+}
+
+// push notifies the client of every job offered to the connection, setting
+// the difficulty beforehand if it changed.
+func (s *stratumServer) push(c *stratumConn) {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case work := <-c.jobs:
+			difficulty, err := s.difficulty(c, work)
+			if err != nil {
+				// Work aged out before being pushed, newer work follows
+				continue
+			}
+			if c.difficulty == nil || c.difficulty.Cmp(difficulty) != 0 {
+				value, _ := new(big.Float).Quo(new(big.Float).SetInt(difficulty), pow32).Float64()
+				if err := c.write(stratumResponse{Method: "mining.set_difficulty", Params: []float64{value}}); err != nil {
+					return
+				}
+				c.difficulty = difficulty
+			}
+			job := strings.TrimPrefix(work[0], "0x")
+			notify := []interface{}{job, strings.TrimPrefix(work[1], "0x"), job, job != c.job}
+			if err := c.write(stratumResponse{Method: "mining.notify", Params: notify}); err != nil {
+				return
+			}
+			c.job = job
+
+		case <-c.closed:
+			return
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// difficulty returns the difficulty the connection searches the given work at.
+func (s *stratumServer) difficulty(c *stratumConn, work WorkPackage) (*big.Int, error) {
+	block, err := s.ethash.remote.retainedBlock(common.HexToHash(work[0]))
+	if err != nil {
+		return nil, err
+	}
+	if c.vardiff != nil {
+		c.vardiff.check(time.Now())
+		if difficulty := c.vardiff.difficulty(); difficulty.Cmp(block.Difficulty()) < 0 {
+			return difficulty, nil
+		}
+	}
+	return block.Difficulty(), nil
+}
+
+// serve handles the requests of a stratum client until it disconnects.
+func (s *stratumServer) serve(c *stratumConn) {
+	defer s.wg.Done()
+	defer func() {
+		s.unregister(c)
+		c.conn.Close()
+		close(c.closed)
+	}()
+
+	scanner := bufio.NewScanner(c.conn)
+	scanner.Buffer(make([]byte, stratumMaxLine), stratumMaxLine)
+	for scanner.Scan() {
+		var req stratumRequest
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			s.ethash.config.Log.Debug("Invalid stratum request", "remote", c.conn.RemoteAddr(), "err", err)
+			return
+		}
+		result, err := s.handle(c, &req)
+		res := stratumResponse{ID: req.ID, Result: result}
+		if err != nil {
+			msg := err.Error()
+			res.Error = &msg
+		}
+		if err := c.write(res); err != nil {
+			return
+		}
+		// Push the current work right after subscribing, later work is fed
+		if req.Method == "mining.subscribe" && err == nil {
+			if work, err := (&API{ethash: s.ethash}).GetWork(); err == nil {
+				c.offer(work)
+			}
+		}
+	}
+}
+
+// handle executes a single stratum request.
+func (s *stratumServer) handle(c *stratumConn, req *stratumRequest) (interface{}, error) {
+	switch req.Method {
+	case "mining.subscribe":
+		c.subscribed.Store(true)
+		extranonce := hex.EncodeToString(c.prefix)
+		return []interface{}{[]string{"mining.notify", extranonce, stratumProtocol}, extranonce}, nil
+
+	case "mining.authorize":
+		var worker string
+		if len(req.Params) < 1 || json.Unmarshal(req.Params[0], &worker) != nil {
+			return nil, errStratumBadParams
+		}
+		if len(worker) > maxWorkerNameLength {
+			return nil, errStratumBadParams
+		}
+		id := crypto.Keccak256Hash([]byte(worker))
+		c.miner, c.worker = &id, worker
+		return true, nil
+
+	case "mining.submit":
+		if !c.subscribed.Load() {
+			return nil, errStratumNotSubscribed
+		}
+		var job, nonce string
+		if len(req.Params) < 3 || json.Unmarshal(req.Params[1], &job) != nil || json.Unmarshal(req.Params[2], &nonce) != nil {
+			return nil, errStratumBadParams
+		}
+		hash, err := hex.DecodeString(strings.TrimPrefix(job, "0x"))
+		if err != nil || len(hash) != common.HashLength {
+			return nil, errStratumBadParams
+		}
+		suffix, err := hex.DecodeString(strings.TrimPrefix(nonce, "0x"))
+		if err != nil || len(suffix) != len(types.BlockNonce{})-stratumNoncePrefixSize {
+			return nil, errStratumBadParams
+		}
+		result := &mineResult{
+			hash:   common.BytesToHash(hash),
+			miner:  c.miner,
+			worker: c.worker,
+			source: remoteHost(c.conn),
+			errc:   make(chan error, 1),
+		}
+		copy(result.nonce[:], c.prefix)
+		copy(result.nonce[stratumNoncePrefixSize:], suffix)
+		if c.vardiff != nil {
+			result.shareDifficulty = c.vardiff.accepted()
+		}
+		select {
+		case s.ethash.remote.submitWorkCh <- result:
+		case <-s.ethash.remote.exitCh:
+			return nil, errEthashStopped
+		}
+		if err := <-result.errc; err != nil {
+			return false, err
+		}
+		// Push the current job again if the share difficulty changed
+		if c.vardiff != nil && c.vardiff.record(time.Now()) {
+			if work, err := (&API{ethash: s.ethash}).GetWork(); err == nil {
+				c.offer(work)
+			}
+		}
+		return true, nil
+
+	default:
+		return nil, errStratumUnknownMethod
+	}
+}
+
+// remoteHost returns the remote host of a miner connection.
+func remoteHost(conn net.Conn) string {
+	host, _, err := net.SplitHostPort(conn.RemoteAddr().String())
+	if err != nil {
+		return conn.RemoteAddr().String()
+	}
+	return host
+}
+
+// write sends a response or notification to the client, failing if the client
+// does not read it in time.
+func (c *stratumConn) write(res stratumResponse) error {
+	if res.ID == nil {
+		res.ID = json.RawMessage("null")
+	}
+	blob, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.conn.SetWriteDeadline(time.Now().Add(stratumWriteTimeout))
+	_, err = c.conn.Write(append(blob, '\n'))
+	return err
+}
@@ -0,0 +1,209 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package ethash
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// stratumClient is a minimal stratum client for testing.
+type stratumClient struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func dialStratum(t *testing.T, ethash *Ethash) *stratumClient {
+	conn, err := net.Dial("tcp", ethash.stratum.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to dial stratum server: %v", err)
+	}
+	return &stratumClient{t: t, conn: conn, reader: bufio.NewReader(conn)}
+}
+
+func (c *stratumClient) send(id int, method string, params ...interface{}) {
+	blob, _ := json.Marshal(map[string]interface{}{"id": id, "method": method, "params": params})
+	if _, err := c.conn.Write(append(blob, '\n')); err != nil {
+		c.t.Fatalf("failed to send %s: %v", method, err)
+	}
+}
+
+func (c *stratumClient) read() map[string]json.RawMessage {
+	c.conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	line, err := c.reader.ReadBytes('\n')
+	if err != nil {
+		c.t.Fatalf("failed to read stratum message: %v", err)
+	}
+	var msg map[string]json.RawMessage
+	if err := json.Unmarshal(line, &msg); err != nil {
+		c.t.Fatalf("failed to decode stratum message %s: %v", line, err)
+	}
+	return msg
+}
+
+// Tests that stratum clients are assigned distinct extranonces, receive jobs
+// to search over and can submit solutions.
+func TestStratum(t *testing.T) {
+	ethash := New(Config{PowMode: ModeTest, StratumAddr: "127.0.0.1:0"}, nil, true)
+	defer ethash.Close()
+	ethash.SetThreads(-1)
+
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100000000)}
+	results := make(chan types.SealResult, 1)
+	ethash.Seal(nil, types.NewBlockWithHeader(header), results, nil)
+	sealhash := ethash.SealHash(header)
+	job := hex.EncodeToString(sealhash[:])
+
+	var extranonces [2]string
+	for i := range extranonces {
+		client := dialStratum(t, ethash)
+		defer client.conn.Close()
+
+		client.send(1, "mining.subscribe", "test", stratumProtocol)
+		var result []json.RawMessage
+		if err := json.Unmarshal(client.read()["result"], &result); err != nil || len(result) != 2 {
+			t.Fatalf("client %d: invalid subscription result %v: %v", i, result, err)
+		}
+		var subscription []string
+		if err := json.Unmarshal(result[0], &subscription); err != nil || len(subscription) != 3 || subscription[0] != "mining.notify" || subscription[2] != stratumProtocol {
+			t.Fatalf("client %d: invalid subscription %v: %v", i, subscription, err)
+		}
+		if err := json.Unmarshal(result[1], &extranonces[i]); err != nil || len(extranonces[i]) != 2*stratumNoncePrefixSize {
+			t.Fatalf("client %d: invalid extranonce %q: %v", i, extranonces[i], err)
+		}
+		var difficulty []float64
+		if msg := client.read(); string(msg["method"]) != `"mining.set_difficulty"` || json.Unmarshal(msg["params"], &difficulty) != nil {
+			t.Fatalf("client %d: difficulty not set: %v", i, msg)
+		}
+		if want := float64(100000000) / (1 << 32); len(difficulty) != 1 || difficulty[0] != want {
+			t.Fatalf("client %d: difficulty mismatch: have %v, want %v", i, difficulty, want)
+		}
+		var notify []interface{}
+		if err := json.Unmarshal(client.read()["params"], &notify); err != nil || len(notify) != 4 {
+			t.Fatalf("client %d: invalid job %v: %v", i, notify, err)
+		}
+		if notify[0] != job || notify[2] != job || notify[3] != true {
+			t.Fatalf("client %d: job mismatch: have %v, want %s", i, notify, job)
+		}
+	}
+	if extranonces[0] == extranonces[1] {
+		t.Fatalf("extranonce assigned twice: %s", extranonces[0])
+	}
+	// Solutions of a client are sealed with its extranonce leading the nonce
+	client := dialStratum(t, ethash)
+	defer client.conn.Close()
+
+	client.send(1, "mining.submit", "worker", job, "000000000001")
+	if msg := client.read(); string(msg["error"]) == "null" {
+		t.Fatalf("submission accepted before subscribing")
+	}
+	client.send(2, "mining.subscribe")
+	var result []json.RawMessage
+	json.Unmarshal(client.read()["result"], &result)
+	var extranonce string
+	json.Unmarshal(result[1], &extranonce)
+	client.read() // Difficulty
+	client.read() // Current job
+
+	client.send(3, "mining.authorize", "worker", "x")
+	if msg := client.read(); string(msg["result"]) != "true" {
+		t.Fatalf("authorization failed: %s", msg["error"])
+	}
+	client.send(4, "mining.submit", "worker", job, "0001")
+	if msg := client.read(); string(msg["error"]) == "null" {
+		t.Fatalf("short nonce accepted")
+	}
+	client.send(5, "mining.submit", "worker", job, "000000000001")
+	if msg := client.read(); string(msg["result"]) != "true" {
+		t.Fatalf("solution rejected: %s", msg["error"])
+	}
+	select {
+	case result := <-results:
+		want, _ := hex.DecodeString(extranonce + "000000000001")
+		if nonce := result.Block.Header().Nonce; !bytes.Equal(nonce[:], want) {
+			t.Errorf("sealed nonce mismatch: have %x, want %x", nonce, want)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("solution not delivered")
+	}
+}
+
+// Tests that stratum clients are set their share difficulty if configured.
+func TestStratumShareDifficulty(t *testing.T) {
+	ethash := New(Config{PowMode: ModeTest, StratumAddr: "127.0.0.1:0", ShareDifficulty: 1 << 33}, nil, true)
+	defer ethash.Close()
+	ethash.SetThreads(-1)
+
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(1 << 40)}
+	ethash.Seal(nil, types.NewBlockWithHeader(header), make(chan types.SealResult, 1), nil)
+
+	client := dialStratum(t, ethash)
+	defer client.conn.Close()
+
+	client.send(1, "mining.subscribe")
+	client.read()
+
+	var difficulty []float64
+	if err := json.Unmarshal(client.read()["params"], &difficulty); err != nil {
+		t.Fatalf("invalid difficulty: %v", err)
+	}
+	if len(difficulty) != 1 || difficulty[0] != 2 {
+		t.Errorf("share difficulty mismatch: have %v, want [2]", difficulty)
+	}
+}
+
+// Tests that stratum connections beyond the limit are dropped, and that the
+// nonce prefixes of disconnected clients are reassigned.
+func TestStratumMaxConns(t *testing.T) {
+	ethash := New(Config{PowMode: ModeTest, StratumAddr: "127.0.0.1:0", StratumMaxConns: 1}, nil, true)
+	defer ethash.Close()
+
+	first := dialStratum(t, ethash)
+	first.send(1, "mining.subscribe")
+	first.read()
+
+	second := dialStratum(t, ethash)
+	defer second.conn.Close()
+
+	second.conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	if _, err := second.reader.ReadBytes('\n'); err != io.EOF {
+		t.Fatalf("connection beyond the limit not dropped: %v", err)
+	}
+	first.conn.Close()
+
+	// The freed slot is eventually served again
+	for i := 0; ; i++ {
+		third := dialStratum(t, ethash)
+		third.send(1, "mining.subscribe")
+		third.conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+		_, err := third.reader.ReadBytes('\n')
+		third.conn.Close()
+		if err == nil {
+			break
+		}
+		if i == 50 {
+			t.Fatalf("freed connection slot not reused: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,534 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package ethash
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Stratum v2 message types of the mining protocol. The job and share messages
+// carry ethash fields in place of the bitcoin specific ones.
+const (
+	sv2SetupConnection        = 0x00
+	sv2SetupConnectionSuccess = 0x01
+	sv2SetupConnectionError   = 0x02
+	sv2OpenChannel            = 0x10 // OpenStandardMiningChannel
+	sv2OpenChannelSuccess     = 0x11
+	sv2OpenChannelError       = 0x12
+	sv2NewMiningJob           = 0x15
+	sv2SubmitShares           = 0x1a // SubmitSharesStandard
+	sv2SubmitSharesSuccess    = 0x1c
+	sv2SubmitSharesError      = 0x1d
+)
+
+const (
+	sv2Version        = 2
+	sv2MiningProtocol = 0
+	sv2ChannelMsgBit  = 0x8000             // Extension type bit of channel bound messages
+	sv2MaxPayload     = 1 << 16            // Maximum accepted payload length
+	sv2JobsRetained   = staleThreshold + 1 // Jobs per connection accepting shares
+)
+
+var errSV2Malformed = errors.New("malformed stratum v2 message")
+
+// sv2Frame is a single stratum v2 message.
+type sv2Frame struct {
+	ext     uint16 // Extension type, including the channel message bit
+	msgType uint8
+	payload []byte
+}
+
+// readSV2Frame reads a frame: a little endian u16 extension type, a u8 message
+// type and a u24 payload length, followed by the payload.
+func readSV2Frame(r io.Reader) (sv2Frame, error) {
+	var header [6]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return sv2Frame{}, err
+	}
+	size := uint32(header[3]) | uint32(header[4])<<8 | uint32(header[5])<<16
+	if size > sv2MaxPayload {
+		return sv2Frame{}, errSV2Malformed
+	}
+	frame := sv2Frame{ext: binary.LittleEndian.Uint16(header[:2]), msgType: header[2], payload: make([]byte, size)}
+	if _, err := io.ReadFull(r, frame.payload); err != nil {
+		return sv2Frame{}, err
+	}
+	return frame, nil
+}
+
+// encode serializes the frame.
+func (f sv2Frame) encode() []byte {
+	blob := make([]byte, 6, 6+len(f.payload))
+	binary.LittleEndian.PutUint16(blob[:2], f.ext)
+	blob[2] = f.msgType
+	blob[3], blob[4], blob[5] = byte(len(f.payload)), byte(len(f.payload)>>8), byte(len(f.payload)>>16)
+	return append(blob, f.payload...)
+}
+
+// sv2Encoder appends stratum v2 primitive types to a payload.
+type sv2Encoder []byte
+
+func (e *sv2Encoder) u8(v uint8)   { *e = append(*e, v) }
+func (e *sv2Encoder) u16(v uint16) { *e = binary.LittleEndian.AppendUint16(*e, v) }
+func (e *sv2Encoder) u32(v uint32) { *e = binary.LittleEndian.AppendUint32(*e, v) }
+func (e *sv2Encoder) u64(v uint64) { *e = binary.LittleEndian.AppendUint64(*e, v) }
+func (e *sv2Encoder) b32(v []byte) { *e = append(*e, v...) } // Fixed 32 bytes, as is
+
+// str encodes a STR0_255 or B0_255, prefixed by its u8 length.
+func (e *sv2Encoder) str(v []byte) {
+	*e = append(append(*e, byte(len(v))), v...)
+}
+
+// u256 encodes a 256 bit integer in little endian.
+func (e *sv2Encoder) u256(v *big.Int) {
+	var word [32]byte
+	v.FillBytes(word[:])
+	for i := 0; i < 16; i++ {
+		word[i], word[31-i] = word[31-i], word[i]
+	}
+	*e = append(*e, word[:]...)
+}
+
+// sv2Decoder reads stratum v2 primitive types from a payload, recording the
+// first read past its end.
+type sv2Decoder struct {
+	buf []byte
+	err error
+}
+
+func (d *sv2Decoder) next(n int) []byte {
+	if d.err != nil || len(d.buf) < n {
+		d.err = errSV2Malformed
+		return make([]byte, n)
+	}
+	blob := d.buf[:n]
+	d.buf = d.buf[n:]
+	return blob
+}
+
+func (d *sv2Decoder) u8() uint8   { return d.next(1)[0] }
+func (d *sv2Decoder) u16() uint16 { return binary.LittleEndian.Uint16(d.next(2)) }
+func (d *sv2Decoder) u32() uint32 { return binary.LittleEndian.Uint32(d.next(4)) }
+func (d *sv2Decoder) u64() uint64 { return binary.LittleEndian.Uint64(d.next(8)) }
+func (d *sv2Decoder) str() []byte { return d.next(int(d.u8())) }
+
+// stratumV2Server serves work of the remote sealer to stratum v2 clients.
+//
+// Connections start with SetupConnection for the mining protocol, after which
+// any number of standard channels can be opened on them, each assigned its own
+// extraNonce as extranonce prefix. Every channel is sent a NewMiningJob when
+// the remote sealer regenerates work, laid out as
+//
+//	channel_id u32, job_id u32, future_job bool, block_number u64,
+//	pow_hash [32]byte, seed_hash [32]byte, target u256
+//
+// where pow_hash is the hash of the work header with the channel's extraNonce.
+// Shares are submitted via SubmitSharesStandard as
+//
+//	channel_id u32, sequence_number u32, job_id u32, nonce u64
+//
+// Connections are not encrypted, the noise handshake is not supported.
+type stratumV2Server struct {
+	ethash   *Ethash
+	listener net.Listener
+
+	lock  sync.Mutex
+	conns map[*sv2Conn]struct{}
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// sv2Conn is a single stratum v2 client connection.
+type sv2Conn struct {
+	conn   net.Conn
+	jobs   chan WorkPackage // Latest work to push, holding at most one
+	closed chan struct{}    // Closed when the client disconnected
+
+	writeLock sync.Mutex // Serializes writes to the connection
+
+	lock     sync.Mutex
+	setup    bool                   // Whether the connection was set up
+	channels map[uint32]*sv2Channel // Open channels by id
+	works    map[uint32]common.Hash // Work pow-hash of recently pushed jobs by id
+	order    []uint32               // Ids of the recently pushed jobs, oldest first
+	nextID   uint32                 // Last assigned channel or job id
+}
+
+// sv2Channel is a standard mining channel of a connection.
+type sv2Channel struct {
+	id         uint32
+	extraNonce []byte
+	miner      common.Hash // Id derived from the user identity
+	worker     string      // User identity, empty if too long for a worker name
+}
+
+// startStratumV2 starts serving the remote sealer's work to stratum v2 clients
+// on the given address.
+func startStratumV2(ethash *Ethash, addr string) (*stratumV2Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &stratumV2Server{
+		ethash:   ethash,
+		listener: listener,
+		conns:    make(map[*sv2Conn]struct{}),
+		quit:     make(chan struct{}),
+	}
+	works := make(chan WorkPackage, 1)
+	sub := ethash.remote.subscribeWork(works)
+
+	s.wg.Add(2)
+	go s.accept()
+	go s.dispatch(works, sub)
+	ethash.config.Log.Info("Started stratum v2 server", "addr", listener.Addr())
+	return s, nil
+}
+
+// close stops accepting clients and drops all connected ones.
+func (s *stratumV2Server) close() {
+	close(s.quit)
+	s.listener.Close()
+
+	s.lock.Lock()
+	for c := range s.conns {
+		c.conn.Close()
+	}
+	s.lock.Unlock()
+
+	s.wg.Wait()
+}
+
+// accept serves incoming connections until the listener is closed.
+func (s *stratumV2Server) accept() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			select {
+			case <-s.quit:
+			default:
+				s.ethash.config.Log.Warn("Stratum v2 listener failed", "err", err)
+			}
+			return
+		}
+		c := &sv2Conn{
+			conn:     conn,
+			jobs:     make(chan WorkPackage, 1),
+			closed:   make(chan struct{}),
+			channels: make(map[uint32]*sv2Channel),
+			works:    make(map[uint32]common.Hash),
+		}
+		s.lock.Lock()
+		s.conns[c] = struct{}{}
+		s.lock.Unlock()
+
+		s.wg.Add(2)
+		go s.serve(c)
+		go s.push(c)
+	}
+}
+
+// dispatch hands regenerated work to all connections without blocking the
+// remote sealer, connections not keeping up only receive the latest work.
+func (s *stratumV2Server) dispatch(works chan WorkPackage, sub event.Subscription) {
+	defer s.wg.Done()
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case work := <-works:
+			s.lock.Lock()
+			for c := range s.conns {
+				c.offer(work)
+			}
+			s.lock.Unlock()
+
+		case <-sub.Err():
+			return
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// offer replaces any pending work of the connection with the given one.
+func (c *sv2Conn) offer(work WorkPackage) {
+	for {
+		select {
+		case c.jobs <- work:
+			return
+		default:
+		}
+		select {
+		case <-c.jobs:
+		default:
+		}
+	}
+}
+
+// push sends a job to every open channel of the connection for each work
+// offered to it.
+func (s *stratumV2Server) push(c *sv2Conn) {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case work := <-c.jobs:
+			block, err := s.ethash.remote.retainedBlock(common.HexToHash(work[0]))
+			if err != nil {
+				continue // Work aged out before being pushed, newer work follows
+			}
+			// Write under the lock for jobs not to overtake channel openings
+			c.lock.Lock()
+			for _, frame := range c.jobFrames(s.ethash, block, work, c.channelList()) {
+				if err = c.write(frame); err != nil {
+					break
+				}
+			}
+			c.lock.Unlock()
+			if err != nil {
+				return
+			}
+		case <-c.closed:
+			return
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// channelList returns the open channels. The connection lock must be held.
+func (c *sv2Conn) channelList() []*sv2Channel {
+	channels := make([]*sv2Channel, 0, len(c.channels))
+	for _, channel := range c.channels {
+		channels = append(channels, channel)
+	}
+	return channels
+}
+
+// jobFrames assigns a job id to the work and assembles its NewMiningJob for
+// each of the given channels. The connection lock must be held.
+func (c *sv2Conn) jobFrames(ethash *Ethash, block *types.Block, work WorkPackage, channels []*sv2Channel) []sv2Frame {
+	hash := common.HexToHash(work[0])
+
+	var job uint32
+	for id, retained := range c.works {
+		if retained == hash {
+			job = id
+		}
+	}
+	if job == 0 {
+		c.nextID++
+		job = c.nextID
+		c.works[job] = hash
+		c.order = append(c.order, job)
+		if len(c.order) > sv2JobsRetained {
+			delete(c.works, c.order[0])
+			c.order = c.order[1:]
+		}
+	}
+	frames := make([]sv2Frame, 0, len(channels))
+	for _, channel := range channels {
+		header, err := ethash.placeExtraNonce(block.Header(), channel.extraNonce)
+		if err != nil {
+			continue
+		}
+		var enc sv2Encoder
+		enc.u32(channel.id)
+		enc.u32(job)
+		enc.u8(0) // Not a future job
+		enc.u64(block.NumberU64())
+		enc.b32(ethash.SealHash(header).Bytes())
+		enc.b32(common.HexToHash(work[1]).Bytes())
+		enc.u256(new(big.Int).Div(two256, block.Difficulty()))
+		frames = append(frames, sv2Frame{ext: sv2ChannelMsgBit, msgType: sv2NewMiningJob, payload: enc})
+	}
+	return frames
+}
+
+// serve handles the messages of a client until it disconnects.
+func (s *stratumV2Server) serve(c *sv2Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.lock.Lock()
+		delete(s.conns, c)
+		s.lock.Unlock()
+		c.conn.Close()
+		close(c.closed)
+	}()
+
+	for {
+		frame, err := readSV2Frame(c.conn)
+		if err != nil {
+			return
+		}
+		replies, err := s.handle(c, frame)
+		if err != nil {
+			s.ethash.config.Log.Debug("Invalid stratum v2 message", "remote", c.conn.RemoteAddr(), "type", frame.msgType, "err", err)
+			return
+		}
+		for _, reply := range replies {
+			if err := c.write(reply); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// handle executes a single client message, returning the frames to reply with.
+// Errors are only returned for messages violating the protocol, dropping the
+// connection.
+func (s *stratumV2Server) handle(c *sv2Conn, frame sv2Frame) ([]sv2Frame, error) {
+	dec := &sv2Decoder{buf: frame.payload}
+
+	c.lock.Lock()
+	setup := c.setup
+	c.lock.Unlock()
+
+	if !setup && frame.msgType != sv2SetupConnection {
+		return nil, errors.New("connection not set up")
+	}
+	switch frame.msgType {
+	case sv2SetupConnection:
+		protocol, minVersion, maxVersion := dec.u8(), dec.u16(), dec.u16()
+		if dec.err != nil {
+			return nil, dec.err
+		}
+		var enc sv2Encoder
+		if setup || protocol != sv2MiningProtocol || minVersion > sv2Version || maxVersion < sv2Version {
+			enc.u32(0)
+			enc.str([]byte("unsupported-protocol"))
+			return []sv2Frame{{msgType: sv2SetupConnectionError, payload: enc}}, nil
+		}
+		c.lock.Lock()
+		c.setup = true
+		c.lock.Unlock()
+
+		enc.u16(sv2Version)
+		enc.u32(0) // No optional features supported
+		return []sv2Frame{{msgType: sv2SetupConnectionSuccess, payload: enc}}, nil
+
+	case sv2OpenChannel:
+		request, identity := dec.u32(), dec.str()
+		if dec.err != nil {
+			return nil, dec.err
+		}
+		work, err := (&API{ethash: s.ethash}).GetWork()
+		if err != nil {
+			var enc sv2Encoder
+			enc.u32(request)
+			enc.str([]byte(err.Error()))
+			return []sv2Frame{{msgType: sv2OpenChannelError, payload: enc}}, nil
+		}
+		block, err := s.ethash.remote.retainedBlock(common.HexToHash(work[0]))
+		if err != nil {
+			return nil, err
+		}
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		c.nextID++
+		channel := &sv2Channel{id: c.nextID, extraNonce: s.ethash.remote.assignExtraNonce(), miner: crypto.Keccak256Hash(identity)}
+		if len(identity) <= maxWorkerNameLength {
+			channel.worker = string(identity)
+		}
+		c.channels[channel.id] = channel
+
+		var enc sv2Encoder
+		enc.u32(request)
+		enc.u32(channel.id)
+		enc.u256(new(big.Int).Div(two256, block.Difficulty()))
+		enc.str(channel.extraNonce)
+		enc.u32(0) // Not part of a group channel
+
+		// Send the current job right away, later jobs are pushed. Both are
+		// written under the lock for pushed jobs not to overtake them.
+		frames := append([]sv2Frame{{ext: sv2ChannelMsgBit, msgType: sv2OpenChannelSuccess, payload: enc}}, c.jobFrames(s.ethash, block, work, []*sv2Channel{channel})...)
+		for _, frame := range frames {
+			if err := c.write(frame); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+
+	case sv2SubmitShares:
+		id, sequence, job, nonce := dec.u32(), dec.u32(), dec.u32(), dec.u64()
+		if dec.err != nil {
+			return nil, dec.err
+		}
+		c.lock.Lock()
+		channel, hash := c.channels[id], c.works[job]
+		c.lock.Unlock()
+
+		var reason string
+		switch {
+		case channel == nil:
+			reason = "invalid-channel-id"
+		case hash == (common.Hash{}):
+			reason = "invalid-job-id"
+		default:
+			errc := make(chan error, 1)
+			select {
+			case s.ethash.remote.submitWorkCh <- &mineResult{
+				nonce:      types.EncodeNonce(nonce),
+				hash:       hash,
+				extraNonce: channel.extraNonce,
+				miner:      &channel.miner,
+				worker:     channel.worker,
+				source:     remoteHost(c.conn),
+				errc:       errc,
+			}:
+			case <-s.ethash.remote.exitCh:
+				return nil, errEthashStopped
+			}
+			if err := <-errc; err != nil {
+				reason = "invalid-share"
+			}
+		}
+		var enc sv2Encoder
+		enc.u32(id)
+		enc.u32(sequence)
+		if reason != "" {
+			enc.str([]byte(reason))
+			return []sv2Frame{{ext: sv2ChannelMsgBit, msgType: sv2SubmitSharesError, payload: enc}}, nil
+		}
+		enc.u32(1) // Accepted submits since the last success
+		enc.u64(1) // Sum of their share difficulties, shares are at block target
+		return []sv2Frame{{ext: sv2ChannelMsgBit, msgType: sv2SubmitSharesSuccess, payload: enc}}, nil
+
+	default:
+		return nil, errors.New("unsupported message")
+	}
+}
+
+// write sends a frame to the client.
+func (c *sv2Conn) write(frame sv2Frame) error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	_, err := c.conn.Write(frame.encode())
+	return err
+}
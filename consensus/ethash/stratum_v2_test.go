@@ -0,0 +1,144 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package ethash
+
+import (
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// sv2Client is a minimal stratum v2 client for testing.
+type sv2Client struct {
+	t    *testing.T
+	conn net.Conn
+}
+
+func (c *sv2Client) send(ext uint16, msgType uint8, enc sv2Encoder) {
+	if _, err := c.conn.Write(sv2Frame{ext: ext, msgType: msgType, payload: enc}.encode()); err != nil {
+		c.t.Fatalf("failed to send message %#x: %v", msgType, err)
+	}
+}
+
+func (c *sv2Client) read(msgType uint8) *sv2Decoder {
+	c.conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	frame, err := readSV2Frame(c.conn)
+	if err != nil {
+		c.t.Fatalf("failed to read message %#x: %v", msgType, err)
+	}
+	if frame.msgType != msgType {
+		c.t.Fatalf("message type mismatch: have %#x, want %#x", frame.msgType, msgType)
+	}
+	return &sv2Decoder{buf: frame.payload}
+}
+
+// Tests that stratum v2 clients can multiplex channels over a connection, each
+// with its own extraNonce, and submit shares on them.
+func TestStratumV2(t *testing.T) {
+	ethash := New(Config{PowMode: ModeTest, StratumV2Addr: "127.0.0.1:0"}, nil, true)
+	defer ethash.Close()
+	ethash.SetThreads(-1)
+
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100000000)}
+	results := make(chan types.SealResult, 1)
+	ethash.Seal(nil, types.NewBlockWithHeader(header), results, nil)
+
+	conn, err := net.Dial("tcp", ethash.stratumV2.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to dial stratum v2 server: %v", err)
+	}
+	defer conn.Close()
+	client := &sv2Client{t: t, conn: conn}
+
+	var setup sv2Encoder
+	setup.u8(sv2MiningProtocol)
+	setup.u16(2)
+	setup.u16(2)
+	setup.u32(0)
+	client.send(0, sv2SetupConnection, setup)
+	if dec := client.read(sv2SetupConnectionSuccess); dec.u16() != sv2Version {
+		t.Fatalf("negotiated version mismatch")
+	}
+	// Open two channels and check their jobs are distinct
+	type opened struct {
+		id, job    uint32
+		extraNonce []byte
+	}
+	var channels [2]opened
+	for i := range channels {
+		var open sv2Encoder
+		open.u32(uint32(i))
+		open.str([]byte("worker"))
+		client.send(0, sv2OpenChannel, open)
+
+		dec := client.read(sv2OpenChannelSuccess)
+		if request := dec.u32(); request != uint32(i) {
+			t.Fatalf("channel %d: request id mismatch: have %d", i, request)
+		}
+		channels[i].id = dec.u32()
+		dec.next(32) // Target
+		channels[i].extraNonce = dec.str()
+
+		dec = client.read(sv2NewMiningJob)
+		if id := dec.u32(); id != channels[i].id {
+			t.Fatalf("channel %d: job channel mismatch: have %d, want %d", i, id, channels[i].id)
+		}
+		channels[i].job = dec.u32()
+		dec.u8()
+		if number := dec.u64(); number != 1 {
+			t.Fatalf("channel %d: job number mismatch: have %d", i, number)
+		}
+		placed, err := ethash.placeExtraNonce(header, channels[i].extraNonce)
+		if err != nil {
+			t.Fatalf("channel %d: invalid extraNonce: %v", i, err)
+		}
+		if hash := common.BytesToHash(dec.next(32)); hash != ethash.SealHash(placed) {
+			t.Fatalf("channel %d: job pow-hash mismatch: have %x, want %x", i, hash, ethash.SealHash(placed))
+		}
+		if dec.err != nil {
+			t.Fatalf("channel %d: malformed job: %v", i, dec.err)
+		}
+	}
+	if channels[0].id == channels[1].id || string(channels[0].extraNonce) == string(channels[1].extraNonce) {
+		t.Fatalf("channels not distinct: %+v", channels)
+	}
+	// Shares for unknown jobs are rejected, valid ones sealed with the channel's extraNonce
+	submit := func(channel, sequence, job uint32) {
+		var enc sv2Encoder
+		enc.u32(channel)
+		enc.u32(sequence)
+		enc.u32(job)
+		enc.u64(7)
+		client.send(sv2ChannelMsgBit, sv2SubmitShares, enc)
+	}
+	submit(channels[1].id, 1, 1000)
+	if dec := client.read(sv2SubmitSharesError); dec.u32() != channels[1].id || dec.u32() != 1 || string(dec.str()) != "invalid-job-id" {
+		t.Fatalf("unknown job share not rejected")
+	}
+	submit(channels[1].id, 2, channels[1].job)
+	if dec := client.read(sv2SubmitSharesSuccess); dec.u32() != channels[1].id || dec.u32() != 2 {
+		t.Fatalf("share success mismatch")
+	}
+	select {
+	case result := <-results:
+		if extra := result.Block.Extra(); string(extra) != string(channels[1].extraNonce) || result.Block.Nonce() != 7 {
+			t.Errorf("sealed block mismatch: extra %x, nonce %d", extra, result.Block.Nonce())
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("share not delivered")
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package ethash
+
+import (
+	"encoding/binary"
+	"encoding/json"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// maxSubmissionPage is the maximum number of records returned by a single
+// submission log query.
+const maxSubmissionPage = 1000
+
+var (
+	submissionPrefix   = []byte("ethash-submission-")       // submissionPrefix + seq (uint64 big endian) -> JSON record
+	submittedPrefix    = []byte("ethash-submitted-")        // submittedPrefix + powhash + nonce + extraNonce -> valid solution marker
+	submissionCountKey = []byte("ethash-submissions-count") // Number of records in the submission log
+)
+
+// SubmissionRecord is an entry of the submission audit log.
+type SubmissionRecord struct {
+	Seq        hexutil.Uint64   `json:"seq"`
+	Nonce      types.BlockNonce `json:"nonce"`
+	MixDigest  common.Hash      `json:"mixDigest"`
+	PowHash    common.Hash      `json:"powHash"`
+	ExtraNonce hexutil.Bytes    `json:"extraNonce,omitempty"`
+	Source     string           `json:"source"` // Remote address of the submitter, empty if unknown
+	Result     string           `json:"result"`
+	Time       hexutil.Uint64   `json:"time"` // Unix timestamp of the submission
+}
+
+// submissionLog is the persistent, append-only log of the solutions submitted
+// to the remote sealer. Valid solutions are indexed to reject their replays
+// for good, beyond the in-memory deduplication window and across restarts.
+//
+// Records are only appended from the remote sealer loop, while the log can be
+// read concurrently.
+type submissionLog struct {
+	db   ethdb.KeyValueStore
+	next uint64 // Sequence number of the next record
+}
+
+// newSubmissionLog opens the submission log stored in the given database.
+func newSubmissionLog(db ethdb.KeyValueStore) *submissionLog {
+	l := &submissionLog{db: db}
+	if blob, err := db.Get(submissionCountKey); err == nil && len(blob) == 8 {
+		l.next = binary.BigEndian.Uint64(blob)
+	}
+	return l
+}
+
+// submissionKey returns the database key of the record with the given sequence
+// number.
+func submissionKey(seq uint64) []byte {
+	return binary.BigEndian.AppendUint64(append([]byte(nil), submissionPrefix...), seq)
+}
+
+// submittedKey returns the database key marking a valid solution.
+func submittedKey(hash common.Hash, nonce types.BlockNonce, extraNonce []byte) []byte {
+	key := append(append([]byte(nil), submittedPrefix...), hash.Bytes()...)
+	key = append(key, nonce[:]...)
+	return append(key, extraNonce...)
+}
+
+// append adds a record to the log, assigning its sequence number. Valid
+// solutions are indexed for replay protection.
+func (l *submissionLog) append(record *SubmissionRecord, valid bool) error {
+	record.Seq = hexutil.Uint64(l.next)
+	blob, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	batch := l.db.NewBatch()
+	batch.Put(submissionKey(l.next), blob)
+	if valid {
+		batch.Put(submittedKey(record.PowHash, record.Nonce, record.ExtraNonce), nil)
+	}
+	batch.Put(submissionCountKey, binary.BigEndian.AppendUint64(nil, l.next+1))
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	l.next++
+	return nil
+}
+
+// replayed returns whether the given solution was already submitted and found
+// valid.
+func (l *submissionLog) replayed(hash common.Hash, nonce types.BlockNonce, extraNonce []byte) bool {
+	ok, _ := l.db.Has(submittedKey(hash, nonce, extraNonce))
+	return ok
+}
+
+// page returns up to count records starting at the given sequence number.
+func (l *submissionLog) page(start, count uint64) ([]SubmissionRecord, error) {
+	it := l.db.NewIterator(submissionPrefix, binary.BigEndian.AppendUint64(nil, start))
+	defer it.Release()
+
+	records := []SubmissionRecord{}
+	for uint64(len(records)) < count && it.Next() {
+		var record SubmissionRecord
+		if err := json.Unmarshal(it.Value(), &record); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, it.Error()
+}
@@ -0,0 +1,213 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package ethash
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// upstreamPollInterval is the interval at which upstream nodes are polled
+	// for their current work.
+	upstreamPollInterval = 500 * time.Millisecond
+
+	// upstreamTimeout is the time allowance of a single upstream request.
+	upstreamTimeout = 2 * time.Second
+
+	// upstreamFreshness is how long a mirrored job is served after an upstream
+	// last reported it.
+	upstreamFreshness = 5 * upstreamPollInterval
+
+	// upstreamJobTimeout is how long a mirrored job accepts solutions after an
+	// upstream last reported it.
+	upstreamJobTimeout = time.Minute
+)
+
+// upstreamJob is a work package mirrored from one or more upstream nodes.
+type upstreamJob struct {
+	work    [11]string
+	sources map[int]struct{} // Upstreams serving the job
+	first   time.Time        // When any upstream first served the job
+	seen    time.Time        // When any upstream last served the job
+}
+
+// upstreamPool mirrors work from a set of upstream nodes and forwards the
+// solutions of mirrored work back to them. Jobs are deduplicated by pow-hash,
+// with solutions submitted to all upstreams serving the job at once, so the
+// failure of a single upstream doesn't lose blocks.
+type upstreamPool struct {
+	ethash  *Ethash
+	urls    []string
+	clients []*rpc.Client
+
+	lock sync.Mutex
+	jobs map[common.Hash]*upstreamJob
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// startUpstreams connects to the given upstream nodes and starts mirroring
+// their work.
+func startUpstreams(ethash *Ethash, urls []string) (*upstreamPool, error) {
+	p := &upstreamPool{
+		ethash: ethash,
+		urls:   urls,
+		jobs:   make(map[common.Hash]*upstreamJob),
+		quit:   make(chan struct{}),
+	}
+	for _, url := range urls {
+		client, err := rpc.Dial(url)
+		if err != nil {
+			for _, client := range p.clients {
+				client.Close()
+			}
+			return nil, err
+		}
+		p.clients = append(p.clients, client)
+	}
+	p.wg.Add(len(p.clients))
+	for i := range p.clients {
+		go p.poll(i)
+	}
+	ethash.config.Log.Info("Mirroring work from upstream nodes", "upstreams", len(urls))
+	return p, nil
+}
+
+// close stops mirroring work and disconnects from the upstream nodes.
+func (p *upstreamPool) close() {
+	close(p.quit)
+	p.wg.Wait()
+
+	for _, client := range p.clients {
+		client.Close()
+	}
+}
+
+// poll fetches the work of a single upstream node until the pool is closed.
+func (p *upstreamPool) poll(index int) {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(upstreamPollInterval)
+	defer ticker.Stop()
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), upstreamTimeout)
+		var work []string
+		err := p.clients[index].CallContext(ctx, &work, "eth_getWork")
+		cancel()
+
+		if err != nil {
+			p.ethash.config.Log.Trace("Failed to fetch upstream work", "upstream", p.urls[index], "err", err)
+		} else if len(work) >= 3 {
+			p.record(index, work, time.Now())
+		}
+		select {
+		case <-ticker.C:
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+// record adds a work package served by the given upstream, deduplicating it
+// against the packages of other upstreams by pow-hash.
+func (p *upstreamPool) record(index int, work []string, now time.Time) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	hash := common.HexToHash(work[0])
+	job, ok := p.jobs[hash]
+	if !ok {
+		job = &upstreamJob{sources: make(map[int]struct{}), first: now}
+		copy(job.work[:], work)
+		p.jobs[hash] = job
+	}
+	job.sources[index] = struct{}{}
+	job.seen = now
+
+	for hash, job := range p.jobs {
+		if now.Sub(job.seen) > upstreamJobTimeout {
+			delete(p.jobs, hash)
+		}
+	}
+}
+
+// work returns the newest mirrored job still served by any upstream.
+func (p *upstreamPool) work(now time.Time) ([11]string, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var latest *upstreamJob
+	for _, job := range p.jobs {
+		if now.Sub(job.seen) > upstreamFreshness {
+			continue
+		}
+		if latest == nil || job.first.After(latest.first) {
+			latest = job
+		}
+	}
+	if latest == nil {
+		return [11]string{}, false
+	}
+	return latest.work, true
+}
+
+// submit forwards a solution of mirrored work to all upstreams that served the
+// job, racing them and returning as soon as any accepts it. Whether the job was
+// mirrored from any upstream is returned too.
+func (p *upstreamPool) submit(nonce types.BlockNonce, hash, digest common.Hash, extraNonce []byte) (accepted bool, known bool) {
+	p.lock.Lock()
+	job, ok := p.jobs[hash]
+	var sources []int
+	if ok {
+		for index := range job.sources {
+			sources = append(sources, index)
+		}
+	}
+	p.lock.Unlock()
+
+	if !ok {
+		return false, false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), upstreamTimeout)
+	defer cancel()
+
+	args := []interface{}{nonce, hash, digest}
+	if extraNonce != nil {
+		args = append(args, hexutil.Encode(extraNonce))
+	}
+	results := make(chan bool, len(sources))
+	for _, index := range sources {
+		go func(index int) {
+			var ok bool
+			if err := p.clients[index].CallContext(ctx, &ok, "eth_submitWork", args...); err != nil {
+				p.ethash.config.Log.Debug("Failed to submit upstream solution", "upstream", p.urls[index], "err", err)
+			}
+			results <- ok
+		}(index)
+	}
+	for range sources {
+		if <-results {
+			return true, true
+		}
+	}
+	return false, true
+}
@@ -0,0 +1,104 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package ethash
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// fakeUpstream is an upstream node serving a fixed work package.
+type fakeUpstream struct {
+	work    []string
+	accept  bool
+	submits atomic.Int32
+}
+
+func (u *fakeUpstream) GetWork() ([]string, error) { return u.work, nil }
+
+func (u *fakeUpstream) SubmitWork(nonce types.BlockNonce, hash, digest common.Hash, extraNonce *string) bool {
+	u.submits.Add(1)
+	return u.accept
+}
+
+// startFakeUpstream serves the given upstream over HTTP.
+func startFakeUpstream(t *testing.T, upstream *fakeUpstream) string {
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", upstream); err != nil {
+		t.Fatalf("failed to register upstream: %v", err)
+	}
+	http := httptest.NewServer(server)
+	t.Cleanup(func() {
+		http.Close()
+		server.Stop()
+	})
+	return http.URL
+}
+
+// Tests that work is mirrored from upstream nodes without local work, and that
+// solutions are raced to all upstreams serving the job.
+func TestUpstreamFailover(t *testing.T) {
+	work := []string{common.Hash{0x01}.Hex(), common.Hash{0x02}.Hex(), common.Hash{0x03}.Hex(), "0x1"}
+	var (
+		rejecting = &fakeUpstream{work: work}
+		accepting = &fakeUpstream{work: work, accept: true}
+	)
+	ethash := New(Config{PowMode: ModeTest, Upstreams: []string{startFakeUpstream(t, rejecting), startFakeUpstream(t, accepting)}}, nil, true)
+	defer ethash.Close()
+	api := &API{ethash: ethash}
+
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		ethash.upstreams.lock.Lock()
+		job := ethash.upstreams.jobs[common.Hash{0x01}]
+		sources := 0
+		if job != nil {
+			sources = len(job.sources)
+		}
+		ethash.upstreams.lock.Unlock()
+		if sources == 2 {
+			break
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("job not mirrored from both upstreams: %d sources", sources)
+		}
+	}
+	mirrored, err := api.GetWork()
+	if err != nil {
+		t.Fatalf("failed to get mirrored work: %v", err)
+	}
+	for i := range work {
+		if mirrored[i] != work[i] {
+			t.Errorf("work item %d mismatch: have %s, want %s", i, mirrored[i], work[i])
+		}
+	}
+	if !api.SubmitWork(context.Background(), types.BlockNonce{}, common.Hash{0x01}, common.Hash{}, nil, nil, nil) {
+		t.Fatal("solution not accepted by any upstream")
+	}
+	if accepting.submits.Load() != 1 {
+		t.Errorf("accepting upstream submissions mismatch: have %d, want 1", accepting.submits.Load())
+	}
+	// Solutions of work not mirrored are left to the local sealer
+	if api.SubmitWork(context.Background(), types.BlockNonce{}, common.Hash{0x09}, common.Hash{}, nil, nil, nil) {
+		t.Error("solution of unknown work accepted")
+	}
+	if total := rejecting.submits.Load() + accepting.submits.Load(); total > 2 {
+		t.Errorf("unknown work forwarded upstream: %d submissions", total)
+	}
+}
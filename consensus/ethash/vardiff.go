@@ -0,0 +1,141 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package ethash
+
+import (
+	"math/big"
+	"sync"
+	"time"
+)
+
+const (
+	// vardiffRetargetShares is the number of shares after which the share
+	// difficulty is retuned, or the number of share intervals without them.
+	vardiffRetargetShares = 8
+
+	// vardiffMaxAdjust is the maximum factor the share difficulty is changed by
+	// in a single retune.
+	vardiffMaxAdjust = 4
+
+	// defaultShareTargetTime is the share interval vardiff aims for if not
+	// configured otherwise.
+	defaultShareTargetTime = 10 * time.Second
+)
+
+// maxTarget is the target of the lowest possible difficulty.
+var maxTarget = new(big.Int).Sub(two256, big.NewInt(1))
+
+// difficultyTarget returns the boundary condition 2^256/difficulty, capped to
+// fit into 256 bits.
+func difficultyTarget(difficulty *big.Int) *big.Int {
+	if difficulty.Cmp(big.NewInt(1)) <= 0 {
+		return maxTarget
+	}
+	return new(big.Int).Div(two256, difficulty)
+}
+
+// vardiff retunes the share difficulty of a single stratum connection, for its
+// miner to submit a share about every target interval regardless of its hash
+// rate.
+type vardiff struct {
+	target time.Duration // Desired interval between shares
+	min    *big.Int      // Minimum share difficulty
+
+	lock     sync.Mutex
+	current  *big.Int  // Share difficulty of new jobs
+	previous *big.Int  // Share difficulty before the last retune
+	shares   int       // Shares since the last retune
+	since    time.Time // Time of the last retune
+}
+
+// newVardiff creates a controller starting out at the minimum difficulty.
+func newVardiff(min *big.Int, target time.Duration, now time.Time) *vardiff {
+	return &vardiff{
+		target:   target,
+		min:      min,
+		current:  min,
+		previous: min,
+		since:    now,
+	}
+}
+
+// difficulty returns the share difficulty of new jobs.
+func (v *vardiff) difficulty() *big.Int {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	return v.current
+}
+
+// accepted returns the lowest share difficulty accepted. Shares for jobs sent
+// before the last retune are accepted at their former difficulty.
+func (v *vardiff) accepted() *big.Int {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if v.previous.Cmp(v.current) < 0 {
+		return v.previous
+	}
+	return v.current
+}
+
+// record accounts a share and retunes the difficulty if due, returning whether
+// it changed.
+func (v *vardiff) record(now time.Time) bool {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	v.shares++
+	return v.retune(now)
+}
+
+// check retunes the difficulty if the miner went quiet, returning whether it
+// changed.
+func (v *vardiff) check(now time.Time) bool {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	return v.retune(now)
+}
+
+// retune scales the difficulty by the ratio of the target interval to the share
+// interval observed since the last retune. The lock must be held.
+func (v *vardiff) retune(now time.Time) bool {
+	elapsed := now.Sub(v.since)
+	if v.shares < vardiffRetargetShares && elapsed < vardiffRetargetShares*v.target {
+		return false
+	}
+	// difficulty * shares * target / elapsed, bounded by the maximum adjustment
+	next := new(big.Int).Mul(v.current, big.NewInt(int64(v.shares)))
+	next.Mul(next, big.NewInt(int64(v.target)))
+	if elapsed > 0 {
+		next.Div(next, big.NewInt(int64(elapsed)))
+	} else {
+		next.Mul(v.current, big.NewInt(vardiffMaxAdjust))
+	}
+	if max := new(big.Int).Mul(v.current, big.NewInt(vardiffMaxAdjust)); next.Cmp(max) > 0 {
+		next = max
+	}
+	if min := new(big.Int).Div(v.current, big.NewInt(vardiffMaxAdjust)); next.Cmp(min) < 0 {
+		next = min
+	}
+	if next.Cmp(v.min) < 0 {
+		next = v.min
+	}
+	v.shares, v.since = 0, now
+	if next.Cmp(v.current) == 0 {
+		return false
+	}
+	v.previous, v.current = v.current, next
+	return true
+}
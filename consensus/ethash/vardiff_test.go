@@ -0,0 +1,72 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package ethash
+
+import (
+	"math/big"
+	"testing"
+	"time"
+)
+
+// Tests that the share difficulty follows the share rate of the miner.
+func TestVardiffRetune(t *testing.T) {
+	var (
+		start = time.Unix(1000, 0)
+		min   = big.NewInt(1000)
+		v     = newVardiff(min, 10*time.Second, start)
+	)
+	// Shares twice as fast as the target double the difficulty
+	now := start
+	for i := 1; i < vardiffRetargetShares; i++ {
+		now = now.Add(5 * time.Second)
+		if v.record(now) {
+			t.Fatalf("share %d: retuned before enough shares", i)
+		}
+	}
+	now = now.Add(5 * time.Second)
+	if !v.record(now) || v.difficulty().Cmp(big.NewInt(2000)) != 0 {
+		t.Fatalf("fast shares: difficulty mismatch: have %v, want 2000", v.difficulty())
+	}
+	// In-flight shares of the former difficulty are still accepted
+	if v.accepted().Cmp(min) != 0 {
+		t.Errorf("accepted difficulty mismatch: have %v, want %v", v.accepted(), min)
+	}
+	// A burst of shares is bounded by the maximum adjustment
+	for i := 0; i < vardiffRetargetShares; i++ {
+		v.record(now)
+	}
+	if v.difficulty().Cmp(big.NewInt(8000)) != 0 {
+		t.Fatalf("burst: difficulty mismatch: have %v, want 8000", v.difficulty())
+	}
+	// A quiet miner is lowered on check, but not below the minimum
+	if v.check(now.Add(time.Second)) {
+		t.Fatalf("retuned without shares before the retarget window")
+	}
+	for i, want := range []int64{2000, 1000, 1000} {
+		now = now.Add(vardiffRetargetShares * 10 * time.Second)
+		v.check(now)
+		if v.difficulty().Cmp(big.NewInt(want)) != 0 {
+			t.Fatalf("idle %d: difficulty mismatch: have %v, want %d", i, v.difficulty(), want)
+		}
+	}
+}
+
+// Tests that share targets of trivial difficulties fit into 256 bits.
+func TestDifficultyTarget(t *testing.T) {
+	if target := difficultyTarget(big.NewInt(1)); target.BitLen() != 256 {
+		t.Errorf("difficulty 1 target mismatch: have %x", target)
+	}
+	if target := difficultyTarget(big.NewInt(2)); target.Cmp(new(big.Int).Lsh(big.NewInt(1), 255)) != 0 {
+		t.Errorf("difficulty 2 target mismatch: have %x", target)
+	}
+}
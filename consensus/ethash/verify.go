@@ -0,0 +1,132 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package ethash
+
+import (
+	"errors"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// maxVerifyWorkers and maxVerifyBatchSize bound the manually configured
+	// concurrency of batch header verification.
+	maxVerifyWorkers   = 1024
+	maxVerifyBatchSize = 4096
+
+	// verifyBatchesPerWorker is the number of tasks an auto-tuned batch size
+	// splits the headers into per worker, keeping the workers balanced while
+	// cutting the dispatch overhead of long header chains.
+	verifyBatchesPerWorker = 4
+
+	// maxAutoVerifyBatchSize caps the auto-tuned batch size, so results keep
+	// streaming back in order without waiting on a long task.
+	maxAutoVerifyBatchSize = 64
+
+	// sealingActiveWindow is how long after the last work package made for
+	// remote miners sealing is considered active.
+	sealingActiveWindow = 30 * time.Second
+)
+
+var (
+	errInvalidVerifyWorkers   = errors.New("verify workers out of range")
+	errInvalidVerifyBatchSize = errors.New("verify batch size out of range")
+)
+
+// verifySettings holds the concurrency of batch header verification, zero
+// values are auto-tuned.
+type verifySettings struct {
+	workers atomic.Int32
+	batch   atomic.Int32
+}
+
+// VerifyConcurrency is the concurrency of batch header verification.
+type VerifyConcurrency struct {
+	Workers   int `json:"workers"`   // Configured number of workers, 0 if auto-tuned
+	BatchSize int `json:"batchSize"` // Configured headers per task, 0 if auto-tuned
+
+	EffectiveWorkers int  `json:"effectiveWorkers"` // Workers a batch verification would use now
+	Cores            int  `json:"cores"`            // Cores available to the process
+	MiningThreads    int  `json:"miningThreads"`    // Local mining threads currently searching
+	RemoteSealing    bool `json:"remoteSealing"`    // Whether work is being served to remote miners
+}
+
+// SetVerifyConcurrency sets the number of workers verifying batches of headers
+// and the number of consecutive headers handed to a worker at once, taking
+// effect from the next batch. Zero values auto-tune the setting.
+func (ethash *Ethash) SetVerifyConcurrency(workers, batch int) error {
+	if workers < 0 || workers > maxVerifyWorkers {
+		return errInvalidVerifyWorkers
+	}
+	if batch < 0 || batch > maxVerifyBatchSize {
+		return errInvalidVerifyBatchSize
+	}
+	ethash.verifySettings.workers.Store(int32(workers))
+	ethash.verifySettings.batch.Store(int32(batch))
+	return nil
+}
+
+// VerifyConcurrency returns the configured and current effective concurrency
+// of batch header verification.
+func (ethash *Ethash) VerifyConcurrency() VerifyConcurrency {
+	return VerifyConcurrency{
+		Workers:          int(ethash.verifySettings.workers.Load()),
+		BatchSize:        int(ethash.verifySettings.batch.Load()),
+		EffectiveWorkers: ethash.verifyWorkers(),
+		Cores:            runtime.GOMAXPROCS(0),
+		MiningThreads:    int(ethash.localStats.running.Load()),
+		RemoteSealing:    ethash.remoteSealing(),
+	}
+}
+
+// verifyWorkers returns the number of workers to verify a batch of headers
+// with. Unless configured, all cores are used apart from the ones taken by
+// local mining threads, and one more is left to the remote sealer while it
+// serves work, so that syncing doesn't hold up sealing.
+func (ethash *Ethash) verifyWorkers() int {
+	if workers := ethash.verifySettings.workers.Load(); workers > 0 {
+		return int(workers)
+	}
+	workers := runtime.GOMAXPROCS(0) - int(ethash.localStats.running.Load())
+	if ethash.remoteSealing() {
+		workers--
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	return workers
+}
+
+// verifyBatchSize returns the number of consecutive headers handed to a worker
+// at once when verifying the given number of headers with the given workers.
+func (ethash *Ethash) verifyBatchSize(headers, workers int) int {
+	if batch := ethash.verifySettings.batch.Load(); batch > 0 {
+		return int(batch)
+	}
+	batch := headers / (workers * verifyBatchesPerWorker)
+	if batch > maxAutoVerifyBatchSize {
+		batch = maxAutoVerifyBatchSize
+	}
+	if batch < 1 {
+		batch = 1
+	}
+	return batch
+}
+
+// remoteSealing reports whether a work package was recently made for remote
+// miners.
+func (ethash *Ethash) remoteSealing() bool {
+	last := ethash.remoteWork.Load()
+	return last != 0 && time.Since(time.Unix(0, last)) < sealingActiveWindow
+}
@@ -125,3 +125,26 @@ func TestCalcBaseFee(t *testing.T) {
 		}
 	}
 }
+
+// TestCalcBaseFeeCustomMarket checks the base fee follows the fee market
+// parameters in effect at the block it's calculated for.
+func TestCalcBaseFeeCustomMarket(t *testing.T) {
+	cfg := config()
+	cfg.FeeMarket = []*params.FeeMarketConfig{{Block: big.NewInt(33), ElasticityMultiplier: 4, BaseFeeChangeDenominator: 16}}
+
+	parent := &types.Header{
+		Number:   common.Big32,
+		GasLimit: 20000000,
+		GasUsed:  10000000,
+		BaseFee:  big.NewInt(params.InitialBaseFee),
+	}
+	// Gas target is a quarter of the limit, usage is double of it
+	if have, want := CalcBaseFee(cfg, parent), big.NewInt(1062500000); have.Cmp(want) != 0 {
+		t.Errorf("have %d, want %d", have, want)
+	}
+	// Before the change, the defaults are in effect
+	parent.Number = big.NewInt(31)
+	if have, want := CalcBaseFee(cfg, parent), big.NewInt(params.InitialBaseFee); have.Cmp(want) != 0 {
+		t.Errorf("have %d, want %d", have, want)
+	}
+}
@@ -14,6 +14,7 @@
 package core
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -93,6 +94,9 @@ const (
 	maxFutureBlocks     = 256
 	maxTimeFutureBlocks = 30
 	TriesInMemory       = 128
+	reorgHistoryLimit   = 128
+
+	reloadHeadEventLimit = 1024 // Maximum number of blocks announced when reloading the head
 
 	// BlockChainVersion ensures that an incompatible database forces a resync from scratch.
 	//
@@ -129,6 +133,8 @@ type CacheConfig struct {
 	TrieCleanNoPrefetch bool          // Whether to disable heuristic state prefetching for followup blocks
 	TrieDirtyLimit      int           // Memory limit (MB) at which to start flushing dirty trie nodes to disk
 	TrieDirtyDisabled   bool          // Whether to disable trie write caching and GC altogether (archive node)
+	StateHistory        uint64        // Number of recent blocks to retain the state of, TriesInMemory if lower
+	FinalityDepth       uint64        // Number of blocks below the head considered final and never reorged, zero to disable
 	TrieTimeLimit       time.Duration // Time limit after which to flush the current in-memory trie to disk
 	SnapshotLimit       int           // Memory allowance (MB) to use for caching snapshot entries in memory
 	Preimages           bool          // Whether to store preimage of trie key to the disk
@@ -162,8 +168,7 @@ var defaultCacheConfig = &CacheConfig{
 // included in the canonical one where as GetBlockByNumber always represents the
 // canonical chain.
 type BlockChain struct {
-	chainConfig *params.ChainConfig // Chain & network configuration
-	cacheConfig *CacheConfig        // Cache configuration for pruning
+	cacheConfig *CacheConfig // Cache configuration for pruning
 
 	db            ethdb.Database                   // Low level persistent database to store final content in
 	snaps         *snapshot.Tree                   // Snapshot tree for fast trie leaf access
@@ -171,6 +176,7 @@ type BlockChain struct {
 	gcproc        time.Duration                    // Accumulates canonical block processing for trie dumping
 	lastWrite     uint64                           // Last block when the state was flushed
 	flushInterval atomic.Int64                     // Time interval (processing time) after which to flush a state
+	finalityDepth atomic.Uint64                    // Number of blocks below the head never reorged, zero to disable
 	triedb        *trie.Database                   // The database handler for maintaining trie nodes.
 	stateCache    state.Database                   // State database to reuse between imports (contains state cache)
 
@@ -188,9 +194,13 @@ type BlockChain struct {
 	chainHeadFeed event.Feed
 	logsFeed      event.Feed
 	blockProcFeed event.Feed
+	reorgFeed     event.Feed
 	scope         event.SubscriptionScope
 	genesisBlock  *types.Block
 
+	reorgsLock sync.Mutex   // Protects the reorg history
+	reorgs     []ReorgEvent // Recent reorgs of the canonical chain, oldest first
+
 	// This mutex synchronizes chain write operations.
 	// Readers don't need to take it, they can just read the database.
 	chainmu *syncx.ClosableMutex
@@ -251,7 +261,6 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 	log.Info("")
 
 	bc := &BlockChain{
-		chainConfig:   chainConfig,
 		cacheConfig:   cacheConfig,
 		db:            db,
 		triedb:        triedb,
@@ -268,11 +277,12 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 		vmConfig:      vmConfig,
 	}
 	bc.flushInterval.Store(int64(cacheConfig.TrieTimeLimit))
+	bc.finalityDepth.Store(cacheConfig.FinalityDepth)
 	bc.forker = NewForkChoice(bc, shouldPreserve)
 	bc.stateCache = state.NewDatabaseWithNodeDB(bc.db, bc.triedb)
-	bc.validator = NewBlockValidator(chainConfig, bc, engine)
-	bc.prefetcher = newStatePrefetcher(chainConfig, bc, engine)
-	bc.processor = NewStateProcessor(chainConfig, bc, engine)
+	bc.validator = NewBlockValidator(bc, engine)
+	bc.prefetcher = newStatePrefetcher(bc, engine)
+	bc.processor = NewStateProcessor(bc, engine)
 
 	var err error
 	bc.hc, err = NewHeaderChain(db, chainConfig, engine, bc.insertStopped)
@@ -364,7 +374,11 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 	bc.engine.VerifyHeader(bc, bc.CurrentHeader(), true)
 
 	// Check the current state of the block hashes and make sure that we do not have any of the bad blocks in our chain
+	banned := bc.hc.BannedHashes()
 	for hash := range BadHashes {
+		banned = append(banned, hash)
+	}
+	for _, hash := range banned {
 		if header := bc.GetHeaderByHash(hash); header != nil {
 			// get the canonical block corresponding to the offending header's number
 			headerByNumber := bc.GetHeaderByNumber(header.Number.Uint64())
@@ -427,6 +441,10 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 		}
 		rawdb.WriteChainConfig(db, genesisHash, chainConfig)
 	}
+	// Derive the finalized block from the head if soft finality is enabled
+	if bc.cacheConfig.FinalityDepth > 0 {
+		bc.updateFinality(bc.CurrentBlock())
+	}
 	// Start tx indexer/unindexer if required.
 	if txLookupLimit != nil {
 		bc.txLookupLimit = *txLookupLimit
@@ -529,6 +547,78 @@ func (bc *BlockChain) loadLastState() error {
 	return nil
 }
 
+// ReloadHead re-reads the head markers of a chain database written by another
+// process, as done by nodes sharing a data directory read-only. Blocks extending
+// the previous head are announced in order, whereas on deeper changes only the
+// new head block is.
+func (bc *BlockChain) ReloadHead() error {
+	if !bc.chainmu.TryLock() {
+		return errChainStopped
+	}
+	defer bc.chainmu.Unlock()
+
+	hash := rawdb.ReadHeadBlockHash(bc.db)
+	if hash == (common.Hash{}) {
+		return errors.New("head block marker missing")
+	}
+	current := bc.CurrentBlock()
+	if hash == current.Hash() {
+		return nil
+	}
+	head := bc.GetBlockByHash(hash)
+	if head == nil {
+		return fmt.Errorf("head block %x missing", hash)
+	}
+	// Gather the blocks added on top of the previous head, if any
+	var blocks []*types.Block
+	for block := head; block != nil && len(blocks) < reloadHeadEventLimit; block = bc.GetBlock(block.ParentHash(), block.NumberU64()-1) {
+		blocks = append(blocks, block)
+		if block.ParentHash() == current.Hash() {
+			break
+		}
+	}
+	if last := blocks[len(blocks)-1]; last.ParentHash() != current.Hash() {
+		blocks = blocks[:1]
+	}
+	// Transaction lookups may have been invalidated by a reorg
+	bc.txLookupCache.Purge()
+
+	headHeader := head.Header()
+	if hash := rawdb.ReadHeadHeaderHash(bc.db); hash != (common.Hash{}) {
+		if header := bc.GetHeaderByHash(hash); header != nil {
+			headHeader = header
+		}
+	}
+	bc.hc.SetCurrentHeader(headHeader)
+	bc.currentBlock.Store(head.Header())
+	headBlockGauge.Update(int64(head.NumberU64()))
+
+	snapHead := head.Header()
+	if hash := rawdb.ReadHeadFastBlockHash(bc.db); hash != (common.Hash{}) {
+		if header := bc.GetHeaderByHash(hash); header != nil {
+			snapHead = header
+		}
+	}
+	bc.currentSnapBlock.Store(snapHead)
+	headFastBlockGauge.Update(int64(snapHead.Number.Uint64()))
+
+	if hash := rawdb.ReadFinalizedBlockHash(bc.db); hash != (common.Hash{}) {
+		if header := bc.GetHeaderByHash(hash); header != nil {
+			bc.currentFinalBlock.Store(header)
+			headFinalizedBlockGauge.Update(int64(header.Number.Uint64()))
+		}
+	}
+	for i := len(blocks) - 1; i >= 0; i-- {
+		logs := bc.collectLogs(blocks[i], false)
+		bc.chainFeed.Send(ChainEvent{Block: blocks[i], Hash: blocks[i].Hash(), Logs: logs})
+		if len(logs) > 0 {
+			bc.logsFeed.Send(logs)
+		}
+	}
+	bc.chainHeadFeed.Send(ChainHeadEvent{Block: head})
+	return nil
+}
+
 // SetHead rewinds the local chain to a new head. Depending on whether the node
 // was fast synced or full synced and in which state, the method will try to
 // delete minimal data from disk whilst retaining chain consistency.
@@ -902,6 +992,100 @@ func (bc *BlockChain) writeHeadBlock(block *types.Block) {
 
 	bc.currentBlock.Store(block.Header())
 	headBlockGauge.Update(int64(block.NumberU64()))
+
+	if bc.finalityDepth.Load() > 0 {
+		bc.updateFinality(block.Header())
+	}
+}
+
+// updateFinality marks the canonical block the finality depth below the given
+// head as both safe and finalized. A higher canonical finalized block is kept,
+// the finalized block only moves back if a forced reorg dropped it.
+func (bc *BlockChain) updateFinality(head *types.Header) {
+	var (
+		depth  = bc.finalityDepth.Load()
+		number uint64
+	)
+	if head.Number.Uint64() > depth {
+		number = head.Number.Uint64() - depth
+	}
+	current := bc.CurrentFinalBlock()
+	if current != nil && current.Number.Uint64() >= number && bc.GetCanonicalHash(current.Number.Uint64()) == current.Hash() {
+		return
+	}
+	final := bc.GetHeaderByNumber(number)
+	if final == nil {
+		return
+	}
+	bc.SetFinalized(final)
+	bc.SetSafe(final)
+}
+
+// ApplyForkSchedule sets the forks of a schedule in the chain config, persisting
+// them along with the forks of the previously applied schedules to be reapplied
+// on restarts. The schedule has to be made for the genesis of the chain and may
+// not change forks already active.
+//
+// The chain config is replaced, not modified, so components retrieving it from
+// the chain on use pick up the new forks. Components holding their own copy, like
+// the transaction pool, have to be handed the returned config.
+func (bc *BlockChain) ApplyForkSchedule(schedule *ForkSchedule) (*params.ChainConfig, error) {
+	if !bc.chainmu.TryLock() {
+		return nil, errChainStopped
+	}
+	defer bc.chainmu.Unlock()
+
+	genesis := bc.genesisBlock.Hash()
+	if blob := rawdb.ReadForkSchedule(bc.db); len(blob) > 0 {
+		if stored, err := DecodeForkSchedule(blob); err != nil {
+			log.Error("Invalid stored fork schedule", "err", err)
+		} else if stored.Genesis == genesis {
+			schedule = schedule.merge(stored)
+		}
+	}
+	head := bc.CurrentBlock()
+	updated, err := schedule.Apply(bc.Config(), genesis, head.Number.Uint64(), head.Time)
+	if err != nil {
+		return nil, err
+	}
+	blob, err := json.Marshal(schedule)
+	if err != nil {
+		return nil, err
+	}
+	batch := bc.db.NewBatch()
+	rawdb.WriteForkSchedule(batch, blob)
+	rawdb.WriteChainConfig(batch, genesis, updated)
+	if err := batch.Write(); err != nil {
+		return nil, err
+	}
+	bc.hc.config.Store(updated)
+	log.Info("Applied fork schedule", "forks", len(schedule.Forks))
+	for _, line := range strings.Split(updated.Description(), "\n") {
+		log.Info(line)
+	}
+	return updated, nil
+}
+
+// FinalityDepth returns the number of blocks below the head considered final,
+// zero if soft finality is disabled.
+func (bc *BlockChain) FinalityDepth() uint64 {
+	return bc.finalityDepth.Load()
+}
+
+// SetFinalityDepth changes the number of blocks below the head considered
+// final, zero disabling soft finality. Lowering the depth advances the finalized
+// block right away, raising it never moves the finalized block back.
+func (bc *BlockChain) SetFinalityDepth(depth uint64) error {
+	if !bc.chainmu.TryLock() {
+		return errChainStopped
+	}
+	defer bc.chainmu.Unlock()
+
+	bc.finalityDepth.Store(depth)
+	if depth > 0 {
+		bc.updateFinality(bc.CurrentBlock())
+	}
+	return nil
 }
 
 // stopWithoutSaving stops the blockchain service. If any imports are currently in progress
@@ -1318,7 +1502,7 @@ func (bc *BlockChain) writeBlockWithoutState(block *types.Block, td *big.Int) (e
 func (bc *BlockChain) writeKnownBlock(block *types.Block) error {
 	current := bc.CurrentBlock()
 	if block.ParentHash() != current.Hash() {
-		if err := bc.reorg(current, block); err != nil {
+		if err := bc.reorg(current, block, false); err != nil {
 			return err
 		}
 	}
@@ -1350,7 +1534,7 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 		log.Crit("Failed to write block into disk", "err", err)
 	}
 	// Commit all cached state changes into underlying memory database.
-	root, err := state.Commit(bc.chainConfig.IsEIP158(block.Number()))
+	root, err := state.Commit(bc.Config().IsEIP158(block.Number()))
 	if err != nil {
 		return err
 	}
@@ -1362,9 +1546,12 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	bc.triedb.Reference(root, common.Hash{}) // metadata reference to keep trie alive
 	bc.triegc.Push(root, -int64(block.NumberU64()))
 
-	current := block.NumberU64()
-	// Flush limits are not considered for the first TriesInMemory blocks.
-	if current <= TriesInMemory {
+	var (
+		current   = block.NumberU64()
+		retention = bc.StateRetention()
+	)
+	// Flush limits are not considered for the first retained blocks.
+	if current <= retention {
 		return nil
 	}
 	// If we exceeded our memory allowance, flush matured singleton nodes to disk
@@ -1376,7 +1563,7 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 		bc.triedb.Cap(limit - ethdb.IdealBatchSize)
 	}
 	// Find the next state trie we need to commit
-	chosen := current - TriesInMemory
+	chosen := current - retention
 	flushInterval := time.Duration(bc.flushInterval.Load())
 	// If we exceeded time allowance, flush an entire trie to disk
 	if bc.gcproc > flushInterval {
@@ -1388,8 +1575,8 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 		} else {
 			// If we're exceeding limits but haven't reached a large enough memory gap,
 			// warn the user that the system is becoming unstable.
-			if chosen < bc.lastWrite+TriesInMemory && bc.gcproc >= 2*flushInterval {
-				log.Info("State in memory for too long, committing", "time", bc.gcproc, "allowance", flushInterval, "optimum", float64(chosen-bc.lastWrite)/TriesInMemory)
+			if chosen < bc.lastWrite+retention && bc.gcproc >= 2*flushInterval {
+				log.Info("State in memory for too long, committing", "time", bc.gcproc, "allowance", flushInterval, "optimum", float64(chosen-bc.lastWrite)/float64(retention))
 			}
 			// Flush an entire trie and restart the counters
 			bc.triedb.Commit(header.Root, true)
@@ -1409,6 +1596,16 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	return nil
 }
 
+// StateRetention returns the number of recent blocks whose state tries are kept
+// referenced in the trie database. Tries of retained blocks exceeding the dirty
+// cache allowance are flushed to disk instead of being garbage collected.
+func (bc *BlockChain) StateRetention() uint64 {
+	if bc.cacheConfig.StateHistory > TriesInMemory {
+		return bc.cacheConfig.StateHistory
+	}
+	return TriesInMemory
+}
+
 // WriteBlockAndSetHead writes the given block and all associated state to the database,
 // and applies the block as the new chain head.
 func (bc *BlockChain) WriteBlockAndSetHead(block *types.Block, receipts []*types.Receipt, logs []*types.Log, state *state.StateDB, emitHeadEvent bool) (status WriteStatus, err error) {
@@ -1434,7 +1631,7 @@ func (bc *BlockChain) writeBlockAndSetHead(block *types.Block, receipts []*types
 	if reorg {
 		// Reorganise the chain if the parent is not the head block
 		if block.ParentHash() != currentBlock.Hash() {
-			if err := bc.reorg(currentBlock, block); err != nil {
+			if err := bc.reorg(currentBlock, block, false); err != nil {
 				return NonStatTy, err
 			}
 		}
@@ -1535,8 +1732,15 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals, setHead bool)
 		return 0, nil
 	}
 
+	// Reject banned blocks and any block building on one, side chains included
+	for i, block := range chain {
+		if bc.hc.IsBanned(block.Hash()) || bc.hc.IsBanned(block.ParentHash()) {
+			bc.reportBlock(block, nil, ErrBannedHash)
+			return i, ErrBannedHash
+		}
+	}
 	// Start a parallel signature recovery (signer will fluke on fork transition, minimal perf loss)
-	SenderCacher.RecoverFromBlocks(types.MakeSigner(bc.chainConfig, chain[0].Number()), chain)
+	SenderCacher.RecoverFromBlocks(types.MakeSigner(bc.Config(), chain[0].Number()), chain)
 
 	var (
 		stats     = insertStats{startTime: mclock.Now()}
@@ -1669,7 +1873,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals, setHead bool)
 			break
 		}
 		// If the header is a banned one, straight out abort
-		if BadHashes[block.Hash()] {
+		if bc.hc.IsBanned(block.Hash()) {
 			bc.reportBlock(block, nil, ErrBannedHash)
 			return it.index, ErrBannedHash
 		}
@@ -1681,7 +1885,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals, setHead bool)
 		// snapshot layer is missing, forcibly rerun the execution to build it.
 		if bc.skipBlock(err, it) {
 			logger := log.Debug
-			if bc.chainConfig.Clique == nil {
+			if bc.Config().Clique == nil {
 				logger = log.Warn
 			}
 			logger("Inserted known block", "number", block.Number(), "hash", block.Hash(),
@@ -2045,7 +2249,7 @@ func (bc *BlockChain) recoverAncestors(block *types.Block) (common.Hash, error)
 // the processing of a block. These logs are later announced as deleted or reborn.
 func (bc *BlockChain) collectLogs(b *types.Block, removed bool) []*types.Log {
 	receipts := rawdb.ReadRawReceipts(bc.db, b.Hash(), b.NumberU64())
-	receipts.DeriveFields(bc.chainConfig, b.Hash(), b.NumberU64(), b.BaseFee(), b.Transactions())
+	receipts.DeriveFields(bc.Config(), b.Hash(), b.NumberU64(), b.BaseFee(), b.Transactions())
 
 	var logs []*types.Log
 	for _, receipt := range receipts {
@@ -2065,7 +2269,10 @@ func (bc *BlockChain) collectLogs(b *types.Block, removed bool) []*types.Log {
 // potential missing transactions and post an event about them.
 // Note the new head block won't be processed here, callers need to handle it
 // externally.
-func (bc *BlockChain) reorg(oldHead *types.Header, newHead *types.Block) error {
+//
+// With soft finality enabled, reorgs dropping the finalized block are refused
+// unless forced.
+func (bc *BlockChain) reorg(oldHead *types.Header, newHead *types.Block, force bool) error {
 	var (
 		newChain    types.Blocks
 		oldChain    types.Blocks
@@ -2127,6 +2334,14 @@ func (bc *BlockChain) reorg(oldHead *types.Header, newHead *types.Block) error {
 		}
 	}
 
+	// Refuse dropping blocks below the soft finalized one
+	if !force && bc.finalityDepth.Load() > 0 {
+		if final := bc.CurrentFinalBlock(); final != nil && commonBlock.NumberU64() < final.Number.Uint64() {
+			log.Warn("Refused reorg below finalized block", "number", commonBlock.Number(), "hash", commonBlock.Hash(),
+				"drop", len(oldChain), "finalized", final.Number, "newhead", newHead.Number(), "newhash", newHead.Hash())
+			return ErrFinalizedReorg
+		}
+	}
 	// Ensure the user sees large reorgs
 	if len(oldChain) > 0 && len(newChain) > 0 {
 		logFn := log.Info
@@ -2140,6 +2355,8 @@ func (bc *BlockChain) reorg(oldHead *types.Header, newHead *types.Block) error {
 		blockReorgAddMeter.Mark(int64(len(newChain)))
 		blockReorgDropMeter.Mark(int64(len(oldChain)))
 		blockReorgMeter.Mark(1)
+
+		defer bc.recordReorg(commonBlock, oldChain, newChain)
 	} else if len(newChain) > 0 {
 		// Special case happens in the post merge stage that current head is
 		// the ancestor of new head while these two blocks are not consecutive
@@ -2227,6 +2444,30 @@ func (bc *BlockChain) reorg(oldHead *types.Header, newHead *types.Block) error {
 	return nil
 }
 
+// recordReorg adds a reorg to the history and notifies the subscribers. Both
+// chains are expected in descending order, as collected by reorg.
+func (bc *BlockChain) recordReorg(ancestor *types.Block, oldChain, newChain types.Blocks) {
+	event := ReorgEvent{
+		Common:   ancestor.Header(),
+		OldChain: make([]*types.Header, 0, len(oldChain)),
+		NewChain: make([]*types.Header, 0, len(newChain)),
+	}
+	for i := len(oldChain) - 1; i >= 0; i-- {
+		event.OldChain = append(event.OldChain, oldChain[i].Header())
+	}
+	for i := len(newChain) - 1; i >= 0; i-- {
+		event.NewChain = append(event.NewChain, newChain[i].Header())
+	}
+	bc.reorgsLock.Lock()
+	bc.reorgs = append(bc.reorgs, event)
+	if len(bc.reorgs) > reorgHistoryLimit {
+		bc.reorgs = append(bc.reorgs[:0], bc.reorgs[len(bc.reorgs)-reorgHistoryLimit:]...)
+	}
+	bc.reorgsLock.Unlock()
+
+	bc.reorgFeed.Send(event)
+}
+
 // InsertBlockWithoutSetHead executes the block, runs the necessary verification
 // upon it and then persist the block and the associate state into the database.
 // The key difference between the InsertChain is it won't do the canonical chain
@@ -2251,6 +2492,10 @@ func (bc *BlockChain) SetCanonical(head *types.Block) (common.Hash, error) {
 	}
 	defer bc.chainmu.Unlock()
 
+	// Refuse branches containing banned blocks
+	if bc.bannedBranch(head.Header()) {
+		return common.Hash{}, ErrBannedHash
+	}
 	// Re-execute the reorged chain in case the head state is missing.
 	if !bc.HasState(head.Root()) {
 		if latestValidHash, err := bc.recoverAncestors(head); err != nil {
@@ -2261,7 +2506,7 @@ func (bc *BlockChain) SetCanonical(head *types.Block) (common.Hash, error) {
 	// Run the reorg if necessary and set the given block as new head.
 	start := time.Now()
 	if head.ParentHash() != bc.CurrentBlock().Hash() {
-		if err := bc.reorg(bc.CurrentBlock(), head); err != nil {
+		if err := bc.reorg(bc.CurrentBlock(), head, true); err != nil {
 			return common.Hash{}, err
 		}
 	}
@@ -2288,6 +2533,97 @@ func (bc *BlockChain) SetCanonical(head *types.Block) (common.Hash, error) {
 	return head.Hash(), nil
 }
 
+// bannedBranch reports whether a block or any of its ancestors not part of the
+// canonical chain is banned.
+func (bc *BlockChain) bannedBranch(header *types.Header) bool {
+	for header != nil {
+		if bc.hc.IsBanned(header.Hash()) {
+			return true
+		}
+		number := header.Number.Uint64()
+		if number == 0 || bc.GetCanonicalHash(number) == header.Hash() {
+			return false
+		}
+		header = bc.GetHeader(header.ParentHash, number-1)
+	}
+	return false
+}
+
+// BanBlockHash bans a block, rejecting it and any block building on it from
+// then on, across restarts too. If the block is part of the canonical chain,
+// the chain is rewound to its parent, discarding all blocks above it including
+// side chains: to keep an alternative branch, make it the head by SetCanonical
+// first. Stored blocks descending from the banned one are deleted.
+func (bc *BlockChain) BanBlockHash(hash common.Hash) error {
+	header := bc.GetHeaderByHash(hash)
+	if header != nil && header.Number.Sign() == 0 {
+		return errors.New("cannot ban the genesis block")
+	}
+	bc.hc.SetBanned(hash, true)
+	if header == nil {
+		return nil
+	}
+	number := header.Number.Uint64()
+	if bc.GetCanonicalHash(number) == hash {
+		log.Warn("Rewinding chain past banned block", "number", number, "hash", hash)
+		if err := bc.SetHead(number - 1); err != nil {
+			return err
+		}
+	}
+	// TryLock waits for any running import or rewind, failing only once the
+	// chain is stopped. The ban is persisted by then, only the deletion is lost.
+	if !bc.chainmu.TryLock() {
+		return fmt.Errorf("ban recorded, stored blocks not deleted: %w", errChainStopped)
+	}
+	defer bc.chainmu.Unlock()
+
+	// Delete the banned block and its descendants, height by height
+	var (
+		batch  = bc.db.NewBatch()
+		doomed = map[common.Hash]struct{}{hash: {}}
+	)
+	if rawdb.HasHeader(bc.db, hash, number) {
+		rawdb.DeleteBlock(batch, hash, number)
+	}
+	for n := number + 1; len(doomed) > 0; n++ {
+		children := make(map[common.Hash]struct{})
+		for _, child := range rawdb.ReadAllHashes(bc.db, n) {
+			if header := rawdb.ReadHeader(bc.db, child, n); header != nil {
+				if _, ok := doomed[header.ParentHash]; ok {
+					rawdb.DeleteBlock(batch, child, n)
+					children[child] = struct{}{}
+				}
+			}
+		}
+		for child := range doomed {
+			bc.hc.headerCache.Remove(child)
+			bc.hc.tdCache.Remove(child)
+			bc.hc.numberCache.Remove(child)
+		}
+		doomed = children
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	bc.bodyCache.Purge()
+	bc.bodyRLPCache.Purge()
+	bc.receiptsCache.Purge()
+	bc.blockCache.Purge()
+	bc.futureBlocks.Purge()
+	return nil
+}
+
+// UnbanBlockHash lifts the ban of a block hash. Blocks deleted by the ban have
+// to be imported again.
+func (bc *BlockChain) UnbanBlockHash(hash common.Hash) {
+	bc.hc.SetBanned(hash, false)
+}
+
+// BannedHashes returns the block hashes banned by the operator.
+func (bc *BlockChain) BannedHashes() []common.Hash {
+	return bc.hc.BannedHashes()
+}
+
 func (bc *BlockChain) updateFutureBlocks() {
 	futureTimer := time.NewTicker(5 * time.Second)
 	defer futureTimer.Stop()
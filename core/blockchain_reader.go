@@ -213,7 +213,12 @@ func (bc *BlockChain) GetReceiptsByHash(hash common.Hash) types.Receipts {
 	if number == nil {
 		return nil
 	}
-	receipts := rawdb.ReadReceipts(bc.db, hash, *number, bc.chainConfig)
+	// Receipts of frozen blocks are read straight from the freezer. They're not
+	// cached to avoid a history scan evicting the receipts of recent blocks.
+	if receipts := rawdb.ReadAncientReceipts(bc.db, hash, *number, bc.Config()); receipts != nil {
+		return receipts
+	}
+	receipts := rawdb.ReadReceipts(bc.db, hash, *number, bc.Config())
 	if receipts == nil {
 		return nil
 	}
@@ -320,7 +325,7 @@ func (bc *BlockChain) StateAt(root common.Hash) (*state.StateDB, error) {
 }
 
 // Config retrieves the chain's fork configuration.
-func (bc *BlockChain) Config() *params.ChainConfig { return bc.chainConfig }
+func (bc *BlockChain) Config() *params.ChainConfig { return bc.hc.Config() }
 
 // Engine retrieves the blockchain's consensus engine.
 func (bc *BlockChain) Engine() consensus.Engine { return bc.engine }
@@ -402,6 +407,30 @@ func (bc *BlockChain) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscript
 	return bc.scope.Track(bc.logsFeed.Subscribe(ch))
 }
 
+// SubscribeReorgEvent registers a subscription of ReorgEvent.
+func (bc *BlockChain) SubscribeReorgEvent(ch chan<- ReorgEvent) event.Subscription {
+	return bc.scope.Track(bc.reorgFeed.Subscribe(ch))
+}
+
+// ReorgHistory returns up to the last n reorgs of the canonical chain, the most
+// recent first.
+func (bc *BlockChain) ReorgHistory(n int) []ReorgEvent {
+	bc.reorgsLock.Lock()
+	defer bc.reorgsLock.Unlock()
+
+	if n > len(bc.reorgs) {
+		n = len(bc.reorgs)
+	}
+	if n < 0 {
+		n = 0
+	}
+	history := make([]ReorgEvent, 0, n)
+	for i := len(bc.reorgs) - 1; i >= len(bc.reorgs)-n; i-- {
+		history = append(history, bc.reorgs[i])
+	}
+	return history
+}
+
 // SubscribeBlockProcessingEvent registers a subscription of bool where true means
 // block processing has started while false means it has stopped.
 func (bc *BlockChain) SubscribeBlockProcessingEvent(ch chan<- bool) event.Subscription {
@@ -105,12 +105,12 @@ func testFork(t *testing.T, blockchain *BlockChain, i, n int, full bool, compara
 		headerChainB []*types.Header
 	)
 	if full {
-		blockChainB = makeBlockChain(blockchain2.chainConfig, blockchain2.GetBlockByHash(blockchain2.CurrentBlock().Hash()), n, ethash.NewFaker(), genDb, forkSeed)
+		blockChainB = makeBlockChain(blockchain2.Config(), blockchain2.GetBlockByHash(blockchain2.CurrentBlock().Hash()), n, ethash.NewFaker(), genDb, forkSeed)
 		if _, err := blockchain2.InsertChain(blockChainB); err != nil {
 			t.Fatalf("failed to insert forking chain: %v", err)
 		}
 	} else {
-		headerChainB = makeHeaderChain(blockchain2.chainConfig, blockchain2.CurrentHeader(), n, ethash.NewFaker(), genDb, forkSeed)
+		headerChainB = makeHeaderChain(blockchain2.Config(), blockchain2.CurrentHeader(), n, ethash.NewFaker(), genDb, forkSeed)
 		if _, err := blockchain2.InsertHeaderChain(headerChainB, 1); err != nil {
 			t.Fatalf("failed to insert forking chain: %v", err)
 		}
@@ -202,7 +202,7 @@ func TestLastBlock(t *testing.T) {
 	}
 	defer blockchain.Stop()
 
-	blocks := makeBlockChain(blockchain.chainConfig, blockchain.GetBlockByHash(blockchain.CurrentBlock().Hash()), 1, ethash.NewFullFaker(), genDb, 0)
+	blocks := makeBlockChain(blockchain.Config(), blockchain.GetBlockByHash(blockchain.CurrentBlock().Hash()), 1, ethash.NewFullFaker(), genDb, 0)
 	if _, err := blockchain.InsertChain(blocks); err != nil {
 		t.Fatalf("Failed to insert block: %v", err)
 	}
@@ -236,7 +236,7 @@ func testInsertAfterMerge(t *testing.T, blockchain *BlockChain, i, n int, full b
 
 	// Extend the newly created chain
 	if full {
-		blockChainB := makeBlockChain(blockchain2.chainConfig, blockchain2.GetBlockByHash(blockchain2.CurrentBlock().Hash()), n, ethash.NewFaker(), genDb, forkSeed)
+		blockChainB := makeBlockChain(blockchain2.Config(), blockchain2.GetBlockByHash(blockchain2.CurrentBlock().Hash()), n, ethash.NewFaker(), genDb, forkSeed)
 		if _, err := blockchain2.InsertChain(blockChainB); err != nil {
 			t.Fatalf("failed to insert forking chain: %v", err)
 		}
@@ -247,7 +247,7 @@ func testInsertAfterMerge(t *testing.T, blockchain *BlockChain, i, n int, full b
 			t.Fatalf("failed to reorg to the given chain")
 		}
 	} else {
-		headerChainB := makeHeaderChain(blockchain2.chainConfig, blockchain2.CurrentHeader(), n, ethash.NewFaker(), genDb, forkSeed)
+		headerChainB := makeHeaderChain(blockchain2.Config(), blockchain2.CurrentHeader(), n, ethash.NewFaker(), genDb, forkSeed)
 		if _, err := blockchain2.InsertHeaderChain(headerChainB, 1); err != nil {
 			t.Fatalf("failed to insert forking chain: %v", err)
 		}
@@ -473,12 +473,12 @@ func testBrokenChain(t *testing.T, full bool) {
 
 	// Create a forked chain, and try to insert with a missing link
 	if full {
-		chain := makeBlockChain(blockchain.chainConfig, blockchain.GetBlockByHash(blockchain.CurrentBlock().Hash()), 5, ethash.NewFaker(), genDb, forkSeed)[1:]
+		chain := makeBlockChain(blockchain.Config(), blockchain.GetBlockByHash(blockchain.CurrentBlock().Hash()), 5, ethash.NewFaker(), genDb, forkSeed)[1:]
 		if err := testBlockChainImport(chain, blockchain); err == nil {
 			t.Errorf("broken block chain not reported")
 		}
 	} else {
-		chain := makeHeaderChain(blockchain.chainConfig, blockchain.CurrentHeader(), 5, ethash.NewFaker(), genDb, forkSeed)[1:]
+		chain := makeHeaderChain(blockchain.Config(), blockchain.CurrentHeader(), 5, ethash.NewFaker(), genDb, forkSeed)[1:]
 		if err := testHeaderChainImport(chain, blockchain); err == nil {
 			t.Errorf("broken header chain not reported")
 		}
@@ -597,14 +597,14 @@ func testBadHashes(t *testing.T, full bool) {
 
 	// Create a chain, ban a hash and try to import
 	if full {
-		blocks := makeBlockChain(blockchain.chainConfig, blockchain.GetBlockByHash(blockchain.CurrentBlock().Hash()), 3, ethash.NewFaker(), genDb, 10)
+		blocks := makeBlockChain(blockchain.Config(), blockchain.GetBlockByHash(blockchain.CurrentBlock().Hash()), 3, ethash.NewFaker(), genDb, 10)
 
 		BadHashes[blocks[2].Header().Hash()] = true
 		defer func() { delete(BadHashes, blocks[2].Header().Hash()) }()
 
 		_, err = blockchain.InsertChain(blocks)
 	} else {
-		headers := makeHeaderChain(blockchain.chainConfig, blockchain.CurrentHeader(), 3, ethash.NewFaker(), genDb, 10)
+		headers := makeHeaderChain(blockchain.Config(), blockchain.CurrentHeader(), 3, ethash.NewFaker(), genDb, 10)
 
 		BadHashes[headers[2].Hash()] = true
 		defer func() { delete(BadHashes, headers[2].Hash()) }()
@@ -616,6 +616,164 @@ func testBadHashes(t *testing.T, full bool) {
 	}
 }
 
+// Tests that blocks banned by the operator are unwound, refused on import and
+// as new heads, and stay banned across restarts.
+func TestBanBlockHash(t *testing.T) {
+	var (
+		db     = rawdb.NewMemoryDatabase()
+		gspec  = &Genesis{Config: params.TestChainConfig, BaseFee: big.NewInt(params.InitialBaseFee)}
+		engine = ethash.NewFaker()
+	)
+	genDb, main, _ := GenerateChainWithGenesis(gspec, engine, 5, nil)
+	fork, _ := GenerateChain(gspec.Config, main[1], engine, genDb, 3, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{0x01})
+	})
+	chain, err := NewBlockChain(db, nil, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	if _, err := chain.InsertChain(main); err != nil {
+		t.Fatalf("failed to insert main chain: %v", err)
+	}
+	if _, err := chain.InsertChain(fork); err != nil {
+		t.Fatalf("failed to insert fork: %v", err)
+	}
+	checkHead := func(want *types.Block) {
+		t.Helper()
+		if head := chain.CurrentBlock().Hash(); head != want.Hash() {
+			t.Fatalf("head mismatch: have %x, want %x", head, want.Hash())
+		}
+	}
+	// Banning a side chain block deletes it and its descendants
+	if err := chain.BanBlockHash(fork[1].Hash()); err != nil {
+		t.Fatalf("failed to ban side block: %v", err)
+	}
+	if chain.HasBlock(fork[1].Hash(), 4) || chain.HasBlock(fork[2].Hash(), 5) || !chain.HasBlock(fork[0].Hash(), 3) {
+		t.Fatalf("side chain not pruned at the banned block")
+	}
+	checkHead(main[4])
+
+	// Moving the head to an alternative branch, then banning the old one
+	if _, err := chain.SetCanonical(fork[0]); err != nil {
+		t.Fatalf("failed to set alternative head: %v", err)
+	}
+	checkHead(fork[0])
+	if err := chain.BanBlockHash(main[2].Hash()); err != nil {
+		t.Fatalf("failed to ban old branch: %v", err)
+	}
+	checkHead(fork[0])
+	if chain.HasBlock(main[4].Hash(), 5) {
+		t.Fatalf("banned branch not pruned")
+	}
+	if _, err := chain.InsertChain(main[2:]); !errors.Is(err, ErrBannedHash) {
+		t.Fatalf("banned block import error mismatch: have %v, want %v", err, ErrBannedHash)
+	}
+	if _, err := chain.InsertChain(main[3:]); !errors.Is(err, ErrBannedHash) {
+		t.Fatalf("banned descendant import error mismatch: have %v, want %v", err, ErrBannedHash)
+	}
+	chain.Stop()
+
+	// The bans survive a restart
+	chain, err = NewBlockChain(db, nil, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to reopen chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if banned := chain.BannedHashes(); len(banned) != 2 || !chain.hc.IsBanned(main[2].Hash()) || !chain.hc.IsBanned(fork[1].Hash()) {
+		t.Fatalf("banned hashes mismatch: have %x", banned)
+	}
+	checkHead(fork[0])
+
+	// Banning a canonical block rewinds the chain to its parent
+	if err := chain.BanBlockHash(fork[0].Hash()); err != nil {
+		t.Fatalf("failed to ban canonical block: %v", err)
+	}
+	checkHead(main[1])
+	if _, err := chain.SetCanonical(fork[0]); !errors.Is(err, ErrBannedHash) {
+		t.Fatalf("banned head error mismatch: have %v, want %v", err, ErrBannedHash)
+	}
+	// Lifting the ban lets the blocks back in
+	chain.UnbanBlockHash(main[2].Hash())
+	if _, err := chain.InsertChain(main[2:]); err != nil {
+		t.Fatalf("failed to import unbanned blocks: %v", err)
+	}
+	checkHead(main[4])
+}
+
+// Tests that reorgs below the soft finalized block are refused unless forced.
+func TestSoftFinality(t *testing.T) {
+	var (
+		gspec  = &Genesis{Config: params.TestChainConfig, BaseFee: big.NewInt(params.InitialBaseFee)}
+		engine = ethash.NewFaker()
+	)
+	genDb, main, _ := GenerateChainWithGenesis(gspec, engine, 6, nil)
+	deep, _ := GenerateChain(gspec.Config, main[1], engine, genDb, 8, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{0x01})
+	})
+	shallow, _ := GenerateChain(gspec.Config, main[3], engine, genDb, 4, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{0x02})
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), &CacheConfig{
+		TrieCleanLimit: 256,
+		TrieDirtyLimit: 256,
+		TrieTimeLimit:  5 * time.Minute,
+		SnapshotLimit:  256,
+		SnapshotWait:   true,
+		FinalityDepth:  2,
+	}, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	checkFinal := func(head *types.Block, final uint64) {
+		t.Helper()
+		if have := chain.CurrentBlock().Hash(); have != head.Hash() {
+			t.Fatalf("head mismatch: have %x, want %x", have, head.Hash())
+		}
+		if have := chain.CurrentFinalBlock(); have == nil || have.Number.Uint64() != final || have.Hash() != chain.GetCanonicalHash(final) {
+			t.Fatalf("finalized block mismatch: have %v, want #%d", have, final)
+		}
+		if safe := chain.CurrentSafeBlock(); safe == nil || safe.Hash() != chain.CurrentFinalBlock().Hash() {
+			t.Fatalf("safe block mismatch: have %v", safe)
+		}
+	}
+	if _, err := chain.InsertChain(main); err != nil {
+		t.Fatalf("failed to insert main chain: %v", err)
+	}
+	checkFinal(main[5], 4)
+
+	// A heavier branch forking below the finalized block is refused
+	n, err := chain.InsertChain(deep)
+	if !errors.Is(err, ErrFinalizedReorg) {
+		t.Fatalf("deep reorg error mismatch: have %v, want %v", err, ErrFinalizedReorg)
+	}
+	checkFinal(main[5], 4)
+
+	// Forking at the finalized block is fine
+	if _, err := chain.InsertChain(shallow); err != nil {
+		t.Fatalf("failed to insert shallow fork: %v", err)
+	}
+	checkFinal(shallow[3], 6)
+
+	// Raising the depth keeps the finalized block, lowering advances it
+	if err := chain.SetFinalityDepth(4); err != nil {
+		t.Fatalf("failed to raise finality depth: %v", err)
+	}
+	checkFinal(shallow[3], 6)
+	if err := chain.SetFinalityDepth(1); err != nil {
+		t.Fatalf("failed to lower finality depth: %v", err)
+	}
+	checkFinal(shallow[3], 7)
+
+	// The operator may still force the deep branch, up to the refused block
+	if _, err := chain.SetCanonical(deep[n]); err != nil {
+		t.Fatalf("failed to force deep branch: %v", err)
+	}
+	checkFinal(deep[n], deep[n].NumberU64()-1)
+}
+
 // Tests that bad hashes are detected on boot, and the chain rolled back to a
 // good state prior to the bad hash.
 func TestReorgBadHeaderHashes(t *testing.T) { testReorgBadHashes(t, false) }
@@ -628,8 +786,8 @@ func testReorgBadHashes(t *testing.T, full bool) {
 		t.Fatalf("failed to create pristine chain: %v", err)
 	}
 	// Create a chain, import and ban afterwards
-	headers := makeHeaderChain(blockchain.chainConfig, blockchain.CurrentHeader(), 4, ethash.NewFaker(), genDb, 10)
-	blocks := makeBlockChain(blockchain.chainConfig, blockchain.GetBlockByHash(blockchain.CurrentBlock().Hash()), 4, ethash.NewFaker(), genDb, 10)
+	headers := makeHeaderChain(blockchain.Config(), blockchain.CurrentHeader(), 4, ethash.NewFaker(), genDb, 10)
+	blocks := makeBlockChain(blockchain.Config(), blockchain.GetBlockByHash(blockchain.CurrentBlock().Hash()), 4, ethash.NewFaker(), genDb, 10)
 
 	if full {
 		if _, err = blockchain.InsertChain(blocks); err != nil {
@@ -692,7 +850,7 @@ func testInsertNonceError(t *testing.T, full bool) {
 			failNum uint64
 		)
 		if full {
-			blocks := makeBlockChain(blockchain.chainConfig, blockchain.GetBlockByHash(blockchain.CurrentBlock().Hash()), i, ethash.NewFaker(), genDb, 0)
+			blocks := makeBlockChain(blockchain.Config(), blockchain.GetBlockByHash(blockchain.CurrentBlock().Hash()), i, ethash.NewFaker(), genDb, 0)
 
 			failAt = rand.Int() % len(blocks)
 			failNum = blocks[failAt].NumberU64()
@@ -700,7 +858,7 @@ func testInsertNonceError(t *testing.T, full bool) {
 			blockchain.engine = ethash.NewFakeFailer(failNum)
 			failRes, err = blockchain.InsertChain(blocks)
 		} else {
-			headers := makeHeaderChain(blockchain.chainConfig, blockchain.CurrentHeader(), i, ethash.NewFaker(), genDb, 0)
+			headers := makeHeaderChain(blockchain.Config(), blockchain.CurrentHeader(), i, ethash.NewFaker(), genDb, 0)
 
 			failAt = rand.Int() % len(headers)
 			failNum = headers[failAt].Number.Uint64()
@@ -1702,6 +1860,35 @@ func TestTrieForkGC(t *testing.T) {
 	}
 }
 
+// Tests that the state of the configured number of recent blocks is retained,
+// while older state is still garbage collected.
+func TestStateHistoryRetention(t *testing.T) {
+	engine := ethash.NewFaker()
+	genesis := &Genesis{
+		Config:  params.TestChainConfig,
+		BaseFee: big.NewInt(params.InitialBaseFee),
+	}
+	_, blocks, _ := GenerateChainWithGenesis(genesis, engine, 3*TriesInMemory, func(i int, b *BlockGen) { b.SetCoinbase(common.Address{1}) })
+
+	config := *defaultCacheConfig
+	config.StateHistory = 2 * TriesInMemory
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), &config, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	for i, block := range blocks {
+		retained := uint64(len(blocks)-i) <= config.StateHistory
+		if have := chain.HasState(block.Root()); have != retained {
+			t.Errorf("block %d: state available mismatch: have %v, want %v", block.NumberU64(), have, retained)
+		}
+	}
+}
+
 // Tests that doing large reorgs works even if the state associated with the
 // forking point is not available any more.
 func TestLargeReorgTrieGC(t *testing.T) {
@@ -4334,3 +4521,143 @@ func TestEIP3651(t *testing.T) {
 		t.Fatalf("sender balance incorrect: expected %d, got %d", expected, actual)
 	}
 }
+
+// Tests that reorgs of the canonical chain are recorded and announced with the
+// dropped and adopted segments.
+func TestReorgHistory(t *testing.T) {
+	genDb, _, blockchain, err := newCanonical(ethash.NewFaker(), 0, true)
+	if err != nil {
+		t.Fatalf("failed to create pristine chain: %v", err)
+	}
+	defer blockchain.Stop()
+
+	reorgs := make(chan ReorgEvent, 1)
+	sub := blockchain.SubscribeReorgEvent(reorgs)
+	defer sub.Unsubscribe()
+
+	genesis := blockchain.CurrentBlock()
+	easyBlocks, _ := GenerateChain(params.TestChainConfig, blockchain.GetBlockByHash(genesis.Hash()), ethash.NewFaker(), genDb, 3, func(i int, b *BlockGen) {
+		b.OffsetTime(0)
+	})
+	diffBlocks, _ := GenerateChain(params.TestChainConfig, blockchain.GetBlockByHash(genesis.Hash()), ethash.NewFaker(), genDb, 4, func(i int, b *BlockGen) {
+		b.OffsetTime(-9)
+	})
+	if _, err := blockchain.InsertChain(easyBlocks); err != nil {
+		t.Fatalf("failed to insert easy chain: %v", err)
+	}
+	if history := blockchain.ReorgHistory(10); len(history) != 0 {
+		t.Fatalf("chain extension recorded as reorg: %v", history)
+	}
+	if _, err := blockchain.InsertChain(diffBlocks); err != nil {
+		t.Fatalf("failed to insert difficult chain: %v", err)
+	}
+	var event ReorgEvent
+	select {
+	case event = <-reorgs:
+	default:
+		t.Fatalf("no reorg announced")
+	}
+	if event.Common.Hash() != genesis.Hash() {
+		t.Errorf("common ancestor mismatch: have %x, want %x", event.Common.Hash(), genesis.Hash())
+	}
+	if len(event.OldChain) != len(easyBlocks) {
+		t.Fatalf("dropped segment length mismatch: have %d, want %d", len(event.OldChain), len(easyBlocks))
+	}
+	for i, header := range event.OldChain {
+		if header.Hash() != easyBlocks[i].Hash() {
+			t.Errorf("dropped block %d mismatch: have %x, want %x", i, header.Hash(), easyBlocks[i].Hash())
+		}
+	}
+	if len(event.NewChain) != len(diffBlocks) {
+		t.Fatalf("adopted segment length mismatch: have %d, want %d", len(event.NewChain), len(diffBlocks))
+	}
+	for i, header := range event.NewChain {
+		if header.Hash() != diffBlocks[i].Hash() {
+			t.Errorf("adopted block %d mismatch: have %x, want %x", i, header.Hash(), diffBlocks[i].Hash())
+		}
+	}
+	if history := blockchain.ReorgHistory(10); len(history) != 1 || history[0].Common.Hash() != genesis.Hash() {
+		t.Errorf("reorg history mismatch: have %v", history)
+	}
+}
+
+// Tests that a chain opened from the database of another process picks up the
+// blocks inserted by the owner when reloading its head, announcing them in order.
+func TestReloadHead(t *testing.T) {
+	var (
+		dir, ancient = t.TempDir(), t.TempDir()
+		gspec        = &Genesis{Config: params.TestChainConfig, BaseFee: big.NewInt(params.InitialBaseFee)}
+		engine       = ethash.NewFaker()
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, engine, 10, nil)
+
+	db, err := rawdb.Open(rawdb.OpenOptions{Directory: dir, AncientsDirectory: ancient, Cache: 16, Handles: 16})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	// Run the owner in archive mode, as the replica can only access the state
+	// flushed to disk
+	archive := &CacheConfig{TrieCleanLimit: 256, TrieDirtyDisabled: true, TrieTimeLimit: 5 * time.Minute}
+	chain, err := NewBlockChain(db, archive, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+	if _, err := chain.InsertChain(blocks[:5]); err != nil {
+		t.Fatalf("failed to insert blocks: %v", err)
+	}
+	// Open the database read-only from the side and follow the owner
+	replicadb, err := rawdb.NewReplicaDatabase(dir, ancient, 16, 16, "")
+	if err != nil {
+		t.Fatalf("failed to open replica database: %v", err)
+	}
+	defer replicadb.Close()
+	nosnap := &CacheConfig{TrieCleanLimit: 256, TrieDirtyLimit: 256, TrieTimeLimit: 5 * time.Minute}
+	replica, err := NewBlockChain(replicadb, nosnap, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create replica chain: %v", err)
+	}
+	defer replica.Stop()
+	if head := replica.CurrentBlock(); head.Hash() != blocks[4].Hash() {
+		t.Fatalf("replica head mismatch: have #%d, want #%d", head.Number, blocks[4].Number())
+	}
+	events := make(chan ChainEvent, 10)
+	sub := replica.SubscribeChainEvent(events)
+	defer sub.Unsubscribe()
+
+	if _, err := chain.InsertChain(blocks[5:]); err != nil {
+		t.Fatalf("failed to insert blocks: %v", err)
+	}
+	if err := replica.ReloadHead(); err != nil {
+		t.Fatalf("failed to reload stale head: %v", err)
+	}
+	if head := replica.CurrentBlock(); head.Hash() != blocks[4].Hash() {
+		t.Fatalf("head moved without refresh: have #%d", head.Number)
+	}
+	if err := replicadb.(ethdb.Refresher).Refresh(); err != nil {
+		t.Fatalf("failed to refresh replica: %v", err)
+	}
+	if err := replica.ReloadHead(); err != nil {
+		t.Fatalf("failed to reload head: %v", err)
+	}
+	if head := replica.CurrentBlock(); head.Hash() != blocks[9].Hash() {
+		t.Fatalf("replica head mismatch: have #%d, want #%d", head.Number, blocks[9].Number())
+	}
+	if _, err := replica.State(); err != nil {
+		t.Fatalf("replica head state unavailable: %v", err)
+	}
+	for _, block := range blocks[5:] {
+		select {
+		case ev := <-events:
+			if ev.Hash != block.Hash() {
+				t.Errorf("chain event mismatch: have #%d, want #%d", ev.Block.Number(), block.Number())
+			}
+		default:
+			t.Fatalf("missing chain event for #%d", block.Number())
+		}
+	}
+	if len(events) != 0 {
+		t.Errorf("unexpected chain events: %d", len(events))
+	}
+}
@@ -25,6 +25,10 @@ var (
 	// ErrBannedHash is returned if a block to import is on the banned list.
 	ErrBannedHash = errors.New("banned hash")
 
+	// ErrFinalizedReorg is returned if a block would reorg the chain below the
+	// soft finalized block.
+	ErrFinalizedReorg = errors.New("reorg below finalized block")
+
 	// ErrNoGenesis is returned when there is no Genesis Block.
 	ErrNoGenesis = errors.New("genesis not found in chain")
 
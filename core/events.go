@@ -37,3 +37,10 @@ type ChainSideEvent struct {
 }
 
 type ChainHeadEvent struct{ Block *types.Block }
+
+// ReorgEvent is posted when the canonical chain switches to a competing branch.
+type ReorgEvent struct {
+	Common   *types.Header   // Common ancestor of the two chains
+	OldChain []*types.Header // Dropped canonical headers in ascending order
+	NewChain []*types.Header // Adopted headers in ascending order
+}
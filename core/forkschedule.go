@@ -0,0 +1,171 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	errForkScheduleUnsigned = errors.New("fork schedule not signed by a trusted key")
+	errForkScheduleNoForks  = errors.New("fork schedule without forks")
+)
+
+// forkFields are the chain config JSON fields a fork schedule may set.
+var forkFields = map[string]bool{
+	"homesteadBlock":      true,
+	"daoForkBlock":        true,
+	"eip150Block":         true,
+	"eip155Block":         true,
+	"eip158Block":         true,
+	"byzantiumBlock":      true,
+	"constantinopleBlock": true,
+	"petersburgBlock":     true,
+	"istanbulBlock":       true,
+	"muirGlacierBlock":    true,
+	"berlinBlock":         true,
+	"londonBlock":         true,
+	"arrowGlacierBlock":   true,
+	"grayGlacierBlock":    true,
+	"mergeNetsplitBlock":  true,
+	"shanghaiTime":        true,
+	"cancunTime":          true,
+	"pragueTime":          true,
+	"feeMarket":           true,
+}
+
+// ForkSchedule is a set of fork activations distributed at runtime, so that the
+// nodes of a network don't have to upgrade their binary to schedule a fork.
+type ForkSchedule struct {
+	Genesis common.Hash                `json:"genesis"` // Hash of the genesis block of the network scheduled for
+	Forks   map[string]json.RawMessage `json:"forks"`   // Chain config fields of the forks, like "londonBlock"
+}
+
+// SignedForkSchedule is a fork schedule signed by a trusted key. The signature
+// is made over the Keccak256 hash of the schedule JSON exactly as encoded.
+type SignedForkSchedule struct {
+	Schedule  json.RawMessage `json:"schedule"`
+	Signature hexutil.Bytes   `json:"signature"`
+}
+
+// VerifyForkSchedule decodes a signed fork schedule, checking it's signed by one
+// of the trusted keys.
+func VerifyForkSchedule(blob []byte, signers []common.Address) (*ForkSchedule, error) {
+	var signed SignedForkSchedule
+	if err := json.Unmarshal(blob, &signed); err != nil {
+		return nil, fmt.Errorf("invalid signed fork schedule: %v", err)
+	}
+	pubkey, err := crypto.SigToPub(crypto.Keccak256(signed.Schedule), signed.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fork schedule signature: %v", err)
+	}
+	signer := crypto.PubkeyToAddress(*pubkey)
+
+	trusted := false
+	for _, addr := range signers {
+		if addr == signer {
+			trusted = true
+			break
+		}
+	}
+	if !trusted {
+		return nil, errForkScheduleUnsigned
+	}
+	return DecodeForkSchedule(signed.Schedule)
+}
+
+// DecodeForkSchedule decodes a fork schedule, checking it only names the fork
+// activation fields of the chain config.
+func DecodeForkSchedule(blob []byte) (*ForkSchedule, error) {
+	schedule := new(ForkSchedule)
+	if err := json.Unmarshal(blob, schedule); err != nil {
+		return nil, fmt.Errorf("invalid fork schedule: %v", err)
+	}
+	if len(schedule.Forks) == 0 {
+		return nil, errForkScheduleNoForks
+	}
+	for field := range schedule.Forks {
+		if !forkFields[field] {
+			return nil, fmt.Errorf("fork schedule field %q is not a fork activation", field)
+		}
+	}
+	return schedule, nil
+}
+
+// Apply returns a copy of the chain config with the forks of the schedule set.
+// The forks already active at the given head may not be changed, neither may a
+// scheduled fork be active already.
+func (s *ForkSchedule) Apply(config *params.ChainConfig, genesis common.Hash, head uint64, headTime uint64) (*params.ChainConfig, error) {
+	if s.Genesis != genesis {
+		return nil, fmt.Errorf("fork schedule genesis mismatch: have %x, want %x", s.Genesis, genesis)
+	}
+	updated, err := s.overlay(config)
+	if err != nil {
+		return nil, err
+	}
+	if err := updated.CheckConfigForkOrder(); err != nil {
+		return nil, err
+	}
+	if err := config.CheckCompatible(updated, head, headTime); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// merge returns a schedule of the forks of both schedules, the ones of s taking
+// precedence over the ones of prev.
+func (s *ForkSchedule) merge(prev *ForkSchedule) *ForkSchedule {
+	merged := &ForkSchedule{
+		Genesis: s.Genesis,
+		Forks:   make(map[string]json.RawMessage, len(prev.Forks)+len(s.Forks)),
+	}
+	for field, value := range prev.Forks {
+		merged.Forks[field] = value
+	}
+	for field, value := range s.Forks {
+		merged.Forks[field] = value
+	}
+	return merged
+}
+
+// overlay returns a copy of the chain config with the forks of the schedule set,
+// without any validation.
+func (s *ForkSchedule) overlay(config *params.ChainConfig) (*params.ChainConfig, error) {
+	// Overlay the forks on the JSON of the config, which deep copies it too
+	blob, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(blob, &fields); err != nil {
+		return nil, err
+	}
+	for field, value := range s.Forks {
+		fields[field] = value
+	}
+	if blob, err = json.Marshal(fields); err != nil {
+		return nil, err
+	}
+	updated := new(params.ChainConfig)
+	if err := json.Unmarshal(blob, updated); err != nil {
+		return nil, fmt.Errorf("invalid fork schedule: %v", err)
+	}
+	return updated, nil
+}
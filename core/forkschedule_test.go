@@ -0,0 +1,153 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package core
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// signForkSchedule encodes a fork schedule signed by a random key, returning
+// the signer too.
+func signForkSchedule(t *testing.T, genesis common.Hash, forks string) ([]byte, common.Address) {
+	t.Helper()
+
+	key, _ := crypto.GenerateKey()
+	schedule := []byte(`{"genesis":"` + genesis.Hex() + `","forks":` + forks + `}`)
+	sig, err := crypto.Sign(crypto.Keccak256(schedule), key)
+	if err != nil {
+		t.Fatalf("failed to sign schedule: %v", err)
+	}
+	blob, _ := json.Marshal(&SignedForkSchedule{Schedule: schedule, Signature: sig})
+	return blob, crypto.PubkeyToAddress(key.PublicKey)
+}
+
+func TestVerifyForkSchedule(t *testing.T) {
+	blob, signer := signForkSchedule(t, common.Hash{0x01}, `{"londonBlock":10}`)
+	if _, err := VerifyForkSchedule(blob, []common.Address{{0x02}}); err != errForkScheduleUnsigned {
+		t.Fatalf("untrusted signer error mismatch: have %v, want %v", err, errForkScheduleUnsigned)
+	}
+	schedule, err := VerifyForkSchedule(blob, []common.Address{{0x02}, signer})
+	if err != nil {
+		t.Fatalf("failed to verify schedule: %v", err)
+	}
+	if schedule.Genesis != (common.Hash{0x01}) || string(schedule.Forks["londonBlock"]) != "10" {
+		t.Fatalf("schedule mismatch: %+v", schedule)
+	}
+	// Tampering with the schedule invalidates the signature
+	var signed SignedForkSchedule
+	json.Unmarshal(blob, &signed)
+	signed.Schedule = []byte(`{"genesis":"` + (common.Hash{0x01}).Hex() + `","forks":{"londonBlock":11}}`)
+	tampered, _ := json.Marshal(&signed)
+	if _, err := VerifyForkSchedule(tampered, []common.Address{signer}); err != errForkScheduleUnsigned {
+		t.Fatalf("tampered schedule error mismatch: have %v, want %v", err, errForkScheduleUnsigned)
+	}
+	// Only fork activations may be scheduled
+	blob, signer = signForkSchedule(t, common.Hash{0x01}, `{"chainId":1}`)
+	if _, err := VerifyForkSchedule(blob, []common.Address{signer}); err == nil {
+		t.Fatalf("non-fork field accepted")
+	}
+}
+
+func TestApplyForkSchedule(t *testing.T) {
+	var (
+		config = &params.ChainConfig{
+			ChainID:             big.NewInt(1),
+			HomesteadBlock:      big.NewInt(0),
+			EIP150Block:         big.NewInt(0),
+			EIP155Block:         big.NewInt(0),
+			EIP158Block:         big.NewInt(0),
+			ByzantiumBlock:      big.NewInt(0),
+			ConstantinopleBlock: big.NewInt(0),
+			PetersburgBlock:     big.NewInt(0),
+			IstanbulBlock:       big.NewInt(0),
+			BerlinBlock:         big.NewInt(0),
+			Ethash:              new(params.EthashConfig),
+		}
+		db     = rawdb.NewMemoryDatabase()
+		gspec  = &Genesis{Config: config}
+		engine = ethash.NewFaker()
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, engine, 5, nil)
+	chain, err := NewBlockChain(db, nil, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	genesis := chain.Genesis().Hash()
+
+	apply := func(genesis common.Hash, forks string) error {
+		t.Helper()
+		blob, signer := signForkSchedule(t, genesis, forks)
+		schedule, err := VerifyForkSchedule(blob, []common.Address{signer})
+		if err != nil {
+			t.Fatalf("failed to verify schedule: %v", err)
+		}
+		_, err = chain.ApplyForkSchedule(schedule)
+		return err
+	}
+	if err := apply(common.Hash{0x01}, `{"londonBlock":10}`); err == nil {
+		t.Fatalf("schedule of another genesis accepted")
+	}
+	if err := apply(genesis, `{"londonBlock":3}`); err == nil {
+		t.Fatalf("fork below the head accepted")
+	}
+	if err := apply(genesis, `{"berlinBlock":10}`); err == nil {
+		t.Fatalf("active fork change accepted")
+	}
+	original := chain.Config()
+	if err := apply(genesis, `{"londonBlock":10}`); err != nil {
+		t.Fatalf("failed to schedule fork: %v", err)
+	}
+	if !chain.Config().IsLondon(big.NewInt(10)) || chain.Config().IsLondon(big.NewInt(9)) {
+		t.Fatalf("fork not scheduled: %v", chain.Config().LondonBlock)
+	}
+	if original.LondonBlock != nil {
+		t.Fatalf("chain config modified in place")
+	}
+	// Future forks may be rescheduled, and later schedules add to earlier ones
+	if err := apply(genesis, `{"londonBlock":20}`); err != nil {
+		t.Fatalf("failed to reschedule fork: %v", err)
+	}
+	if err := apply(genesis, `{"arrowGlacierBlock":30}`); err != nil {
+		t.Fatalf("failed to schedule another fork: %v", err)
+	}
+	chain.Stop()
+
+	// The schedule is reapplied on restart, even over a given genesis
+	gspec.Config = &params.ChainConfig{}
+	*gspec.Config = *config
+	gspec.Config.LondonBlock = nil
+
+	chain, err = NewBlockChain(db, nil, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to reopen chain: %v", err)
+	}
+	defer chain.Stop()
+	if london := chain.Config().LondonBlock; london == nil || london.Uint64() != 20 {
+		t.Fatalf("schedule not reapplied: london block %v", london)
+	}
+	if arrow := chain.Config().ArrowGlacierBlock; arrow == nil || arrow.Uint64() != 30 {
+		t.Fatalf("schedule not reapplied: arrow glacier block %v", arrow)
+	}
+}
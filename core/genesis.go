@@ -289,6 +289,16 @@ func SetupGenesisBlockWithOverride(db ethdb.Database, triedb *trie.Database, gen
 	}
 	applyOverrides := func(config *params.ChainConfig) {
 		if config != nil {
+			// Reapply the fork schedule set at runtime, it's been validated then
+			if blob := rawdb.ReadForkSchedule(db); len(blob) > 0 {
+				if schedule, err := DecodeForkSchedule(blob); err != nil {
+					log.Error("Invalid stored fork schedule", "err", err)
+				} else if updated, err := schedule.overlay(config); err != nil {
+					log.Error("Failed to apply stored fork schedule", "err", err)
+				} else {
+					*config = *updated
+				}
+			}
 			if overrides != nil && overrides.OverrideShanghai != nil {
 				config.ShanghaiTime = overrides.OverrideShanghai
 			}
@@ -483,8 +493,16 @@ func (g *Genesis) Commit(db ethdb.Database, triedb *trie.Database) (*types.Block
 	if err := config.CheckConfigForkOrder(); err != nil {
 		return nil, err
 	}
-	if config.Clique != nil && len(block.Extra()) < 32+crypto.SignatureLength {
-		return nil, errors.New("can't start clique chain without signers")
+	if config.Clique != nil {
+		// The extra-data of a clique genesis is the vanity, the list of the
+		// initial signers and an empty seal
+		signers := len(block.Extra()) - 32 - crypto.SignatureLength
+		if signers <= 0 {
+			return nil, errors.New("can't start clique chain without signers")
+		}
+		if signers%common.AddressLength != 0 {
+			return nil, fmt.Errorf("invalid clique signer list in extra-data: %d bytes, not a multiple of %d", signers, common.AddressLength)
+		}
 	}
 	// All the checks has passed, flush the states derived from the genesis
 	// specification as well as the specification itself into the provided
@@ -572,13 +590,37 @@ func DeveloperGenesisBlock(period uint64, gasLimit uint64, faucet common.Address
 		Period: period,
 		Epoch:  config.Clique.Epoch,
 	}
+	return developerGenesisBlock(&config, gasLimit, faucet)
+}
+
+// DeveloperBitnetGenesisBlock returns the 'bitnet --dev.bitnet' genesis block,
+// activating the forks of the Bitnet main network on a proof-of-authority chain.
+// The proof-of-work settings of the main network, including its payout split,
+// are dropped as clique never pays out rewards.
+func DeveloperBitnetGenesisBlock(period uint64, gasLimit uint64, faucet common.Address) *Genesis {
+	config := *params.MainnetChainConfig
+	config.ChainID = big.NewInt(1337)
+	config.Ethash = nil
+	config.PayoutSplit = nil
+	config.Clique = &params.CliqueConfig{
+		Period: period,
+		Epoch:  params.AllCliqueProtocolChanges.Clique.Epoch,
+	}
+	return developerGenesisBlock(&config, gasLimit, faucet)
+}
 
-	// Assemble and return the genesis with the precompiles and faucet pre-funded
+// developerGenesisBlock assembles a developer genesis block with the precompiles
+// and the faucet pre-funded, the faucet being the single clique signer.
+func developerGenesisBlock(config *params.ChainConfig, gasLimit uint64, faucet common.Address) *Genesis {
+	var baseFee *big.Int
+	if config.IsLondon(common.Big0) {
+		baseFee = big.NewInt(params.InitialBaseFee)
+	}
 	return &Genesis{
-		Config:     &config,
+		Config:     config,
 		ExtraData:  append(append(make([]byte, 32), faucet[:]...), make([]byte, crypto.SignatureLength)...),
 		GasLimit:   gasLimit,
-		BaseFee:    big.NewInt(params.InitialBaseFee),
+		BaseFee:    baseFee,
 		Difficulty: big.NewInt(1),
 		Alloc: map[common.Address]GenesisAccount{
 			common.BytesToAddress([]byte{1}): {Balance: big.NewInt(1)}, // ECRecover
@@ -16,6 +16,7 @@ import (
 	"encoding/json"
 	"math/big"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/davecgh/go-spew/spew"
@@ -23,6 +24,7 @@ import (
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/trie"
@@ -35,6 +37,15 @@ func TestInvalidCliqueConfig(t *testing.T) {
 	if _, err := block.Commit(db, trie.NewDatabase(db)); err == nil {
 		t.Fatal("Expected error on invalid clique config")
 	}
+	// Signer lists cut short are rejected too
+	block.ExtraData = make([]byte, 32+common.AddressLength+1+crypto.SignatureLength)
+	if _, err := block.Commit(db, trie.NewDatabase(db)); err == nil {
+		t.Fatal("Expected error on misaligned clique signers")
+	}
+	block.ExtraData = make([]byte, 32+common.AddressLength+crypto.SignatureLength)
+	if _, err := block.Commit(db, trie.NewDatabase(db)); err != nil {
+		t.Fatalf("Failed to commit clique genesis: %v", err)
+	}
 }
 
 func TestSetupGenesis(t *testing.T) {
@@ -182,6 +193,21 @@ func TestGenesisHashes(t *testing.T) {
 	}
 }
 
+// Tests that the Bitnet developer chain is configured with clique as its only
+// consensus engine.
+func TestDeveloperBitnetGenesisEngine(t *testing.T) {
+	config := DeveloperBitnetGenesisBlock(5, 11500000, common.Address{0x01}).Config
+	if config.Clique == nil {
+		t.Fatalf("clique not configured")
+	}
+	if config.Ethash != nil || config.PayoutSplit != nil {
+		t.Errorf("proof-of-work settings retained: ethash %v, payout split %v", config.Ethash, config.PayoutSplit)
+	}
+	if engine := config.Description(); strings.Contains(engine, "Ethash") {
+		t.Errorf("ethash reported as engine: %s", engine)
+	}
+}
+
 func TestGenesis_Commit(t *testing.T) {
 	genesis := &Genesis{
 		BaseFee: big.NewInt(params.InitialBaseFee),
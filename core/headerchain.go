@@ -13,12 +13,15 @@
 package core
 
 import (
+	"bytes"
 	crand "crypto/rand"
 	"errors"
 	"fmt"
 	"math"
 	"math/big"
 	mrand "math/rand"
+	"sort"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -53,7 +56,7 @@ const (
 // It is not thread safe either, the encapsulating chain structures should do
 // the necessary mutex locking/unlocking.
 type HeaderChain struct {
-	config        *params.ChainConfig
+	config        atomic.Pointer[params.ChainConfig] // Replaced, never modified, when forks are scheduled at runtime
 	chainDb       ethdb.Database
 	genesisHeader *types.Header
 
@@ -66,6 +69,9 @@ type HeaderChain struct {
 
 	procInterrupt func() bool
 
+	banned     map[common.Hash]struct{} // Block hashes banned by the operator, on top of BadHashes
+	bannedLock sync.RWMutex
+
 	rand   *mrand.Rand
 	engine consensus.Engine
 }
@@ -79,15 +85,19 @@ func NewHeaderChain(chainDb ethdb.Database, config *params.ChainConfig, engine c
 		return nil, err
 	}
 	hc := &HeaderChain{
-		config:        config,
 		chainDb:       chainDb,
 		headerCache:   lru.NewCache[common.Hash, *types.Header](headerCacheLimit),
 		tdCache:       lru.NewCache[common.Hash, *big.Int](tdCacheLimit),
 		numberCache:   lru.NewCache[common.Hash, uint64](numberCacheLimit),
 		procInterrupt: procInterrupt,
+		banned:        make(map[common.Hash]struct{}),
 		rand:          mrand.New(mrand.NewSource(seed.Int64())),
 		engine:        engine,
 	}
+	hc.config.Store(config)
+	for _, hash := range rawdb.ReadBannedHashes(chainDb) {
+		hc.banned[hash] = struct{}{}
+	}
 	hc.genesisHeader = hc.GetHeaderByNumber(0)
 	if hc.genesisHeader == nil {
 		return nil, ErrNoGenesis
@@ -103,6 +113,52 @@ func NewHeaderChain(chainDb ethdb.Database, config *params.ChainConfig, engine c
 	return hc, nil
 }
 
+// IsBanned reports whether a block hash is banned, either by the hard coded bad
+// hashes or by the operator.
+func (hc *HeaderChain) IsBanned(hash common.Hash) bool {
+	if BadHashes[hash] {
+		return true
+	}
+	hc.bannedLock.RLock()
+	defer hc.bannedLock.RUnlock()
+
+	_, ok := hc.banned[hash]
+	return ok
+}
+
+// BannedHashes returns the block hashes banned by the operator.
+func (hc *HeaderChain) BannedHashes() []common.Hash {
+	hc.bannedLock.RLock()
+	defer hc.bannedLock.RUnlock()
+
+	return sortedHashes(hc.banned)
+}
+
+// SetBanned bans or unbans a block hash, persisting the operator banned hashes
+// across restarts.
+func (hc *HeaderChain) SetBanned(hash common.Hash, banned bool) {
+	hc.bannedLock.Lock()
+	defer hc.bannedLock.Unlock()
+
+	if banned {
+		hc.banned[hash] = struct{}{}
+	} else {
+		delete(hc.banned, hash)
+	}
+	rawdb.WriteBannedHashes(hc.chainDb, sortedHashes(hc.banned))
+}
+
+func sortedHashes(set map[common.Hash]struct{}) []common.Hash {
+	hashes := make([]common.Hash, 0, len(set))
+	for hash := range set {
+		hashes = append(hashes, hash)
+	}
+	sort.Slice(hashes, func(i, j int) bool {
+		return bytes.Compare(hashes[i][:], hashes[j][:]) < 0
+	})
+	return hashes
+}
+
 // GetBlockNumber retrieves the block number belonging to the given hash
 // from the cache or database
 func (hc *HeaderChain) GetBlockNumber(hash common.Hash) *uint64 {
@@ -310,11 +366,11 @@ func (hc *HeaderChain) ValidateHeaderChain(chain []*types.Header, checkFreq int)
 				parentHash.Bytes()[:4], i, chain[i].Number, hash.Bytes()[:4], chain[i].ParentHash[:4])
 		}
 		// If the header is a banned one, straight out abort
-		if BadHashes[chain[i].ParentHash] {
+		if hc.IsBanned(chain[i].ParentHash) {
 			return i - 1, ErrBannedHash
 		}
 		// If it's the last header in the cunk, we need to check it too
-		if i == len(chain)-1 && BadHashes[chain[i].Hash()] {
+		if i == len(chain)-1 && hc.IsBanned(chain[i].Hash()) {
 			return i, ErrBannedHash
 		}
 	}
@@ -677,7 +733,7 @@ func (hc *HeaderChain) SetGenesis(head *types.Header) {
 }
 
 // Config retrieves the header chain's chain configuration.
-func (hc *HeaderChain) Config() *params.ChainConfig { return hc.config }
+func (hc *HeaderChain) Config() *params.ChainConfig { return hc.config.Load() }
 
 // Engine retrieves the header chain's consensus engine.
 func (hc *HeaderChain) Engine() consensus.Engine { return hc.engine }
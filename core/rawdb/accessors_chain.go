@@ -646,6 +646,50 @@ func ReadReceipts(db ethdb.Reader, hash common.Hash, number uint64, config *para
 	return receipts
 }
 
+// ReadAncientReceipts retrieves all the transaction receipts belonging to a
+// frozen canonical block, including their metadata fields. The receipts, body
+// and header are all read from the freezer in a single pass, without falling
+// back to the key-value store. Nil is returned if the block is not frozen.
+func ReadAncientReceipts(db ethdb.AncientReader, hash common.Hash, number uint64, config *params.ChainConfig) types.Receipts {
+	var receiptsRLP, bodyRLP, headerRLP []byte
+	db.ReadAncients(func(reader ethdb.AncientReaderOp) error {
+		if !isCanon(reader, number, hash) {
+			return nil
+		}
+		receiptsRLP, _ = reader.Ancient(ChainFreezerReceiptTable, number)
+		bodyRLP, _ = reader.Ancient(ChainFreezerBodiesTable, number)
+		headerRLP, _ = reader.Ancient(ChainFreezerHeaderTable, number)
+		return nil
+	})
+	if len(receiptsRLP) == 0 || len(bodyRLP) == 0 || len(headerRLP) == 0 {
+		return nil
+	}
+	storageReceipts := []*types.ReceiptForStorage{}
+	if err := rlp.DecodeBytes(receiptsRLP, &storageReceipts); err != nil {
+		log.Error("Invalid receipt array RLP", "hash", hash, "err", err)
+		return nil
+	}
+	body := new(types.Body)
+	if err := rlp.DecodeBytes(bodyRLP, body); err != nil {
+		log.Error("Invalid block body RLP", "hash", hash, "err", err)
+		return nil
+	}
+	header := new(types.Header)
+	if err := rlp.DecodeBytes(headerRLP, header); err != nil {
+		log.Error("Invalid block header RLP", "hash", hash, "err", err)
+		return nil
+	}
+	receipts := make(types.Receipts, len(storageReceipts))
+	for i, storageReceipt := range storageReceipts {
+		receipts[i] = (*types.Receipt)(storageReceipt)
+	}
+	if err := receipts.DeriveFields(config, hash, number, header.BaseFee, body.Transactions); err != nil {
+		log.Error("Failed to derive block receipts fields", "hash", hash, "number", number, "err", err)
+		return nil
+	}
+	return receipts
+}
+
 // WriteReceipts stores all the transaction receipts belonging to a block.
 func WriteReceipts(db ethdb.KeyValueWriter, hash common.Hash, number uint64, receipts types.Receipts) {
 	// Convert the receipts into their storage form and serialize them
@@ -921,6 +965,31 @@ func DeleteBadBlocks(db ethdb.KeyValueWriter) {
 	}
 }
 
+// ReadBannedHashes retrieves the block hashes banned by the operator.
+func ReadBannedHashes(db ethdb.KeyValueReader) []common.Hash {
+	blob, err := db.Get(bannedHashesKey)
+	if err != nil {
+		return nil
+	}
+	var hashes []common.Hash
+	if err := rlp.DecodeBytes(blob, &hashes); err != nil {
+		log.Error("Invalid banned hashes RLP", "err", err)
+		return nil
+	}
+	return hashes
+}
+
+// WriteBannedHashes stores the block hashes banned by the operator.
+func WriteBannedHashes(db ethdb.KeyValueWriter, hashes []common.Hash) {
+	data, err := rlp.EncodeToBytes(hashes)
+	if err != nil {
+		log.Crit("Failed to encode banned hashes", "err", err)
+	}
+	if err := db.Put(bannedHashesKey, data); err != nil {
+		log.Crit("Failed to store banned hashes", "err", err)
+	}
+}
+
 // FindCommonAncestor returns the last common ancestor of two block headers
 func FindCommonAncestor(db ethdb.Reader, a, b *types.Header) *types.Header {
 	for bn := b.Number.Uint64(); a.Number.Uint64() > bn; {
@@ -492,6 +492,51 @@ func TestAncientStorage(t *testing.T) {
 	}
 }
 
+// Tests that the receipts of frozen blocks are read with their metadata from
+// the freezer alone.
+func TestAncientReceipts(t *testing.T) {
+	db, err := NewDatabaseWithFreezer(NewMemoryDatabase(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("failed to create database with ancient backend")
+	}
+	defer db.Close()
+
+	tx1 := types.NewTransaction(1, common.HexToAddress("0x1"), big.NewInt(1), 1, big.NewInt(1), nil)
+	tx2 := types.NewTransaction(2, common.HexToAddress("0x2"), big.NewInt(2), 2, big.NewInt(2), nil)
+	receipts := types.Receipts{
+		{Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: 1, TxHash: tx1.Hash(), GasUsed: 1, Logs: []*types.Log{}},
+		{Status: types.ReceiptStatusFailed, CumulativeGasUsed: 3, TxHash: tx2.Hash(), GasUsed: 2, Logs: []*types.Log{}},
+	}
+	block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(0)}).WithBody(types.Transactions{tx1, tx2}, nil)
+	hash, number := block.Hash(), block.NumberU64()
+
+	if rs := ReadAncientReceipts(db, hash, number, params.TestChainConfig); rs != nil {
+		t.Fatalf("non existent receipts returned: %v", rs)
+	}
+	// Receipts only in the key-value store are not frozen
+	WriteBlock(db, block)
+	WriteReceipts(db, hash, number, receipts)
+	if rs := ReadAncientReceipts(db, hash, number, params.TestChainConfig); rs != nil {
+		t.Fatalf("receipts of unfrozen block returned: %v", rs)
+	}
+	WriteAncientBlocks(db, []*types.Block{block}, []types.Receipts{receipts}, big.NewInt(100))
+	DeleteBlock(db, hash, number)
+	DeleteReceipts(db, hash, number)
+
+	rs := ReadAncientReceipts(db, hash, number, params.TestChainConfig)
+	if err := checkReceiptsRLP(rs, receipts); err != nil {
+		t.Fatal(err)
+	}
+	for i, receipt := range rs {
+		if receipt.BlockHash != hash || receipt.TransactionIndex != uint(i) || receipt.TxHash != block.Transactions()[i].Hash() {
+			t.Errorf("receipt %d: metadata not derived: %+v", i, receipt)
+		}
+	}
+	if rs := ReadAncientReceipts(db, common.Hash{0x01}, number, params.TestChainConfig); rs != nil {
+		t.Fatalf("receipts of non canonical block returned: %v", rs)
+	}
+}
+
 func TestCanonicalHashIteration(t *testing.T) {
 	var cases = []struct {
 		from, to uint64
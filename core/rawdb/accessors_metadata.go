@@ -77,6 +77,21 @@ func WriteChainConfig(db ethdb.KeyValueWriter, hash common.Hash, cfg *params.Cha
 	}
 }
 
+// ReadForkSchedule retrieves the forks scheduled at runtime, merged into a single
+// fork schedule, as JSON.
+func ReadForkSchedule(db ethdb.KeyValueReader) []byte {
+	data, _ := db.Get(forkScheduleKey)
+	return data
+}
+
+// WriteForkSchedule stores the JSON of the forks scheduled at runtime, merged into
+// a single fork schedule.
+func WriteForkSchedule(db ethdb.KeyValueWriter, data []byte) {
+	if err := db.Put(forkScheduleKey, data); err != nil {
+		log.Crit("Failed to store fork schedule", "err", err)
+	}
+}
+
 // ReadGenesisStateSpec retrieves the genesis state specification based on the
 // given genesis (block-)hash.
 func ReadGenesisStateSpec(db ethdb.KeyValueReader, blockhash common.Hash) []byte {
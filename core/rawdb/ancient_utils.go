@@ -14,9 +14,11 @@ package rawdb
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
 )
 
 type tableSize struct {
@@ -115,3 +117,37 @@ func InspectFreezerTable(ancient string, freezerName string, tableName string, s
 	table.dumpIndexStdout(start, end)
 	return nil
 }
+
+// CompressChainFreezer rewrites the compressed tables of the chain freezer with
+// the given compression. The passed ancient indicates the path of root ancient
+// directory where the chain freezer can be opened, which must not be in use.
+func CompressChainFreezer(ancient string, compression FreezerCompression) error {
+	freezer, err := NewChainFreezer(resolveChainFreezerDir(ancient), "", false)
+	if err != nil {
+		return err
+	}
+	defer freezer.Close()
+
+	var names []string
+	for name, noSnappy := range chainFreezerNoSnappy {
+		if !noSnappy {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		before, err := freezer.AncientSize(name)
+		if err != nil {
+			return err
+		}
+		if err := freezer.CompressTable(name, compression); err != nil {
+			return fmt.Errorf("failed to compress table %s: %w", name, err)
+		}
+		after, err := freezer.AncientSize(name)
+		if err != nil {
+			return err
+		}
+		log.Info("Freezer table compressed", "table", name, "compression", compression, "before", common.StorageSize(before), "after", common.StorageSize(after))
+	}
+	return nil
+}
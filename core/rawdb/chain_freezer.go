@@ -47,9 +47,10 @@ type chainFreezer struct {
 	trigger chan chan struct{} // Manual blocking freeze trigger, test determinism
 }
 
-// newChainFreezer initializes the freezer for ancient chain data.
-func newChainFreezer(datadir string, namespace string, readonly bool) (*chainFreezer, error) {
-	freezer, err := NewChainFreezer(datadir, namespace, readonly)
+// newChainFreezer initializes the freezer for ancient chain data, creating new
+// compressed tables with the given compression.
+func newChainFreezer(datadir string, namespace string, readonly bool, compression FreezerCompression) (*chainFreezer, error) {
+	freezer, err := newChainFreezerWithCompression(datadir, namespace, readonly, compression)
 	if err != nil {
 		return nil, err
 	}
@@ -26,7 +26,9 @@ import (
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/ethdb/leveldb"
 	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/ethdb/remotekv"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/olekukonko/tablewriter"
 )
 
@@ -194,8 +196,14 @@ func resolveChainFreezerDir(ancient string) string {
 // storage. The passed ancient indicates the path of root ancient directory
 // where the chain freezer can be opened.
 func NewDatabaseWithFreezer(db ethdb.KeyValueStore, ancient string, namespace string, readonly bool) (ethdb.Database, error) {
+	return newDatabaseWithFreezer(db, ancient, namespace, readonly, FreezerSnappy)
+}
+
+// newDatabaseWithFreezer is NewDatabaseWithFreezer, creating missing compressed
+// freezer tables with the given compression.
+func newDatabaseWithFreezer(db ethdb.KeyValueStore, ancient string, namespace string, readonly bool, compression FreezerCompression) (ethdb.Database, error) {
 	// Create the idle freezer instance
-	frdb, err := newChainFreezer(resolveChainFreezerDir(ancient), namespace, readonly)
+	frdb, err := newChainFreezer(resolveChainFreezerDir(ancient), namespace, readonly, compression)
 	if err != nil {
 		printChainMetadata(db)
 		return nil, err
@@ -317,9 +325,31 @@ func NewLevelDBDatabase(file string, cache int, handles int, namespace string, r
 	return NewDatabase(db), nil
 }
 
+// NewRemoteDatabase creates a read-only view of the chain database shared by
+// the key-value service of the remote node reachable at the given url, along
+// with its ancient store. Like with replicas, the local writes are kept in
+// memory, overlaid on the remote store, until the view is refreshed.
+func NewRemoteDatabase(url string) (ethdb.Database, error) {
+	if url == "" {
+		return nil, errors.New("db.engine 'remote' requires the url of the remote store")
+	}
+	client, err := rpc.Dial(url)
+	if err != nil {
+		return nil, err
+	}
+	log.Warn("Using experimental remote store as the backing database", "url", url)
+
+	remote := remotekv.New(client)
+	return &remotedb{
+		KeyValueStore: &overlaydb{base: remote, mem: memorydb.New()},
+		AncientStore:  remote,
+	}, nil
+}
+
 const (
 	dbPebble  = "pebble"
 	dbLeveldb = "leveldb"
+	dbRemote  = "remote"
 )
 
 // hasPreexistingDb checks the given data directory whether a database is already
@@ -341,13 +371,18 @@ func hasPreexistingDb(path string) string {
 // OpenOptions contains the options to apply when opening a database.
 // OBS: If AncientsDirectory is empty, it indicates that no freezer is to be used.
 type OpenOptions struct {
-	Type              string // "leveldb" | "pebble"
+	Type              string // "leveldb" | "pebble" | "remote"
+	Remote            string // the url of the remote store, for the "remote" type
 	Directory         string // the datadir
 	AncientsDirectory string // the ancients-dir
 	Namespace         string // the namespace for database relevant metrics
 	Cache             int    // the capacity(in megabytes) of the data caching
 	Handles           int    // number of files to be open simultaneously
 	ReadOnly          bool
+
+	// AncientCompression is the compression of newly created compressed
+	// ancient tables, existing tables keep theirs.
+	AncientCompression FreezerCompression
 }
 
 // openKeyValueDatabase opens a disk-based key-value database, e.g. leveldb or pebble.
@@ -382,7 +417,13 @@ func openKeyValueDatabase(o OpenOptions) (ethdb.Database, error) {
 // set on the provided OpenOptions.
 // The passed o.AncientDir indicates the path of root ancient directory where
 // the chain freezer can be opened.
+//
+// A remote database is opened without a local freezer, as it already serves the
+// ancient store of the remote node.
 func Open(o OpenOptions) (ethdb.Database, error) {
+	if o.Type == dbRemote {
+		return NewRemoteDatabase(o.Remote)
+	}
 	kvdb, err := openKeyValueDatabase(o)
 	if err != nil {
 		return nil, err
@@ -390,7 +431,7 @@ func Open(o OpenOptions) (ethdb.Database, error) {
 	if len(o.AncientsDirectory) == 0 {
 		return kvdb, nil
 	}
-	frdb, err := NewDatabaseWithFreezer(kvdb, o.AncientsDirectory, o.Namespace, o.ReadOnly)
+	frdb, err := newDatabaseWithFreezer(kvdb, o.AncientsDirectory, o.Namespace, o.ReadOnly, o.AncientCompression)
 	if err != nil {
 		kvdb.Close()
 		return nil, err
@@ -525,7 +566,7 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 				databaseVersionKey, headHeaderKey, headBlockKey, headFastBlockKey, headFinalizedBlockKey,
 				lastPivotKey, fastTrieProgressKey, snapshotDisabledKey, SnapshotRootKey, snapshotJournalKey,
 				snapshotGeneratorKey, snapshotRecoveryKey, txIndexTailKey, fastTxLookupLimitKey,
-				uncleanShutdownKey, badBlockKey, transitionStatusKey, skeletonSyncStatusKey,
+				uncleanShutdownKey, badBlockKey, bannedHashesKey, forkScheduleKey, transitionStatusKey, skeletonSyncStatusKey,
 			} {
 				if bytes.Equal(key, meta) {
 					metadata.Add(size)
@@ -67,8 +67,10 @@ type Freezer struct {
 	writeBatch *freezerBatch
 
 	readonly     bool
+	replica      bool                     // Whether the freezer is a read-only view of a freezer owned by another process
+	compression  FreezerCompression       // Compression of newly created compressed tables
 	tables       map[string]*freezerTable // Data tables for storing everything
-	instanceLock *flock.Flock             // File-system lock to prevent double opens
+	instanceLock *flock.Flock             // File-system lock to prevent double opens, nil for replicas
 	closeOnce    sync.Once
 }
 
@@ -78,12 +80,32 @@ func NewChainFreezer(datadir string, namespace string, readonly bool) (*Freezer,
 	return NewFreezer(datadir, namespace, readonly, freezerTableSize, chainFreezerNoSnappy)
 }
 
+// newChainFreezerWithCompression opens the chain freezer, creating missing
+// compressed tables with the given compression.
+func newChainFreezerWithCompression(datadir string, namespace string, readonly bool, compression FreezerCompression) (*Freezer, error) {
+	return openFreezer(datadir, namespace, readonly, false, freezerTableSize, chainFreezerNoSnappy, compression)
+}
+
 // NewFreezer creates a freezer instance for maintaining immutable ordered
 // data according to the given parameters.
 //
 // The 'tables' argument defines the data tables. If the value of a map
-// entry is true, snappy compression is disabled for the table.
+// entry is true, compression is disabled for the table, otherwise new tables
+// are snappy compressed.
 func NewFreezer(datadir string, namespace string, readonly bool, maxTableSize uint32, tables map[string]bool) (*Freezer, error) {
+	return openFreezer(datadir, namespace, readonly, false, maxTableSize, tables, FreezerSnappy)
+}
+
+// NewReplicaFreezer opens a read-only view of a freezer held open by another
+// process, without contending for its lock. The view tolerates the concurrent
+// writes of the owner and catches up with them via Refresh.
+func NewReplicaFreezer(datadir string, namespace string, maxTableSize uint32, tables map[string]bool) (*Freezer, error) {
+	return openFreezer(datadir, namespace, true, true, maxTableSize, tables, FreezerSnappy)
+}
+
+// openFreezer opens a freezer, locking it unless it's a replica. Compressed
+// tables created anew use the given compression.
+func openFreezer(datadir string, namespace string, readonly bool, replica bool, maxTableSize uint32, tables map[string]bool, compression FreezerCompression) (*Freezer, error) {
 	// Create the initial freezer object
 	var (
 		readMeter  = metrics.NewRegisteredMeter(namespace+"ancient/read", nil)
@@ -97,33 +119,40 @@ func NewFreezer(datadir string, namespace string, readonly bool, maxTableSize ui
 			return nil, errSymlinkDatadir
 		}
 	}
-	flockFile := filepath.Join(datadir, "FLOCK")
-	if err := os.MkdirAll(filepath.Dir(flockFile), 0755); err != nil {
-		return nil, err
-	}
-	// Leveldb uses LOCK as the filelock filename. To prevent the
-	// name collision, we use FLOCK as the lock name.
-	lock := flock.New(flockFile)
-	if locked, err := lock.TryLock(); err != nil {
-		return nil, err
-	} else if !locked {
-		return nil, errors.New("locking failed")
+	var lock *flock.Flock
+	if !replica {
+		flockFile := filepath.Join(datadir, "FLOCK")
+		if err := os.MkdirAll(filepath.Dir(flockFile), 0755); err != nil {
+			return nil, err
+		}
+		// Leveldb uses LOCK as the filelock filename. To prevent the
+		// name collision, we use FLOCK as the lock name.
+		lock = flock.New(flockFile)
+		if locked, err := lock.TryLock(); err != nil {
+			return nil, err
+		} else if !locked {
+			return nil, errors.New("locking failed")
+		}
 	}
 	// Open all the supported data tables
 	freezer := &Freezer{
 		readonly:     readonly,
+		replica:      replica,
+		compression:  compression,
 		tables:       make(map[string]*freezerTable),
 		instanceLock: lock,
 	}
 
 	// Create the tables.
 	for name, disableSnappy := range tables {
-		table, err := newTable(datadir, name, readMeter, writeMeter, sizeGauge, maxTableSize, disableSnappy, readonly)
+		table, err := openTable(datadir, name, readMeter, writeMeter, sizeGauge, maxTableSize, disableSnappy, compression, readonly, replica)
 		if err != nil {
 			for _, table := range freezer.tables {
 				table.Close()
 			}
-			lock.Unlock()
+			if lock != nil {
+				lock.Unlock()
+			}
 			return nil, err
 		}
 		freezer.tables[name] = table
@@ -141,7 +170,9 @@ func NewFreezer(datadir string, namespace string, readonly bool, maxTableSize ui
 		for _, table := range freezer.tables {
 			table.Close()
 		}
-		lock.Unlock()
+		if lock != nil {
+			lock.Unlock()
+		}
 		return nil, err
 	}
 
@@ -164,8 +195,10 @@ func (f *Freezer) Close() error {
 				errs = append(errs, err)
 			}
 		}
-		if err := f.instanceLock.Unlock(); err != nil {
-			errs = append(errs, err)
+		if f.instanceLock != nil {
+			if err := f.instanceLock.Unlock(); err != nil {
+				errs = append(errs, err)
+			}
 		}
 	})
 	if errs != nil {
@@ -341,6 +374,21 @@ func (f *Freezer) validate() error {
 		name = kind
 		break
 	}
+	// A replica may observe an append or a tail truncation of the owner in the
+	// middle of its tables, settle for the range present in all of them.
+	if f.replica {
+		for _, table := range f.tables {
+			if items := table.items.Load(); items < head {
+				head = items
+			}
+			if hidden := table.itemHidden.Load(); hidden > tail {
+				tail = hidden
+			}
+		}
+		f.frozen.Store(head)
+		f.tail.Store(tail)
+		return nil
+	}
 	// Now check every table against those boundaries.
 	for kind, table := range f.tables {
 		if head != table.items.Load() {
@@ -355,6 +403,23 @@ func (f *Freezer) validate() error {
 	return nil
 }
 
+// Refresh catches up a replica with the writes of the process owning the
+// freezer.
+func (f *Freezer) Refresh() error {
+	if !f.replica {
+		return errors.New("refreshing a non-replica freezer")
+	}
+	f.writeLock.Lock()
+	defer f.writeLock.Unlock()
+
+	for _, table := range f.tables {
+		if err := table.refresh(); err != nil {
+			return err
+		}
+	}
+	return f.validate()
+}
+
 // repair truncates all data tables to the same length.
 func (f *Freezer) repair() error {
 	var (
@@ -495,3 +560,119 @@ func (f *Freezer) MigrateTable(kind string, convert convertLegacyFn) error {
 	}
 	return nil
 }
+
+// CompressTable rewrites the items of a compressed table with the given
+// compression, dropping the items hidden at its tail. Writes to the freezer are
+// blocked until done and the table is replaced in place. The table files are
+// swapped non-atomically at the end, an interrupted swap corrupts the table.
+func (f *Freezer) CompressTable(kind string, compression FreezerCompression) error {
+	if f.readonly {
+		return errReadOnly
+	}
+	f.writeLock.Lock()
+	defer f.writeLock.Unlock()
+
+	table, ok := f.tables[kind]
+	if !ok {
+		return errUnknownTable
+	}
+	if table.noCompression {
+		return fmt.Errorf("freezer table %s is not compressed", kind)
+	}
+	if table.compression == compression {
+		return nil
+	}
+	var (
+		ancientsPath  = filepath.Dir(table.index.Name())
+		migrationPath = filepath.Join(ancientsPath, "compression")
+		tail          = table.itemHidden.Load()
+		items         = table.items.Load()
+	)
+	// Set up a new dir for the rewritten table, discarding any leftover of an
+	// interrupted attempt. The new table starts at the tail of the old one.
+	if err := os.RemoveAll(migrationPath); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(migrationPath, 0755); err != nil {
+		return err
+	}
+	if tail > 0 {
+		entry := indexEntry{offset: uint32(tail)}
+		if err := os.WriteFile(filepath.Join(migrationPath, fmt.Sprintf("%s.cidx", kind)), entry.append(nil), 0644); err != nil {
+			return err
+		}
+	}
+	newTable, err := openTable(migrationPath, kind, metrics.NilMeter{}, metrics.NilMeter{}, metrics.NilGauge{}, table.maxFileSize, false, compression, false, false)
+	if err != nil {
+		return err
+	}
+	var (
+		batch  = newTable.newBatch()
+		start  = time.Now()
+		logged = time.Now()
+	)
+	for i := tail; i < items; {
+		data, err := table.RetrieveItems(i, 1024, 1024*1024)
+		if err != nil {
+			newTable.Close()
+			return err
+		}
+		for _, item := range data {
+			if err := batch.AppendRaw(i, item); err != nil {
+				newTable.Close()
+				return err
+			}
+			i++
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Compressing freezer table", "table", kind, "compression", compression, "items", i-tail, "remaining", items-i, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if err := batch.commit(); err != nil {
+		newTable.Close()
+		return err
+	}
+	if err := newTable.Close(); err != nil {
+		return err
+	}
+	// Replace the old table files with the rewritten ones and reopen the table
+	size, err := table.size()
+	if err != nil {
+		return err
+	}
+	table.sizeGauge.Dec(int64(size))
+	if err := table.Close(); err != nil {
+		return err
+	}
+	stale, err := filepath.Glob(filepath.Join(ancientsPath, fmt.Sprintf("%s.*.cdat", kind)))
+	if err != nil {
+		return err
+	}
+	for _, path := range stale {
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+	files, err := os.ReadDir(migrationPath)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		// This replaces the old index and metadata files as a side-effect
+		if err := os.Rename(filepath.Join(migrationPath, file.Name()), filepath.Join(ancientsPath, file.Name())); err != nil {
+			return err
+		}
+	}
+	if err := os.Remove(migrationPath); err != nil {
+		return err
+	}
+	reopened, err := openTable(ancientsPath, kind, table.readMeter, table.writeMeter, table.sizeGauge, table.maxFileSize, false, compression, false, false)
+	if err != nil {
+		return err
+	}
+	f.tables[kind] = reopened
+	f.writeBatch.tables[kind] = reopened.newBatch()
+	log.Info("Compressed freezer table", "table", kind, "compression", compression, "items", items-tail, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
@@ -80,7 +80,7 @@ func (batch *freezerBatch) commit() (item uint64, writeSize int64, err error) {
 type freezerTableBatch struct {
 	t *freezerTable
 
-	sb          *snappyBuffer
+	compressor  itemCompressor
 	encBuffer   writeBuffer
 	dataBuffer  []byte
 	indexBuffer []byte
@@ -92,7 +92,7 @@ type freezerTableBatch struct {
 func (t *freezerTable) newBatch() *freezerTableBatch {
 	batch := &freezerTableBatch{t: t}
 	if !t.noCompression {
-		batch.sb = new(snappyBuffer)
+		batch.compressor = newItemCompressor(t.compression)
 	}
 	batch.reset()
 	return batch
@@ -120,8 +120,8 @@ func (batch *freezerTableBatch) Append(item uint64, data interface{}) error {
 		return err
 	}
 	encItem := batch.encBuffer.data
-	if batch.sb != nil {
-		encItem = batch.sb.compress(encItem)
+	if batch.compressor != nil {
+		encItem = batch.compressor.compress(encItem)
 	}
 	return batch.appendItem(encItem)
 }
@@ -135,8 +135,8 @@ func (batch *freezerTableBatch) AppendRaw(item uint64, blob []byte) error {
 	}
 
 	encItem := blob
-	if batch.sb != nil {
-		encItem = batch.sb.compress(blob)
+	if batch.compressor != nil {
+		encItem = batch.compressor.compress(blob)
 	}
 	return batch.appendItem(encItem)
 }
@@ -0,0 +1,117 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package rawdb
+
+import (
+	"fmt"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// FreezerCompression is the compression algorithm of the items of a compressed
+// freezer table. It's recorded in the table metadata, so a table is read with
+// the algorithm it was written with regardless of the configured one, which
+// only applies to newly created tables.
+type FreezerCompression uint8
+
+const (
+	FreezerSnappy FreezerCompression = iota // Snappy block format, the legacy default
+	FreezerZstd                             // Zstandard frames, smaller but slower to write
+)
+
+// ParseFreezerCompression parses the name of a freezer compression algorithm.
+func ParseFreezerCompression(name string) (FreezerCompression, error) {
+	switch name {
+	case "", "snappy":
+		return FreezerSnappy, nil
+	case "zstd":
+		return FreezerZstd, nil
+	default:
+		return 0, fmt.Errorf("unknown freezer compression %q, supported ones: snappy, zstd", name)
+	}
+}
+
+// String implements fmt.Stringer.
+func (c FreezerCompression) String() string {
+	switch c {
+	case FreezerSnappy:
+		return "snappy"
+	case FreezerZstd:
+		return "zstd"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(c))
+	}
+}
+
+// Shared zstd coders, both are safe for concurrent use via EncodeAll and
+// DecodeAll.
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedBetterCompression), zstd.WithEncoderConcurrency(1))
+	zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0))
+)
+
+// itemCompressor compresses the items appended to a freezer table, reusing its
+// output buffer between items.
+type itemCompressor interface {
+	compress(data []byte) []byte
+}
+
+// newItemCompressor creates a compressor of the given algorithm.
+func newItemCompressor(compression FreezerCompression) itemCompressor {
+	if compression == FreezerZstd {
+		return new(zstdBuffer)
+	}
+	return new(snappyBuffer)
+}
+
+// zstdBuffer writes single zstd frames, and can be reused.
+type zstdBuffer struct {
+	dst []byte
+}
+
+// compress zstd-compresses the data.
+func (z *zstdBuffer) compress(data []byte) []byte {
+	z.dst = zstdEncoder.EncodeAll(data, z.dst[:0])
+	return z.dst
+}
+
+// decodedLen returns the length of a compressed item once decompressed.
+func decodedLen(compression FreezerCompression, item []byte) (int, error) {
+	if compression == FreezerZstd {
+		var header zstd.Header
+		if err := header.Decode(item); err != nil {
+			return 0, err
+		}
+		if !header.HasFCS {
+			return len(item), nil
+		}
+		return int(header.FrameContentSize), nil
+	}
+	return snappy.DecodedLen(item)
+}
+
+// decompress decompresses an item of a compressed table.
+func decompress(compression FreezerCompression, item []byte) ([]byte, error) {
+	if compression == FreezerZstd {
+		data, err := zstdDecoder.DecodeAll(item, nil)
+		if err != nil {
+			return nil, err
+		}
+		if data == nil {
+			data = []byte{}
+		}
+		return data, nil
+	}
+	return snappy.Decode(nil, item)
+}
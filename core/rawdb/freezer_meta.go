@@ -32,13 +32,19 @@ type freezerTableMeta struct {
 	// plus the number of items hidden in the table, so it should never
 	// be lower than the "actual tail".
 	VirtualTail uint64
+
+	// Compression is the compression algorithm of the items of compressed
+	// tables. It's omitted for snappy, keeping the legacy encoding.
+	Compression FreezerCompression `rlp:"optional"`
 }
 
-// newMetadata initializes the metadata object with the given virtual tail.
-func newMetadata(tail uint64) *freezerTableMeta {
+// newMetadata initializes the metadata object with the given virtual tail and
+// compression.
+func newMetadata(tail uint64, compression FreezerCompression) *freezerTableMeta {
 	return &freezerTableMeta{
 		Version:     freezerVersion,
 		VirtualTail: tail,
+		Compression: compression,
 	}
 }
 
@@ -67,9 +73,9 @@ func writeMetadata(file *os.File, meta *freezerTableMeta) error {
 }
 
 // loadMetadata loads the metadata from the given metadata file.
-// Initializes the metadata file with the given "actual tail" if
-// it's empty.
-func loadMetadata(file *os.File, tail uint64) (*freezerTableMeta, error) {
+// Initializes the metadata file with the given "actual tail" and
+// compression if it's empty.
+func loadMetadata(file *os.File, tail uint64, compression FreezerCompression) (*freezerTableMeta, error) {
 	stat, err := file.Stat()
 	if err != nil {
 		return nil, err
@@ -81,7 +87,7 @@ func loadMetadata(file *os.File, tail uint64) (*freezerTableMeta, error) {
 	// In both cases, write the meta into the file with the actual tail
 	// as the virtual tail.
 	if stat.Size() == 0 {
-		m := newMetadata(tail)
+		m := newMetadata(tail, compression)
 		if err := writeMetadata(file, m); err != nil {
 			return nil, err
 		}
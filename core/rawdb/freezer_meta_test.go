@@ -22,7 +22,7 @@ func TestReadWriteFreezerTableMeta(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to create file %v", err)
 	}
-	err = writeMetadata(f, newMetadata(100))
+	err = writeMetadata(f, newMetadata(100, FreezerSnappy))
 	if err != nil {
 		t.Fatalf("Failed to write metadata %v", err)
 	}
@@ -43,7 +43,7 @@ func TestInitializeFreezerTableMeta(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to create file %v", err)
 	}
-	meta, err := loadMetadata(f, uint64(100))
+	meta, err := loadMetadata(f, uint64(100), FreezerSnappy)
 	if err != nil {
 		t.Fatalf("Failed to read metadata %v", err)
 	}
@@ -26,7 +26,6 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
-	"github.com/golang/snappy"
 )
 
 var (
@@ -81,7 +80,7 @@ func (i *indexEntry) bounds(end *indexEntry) (startOffset, endOffset, fileId uin
 }
 
 // freezerTable represents a single chained data table within the freezer (e.g. blocks).
-// It consists of a data file (snappy encoded arbitrary data blobs) and an indexEntry
+// It consists of a data file (snappy or zstd encoded arbitrary data blobs) and an indexEntry
 // file (uncompressed 64 bit indices into the data file).
 type freezerTable struct {
 	items      atomic.Uint64 // Number of items stored in the table (including items removed from tail)
@@ -94,8 +93,10 @@ type freezerTable struct {
 	// should never be lower than itemOffset.
 	itemHidden atomic.Uint64
 
-	noCompression bool // if true, disables snappy compression. Note: does not work retroactively
+	noCompression bool               // if true, disables compression. Note: does not work retroactively
+	compression   FreezerCompression // Compression of the items, as recorded in the metadata
 	readonly      bool
+	replica       bool   // Whether the table is a read-only view of a table written by another process
 	maxFileSize   uint32 // Max file size for data-files
 	name          string
 	path          string
@@ -125,6 +126,13 @@ func newFreezerTable(path, name string, disableSnappy, readonly bool) (*freezerT
 // non-existent. Both files are truncated to the shortest common length to ensure
 // they don't go out of sync.
 func newTable(path string, name string, readMeter metrics.Meter, writeMeter metrics.Meter, sizeGauge metrics.Gauge, maxFilesize uint32, noCompression, readonly bool) (*freezerTable, error) {
+	return openTable(path, name, readMeter, writeMeter, sizeGauge, maxFilesize, noCompression, FreezerSnappy, readonly, false)
+}
+
+// openTable opens a freezer table, either owned or as a read-only replica of a
+// table written by another process. The compression only applies if the table
+// is compressed and created anew, existing tables keep their recorded one.
+func openTable(path string, name string, readMeter metrics.Meter, writeMeter metrics.Meter, sizeGauge metrics.Gauge, maxFilesize uint32, noCompression bool, compression FreezerCompression, readonly bool, replica bool) (*freezerTable, error) {
 	// Ensure the containing directory exists and open the indexEntry file
 	if err := os.MkdirAll(path, 0755); err != nil {
 		return nil, err
@@ -172,7 +180,9 @@ func newTable(path string, name string, readMeter metrics.Meter, writeMeter metr
 		path:          path,
 		logger:        log.New("database", path, "table", name),
 		noCompression: noCompression,
+		compression:   compression,
 		readonly:      readonly,
+		replica:       replica,
 		maxFileSize:   maxFilesize,
 	}
 	if err := tab.repair(); err != nil {
@@ -207,14 +217,18 @@ func (t *freezerTable) repair() error {
 		}
 	}
 	// Ensure the index is a multiple of indexEntrySize bytes
-	if overflow := stat.Size() % indexEntrySize; overflow != 0 {
+	if overflow := stat.Size() % indexEntrySize; overflow != 0 && !t.replica {
 		truncateFreezerFile(t.index, stat.Size()-overflow) // New file can't trigger this path
 	}
-	// Retrieve the file sizes and prepare for truncation
+	// Retrieve the file sizes and prepare for truncation. Replicas ignore the
+	// partially written index entry of a concurrent append.
 	if stat, err = t.index.Stat(); err != nil {
 		return err
 	}
 	offsetsSize := stat.Size()
+	if t.replica {
+		offsetsSize -= offsetsSize % indexEntrySize
+	}
 
 	// Open the head file
 	var (
@@ -236,12 +250,22 @@ func (t *freezerTable) repair() error {
 	t.tailId = firstIndex.filenum
 	t.itemOffset.Store(uint64(firstIndex.offset))
 
-	// Load metadata from the file
-	meta, err := loadMetadata(t.meta, t.itemOffset.Load())
+	// Load metadata from the file. Tables holding items without metadata
+	// predate it and are snappy compressed, only empty tables adopt the
+	// configured compression.
+	compression := t.compression
+	if t.noCompression || offsetsSize > indexEntrySize {
+		compression = FreezerSnappy
+	}
+	meta, err := loadMetadata(t.meta, t.itemOffset.Load(), compression)
 	if err != nil {
 		return err
 	}
+	if meta.Compression > FreezerZstd {
+		return fmt.Errorf("freezer table %s has unsupported compression %v", t.name, meta.Compression)
+	}
 	t.itemHidden.Store(meta.VirtualTail)
+	t.compression = meta.Compression
 
 	// Read the last index, use the default value in case the freezer is empty
 	if offsetsSize == indexEntrySize {
@@ -266,6 +290,13 @@ func (t *freezerTable) repair() error {
 	// Keep truncating both files until they come in sync
 	contentExp = int64(lastIndex.offset)
 	for contentExp != contentSize {
+		// Replicas leave the dangling head of a concurrent append alone
+		if t.replica {
+			if contentExp > contentSize {
+				return fmt.Errorf("freezer table %s index points beyond the head file: %d > %d", t.name, contentExp, contentSize)
+			}
+			break
+		}
 		verbose = true
 		// Truncate the head file to the last offset pointer
 		if contentExp < contentSize {
@@ -325,14 +356,14 @@ func (t *freezerTable) repair() error {
 	}
 	// Update the item and byte counters and return
 	t.items.Store(t.itemOffset.Load() + uint64(offsetsSize/indexEntrySize-1)) // last indexEntry points to the end of the data file
-	t.headBytes = contentSize
+	t.headBytes = contentExp
 	t.headId = lastIndex.filenum
 
 	// Delete the leftover files because of head deletion
-	t.releaseFilesAfter(t.headId, true)
+	t.releaseFilesAfter(t.headId, !t.readonly)
 
 	// Delete the leftover files because of tail deletion
-	t.releaseFilesBefore(t.tailId, true)
+	t.releaseFilesBefore(t.tailId, !t.readonly)
 
 	// Close opened files and preopen all files
 	if err := t.preopen(); err != nil {
@@ -369,6 +400,81 @@ func (t *freezerTable) preopen() (err error) {
 	return err
 }
 
+// refresh catches up a read-only table with the items appended and removed by
+// the process owning it.
+func (t *freezerTable) refresh() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.index == nil {
+		return errClosed
+	}
+	// Reopen the index and metadata files, tail truncations replacing them
+	index, err := openFreezerFileForReadOnly(t.index.Name())
+	if err != nil {
+		return err
+	}
+	meta, err := openFreezerFileForReadOnly(t.meta.Name())
+	if err != nil {
+		index.Close()
+		return err
+	}
+	fail := func(err error) error {
+		index.Close()
+		meta.Close()
+		return err
+	}
+	stat, err := index.Stat()
+	if err != nil {
+		return fail(err)
+	}
+	offsetsSize := stat.Size() - stat.Size()%indexEntrySize
+	if offsetsSize < indexEntrySize {
+		return fail(fmt.Errorf("freezer table %s index is empty", t.name))
+	}
+	var (
+		buffer                = make([]byte, indexEntrySize)
+		firstIndex, lastIndex indexEntry
+	)
+	if _, err := index.ReadAt(buffer, 0); err != nil {
+		return fail(err)
+	}
+	firstIndex.unmarshalBinary(buffer)
+	if _, err := index.ReadAt(buffer, offsetsSize-indexEntrySize); err != nil {
+		return fail(err)
+	}
+	lastIndex.unmarshalBinary(buffer)
+	if offsetsSize == indexEntrySize {
+		lastIndex = indexEntry{filenum: firstIndex.filenum}
+	}
+	m, err := readMetadata(meta)
+	if err != nil {
+		return fail(err)
+	}
+	// Open the data files created since the last refresh
+	for num := firstIndex.filenum; num <= lastIndex.filenum; num++ {
+		if _, err := t.openFile(num, openFreezerFileForReadOnly); err != nil {
+			return fail(err)
+		}
+	}
+	t.index.Close()
+	t.meta.Close()
+	t.index, t.meta = index, meta
+
+	t.tailId = firstIndex.filenum
+	t.itemOffset.Store(uint64(firstIndex.offset))
+	if m.VirtualTail < t.itemOffset.Load() {
+		m.VirtualTail = t.itemOffset.Load()
+	}
+	t.itemHidden.Store(m.VirtualTail)
+	t.releaseFilesBefore(t.tailId, false)
+
+	t.head, t.headId = t.files[lastIndex.filenum], lastIndex.filenum
+	t.headBytes = int64(lastIndex.offset)
+	t.items.Store(t.itemOffset.Load() + uint64(offsetsSize/indexEntrySize-1))
+	return nil
+}
+
 // truncateHead discards any recent data above the provided threshold number.
 func (t *freezerTable) truncateHead(items uint64) error {
 	t.lock.Lock()
@@ -472,7 +578,7 @@ func (t *freezerTable) truncateTail(items uint64) error {
 	}
 	// Update the virtual tail marker and hidden these entries in table.
 	t.itemHidden.Store(items)
-	if err := writeMetadata(t.meta, newMetadata(items)); err != nil {
+	if err := writeMetadata(t.meta, newMetadata(items, t.compression)); err != nil {
 		return err
 	}
 	// Hidden items still fall in the current tail file, no data file
@@ -706,13 +812,13 @@ func (t *freezerTable) RetrieveItems(start, count, maxBytes uint64) ([][]byte, e
 		offset += diskSize
 		decompressedSize := diskSize
 		if !t.noCompression {
-			decompressedSize, _ = snappy.DecodedLen(item)
+			decompressedSize, _ = decodedLen(t.compression, item)
 		}
 		if i > 0 && uint64(outputSize+decompressedSize) > maxBytes {
 			break
 		}
 		if !t.noCompression {
-			data, err := snappy.Decode(nil, item)
+			data, err := decompress(t.compression, item)
 			if err != nil {
 				return nil, err
 			}
@@ -917,8 +1023,8 @@ func (t *freezerTable) dumpIndex(w io.Writer, start, stop int64) {
 		fmt.Fprintf(w, "Failed to decode freezer table %v\n", err)
 		return
 	}
-	fmt.Fprintf(w, "Version %d count %d, deleted %d, hidden %d\n", meta.Version,
-		t.items.Load(), t.itemOffset.Load(), t.itemHidden.Load())
+	fmt.Fprintf(w, "Version %d count %d, deleted %d, hidden %d, compression %v\n", meta.Version,
+		t.items.Load(), t.itemOffset.Load(), t.itemHidden.Load(), meta.Compression)
 
 	buf := make([]byte, indexEntrySize)
 
@@ -425,3 +425,104 @@ func TestFreezerCloseSync(t *testing.T) {
 		t.Fatalf("want %v, have %v", have, want)
 	}
 }
+
+// Tests that compressed tables are created with the configured compression,
+// keep it when reopened with another one, and are converted in place.
+func TestFreezerCompression(t *testing.T) {
+	t.Parallel()
+
+	var (
+		dir    = t.TempDir()
+		tables = map[string]bool{"raw": true, "rlp": false}
+		values [][]byte
+	)
+	for x := 0; x < 100; x++ {
+		values = append(values, bytes.Repeat([]byte{byte(x)}, 256))
+	}
+	f, err := openFreezer(dir, "", false, false, 2049, tables, FreezerZstd)
+	if err != nil {
+		t.Fatal("can't open freezer", err)
+	}
+	if _, err := f.ModifyAncients(func(op ethdb.AncientWriteOp) error {
+		for i, value := range values {
+			if err := op.AppendRaw("raw", uint64(i), value); err != nil {
+				return err
+			}
+			if err := op.AppendRaw("rlp", uint64(i), value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		t.Fatal("ModifyAncients failed:", err)
+	}
+	if have := f.tables["rlp"].compression; have != FreezerZstd {
+		t.Fatalf("compressed table compression mismatch: have %v, want %v", have, FreezerZstd)
+	}
+	if have := f.tables["raw"].compression; have != FreezerSnappy {
+		t.Fatalf("raw table compression mismatch: have %v, want %v", have, FreezerSnappy)
+	}
+	check := func(f *Freezer, from uint64) {
+		t.Helper()
+		for i := from; i < uint64(len(values)); i++ {
+			for _, kind := range []string{"raw", "rlp"} {
+				if v, err := f.Ancient(kind, i); err != nil || !bytes.Equal(v, values[i]) {
+					t.Fatalf("wrong %s value at %d: %x, %v", kind, i, v, err)
+				}
+			}
+		}
+		items, err := f.AncientRange("rlp", from, uint64(len(values)), 4*256)
+		if err != nil || len(items) != 4 {
+			t.Fatalf("byte limited range mismatch: have %d items, %v, want 4", len(items), err)
+		}
+	}
+	check(f, 0)
+	f.Close()
+
+	// Reopening with another compression keeps the recorded one
+	if f, err = NewFreezer(dir, "", false, 2049, tables); err != nil {
+		t.Fatal("can't reopen freezer", err)
+	}
+	if have := f.tables["rlp"].compression; have != FreezerZstd {
+		t.Fatalf("reopened table compression mismatch: have %v, want %v", have, FreezerZstd)
+	}
+	check(f, 0)
+
+	// Convert the tail truncated table to snappy and back
+	if err := f.TruncateTail(30); err != nil {
+		t.Fatal("failed to truncate tail", err)
+	}
+	if err := f.CompressTable("raw", FreezerZstd); err == nil {
+		t.Fatal("raw table compressed")
+	}
+	for _, compression := range []FreezerCompression{FreezerSnappy, FreezerZstd} {
+		if err := f.CompressTable("rlp", compression); err != nil {
+			t.Fatalf("failed to convert table to %v: %v", compression, err)
+		}
+		if have := f.tables["rlp"].compression; have != compression {
+			t.Fatalf("converted table compression mismatch: have %v, want %v", have, compression)
+		}
+		if _, err := f.Ancient("rlp", 29); err == nil {
+			t.Fatalf("truncated item retained after converting to %v", compression)
+		}
+		check(f, 30)
+	}
+	if _, err := f.ModifyAncients(func(op ethdb.AncientWriteOp) error {
+		if err := op.AppendRaw("raw", 100, values[0]); err != nil {
+			return err
+		}
+		return op.AppendRaw("rlp", 100, values[0])
+	}); err != nil {
+		t.Fatal("failed to append to converted table:", err)
+	}
+	f.Close()
+
+	if f, err = NewFreezer(dir, "", false, 2049, tables); err != nil {
+		t.Fatal("can't reopen converted freezer", err)
+	}
+	defer f.Close()
+	if frozen, _ := f.Ancients(); frozen != 101 {
+		t.Fatalf("converted freezer items mismatch: have %d, want 101", frozen)
+	}
+	check(f, 30)
+}
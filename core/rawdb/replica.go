@@ -0,0 +1,351 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package rawdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/leveldb"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// Markers of the values stored in the overlay of a replica, telling apart the
+// local writes from the local deletions.
+const (
+	overlayDeleted = 0x00
+	overlayWritten = 0x01
+)
+
+// errNotFound is returned when retrieving a key deleted in the overlay.
+var errNotFound = errors.New("not found")
+
+// replicadb is a read-only view of the chain database of another process, used
+// to serve requests off the process owning the database. The local writes of
+// the process are kept in memory, overlaid on the view and never persisted.
+type replicadb struct {
+	ancientRoot string
+	ethdb.KeyValueStore
+	ethdb.AncientStore
+
+	kvdb    *leveldb.Replica
+	freezer *Freezer
+}
+
+// NewReplicaDatabase opens a read-only view of the chain database held open by
+// another process at the given path, along with its chain freezer. The view is
+// refreshed via Refresh to catch up with the writes of the owner.
+func NewReplicaDatabase(file string, ancient string, cache int, handles int, namespace string) (ethdb.Database, error) {
+	if hasPreexistingDb(file) == dbPebble {
+		return nil, errors.New("replica of pebble database not supported")
+	}
+	kvdb, err := leveldb.NewReplica(file, cache, handles)
+	if err != nil {
+		return nil, err
+	}
+	freezer, err := NewReplicaFreezer(resolveChainFreezerDir(ancient), namespace, freezerTableSize, chainFreezerNoSnappy)
+	if err != nil {
+		kvdb.Close()
+		return nil, err
+	}
+	log.Info("Opened replica of chain database", "database", file, "ancient", ancient)
+	return &replicadb{
+		ancientRoot:   ancient,
+		KeyValueStore: &overlaydb{base: kvdb, mem: memorydb.New()},
+		AncientStore:  freezer,
+		kvdb:          kvdb,
+		freezer:       freezer,
+	}, nil
+}
+
+// AncientDatadir returns the path of root ancient directory.
+func (db *replicadb) AncientDatadir() (string, error) {
+	return db.ancientRoot, nil
+}
+
+// Refresh catches up with the writes of the process owning the database. The
+// freezer is refreshed first, so that chain segments moved into it meanwhile
+// are not missed. The local writes are discarded, as they would otherwise keep
+// shadowing the progress of the owner, e.g. with stale head markers.
+func (db *replicadb) Refresh() error {
+	if err := db.freezer.Refresh(); err != nil {
+		return err
+	}
+	if err := db.kvdb.Refresh(); err != nil {
+		return err
+	}
+	return db.KeyValueStore.(*overlaydb).reset()
+}
+
+// Close closes both the key-value view and the freezer view.
+func (db *replicadb) Close() error {
+	var errs []error
+	if err := db.AncientStore.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := db.KeyValueStore.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("%v", errs)
+	}
+	return nil
+}
+
+// remotedb is a read-only view of the chain database shared by a remote node.
+// The local writes are kept in memory, overlaid on the remote key-value store.
+type remotedb struct {
+	ethdb.KeyValueStore
+	ethdb.AncientStore
+}
+
+// AncientDatadir returns an empty path, the ancient store not being local.
+func (db *remotedb) AncientDatadir() (string, error) {
+	return "", nil
+}
+
+// Refresh discards the local writes, which would otherwise keep shadowing the
+// progress of the remote node. The remote store itself is always current.
+func (db *remotedb) Refresh() error {
+	return db.KeyValueStore.(*overlaydb).reset()
+}
+
+// Close closes the connection to the remote node, discarding the overlay.
+func (db *remotedb) Close() error {
+	return db.KeyValueStore.Close()
+}
+
+// overlaydb is a key-value store keeping its writes in memory, overlaid on a
+// read-only base store.
+type overlaydb struct {
+	base ethdb.KeyValueStore
+	mem  *memorydb.Database // Local writes and deletions, prefixed by their marker
+}
+
+// Has retrieves if a key is present in the overlay or the base store.
+func (db *overlaydb) Has(key []byte) (bool, error) {
+	if value, err := db.mem.Get(key); err == nil {
+		return value[0] == overlayWritten, nil
+	}
+	return db.base.Has(key)
+}
+
+// Get retrieves the given key from the overlay or the base store.
+func (db *overlaydb) Get(key []byte) ([]byte, error) {
+	if value, err := db.mem.Get(key); err == nil {
+		if value[0] == overlayDeleted {
+			return nil, errNotFound
+		}
+		return common.CopyBytes(value[1:]), nil
+	}
+	return db.base.Get(key)
+}
+
+// reset discards all the local writes and deletions.
+func (db *overlaydb) reset() error {
+	it := db.mem.NewIterator(nil, nil)
+	defer it.Release()
+
+	for it.Next() {
+		if err := db.mem.Delete(it.Key()); err != nil {
+			return err
+		}
+	}
+	return it.Error()
+}
+
+// Put inserts the given value into the overlay.
+func (db *overlaydb) Put(key []byte, value []byte) error {
+	return db.mem.Put(key, append([]byte{overlayWritten}, value...))
+}
+
+// Delete hides the key of the base store in the overlay.
+func (db *overlaydb) Delete(key []byte) error {
+	return db.mem.Put(key, []byte{overlayDeleted})
+}
+
+// NewBatch creates a write-only key-value store that buffers changes to the
+// overlay until a final write is called.
+func (db *overlaydb) NewBatch() ethdb.Batch {
+	return &overlayBatch{db.mem.NewBatch()}
+}
+
+// NewBatchWithSize creates a write-only database batch with pre-allocated buffer.
+func (db *overlaydb) NewBatchWithSize(size int) ethdb.Batch {
+	return &overlayBatch{db.mem.NewBatchWithSize(size)}
+}
+
+// NewIterator creates a binary-alphabetical iterator over the overlay merged
+// with the base store.
+func (db *overlaydb) NewIterator(prefix []byte, start []byte) ethdb.Iterator {
+	it := &overlayIterator{
+		mem:  db.mem.NewIterator(prefix, start),
+		base: db.base.NewIterator(prefix, start),
+	}
+	it.memOk, it.baseOk = it.mem.Next(), it.base.Next()
+	return it
+}
+
+// NewSnapshot creates a snapshot of the overlay and the base store.
+func (db *overlaydb) NewSnapshot() (ethdb.Snapshot, error) {
+	mem, err := db.mem.NewSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	base, err := db.base.NewSnapshot()
+	if err != nil {
+		mem.Release()
+		return nil, err
+	}
+	return &overlaySnapshot{mem: mem, base: base}, nil
+}
+
+// Stat returns a particular internal stat of the base store.
+func (db *overlaydb) Stat(property string) (string, error) {
+	return db.base.Stat(property)
+}
+
+// Compact is a noop, the base store being read-only.
+func (db *overlaydb) Compact(start []byte, limit []byte) error {
+	return nil
+}
+
+// Close closes the base store, discarding the overlay.
+func (db *overlaydb) Close() error {
+	db.mem.Close()
+	return db.base.Close()
+}
+
+// overlayBatch is a batch of writes and deletions to the overlay.
+type overlayBatch struct {
+	ethdb.Batch
+}
+
+// Put inserts the given value into the batch for later committing.
+func (b *overlayBatch) Put(key, value []byte) error {
+	return b.Batch.Put(key, append([]byte{overlayWritten}, value...))
+}
+
+// Delete inserts a key removal into the batch for later committing.
+func (b *overlayBatch) Delete(key []byte) error {
+	return b.Batch.Put(key, []byte{overlayDeleted})
+}
+
+// Replay replays the batch contents, stripped of their overlay markers.
+func (b *overlayBatch) Replay(w ethdb.KeyValueWriter) error {
+	return b.Batch.Replay(&overlayReplayer{w})
+}
+
+// overlayReplayer translates the overlay writes into writes and deletions.
+type overlayReplayer struct {
+	ethdb.KeyValueWriter
+}
+
+func (r *overlayReplayer) Put(key, value []byte) error {
+	if value[0] == overlayDeleted {
+		return r.KeyValueWriter.Delete(key)
+	}
+	return r.KeyValueWriter.Put(key, value[1:])
+}
+
+// overlayIterator merges the iteration over the overlay with the iteration over
+// the base store, the overlay taking precedence.
+type overlayIterator struct {
+	mem, base     ethdb.Iterator
+	memOk, baseOk bool // Whether the iterators are positioned on an entry
+
+	key, value []byte
+}
+
+// Next moves the iterator to the next key/value pair. It returns whether the
+// iterator is exhausted.
+func (it *overlayIterator) Next() bool {
+	for it.memOk || it.baseOk {
+		if !it.memOk || (it.baseOk && bytes.Compare(it.base.Key(), it.mem.Key()) < 0) {
+			it.key, it.value = common.CopyBytes(it.base.Key()), common.CopyBytes(it.base.Value())
+			it.baseOk = it.base.Next()
+			return true
+		}
+		// The overlay is positioned on the smallest key, shadowing the base store
+		if it.baseOk && bytes.Equal(it.base.Key(), it.mem.Key()) {
+			it.baseOk = it.base.Next()
+		}
+		key, value := it.mem.Key(), it.mem.Value()
+		it.memOk = it.mem.Next()
+		if value[0] == overlayDeleted {
+			continue
+		}
+		it.key, it.value = common.CopyBytes(key), common.CopyBytes(value[1:])
+		return true
+	}
+	it.key, it.value = nil, nil
+	return false
+}
+
+// Error returns any accumulated error.
+func (it *overlayIterator) Error() error {
+	if err := it.mem.Error(); err != nil {
+		return err
+	}
+	return it.base.Error()
+}
+
+// Key returns the key of the current key/value pair, or nil if done.
+func (it *overlayIterator) Key() []byte {
+	return it.key
+}
+
+// Value returns the value of the current key/value pair, or nil if done.
+func (it *overlayIterator) Value() []byte {
+	return it.value
+}
+
+// Release releases associated resources.
+func (it *overlayIterator) Release() {
+	it.mem.Release()
+	it.base.Release()
+}
+
+// overlaySnapshot is a snapshot of the overlay and the base store.
+type overlaySnapshot struct {
+	mem, base ethdb.Snapshot
+}
+
+// Has retrieves if a key is present in the snapshot.
+func (snap *overlaySnapshot) Has(key []byte) (bool, error) {
+	if value, err := snap.mem.Get(key); err == nil {
+		return value[0] == overlayWritten, nil
+	}
+	return snap.base.Has(key)
+}
+
+// Get retrieves the given key if it's present in the snapshot.
+func (snap *overlaySnapshot) Get(key []byte) ([]byte, error) {
+	if value, err := snap.mem.Get(key); err == nil {
+		if value[0] == overlayDeleted {
+			return nil, errNotFound
+		}
+		return common.CopyBytes(value[1:]), nil
+	}
+	return snap.base.Get(key)
+}
+
+// Release releases associated resources.
+func (snap *overlaySnapshot) Release() {
+	snap.mem.Release()
+	snap.base.Release()
+}
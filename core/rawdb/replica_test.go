@@ -0,0 +1,126 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package rawdb
+
+import (
+	"math/big"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// Tests that a replica can be opened alongside the owner of the database, sees
+// both its key-value and ancient data, catches up with it once refreshed, and
+// keeps its own writes local until then.
+func TestReplicaDatabase(t *testing.T) {
+	var (
+		dir     = t.TempDir()
+		ancient = filepath.Join(dir, "ancient")
+	)
+	db, err := Open(OpenOptions{Type: dbLeveldb, Directory: dir, AncientsDirectory: ancient})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	blocks := make([]*types.Block, 5)
+	for i := range blocks {
+		blocks[i] = types.NewBlockWithHeader(&types.Header{Number: big.NewInt(int64(i)), Difficulty: big.NewInt(1)})
+	}
+	receipts := make([]types.Receipts, len(blocks))
+
+	// Freeze the first two blocks, and store the third one in the key-value store
+	if _, err := WriteAncientBlocks(db, blocks[:2], receipts[:2], big.NewInt(1)); err != nil {
+		t.Fatalf("failed to write ancient blocks: %v", err)
+	}
+	WriteCanonicalHash(db, blocks[2].Hash(), 2)
+	db.Put([]byte("test-1"), []byte{0x01})
+	db.Put([]byte("test-3"), []byte{0x03})
+
+	replica, err := NewReplicaDatabase(dir, ancient, 16, 16, "")
+	if err != nil {
+		t.Fatalf("failed to open replica: %v", err)
+	}
+	defer replica.Close()
+
+	for i := 0; i < 3; i++ {
+		if hash := ReadCanonicalHash(replica, uint64(i)); hash != blocks[i].Hash() {
+			t.Fatalf("block %d: hash mismatch: have %x, want %x", i, hash, blocks[i].Hash())
+		}
+	}
+	// Extend the database, only visible to the replica once refreshed
+	if _, err := WriteAncientBlocks(db, blocks[2:4], receipts[2:4], big.NewInt(3)); err != nil {
+		t.Fatalf("failed to write ancient blocks: %v", err)
+	}
+	WriteCanonicalHash(db, blocks[4].Hash(), 4)
+
+	if frozen, _ := replica.Ancients(); frozen != 2 {
+		t.Fatalf("ancients visible before refresh: have %d, want %d", frozen, 2)
+	}
+	if err := replica.(ethdb.Refresher).Refresh(); err != nil {
+		t.Fatalf("failed to refresh replica: %v", err)
+	}
+	if frozen, _ := replica.Ancients(); frozen != 4 {
+		t.Fatalf("ancients mismatch after refresh: have %d, want %d", frozen, 4)
+	}
+	for i := range blocks {
+		if hash := ReadCanonicalHash(replica, uint64(i)); hash != blocks[i].Hash() {
+			t.Fatalf("block %d: hash mismatch after refresh: have %x, want %x", i, hash, blocks[i].Hash())
+		}
+	}
+	// Modify the replica, and ensure the changes stay local
+	WriteCanonicalHash(replica, common.Hash{0x01}, 10)
+	DeleteCanonicalHash(replica, 4)
+	replica.Put([]byte("test-2"), []byte{0x02})
+	replica.Delete([]byte("test-3"))
+
+	if hash := ReadCanonicalHash(replica, 10); hash != (common.Hash{0x01}) {
+		t.Fatalf("local write missing: have %x", hash)
+	}
+	if hash := ReadCanonicalHash(replica, 4); hash != (common.Hash{}) {
+		t.Fatalf("local deletion missing: have %x", hash)
+	}
+	if hash := ReadCanonicalHash(db, 10); hash != (common.Hash{}) {
+		t.Fatalf("local write leaked into the database: have %x", hash)
+	}
+	if hash := ReadCanonicalHash(db, 4); hash != blocks[4].Hash() {
+		t.Fatalf("local deletion leaked into the database: have %x", hash)
+	}
+	it := replica.NewIterator([]byte("test-"), nil)
+	defer it.Release()
+
+	var keys []string
+	for it.Next() {
+		keys = append(keys, string(it.Key()))
+	}
+	if want := []string{"test-1", "test-2"}; !reflect.DeepEqual(keys, want) {
+		t.Fatalf("iterated keys mismatch: have %v, want %v", keys, want)
+	}
+	// Refresh the replica, and ensure the local changes are discarded
+	if err := replica.(ethdb.Refresher).Refresh(); err != nil {
+		t.Fatalf("failed to refresh replica: %v", err)
+	}
+	if hash := ReadCanonicalHash(replica, 10); hash != (common.Hash{}) {
+		t.Fatalf("local write kept after refresh: have %x", hash)
+	}
+	if hash := ReadCanonicalHash(replica, 4); hash != blocks[4].Hash() {
+		t.Fatalf("local deletion kept after refresh: have %x", hash)
+	}
+	if ok, _ := replica.Has([]byte("test-3")); !ok {
+		t.Fatalf("local deletion kept after refresh")
+	}
+}
@@ -74,6 +74,12 @@ var (
 	// badBlockKey tracks the list of bad blocks seen by local
 	badBlockKey = []byte("InvalidBlock")
 
+	// bannedHashesKey tracks the list of block hashes banned by the operator
+	bannedHashesKey = []byte("BannedHashes")
+
+	// forkScheduleKey tracks the last signed fork schedule applied at runtime.
+	forkScheduleKey = []byte("ForkSchedule")
+
 	// uncleanShutdownKey tracks the list of local crashes
 	uncleanShutdownKey = []byte("unclean-shutdown") // config prefix for the db
 
@@ -0,0 +1,410 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package pruner
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+const (
+	// onlineSweepBatch is the number of trie nodes checked and deleted at once
+	// while sweeping, blocking the trie flushes of the chain meanwhile.
+	onlineSweepBatch = 8192
+
+	// onlineAbortCheck is the number of trie nodes marked between two checks
+	// whether the pruning round was aborted.
+	onlineAbortCheck = 10000
+)
+
+var (
+	errPruneRunning = errors.New("pruning already running")
+	errPruneAborted = errors.New("pruning aborted")
+	errPrunerClosed = errors.New("pruner closed")
+)
+
+// OnlineChain defines the methods of the chain the online pruner needs to find
+// the states to preserve.
+type OnlineChain interface {
+	// CurrentBlock retrieves the head block of the canonical chain.
+	CurrentBlock() *types.Header
+
+	// GetHeaderByNumber retrieves a canonical block header by number.
+	GetHeaderByNumber(number uint64) *types.Header
+
+	// Genesis retrieves the genesis block of the chain.
+	Genesis() *types.Block
+
+	// TrieDB retrieves the trie database the chain commits its states through.
+	TrieDB() *trie.Database
+
+	// StateRetention returns the number of recent blocks whose states are kept.
+	StateRetention() uint64
+}
+
+// OnlinePruneStatus is the progress of the running or last online pruning round.
+type OnlinePruneStatus struct {
+	Running bool                  `json:"running"`         // Whether a pruning round is running
+	Phase   string                `json:"phase"`           // Phase of the running round, marking or sweeping
+	Number  uint64                `json:"number"`          // Block number of the pruning target state
+	Root    common.Hash           `json:"root"`            // Root of the pruning target state
+	Marked  uint64                `json:"marked"`          // Trie nodes marked as live
+	Scanned uint64                `json:"scanned"`         // Database entries swept
+	Deleted uint64                `json:"deleted"`         // Stale trie nodes deleted
+	Size    common.StorageSize    `json:"size"`            // Storage freed by the deleted trie nodes
+	Elapsed common.PrettyDuration `json:"elapsed"`         // Time spent in the round
+	Error   string                `json:"error,omitempty"` // Failure of the last round
+}
+
+// OnlinePruner deletes stale trie nodes in the background while the chain keeps
+// importing blocks. A pruning round marks the live trie nodes into a bloom
+// filter, then sweeps the database deleting all the others:
+//
+//   - the pruning target is the newest state fully persisted on disk
+//   - the states of all blocks within the retention window of the head, side
+//     chains included, are marked where they differ from the target, as most
+//     of them only live in memory, referencing persisted nodes
+//   - the genesis state is marked where it differs from the target
+//   - the target state is marked in full
+//
+// All trie nodes flushed to disk while the round runs are marked too, before
+// being written, and a sweep batch checks and deletes its nodes atomically with
+// respect to the flushes. New states only reference the nodes of their parents
+// or newly flushed ones, so all of them survive the sweep.
+//
+// States older than the retention window are not retained, except the target,
+// and a few stale nodes survive due to the false positives of the bloom filter.
+type OnlinePruner struct {
+	db        ethdb.Database
+	chain     OnlineChain
+	bloomSize uint64
+
+	bloom  *stateBloom       // Live trie nodes of the running round
+	status OnlinePruneStatus // Progress of the running or last round
+	start  time.Time         // Time the running or last round started
+	closed bool              // Whether the pruner was stopped
+	lock   sync.Mutex        // Protects the bloom and the progress
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewOnlinePruner creates an online pruner for the states of the chain, using a
+// bloom filter of the given size in megabytes for each round.
+func NewOnlinePruner(db ethdb.Database, chain OnlineChain, bloomSize uint64) *OnlinePruner {
+	if bloomSize < 256 {
+		log.Warn("Sanitizing bloomfilter size", "provided(MB)", bloomSize, "updated(MB)", 256)
+		bloomSize = 256
+	}
+	return &OnlinePruner{
+		db:        db,
+		chain:     chain,
+		bloomSize: bloomSize,
+		quit:      make(chan struct{}),
+	}
+}
+
+// Prune starts a pruning round in the background, unless one is running.
+func (p *OnlinePruner) Prune() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.closed {
+		return errPrunerClosed
+	}
+	if p.status.Running {
+		return errPruneRunning
+	}
+	bloom, err := newStateBloomWithSize(p.bloomSize)
+	if err != nil {
+		return err
+	}
+	p.bloom, p.status, p.start = bloom, OnlinePruneStatus{Running: true, Phase: "marking"}, time.Now()
+
+	p.wg.Add(1)
+	go p.run()
+	return nil
+}
+
+// Status returns the progress of the running or last pruning round.
+func (p *OnlinePruner) Status() OnlinePruneStatus {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	status := p.status
+	if status.Running {
+		status.Elapsed = common.PrettyDuration(time.Since(p.start))
+	}
+	return status
+}
+
+// Stop aborts the running pruning round, if any, and waits for it to exit.
+func (p *OnlinePruner) Stop() {
+	p.lock.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.quit)
+	}
+	p.lock.Unlock()
+
+	p.wg.Wait()
+}
+
+// run executes a pruning round, recording its outcome.
+func (p *OnlinePruner) run() {
+	defer p.wg.Done()
+
+	triedb := p.chain.TrieDB()
+	triedb.SetFlushHook(p.mark)
+	defer triedb.SetFlushHook(nil)
+
+	err := p.prune()
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.bloom = nil
+	p.status.Running, p.status.Phase = false, ""
+	p.status.Elapsed = common.PrettyDuration(time.Since(p.start))
+	if err != nil {
+		p.status.Error = err.Error()
+		log.Error("Online state pruning failed", "err", err)
+		return
+	}
+	log.Info("Online state pruning finished", "deleted", p.status.Deleted, "size", p.status.Size, "elapsed", p.status.Elapsed)
+}
+
+// prune marks the live trie nodes and sweeps the rest.
+func (p *OnlinePruner) prune() error {
+	// Find the newest state fully persisted on disk to prune against
+	head := p.chain.CurrentBlock()
+	target := head
+	for target.Root != types.EmptyRootHash && !rawdb.HasLegacyTrieNode(p.db, target.Root) {
+		if target.Number.Uint64() == 0 {
+			return errors.New("no persisted state found")
+		}
+		if target = p.chain.GetHeaderByNumber(target.Number.Uint64() - 1); target == nil {
+			return errors.New("missing canonical header")
+		}
+	}
+	p.lock.Lock()
+	p.status.Number, p.status.Root = target.Number.Uint64(), target.Root
+	p.lock.Unlock()
+
+	log.Info("Started online state pruning", "number", target.Number, "root", target.Root)
+
+	// Mark the retained states first, before they are garbage collected, on all
+	// branches as side chains may still become canonical. A missing node means
+	// the state was already dereferenced or never executed.
+	var number uint64
+	if head, recent := head.Number.Uint64(), p.chain.StateRetention(); head > recent {
+		number = head - recent
+	}
+	for ; number <= p.chain.CurrentBlock().Number.Uint64(); number++ {
+		for _, hash := range rawdb.ReadAllHashes(p.db, number) {
+			header := rawdb.ReadHeader(p.db, hash, number)
+			if header == nil {
+				continue
+			}
+			err := p.markState(header.Root, target.Root)
+			if missing := new(trie.MissingNodeError); errors.As(err, &missing) {
+				log.Debug("Skipping dereferenced state", "number", number, "hash", hash, "root", header.Root)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if err := p.markState(p.chain.Genesis().Root(), target.Root); err != nil {
+		return err
+	}
+	if err := p.markState(target.Root, common.Hash{}); err != nil {
+		return err
+	}
+	p.lock.Lock()
+	p.status.Phase = "sweeping"
+	p.lock.Unlock()
+
+	return p.sweep()
+}
+
+// mark records a trie node as live.
+func (p *OnlinePruner) mark(hash common.Hash) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.bloom != nil {
+		p.bloom.Put(hash.Bytes(), nil)
+		p.status.Marked++
+	}
+}
+
+// markState marks the trie nodes of a state, including its storage tries. If a
+// base state is given, only the nodes differing from it are marked.
+func (p *OnlinePruner) markState(root, base common.Hash) error {
+	if root == types.EmptyRootHash || root == base {
+		return nil
+	}
+	triedb := p.chain.TrieDB()
+	accTrie, err := trie.NewStateTrie(trie.StateTrieID(root), triedb)
+	if err != nil {
+		return err
+	}
+	var (
+		baseTrie *trie.StateTrie
+		it       = accTrie.NodeIterator(nil)
+	)
+	if base != (common.Hash{}) {
+		if baseTrie, err = trie.NewStateTrie(trie.StateTrieID(base), triedb); err != nil {
+			return err
+		}
+		it, _ = trie.NewDifferenceIterator(baseTrie.NodeIterator(nil), it)
+	}
+	for count := 0; it.Next(true); count++ {
+		if count%onlineAbortCheck == 0 {
+			select {
+			case <-p.quit:
+				return errPruneAborted
+			default:
+			}
+		}
+		if hash := it.Hash(); hash != (common.Hash{}) {
+			p.mark(hash)
+		}
+		if !it.Leaf() {
+			continue
+		}
+		var acc types.StateAccount
+		if err := rlp.DecodeBytes(it.LeafBlob(), &acc); err != nil {
+			return err
+		}
+		owner := common.BytesToHash(it.LeafKey())
+
+		var baseRoot common.Hash
+		if baseTrie != nil {
+			baseAcc, err := baseTrie.GetAccountByHash(owner)
+			if err != nil {
+				return err
+			}
+			if baseAcc != nil {
+				baseRoot = baseAcc.Root
+			}
+		}
+		if err := p.markStorage(root, owner, acc.Root, base, baseRoot); err != nil {
+			return err
+		}
+	}
+	return it.Error()
+}
+
+// markStorage marks the trie nodes of a storage trie. If a base storage trie is
+// given, only the nodes differing from it are marked.
+func (p *OnlinePruner) markStorage(stateRoot, owner, root, baseStateRoot, base common.Hash) error {
+	if root == types.EmptyRootHash || root == base {
+		return nil
+	}
+	triedb := p.chain.TrieDB()
+	storage, err := trie.New(trie.StorageTrieID(stateRoot, owner, root), triedb)
+	if err != nil {
+		return err
+	}
+	it := storage.NodeIterator(nil)
+	if base != (common.Hash{}) && base != types.EmptyRootHash {
+		baseStorage, err := trie.New(trie.StorageTrieID(baseStateRoot, owner, base), triedb)
+		if err != nil {
+			return err
+		}
+		it, _ = trie.NewDifferenceIterator(baseStorage.NodeIterator(nil), it)
+	}
+	for it.Next(true) {
+		if hash := it.Hash(); hash != (common.Hash{}) {
+			p.mark(hash)
+		}
+	}
+	return it.Error()
+}
+
+// sweep deletes all the unmarked trie nodes from the database.
+func (p *OnlinePruner) sweep() error {
+	iter := p.db.NewIterator(nil, nil)
+	defer func() { iter.Release() }()
+
+	var (
+		keys  [][]byte
+		sizes []int
+	)
+	for done := false; !done; {
+		select {
+		case <-p.quit:
+			return errPruneAborted
+		default:
+		}
+		keys, sizes = keys[:0], sizes[:0]
+
+		var scanned uint64
+		for len(keys) < onlineSweepBatch {
+			if !iter.Next() {
+				done = true
+				break
+			}
+			scanned++
+			if key := iter.Key(); len(key) == common.HashLength {
+				keys, sizes = append(keys, common.CopyBytes(key)), append(sizes, len(key)+len(iter.Value()))
+			}
+		}
+		if err := iter.Error(); err != nil {
+			return err
+		}
+		if err := p.sweepBatch(keys, sizes, scanned); err != nil {
+			return err
+		}
+		// Recreate the iterator after every batch to allow the underlying
+		// compactor to drop the deleted entries
+		if !done {
+			iter.Release()
+			iter = p.db.NewIterator(nil, keys[len(keys)-1])
+		}
+	}
+	return nil
+}
+
+// sweepBatch deletes the unmarked trie nodes among the given ones. The lock is
+// held until the deletions are written, so that no node marked by a concurrent
+// flush is deleted.
+func (p *OnlinePruner) sweepBatch(keys [][]byte, sizes []int, scanned uint64) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.status.Scanned += scanned
+
+	batch := p.db.NewBatch()
+	for i, key := range keys {
+		if ok, _ := p.bloom.Contain(key); ok {
+			continue
+		}
+		batch.Delete(key)
+		p.status.Deleted++
+		p.status.Size += common.StorageSize(sizes[i])
+	}
+	return batch.Write()
+}
@@ -0,0 +1,111 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package pruner
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that online pruning deletes the states older than the retention window
+// while preserving the retained, the target, the newer and the genesis states,
+// and that the chain keeps importing blocks meanwhile.
+func TestOnlinePrune(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &core.Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   core.GenesisAlloc{address: {Balance: big.NewInt(1000000000000000)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	// Every block sends funds to a new account, rewriting the account trie
+	_, blocks, _ := core.GenerateChainWithGenesis(gspec, ethash.NewFaker(), 400, func(i int, block *core.BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(block.TxNonce(address), common.BigToAddress(big.NewInt(int64(i+1))), big.NewInt(1000), params.TxGas, block.BaseFee(), nil), signer, key)
+		if err != nil {
+			panic(err)
+		}
+		block.AddTx(tx)
+	})
+	// Import the chain in archive mode, persisting all the states
+	db := rawdb.NewMemoryDatabase()
+	chain, err := core.NewBlockChain(db, &core.CacheConfig{TrieDirtyDisabled: true}, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks[:300]); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	pruner := NewOnlinePruner(db, chain, 256)
+	defer pruner.Stop()
+
+	if err := pruner.Prune(); err != nil {
+		t.Fatalf("failed to start pruning: %v", err)
+	}
+	if err := pruner.Prune(); err != errPruneRunning {
+		t.Fatalf("concurrent pruning error mismatch: have %v, want %v", err, errPruneRunning)
+	}
+	// Keep importing blocks while pruning, flushing their states
+	if n, err := chain.InsertChain(blocks[300:]); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n+300, err)
+	}
+	for pruner.Status().Running {
+		time.Sleep(10 * time.Millisecond)
+	}
+	status := pruner.Status()
+	if status.Error != "" {
+		t.Fatalf("pruning failed: %v", status.Error)
+	}
+	if status.Number != 300 || status.Deleted == 0 {
+		t.Fatalf("pruning status mismatch: target #%d, deleted %d", status.Number, status.Deleted)
+	}
+	// The states older than the retention window of the head at the start are
+	// gone, the others are complete
+	oldest := 300 - chain.StateRetention() + 1
+	for _, number := range []uint64{0, oldest, 299, 300, 350, 400} {
+		if err := verifyState(chain, chain.GetHeaderByNumber(number).Root); err != nil {
+			t.Errorf("state #%d damaged: %v", number, err)
+		}
+	}
+	if root := chain.GetHeaderByNumber(oldest - 2).Root; chain.HasState(root) {
+		t.Errorf("stale state #%d not pruned", oldest-2)
+	}
+}
+
+// verifyState iterates over all the trie nodes of a state, failing if any is
+// missing.
+func verifyState(chain *core.BlockChain, root common.Hash) error {
+	statedb, err := state.New(root, chain.StateCache(), nil)
+	if err != nil {
+		return err
+	}
+	it := state.NewNodeIterator(statedb)
+	for it.Next() {
+	}
+	return it.Error
+}
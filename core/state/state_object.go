@@ -177,6 +177,9 @@ func (s *stateObject) GetCommittedState(db Database, key common.Hash) common.Has
 	if _, destructed := s.db.stateObjectsDestruct[s.address]; destructed {
 		return common.Hash{}
 	}
+	if s.db.witness != nil {
+		s.db.witness.addSlot(s.address, key)
+	}
 	// If no live objects are available, attempt to use snapshots
 	var (
 		enc []byte
@@ -431,6 +434,9 @@ func (s *stateObject) Code(db Database) []byte {
 	if bytes.Equal(s.CodeHash(), types.EmptyCodeHash.Bytes()) {
 		return nil
 	}
+	if s.db.witness != nil {
+		s.db.witness.addCode(s.CodeHash())
+	}
 	code, err := db.ContractCode(s.addrHash, common.BytesToHash(s.CodeHash()))
 	if err != nil {
 		s.db.setError(fmt.Errorf("can't load code hash %x: %v", s.CodeHash(), err))
@@ -449,6 +455,9 @@ func (s *stateObject) CodeSize(db Database) int {
 	if bytes.Equal(s.CodeHash(), types.EmptyCodeHash.Bytes()) {
 		return 0
 	}
+	if s.db.witness != nil {
+		s.db.witness.addCode(s.CodeHash())
+	}
 	size, err := db.ContractCodeSize(s.addrHash, common.BytesToHash(s.CodeHash()))
 	if err != nil {
 		s.db.setError(fmt.Errorf("can't load code size %x: %v", s.CodeHash(), err))
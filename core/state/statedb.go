@@ -99,6 +99,9 @@ type StateDB struct {
 	// Transient storage
 	transientStorage transientStorage
 
+	// Pre-state accessed, recorded only if requested
+	witness *Witness
+
 	// Journal of state modifications. This is the backbone of
 	// Snapshot and RevertToSnapshot.
 	journal        *journal
@@ -553,6 +556,9 @@ func (s *StateDB) getDeletedStateObject(addr common.Address) *stateObject {
 	if obj := s.stateObjects[addr]; obj != nil {
 		return obj
 	}
+	if s.witness != nil {
+		s.witness.addAccount(addr)
+	}
 	// If no live objects are available, attempt to use snapshots
 	var data *types.StateAccount
 	if s.snap != nil {
@@ -0,0 +1,53 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package state
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Witness is the part of the pre-state accessed by a state transition: the
+// accounts and the storage slots loaded from the database, and the hashes of
+// the contract codes loaded.
+type Witness struct {
+	Accounts map[common.Address]map[common.Hash]struct{} // Accessed accounts with their accessed slots
+	Codes    map[common.Hash]struct{}                    // Hashes of the accessed codes
+}
+
+// StartWitness starts recording the pre-state accessed through the state into
+// a new witness, which is returned. Objects already loaded are not recorded.
+func (s *StateDB) StartWitness() *Witness {
+	s.witness = &Witness{
+		Accounts: make(map[common.Address]map[common.Hash]struct{}),
+		Codes:    make(map[common.Hash]struct{}),
+	}
+	return s.witness
+}
+
+func (w *Witness) addAccount(addr common.Address) {
+	if _, ok := w.Accounts[addr]; !ok {
+		w.Accounts[addr] = make(map[common.Hash]struct{})
+	}
+}
+
+func (w *Witness) addSlot(addr common.Address, key common.Hash) {
+	w.addAccount(addr)
+	w.Accounts[addr][key] = struct{}{}
+}
+
+func (w *Witness) addCode(hash []byte) {
+	if codeHash := common.BytesToHash(hash); codeHash != types.EmptyCodeHash {
+		w.Codes[codeHash] = struct{}{}
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package txpool
+
+import (
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// restorePool reinjects the transactions persisted on the last shutdown. All
+// of them are validated anew, dropping those mined or invalidated meanwhile.
+func (pool *TxPool) restorePool() {
+	if err := newTxJournal(pool.config.PersistJournal).load(pool.AddRemotesSync); err != nil {
+		log.Warn("Failed to load persisted transaction pool", "err", err)
+	}
+}
+
+// persistPool writes all pending and queued transactions to disk, except the
+// local ones already covered by the local transaction journal.
+func (pool *TxPool) persistPool() error {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	output, err := os.OpenFile(pool.config.PersistJournal+".new", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	persisted := 0
+	for _, set := range []map[common.Address]*list{pool.pending, pool.queue} {
+		for addr, list := range set {
+			if pool.journal != nil && pool.locals.contains(addr) {
+				continue
+			}
+			for _, tx := range list.Flatten() {
+				if err := rlp.Encode(output, tx); err != nil {
+					output.Close()
+					return err
+				}
+			}
+			persisted += list.Len()
+		}
+	}
+	if err := output.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(pool.config.PersistJournal+".new", pool.config.PersistJournal); err != nil {
+		return err
+	}
+	log.Info("Persisted transaction pool", "transactions", persisted)
+	return nil
+}
@@ -0,0 +1,290 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package txpool
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"plugin"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var (
+	// ErrPolicyRejected is returned if the transaction is rejected by the
+	// operator's pool policy.
+	ErrPolicyRejected = errors.New("rejected by pool policy")
+
+	// ErrPolicySlots is returned if the sender already holds as many
+	// transactions as the operator's pool policy permits.
+	ErrPolicySlots = errors.New("sender slots exceeded by pool policy")
+)
+
+// Policy is an operator supplied rule set deciding which transactions the pool
+// admits and keeps, on top of the pool's own validation and limits.
+type Policy interface {
+	// Admit returns an error if the transaction must not enter the pool. It is
+	// also consulted for the pooled transactions when the policy is installed,
+	// evicting the ones rejected.
+	Admit(tx *types.Transaction, from common.Address, local bool) error
+
+	// SenderSlots returns the maximum number of transactions the sender may
+	// hold in the pool, zero if only the pool's own limits apply. Local senders
+	// are exempt.
+	SenderSlots(from common.Address) uint64
+}
+
+// policyPluginSymbol is the constructor a policy plugin must export, of type
+// func() (txpool.Policy, error).
+const policyPluginSymbol = "NewPolicy"
+
+// LoadPolicy loads a pool policy from the given path. Paths ending in .so are
+// opened as Go plugins exporting a NewPolicy constructor, anything else is
+// parsed as a JSON rules file.
+func LoadPolicy(path string) (Policy, error) {
+	if strings.HasSuffix(path, ".so") {
+		plug, err := plugin.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		sym, err := plug.Lookup(policyPluginSymbol)
+		if err != nil {
+			return nil, err
+		}
+		constructor, ok := sym.(func() (Policy, error))
+		if !ok {
+			return nil, fmt.Errorf("plugin %s has invalid %s type %T", path, policyPluginSymbol, sym)
+		}
+		return constructor()
+	}
+	blob, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePolicyRules(blob)
+}
+
+// policyRule matches transactions by sender, recipient and call selector, all
+// of the set criteria having to match. Unset criteria match any transaction.
+type policyRule struct {
+	Action    string           `json:"action"`    // Either "accept" or "reject"
+	From      []common.Address `json:"from"`      // Senders matched
+	To        []common.Address `json:"to"`        // Recipients matched
+	Create    bool             `json:"create"`    // Whether to match contract creations only
+	Selectors []hexutil.Bytes  `json:"selectors"` // Leading 4 bytes of the call data matched
+}
+
+// slotRule caps the number of pooled transactions of the matched senders.
+type slotRule struct {
+	From []common.Address `json:"from"` // Senders matched, all of them if empty
+	Max  uint64           `json:"max"`  // Maximum transactions per sender, zero for no cap
+}
+
+// rulesPolicy is a pool policy configured by a rules file, for example:
+//
+//	{
+//	  "rules": [
+//	    {"action": "accept", "from": ["0x<searcher>"]},
+//	    {"action": "reject", "to": ["0x<contract>"], "selectors": ["0xa9059cbb"]},
+//	    {"action": "reject", "create": true}
+//	  ],
+//	  "slots": [
+//	    {"from": ["0x<exchange>"], "max": 256},
+//	    {"max": 8}
+//	  ]
+//	}
+//
+// Rules are evaluated in order, the first one matching a transaction decides,
+// unmatched transactions being accepted. Likewise the first slot rule matching
+// the sender caps its transactions.
+type rulesPolicy struct {
+	Rules []policyRule `json:"rules"`
+	Slots []slotRule   `json:"slots"`
+}
+
+// ParsePolicyRules parses a JSON rules file into a pool policy.
+func ParsePolicyRules(blob []byte) (Policy, error) {
+	var policy rulesPolicy
+	if err := json.Unmarshal(blob, &policy); err != nil {
+		return nil, err
+	}
+	for i, rule := range policy.Rules {
+		if rule.Action != "accept" && rule.Action != "reject" {
+			return nil, fmt.Errorf("rule %d: invalid action %q", i, rule.Action)
+		}
+		if rule.Create && (len(rule.To) > 0 || len(rule.Selectors) > 0) {
+			return nil, fmt.Errorf("rule %d: contract creations have no recipient or selector", i)
+		}
+		for _, selector := range rule.Selectors {
+			if len(selector) != 4 {
+				return nil, fmt.Errorf("rule %d: invalid selector %s", i, selector)
+			}
+		}
+	}
+	return &policy, nil
+}
+
+// matches returns whether the rule matches the transaction.
+func (r *policyRule) matches(tx *types.Transaction, from common.Address) bool {
+	if len(r.From) > 0 && !containsAddr(r.From, from) {
+		return false
+	}
+	if r.Create && tx.To() != nil {
+		return false
+	}
+	if len(r.To) > 0 && (tx.To() == nil || !containsAddr(r.To, *tx.To())) {
+		return false
+	}
+	if len(r.Selectors) > 0 {
+		data := tx.Data()
+		if len(data) < 4 {
+			return false
+		}
+		var found bool
+		for _, selector := range r.Selectors {
+			if bytes.Equal(data[:4], selector) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Admit implements Policy, rejecting the transaction if the first rule matching
+// it says so.
+func (p *rulesPolicy) Admit(tx *types.Transaction, from common.Address, local bool) error {
+	for i := range p.Rules {
+		if p.Rules[i].matches(tx, from) {
+			if p.Rules[i].Action == "reject" {
+				return fmt.Errorf("%w: rule %d", ErrPolicyRejected, i)
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// SenderSlots implements Policy, returning the cap of the first slot rule
+// matching the sender.
+func (p *rulesPolicy) SenderSlots(from common.Address) uint64 {
+	for _, rule := range p.Slots {
+		if len(rule.From) == 0 || containsAddr(rule.From, from) {
+			return rule.Max
+		}
+	}
+	return 0
+}
+
+// containsAddr returns whether the address is in the list.
+func containsAddr(addrs []common.Address, addr common.Address) bool {
+	for _, a := range addrs {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// SetPolicy installs the operator's admission and eviction policy, nil removing
+// any. Pooled transactions rejected by the new policy are dropped, as well as
+// the highest nonce ones of the senders above their slot cap.
+func (pool *TxPool) SetPolicy(policy Policy) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	pool.policyMu.Lock()
+	pool.policy = policy
+	pool.policyMu.Unlock()
+
+	if policy == nil {
+		return
+	}
+	senders := make(map[common.Address]struct{}, len(pool.pending)+len(pool.queue))
+	for addr := range pool.pending {
+		senders[addr] = struct{}{}
+	}
+	for addr := range pool.queue {
+		senders[addr] = struct{}{}
+	}
+	var evict []common.Hash
+	for addr := range senders {
+		// Pending nonces always precede the queued ones of a sender
+		var txs types.Transactions
+		if list := pool.pending[addr]; list != nil {
+			txs = append(txs, list.Flatten()...)
+		}
+		if list := pool.queue[addr]; list != nil {
+			txs = append(txs, list.Flatten()...)
+		}
+		var (
+			local = pool.locals.contains(addr)
+			slots = policy.SenderSlots(addr)
+			kept  uint64
+		)
+		for _, tx := range txs {
+			if policy.Admit(tx, addr, local) != nil || (!local && slots > 0 && kept >= slots) {
+				evict = append(evict, tx.Hash())
+				continue
+			}
+			kept++
+		}
+	}
+	for _, hash := range evict {
+		pool.removeTx(hash, true)
+	}
+	if len(evict) > 0 {
+		log.Info("Evicted transactions by pool policy", "count", len(evict))
+	}
+}
+
+// currentPolicy returns the installed pool policy, nil if there is none.
+func (pool *TxPool) currentPolicy() Policy {
+	pool.policyMu.RLock()
+	defer pool.policyMu.RUnlock()
+
+	return pool.policy
+}
+
+// hasNonce returns whether the sender has a pooled transaction of the nonce.
+func (pool *TxPool) hasNonce(from common.Address, nonce uint64) bool {
+	if list := pool.pending[from]; list != nil && list.Contains(nonce) {
+		return true
+	}
+	if list := pool.queue[from]; list != nil && list.Contains(nonce) {
+		return true
+	}
+	return false
+}
+
+// senderTxs returns the number of pooled transactions of the sender.
+func (pool *TxPool) senderTxs(from common.Address) uint64 {
+	var count int
+	if list := pool.pending[from]; list != nil {
+		count += list.Len()
+	}
+	if list := pool.queue[from]; list != nil {
+		count += list.Len()
+	}
+	return uint64(count)
+}
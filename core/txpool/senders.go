@@ -0,0 +1,136 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package txpool
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// senderLists are the operator managed sender allow and deny lists. Denylisted
+// senders are rejected at ingress, allowlisted ones bypass the minimum tip.
+type senderLists struct {
+	path string // Disk path the lists are persisted to, empty if not persisted
+
+	lock  sync.RWMutex
+	allow map[common.Address]struct{}
+	deny  map[common.Address]struct{}
+}
+
+// senderListsJSON is the on-disk format of the sender lists.
+type senderListsJSON struct {
+	Allow []common.Address `json:"allow"`
+	Deny  []common.Address `json:"deny"`
+}
+
+// newSenderLists creates the sender lists, restoring any persisted at the path.
+func newSenderLists(path string) *senderLists {
+	lists := &senderLists{
+		path:  path,
+		allow: make(map[common.Address]struct{}),
+		deny:  make(map[common.Address]struct{}),
+	}
+	if path == "" {
+		return lists
+	}
+	blob, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Warn("Failed to load sender lists", "path", path, "err", err)
+		}
+		return lists
+	}
+	var stored senderListsJSON
+	if err := json.Unmarshal(blob, &stored); err != nil {
+		log.Warn("Failed to decode sender lists", "path", path, "err", err)
+		return lists
+	}
+	lists.allow = toAddrSet(stored.Allow)
+	lists.deny = toAddrSet(stored.Deny)
+	log.Info("Loaded sender lists", "allowed", len(lists.allow), "denied", len(lists.deny))
+	return lists
+}
+
+// toAddrSet converts a list of addresses into a set.
+func toAddrSet(addrs []common.Address) map[common.Address]struct{} {
+	set := make(map[common.Address]struct{}, len(addrs))
+	for _, addr := range addrs {
+		set[addr] = struct{}{}
+	}
+	return set
+}
+
+// allowed returns whether the sender is allowlisted.
+func (l *senderLists) allowed(addr common.Address) bool {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	_, ok := l.allow[addr]
+	return ok
+}
+
+// denied returns whether the sender is denylisted.
+func (l *senderLists) denied(addr common.Address) bool {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	_, ok := l.deny[addr]
+	return ok
+}
+
+// setAllow replaces the allowlist and persists the lists.
+func (l *senderLists) setAllow(addrs []common.Address) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.allow = toAddrSet(addrs)
+	return l.save()
+}
+
+// setDeny replaces the denylist and persists the lists.
+func (l *senderLists) setDeny(addrs []common.Address) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.deny = toAddrSet(addrs)
+	return l.save()
+}
+
+// save writes the lists to disk, replacing the previous ones atomically. It
+// assumes the lock is held.
+func (l *senderLists) save() error {
+	if l.path == "" {
+		return nil
+	}
+	stored := senderListsJSON{Allow: make([]common.Address, 0, len(l.allow)), Deny: make([]common.Address, 0, len(l.deny))}
+	for addr := range l.allow {
+		stored.Allow = append(stored.Allow, addr)
+	}
+	for addr := range l.deny {
+		stored.Deny = append(stored.Deny, addr)
+	}
+	blob, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(l.path+".new", blob, 0644); err != nil {
+		return err
+	}
+	return os.Rename(l.path+".new", l.path)
+}
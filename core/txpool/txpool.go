@@ -18,6 +18,8 @@ import (
 	"math"
 	"math/big"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -59,6 +61,10 @@ var (
 	// ErrInvalidSender is returned if the transaction contains an invalid signature.
 	ErrInvalidSender = errors.New("invalid sender")
 
+	// ErrDenylisted is returned if the transaction's sender is denylisted by the
+	// node operator.
+	ErrDenylisted = errors.New("sender denylisted")
+
 	// ErrUnderpriced is returned if a transaction's gas price is below the minimum
 	// configured for the transaction pool.
 	ErrUnderpriced = errors.New("transaction underpriced")
@@ -163,9 +169,17 @@ type Config struct {
 	Journal   string           // Journal of local transactions to survive node restarts
 	Rejournal time.Duration    // Time interval to regenerate the local transaction journal
 
+	SenderLists string // Sender allow and deny lists to survive node restarts
+	Policy      string // Admission and eviction policy, a Go plugin or a rules file
+
+	Persist        bool   // Whether to persist the whole pool across restarts
+	PersistJournal string // Disk journal of the whole pool, written on shutdown
+
 	PriceLimit uint64 // Minimum gas price to enforce for acceptance into the pool
 	PriceBump  uint64 // Minimum price bump percentage to replace an already existing transaction (nonce)
 
+	PriceBumps map[uint8]uint64 // Price bump percentages overriding PriceBump for replacements of a transaction type
+
 	AccountSlots uint64 // Number of executable transaction slots guaranteed per account
 	GlobalSlots  uint64 // Maximum number of executable transaction slots for all accounts
 	AccountQueue uint64 // Maximum number of non-executable transaction slots permitted per account
@@ -180,6 +194,10 @@ var DefaultConfig = Config{
 	Journal:   "transactions.rlp",
 	Rejournal: time.Hour,
 
+	SenderLists: "senderlists.json",
+
+	PersistJournal: "mempool.rlp",
+
 	PriceLimit: 1,
 	PriceBump:  10,
 
@@ -207,6 +225,17 @@ func (config *Config) sanitize() Config {
 		log.Warn("Sanitizing invalid txpool price bump", "provided", conf.PriceBump, "updated", DefaultConfig.PriceBump)
 		conf.PriceBump = DefaultConfig.PriceBump
 	}
+	if len(conf.PriceBumps) > 0 {
+		bumps := make(map[uint8]uint64, len(conf.PriceBumps))
+		for txType, bump := range conf.PriceBumps {
+			if bump < 1 {
+				log.Warn("Sanitizing invalid txpool price bump", "type", txType, "provided", bump, "updated", conf.PriceBump)
+				continue
+			}
+			bumps[txType] = bump
+		}
+		conf.PriceBumps = bumps
+	}
 	if conf.AccountSlots < 1 {
 		log.Warn("Sanitizing invalid txpool account slots", "provided", conf.AccountSlots, "updated", DefaultConfig.AccountSlots)
 		conf.AccountSlots = DefaultConfig.AccountSlots
@@ -230,6 +259,40 @@ func (config *Config) sanitize() Config {
 	return conf
 }
 
+// priceBump returns the minimum price bump percentage for a transaction of the
+// given type to replace an already existing one.
+func (config *Config) priceBump(txType uint8) uint64 {
+	if bump, ok := config.PriceBumps[txType]; ok {
+		return bump
+	}
+	return config.PriceBump
+}
+
+// ParsePriceBumps parses a comma separated list of type=percent pairs into
+// the per transaction type price bumps of the pool.
+func ParsePriceBumps(spec string) (map[uint8]uint64, error) {
+	bumps := make(map[uint8]uint64)
+	for _, entry := range strings.Split(spec, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		kind, percent, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid price bump %q, want type=percent", entry)
+		}
+		txType, err := strconv.ParseUint(strings.TrimSpace(kind), 0, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid transaction type %q: %v", kind, err)
+		}
+		bump, err := strconv.ParseUint(strings.TrimSpace(percent), 10, 64)
+		if err != nil || bump < 1 {
+			return nil, fmt.Errorf("invalid price bump percentage %q", percent)
+		}
+		bumps[uint8(txType)] = bump
+	}
+	return bumps, nil
+}
+
 // TxPool contains all currently known transactions. Transactions
 // enter the pool when they are received from the network or submitted
 // locally. They exit the pool when they are included in the blockchain.
@@ -244,9 +307,11 @@ type TxPool struct {
 	gasPrice    *big.Int
 	txFeed      event.Feed
 	scope       event.SubscriptionScope
-	signer      types.Signer
 	mu          sync.RWMutex
 
+	signerMu sync.RWMutex // The lock used to protect the signer
+	signer   types.Signer // Signer of the chain config, replaced along with it
+
 	istanbul atomic.Bool // Fork indicator whether we are in the istanbul stage.
 	eip2718  atomic.Bool // Fork indicator whether we are using EIP-2718 type transactions.
 	eip1559  atomic.Bool // Fork indicator whether we are using EIP-1559 type transactions.
@@ -256,8 +321,12 @@ type TxPool struct {
 	pendingNonces *noncer        // Pending state tracking virtual nonces
 	currentMaxGas atomic.Uint64  // Current gas limit for transaction caps
 
-	locals  *accountSet // Set of local transaction to exempt from eviction rules
-	journal *journal    // Journal of local transaction to back up to disk
+	locals  *accountSet  // Set of local transaction to exempt from eviction rules
+	journal *journal     // Journal of local transaction to back up to disk
+	senders *senderLists // Operator managed sender allow and deny lists
+
+	policyMu sync.RWMutex // The lock used to protect the policy
+	policy   Policy       // Operator supplied admission and eviction rules, nil if none
 
 	pending map[common.Address]*list     // All currently processable transactions
 	queue   map[common.Address]*list     // Queued but non-processable transactions
@@ -307,11 +376,12 @@ func NewTxPool(config Config, chainconfig *params.ChainConfig, chain blockChain)
 		initDoneCh:      make(chan struct{}),
 		gasPrice:        new(big.Int).SetUint64(config.PriceLimit),
 	}
-	pool.locals = newAccountSet(pool.signer)
+	pool.locals = newAccountSet(pool.currentSigner())
 	for _, addr := range config.Locals {
 		log.Info("Setting new local account", "address", addr)
 		pool.locals.add(addr)
 	}
+	pool.senders = newSenderLists(config.SenderLists)
 	pool.priced = newPricedList(pool.all)
 	pool.reset(nil, chain.CurrentBlock())
 
@@ -330,6 +400,10 @@ func NewTxPool(config Config, chainconfig *params.ChainConfig, chain blockChain)
 			log.Warn("Failed to rotate transaction journal", "err", err)
 		}
 	}
+	// If the whole pool is persisted, reinject the transactions from disk
+	if config.Persist && config.PersistJournal != "" {
+		pool.restorePool()
+	}
 
 	// Subscribe events from blockchain and start the main event loop.
 	pool.chainHeadSub = pool.chain.SubscribeChainHeadEvent(pool.chainHeadCh)
@@ -427,6 +501,11 @@ func (pool *TxPool) Stop() {
 	pool.chainHeadSub.Unsubscribe()
 	pool.wg.Wait()
 
+	if pool.config.Persist && pool.config.PersistJournal != "" {
+		if err := pool.persistPool(); err != nil {
+			log.Warn("Failed to persist transaction pool", "err", err)
+		}
+	}
 	if pool.journal != nil {
 		pool.journal.close()
 	}
@@ -447,6 +526,12 @@ func (pool *TxPool) GasPrice() *big.Int {
 	return new(big.Int).Set(pool.gasPrice)
 }
 
+// PriceBump returns the minimum price bump percentage for a transaction of the
+// given type to replace an already pooled one of the same nonce.
+func (pool *TxPool) PriceBump(txType uint8) uint64 {
+	return pool.config.priceBump(txType)
+}
+
 // SetGasPrice updates the minimum price required by the transaction pool for a
 // new transaction, and drops all transactions below this threshold.
 func (pool *TxPool) SetGasPrice(price *big.Int) {
@@ -459,15 +544,44 @@ func (pool *TxPool) SetGasPrice(price *big.Int) {
 	if price.Cmp(old) > 0 {
 		// pool.priced is sorted by GasFeeCap, so we have to iterate through pool.all instead
 		drop := pool.all.RemotesBelowTip(price)
+		dropped := 0
 		for _, tx := range drop {
+			if from, _ := types.Sender(pool.currentSigner(), tx); pool.senders.allowed(from) {
+				continue
+			}
 			pool.removeTx(tx.Hash(), false)
+			dropped++
 		}
-		pool.priced.Removed(len(drop))
+		pool.priced.Removed(dropped)
 	}
 
 	log.Info("Transaction pool price threshold updated", "price", price)
 }
 
+// SetChainConfig replaces the chain config the pool derives its signer and fork
+// rules from, resetting the pool to the current head to apply them.
+func (pool *TxPool) SetChainConfig(config *params.ChainConfig) {
+	signer := types.LatestSigner(config)
+
+	pool.mu.Lock()
+	pool.chainconfig = config
+	pool.signerMu.Lock()
+	pool.signer = signer
+	pool.signerMu.Unlock()
+	pool.locals.signer = signer
+	pool.mu.Unlock()
+
+	<-pool.requestReset(nil, pool.chain.CurrentBlock())
+}
+
+// currentSigner returns the signer of the chain config in effect.
+func (pool *TxPool) currentSigner() types.Signer {
+	pool.signerMu.RLock()
+	defer pool.signerMu.RUnlock()
+
+	return pool.signer
+}
+
 // Nonce returns the next nonce of an account, with all transactions executable
 // by the pool already applied on top.
 func (pool *TxPool) Nonce(addr common.Address) uint64 {
@@ -550,7 +664,7 @@ func (pool *TxPool) Pending(enforceTips bool) map[common.Address]types.Transacti
 		txs := list.Flatten()
 
 		// If the miner requests tip enforcement, cap the lists now
-		if enforceTips && !pool.locals.contains(addr) {
+		if enforceTips && !pool.locals.contains(addr) && !pool.senders.allowed(addr) {
 			for i, tx := range txs {
 				if tx.EffectiveGasTipIntCmp(pool.gasPrice, pool.priced.urgent.baseFee) < 0 {
 					txs = txs[:i]
@@ -565,6 +679,42 @@ func (pool *TxPool) Pending(enforceTips bool) map[common.Address]types.Transacti
 	return pending
 }
 
+// SetAllowlist replaces the senders whose transactions bypass the minimum tip
+// of the pool, persisting the list across restarts.
+func (pool *TxPool) SetAllowlist(addrs []common.Address) error {
+	return pool.senders.setAllow(addrs)
+}
+
+// SetDenylist replaces the senders whose transactions are rejected by the pool,
+// persisting the list across restarts. Transactions of the denylisted senders
+// already in the pool are dropped.
+func (pool *TxPool) SetDenylist(addrs []common.Address) error {
+	if err := pool.senders.setDeny(addrs); err != nil {
+		return err
+	}
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	dropped := 0
+	for _, addr := range addrs {
+		var txs types.Transactions
+		if list := pool.pending[addr]; list != nil {
+			txs = append(txs, list.Flatten()...)
+		}
+		if list := pool.queue[addr]; list != nil {
+			txs = append(txs, list.Flatten()...)
+		}
+		for _, tx := range txs {
+			pool.removeTx(tx.Hash(), true)
+		}
+		dropped += len(txs)
+	}
+	if dropped > 0 {
+		log.Info("Dropped denylisted transactions", "count", dropped)
+	}
+	return nil
+}
+
 // Locals retrieves the accounts currently considered local by the pool.
 func (pool *TxPool) Locals() []common.Address {
 	pool.mu.Lock()
@@ -631,11 +781,23 @@ func (pool *TxPool) validateTxBasics(tx *types.Transaction, local bool) error {
 		return core.ErrTipAboveFeeCap
 	}
 	// Make sure the transaction is signed properly.
-	if _, err := types.Sender(pool.signer, tx); err != nil {
+	from, err := types.Sender(pool.currentSigner(), tx)
+	if err != nil {
 		return ErrInvalidSender
 	}
-	// Drop non-local transactions under our own minimal accepted gas price or tip
-	if !local && tx.GasTipCapIntCmp(pool.gasPrice) < 0 {
+	// Drop transactions of senders denylisted by the operator
+	if pool.senders.denied(from) {
+		return ErrDenylisted
+	}
+	// Drop transactions rejected by the operator's policy
+	if policy := pool.currentPolicy(); policy != nil {
+		if err := policy.Admit(tx, from, local); err != nil {
+			return err
+		}
+	}
+	// Drop non-local transactions under our own minimal accepted gas price or tip,
+	// unless the sender is allowlisted by the operator
+	if !local && !pool.senders.allowed(from) && tx.GasTipCapIntCmp(pool.gasPrice) < 0 {
 		return ErrUnderpriced
 	}
 	// Ensure the transaction has more gas than the basic tx fee.
@@ -653,7 +815,7 @@ func (pool *TxPool) validateTxBasics(tx *types.Transaction, local bool) error {
 // rules and adheres to some heuristic limits of the local node (price and size).
 func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	// Signature has been checked already, this cannot error.
-	from, _ := types.Sender(pool.signer, tx)
+	from, _ := types.Sender(pool.currentSigner(), tx)
 	// Ensure the transaction adheres to nonce ordering
 	if pool.currentState.GetNonce(from) > tx.Nonce() {
 		return core.ErrNonceTooLow
@@ -708,7 +870,14 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 	}
 
 	// already validated by this point
-	from, _ := types.Sender(pool.signer, tx)
+	from, _ := types.Sender(pool.currentSigner(), tx)
+
+	// Enforce the sender's slot cap of the operator's policy on new nonces
+	if policy := pool.currentPolicy(); policy != nil && !isLocal {
+		if slots := policy.SenderSlots(from); slots > 0 && !pool.hasNonce(from, tx.Nonce()) && pool.senderTxs(from) >= slots {
+			return false, ErrPolicySlots
+		}
+	}
 
 	// If the transaction pool is full, discard underpriced transactions
 	if uint64(pool.all.Slots()+numSlots(tx)) > pool.config.GlobalSlots+pool.config.GlobalQueue {
@@ -744,7 +913,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 		if !isLocal && pool.isGapped(from, tx) {
 			var replacesPending bool
 			for _, dropTx := range drop {
-				dropSender, _ := types.Sender(pool.signer, dropTx)
+				dropSender, _ := types.Sender(pool.currentSigner(), dropTx)
 				if list := pool.pending[dropSender]; list != nil && list.Contains(dropTx.Nonce()) {
 					replacesPending = true
 					break
@@ -772,7 +941,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 	// Try to replace an existing transaction in the pending pool
 	if list := pool.pending[from]; list != nil && list.Contains(tx.Nonce()) {
 		// Nonce already pending, check if required price bump is met
-		inserted, old := list.Add(tx, pool.config.PriceBump)
+		inserted, old := list.Add(tx, pool.config.priceBump(tx.Type()))
 		if !inserted {
 			pendingDiscardMeter.Mark(1)
 			return false, ErrReplaceUnderpriced
@@ -840,11 +1009,11 @@ func (pool *TxPool) isGapped(from common.Address, tx *types.Transaction) bool {
 // Note, this method assumes the pool lock is held!
 func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction, local bool, addAll bool) (bool, error) {
 	// Try to insert the transaction into the future queue
-	from, _ := types.Sender(pool.signer, tx) // already validated
+	from, _ := types.Sender(pool.currentSigner(), tx) // already validated
 	if pool.queue[from] == nil {
 		pool.queue[from] = newList(false)
 	}
-	inserted, old := pool.queue[from].Add(tx, pool.config.PriceBump)
+	inserted, old := pool.queue[from].Add(tx, pool.config.priceBump(tx.Type()))
 	if !inserted {
 		// An older transaction was better, discard this
 		queuedDiscardMeter.Mark(1)
@@ -898,7 +1067,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 	}
 	list := pool.pending[addr]
 
-	inserted, old := list.Add(tx, pool.config.PriceBump)
+	inserted, old := list.Add(tx, pool.config.priceBump(tx.Type()))
 	if !inserted {
 		// An older transaction was better, discard this
 		pool.all.Remove(hash)
@@ -1022,7 +1191,7 @@ func (pool *TxPool) addTxs(txs []*types.Transaction, local, sync bool) []error {
 // addTxsLocked attempts to queue a batch of transactions if they are valid.
 // The transaction pool lock must be held.
 func (pool *TxPool) addTxsLocked(txs []*types.Transaction, local bool) ([]error, *accountSet) {
-	dirty := newAccountSet(pool.signer)
+	dirty := newAccountSet(pool.currentSigner())
 	errs := make([]error, len(txs))
 	for i, tx := range txs {
 		replaced, err := pool.add(tx, local)
@@ -1044,7 +1213,7 @@ func (pool *TxPool) Status(hashes []common.Hash) []TxStatus {
 		if tx == nil {
 			continue
 		}
-		from, _ := types.Sender(pool.signer, tx) // already validated
+		from, _ := types.Sender(pool.currentSigner(), tx) // already validated
 		pool.mu.RLock()
 		if txList := pool.pending[from]; txList != nil && txList.txs.items[tx.Nonce()] != nil {
 			status[i] = TxStatusPending
@@ -1078,7 +1247,7 @@ func (pool *TxPool) removeTx(hash common.Hash, outofbound bool) int {
 	if tx == nil {
 		return 0
 	}
-	addr, _ := types.Sender(pool.signer, tx) // already validated during insertion
+	addr, _ := types.Sender(pool.currentSigner(), tx) // already validated during insertion
 
 	// Remove it from the list of known transactions
 	pool.all.Remove(hash)
@@ -1203,7 +1372,7 @@ func (pool *TxPool) scheduleReorgLoop() {
 		case tx := <-pool.queueTxEventCh:
 			// Queue up the event, but don't schedule a reorg. It's up to the caller to
 			// request one later if they want the events sent.
-			addr, _ := types.Sender(pool.signer, tx)
+			addr, _ := types.Sender(pool.currentSigner(), tx)
 			if _, ok := queuedEvents[addr]; !ok {
 				queuedEvents[addr] = newSortedMap()
 			}
@@ -1285,7 +1454,7 @@ func (pool *TxPool) runReorg(done chan struct{}, reset *txpoolResetRequest, dirt
 
 	// Notify subsystems for newly added transactions
 	for _, tx := range promoted {
-		addr, _ := types.Sender(pool.signer, tx)
+		addr, _ := types.Sender(pool.currentSigner(), tx)
 		if _, ok := events[addr]; !ok {
 			events[addr] = newSortedMap()
 		}
@@ -1381,7 +1550,7 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 
 	// Inject any transactions discarded due to reorgs
 	log.Debug("Reinjecting stale transactions", "count", len(reinject))
-	core.SenderCacher.Recover(pool.signer, reinject)
+	core.SenderCacher.Recover(pool.currentSigner(), reinject)
 	pool.addTxsLocked(reinject, false)
 
 	// Update all fork indicator by next pending block number.
@@ -20,6 +20,7 @@ import (
 	"math/big"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -47,6 +48,7 @@ var (
 func init() {
 	testTxPoolConfig = DefaultConfig
 	testTxPoolConfig.Journal = ""
+	testTxPoolConfig.SenderLists = ""
 
 	cpy := *params.TestChainConfig
 	eip1559Config = &cpy
@@ -422,6 +424,36 @@ func TestVeryHighValues(t *testing.T) {
 	}
 }
 
+// Tests that replacing the chain config switches the pool to its signer and
+// fork rules.
+func TestSetChainConfig(t *testing.T) {
+	t.Parallel()
+
+	config := *params.TestChainConfig
+	config.LondonBlock = nil
+
+	pool, key := setupPoolWithConfig(&config)
+	defer pool.Stop()
+
+	tx := dynamicFeeTx(0, 100000, big.NewInt(params.InitialBaseFee), big.NewInt(1), key)
+	testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000000000000))
+
+	if err := pool.AddRemote(tx); err != core.ErrTxTypeNotSupported {
+		t.Fatalf("dynamic fee transaction error mismatch before london: have %v, want %v", err, core.ErrTxTypeNotSupported)
+	}
+	// Schedule london for the pending block
+	london := config
+	london.LondonBlock = big.NewInt(1)
+
+	pool.SetChainConfig(&london)
+	if err := pool.AddRemote(tx); err != nil {
+		t.Fatalf("dynamic fee transaction rejected after london: %v", err)
+	}
+	if !pool.locals.signer.Equal(types.LatestSigner(&london)) {
+		t.Errorf("local accounts not switched to the new signer")
+	}
+}
+
 func TestChainFork(t *testing.T) {
 	t.Parallel()
 
@@ -2249,6 +2281,69 @@ func TestReplacementDynamicFee(t *testing.T) {
 	}
 }
 
+// Tests that replacements are checked against the price bump configured for
+// their transaction type, falling back to the pool wide one.
+func TestReplacementPerTypeBump(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := newTestBlockChain(10000000, statedb, new(event.Feed))
+
+	config := testTxPoolConfig
+	config.PriceBumps = map[uint8]uint64{types.DynamicFeeTxType: 50, types.AccessListTxType: 0}
+
+	pool := NewTxPool(config, eip1559Config, blockchain)
+	defer pool.Stop()
+
+	if bump := pool.PriceBump(types.DynamicFeeTxType); bump != 50 {
+		t.Fatalf("dynamic fee price bump mismatch: have %d, want %d", bump, 50)
+	}
+	if bump := pool.PriceBump(types.AccessListTxType); bump != config.PriceBump {
+		t.Fatalf("sanitized access list price bump mismatch: have %d, want %d", bump, config.PriceBump)
+	}
+	key, _ := crypto.GenerateKey()
+	testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+
+	// Legacy replacements need the default bump of 10%
+	if err := pool.addRemoteSync(pricedTransaction(0, 100000, big.NewInt(100), key)); err != nil {
+		t.Fatalf("failed to add original legacy transaction: %v", err)
+	}
+	if err := pool.AddRemote(pricedTransaction(0, 100000, big.NewInt(109), key)); err != ErrReplaceUnderpriced {
+		t.Fatalf("legacy replacement error mismatch: have %v, want %v", err, ErrReplaceUnderpriced)
+	}
+	if err := pool.AddRemote(pricedTransaction(0, 100000, big.NewInt(110), key)); err != nil {
+		t.Fatalf("failed to replace legacy transaction: %v", err)
+	}
+	// Dynamic fee replacements need the configured 50%
+	if err := pool.addRemoteSync(dynamicFeeTx(1, 100000, big.NewInt(100), big.NewInt(100), key)); err != nil {
+		t.Fatalf("failed to add original dynamic fee transaction: %v", err)
+	}
+	if err := pool.AddRemote(dynamicFeeTx(1, 100000, big.NewInt(149), big.NewInt(149), key)); err != ErrReplaceUnderpriced {
+		t.Fatalf("dynamic fee replacement error mismatch: have %v, want %v", err, ErrReplaceUnderpriced)
+	}
+	if err := pool.AddRemote(dynamicFeeTx(1, 100000, big.NewInt(150), big.NewInt(150), key)); err != nil {
+		t.Fatalf("failed to replace dynamic fee transaction: %v", err)
+	}
+	if err := validatePoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+func TestParsePriceBumps(t *testing.T) {
+	bumps, err := ParsePriceBumps("0=12, 2=25,")
+	if err != nil {
+		t.Fatalf("failed to parse price bumps: %v", err)
+	}
+	if len(bumps) != 2 || bumps[types.LegacyTxType] != 12 || bumps[types.DynamicFeeTxType] != 25 {
+		t.Fatalf("price bumps mismatch: have %v", bumps)
+	}
+	for _, spec := range []string{"2", "2=0", "256=10", "x=10", "2=-1"} {
+		if _, err := ParsePriceBumps(spec); err == nil {
+			t.Errorf("invalid spec %q accepted", spec)
+		}
+	}
+}
+
 // Tests that local transactions are journaled to disk, but remote transactions
 // get discarded between restarts.
 func TestJournaling(t *testing.T)         { testJournaling(t, false) }
@@ -2576,3 +2671,219 @@ func BenchmarkMultiAccountBatchInsert(b *testing.B) {
 		pool.AddRemotesSync([]*types.Transaction{tx})
 	}
 }
+
+// Tests that denylisted senders are rejected and dropped, allowlisted ones
+// bypass the minimum tip, and both lists survive a pool restart.
+func TestSenderLists(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := newTestBlockChain(1000000, statedb, new(event.Feed))
+
+	config := testTxPoolConfig
+	config.SenderLists = filepath.Join(t.TempDir(), "senderlists.json")
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	pool.SetGasPrice(big.NewInt(10))
+
+	var (
+		allowed, _ = crypto.GenerateKey()
+		denied, _  = crypto.GenerateKey()
+	)
+	testAddBalance(pool, crypto.PubkeyToAddress(allowed.PublicKey), big.NewInt(10000000))
+	testAddBalance(pool, crypto.PubkeyToAddress(denied.PublicKey), big.NewInt(10000000))
+
+	if err := pool.addRemoteSync(pricedTransaction(0, 100000, big.NewInt(1), allowed)); !errors.Is(err, ErrUnderpriced) {
+		t.Fatalf("underpriced transaction error mismatch: have %v, want %v", err, ErrUnderpriced)
+	}
+	if err := pool.addRemoteSync(pricedTransaction(0, 100000, big.NewInt(10), denied)); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	if err := pool.SetAllowlist([]common.Address{crypto.PubkeyToAddress(allowed.PublicKey)}); err != nil {
+		t.Fatalf("failed to set allowlist: %v", err)
+	}
+	if err := pool.SetDenylist([]common.Address{crypto.PubkeyToAddress(denied.PublicKey)}); err != nil {
+		t.Fatalf("failed to set denylist: %v", err)
+	}
+	if pending, queued := pool.Stats(); pending != 0 || queued != 0 {
+		t.Fatalf("denylisted transactions not dropped: pending %d, queued %d", pending, queued)
+	}
+	if err := pool.addRemoteSync(pricedTransaction(0, 100000, big.NewInt(1), allowed)); err != nil {
+		t.Fatalf("failed to add allowlisted transaction: %v", err)
+	}
+	if pending := pool.Pending(true); len(pending[crypto.PubkeyToAddress(allowed.PublicKey)]) != 1 {
+		t.Fatalf("allowlisted transaction not pending with tips enforced")
+	}
+	pool.Stop()
+
+	// Restart the pool and ensure the lists are restored
+	pool = NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+	pool.SetGasPrice(big.NewInt(10))
+
+	if err := pool.addRemoteSync(pricedTransaction(0, 100000, big.NewInt(10), denied)); !errors.Is(err, ErrDenylisted) {
+		t.Fatalf("denylisted transaction error mismatch: have %v, want %v", err, ErrDenylisted)
+	}
+	if err := pool.addRemoteSync(pricedTransaction(1, 100000, big.NewInt(1), allowed)); err != nil {
+		t.Fatalf("failed to add allowlisted transaction: %v", err)
+	}
+	if err := validatePoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that remote pending and queued transactions survive a pool restart if
+// the pool is persisted.
+func TestPersistPool(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := newTestBlockChain(1000000, statedb, new(event.Feed))
+
+	config := testTxPoolConfig
+	config.Persist = true
+	config.PersistJournal = filepath.Join(t.TempDir(), "mempool.rlp")
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+
+	key, _ := crypto.GenerateKey()
+	testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+
+	errs := pool.AddRemotesSync([]*types.Transaction{
+		pricedTransaction(0, 100000, big.NewInt(1), key),
+		pricedTransaction(1, 100000, big.NewInt(1), key),
+		pricedTransaction(3, 100000, big.NewInt(1), key),
+	})
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("tx %d: failed to add transaction: %v", i, err)
+		}
+	}
+	pool.Stop()
+
+	// Mine the first transaction meanwhile and restart the pool
+	statedb.SetNonce(crypto.PubkeyToAddress(key.PublicKey), 1)
+	pool = NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	if pending, queued := pool.Stats(); pending != 1 || queued != 1 {
+		t.Fatalf("restored transactions mismatch: have %d/%d pending/queued, want 1/1", pending, queued)
+	}
+	if err := validatePoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that the operator's policy rejects matching transactions at ingress,
+// evicts pooled ones when installed and caps the transactions per sender.
+func TestPolicy(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupPool()
+	defer pool.Stop()
+
+	var (
+		capped, _ = crypto.GenerateKey()
+		contract  = common.Address{0xc0}
+		call      = func(nonce uint64, to common.Address, data []byte, key *ecdsa.PrivateKey) *types.Transaction {
+			tx, _ := types.SignTx(types.NewTransaction(nonce, to, big.NewInt(0), 100000, big.NewInt(1), data), types.HomesteadSigner{}, key)
+			return tx
+		}
+	)
+	testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+	testAddBalance(pool, crypto.PubkeyToAddress(capped.PublicKey), big.NewInt(1000000000))
+
+	// Pool a transfer and a call of the contract, along with queued transactions
+	// beyond the slot cap to be set
+	for i, tx := range []*types.Transaction{
+		call(0, contract, []byte{0xa9, 0x05, 0x9c, 0xbb, 0x01}, key),
+		call(1, contract, []byte{0x01, 0x02, 0x03, 0x04}, key),
+		call(0, common.Address{}, nil, capped),
+		call(1, common.Address{}, nil, capped),
+		call(5, common.Address{}, nil, capped),
+	} {
+		if err := pool.addRemoteSync(tx); err != nil {
+			t.Fatalf("failed to add transaction %d: %v", i, err)
+		}
+	}
+	policy, err := ParsePolicyRules([]byte(fmt.Sprintf(`{
+		"rules": [
+			{"action": "reject", "to": ["%s"], "selectors": ["0xa9059cbb"]}
+		],
+		"slots": [
+			{"from": ["%s"], "max": 2}
+		]
+	}`, contract, crypto.PubkeyToAddress(capped.PublicKey))))
+	if err != nil {
+		t.Fatalf("failed to parse rules: %v", err)
+	}
+	pool.SetPolicy(policy)
+
+	// The rejected call demotes its successor, the highest nonce is evicted
+	if pending, queued := pool.Stats(); pending != 2 || queued != 1 {
+		t.Fatalf("pool size mismatch after policy: have %d/%d, want %d/%d", pending, queued, 2, 1)
+	}
+	if err := validatePoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	if err := pool.addRemoteSync(call(0, contract, []byte{0xa9, 0x05, 0x9c, 0xbb}, key)); !errors.Is(err, ErrPolicyRejected) {
+		t.Fatalf("rejected transaction error mismatch: have %v, want %v", err, ErrPolicyRejected)
+	}
+	if err := pool.addRemoteSync(call(0, contract, []byte{0x01, 0x02, 0x03, 0x04}, key)); err != nil {
+		t.Fatalf("failed to add accepted transaction: %v", err)
+	}
+	if err := pool.addRemoteSync(call(2, common.Address{}, nil, capped)); !errors.Is(err, ErrPolicySlots) {
+		t.Fatalf("capped transaction error mismatch: have %v, want %v", err, ErrPolicySlots)
+	}
+	// Replacements are not subject to the cap
+	replacement, _ := types.SignTx(types.NewTransaction(1, common.Address{}, big.NewInt(0), 100000, big.NewInt(2), nil), types.HomesteadSigner{}, capped)
+	if err := pool.addRemoteSync(replacement); err != nil {
+		t.Fatalf("failed to replace capped transaction: %v", err)
+	}
+	// Removing the policy lifts the restrictions
+	pool.SetPolicy(nil)
+	if err := pool.addRemoteSync(call(2, common.Address{}, nil, capped)); err != nil {
+		t.Fatalf("failed to add transaction without policy: %v", err)
+	}
+}
+
+func TestParsePolicyRules(t *testing.T) {
+	t.Parallel()
+
+	for i, rules := range []string{
+		`{"rules": [{"action": "drop"}]}`,
+		`{"rules": [{"action": "reject", "create": true, "to": ["0x0000000000000000000000000000000000000001"]}]}`,
+		`{"rules": [{"action": "reject", "selectors": ["0xa9059c"]}]}`,
+		`{"slots": [{"max": "8"}]}`,
+	} {
+		if _, err := ParsePolicyRules([]byte(rules)); err == nil {
+			t.Errorf("test %d: invalid rules accepted", i)
+		}
+	}
+	policy, err := ParsePolicyRules([]byte(`{
+		"rules": [
+			{"action": "accept", "from": ["0x0000000000000000000000000000000000000001"]},
+			{"action": "reject", "create": true}
+		],
+		"slots": [
+			{"from": ["0x0000000000000000000000000000000000000002"], "max": 64},
+			{"max": 8}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("failed to parse rules: %v", err)
+	}
+	create := types.NewContractCreation(0, big.NewInt(0), 100000, big.NewInt(1), nil)
+	if err := policy.Admit(create, common.HexToAddress("0x01"), false); err != nil {
+		t.Errorf("accepted sender's creation rejected: %v", err)
+	}
+	if err := policy.Admit(create, common.HexToAddress("0x02"), false); !errors.Is(err, ErrPolicyRejected) {
+		t.Errorf("creation error mismatch: have %v, want %v", err, ErrPolicyRejected)
+	}
+	if slots := policy.SenderSlots(common.HexToAddress("0x02")); slots != 64 {
+		t.Errorf("listed sender slots mismatch: have %d, want 64", slots)
+	}
+	if slots := policy.SenderSlots(common.HexToAddress("0x03")); slots != 8 {
+		t.Errorf("default sender slots mismatch: have %d, want 8", slots)
+	}
+}
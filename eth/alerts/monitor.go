@@ -0,0 +1,353 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+// Package alerts implements a monitor of the chain raising alerts on the signs
+// of a majority attack or consensus failure: deep reorgs, competing branches
+// far apart in cumulative difficulty and sudden difficulty changes.
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// webhookTimeout is the time allowed for a webhook to accept an alert.
+	webhookTimeout = 10 * time.Second
+
+	// webhookQueue is the number of alerts awaiting webhook delivery before
+	// further ones are dropped.
+	webhookQueue = 64
+)
+
+// Kinds of alerts raised by the monitor.
+const (
+	KindDeepReorg         = "deepReorg"         // Reorg dropping at least the configured number of blocks
+	KindDifficultySwing   = "difficultySwing"   // Reorg between branches far apart in cumulative difficulty
+	KindDifficultyAnomaly = "difficultyAnomaly" // Block difficulty deviating from the recent average
+)
+
+// Config are the settings of the monitor.
+type Config struct {
+	ReorgDepth      uint64 // Dropped blocks of a reorg raising an alert, zero to disable
+	DifficultySwing uint64 // Cumulative difficulty gap of reorged branches raising an alert, in average blocks, zero to disable
+	DifficultyJump  uint64 // Percentage a block difficulty may deviate from the recent average, zero to disable
+	Window          uint64 // Number of recent blocks averaged
+	Webhook         string // URL the alerts are posted to as JSON, if set
+}
+
+// DefaultConfig contains the default settings of the monitor.
+var DefaultConfig = Config{
+	ReorgDepth:      6,
+	DifficultySwing: 4,
+	DifficultyJump:  25,
+	Window:          64,
+}
+
+// Alert is a suspicious chain event detected by the monitor.
+type Alert struct {
+	Kind       string         `json:"kind"`
+	Time       hexutil.Uint64 `json:"time"`                 // Unix time the alert was raised at
+	Number     hexutil.Uint64 `json:"number"`               // Number of the block raising the alert
+	Hash       common.Hash    `json:"hash"`                 // Hash of the block raising the alert
+	Message    string         `json:"message"`              // Human readable description
+	Depth      hexutil.Uint64 `json:"depth,omitempty"`      // Dropped blocks of a reorg
+	Difficulty *hexutil.Big   `json:"difficulty,omitempty"` // Difficulty of the block, or cumulative one of the adopted branch
+	Expected   *hexutil.Big   `json:"expected,omitempty"`   // Recent average difficulty, or cumulative one of the dropped branch
+}
+
+// Backend is the chain access the monitor requires.
+type Backend interface {
+	CurrentHeader() *types.Header
+	GetHeaderByNumber(number uint64) *types.Header
+	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
+	SubscribeReorgEvent(ch chan<- core.ReorgEvent) event.Subscription
+}
+
+// Monitor follows the chain head and its reorgs, raising alerts on suspicious
+// events. Alerts are logged, streamed to subscribers and posted to the webhook.
+type Monitor struct {
+	backend Backend
+	config  Config
+	client  *http.Client
+
+	feed  event.Feed
+	scope event.SubscriptionScope
+
+	window    []*big.Int  // Difficulties of the recent canonical blocks, oldest first
+	last      common.Hash // Hash of the head the window ends at
+	anomalous bool        // Whether the difficulty deviated at the last head, to only alert once
+
+	hooks chan *Alert
+	quit  chan struct{}
+	wg    sync.WaitGroup
+}
+
+// New creates a monitor of the chain of the given backend.
+func New(backend Backend, config Config) *Monitor {
+	if config.Window == 0 {
+		config.Window = DefaultConfig.Window
+	}
+	return &Monitor{
+		backend: backend,
+		config:  config,
+		client:  &http.Client{Timeout: webhookTimeout},
+		hooks:   make(chan *Alert, webhookQueue),
+		quit:    make(chan struct{}),
+	}
+}
+
+// APIs returns the RPC APIs streaming the alerts.
+func (m *Monitor) APIs() []rpc.API {
+	return []rpc.API{{
+		Namespace: "eth",
+		Service:   &API{m},
+	}}
+}
+
+// Start implements node.Lifecycle, starting to monitor the chain.
+func (m *Monitor) Start() error {
+	m.wg.Add(1)
+	go m.loop()
+
+	if m.config.Webhook != "" {
+		m.wg.Add(1)
+		go m.deliver()
+	}
+	return nil
+}
+
+// Stop implements node.Lifecycle, terminating the monitoring.
+func (m *Monitor) Stop() error {
+	close(m.quit)
+	m.wg.Wait()
+	m.scope.Close()
+	return nil
+}
+
+// SubscribeAlerts registers a subscription of the raised alerts.
+func (m *Monitor) SubscribeAlerts(ch chan<- *Alert) event.Subscription {
+	return m.scope.Track(m.feed.Subscribe(ch))
+}
+
+// loop checks every new chain head and reorg.
+func (m *Monitor) loop() {
+	defer m.wg.Done()
+
+	var (
+		heads  = make(chan core.ChainHeadEvent, 16)
+		reorgs = make(chan core.ReorgEvent, 16)
+	)
+	headSub := m.backend.SubscribeChainHeadEvent(heads)
+	defer headSub.Unsubscribe()
+	reorgSub := m.backend.SubscribeReorgEvent(reorgs)
+	defer reorgSub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-heads:
+			m.checkHead(ev.Block.Header())
+		case ev := <-reorgs:
+			m.checkReorg(ev)
+		case <-headSub.Err():
+			return
+		case <-reorgSub.Err():
+			return
+		case <-m.quit:
+			return
+		}
+	}
+}
+
+// checkHead raises an alert if the difficulty of a new head deviates too much
+// from the recent average, then adds it to the averaged window.
+func (m *Monitor) checkHead(header *types.Header) {
+	if header.ParentHash != m.last {
+		m.seed(header.Number.Uint64())
+	}
+	if avg := m.average(); m.config.DifficultyJump > 0 && avg != nil && uint64(len(m.window)) == m.config.Window {
+		deviation := new(big.Int).Sub(header.Difficulty, avg)
+		deviation.Abs(deviation).Mul(deviation, big.NewInt(100)).Div(deviation, avg)
+
+		if deviation.Cmp(new(big.Int).SetUint64(m.config.DifficultyJump)) >= 0 {
+			if !m.anomalous {
+				m.raise(&Alert{
+					Kind:       KindDifficultyAnomaly,
+					Number:     hexutil.Uint64(header.Number.Uint64()),
+					Hash:       header.Hash(),
+					Message:    fmt.Sprintf("block difficulty deviates %v%% from the average of the last %d blocks", deviation, len(m.window)),
+					Difficulty: (*hexutil.Big)(header.Difficulty),
+					Expected:   (*hexutil.Big)(avg),
+				})
+			}
+			m.anomalous = true
+		} else {
+			m.anomalous = false
+		}
+	}
+	m.window = append(m.window, header.Difficulty)
+	if uint64(len(m.window)) > m.config.Window {
+		m.window = m.window[1:]
+	}
+	m.last = header.Hash()
+}
+
+// seed fills the averaged window with the canonical blocks preceding the given
+// one, after startup or a reorg.
+func (m *Monitor) seed(number uint64) {
+	m.window = m.window[:0]
+
+	first := uint64(0)
+	if number > m.config.Window {
+		first = number - m.config.Window
+	}
+	for n := first; n < number; n++ {
+		header := m.backend.GetHeaderByNumber(n)
+		if header == nil {
+			m.window = m.window[:0]
+			continue
+		}
+		m.window = append(m.window, header.Difficulty)
+	}
+}
+
+// average returns the average difficulty of the window, nil if it's empty.
+func (m *Monitor) average() *big.Int {
+	if len(m.window) == 0 {
+		return nil
+	}
+	sum := new(big.Int)
+	for _, difficulty := range m.window {
+		sum.Add(sum, difficulty)
+	}
+	return sum.Div(sum, big.NewInt(int64(len(m.window))))
+}
+
+// checkReorg raises alerts if a reorg dropped too many blocks, or if the two
+// branches are too far apart in cumulative difficulty.
+func (m *Monitor) checkReorg(ev core.ReorgEvent) {
+	head := ev.Common
+	if len(ev.NewChain) > 0 {
+		head = ev.NewChain[len(ev.NewChain)-1]
+	}
+	depth := uint64(len(ev.OldChain))
+	if m.config.ReorgDepth > 0 && depth >= m.config.ReorgDepth {
+		m.raise(&Alert{
+			Kind:    KindDeepReorg,
+			Number:  hexutil.Uint64(head.Number.Uint64()),
+			Hash:    head.Hash(),
+			Message: fmt.Sprintf("reorg dropped %d blocks above #%d", depth, ev.Common.Number),
+			Depth:   hexutil.Uint64(depth),
+		})
+	}
+	if m.config.DifficultySwing == 0 || depth == 0 {
+		return
+	}
+	var oldTD, newTD = new(big.Int), new(big.Int)
+	for _, header := range ev.OldChain {
+		oldTD.Add(oldTD, header.Difficulty)
+	}
+	for _, header := range ev.NewChain {
+		newTD.Add(newTD, header.Difficulty)
+	}
+	// Measure the gap against the recent average block, or the average one of
+	// the dropped branch if none is known yet
+	avg := m.average()
+	if avg == nil || avg.Sign() == 0 {
+		avg = new(big.Int).Div(oldTD, new(big.Int).SetUint64(depth))
+	}
+	gap := new(big.Int).Sub(newTD, oldTD)
+	gap.Abs(gap)
+	if avg.Sign() > 0 && gap.Cmp(new(big.Int).Mul(avg, new(big.Int).SetUint64(m.config.DifficultySwing))) >= 0 {
+		m.raise(&Alert{
+			Kind:       KindDifficultySwing,
+			Number:     hexutil.Uint64(head.Number.Uint64()),
+			Hash:       head.Hash(),
+			Message:    fmt.Sprintf("reorged branches differ by %v blocks worth of difficulty", new(big.Int).Div(gap, avg)),
+			Depth:      hexutil.Uint64(depth),
+			Difficulty: (*hexutil.Big)(newTD),
+			Expected:   (*hexutil.Big)(oldTD),
+		})
+	}
+}
+
+// raise logs an alert, streams it to the subscribers and queues it for the
+// webhook.
+func (m *Monitor) raise(alert *Alert) {
+	alert.Time = hexutil.Uint64(time.Now().Unix())
+	log.Warn("Chain security alert", "kind", alert.Kind, "number", uint64(alert.Number), "hash", alert.Hash, "msg", alert.Message)
+
+	m.feed.Send(alert)
+	if m.config.Webhook != "" {
+		select {
+		case m.hooks <- alert:
+		default:
+			log.Warn("Dropping security alert, webhook backlogged", "kind", alert.Kind, "number", uint64(alert.Number))
+		}
+	}
+}
+
+// deliver posts the queued alerts to the webhook.
+func (m *Monitor) deliver() {
+	defer m.wg.Done()
+
+	for {
+		select {
+		case alert := <-m.hooks:
+			if err := m.post(alert); err != nil {
+				log.Warn("Failed to post security alert", "url", m.config.Webhook, "err", err)
+			}
+		case <-m.quit:
+			return
+		}
+	}
+}
+
+// post sends an alert to the webhook.
+func (m *Monitor) post(alert *Alert) error {
+	blob, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.config.Webhook, bytes.NewReader(blob))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded %s", res.Status)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package alerts
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+type testBackend struct {
+	headers   []*types.Header
+	headFeed  event.Feed
+	reorgFeed event.Feed
+}
+
+func (b *testBackend) CurrentHeader() *types.Header { return b.headers[len(b.headers)-1] }
+
+func (b *testBackend) GetHeaderByNumber(number uint64) *types.Header {
+	if number < uint64(len(b.headers)) {
+		return b.headers[number]
+	}
+	return nil
+}
+
+func (b *testBackend) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
+	return b.headFeed.Subscribe(ch)
+}
+
+func (b *testBackend) SubscribeReorgEvent(ch chan<- core.ReorgEvent) event.Subscription {
+	return b.reorgFeed.Subscribe(ch)
+}
+
+// extend appends blocks of the given difficulties to the canonical chain.
+func (b *testBackend) extend(difficulties ...int64) []*types.Header {
+	var added []*types.Header
+	for _, difficulty := range difficulties {
+		header := &types.Header{Difficulty: big.NewInt(difficulty)}
+		if len(b.headers) == 0 {
+			header.Number = new(big.Int)
+		} else {
+			parent := b.headers[len(b.headers)-1]
+			header.ParentHash = parent.Hash()
+			header.Number = new(big.Int).Add(parent.Number, big.NewInt(1))
+		}
+		b.headers = append(b.headers, header)
+		added = append(added, header)
+	}
+	return added
+}
+
+// fork returns a branch of blocks of the given difficulties on top of the given
+// canonical block, not adding it to the chain.
+func fork(parent *types.Header, difficulties ...int64) []*types.Header {
+	var branch []*types.Header
+	for _, difficulty := range difficulties {
+		header := &types.Header{
+			ParentHash: parent.Hash(),
+			Number:     new(big.Int).Add(parent.Number, big.NewInt(1)),
+			Difficulty: big.NewInt(difficulty),
+			Extra:      []byte("fork"),
+		}
+		branch = append(branch, header)
+		parent = header
+	}
+	return branch
+}
+
+func TestDifficultyAnomaly(t *testing.T) {
+	backend := new(testBackend)
+	backend.extend(100, 100, 100, 100, 100)
+
+	m := New(backend, Config{DifficultyJump: 25, Window: 4})
+	alerts := make(chan *Alert, 16)
+	defer m.SubscribeAlerts(alerts).Unsubscribe()
+
+	// Heads within the limit don't alert, the first one outside does, once
+	for _, header := range backend.extend(110, 90, 200, 200) {
+		m.checkHead(header)
+	}
+	select {
+	case alert := <-alerts:
+		if alert.Kind != KindDifficultyAnomaly || uint64(alert.Number) != 7 || alert.Difficulty.ToInt().Int64() != 200 || alert.Expected.ToInt().Int64() != 100 {
+			t.Fatalf("alert mismatch: %+v", alert)
+		}
+	default:
+		t.Fatalf("no alert raised")
+	}
+	select {
+	case alert := <-alerts:
+		t.Fatalf("repeated alert: %+v", alert)
+	default:
+	}
+}
+
+func TestReorgAlerts(t *testing.T) {
+	backend := new(testBackend)
+	chain := backend.extend(100, 100, 100, 100, 100, 100, 100, 100)
+
+	m := New(backend, Config{ReorgDepth: 3, DifficultySwing: 2, Window: 4})
+	alerts := make(chan *Alert, 16)
+	defer m.SubscribeAlerts(alerts).Unsubscribe()
+	m.checkHead(chain[len(chain)-1])
+
+	// A shallow reorg between branches of equal weight is fine
+	m.checkReorg(core.ReorgEvent{Common: chain[5], OldChain: chain[6:], NewChain: fork(chain[5], 100, 100)})
+	select {
+	case alert := <-alerts:
+		t.Fatalf("unexpected alert: %+v", alert)
+	default:
+	}
+	// A deep reorg to a much heavier branch raises both alerts
+	m.checkReorg(core.ReorgEvent{Common: chain[4], OldChain: chain[5:], NewChain: fork(chain[4], 200, 200, 100)})
+	for _, kind := range []string{KindDeepReorg, KindDifficultySwing} {
+		select {
+		case alert := <-alerts:
+			if alert.Kind != kind || uint64(alert.Depth) != 3 || uint64(alert.Number) != 7 {
+				t.Fatalf("alert mismatch: have %+v, want %s", alert, kind)
+			}
+		default:
+			t.Fatalf("no %s alert raised", kind)
+		}
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	posted := make(chan *Alert, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		alert := new(Alert)
+		if err := json.NewDecoder(r.Body).Decode(alert); err != nil {
+			t.Errorf("invalid webhook body: %v", err)
+		}
+		select {
+		case posted <- alert:
+		default:
+		}
+	}))
+	defer server.Close()
+
+	backend := new(testBackend)
+	chain := backend.extend(100, 100, 100, 100)
+
+	m := New(backend, Config{ReorgDepth: 2, Webhook: server.URL})
+	m.Start()
+	defer m.Stop()
+
+	// The monitor may not have subscribed yet, keep sending until it reacts
+	ev := core.ReorgEvent{Common: chain[1], OldChain: chain[2:], NewChain: fork(chain[1], 100, 100)}
+	timeout := time.After(5 * time.Second)
+	for {
+		backend.reorgFeed.Send(ev)
+		select {
+		case alert := <-posted:
+			if alert.Kind != KindDeepReorg || uint64(alert.Depth) != 2 {
+				t.Fatalf("alert mismatch: %+v", alert)
+			}
+			return
+		case <-time.After(50 * time.Millisecond):
+		case <-timeout:
+			t.Fatalf("webhook not called")
+		}
+	}
+}
@@ -15,6 +15,7 @@ package eth
 import (
 	"compress/gzip"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -24,14 +25,25 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/state/pruner"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/protocols/snap"
+	"github.com/ethereum/go-ethereum/eth/rebroadcast"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	_ "github.com/ethereum/go-ethereum/eth/tracers/native" // Tracers of simulated blocks
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/miner"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/ethereum/go-ethereum/trie"
@@ -67,6 +79,429 @@ func (api *EthereumAPI) Mining() bool {
 	return api.e.IsMining()
 }
 
+// GetPendingBlock returns the block the miner is currently sealing, the exact one
+// a solution found by the local or a remote sealer would seal, as opposed to the
+// synthetic pending block. Besides the block fields, the seal hash, the priority
+// fees, the coinbase profit including direct payments and the hash of the
+// candidate transactions to replay the construction with are returned. Null is
+// returned if the node is not mining.
+func (api *EthereumAPI) GetPendingBlock(fullTx bool) (map[string]interface{}, error) {
+	work := api.e.Miner().SealingWork()
+	if work == nil {
+		return nil, nil
+	}
+	fields, err := ethapi.RPCMarshalBlock(work.Block, true, fullTx, api.e.BlockChain().Config())
+	if err != nil {
+		return nil, err
+	}
+	fields["sealHash"] = work.SealHash
+	fields["fees"] = (*hexutil.Big)(work.Fees)
+	fields["profit"] = (*hexutil.Big)(work.Profit)
+	fields["txSetHash"] = work.TxSetHash
+	fields["createdAt"] = hexutil.Uint64(work.CreatedAt.Unix())
+	return fields, nil
+}
+
+// maxDifficultyHistory is the maximum number of headers a difficulty history
+// or network hashrate estimation may span.
+const maxDifficultyHistory = 10000
+
+// DifficultyEntry is the difficulty and timestamp of a canonical block.
+type DifficultyEntry struct {
+	Number     hexutil.Uint64 `json:"number"`
+	Hash       common.Hash    `json:"hash"`
+	Timestamp  hexutil.Uint64 `json:"timestamp"`
+	Difficulty *hexutil.Big   `json:"difficulty"`
+}
+
+// GetDifficultyHistory returns the difficulties and timestamps of the canonical
+// blocks in the given inclusive range, capped at the current head.
+func (api *EthereumAPI) GetDifficultyHistory(from, to hexutil.Uint64) ([]*DifficultyEntry, error) {
+	if head := api.e.BlockChain().CurrentHeader().Number.Uint64(); uint64(to) > head {
+		to = hexutil.Uint64(head)
+	}
+	if from > to {
+		return nil, fmt.Errorf("invalid range %d-%d", from, to)
+	}
+	if to-from >= maxDifficultyHistory {
+		return nil, fmt.Errorf("range exceeds %d blocks", maxDifficultyHistory)
+	}
+	entries := make([]*DifficultyEntry, 0, to-from+1)
+	for number := uint64(from); number <= uint64(to); number++ {
+		header := api.e.BlockChain().GetHeaderByNumber(number)
+		if header == nil {
+			return nil, fmt.Errorf("header #%d not found", number)
+		}
+		entries = append(entries, &DifficultyEntry{
+			Number:     hexutil.Uint64(number),
+			Hash:       header.Hash(),
+			Timestamp:  hexutil.Uint64(header.Time),
+			Difficulty: (*hexutil.Big)(header.Difficulty),
+		})
+	}
+	return entries, nil
+}
+
+// EstimateNetworkHashrate estimates the network hashrate in hashes per second
+// from the work of the given number of most recent blocks, the sum of their
+// difficulties, over the time elapsed since their parent.
+func (api *EthereumAPI) EstimateNetworkHashrate(window hexutil.Uint64) (*hexutil.Big, error) {
+	if window == 0 || window > maxDifficultyHistory {
+		return nil, fmt.Errorf("window must be within 1-%d blocks", maxDifficultyHistory)
+	}
+	head := api.e.BlockChain().CurrentHeader()
+	if uint64(window) > head.Number.Uint64() {
+		window = hexutil.Uint64(head.Number.Uint64())
+	}
+	headers := []*types.Header{head}
+	for i := uint64(0); i < uint64(window); i++ {
+		last := headers[len(headers)-1]
+		parent := api.e.BlockChain().GetHeader(last.ParentHash, last.Number.Uint64()-1)
+		if parent == nil {
+			break
+		}
+		headers = append(headers, parent)
+	}
+	hashrate := estimateHashrate(headers)
+	if hashrate == nil {
+		return nil, errors.New("not enough blocks to estimate the hashrate")
+	}
+	return (*hexutil.Big)(hashrate), nil
+}
+
+// maxTipForecastBlocks is the maximum number of blocks a tip forecast may
+// target inclusion within.
+const maxTipForecastBlocks = 1024
+
+// SuggestTipByPercentile returns the tip needed for a transaction to be
+// included within the given number of blocks with the given percent of
+// probability. The forecast is computed from the inclusion cutoffs of the
+// pending block templates recently built by the miner, so it is only available
+// on nodes assembling blocks.
+func (api *EthereumAPI) SuggestTipByPercentile(percentile float64, blocks hexutil.Uint64) (*hexutil.Big, error) {
+	if percentile <= 0 || percentile > 100 {
+		return nil, fmt.Errorf("percentile must be within (0, 100], got %v", percentile)
+	}
+	if blocks == 0 || blocks > maxTipForecastBlocks {
+		return nil, fmt.Errorf("blocks must be within 1-%d", maxTipForecastBlocks)
+	}
+	tip, err := api.e.Miner().SuggestTip(percentile/100, uint64(blocks))
+	if err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(tip), nil
+}
+
+// estimateHashrate computes the hashrate from a descending run of headers, the
+// difficulties of all but the oldest divided by the time span they cover. Nil
+// is returned if the headers span no time.
+func estimateHashrate(headers []*types.Header) *big.Int {
+	if len(headers) < 2 {
+		return nil
+	}
+	oldest := headers[len(headers)-1]
+	if headers[0].Time <= oldest.Time {
+		return nil
+	}
+	work := new(big.Int)
+	for _, header := range headers[:len(headers)-1] {
+		work.Add(work, header.Difficulty)
+	}
+	return work.Div(work, new(big.Int).SetUint64(headers[0].Time-oldest.Time))
+}
+
+// ReorgInfo is a reorg of the canonical chain, with the dropped and adopted
+// block hashes in ascending order.
+type ReorgInfo struct {
+	Depth        hexutil.Uint64 `json:"depth"`
+	CommonNumber hexutil.Uint64 `json:"commonNumber"`
+	CommonHash   common.Hash    `json:"commonHash"`
+	OldChain     []common.Hash  `json:"oldChain"`
+	NewChain     []common.Hash  `json:"newChain"`
+}
+
+// newReorgInfo converts a reorg event into its RPC representation.
+func newReorgInfo(event core.ReorgEvent) *ReorgInfo {
+	info := &ReorgInfo{
+		Depth:        hexutil.Uint64(len(event.OldChain)),
+		CommonNumber: hexutil.Uint64(event.Common.Number.Uint64()),
+		CommonHash:   event.Common.Hash(),
+		OldChain:     make([]common.Hash, 0, len(event.OldChain)),
+		NewChain:     make([]common.Hash, 0, len(event.NewChain)),
+	}
+	for _, header := range event.OldChain {
+		info.OldChain = append(info.OldChain, header.Hash())
+	}
+	for _, header := range event.NewChain {
+		info.NewChain = append(info.NewChain, header.Hash())
+	}
+	return info
+}
+
+// Reorg creates a subscription that is triggered each time the canonical chain
+// switches to a competing branch, reporting the dropped and adopted blocks.
+func (api *EthereumAPI) Reorg(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		reorgs := make(chan core.ReorgEvent, 16)
+		reorgSub := api.e.BlockChain().SubscribeReorgEvent(reorgs)
+		defer reorgSub.Unsubscribe()
+
+		for {
+			select {
+			case event := <-reorgs:
+				notifier.Notify(rpcSub.ID, newReorgInfo(event))
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// SendBundleArgs are the arguments of eth_sendBundle, following the Flashbots
+// bundle format.
+type SendBundleArgs struct {
+	Txs               []hexutil.Bytes `json:"txs"`
+	BlockNumber       hexutil.Uint64  `json:"blockNumber"`
+	MinTimestamp      *uint64         `json:"minTimestamp"`
+	MaxTimestamp      *uint64         `json:"maxTimestamp"`
+	RevertingTxHashes []common.Hash   `json:"revertingTxHashes"`
+}
+
+// SendBundleResult is the reply to eth_sendBundle.
+type SendBundleResult struct {
+	BundleHash common.Hash `json:"bundleHash"`
+}
+
+// SendBundle submits an atomic transaction bundle to the miner's private pool.
+// The bundle is never gossiped and only included by this node's miner, in the
+// targeted block, in full or not at all.
+func (api *EthereumAPI) SendBundle(args SendBundleArgs) (SendBundleResult, error) {
+	txs, err := decodeBundleTxs(args.Txs)
+	if err != nil {
+		return SendBundleResult{}, err
+	}
+	bundle := &miner.Bundle{
+		Txs:               txs,
+		BlockNumber:       uint64(args.BlockNumber),
+		RevertingTxHashes: args.RevertingTxHashes,
+	}
+	if args.MinTimestamp != nil {
+		bundle.MinTimestamp = *args.MinTimestamp
+	}
+	if args.MaxTimestamp != nil {
+		bundle.MaxTimestamp = *args.MaxTimestamp
+	}
+	if err := api.e.Miner().SendBundle(bundle); err != nil {
+		return SendBundleResult{}, err
+	}
+	return SendBundleResult{BundleHash: bundle.Hash()}, nil
+}
+
+// CallBundleArgs are the arguments of eth_callBundle. Bundles are always
+// simulated on top of the current chain head.
+type CallBundleArgs struct {
+	Txs       []hexutil.Bytes `json:"txs"`
+	Timestamp *uint64         `json:"timestamp"`
+}
+
+// CallBundleTxResult is the outcome of a single simulated bundle transaction.
+type CallBundleTxResult struct {
+	TxHash       common.Hash    `json:"txHash"`
+	GasUsed      hexutil.Uint64 `json:"gasUsed"`
+	Reverted     bool           `json:"reverted"`
+	CoinbaseDiff *hexutil.Big   `json:"coinbaseDiff"`
+}
+
+// CallBundleResult is the reply to eth_callBundle.
+type CallBundleResult struct {
+	BundleHash       common.Hash          `json:"bundleHash"`
+	Results          []CallBundleTxResult `json:"results"`
+	CoinbaseDiff     *hexutil.Big         `json:"coinbaseDiff"`
+	TotalGasUsed     hexutil.Uint64       `json:"totalGasUsed"`
+	StateBlockNumber hexutil.Uint64       `json:"stateBlockNumber"`
+}
+
+// CallBundle simulates a transaction bundle on top of the current chain head
+// without submitting it, reporting the gas used and coinbase payment of each
+// transaction.
+func (api *EthereumAPI) CallBundle(args CallBundleArgs) (CallBundleResult, error) {
+	txs, err := decodeBundleTxs(args.Txs)
+	if err != nil {
+		return CallBundleResult{}, err
+	}
+	timestamp := uint64(time.Now().Unix())
+	if args.Timestamp != nil {
+		timestamp = *args.Timestamp
+	}
+	bundle := &miner.Bundle{Txs: txs}
+	sim, err := api.e.Miner().CallBundle(bundle, timestamp)
+	if err != nil {
+		return CallBundleResult{}, err
+	}
+	res := CallBundleResult{
+		BundleHash:       bundle.Hash(),
+		Results:          make([]CallBundleTxResult, 0, len(sim.Results)),
+		CoinbaseDiff:     (*hexutil.Big)(sim.CoinbaseDiff),
+		TotalGasUsed:     hexutil.Uint64(sim.TotalGasUsed),
+		StateBlockNumber: hexutil.Uint64(sim.StateBlockNumber),
+	}
+	for _, result := range sim.Results {
+		res.Results = append(res.Results, CallBundleTxResult{
+			TxHash:       result.TxHash,
+			GasUsed:      hexutil.Uint64(result.GasUsed),
+			Reverted:     result.Reverted,
+			CoinbaseDiff: (*hexutil.Big)(result.CoinbaseDiff),
+		})
+	}
+	return res, nil
+}
+
+// SimulateBlockArgs are the arguments of eth_simulateBlock. The block number is
+// implied by the parent and can't be overridden.
+type SimulateBlockArgs struct {
+	Txs            []hexutil.Bytes        `json:"txs"`
+	Parent         *rpc.BlockNumberOrHash `json:"parent"`
+	BlockOverrides *ethapi.BlockOverrides `json:"blockOverrides"`
+}
+
+// SimulateBlockTxResult is the outcome of a single transaction of a simulated
+// block, along with its call trace and the state changes it caused.
+type SimulateBlockTxResult struct {
+	TxHash       common.Hash     `json:"txHash"`
+	Receipt      *types.Receipt  `json:"receipt"`
+	CoinbaseDiff *hexutil.Big    `json:"coinbaseDiff"`
+	Trace        json.RawMessage `json:"trace"`
+	StateDiff    json.RawMessage `json:"stateDiff"`
+}
+
+// SimulateBlockResult is the reply to eth_simulateBlock.
+type SimulateBlockResult struct {
+	ParentHash     common.Hash             `json:"parentHash"`
+	Number         hexutil.Uint64          `json:"number"`
+	Timestamp      hexutil.Uint64          `json:"timestamp"`
+	Coinbase       common.Address          `json:"coinbase"`
+	GasLimit       hexutil.Uint64          `json:"gasLimit"`
+	GasUsed        hexutil.Uint64          `json:"gasUsed"`
+	BaseFee        *hexutil.Big            `json:"baseFee,omitempty"`
+	Results        []SimulateBlockTxResult `json:"results"`
+	CoinbaseProfit *hexutil.Big            `json:"coinbaseProfit"`
+}
+
+// simulateTracerConfig runs the call tracer and the state diffing tracer over
+// each transaction of a simulated block.
+var simulateTracerConfig = json.RawMessage(`{"callTracer":{},"prestateTracer":{"diffMode":true}}`)
+
+// SimulateBlock executes the given transactions as a hypothetical block on top
+// of a parent, the latest block by default, with the header fields optionally
+// overridden. Each transaction is traced, and the coinbase profit is computed
+// the way the miner evaluates block templates. Nothing is persisted.
+func (api *EthereumAPI) SimulateBlock(ctx context.Context, args SimulateBlockArgs) (*SimulateBlockResult, error) {
+	txs, err := decodeBundleTxs(args.Txs)
+	if err != nil {
+		return nil, err
+	}
+	parentNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	if args.Parent != nil {
+		parentNrOrHash = *args.Parent
+	}
+	parent, err := api.e.APIBackend.HeaderByNumberOrHash(ctx, parentNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if parent == nil {
+		return nil, errors.New("parent block not found")
+	}
+	params := &miner.SimulationParams{Parent: parent.Hash()}
+	if diff := args.BlockOverrides; diff != nil {
+		if diff.Number != nil && diff.Number.ToInt().Cmp(new(big.Int).Add(parent.Number, common.Big1)) != 0 {
+			return nil, errors.New("block number is implied by the parent")
+		}
+		if diff.Time != nil {
+			params.Timestamp = uint64(*diff.Time)
+		}
+		params.Coinbase = diff.Coinbase
+		params.GasLimit = (*uint64)(diff.GasLimit)
+		params.BaseFee = (*big.Int)(diff.BaseFee)
+		params.Difficulty = (*big.Int)(diff.Difficulty)
+		if diff.Random != nil {
+			params.Random = *diff.Random
+		}
+	}
+	var (
+		tracerList = make([]tracers.Tracer, len(txs))
+		tracerErr  error
+	)
+	params.Tracer = func(index int, tx *types.Transaction) vm.EVMLogger {
+		tracer, err := tracers.DefaultDirectory.New("muxTracer", &tracers.Context{TxIndex: index, TxHash: tx.Hash()}, simulateTracerConfig)
+		if err != nil {
+			tracerErr = err
+			return nil
+		}
+		tracerList[index] = tracer
+		return tracer
+	}
+	sim, err := api.e.Miner().SimulateBlock(txs, params)
+	if err != nil {
+		return nil, err
+	}
+	if tracerErr != nil {
+		return nil, tracerErr
+	}
+	res := &SimulateBlockResult{
+		ParentHash:     sim.Header.ParentHash,
+		Number:         hexutil.Uint64(sim.Header.Number.Uint64()),
+		Timestamp:      hexutil.Uint64(sim.Header.Time),
+		Coinbase:       sim.Header.Coinbase,
+		GasLimit:       hexutil.Uint64(sim.Header.GasLimit),
+		GasUsed:        hexutil.Uint64(sim.Header.GasUsed),
+		BaseFee:        (*hexutil.Big)(sim.Header.BaseFee),
+		Results:        make([]SimulateBlockTxResult, len(txs)),
+		CoinbaseProfit: (*hexutil.Big)(sim.Profit),
+	}
+	for i, tx := range txs {
+		traces, err := tracerList[i].GetResult()
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d (%x): %v", i, tx.Hash(), err)
+		}
+		var results map[string]json.RawMessage
+		if err := json.Unmarshal(traces, &results); err != nil {
+			return nil, err
+		}
+		res.Results[i] = SimulateBlockTxResult{
+			TxHash:       tx.Hash(),
+			Receipt:      sim.Receipts[i],
+			CoinbaseDiff: (*hexutil.Big)(sim.CoinbaseDiffs[i]),
+			Trace:        results["callTracer"],
+			StateDiff:    results["prestateTracer"],
+		}
+	}
+	return res, nil
+}
+
+// decodeBundleTxs decodes the binary encoded transactions of a bundle.
+func decodeBundleTxs(blobs []hexutil.Bytes) (types.Transactions, error) {
+	txs := make(types.Transactions, 0, len(blobs))
+	for i, blob := range blobs {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(blob); err != nil {
+			return nil, fmt.Errorf("invalid transaction %d: %v", i, err)
+		}
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
+
 // MinerAPI provides an API to control the miner.
 type MinerAPI struct {
 	e *Ethereum
@@ -103,6 +538,15 @@ func (api *MinerAPI) SetExtra(extra string) (bool, error) {
 	return true, nil
 }
 
+// SetExtraTemplate sets the extra data template resolved for every block this
+// miner mines, with the {{.WorkerID}}, {{.PoolTag}} and {{.Height}} variables.
+func (api *MinerAPI) SetExtraTemplate(template string, workerID, poolTag string) (bool, error) {
+	if err := api.e.Miner().SetExtraTemplate(template, workerID, poolTag); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // SetGasPrice sets the minimum accepted gas price for the miner.
 func (api *MinerAPI) SetGasPrice(gasPrice hexutil.Big) bool {
 	api.e.lock.Lock()
@@ -119,6 +563,43 @@ func (api *MinerAPI) SetGasLimit(gasLimit hexutil.Uint64) bool {
 	return true
 }
 
+// SetGasLimitTarget gradually moves the gaslimit toward the target over the
+// given number of blocks.
+func (api *MinerAPI) SetGasLimitTarget(target hexutil.Uint64, blocks hexutil.Uint64) (bool, error) {
+	if err := api.e.Miner().SetGasLimitTarget(uint64(target), uint64(blocks)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetUnclePolicy sets the policy selecting the uncles included in mined blocks.
+func (api *MinerAPI) SetUnclePolicy(policy miner.UnclePolicy) (bool, error) {
+	if err := api.e.Miner().SetUnclePolicy(policy); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetCoinbaseRotation spreads the rewards of the mined blocks over the given
+// payout addresses, switching the coinbase of the sealing work after every
+// mined block. The policy is round-robin or weighted, the latter taking a weight
+// per address. An empty list of addresses disables the rotation.
+func (api *MinerAPI) SetCoinbaseRotation(addresses []common.Address, policy string, weights *[]uint64) (bool, error) {
+	var w []uint64
+	if weights != nil {
+		w = *weights
+	}
+	if err := api.e.Miner().SetCoinbaseRotation(addresses, policy, w); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// CoinbaseRotation returns the status of the coinbase rotation, nil if disabled.
+func (api *MinerAPI) CoinbaseRotation() *miner.CoinbaseRotation {
+	return api.e.Miner().CoinbaseRotation()
+}
+
 // SetEtherbase sets the etherbase of the miner.
 func (api *MinerAPI) SetEtherbase(etherbase common.Address) bool {
 	api.e.SetEtherbase(etherbase)
@@ -130,6 +611,95 @@ func (api *MinerAPI) SetRecommitInterval(interval int) {
 	api.e.Miner().SetRecommitInterval(time.Duration(interval) * time.Millisecond)
 }
 
+// SetPriorityAddresses pins the given senders' transactions to be ordered first
+// in block assembly regardless of their tip, replacing any previous set.
+func (api *MinerAPI) SetPriorityAddresses(addrs []common.Address) bool {
+	api.e.Miner().SetPriorityAddresses(addrs)
+	return true
+}
+
+// SetPriorityTxs pins the given transactions to be ordered first in block
+// assembly regardless of their tip, replacing any previous set.
+func (api *MinerAPI) SetPriorityTxs(hashes []common.Hash) bool {
+	api.e.Miner().SetPriorityTxs(hashes)
+	return true
+}
+
+// TxPoolAdminAPI provides an API to manage the sender lists of the transaction
+// pool. It should only be exposed to the node operator.
+type TxPoolAdminAPI struct {
+	e *Ethereum
+}
+
+// NewTxPoolAdminAPI creates a new TxPoolAdminAPI instance.
+func NewTxPoolAdminAPI(e *Ethereum) *TxPoolAdminAPI {
+	return &TxPoolAdminAPI{e}
+}
+
+// SetAllowlist replaces the senders whose transactions bypass the minimum tip
+// of the transaction pool and of the miner.
+func (api *TxPoolAdminAPI) SetAllowlist(addrs []common.Address) (bool, error) {
+	if err := api.e.TxPool().SetAllowlist(addrs); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetDenylist replaces the senders whose transactions are rejected by the
+// transaction pool, dropping the ones already pooled.
+func (api *TxPoolAdminAPI) SetDenylist(addrs []common.Address) (bool, error) {
+	if err := api.e.TxPool().SetDenylist(addrs); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ReplaceTransaction replaces a pooled transaction with a copy of its fees
+// bumped by the percentage the pool requires for replacements of its type, or
+// by the given larger one, signed by the sender's wallet in the account manager.
+// The wallet needs to be unlocked for this to succeed.
+func (api *TxPoolAdminAPI) ReplaceTransaction(hash common.Hash, percent *uint64) (common.Hash, error) {
+	pool := api.e.TxPool()
+	tx := pool.Get(hash)
+	if tx == nil {
+		return common.Hash{}, fmt.Errorf("transaction %x not in pool", hash)
+	}
+	bump := pool.PriceBump(tx.Type())
+	if percent != nil {
+		if *percent < bump {
+			return common.Hash{}, fmt.Errorf("price bump of %d%% below the %d%% required for replacements", *percent, bump)
+		}
+		bump = *percent
+	}
+	replacement, err := rebroadcast.Bump(tx, bump)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	config := api.e.BlockChain().Config()
+	from, err := types.Sender(types.LatestSigner(config), tx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	account := accounts.Account{Address: from}
+	wallet, err := api.e.AccountManager().Find(account)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	var chainID *big.Int
+	if tx.Protected() {
+		chainID = config.ChainID
+	}
+	signed, err := wallet.SignTx(account, replacement, chainID)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if err := pool.AddLocal(signed); err != nil {
+		return common.Hash{}, err
+	}
+	log.Info("Replaced pooled transaction", "hash", hash, "replacement", signed.Hash(), "from", from, "nonce", tx.Nonce(), "bump", bump)
+	return signed.Hash(), nil
+}
+
 // AdminAPI is the collection of Ethereum full node related APIs for node
 // administration.
 type AdminAPI struct {
@@ -239,6 +809,189 @@ func (api *AdminAPI) ImportChain(file string) (bool, error) {
 	return true, nil
 }
 
+// SetServeLimits replaces the per-peer and global bandwidth and CPU budgets of
+// serving snap sync requests, taking effect immediately.
+func (api *AdminAPI) SetServeLimits(limits snap.ServeLimits) (bool, error) {
+	if err := snap.SetServeLimits(limits); err != nil {
+		return false, err
+	}
+	log.Info("Updated snap serve limits", "peerBandwidth", limits.PeerBandwidth, "globalBandwidth", limits.GlobalBandwidth,
+		"peerCPU", limits.PeerCPU, "globalCPU", limits.GlobalCPU)
+	return true, nil
+}
+
+// ServeLimits returns the budgets of serving snap sync requests.
+func (api *AdminAPI) ServeLimits() snap.ServeLimits {
+	return snap.GetServeLimits()
+}
+
+// AddTrustedPropagationPeer marks a peer, such as another pool node or a relay
+// network, to be pushed newly mined blocks in full ahead of general gossip. The
+// peer is also trusted by the p2p server and connected to.
+func (api *AdminAPI) AddTrustedPropagationPeer(url string) (bool, error) {
+	node, err := enode.Parse(enode.ValidSchemes, url)
+	if err != nil {
+		return false, fmt.Errorf("invalid enode: %v", err)
+	}
+	api.eth.handler.peers.addPropagationPeer(node.ID().String())
+	api.eth.p2pServer.AddTrustedPeer(node)
+	api.eth.p2pServer.AddPeer(node)
+	return true, nil
+}
+
+// RemoveTrustedPropagationPeer demotes a peer to receiving blocks via general
+// gossip. It is neither untrusted nor disconnected by the p2p server.
+func (api *AdminAPI) RemoveTrustedPropagationPeer(url string) (bool, error) {
+	node, err := enode.Parse(enode.ValidSchemes, url)
+	if err != nil {
+		return false, fmt.Errorf("invalid enode: %v", err)
+	}
+	api.eth.handler.peers.removePropagationPeer(node.ID().String())
+	return true, nil
+}
+
+// TrustedPropagationPeers returns the ids of the peers pushed newly mined blocks
+// ahead of general gossip.
+func (api *AdminAPI) TrustedPropagationPeers() []string {
+	return api.eth.handler.peers.propagationPeers()
+}
+
+// PruneState starts an online state pruning round in the background, deleting
+// the trie nodes of stale states while the node keeps running. The progress is
+// reported by PruneStatus.
+func (api *AdminAPI) PruneState() (bool, error) {
+	if api.eth.pruner == nil {
+		return false, errors.New("state pruning is disabled in archive mode")
+	}
+	if !api.eth.Synced() {
+		return false, errors.New("state pruning is unavailable while syncing")
+	}
+	if err := api.eth.pruner.Prune(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// PruneStatus returns the progress of the running or last online state pruning
+// round.
+func (api *AdminAPI) PruneStatus() (pruner.OnlinePruneStatus, error) {
+	if api.eth.pruner == nil {
+		return pruner.OnlinePruneStatus{}, errors.New("state pruning is disabled in archive mode")
+	}
+	return api.eth.pruner.Status(), nil
+}
+
+// SetVerifyConcurrency sets the number of workers verifying batches of headers
+// during sync and the number of consecutive headers handed to a worker at once.
+// Zero values auto-tune the setting to the cores left by sealing.
+func (api *AdminAPI) SetVerifyConcurrency(workers int, batchSize int) (ethash.VerifyConcurrency, error) {
+	engine := api.eth.ethashEngine()
+	if engine == nil {
+		return ethash.VerifyConcurrency{}, errors.New("header verification concurrency requires ethash")
+	}
+	if err := engine.SetVerifyConcurrency(workers, batchSize); err != nil {
+		return ethash.VerifyConcurrency{}, err
+	}
+	log.Info("Updated header verification concurrency", "workers", workers, "batch", batchSize)
+	return engine.VerifyConcurrency(), nil
+}
+
+// VerifyConcurrency returns the configured and current effective concurrency of
+// header verification.
+func (api *AdminAPI) VerifyConcurrency() (ethash.VerifyConcurrency, error) {
+	engine := api.eth.ethashEngine()
+	if engine == nil {
+		return ethash.VerifyConcurrency{}, errors.New("header verification concurrency requires ethash")
+	}
+	return engine.VerifyConcurrency(), nil
+}
+
+// ForkChoiceAPI gives the operator emergency control over the fork choice, to
+// recover from attacks or consensus bugs. Its methods are in the admin namespace
+// and only served over IPC, never over HTTP or WebSocket.
+type ForkChoiceAPI struct {
+	eth *Ethereum
+}
+
+// NewForkChoiceAPI creates a new ForkChoiceAPI instance.
+func NewForkChoiceAPI(eth *Ethereum) *ForkChoiceAPI {
+	return &ForkChoiceAPI{eth: eth}
+}
+
+// SetHead reorgs the chain onto the locally known block with the given hash,
+// regardless of its total difficulty and of the soft finalized block. Branches
+// containing banned blocks are refused.
+//
+// The fork choice rule is not overridden beyond this reorg: on proof-of-work,
+// the next imported block of a branch with a higher total difficulty reorgs
+// back onto it. Ban the first block of the competing branch to keep the head.
+func (api *ForkChoiceAPI) SetHead(hash common.Hash) (bool, error) {
+	block := api.eth.blockchain.GetBlockByHash(hash)
+	if block == nil {
+		return false, fmt.Errorf("block %#x not found", hash)
+	}
+	if _, err := api.eth.blockchain.SetCanonical(block); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// BanBlockHash rejects the block with the given hash and every block building
+// on it, across restarts too. A canonical banned block rewinds the chain to its
+// parent, call SetHead first to keep an alternative branch.
+func (api *ForkChoiceAPI) BanBlockHash(hash common.Hash) (bool, error) {
+	if err := api.eth.blockchain.BanBlockHash(hash); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// UnbanBlockHash lifts the ban of a block hash.
+func (api *ForkChoiceAPI) UnbanBlockHash(hash common.Hash) bool {
+	api.eth.blockchain.UnbanBlockHash(hash)
+	return true
+}
+
+// BannedBlockHashes returns the block hashes banned by the operator.
+func (api *ForkChoiceAPI) BannedBlockHashes() []common.Hash {
+	return api.eth.blockchain.BannedHashes()
+}
+
+// SetFinalityDepth changes the number of blocks below the head considered final,
+// zero disabling soft finality. Raising the depth never moves the finalized
+// block back.
+func (api *ForkChoiceAPI) SetFinalityDepth(depth hexutil.Uint64) (bool, error) {
+	if err := api.eth.blockchain.SetFinalityDepth(uint64(depth)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// FinalityDepth returns the number of blocks below the head considered final,
+// zero if soft finality is disabled.
+func (api *ForkChoiceAPI) FinalityDepth() hexutil.Uint64 {
+	return hexutil.Uint64(api.eth.blockchain.FinalityDepth())
+}
+
+// ChainConfigAPI lets the operator schedule forks at runtime, from a fork
+// schedule signed by a trusted key. Its methods are in the admin namespace and
+// only served over IPC, never over HTTP or WebSocket.
+type ChainConfigAPI struct {
+	eth *Ethereum
+}
+
+// NewChainConfigAPI creates a new ChainConfigAPI instance.
+func NewChainConfigAPI(eth *Ethereum) *ChainConfigAPI {
+	return &ChainConfigAPI{eth: eth}
+}
+
+// ReloadChainConfig applies the signed fork schedule of the configured file,
+// returning the updated chain config. The schedule has to be made for the local
+// genesis and may not change forks already active.
+func (api *ChainConfigAPI) ReloadChainConfig() (*params.ChainConfig, error) {
+	return api.eth.ReloadForkSchedule()
+}
+
 // DebugAPI is the collection of Ethereum full node APIs for debugging the
 // protocol.
 type DebugAPI struct {
@@ -608,3 +1361,58 @@ func (api *DebugAPI) SetTrieFlushInterval(interval string) error {
 	api.eth.blockchain.SetTrieFlushInterval(t)
 	return nil
 }
+
+// GetReorgHistory returns up to the last n reorgs of the canonical chain since
+// the node started, the most recent first.
+func (api *DebugAPI) GetReorgHistory(n int) []*ReorgInfo {
+	history := api.eth.blockchain.ReorgHistory(n)
+
+	infos := make([]*ReorgInfo, 0, len(history))
+	for _, event := range history {
+		infos = append(infos, newReorgInfo(event))
+	}
+	return infos
+}
+
+// TemplateRebuildResult is the reply to debug_rebuildBlockTemplate.
+type TemplateRebuildResult struct {
+	ParentHash           common.Hash    `json:"parentHash"`
+	Number               hexutil.Uint64 `json:"number"`
+	Timestamp            hexutil.Uint64 `json:"timestamp"`
+	Coinbase             common.Address `json:"coinbase"`
+	GasUsed              hexutil.Uint64 `json:"gasUsed"`
+	Source               string         `json:"source"`
+	Interrupted          bool           `json:"interrupted"`
+	Transactions         []common.Hash  `json:"transactions"`
+	Profit               *hexutil.Big   `json:"profit"`
+	ReportedTransactions []common.Hash  `json:"reportedTransactions"`
+	ReportedProfit       *hexutil.Big   `json:"reportedProfit"`
+	Matches              bool           `json:"matches"`
+}
+
+// RebuildBlockTemplate deterministically replays the miner's construction of the
+// block template on top of a parent at a timestamp from a set of candidate
+// transactions, identified by the txSetHash of eth_getPendingBlock. The rebuilt
+// transaction order and coinbase profit are returned along with the reported
+// ones, for the profit figure served with the work to be audited. Only recent
+// constructions are journaled.
+func (api *DebugAPI) RebuildBlockTemplate(parentHash common.Hash, timestamp hexutil.Uint64, txSetHash common.Hash) (*TemplateRebuildResult, error) {
+	rebuild, err := api.eth.Miner().RebuildBlockTemplate(parentHash, uint64(timestamp), txSetHash)
+	if err != nil {
+		return nil, err
+	}
+	return &TemplateRebuildResult{
+		ParentHash:           rebuild.Header.ParentHash,
+		Number:               hexutil.Uint64(rebuild.Header.Number.Uint64()),
+		Timestamp:            hexutil.Uint64(rebuild.Header.Time),
+		Coinbase:             rebuild.Coinbase,
+		GasUsed:              hexutil.Uint64(rebuild.Header.GasUsed),
+		Source:               rebuild.Source,
+		Interrupted:          rebuild.Interrupted,
+		Transactions:         rebuild.Transactions,
+		Profit:               (*hexutil.Big)(rebuild.Profit),
+		ReportedTransactions: rebuild.ReportedTransactions,
+		ReportedProfit:       (*hexutil.Big)(rebuild.ReportedProfit),
+		Matches:              rebuild.Matches(),
+	}, nil
+}
@@ -60,6 +60,13 @@ func (b *EthAPIBackend) SetHead(number uint64) {
 	b.eth.blockchain.SetHead(number)
 }
 
+// finalityEnabled reports whether the safe and finalized blocks are known,
+// either set by the consensus layer after the merge or derived from the head by
+// the soft finality depth.
+func (b *EthAPIBackend) finalityEnabled() bool {
+	return b.eth.Merger().TDDReached() || b.eth.blockchain.FinalityDepth() > 0
+}
+
 func (b *EthAPIBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
 	// Pending block is only known by the miner
 	if number == rpc.PendingBlockNumber {
@@ -71,8 +78,8 @@ func (b *EthAPIBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumb
 		return b.eth.blockchain.CurrentBlock(), nil
 	}
 	if number == rpc.FinalizedBlockNumber {
-		if !b.eth.Merger().TDDReached() {
-			return nil, errors.New("'finalized' tag not supported on pre-merge network")
+		if !b.finalityEnabled() {
+			return nil, errors.New("'finalized' tag not supported on pre-merge network without soft finality")
 		}
 		block := b.eth.blockchain.CurrentFinalBlock()
 		if block != nil {
@@ -81,8 +88,8 @@ func (b *EthAPIBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumb
 		return nil, errors.New("finalized block not found")
 	}
 	if number == rpc.SafeBlockNumber {
-		if !b.eth.Merger().TDDReached() {
-			return nil, errors.New("'safe' tag not supported on pre-merge network")
+		if !b.finalityEnabled() {
+			return nil, errors.New("'safe' tag not supported on pre-merge network without soft finality")
 		}
 		block := b.eth.blockchain.CurrentSafeBlock()
 		if block != nil {
@@ -126,17 +133,23 @@ func (b *EthAPIBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumbe
 		return b.eth.blockchain.GetBlock(header.Hash(), header.Number.Uint64()), nil
 	}
 	if number == rpc.FinalizedBlockNumber {
-		if !b.eth.Merger().TDDReached() {
-			return nil, errors.New("'finalized' tag not supported on pre-merge network")
+		if !b.finalityEnabled() {
+			return nil, errors.New("'finalized' tag not supported on pre-merge network without soft finality")
 		}
 		header := b.eth.blockchain.CurrentFinalBlock()
+		if header == nil {
+			return nil, errors.New("finalized block not found")
+		}
 		return b.eth.blockchain.GetBlock(header.Hash(), header.Number.Uint64()), nil
 	}
 	if number == rpc.SafeBlockNumber {
-		if !b.eth.Merger().TDDReached() {
-			return nil, errors.New("'safe' tag not supported on pre-merge network")
+		if !b.finalityEnabled() {
+			return nil, errors.New("'safe' tag not supported on pre-merge network without soft finality")
 		}
 		header := b.eth.blockchain.CurrentSafeBlock()
+		if header == nil {
+			return nil, errors.New("safe block not found")
+		}
 		return b.eth.blockchain.GetBlock(header.Hash(), header.Number.Uint64()), nil
 	}
 	return b.eth.blockchain.GetBlockByNumber(uint64(number)), nil
@@ -311,6 +324,16 @@ func (b *EthAPIBackend) TxPool() *txpool.TxPool {
 	return b.eth.TxPool()
 }
 
+func (b *EthAPIBackend) TxPoolLocals() []common.Address {
+	return b.eth.TxPool().Locals()
+}
+
+// BroadcastTransactions propagates the transactions to the peers not known to
+// have them already.
+func (b *EthAPIBackend) BroadcastTransactions(txs types.Transactions) {
+	b.eth.handler.BroadcastTransactions(txs)
+}
+
 func (b *EthAPIBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subscription {
 	return b.eth.TxPool().SubscribeNewTxsEvent(ch)
 }
@@ -355,6 +378,19 @@ func (b *EthAPIBackend) RPCEVMTimeout() time.Duration {
 	return b.eth.config.RPCEVMTimeout
 }
 
+func (b *EthAPIBackend) RPCTracerLimits() *tracers.Limits {
+	config := b.eth.config
+	if config.RPCTracerCPUTime == 0 && config.RPCTracerMemory == 0 && config.RPCTracerOutput == 0 && len(config.RPCTracers) == 0 {
+		return nil
+	}
+	return &tracers.Limits{
+		CPUTime: config.RPCTracerCPUTime,
+		Memory:  config.RPCTracerMemory,
+		Output:  config.RPCTracerOutput,
+		Tracers: config.RPCTracers,
+	}
+}
+
 func (b *EthAPIBackend) RPCTxFeeCap() float64 {
 	return b.eth.config.RPCTxFeeCap
 }
@@ -0,0 +1,61 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package eth
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/miner"
+)
+
+// RentAPI provides an API to lease the sealing work of the node to external
+// coinbases, switching the coinbase of the block templates handed out to the
+// remote miners for the leased periods and accounting the work delivered.
+type RentAPI struct {
+	e *Ethereum
+}
+
+// NewRentAPI creates a new RentAPI instance.
+func NewRentAPI(e *Ethereum) *RentAPI {
+	return &RentAPI{e}
+}
+
+// CreateLease schedules a lease of the sealing work to the given coinbase for
+// the given number of seconds, starting as soon as the leases scheduled before
+// it ended. The amount due for the lease is the flat fee of the terms plus their
+// price for every gigahash of work delivered.
+func (api *RentAPI) CreateLease(coinbase common.Address, duration hexutil.Uint64, terms miner.LeaseTerms) (*miner.Lease, error) {
+	if duration > math.MaxInt64/hexutil.Uint64(time.Second) {
+		return nil, errors.New("lease duration too long")
+	}
+	return api.e.Miner().CreateLease(coinbase, time.Duration(duration)*time.Second, terms)
+}
+
+// CancelLease ends an active lease immediately, or drops a scheduled one.
+func (api *RentAPI) CancelLease(id hexutil.Uint64) (*miner.Lease, error) {
+	return api.e.Miner().CancelLease(uint64(id))
+}
+
+// GetLease retrieves a lease along with the work delivered under it.
+func (api *RentAPI) GetLease(id hexutil.Uint64) (*miner.Lease, error) {
+	return api.e.Miner().Lease(uint64(id))
+}
+
+// Leases retrieves the scheduled, active and recently finished leases.
+func (api *RentAPI) Leases() []*miner.Lease {
+	return api.e.Miner().Leases()
+}
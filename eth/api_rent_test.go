@@ -0,0 +1,34 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package eth
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/miner"
+)
+
+// Tests that lease durations overflowing a time.Duration are rejected instead
+// of wrapping around.
+func TestCreateLeaseDurationOverflow(t *testing.T) {
+	api := NewRentAPI(nil)
+	for _, duration := range []hexutil.Uint64{math.MaxInt64/hexutil.Uint64(time.Second) + 1, math.MaxUint64} {
+		if _, err := api.CreateLease(common.Address{1}, duration, miner.LeaseTerms{}); err == nil {
+			t.Errorf("duration %d: overflowing lease accepted", duration)
+		}
+	}
+}
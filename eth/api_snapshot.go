@@ -0,0 +1,184 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package eth
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const (
+	// snapshotRangeMaxResults is the maximum number of entries returned by a
+	// snapshot range query.
+	snapshotRangeMaxResults = 1024
+
+	// snapshotRangeMaxScan is the maximum number of accounts a balance query
+	// iterates before returning, so that sparse matches don't stall the node.
+	snapshotRangeMaxScan = 100000
+)
+
+var errNoSnapshot = errors.New("state snapshot not available")
+
+// AccountBalance is an account of a snapshot balance query.
+type AccountBalance struct {
+	Hash    common.Hash     `json:"hash"`
+	Address *common.Address `json:"address"` // nil if the preimage is unknown
+	Balance *hexutil.Big    `json:"balance"`
+	Nonce   hexutil.Uint64  `json:"nonce"`
+}
+
+// AccountsPage is a page of accounts iterated from the state snapshot.
+type AccountsPage struct {
+	Number   hexutil.Uint64    `json:"number"`
+	Root     common.Hash       `json:"root"`
+	Accounts []*AccountBalance `json:"accounts"`
+	Next     *common.Hash      `json:"next"` // Cursor of the next page, nil if the iteration completed
+}
+
+// StorageSlot is a storage slot of a snapshot storage range query.
+type StorageSlot struct {
+	Hash  common.Hash  `json:"hash"`
+	Key   *common.Hash `json:"key"` // nil if the preimage is unknown
+	Value common.Hash  `json:"value"`
+}
+
+// StoragePage is a page of storage slots iterated from the state snapshot.
+type StoragePage struct {
+	Number  hexutil.Uint64 `json:"number"`
+	Root    common.Hash    `json:"root"`
+	Storage []*StorageSlot `json:"storage"`
+	Next    *common.Hash   `json:"next"` // Cursor of the next page, nil if the iteration completed
+}
+
+// GetAccountsByBalance iterates the accounts of the head state snapshot in hash
+// order from the given cursor, returning the ones holding at least the given
+// balance. A page may hold fewer accounts than requested if the iteration gave
+// up scanning, the next cursor is set as long as accounts are left.
+func (api *EthereumAPI) GetAccountsByBalance(min hexutil.Big, cursor *common.Hash, count *hexutil.Uint64) (*AccountsPage, error) {
+	snaps := api.e.BlockChain().Snapshots()
+	if snaps == nil {
+		return nil, errNoSnapshot
+	}
+	head := api.e.BlockChain().CurrentBlock()
+	page, err := accountsByBalance(snaps, api.e.ChainDb(), head.Root, (*big.Int)(&min), cursorOf(cursor), limitOf(count))
+	if err != nil {
+		return nil, err
+	}
+	page.Number = hexutil.Uint64(head.Number.Uint64())
+	return page, nil
+}
+
+// GetStorageRange iterates the storage slots of an account in the head state
+// snapshot in hash order from the given cursor.
+func (api *EthereumAPI) GetStorageRange(address common.Address, cursor *common.Hash, count *hexutil.Uint64) (*StoragePage, error) {
+	snaps := api.e.BlockChain().Snapshots()
+	if snaps == nil {
+		return nil, errNoSnapshot
+	}
+	head := api.e.BlockChain().CurrentBlock()
+	page, err := storageRange(snaps, api.e.ChainDb(), head.Root, crypto.Keccak256Hash(address.Bytes()), cursorOf(cursor), limitOf(count))
+	if err != nil {
+		return nil, err
+	}
+	page.Number = hexutil.Uint64(head.Number.Uint64())
+	return page, nil
+}
+
+func cursorOf(cursor *common.Hash) common.Hash {
+	if cursor == nil {
+		return common.Hash{}
+	}
+	return *cursor
+}
+
+func limitOf(count *hexutil.Uint64) int {
+	if count == nil || *count == 0 || *count > snapshotRangeMaxResults {
+		return snapshotRangeMaxResults
+	}
+	return int(*count)
+}
+
+// accountsByBalance collects the accounts of the snapshot with the given root
+// holding at least min, starting at the cursor.
+func accountsByBalance(snaps *snapshot.Tree, db ethdb.KeyValueReader, root common.Hash, min *big.Int, cursor common.Hash, limit int) (*AccountsPage, error) {
+	it, err := snaps.AccountIterator(root, cursor)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Release()
+
+	page := &AccountsPage{Root: root, Accounts: make([]*AccountBalance, 0)}
+	for scanned := 0; it.Next(); scanned++ {
+		if len(page.Accounts) == limit || scanned == snapshotRangeMaxScan {
+			next := it.Hash()
+			page.Next = &next
+			break
+		}
+		account, err := snapshot.FullAccount(it.Account())
+		if err != nil {
+			return nil, err
+		}
+		if account.Balance.Cmp(min) < 0 {
+			continue
+		}
+		entry := &AccountBalance{
+			Hash:    it.Hash(),
+			Balance: (*hexutil.Big)(account.Balance),
+			Nonce:   hexutil.Uint64(account.Nonce),
+		}
+		if preimage := rawdb.ReadPreimage(db, it.Hash()); len(preimage) == common.AddressLength {
+			addr := common.BytesToAddress(preimage)
+			entry.Address = &addr
+		}
+		page.Accounts = append(page.Accounts, entry)
+	}
+	return page, it.Error()
+}
+
+// storageRange collects the storage slots of an account in the snapshot with
+// the given root, starting at the cursor.
+func storageRange(snaps *snapshot.Tree, db ethdb.KeyValueReader, root common.Hash, account common.Hash, cursor common.Hash, limit int) (*StoragePage, error) {
+	it, err := snaps.StorageIterator(root, account, cursor)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Release()
+
+	page := &StoragePage{Root: root, Storage: make([]*StorageSlot, 0)}
+	for it.Next() {
+		if len(page.Storage) == limit {
+			next := it.Hash()
+			page.Next = &next
+			break
+		}
+		_, content, _, err := rlp.Split(it.Slot())
+		if err != nil {
+			return nil, err
+		}
+		slot := &StorageSlot{Hash: it.Hash(), Value: common.BytesToHash(content)}
+		if preimage := rawdb.ReadPreimage(db, it.Hash()); len(preimage) == common.HashLength {
+			key := common.BytesToHash(preimage)
+			slot.Key = &key
+		}
+		page.Storage = append(page.Storage, slot)
+	}
+	return page, it.Error()
+}
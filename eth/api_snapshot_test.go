@@ -0,0 +1,121 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package eth
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestSnapshotRanges(t *testing.T) {
+	var (
+		db       = rawdb.NewMemoryDatabase()
+		contract = common.HexToAddress("0xc0de")
+		alloc    = core.GenesisAlloc{
+			contract: {Balance: big.NewInt(1), Code: []byte{0x00}, Storage: make(map[common.Hash]common.Hash)},
+		}
+	)
+	for i := 1; i <= 10; i++ {
+		alloc[common.BigToAddress(big.NewInt(int64(i)))] = core.GenesisAccount{Balance: new(big.Int).Mul(big.NewInt(int64(i)), big.NewInt(params.Ether))}
+		alloc[contract].Storage[common.BigToHash(big.NewInt(int64(i)))] = common.BigToHash(big.NewInt(int64(i * 100)))
+	}
+	genesis := &core.Genesis{Config: params.TestChainConfig, Alloc: alloc}
+	chain, err := core.NewBlockChain(db, nil, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	var (
+		snaps = chain.Snapshots()
+		root  = chain.CurrentBlock().Root
+		min   = big.NewInt(5 * params.Ether)
+	)
+	// Page through the rich accounts two at a time
+	var (
+		rich   = make(map[common.Hash]*big.Int)
+		cursor common.Hash
+	)
+	for pages := 0; ; pages++ {
+		if pages > 10 {
+			t.Fatalf("balance query didn't terminate")
+		}
+		page, err := accountsByBalance(snaps, db, root, min, cursor, 2)
+		if err != nil {
+			t.Fatalf("balance query failed: %v", err)
+		}
+		if len(page.Accounts) > 2 {
+			t.Fatalf("page overflow: have %d accounts, want at most 2", len(page.Accounts))
+		}
+		for _, account := range page.Accounts {
+			rich[account.Hash] = account.Balance.ToInt()
+		}
+		if page.Next == nil {
+			break
+		}
+		cursor = *page.Next
+	}
+	if len(rich) != 6 {
+		t.Fatalf("rich account count mismatch: have %d, want 6", len(rich))
+	}
+	for i := 5; i <= 10; i++ {
+		hash := crypto.Keccak256Hash(common.BigToAddress(big.NewInt(int64(i))).Bytes())
+		if balance := rich[hash]; balance == nil || balance.Cmp(new(big.Int).Mul(big.NewInt(int64(i)), big.NewInt(params.Ether))) != 0 {
+			t.Errorf("account %d balance mismatch: have %v", i, balance)
+		}
+	}
+	// Iterate the contract storage in pages
+	var (
+		slots   = make(map[common.Hash]common.Hash)
+		account = crypto.Keccak256Hash(contract.Bytes())
+	)
+	cursor = common.Hash{}
+	for pages := 0; ; pages++ {
+		if pages > 10 {
+			t.Fatalf("storage query didn't terminate")
+		}
+		page, err := storageRange(snaps, db, root, account, cursor, 3)
+		if err != nil {
+			t.Fatalf("storage query failed: %v", err)
+		}
+		for _, slot := range page.Storage {
+			slots[slot.Hash] = slot.Value
+		}
+		if page.Next == nil {
+			break
+		}
+		cursor = *page.Next
+	}
+	if len(slots) != 10 {
+		t.Fatalf("slot count mismatch: have %d, want 10", len(slots))
+	}
+	for i := 1; i <= 10; i++ {
+		hash := crypto.Keccak256Hash(common.BigToHash(big.NewInt(int64(i))).Bytes())
+		if have, want := slots[hash], common.BigToHash(big.NewInt(int64(i*100))); have != want {
+			t.Errorf("slot %d mismatch: have %x, want %x", i, have, want)
+		}
+	}
+	// Accounts without storage yield empty pages
+	page, err := storageRange(snaps, db, root, crypto.Keccak256Hash(common.BigToAddress(big.NewInt(1)).Bytes()), common.Hash{}, 3)
+	if err != nil || len(page.Storage) != 0 || page.Next != nil {
+		t.Errorf("storage of plain account mismatch: have %+v, %v", page, err)
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/trie"
 )
@@ -219,3 +220,22 @@ func TestStorageRangeAt(t *testing.T) {
 		}
 	}
 }
+
+// Tests that the network hashrate is estimated from the difficulties of all but
+// the oldest header over the time span covered.
+func TestEstimateHashrate(t *testing.T) {
+	headers := []*types.Header{
+		{Time: 130, Difficulty: big.NewInt(3000)},
+		{Time: 120, Difficulty: big.NewInt(2000)},
+		{Time: 100, Difficulty: big.NewInt(1000)},
+	}
+	if have := estimateHashrate(headers); have == nil || have.Cmp(big.NewInt(5000/30)) != 0 {
+		t.Errorf("hashrate mismatch: have %v, want %v", have, 5000/30)
+	}
+	if have := estimateHashrate(headers[:1]); have != nil {
+		t.Errorf("single header estimated: %v", have)
+	}
+	if have := estimateHashrate([]*types.Header{{Time: 100, Difficulty: big.NewInt(1)}, {Time: 100}}); have != nil {
+		t.Errorf("zero time span estimated: %v", have)
+	}
+}
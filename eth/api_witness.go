@@ -0,0 +1,145 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package eth
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// witnessReexec is the number of blocks re-executed to regenerate the parent
+// state of a block missing from the database.
+const witnessReexec = 128
+
+// BlockWitness is the pre-state accessed by the execution of a block, proven
+// against the state root of its parent.
+type BlockWitness struct {
+	Number    hexutil.Uint64    `json:"number"`
+	Hash      common.Hash       `json:"hash"`
+	StateRoot common.Hash       `json:"stateRoot"` // Root of the pre-state the proofs are against
+	Accounts  []*AccountWitness `json:"accounts"`
+	Codes     []hexutil.Bytes   `json:"codes"`
+}
+
+// AccountWitness is an account accessed by a block with the Merkle proofs of
+// the account and of its accessed storage slots.
+type AccountWitness struct {
+	Address      common.Address    `json:"address"`
+	AccountProof []hexutil.Bytes   `json:"accountProof"`
+	Storage      []*StorageWitness `json:"storage"`
+}
+
+// StorageWitness is a storage slot accessed by a block with its Merkle proof.
+type StorageWitness struct {
+	Key   common.Hash     `json:"key"`
+	Proof []hexutil.Bytes `json:"proof"`
+}
+
+// GetBlockWitness re-executes a block on the state of its parent and returns the
+// execution witness: the accounts, storage slots and codes accessed, along with
+// the proofs of them against the parent state root.
+func (api *EthereumAPI) GetBlockWitness(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*BlockWitness, error) {
+	block, err := api.e.APIBackend.BlockByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, errors.New("block not found")
+	}
+	if block.NumberU64() == 0 {
+		return nil, errors.New("genesis is not executed")
+	}
+	parent := api.e.blockchain.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, fmt.Errorf("parent %#x not found", block.ParentHash())
+	}
+	statedb, release, err := api.e.StateAtBlock(ctx, parent, witnessReexec, nil, true, false)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return blockWitness(api.e.blockchain.Processor(), block, statedb)
+}
+
+// blockWitness processes a block on top of its parent state, recording the
+// pre-state accessed and proving it against the parent state.
+func blockWitness(processor core.Processor, block *types.Block, statedb *state.StateDB) (*BlockWitness, error) {
+	prestate := statedb.Copy()
+	witness := statedb.StartWitness()
+	if _, _, _, err := processor.Process(block, statedb, vm.Config{}); err != nil {
+		return nil, fmt.Errorf("block processing failed: %w", err)
+	}
+	result := &BlockWitness{
+		Number:    hexutil.Uint64(block.NumberU64()),
+		Hash:      block.Hash(),
+		StateRoot: prestate.IntermediateRoot(false),
+		Accounts:  make([]*AccountWitness, 0, len(witness.Accounts)),
+		Codes:     make([]hexutil.Bytes, 0, len(witness.Codes)),
+	}
+	for addr, slots := range witness.Accounts {
+		proof, err := prestate.GetProof(addr)
+		if err != nil {
+			return nil, err
+		}
+		account := &AccountWitness{
+			Address:      addr,
+			AccountProof: toHexSlice(proof),
+			Storage:      make([]*StorageWitness, 0, len(slots)),
+		}
+		for key := range slots {
+			proof, err := prestate.GetStorageProof(addr, key)
+			if err != nil {
+				return nil, err
+			}
+			account.Storage = append(account.Storage, &StorageWitness{Key: key, Proof: toHexSlice(proof)})
+		}
+		sort.Slice(account.Storage, func(i, j int) bool {
+			return bytes.Compare(account.Storage[i].Key[:], account.Storage[j].Key[:]) < 0
+		})
+		result.Accounts = append(result.Accounts, account)
+	}
+	sort.Slice(result.Accounts, func(i, j int) bool {
+		return bytes.Compare(result.Accounts[i].Address[:], result.Accounts[j].Address[:]) < 0
+	})
+	for hash := range witness.Codes {
+		code, err := prestate.Database().ContractCode(common.Hash{}, hash)
+		if err != nil {
+			return nil, err
+		}
+		result.Codes = append(result.Codes, code)
+	}
+	sort.Slice(result.Codes, func(i, j int) bool {
+		return bytes.Compare(result.Codes[i], result.Codes[j]) < 0
+	})
+	return result, nil
+}
+
+func toHexSlice(b [][]byte) []hexutil.Bytes {
+	r := make([]hexutil.Bytes, len(b))
+	for i := range b {
+		r[i] = b[i]
+	}
+	return r
+}
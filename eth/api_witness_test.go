@@ -0,0 +1,122 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package eth
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+func TestBlockWitness(t *testing.T) {
+	var (
+		key, _   = crypto.GenerateKey()
+		sender   = crypto.PubkeyToAddress(key.PublicKey)
+		contract = common.HexToAddress("0xc0de")
+		coinbase = common.HexToAddress("0xc0ffee")
+		code     = common.Hex2Bytes("6001546000" + "5500") // SSTORE(0, SLOAD(1))
+		genesis  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: core.GenesisAlloc{
+				sender:   {Balance: big.NewInt(params.Ether)},
+				contract: {Balance: new(big.Int), Code: code, Storage: map[common.Hash]common.Hash{common.HexToHash("0x01"): common.HexToHash("0x02")}},
+			},
+		}
+		signer = types.LatestSigner(genesis.Config)
+	)
+	engine := ethash.NewFaker()
+	_, blocks, _ := core.GenerateChainWithGenesis(genesis, engine, 1, func(i int, b *core.BlockGen) {
+		b.SetCoinbase(coinbase)
+		tx, _ := types.SignNewTx(key, signer, &types.LegacyTx{To: &contract, Gas: 100000, GasPrice: b.BaseFee()})
+		b.AddTx(tx)
+	})
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	parent := chain.Genesis()
+	statedb, err := chain.StateAt(parent.Root())
+	if err != nil {
+		t.Fatalf("failed to open parent state: %v", err)
+	}
+	witness, err := blockWitness(chain.Processor(), blocks[0], statedb)
+	if err != nil {
+		t.Fatalf("failed to generate witness: %v", err)
+	}
+	if witness.StateRoot != parent.Root() {
+		t.Fatalf("pre-state root mismatch: have %x, want %x", witness.StateRoot, parent.Root())
+	}
+	accounts := make(map[common.Address]*AccountWitness)
+	for _, account := range witness.Accounts {
+		accounts[account.Address] = account
+	}
+	for _, addr := range []common.Address{sender, contract, coinbase} {
+		if accounts[addr] == nil {
+			t.Errorf("account %x missing from witness", addr)
+		}
+	}
+	if len(witness.Codes) != 1 || common.Bytes2Hex(witness.Codes[0]) != common.Bytes2Hex(code) {
+		t.Errorf("codes mismatch: have %x", witness.Codes)
+	}
+	// The proofs have to verify against the parent state
+	for _, account := range witness.Accounts {
+		proof := memorydb.New()
+		for _, node := range account.AccountProof {
+			proof.Put(crypto.Keccak256(node), node)
+		}
+		value, err := trie.VerifyProof(witness.StateRoot, crypto.Keccak256(account.Address.Bytes()), proof)
+		if err != nil {
+			t.Errorf("account %x: invalid proof: %v", account.Address, err)
+		}
+		if exists := value != nil; exists != (account.Address != coinbase) {
+			t.Errorf("account %x: existence mismatch: have %v", account.Address, exists)
+		}
+	}
+	slots := accounts[contract].Storage
+	if len(slots) != 2 || slots[0].Key != common.HexToHash("0x00") || slots[1].Key != common.HexToHash("0x01") {
+		t.Fatalf("contract slots mismatch: have %+v", slots)
+	}
+	prestate, _ := chain.StateAt(parent.Root())
+	storage, err := prestate.StorageTrie(contract)
+	if err != nil {
+		t.Fatalf("failed to open contract storage: %v", err)
+	}
+	storageRoot := storage.Hash()
+	for _, slot := range slots {
+		proof := memorydb.New()
+		for _, node := range slot.Proof {
+			proof.Put(crypto.Keccak256(node), node)
+		}
+		value, err := trie.VerifyProof(storageRoot, crypto.Keccak256(slot.Key.Bytes()), proof)
+		if err != nil {
+			t.Errorf("slot %x: invalid proof: %v", slot.Key, err)
+		}
+		if exists := value != nil; exists != (slot.Key == common.HexToHash("0x01")) {
+			t.Errorf("slot %x: existence mismatch: have %v", slot.Key, exists)
+		}
+	}
+}
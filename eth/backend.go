@@ -17,9 +17,11 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"os"
 	"runtime"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
@@ -27,6 +29,8 @@ import (
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/beacon"
 	"github.com/ethereum/go-ethereum/consensus/clique"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/consensus/misc"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/bloombits"
 	"github.com/ethereum/go-ethereum/core/rawdb"
@@ -34,12 +38,16 @@ import (
 	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/blocknotify"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/eth/fastannounce"
 	"github.com/ethereum/go-ethereum/eth/gasprice"
+	"github.com/ethereum/go-ethereum/eth/protocols/compact"
 	"github.com/ethereum/go-ethereum/eth/protocols/eth"
 	"github.com/ethereum/go-ethereum/eth/protocols/snap"
 	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/remotekv"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/internal/shutdowncheck"
@@ -81,6 +89,11 @@ type Ethereum struct {
 	bloomIndexer      *core.ChainIndexer             // Bloom indexer operating during block imports
 	closeBloomHandler chan struct{}
 
+	pruner         *pruner.OnlinePruner // Online state pruner, nil in archive mode
+	closePruneLoop chan struct{}
+
+	replica *replicaFollower // Follower of the node owning a read-only datadir, nil otherwise
+
 	APIBackend *EthAPIBackend
 
 	miner     *miner.Miner
@@ -101,7 +114,7 @@ type Ethereum struct {
 // initialisation of the common Ethereum object)
 func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	// Ensure configuration values are compatible and sane
-	if config.SyncMode == downloader.LightSync {
+	if config.SyncMode.IsLight() {
 		return nil, errors.New("can't run eth.Ethereum in light sync mode, use les.LightEthereum")
 	}
 	if !config.SyncMode.IsValid() {
@@ -127,12 +140,21 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	if err != nil {
 		return nil, err
 	}
-	if err := pruner.RecoverPruning(stack.ResolvePath(""), chainDb, stack.ResolvePath(config.TrieCleanCacheJournal)); err != nil {
+	// A read-only datadir or remote database is maintained by the node owning
+	// it, leave its state journals and the recovery of interrupted prunings to
+	// that node
+	readonly := stack.Config().DataDirReadOnly || stack.Config().DBEngine == "remote"
+	if readonly {
+		config.TrieCleanCacheJournal, config.TrieCleanCacheRejournal = "", 0
+		config.TxPool.Journal, config.TxPool.PersistJournal = "", ""
+	} else if err := pruner.RecoverPruning(stack.ResolvePath(""), chainDb, stack.ResolvePath(config.TrieCleanCacheJournal)); err != nil {
 		log.Error("Failed to recover state", "error", err)
 	}
 	// Transfer mining-related config to the ethash config.
 	ethashConfig := config.Ethash
 	ethashConfig.NotifyFull = config.Miner.NotifyFull
+	ethashConfig.NotifyStructured = config.Miner.NotifyStructured
+	ethashConfig.NotifySecret = config.Miner.NotifySecret
 	cliqueConfig, err := core.LoadCliqueConfig(chainDb, config.Genesis)
 	if err != nil {
 		return nil, err
@@ -147,6 +169,7 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		accountManager:    stack.AccountManager(),
 		engine:            engine,
 		closeBloomHandler: make(chan struct{}),
+		closePruneLoop:    make(chan struct{}),
 		networkID:         config.NetworkId,
 		gasPrice:          config.Miner.GasPrice,
 		etherbase:         config.Miner.Etherbase,
@@ -173,17 +196,27 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 			rawdb.WriteDatabaseVersion(chainDb, core.BlockChainVersion)
 		}
 	}
+	var (
+		trieJournal string
+		txLookup    *uint64
+	)
+	if !readonly {
+		trieJournal = stack.ResolvePath(config.TrieCleanCacheJournal)
+		txLookup = &config.TxLookupLimit
+	}
 	var (
 		vmConfig = vm.Config{
 			EnablePreimageRecording: config.EnablePreimageRecording,
 		}
 		cacheConfig = &core.CacheConfig{
 			TrieCleanLimit:      config.TrieCleanCache,
-			TrieCleanJournal:    stack.ResolvePath(config.TrieCleanCacheJournal),
+			TrieCleanJournal:    trieJournal,
 			TrieCleanRejournal:  config.TrieCleanCacheRejournal,
 			TrieCleanNoPrefetch: config.NoPrefetch,
 			TrieDirtyLimit:      config.TrieDirtyCache,
 			TrieDirtyDisabled:   config.NoPruning,
+			StateHistory:        config.StateHistory,
+			FinalityDepth:       config.FinalityDepth,
 			TrieTimeLimit:       config.TrieTimeout,
 			SnapshotLimit:       config.SnapshotCache,
 			Preimages:           config.Preimages,
@@ -194,16 +227,49 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	if config.OverrideShanghai != nil {
 		overrides.OverrideShanghai = config.OverrideShanghai
 	}
-	eth.blockchain, err = core.NewBlockChain(chainDb, cacheConfig, config.Genesis, &overrides, eth.engine, vmConfig, eth.shouldPreserve, &config.TxLookupLimit)
+	eth.blockchain, err = core.NewBlockChain(chainDb, cacheConfig, config.Genesis, &overrides, eth.engine, vmConfig, eth.shouldPreserve, txLookup)
 	if err != nil {
 		return nil, err
 	}
+	if config.ForkScheduleFile != "" {
+		config.ForkScheduleFile = stack.ResolvePath(config.ForkScheduleFile)
+		if _, err := eth.ReloadForkSchedule(); err != nil {
+			log.Warn("Failed to apply fork schedule", "file", config.ForkScheduleFile, "err", err)
+		}
+	}
 	eth.bloomIndexer.Start(eth.blockchain)
 
+	if readonly {
+		eth.replica = newReplicaFollower(config.ReplicaOf, chainDb, eth.blockchain)
+	} else if !config.NoPruning {
+		eth.pruner = pruner.NewOnlinePruner(chainDb, eth.blockchain, config.OnlinePruneBloomSize)
+	}
+
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = stack.ResolvePath(config.TxPool.Journal)
 	}
+	if config.TxPool.PersistJournal != "" {
+		config.TxPool.PersistJournal = stack.ResolvePath(config.TxPool.PersistJournal)
+	}
+	if config.TxPool.SenderLists != "" {
+		config.TxPool.SenderLists = stack.ResolvePath(config.TxPool.SenderLists)
+	}
 	eth.txPool = txpool.NewTxPool(config.TxPool, eth.blockchain.Config(), eth.blockchain)
+	if config.TxPool.Policy != "" {
+		policy, err := txpool.LoadPolicy(stack.ResolvePath(config.TxPool.Policy))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load txpool policy: %w", err)
+		}
+		eth.txPool.SetPolicy(policy)
+	}
+
+	// Let ethash forecast the next block's MEV from the transaction pool, report
+	// the miner's uncle candidates and watch the local clock against the chain
+	if engine := eth.ethashEngine(); engine != nil {
+		engine.SetMEVEstimator(eth.estimateNextBlockMEV)
+		engine.SetUncleSource(eth.pendingUncles)
+		engine.MonitorClockSkew(eth.blockchain)
+	}
 
 	// Permit the downloader to use the trie cache allowance during fast sync
 	cacheLimit := cacheConfig.TrieCleanLimit + cacheConfig.TrieDirtyLimit + cacheConfig.SnapshotLimit
@@ -222,12 +288,32 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		EventMux:       eth.eventMux,
 		Checkpoint:     checkpoint,
 		RequiredBlocks: config.RequiredBlocks,
+		SyncCheckpoint: config.SyncCheckpoint,
+		AutoPrune:      config.AutoPrune,
 	}); err != nil {
 		return nil, err
 	}
+	if config.FastAnnounce.Addr != "" {
+		self := enode.PubkeyToIDV4(&stack.Config().NodeKey().PublicKey)
+		announcer, err := fastannounce.New(config.FastAnnounce, self, (*ethHandler)(eth.handler).handleFastAnnouncement)
+		if err != nil {
+			return nil, err
+		}
+		eth.handler.announcer = announcer
+		stack.RegisterLifecycle(announcer)
+	}
+	if len(config.BlockNotify.URLs) > 0 {
+		notifier, err := blocknotify.New(config.BlockNotify, eth.blockchain, eth.eventMux)
+		if err != nil {
+			return nil, err
+		}
+		stack.RegisterLifecycle(notifier)
+	}
 
-	eth.miner = miner.New(eth, &config.Miner, eth.blockchain.Config(), eth.EventMux(), eth.engine, eth.isLocalBlock)
-	eth.miner.SetExtra(makeExtraData(config.Miner.ExtraData))
+	eth.miner = miner.New(eth, &config.Miner, eth.EventMux(), eth.engine, eth.isLocalBlock)
+	if err := eth.miner.SetExtra(makeExtraData(config.Miner.ExtraData)); err != nil {
+		log.Warn("Invalid miner extra data", "err", err)
+	}
 
 	eth.APIBackend = &EthAPIBackend{stack.Config().ExtRPCEnabled(), stack.Config().AllowUnprotectedTxs, eth, nil}
 	if eth.APIBackend.allowUnprotectedTxs {
@@ -274,13 +360,39 @@ func makeExtraData(extra []byte) []byte {
 			runtime.GOOS,
 		})
 	}
-	if uint64(len(extra)) > params.MaximumExtraDataSize {
+	if uint64(len(extra)) > params.MaximumExtraDataSize && !miner.IsExtraTemplate(extra) {
 		log.Warn("Miner extra data exceed limit", "extra", hexutil.Bytes(extra), "limit", params.MaximumExtraDataSize)
 		extra = nil
 	}
 	return extra
 }
 
+// ReloadForkSchedule applies the signed fork schedule of the configured file to
+// the chain config, returning the updated config. The transaction pool switches
+// to the signer and fork rules of the updated config right away.
+func (s *Ethereum) ReloadForkSchedule() (*params.ChainConfig, error) {
+	if s.config.ForkScheduleFile == "" {
+		return nil, errors.New("no fork schedule file configured")
+	}
+	blob, err := os.ReadFile(s.config.ForkScheduleFile)
+	if err != nil {
+		return nil, err
+	}
+	schedule, err := core.VerifyForkSchedule(blob, s.config.ForkScheduleSigners)
+	if err != nil {
+		return nil, err
+	}
+	config, err := s.blockchain.ApplyForkSchedule(schedule)
+	if err != nil {
+		return nil, err
+	}
+	// The pool isn't created yet when applying the schedule on startup
+	if s.txPool != nil {
+		s.txPool.SetChainConfig(config)
+	}
+	return config, nil
+}
+
 // APIs return the collection of RPC services the ethereum package offers.
 // NOTE, some of these services probably need to be moved to somewhere else.
 func (s *Ethereum) APIs() []rpc.API {
@@ -289,20 +401,34 @@ func (s *Ethereum) APIs() []rpc.API {
 	// Append any APIs exposed explicitly by the consensus engine
 	apis = append(apis, s.engine.APIs(s.BlockChain())...)
 
-	// Append all the local APIs and return
-	return append(apis, []rpc.API{
+	// Append all the local APIs
+	apis = append(apis, []rpc.API{
 		{
 			Namespace: "eth",
 			Service:   NewEthereumAPI(s),
 		}, {
 			Namespace: "miner",
 			Service:   NewMinerAPI(s),
+		}, {
+			Namespace: "rent",
+			Service:   NewRentAPI(s),
+		}, {
+			Namespace: "admin",
+			Service:   NewTxPoolAdminAPI(s),
 		}, {
 			Namespace: "eth",
 			Service:   downloader.NewDownloaderAPI(s.handler.downloader, s.eventMux),
 		}, {
 			Namespace: "admin",
 			Service:   NewAdminAPI(s),
+		}, {
+			Namespace:     "admin",
+			Service:       NewForkChoiceAPI(s),
+			Authenticated: true,
+		}, {
+			Namespace:     "admin",
+			Service:       NewChainConfigAPI(s),
+			Authenticated: true,
 		}, {
 			Namespace: "debug",
 			Service:   NewDebugAPI(s),
@@ -311,6 +437,14 @@ func (s *Ethereum) APIs() []rpc.API {
 			Service:   s.netRPCService,
 		},
 	}...)
+	// Share the chain database only if explicitly requested
+	if s.config.ShareDatabase {
+		apis = append(apis, rpc.API{
+			Namespace: remotekv.Namespace,
+			Service:   remotekv.NewService(s.chainDb),
+		})
+	}
+	return apis
 }
 
 func (s *Ethereum) ResetWithGenesisBlock(gb *types.Block) {
@@ -343,7 +477,7 @@ func (s *Ethereum) isLocalBlock(header *types.Header) bool {
 	s.lock.RLock()
 	etherbase := s.etherbase
 	s.lock.RUnlock()
-	if author == etherbase {
+	if author == etherbase || (s.miner != nil && s.miner.IsRotatedCoinbase(author)) {
 		return true
 	}
 	// Check whether the given address is specified by `txpool.local`
@@ -445,6 +579,63 @@ func (s *Ethereum) StartMining(threads int) error {
 	return nil
 }
 
+// ethashEngine returns the ethash engine backing the node, if any.
+func (s *Ethereum) ethashEngine() *ethash.Ethash {
+	engine := s.engine
+	if b, ok := engine.(*beacon.Beacon); ok {
+		engine = b.InnerEngine()
+	}
+	e, _ := engine.(*ethash.Ethash)
+	return e
+}
+
+// pendingUncles lists the side blocks the miner considers as uncles of the block
+// it is sealing.
+func (s *Ethereum) pendingUncles() (*types.Header, []ethash.PendingUncle, error) {
+	header, candidates, err := s.miner.UncleCandidates()
+	if err != nil {
+		return nil, nil, err
+	}
+	uncles := make([]ethash.PendingUncle, len(candidates))
+	for i, candidate := range candidates {
+		uncles[i] = ethash.PendingUncle{
+			Header:   candidate.Header,
+			Local:    candidate.Local,
+			Included: candidate.Included,
+			Err:      candidate.Err,
+		}
+	}
+	return header, uncles, nil
+}
+
+// estimateNextBlockMEV forecasts the priority fees the next block could collect
+// by filling it with the most profitable executable pool transactions.
+func (s *Ethereum) estimateNextBlockMEV() (*big.Int, error) {
+	var (
+		config  = s.blockchain.Config()
+		parent  = s.blockchain.CurrentBlock()
+		baseFee *big.Int
+	)
+	if config.IsLondon(new(big.Int).Add(parent.Number, common.Big1)) {
+		baseFee = misc.CalcBaseFee(config, parent)
+	}
+	var (
+		txs    = types.NewTransactionsByPriceAndNonce(types.LatestSigner(config), s.txPool.Pending(true), baseFee)
+		gas    = parent.GasLimit
+		profit = new(big.Int)
+	)
+	for tx := txs.Peek(); tx != nil; tx = txs.Peek() {
+		if tx.Gas() > gas {
+			txs.Pop()
+			continue
+		}
+		gas -= tx.Gas()
+		profit.Add(profit, new(big.Int).Mul(tx.EffectiveGasTipValue(baseFee), new(big.Int).SetUint64(tx.Gas())))
+		txs.Shift()
+	}
+	return profit, nil
+}
+
 // StopMining terminates the miner, both at the consensus engine level as well as
 // at the block creation level.
 func (s *Ethereum) StopMining() {
@@ -487,6 +678,7 @@ func (s *Ethereum) Protocols() []p2p.Protocol {
 	if s.config.SnapshotCache > 0 {
 		protos = append(protos, snap.MakeProtocols((*snapHandler)(s.handler), s.snapDialCandidates)...)
 	}
+	protos = append(protos, compact.MakeProtocols((*compactHandler)(s.handler))...)
 	return protos
 }
 
@@ -501,6 +693,15 @@ func (s *Ethereum) Start() error {
 	// Regularly update shutdown marker
 	s.shutdownTracker.Start()
 
+	// Follow the node owning the datadir if opened read-only
+	if s.replica != nil {
+		s.replica.start()
+	}
+	// Start the periodic online state pruning if requested
+	if s.pruner != nil && s.config.OnlinePruneInterval > 0 {
+		go s.pruneLoop(s.config.OnlinePruneInterval)
+	}
+
 	// Figure out a max peers count based on the server limits
 	maxPeers := s.p2pServer.MaxPeers
 	if s.config.LightServ > 0 {
@@ -514,6 +715,27 @@ func (s *Ethereum) Start() error {
 	return nil
 }
 
+// pruneLoop periodically starts an online state pruning round once the node is
+// synced, skipping the rounds requested while the previous one is running.
+func (s *Ethereum) pruneLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if !s.Synced() {
+				continue
+			}
+			if err := s.pruner.Prune(); err != nil {
+				log.Debug("Skipping online state pruning", "err", err)
+			}
+		case <-s.closePruneLoop:
+			return
+		}
+	}
+}
+
 // Stop implements node.Lifecycle, terminating all internal goroutines used by the
 // Ethereum protocol.
 func (s *Ethereum) Stop() error {
@@ -527,6 +749,13 @@ func (s *Ethereum) Stop() error {
 	close(s.closeBloomHandler)
 	s.txPool.Stop()
 	s.miner.Close()
+	close(s.closePruneLoop)
+	if s.pruner != nil {
+		s.pruner.Stop()
+	}
+	if s.replica != nil {
+		s.replica.stop()
+	}
 	s.blockchain.Stop()
 	s.engine.Close()
 
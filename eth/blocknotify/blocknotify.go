@@ -0,0 +1,377 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+// Package blocknotify implements webhooks notifying the operator of the blocks
+// sealed by the node, and of the sealed blocks reorged out of the chain later.
+package blocknotify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+const (
+	// trackDepth is the number of blocks a sealed block is watched for reorgs
+	// after which it's considered final.
+	trackDepth = 64
+
+	// queueSize is the number of notifications buffered for delivery, newer
+	// ones are dropped if the webhooks can't keep up.
+	queueSize = 64
+
+	// deliveryAttempts is the number of times a notification is posted to a
+	// webhook failing to accept it, backing off exponentially in between.
+	deliveryAttempts = 3
+
+	// deliveryTimeout is the timeout of a single notification request.
+	deliveryTimeout = 5 * time.Second
+
+	// SignatureHeader is the HTTP header carrying the hex encoded HMAC-SHA256 of
+	// the payload, keyed with the shared secret and prefixed with "sha256=".
+	SignatureHeader = "X-Bitnet-Signature"
+)
+
+// Notification events.
+const (
+	EventFound   = "blockFound"   // A block sealed by the node got imported
+	EventReorged = "blockReorged" // A block sealed by the node got reorged out
+)
+
+var (
+	notifySentMeter    = metrics.NewRegisteredMeter("eth/blocknotify/sent", nil)    // Notifications accepted by a webhook
+	notifyFailedMeter  = metrics.NewRegisteredMeter("eth/blocknotify/failed", nil)  // Notifications a webhook failed to accept
+	notifyDroppedMeter = metrics.NewRegisteredMeter("eth/blocknotify/dropped", nil) // Notifications dropped from a full queue
+)
+
+var errMissingSecret = errors.New("block notification webhooks require a secret")
+
+// Config are the settings of the block notification webhooks.
+type Config struct {
+	URLs   []string `toml:",omitempty"` // Webhooks to post the notifications to, empty to disable them
+	Secret string   `toml:",omitempty"` // Secret signing the notification payloads
+}
+
+// Chain is the chain access required to follow the sealed blocks.
+type Chain interface {
+	Config() *params.ChainConfig
+	CurrentHeader() *types.Header
+	GetHeaderByNumber(number uint64) *types.Header
+	GetBlockByHash(hash common.Hash) *types.Block
+	GetReceiptsByHash(hash common.Hash) types.Receipts
+	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
+}
+
+// Reward itemizes the coinbase credit of a sealed block. The net credit is the
+// subsidy and uncle inclusion rewards plus the fees minus the burnt base fee.
+type Reward struct {
+	Subsidy      *hexutil.Big `json:"subsidy"`      // Static block reward
+	UncleRewards *hexutil.Big `json:"uncleRewards"` // Rewards for including uncles
+	Fees         *hexutil.Big `json:"fees"`         // Total fees paid by the transactions
+	Burnt        *hexutil.Big `json:"burnt"`        // Base fee burnt, part of the fees
+	Net          *hexutil.Big `json:"net"`          // Net coinbase credit
+}
+
+// Payload is the notification posted to the webhooks.
+type Payload struct {
+	Event        string         `json:"event"`
+	Number       hexutil.Uint64 `json:"number"`
+	Hash         common.Hash    `json:"hash"`
+	ParentHash   common.Hash    `json:"parentHash"`
+	Coinbase     common.Address `json:"coinbase"`
+	Timestamp    hexutil.Uint64 `json:"timestamp"`
+	Difficulty   *hexutil.Big   `json:"difficulty"`
+	Transactions hexutil.Uint64 `json:"transactions"`
+	Uncles       hexutil.Uint64 `json:"uncles"`
+	Reward       *Reward        `json:"reward"`
+	ReplacedBy   *common.Hash   `json:"replacedBy,omitempty"` // Canonical block at the height of a reorged block
+}
+
+// Notifier follows the blocks sealed by the local miner, posting a notification
+// to the webhooks once each is imported, and another if it gets reorged out of
+// the canonical chain within the tracked depth.
+type Notifier struct {
+	config Config
+	chain  Chain
+	mux    *event.TypeMux
+	client *http.Client
+
+	pending []*types.Block           // Sealed blocks waiting to be imported
+	tracked map[common.Hash]*Payload // Notified blocks watched for reorgs
+
+	queue chan *Payload
+	quit  chan struct{}
+	wg    sync.WaitGroup
+}
+
+// New creates a notifier of the blocks sealed into the given chain, as posted
+// on the event mux by the miner.
+func New(config Config, chain Chain, mux *event.TypeMux) (*Notifier, error) {
+	if config.Secret == "" {
+		return nil, errMissingSecret
+	}
+	return &Notifier{
+		config:  config,
+		chain:   chain,
+		mux:     mux,
+		client:  &http.Client{Timeout: deliveryTimeout},
+		tracked: make(map[common.Hash]*Payload),
+		queue:   make(chan *Payload, queueSize),
+		quit:    make(chan struct{}),
+	}, nil
+}
+
+// Start starts following the sealed blocks, implementing node.Lifecycle.
+func (n *Notifier) Start() error {
+	n.wg.Add(2)
+	go n.loop()
+	go n.deliverLoop()
+
+	log.Info("Started block notification webhooks", "urls", len(n.config.URLs))
+	return nil
+}
+
+// Stop terminates the notifier, dropping undelivered notifications,
+// implementing node.Lifecycle.
+func (n *Notifier) Stop() error {
+	close(n.quit)
+	n.wg.Wait()
+	return nil
+}
+
+// loop tracks the sealed blocks, checking them against the chain on every new
+// chain head.
+func (n *Notifier) loop() {
+	defer n.wg.Done()
+
+	mined := n.mux.Subscribe(core.NewMinedBlockEvent{})
+	defer mined.Unsubscribe()
+
+	heads := make(chan core.ChainHeadEvent, 16)
+	sub := n.chain.SubscribeChainHeadEvent(heads)
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-mined.Chan():
+			if ev == nil {
+				return
+			}
+			if block, ok := ev.Data.(core.NewMinedBlockEvent); ok {
+				// Blocks are usually posted ahead of their import, but don't
+				// wait for the next head if it raced ahead
+				n.pending = append(n.pending, block.Block)
+				n.update(n.chain.CurrentHeader().Number.Uint64())
+			}
+		case ev := <-heads:
+			n.update(ev.Block.NumberU64())
+		case <-sub.Err():
+			return
+		case <-n.quit:
+			return
+		}
+	}
+}
+
+// update notifies the sealed blocks imported since the last update, and the
+// tracked ones not canonical anymore at the given head.
+func (n *Notifier) update(head uint64) {
+	var pending []*types.Block
+	for _, block := range n.pending {
+		number := block.NumberU64()
+		switch {
+		case n.chain.GetBlockByHash(block.Hash()) != nil:
+			payload := n.payload(EventFound, block)
+			n.tracked[payload.Hash] = payload
+			n.enqueue(payload)
+		case head < number+trackDepth:
+			pending = append(pending, block) // Not imported yet
+		}
+	}
+	n.pending = pending
+
+	for hash, payload := range n.tracked {
+		number := uint64(payload.Number)
+		if head >= number+trackDepth {
+			delete(n.tracked, hash)
+			continue
+		}
+		canon := n.chain.GetHeaderByNumber(number)
+		if canon != nil && canon.Hash() == payload.Hash {
+			continue
+		}
+		reorged := *payload
+		reorged.Event = EventReorged
+		if canon != nil {
+			replacement := canon.Hash()
+			reorged.ReplacedBy = &replacement
+		}
+		delete(n.tracked, hash)
+		n.enqueue(&reorged)
+	}
+}
+
+// payload assembles the notification of a sealed block.
+func (n *Notifier) payload(event string, block *types.Block) *Payload {
+	return &Payload{
+		Event:        event,
+		Number:       hexutil.Uint64(block.NumberU64()),
+		Hash:         block.Hash(),
+		ParentHash:   block.ParentHash(),
+		Coinbase:     block.Coinbase(),
+		Timestamp:    hexutil.Uint64(block.Time()),
+		Difficulty:   (*hexutil.Big)(block.Difficulty()),
+		Transactions: hexutil.Uint64(len(block.Transactions())),
+		Uncles:       hexutil.Uint64(len(block.Uncles())),
+		Reward:       n.reward(block),
+	}
+}
+
+// reward itemizes the coinbase credit of a sealed block from its receipts.
+func (n *Notifier) reward(block *types.Block) *Reward {
+	var (
+		config  = n.chain.Config()
+		subsidy = new(big.Int)
+		uncles  = new(big.Int)
+		fees    = new(big.Int)
+		burnt   = new(big.Int)
+	)
+	if config.Ethash != nil {
+		subsidy = ethash.BlockReward(config, block.Number())
+		uncles.Mul(ethash.UncleInclusionReward(config, block.Number()), big.NewInt(int64(len(block.Uncles()))))
+	}
+	receipts := n.chain.GetReceiptsByHash(block.Hash())
+	for i, tx := range block.Transactions() {
+		if i >= len(receipts) {
+			break
+		}
+		used := new(big.Int).SetUint64(receipts[i].GasUsed)
+		fees.Add(fees, new(big.Int).Mul(used, tx.EffectiveGasTipValue(block.BaseFee())))
+	}
+	if baseFee := block.BaseFee(); baseFee != nil {
+		burnt.Mul(baseFee, new(big.Int).SetUint64(block.GasUsed()))
+		fees.Add(fees, burnt)
+	}
+	net := new(big.Int).Add(subsidy, uncles)
+	net.Add(net, fees)
+	net.Sub(net, burnt)
+
+	return &Reward{
+		Subsidy:      (*hexutil.Big)(subsidy),
+		UncleRewards: (*hexutil.Big)(uncles),
+		Fees:         (*hexutil.Big)(fees),
+		Burnt:        (*hexutil.Big)(burnt),
+		Net:          (*hexutil.Big)(net),
+	}
+}
+
+// enqueue schedules a notification for delivery, dropping it if the queue is
+// full.
+func (n *Notifier) enqueue(payload *Payload) {
+	select {
+	case n.queue <- payload:
+	default:
+		log.Warn("Dropped block notification", "event", payload.Event, "number", uint64(payload.Number), "hash", payload.Hash)
+		notifyDroppedMeter.Mark(1)
+	}
+}
+
+// deliverLoop posts the queued notifications to all the webhooks.
+func (n *Notifier) deliverLoop() {
+	defer n.wg.Done()
+
+	for {
+		select {
+		case payload := <-n.queue:
+			body, err := json.Marshal(payload)
+			if err != nil {
+				log.Error("Failed to encode block notification", "err", err)
+				continue
+			}
+			for _, url := range n.config.URLs {
+				n.deliver(url, body)
+			}
+		case <-n.quit:
+			return
+		}
+	}
+}
+
+// deliver posts a notification to a webhook, retrying failed attempts.
+func (n *Notifier) deliver(url string, body []byte) {
+	backoff := time.Second
+	for attempt := 1; ; attempt++ {
+		err := n.post(url, body)
+		if err == nil {
+			notifySentMeter.Mark(1)
+			return
+		}
+		if attempt == deliveryAttempts {
+			log.Warn("Failed to deliver block notification", "url", url, "err", err)
+			notifyFailedMeter.Mark(1)
+			return
+		}
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-n.quit:
+			return
+		}
+	}
+}
+
+// post sends a signed notification to a webhook.
+func (n *Notifier) post(url string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(n.config.Secret, body))
+
+	res, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded %s", res.Status)
+	}
+	return nil
+}
+
+// Sign returns the signature of a notification payload, as sent in the
+// SignatureHeader for the webhooks to authenticate the notification with.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,143 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package blocknotify
+
+import (
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that sealed blocks are notified once imported, and again when reorged
+// out, with signed payloads.
+func TestNotifier(t *testing.T) {
+	var (
+		key, _  = crypto.GenerateKey()
+		sender  = crypto.PubkeyToAddress(key.PublicKey)
+		local   = common.Address{0x01}
+		remote  = common.Address{0x02}
+		tip     = big.NewInt(params.GWei)
+		genesis = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  core.GenesisAlloc{sender: {Balance: big.NewInt(params.Ether)}},
+		}
+		engine = ethash.NewFaker()
+	)
+	genDb, blocks, _ := core.GenerateChainWithGenesis(genesis, engine, 2, func(i int, b *core.BlockGen) {
+		b.SetCoinbase(local)
+		if i == 1 {
+			tx, _ := types.SignNewTx(key, types.LatestSigner(genesis.Config), &types.LegacyTx{
+				To:       &remote,
+				Gas:      params.TxGas,
+				GasPrice: new(big.Int).Add(b.BaseFee(), tip),
+			})
+			b.AddTx(tx)
+		}
+	})
+	// The competing chain forks off after the first block, outgrowing ours
+	fork, _ := core.GenerateChain(genesis.Config, blocks[0], engine, genDb, 2, func(i int, b *core.BlockGen) {
+		b.SetCoinbase(remote)
+	})
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	notifications := make(chan *Payload, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if have, want := r.Header.Get(SignatureHeader), Sign("secret", body); have != want {
+			t.Errorf("signature mismatch: have %s, want %s", have, want)
+		}
+		payload := new(Payload)
+		if err := json.Unmarshal(body, payload); err != nil {
+			t.Errorf("invalid payload: %v", err)
+		}
+		notifications <- payload
+	}))
+	defer server.Close()
+
+	if _, err := New(Config{URLs: []string{server.URL}}, chain, new(event.TypeMux)); err != errMissingSecret {
+		t.Fatalf("missing secret error mismatch: have %v, want %v", err, errMissingSecret)
+	}
+	mux := new(event.TypeMux)
+	notifier, err := New(Config{URLs: []string{server.URL}, Secret: "secret"}, chain, mux)
+	if err != nil {
+		t.Fatalf("failed to create notifier: %v", err)
+	}
+	notifier.Start()
+	defer notifier.Stop()
+
+	wait := func() *Payload {
+		t.Helper()
+		select {
+		case payload := <-notifications:
+			return payload
+		case <-time.After(5 * time.Second):
+			t.Fatalf("notification timeout")
+			return nil
+		}
+	}
+	// Only the block posted as sealed by the miner is notified
+	if _, err := chain.InsertChain(blocks[:1]); err != nil {
+		t.Fatalf("failed to insert block: %v", err)
+	}
+	mux.Post(core.NewMinedBlockEvent{Block: blocks[1]})
+	if _, err := chain.InsertChain(blocks[1:]); err != nil {
+		t.Fatalf("failed to insert sealed block: %v", err)
+	}
+	found := wait()
+	if found.Event != EventFound || found.Hash != blocks[1].Hash() || found.Number != 2 || found.Coinbase != local || found.Transactions != 1 {
+		t.Fatalf("found notification mismatch: %+v", found)
+	}
+	var (
+		subsidy = ethash.BlockReward(genesis.Config, big.NewInt(2))
+		tips    = new(big.Int).Mul(tip, big.NewInt(int64(params.TxGas)))
+		burnt   = new(big.Int).Mul(blocks[1].BaseFee(), big.NewInt(int64(params.TxGas)))
+	)
+	reward := found.Reward
+	if reward.Subsidy.ToInt().Cmp(subsidy) != 0 || reward.Burnt.ToInt().Cmp(burnt) != 0 || reward.Fees.ToInt().Cmp(new(big.Int).Add(tips, burnt)) != 0 {
+		t.Errorf("reward breakdown mismatch: %+v", reward)
+	}
+	if want := new(big.Int).Add(subsidy, tips); reward.Net.ToInt().Cmp(want) != 0 {
+		t.Errorf("net reward mismatch: have %v, want %v", reward.Net, want)
+	}
+	// Reorging the sealed block out notifies it again
+	if _, err := chain.InsertChain(fork); err != nil {
+		t.Fatalf("failed to insert fork: %v", err)
+	}
+	reorged := wait()
+	if reorged.Event != EventReorged || reorged.Hash != blocks[1].Hash() || reorged.ReplacedBy == nil || *reorged.ReplacedBy != fork[0].Hash() {
+		t.Fatalf("reorged notification mismatch: %+v", reorged)
+	}
+	select {
+	case payload := <-notifications:
+		t.Errorf("unexpected notification: %+v", payload)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+// Package devnet implements the RPC API of the developer chains, funding
+// accounts from the developer faucet and sealing blocks on demand.
+package devnet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// maxMineBlocks is the maximum number of blocks sealed by a single request.
+const maxMineBlocks = 1024
+
+var (
+	errNoAmount      = errors.New("amount to fund missing")
+	errTooManyBlocks = fmt.Errorf("too many blocks requested, maximum %d", maxMineBlocks)
+)
+
+// Backend is the node access the developer API requires.
+type Backend interface {
+	ChainConfig() *params.ChainConfig
+	CurrentHeader() *types.Header
+	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
+	AccountManager() *accounts.Manager
+
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	GetPoolNonce(ctx context.Context, addr common.Address) (uint64, error)
+	SendTx(ctx context.Context, tx *types.Transaction) error
+}
+
+// API is the developer chain API, transacting from the unlocked faucet account.
+type API struct {
+	backend Backend
+	faucet  common.Address
+
+	lock sync.Mutex // Serializes the nonces of the faucet transactions
+}
+
+// New creates the developer API of a chain, paying from the given account.
+func New(backend Backend, faucet common.Address) *API {
+	return &API{
+		backend: backend,
+		faucet:  faucet,
+	}
+}
+
+// APIs returns the RPC APIs of the developer chain.
+func (api *API) APIs() []rpc.API {
+	return []rpc.API{{
+		Namespace: "dev",
+		Service:   api,
+	}}
+}
+
+// FundAccount transfers the given amount from the developer faucet to an
+// address, returning the hash of the transaction.
+func (api *API) FundAccount(ctx context.Context, addr common.Address, amount *hexutil.Big) (common.Hash, error) {
+	if amount == nil {
+		return common.Hash{}, errNoAmount
+	}
+	tx, err := api.transfer(ctx, addr, amount.ToInt())
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return tx.Hash(), nil
+}
+
+// MineBlocks seals the given number of blocks, returning the number of the new
+// head. Chains sealing only on demand get a zero value transfer of the faucet to
+// itself included in every block, while chains sealing periodically are waited
+// for.
+func (api *API) MineBlocks(ctx context.Context, count hexutil.Uint64) (hexutil.Uint64, error) {
+	if count > maxMineBlocks {
+		return 0, errTooManyBlocks
+	}
+	heads := make(chan core.ChainHeadEvent, 16)
+	sub := api.backend.SubscribeChainHeadEvent(heads)
+	defer sub.Unsubscribe()
+
+	var (
+		onDemand = api.onDemand()
+		head     = api.backend.CurrentHeader().Number.Uint64()
+		target   = head + uint64(count)
+	)
+	for head < target {
+		if onDemand {
+			if _, err := api.transfer(ctx, api.faucet, new(big.Int)); err != nil {
+				return hexutil.Uint64(head), err
+			}
+		}
+		// Wait for the head to advance, ignoring reorged or stale heads
+		for advanced := false; !advanced; {
+			select {
+			case ev := <-heads:
+				if number := ev.Block.NumberU64(); number > head {
+					head, advanced = number, true
+				}
+			case err := <-sub.Err():
+				return hexutil.Uint64(head), err
+			case <-ctx.Done():
+				return hexutil.Uint64(head), ctx.Err()
+			}
+		}
+	}
+	return hexutil.Uint64(head), nil
+}
+
+// onDemand returns whether the chain only seals blocks with transactions pending.
+func (api *API) onDemand() bool {
+	config := api.backend.ChainConfig()
+	return config.Clique != nil && config.Clique.Period == 0
+}
+
+// transfer signs and submits a transfer from the faucet.
+func (api *API) transfer(ctx context.Context, to common.Address, amount *big.Int) (*types.Transaction, error) {
+	api.lock.Lock()
+	defer api.lock.Unlock()
+
+	wallet, err := api.backend.AccountManager().Find(accounts.Account{Address: api.faucet})
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := api.backend.GetPoolNonce(ctx, api.faucet)
+	if err != nil {
+		return nil, err
+	}
+	price, err := api.backend.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if baseFee := api.backend.CurrentHeader().BaseFee; baseFee != nil {
+		price = new(big.Int).Add(price, new(big.Int).Mul(baseFee, big.NewInt(2)))
+	}
+	tx := types.NewTx(&types.LegacyTx{
+		Nonce:    nonce,
+		To:       &to,
+		Value:    amount,
+		Gas:      params.TxGas,
+		GasPrice: price,
+	})
+	signed, err := wallet.SignTx(accounts.Account{Address: api.faucet}, tx, api.backend.ChainConfig().ChainID)
+	if err != nil {
+		return nil, err
+	}
+	if err := api.backend.SendTx(ctx, signed); err != nil {
+		return nil, err
+	}
+	return signed, nil
+}
@@ -0,0 +1,141 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package devnet
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// testBackend is a clique chain sealing a block on every submitted transaction.
+type testBackend struct {
+	am     *accounts.Manager
+	config *params.ChainConfig
+
+	lock   sync.Mutex
+	head   uint64
+	sent   types.Transactions
+	heads  event.Feed
+	sealer sync.WaitGroup
+}
+
+func (b *testBackend) ChainConfig() *params.ChainConfig { return b.config }
+func (b *testBackend) CurrentHeader() *types.Header {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return &types.Header{Number: new(big.Int).SetUint64(b.head)}
+}
+func (b *testBackend) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
+	return b.heads.Subscribe(ch)
+}
+func (b *testBackend) AccountManager() *accounts.Manager { return b.am }
+func (b *testBackend) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(1), nil
+}
+func (b *testBackend) GetPoolNonce(ctx context.Context, addr common.Address) (uint64, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return uint64(len(b.sent)), nil
+}
+func (b *testBackend) SendTx(ctx context.Context, tx *types.Transaction) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.sent = append(b.sent, tx)
+	b.head++
+	block := types.NewBlockWithHeader(&types.Header{Number: new(big.Int).SetUint64(b.head)})
+
+	b.sealer.Add(1)
+	go func() {
+		defer b.sealer.Done()
+		b.heads.Send(core.ChainHeadEvent{Block: block})
+	}()
+	return nil
+}
+
+func newTestAPI(t *testing.T) (*API, *testBackend) {
+	ks := keystore.NewKeyStore(t.TempDir(), keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.NewAccount("")
+	if err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+	if err := ks.Unlock(account, ""); err != nil {
+		t.Fatalf("failed to unlock account: %v", err)
+	}
+	am := accounts.NewManager(&accounts.Config{}, ks)
+	t.Cleanup(func() { am.Close() })
+
+	config := *params.AllCliqueProtocolChanges
+	config.Clique = &params.CliqueConfig{Period: 0, Epoch: 30000}
+	backend := &testBackend{am: am, config: &config}
+	t.Cleanup(backend.sealer.Wait)
+
+	return New(backend, account.Address), backend
+}
+
+func TestFundAccount(t *testing.T) {
+	api, backend := newTestAPI(t)
+
+	recipient := common.HexToAddress("0xbeef")
+	for i := int64(1); i <= 2; i++ {
+		hash, err := api.FundAccount(context.Background(), recipient, (*hexutil.Big)(big.NewInt(i*params.Ether)))
+		if err != nil {
+			t.Fatalf("failed to fund account: %v", err)
+		}
+		tx := backend.sent[i-1]
+		if tx.Hash() != hash || tx.Nonce() != uint64(i-1) || *tx.To() != recipient || tx.Value().Cmp(big.NewInt(i*params.Ether)) != 0 {
+			t.Fatalf("transfer %d mismatch: %+v", i, tx)
+		}
+		if from, err := types.Sender(types.LatestSigner(backend.config), tx); err != nil || from != api.faucet {
+			t.Fatalf("transfer %d sender mismatch: have %v (%v), want %v", i, from, err, api.faucet)
+		}
+	}
+	if _, err := api.FundAccount(context.Background(), recipient, nil); err != errNoAmount {
+		t.Fatalf("missing amount error mismatch: have %v, want %v", err, errNoAmount)
+	}
+}
+
+func TestMineBlocks(t *testing.T) {
+	api, backend := newTestAPI(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	head, err := api.MineBlocks(ctx, 3)
+	if err != nil {
+		t.Fatalf("failed to mine blocks: %v", err)
+	}
+	if head != 3 || len(backend.sent) != 3 {
+		t.Fatalf("mined blocks mismatch: head %d, %d transactions, want 3", head, len(backend.sent))
+	}
+	for _, tx := range backend.sent {
+		if *tx.To() != api.faucet || tx.Value().Sign() != 0 {
+			t.Fatalf("sealing transaction mismatch: %+v", tx)
+		}
+	}
+	if _, err := api.MineBlocks(ctx, maxMineBlocks+1); err != errTooManyBlocks {
+		t.Fatalf("block limit error mismatch: have %v, want %v", err, errTooManyBlocks)
+	}
+}
@@ -76,6 +76,7 @@ var (
 	errTooOld                  = errors.New("peer's protocol version too old")
 	errNoAncestorFound         = errors.New("no common ancestor found")
 	errNoPivotHeader           = errors.New("pivot header is not found")
+	errCheckpointMismatch      = errors.New("trusted checkpoint mismatch")
 	ErrMergeTransition         = errors.New("legacy sync reached the merge")
 )
 
@@ -86,6 +87,14 @@ type peerDropFn func(id string)
 // the origin header requested to sync to, produced a chain with a bad block.
 type badBlockFn func(invalid *types.Header, origin *types.Header)
 
+// Checkpoint is a block of the canonical chain trusted by the node operator.
+// During snap sync, the headers below it are linked to it by hash alone, their
+// seals not being verified.
+type Checkpoint struct {
+	Number uint64      // Block number of the checkpoint
+	Hash   common.Hash // Block hash of the checkpoint
+}
+
 // headerTask is a set of downloaded headers to queue along with their precomputed
 // hashes to avoid constant rehashing.
 type headerTask struct {
@@ -97,10 +106,11 @@ type Downloader struct {
 	mode atomic.Uint32  // Synchronisation mode defining the strategy used (per sync cycle), use d.getMode() to get the SyncMode
 	mux  *event.TypeMux // Event multiplexer to announce sync operation events
 
-	checkpoint uint64   // Checkpoint block number to enforce head against (e.g. snap sync)
-	genesis    uint64   // Genesis block number to limit sync to (e.g. light client CHT)
-	queue      *queue   // Scheduler for selecting the hashes to download
-	peers      *peerSet // Set of active peers from which download can proceed
+	checkpoint uint64      // Checkpoint block number to enforce head against (e.g. snap sync)
+	trusted    *Checkpoint // Operator trusted checkpoint to skip seal verification below
+	genesis    uint64      // Genesis block number to limit sync to (e.g. light client CHT)
+	queue      *queue      // Scheduler for selecting the hashes to download
+	peers      *peerSet    // Set of active peers from which download can proceed
 
 	stateDB ethdb.Database // Database to state sync into (and deduplicate via)
 
@@ -214,15 +224,20 @@ type BlockChain interface {
 	TrieDB() *trie.Database
 }
 
-// New creates a new downloader to fetch hashes and blocks from remote peers.
-func New(checkpoint uint64, stateDb ethdb.Database, mux *event.TypeMux, chain BlockChain, lightchain LightChain, dropPeer peerDropFn, success func()) *Downloader {
+// New creates a new downloader to fetch hashes and blocks from remote peers. An
+// optional trusted checkpoint supersedes the hardcoded checkpoint number.
+func New(checkpoint uint64, trusted *Checkpoint, stateDb ethdb.Database, mux *event.TypeMux, chain BlockChain, lightchain LightChain, dropPeer peerDropFn, success func()) *Downloader {
 	if lightchain == nil {
 		lightchain = chain
 	}
+	if trusted != nil {
+		checkpoint = trusted.Number
+	}
 	dl := &Downloader{
 		stateDB:        stateDb,
 		mux:            mux,
 		checkpoint:     checkpoint,
+		trusted:        trusted,
 		queue:          newQueue(blockCacheMaxItems, blockCacheInitialItems),
 		peers:          newPeerSet(),
 		blockchain:     chain,
@@ -1280,6 +1295,7 @@ func (d *Downloader) processHeaders(origin uint64, td, ttd *big.Int, beaconMode
 		rollback    uint64 // Zero means no rollback (fine as you can't unroll the genesis)
 		rollbackErr error
 		mode        = d.getMode()
+		unconfirmed uint64 // First header imported unverified below the trusted checkpoint, pending its arrival
 	)
 	defer func() {
 		if rollback > 0 {
@@ -1358,6 +1374,12 @@ func (d *Downloader) processHeaders(origin uint64, td, ttd *big.Int, beaconMode
 						}
 					}
 				}
+				// Headers imported unverified must be confirmed by the trusted
+				// checkpoint, which the peer promised by its head
+				if unconfirmed != 0 {
+					rollback, rollbackErr = unconfirmed, errStallingPeer
+					return errStallingPeer
+				}
 				// Disable any rollback and return
 				rollback = 0
 				return nil
@@ -1397,6 +1419,29 @@ func (d *Downloader) processHeaders(origin uint64, td, ttd *big.Int, beaconMode
 					if chunkHeaders[len(chunkHeaders)-1].Number.Uint64()+uint64(fsHeaderForceVerify) > pivot {
 						frequency = 1
 					}
+					// Headers below the trusted checkpoint are linked to it by hash, so
+					// their seals need not be verified. Until the checkpoint arrives they
+					// are uncertain though, and need to be rolled back on a mismatch.
+					if d.trusted != nil {
+						first, last := chunkHeaders[0].Number.Uint64(), chunkHeaders[len(chunkHeaders)-1].Number.Uint64()
+						if last <= d.trusted.Number {
+							frequency = 0
+							if unconfirmed == 0 {
+								unconfirmed = first
+							}
+						}
+						if first <= d.trusted.Number && d.trusted.Number <= last {
+							if hash := chunkHashes[d.trusted.Number-first]; hash != d.trusted.Hash {
+								if unconfirmed != 0 {
+									rollback = unconfirmed
+								}
+								rollbackErr = errCheckpointMismatch
+								log.Warn("Trusted checkpoint mismatch", "number", d.trusted.Number, "hash", hash, "want", d.trusted.Hash)
+								return fmt.Errorf("%w: %v", errInvalidChain, errCheckpointMismatch)
+							}
+							unconfirmed = 0
+						}
+					}
 					// Although the received headers might be all valid, a legacy
 					// PoW/PoA sync must not accept post-merge headers. Make sure
 					// that any transition is rejected at this point.
@@ -1436,6 +1481,9 @@ func (d *Downloader) processHeaders(origin uint64, td, ttd *big.Int, beaconMode
 							if (mode == SnapSync || frequency > 1) && n > 0 && rollback == 0 {
 								rollback = chunkHeaders[0].Number.Uint64()
 							}
+							if unconfirmed != 0 {
+								rollback = unconfirmed
+							}
 							log.Warn("Invalid header encountered", "number", chunkHeaders[n].Number, "hash", chunkHashes[n], "parent", chunkHeaders[n].ParentHash, "err", err)
 							return fmt.Errorf("%w: %v", errInvalidChain, err)
 						}
@@ -1448,6 +1496,9 @@ func (d *Downloader) processHeaders(origin uint64, td, ttd *big.Int, beaconMode
 								rollback = 1
 							}
 						}
+						if unconfirmed != 0 {
+							rollback = unconfirmed
+						}
 					}
 					if len(rejected) != 0 {
 						// Merge threshold reached, stop importing, but don't roll back
@@ -78,7 +78,7 @@ func newTesterWithNotification(t *testing.T, success func()) *downloadTester {
 		chain:   chain,
 		peers:   make(map[string]*downloadTesterPeer),
 	}
-	tester.downloader = New(0, db, new(event.TypeMux), tester.chain, nil, tester.dropPeer, success)
+	tester.downloader = New(0, nil, db, new(event.TypeMux), tester.chain, nil, tester.dropPeer, success)
 	return tester
 }
 
@@ -955,6 +955,38 @@ func testInvalidHeaderRollback(t *testing.T, protocol uint, mode SyncMode) {
 	assertOwnChain(t, tester, len(chain.blocks))
 }
 
+// Tests that snap syncing against a trusted checkpoint imports the headers below
+// it, and that a chain not matching the checkpoint is rolled back entirely, as
+// the headers below it were imported without seal verification.
+func TestTrustedCheckpoint66Snap(t *testing.T) { testTrustedCheckpoint(t, eth.ETH66, SnapSync) }
+func TestTrustedCheckpoint67Snap(t *testing.T) { testTrustedCheckpoint(t, eth.ETH67, SnapSync) }
+
+func testTrustedCheckpoint(t *testing.T, protocol uint, mode SyncMode) {
+	chain := testChainBase.shorten(blockCacheMaxItems - 15)
+	number := uint64(len(chain.blocks) / 2)
+
+	// Sync against a checkpoint the peer's chain doesn't contain
+	tester := newTester(t)
+	defer tester.terminate()
+
+	tester.downloader.trusted = &Checkpoint{Number: number, Hash: common.Hash{0x01}}
+	tester.downloader.checkpoint = number
+
+	tester.newPeer("peer", protocol, chain.blocks[1:])
+	if err := tester.sync("peer", nil, mode); !errors.Is(err, errInvalidChain) {
+		t.Fatalf("sync error mismatch: have %v, want %v", err, errInvalidChain)
+	}
+	if head := tester.chain.CurrentHeader().Number.Uint64(); head != 0 {
+		t.Fatalf("unconfirmed headers not rolled back: head #%d", head)
+	}
+	// Sync against the correct checkpoint
+	tester.downloader.trusted = &Checkpoint{Number: number, Hash: chain.blocks[number].Hash()}
+	if err := tester.sync("peer", nil, mode); err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	assertOwnChain(t, tester, len(chain.blocks))
+}
+
 // Tests that a peer advertising a high TD doesn't get to stall the downloader
 // afterwards by not sending any useful hashes.
 func TestHighTDStarvationAttack66Full(t *testing.T) {
@@ -19,13 +19,20 @@ import "fmt"
 type SyncMode uint32
 
 const (
-	FullSync  SyncMode = iota // Synchronise the entire blockchain history from full blocks
-	SnapSync                  // Download the chain and the state via compact snapshots
-	LightSync                 // Download only the headers and terminate afterwards
+	FullSync    SyncMode = iota // Synchronise the entire blockchain history from full blocks
+	SnapSync                    // Download the chain and the state via compact snapshots
+	LightSync                   // Download only the headers and terminate afterwards
+	HeadersSync                 // Light sync proxying mining work to upstream full nodes
 )
 
 func (mode SyncMode) IsValid() bool {
-	return mode >= FullSync && mode <= LightSync
+	return mode >= FullSync && mode <= HeadersSync
+}
+
+// IsLight returns whether the mode runs a light client, maintaining only the
+// header chain.
+func (mode SyncMode) IsLight() bool {
+	return mode == LightSync || mode == HeadersSync
 }
 
 // String implements the stringer interface.
@@ -37,6 +44,8 @@ func (mode SyncMode) String() string {
 		return "snap"
 	case LightSync:
 		return "light"
+	case HeadersSync:
+		return "headers"
 	default:
 		return "unknown"
 	}
@@ -50,6 +59,8 @@ func (mode SyncMode) MarshalText() ([]byte, error) {
 		return []byte("snap"), nil
 	case LightSync:
 		return []byte("light"), nil
+	case HeadersSync:
+		return []byte("headers"), nil
 	default:
 		return nil, fmt.Errorf("unknown sync mode %d", mode)
 	}
@@ -63,8 +74,10 @@ func (mode *SyncMode) UnmarshalText(text []byte) error {
 		*mode = SnapSync
 	case "light":
 		*mode = LightSync
+	case "headers":
+		*mode = HeadersSync
 	default:
-		return fmt.Errorf(`unknown sync mode %q, want "full", "snap" or "light"`, text)
+		return fmt.Errorf(`unknown sync mode %q, want "full", "snap", "light" or "headers"`, text)
 	}
 	return nil
 }
@@ -27,7 +27,9 @@ import (
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/eth/blocknotify"
 	"github.com/ethereum/go-ethereum/eth/downloader"
+	"github.com/ethereum/go-ethereum/eth/fastannounce"
 	"github.com/ethereum/go-ethereum/eth/gasprice"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
@@ -44,6 +46,8 @@ var FullNodeGPO = gasprice.Config{
 	MaxBlockHistory:  1024,
 	MaxPrice:         gasprice.DefaultMaxPrice,
 	IgnorePrice:      gasprice.DefaultIgnorePrice,
+	PoolPercentile:   20,
+	PoolDepth:        2,
 }
 
 // LightClientGPO contains default gasprice oracle settings for light client.
@@ -54,6 +58,8 @@ var LightClientGPO = gasprice.Config{
 	MaxBlockHistory:  5,
 	MaxPrice:         gasprice.DefaultMaxPrice,
 	IgnorePrice:      gasprice.DefaultIgnorePrice,
+	PoolPercentile:   20,
+	PoolDepth:        2,
 }
 
 // Defaults contains default settings for use on the Ethereum main net.
@@ -70,7 +76,9 @@ var Defaults = Config{
 	},
 	NetworkId:               1,
 	TxLookupLimit:           2350000,
+	OnlinePruneBloomSize:    2048,
 	LightPeers:              100,
+	LightSealGuard:          500 * time.Millisecond,
 	UltraLightFraction:      75,
 	DatabaseCache:           512,
 	TrieCleanCache:          154,
@@ -130,20 +138,55 @@ type Config struct {
 	NoPrefetch bool // Whether to disable prefetching and only load state on demand
 
 	TxLookupLimit uint64 `toml:",omitempty"` // The maximum number of blocks from head whose tx indices are reserved.
+	StateHistory  uint64 `toml:",omitempty"` // The number of blocks from head whose state is retained, ignored if below 128 or not pruning.
+	FinalityDepth uint64 `toml:",omitempty"` // The number of blocks below head considered final and never reorged, soft finality disabled if zero.
+
+	OnlinePruneInterval  time.Duration `toml:",omitempty"` // Time between two automatic online state prunings, disabled if zero.
+	OnlinePruneBloomSize uint64        `toml:",omitempty"` // Megabytes of memory allocated to the online pruning bloom filter.
+
+	// ReplicaOf is the IPC endpoint of the node owning a data directory opened
+	// read-only, whose new heads trigger a refresh of the shared database.
+	ReplicaOf string `toml:",omitempty"`
+
+	// ShareDatabase serves the chain database read-only over RPC to the nodes
+	// using it as their remote database.
+	ShareDatabase bool `toml:",omitempty"`
+
+	// ForkScheduleFile is the path of a signed fork schedule, applied on startup
+	// and on admin_reloadChainConfig if signed by one of ForkScheduleSigners.
+	ForkScheduleFile    string           `toml:",omitempty"`
+	ForkScheduleSigners []common.Address `toml:",omitempty"`
 
 	// RequiredBlocks is a set of block number -> hash mappings which must be in the
 	// canonical chain of all remote peers. Setting the option makes geth verify the
 	// presence of these blocks for every new peer connection.
 	RequiredBlocks map[uint64]common.Hash `toml:"-"`
 
+	// SyncCheckpoint is a block trusted by the operator to be canonical. Peers must
+	// have it, and the headers below it are snap synced without seal verification.
+	SyncCheckpoint *downloader.Checkpoint `toml:",omitempty"`
+
+	// FastAnnounce configures the UDP sidechannel announcing newly sealed blocks
+	// to the other nodes of the pool ahead of devp2p.
+	FastAnnounce fastannounce.Config `toml:",omitempty"`
+
+	// BlockNotify configures the webhooks notified of the blocks sealed by the
+	// node and of the sealed blocks reorged out.
+	BlockNotify blocknotify.Config `toml:",omitempty"`
+
+	// AutoPrune periodically disconnects the peer least useful in propagating
+	// blocks, if its usefulness score drops below the prune threshold.
+	AutoPrune bool `toml:",omitempty"`
+
 	// Light client options
-	LightServ          int  `toml:",omitempty"` // Maximum percentage of time allowed for serving LES requests
-	LightIngress       int  `toml:",omitempty"` // Incoming bandwidth limit for light servers
-	LightEgress        int  `toml:",omitempty"` // Outgoing bandwidth limit for light servers
-	LightPeers         int  `toml:",omitempty"` // Maximum number of LES client peers
-	LightNoPrune       bool `toml:",omitempty"` // Whether to disable light chain pruning
-	LightNoSyncServe   bool `toml:",omitempty"` // Whether to serve light clients before syncing
-	SyncFromCheckpoint bool `toml:",omitempty"` // Whether to sync the header chain from the configured checkpoint
+	LightServ          int           `toml:",omitempty"` // Maximum percentage of time allowed for serving LES requests
+	LightIngress       int           `toml:",omitempty"` // Incoming bandwidth limit for light servers
+	LightEgress        int           `toml:",omitempty"` // Outgoing bandwidth limit for light servers
+	LightPeers         int           `toml:",omitempty"` // Maximum number of LES client peers
+	LightNoPrune       bool          `toml:",omitempty"` // Whether to disable light chain pruning
+	LightNoSyncServe   bool          `toml:",omitempty"` // Whether to serve light clients before syncing
+	LightSealGuard     time.Duration `toml:",omitempty"` // Time around local block broadcasts to back off serving LES requests for
+	SyncFromCheckpoint bool          `toml:",omitempty"` // Whether to sync the header chain from the configured checkpoint
 
 	// Ultra Light client options
 	UltraLightServers      []string `toml:",omitempty"` // List of trusted ultra light servers
@@ -167,6 +210,10 @@ type Config struct {
 	// This is the number of blocks for which logs will be cached in the filter system.
 	FilterLogCacheSize int
 
+	// Limits of a single log query, 0 disables them.
+	FilterMaxResults int    // Maximum number of logs returned
+	FilterMaxBlocks  uint64 // Maximum number of blocks searched
+
 	// Mining options
 	Miner miner.Config
 
@@ -191,6 +238,16 @@ type Config struct {
 	// RPCEVMTimeout is the global timeout for eth-call.
 	RPCEVMTimeout time.Duration
 
+	// RPCTracerCPUTime, RPCTracerMemory and RPCTracerOutput bound the resources
+	// a single tracer invocation over RPC may consume (0 = unlimited).
+	RPCTracerCPUTime time.Duration
+	RPCTracerMemory  uint64
+	RPCTracerOutput  uint64
+
+	// RPCTracers is the allowlist of named tracers usable over RPC. If set,
+	// arbitrary JS tracers are rejected.
+	RPCTracers []string `toml:",omitempty"`
+
 	// RPCTxFeeCap is the global transaction fee(price * gaslimit) cap for
 	// send-transaction variants. The unit is ether.
 	RPCTxFeeCap float64
@@ -221,16 +278,48 @@ func CreateConsensusEngine(stack *node.Node, ethashConfig *ethash.Config, clique
 			log.Warn("Ethash used in shared mode")
 		}
 		engine = ethash.New(ethash.Config{
-			PowMode:          ethashConfig.PowMode,
-			CacheDir:         stack.ResolvePath(ethashConfig.CacheDir),
-			CachesInMem:      ethashConfig.CachesInMem,
-			CachesOnDisk:     ethashConfig.CachesOnDisk,
-			CachesLockMmap:   ethashConfig.CachesLockMmap,
-			DatasetDir:       ethashConfig.DatasetDir,
-			DatasetsInMem:    ethashConfig.DatasetsInMem,
-			DatasetsOnDisk:   ethashConfig.DatasetsOnDisk,
-			DatasetsLockMmap: ethashConfig.DatasetsLockMmap,
-			NotifyFull:       ethashConfig.NotifyFull,
+			PowMode:                ethashConfig.PowMode,
+			CacheDir:               stack.ResolvePath(ethashConfig.CacheDir),
+			CachesInMem:            ethashConfig.CachesInMem,
+			CachesOnDisk:           ethashConfig.CachesOnDisk,
+			CachesLockMmap:         ethashConfig.CachesLockMmap,
+			DatasetDir:             ethashConfig.DatasetDir,
+			DatasetsInMem:          ethashConfig.DatasetsInMem,
+			DatasetsOnDisk:         ethashConfig.DatasetsOnDisk,
+			DatasetsLockMmap:       ethashConfig.DatasetsLockMmap,
+			NotifyFull:             ethashConfig.NotifyFull,
+			NotifyStructured:       ethashConfig.NotifyStructured,
+			NotifySecret:           ethashConfig.NotifySecret,
+			DedupWindow:            ethashConfig.DedupWindow,
+			WorkLookupWindow:       ethashConfig.WorkLookupWindow,
+			KeccakImpl:             ethashConfig.KeccakImpl,
+			MinerCPUs:              ethashConfig.MinerCPUs,
+			ThreadAffinity:         ethashConfig.ThreadAffinity,
+			NUMALocalDAG:           ethashConfig.NUMALocalDAG,
+			MixVariant:             ethashConfig.MixVariant,
+			LogUnknownWork:         ethashConfig.LogUnknownWork,
+			MinWorkInterval:        ethashConfig.MinWorkInterval,
+			VerifyWorkers:          ethashConfig.VerifyWorkers,
+			VerifyBatchSize:        ethashConfig.VerifyBatchSize,
+			CacheGenThreads:        ethashConfig.CacheGenThreads,
+			StatusLogInterval:      ethashConfig.StatusLogInterval,
+			AllowDirectSubmit:      ethashConfig.AllowDirectSubmit,
+			RejectDuplicateIDs:     ethashConfig.RejectDuplicateIDs,
+			VerifyFailuresRetained: ethashConfig.VerifyFailuresRetained,
+			ClockSkewThreshold:     ethashConfig.ClockSkewThreshold,
+			VerifyTimeout:          ethashConfig.VerifyTimeout,
+			ShareVerifiers:         ethashConfig.ShareVerifiers,
+			StratumAddr:            ethashConfig.StratumAddr,
+			StratumMaxConns:        ethashConfig.StratumMaxConns,
+			ShareDifficulty:        ethashConfig.ShareDifficulty,
+			ShareTargetTime:        ethashConfig.ShareTargetTime,
+			StratumV2Addr:          ethashConfig.StratumV2Addr,
+			Upstreams:              ethashConfig.Upstreams,
+			StaleWindow:            ethashConfig.StaleWindow,
+			SubmissionDB:           db,
+			WorkEncoder:            ethashConfig.WorkEncoder,
+			CollectSolutions:       ethashConfig.CollectSolutions,
+			OnSealed:               ethashConfig.OnSealed,
 		}, notify, noverify)
 		engine.(*ethash.Ethash).SetThreads(-1) // Disable CPU mining
 	}
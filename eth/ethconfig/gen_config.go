@@ -9,7 +9,9 @@ import (
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/eth/blocknotify"
 	"github.com/ethereum/go-ethereum/eth/downloader"
+	"github.com/ethereum/go-ethereum/eth/fastannounce"
 	"github.com/ethereum/go-ethereum/eth/gasprice"
 	"github.com/ethereum/go-ethereum/miner"
 	"github.com/ethereum/go-ethereum/params"
@@ -26,13 +28,26 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		NoPruning               bool
 		NoPrefetch              bool
 		TxLookupLimit           uint64                 `toml:",omitempty"`
+		StateHistory            uint64                 `toml:",omitempty"`
+		FinalityDepth           uint64                 `toml:",omitempty"`
+		OnlinePruneInterval     time.Duration          `toml:",omitempty"`
+		OnlinePruneBloomSize    uint64                 `toml:",omitempty"`
+		ReplicaOf               string                 `toml:",omitempty"`
+		ShareDatabase           bool                   `toml:",omitempty"`
+		ForkScheduleFile        string                 `toml:",omitempty"`
+		ForkScheduleSigners     []common.Address       `toml:",omitempty"`
 		RequiredBlocks          map[uint64]common.Hash `toml:"-"`
+		SyncCheckpoint          *downloader.Checkpoint `toml:",omitempty"`
+		FastAnnounce            fastannounce.Config    `toml:",omitempty"`
+		BlockNotify             blocknotify.Config     `toml:",omitempty"`
+		AutoPrune               bool                   `toml:",omitempty"`
 		LightServ               int                    `toml:",omitempty"`
 		LightIngress            int                    `toml:",omitempty"`
 		LightEgress             int                    `toml:",omitempty"`
 		LightPeers              int                    `toml:",omitempty"`
 		LightNoPrune            bool                   `toml:",omitempty"`
 		LightNoSyncServe        bool                   `toml:",omitempty"`
+		LightSealGuard          time.Duration          `toml:",omitempty"`
 		SyncFromCheckpoint      bool                   `toml:",omitempty"`
 		UltraLightServers       []string               `toml:",omitempty"`
 		UltraLightFraction      int                    `toml:",omitempty"`
@@ -49,6 +64,8 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		SnapshotCache           int
 		Preimages               bool
 		FilterLogCacheSize      int
+		FilterMaxResults        int
+		FilterMaxBlocks         uint64
 		Miner                   miner.Config
 		Ethash                  ethash.Config
 		TxPool                  txpool.Config
@@ -57,6 +74,10 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		DocRoot                 string `toml:"-"`
 		RPCGasCap               uint64
 		RPCEVMTimeout           time.Duration
+		RPCTracerCPUTime        time.Duration
+		RPCTracerMemory         uint64
+		RPCTracerOutput         uint64
+		RPCTracers              []string `toml:",omitempty"`
 		RPCTxFeeCap             float64
 		Checkpoint              *params.TrustedCheckpoint      `toml:",omitempty"`
 		CheckpointOracle        *params.CheckpointOracleConfig `toml:",omitempty"`
@@ -71,13 +92,26 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.NoPruning = c.NoPruning
 	enc.NoPrefetch = c.NoPrefetch
 	enc.TxLookupLimit = c.TxLookupLimit
+	enc.StateHistory = c.StateHistory
+	enc.FinalityDepth = c.FinalityDepth
+	enc.OnlinePruneInterval = c.OnlinePruneInterval
+	enc.OnlinePruneBloomSize = c.OnlinePruneBloomSize
+	enc.ReplicaOf = c.ReplicaOf
+	enc.ShareDatabase = c.ShareDatabase
+	enc.ForkScheduleFile = c.ForkScheduleFile
+	enc.ForkScheduleSigners = c.ForkScheduleSigners
 	enc.RequiredBlocks = c.RequiredBlocks
+	enc.SyncCheckpoint = c.SyncCheckpoint
+	enc.FastAnnounce = c.FastAnnounce
+	enc.BlockNotify = c.BlockNotify
+	enc.AutoPrune = c.AutoPrune
 	enc.LightServ = c.LightServ
 	enc.LightIngress = c.LightIngress
 	enc.LightEgress = c.LightEgress
 	enc.LightPeers = c.LightPeers
 	enc.LightNoPrune = c.LightNoPrune
 	enc.LightNoSyncServe = c.LightNoSyncServe
+	enc.LightSealGuard = c.LightSealGuard
 	enc.SyncFromCheckpoint = c.SyncFromCheckpoint
 	enc.UltraLightServers = c.UltraLightServers
 	enc.UltraLightFraction = c.UltraLightFraction
@@ -94,6 +128,8 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.SnapshotCache = c.SnapshotCache
 	enc.Preimages = c.Preimages
 	enc.FilterLogCacheSize = c.FilterLogCacheSize
+	enc.FilterMaxResults = c.FilterMaxResults
+	enc.FilterMaxBlocks = c.FilterMaxBlocks
 	enc.Miner = c.Miner
 	enc.Ethash = c.Ethash
 	enc.TxPool = c.TxPool
@@ -102,6 +138,10 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.DocRoot = c.DocRoot
 	enc.RPCGasCap = c.RPCGasCap
 	enc.RPCEVMTimeout = c.RPCEVMTimeout
+	enc.RPCTracerCPUTime = c.RPCTracerCPUTime
+	enc.RPCTracerMemory = c.RPCTracerMemory
+	enc.RPCTracerOutput = c.RPCTracerOutput
+	enc.RPCTracers = c.RPCTracers
 	enc.RPCTxFeeCap = c.RPCTxFeeCap
 	enc.Checkpoint = c.Checkpoint
 	enc.CheckpointOracle = c.CheckpointOracle
@@ -120,13 +160,26 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		NoPruning               *bool
 		NoPrefetch              *bool
 		TxLookupLimit           *uint64                `toml:",omitempty"`
+		StateHistory            *uint64                `toml:",omitempty"`
+		FinalityDepth           *uint64                `toml:",omitempty"`
+		OnlinePruneInterval     *time.Duration         `toml:",omitempty"`
+		OnlinePruneBloomSize    *uint64                `toml:",omitempty"`
+		ReplicaOf               *string                `toml:",omitempty"`
+		ShareDatabase           *bool                  `toml:",omitempty"`
+		ForkScheduleFile        *string                `toml:",omitempty"`
+		ForkScheduleSigners     []common.Address       `toml:",omitempty"`
 		RequiredBlocks          map[uint64]common.Hash `toml:"-"`
+		SyncCheckpoint          *downloader.Checkpoint `toml:",omitempty"`
+		FastAnnounce            *fastannounce.Config   `toml:",omitempty"`
+		BlockNotify             *blocknotify.Config    `toml:",omitempty"`
+		AutoPrune               *bool                  `toml:",omitempty"`
 		LightServ               *int                   `toml:",omitempty"`
 		LightIngress            *int                   `toml:",omitempty"`
 		LightEgress             *int                   `toml:",omitempty"`
 		LightPeers              *int                   `toml:",omitempty"`
 		LightNoPrune            *bool                  `toml:",omitempty"`
 		LightNoSyncServe        *bool                  `toml:",omitempty"`
+		LightSealGuard          *time.Duration         `toml:",omitempty"`
 		SyncFromCheckpoint      *bool                  `toml:",omitempty"`
 		UltraLightServers       []string               `toml:",omitempty"`
 		UltraLightFraction      *int                   `toml:",omitempty"`
@@ -143,6 +196,8 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		SnapshotCache           *int
 		Preimages               *bool
 		FilterLogCacheSize      *int
+		FilterMaxResults        *int
+		FilterMaxBlocks         *uint64
 		Miner                   *miner.Config
 		Ethash                  *ethash.Config
 		TxPool                  *txpool.Config
@@ -151,6 +206,10 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		DocRoot                 *string `toml:"-"`
 		RPCGasCap               *uint64
 		RPCEVMTimeout           *time.Duration
+		RPCTracerCPUTime        *time.Duration
+		RPCTracerMemory         *uint64
+		RPCTracerOutput         *uint64
+		RPCTracers              []string `toml:",omitempty"`
 		RPCTxFeeCap             *float64
 		Checkpoint              *params.TrustedCheckpoint      `toml:",omitempty"`
 		CheckpointOracle        *params.CheckpointOracleConfig `toml:",omitempty"`
@@ -184,9 +243,45 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.TxLookupLimit != nil {
 		c.TxLookupLimit = *dec.TxLookupLimit
 	}
+	if dec.StateHistory != nil {
+		c.StateHistory = *dec.StateHistory
+	}
+	if dec.FinalityDepth != nil {
+		c.FinalityDepth = *dec.FinalityDepth
+	}
+	if dec.OnlinePruneInterval != nil {
+		c.OnlinePruneInterval = *dec.OnlinePruneInterval
+	}
+	if dec.OnlinePruneBloomSize != nil {
+		c.OnlinePruneBloomSize = *dec.OnlinePruneBloomSize
+	}
+	if dec.ReplicaOf != nil {
+		c.ReplicaOf = *dec.ReplicaOf
+	}
+	if dec.ShareDatabase != nil {
+		c.ShareDatabase = *dec.ShareDatabase
+	}
+	if dec.ForkScheduleFile != nil {
+		c.ForkScheduleFile = *dec.ForkScheduleFile
+	}
+	if dec.ForkScheduleSigners != nil {
+		c.ForkScheduleSigners = dec.ForkScheduleSigners
+	}
 	if dec.RequiredBlocks != nil {
 		c.RequiredBlocks = dec.RequiredBlocks
 	}
+	if dec.SyncCheckpoint != nil {
+		c.SyncCheckpoint = dec.SyncCheckpoint
+	}
+	if dec.FastAnnounce != nil {
+		c.FastAnnounce = *dec.FastAnnounce
+	}
+	if dec.BlockNotify != nil {
+		c.BlockNotify = *dec.BlockNotify
+	}
+	if dec.AutoPrune != nil {
+		c.AutoPrune = *dec.AutoPrune
+	}
 	if dec.LightServ != nil {
 		c.LightServ = *dec.LightServ
 	}
@@ -205,6 +300,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.LightNoSyncServe != nil {
 		c.LightNoSyncServe = *dec.LightNoSyncServe
 	}
+	if dec.LightSealGuard != nil {
+		c.LightSealGuard = *dec.LightSealGuard
+	}
 	if dec.SyncFromCheckpoint != nil {
 		c.SyncFromCheckpoint = *dec.SyncFromCheckpoint
 	}
@@ -253,6 +351,12 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.FilterLogCacheSize != nil {
 		c.FilterLogCacheSize = *dec.FilterLogCacheSize
 	}
+	if dec.FilterMaxResults != nil {
+		c.FilterMaxResults = *dec.FilterMaxResults
+	}
+	if dec.FilterMaxBlocks != nil {
+		c.FilterMaxBlocks = *dec.FilterMaxBlocks
+	}
 	if dec.Miner != nil {
 		c.Miner = *dec.Miner
 	}
@@ -277,6 +381,18 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.RPCEVMTimeout != nil {
 		c.RPCEVMTimeout = *dec.RPCEVMTimeout
 	}
+	if dec.RPCTracerCPUTime != nil {
+		c.RPCTracerCPUTime = *dec.RPCTracerCPUTime
+	}
+	if dec.RPCTracerMemory != nil {
+		c.RPCTracerMemory = *dec.RPCTracerMemory
+	}
+	if dec.RPCTracerOutput != nil {
+		c.RPCTracerOutput = *dec.RPCTracerOutput
+	}
+	if dec.RPCTracers != nil {
+		c.RPCTracers = dec.RPCTracers
+	}
 	if dec.RPCTxFeeCap != nil {
 		c.RPCTxFeeCap = *dec.RPCTxFeeCap
 	}
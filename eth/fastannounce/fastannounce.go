@@ -0,0 +1,254 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+// Package fastannounce implements a UDP sidechannel between explicitly
+// configured pool nodes, announcing the headers of newly sealed blocks ahead of
+// the devp2p propagation. The bodies follow over devp2p.
+package fastannounce
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const (
+	// maxPacketSize is the maximum size of an announcement datagram, fitting a
+	// header in a single unfragmented packet on common links.
+	maxPacketSize = 1280
+
+	// macSize is the size of the authentication code prefixing the datagrams.
+	macSize = sha256.Size
+
+	// seenCacheSize is the number of recently announced block hashes remembered
+	// to deliver announcements arriving from multiple pool nodes only once.
+	seenCacheSize = 256
+)
+
+var (
+	announceOutMeter    = metrics.NewRegisteredMeter("eth/fastannounce/out", nil)    // Announcements sent to the pool nodes
+	announceInMeter     = metrics.NewRegisteredMeter("eth/fastannounce/in", nil)     // Announcements delivered from the pool nodes
+	announceDropMeter   = metrics.NewRegisteredMeter("eth/fastannounce/drop", nil)   // Datagrams failing authentication or decoding
+	announceDoubleMeter = metrics.NewRegisteredMeter("eth/fastannounce/double", nil) // Announcements of already delivered blocks
+)
+
+var (
+	errMissingSecret     = errors.New("fast announcement sidechannel requires a shared secret")
+	errUnauthenticated   = errors.New("unauthenticated announcement")
+	errOversizedAnnounce = errors.New("oversized announcement")
+)
+
+// Config are the settings of the announcement sidechannel.
+type Config struct {
+	Addr   string   `toml:",omitempty"` // UDP address to listen on, empty to disable the sidechannel
+	Peers  []string `toml:",omitempty"` // UDP addresses of the pool nodes to announce to and accept announcements from
+	Secret string   `toml:",omitempty"` // Shared secret authenticating the announcements
+}
+
+// Announcement is a newly sealed block announced by a pool node.
+type Announcement struct {
+	Sender enode.ID      // Devp2p identity of the announcing node, to retrieve the body from
+	Header *types.Header // Header of the sealed block
+	TD     *big.Int      // Total difficulty of the block
+}
+
+// Server sends and receives block announcements over UDP. Datagrams are only
+// accepted from the configured pool nodes and authenticated with the shared
+// secret, as UDP source addresses are trivially spoofed.
+type Server struct {
+	config  Config
+	self    enode.ID
+	deliver func(*Announcement)
+
+	peers   []*net.UDPAddr
+	allowed map[string]bool // IPs of the configured pool nodes
+
+	conn *net.UDPConn
+	seen lru.BasicLRU[common.Hash, struct{}]
+	lock sync.Mutex // Protects the seen cache
+	wg   sync.WaitGroup
+}
+
+// New creates an announcement sidechannel, delivering the announcements of the
+// pool nodes to the given callback. The self id is sent along so the receivers
+// know which devp2p peer to retrieve the body from.
+func New(config Config, self enode.ID, deliver func(*Announcement)) (*Server, error) {
+	if config.Secret == "" {
+		return nil, errMissingSecret
+	}
+	s := &Server{
+		config:  config,
+		self:    self,
+		deliver: deliver,
+		allowed: make(map[string]bool),
+		seen:    lru.NewBasicLRU[common.Hash, struct{}](seenCacheSize),
+	}
+	for _, peer := range config.Peers {
+		addr, err := net.ResolveUDPAddr("udp", peer)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fast announcement peer %q: %v", peer, err)
+		}
+		s.peers = append(s.peers, addr)
+		s.allowed[addr.IP.String()] = true
+	}
+	return s, nil
+}
+
+// Start opens the UDP socket and starts accepting announcements, implementing
+// node.Lifecycle.
+func (s *Server) Start() error {
+	addr, err := net.ResolveUDPAddr("udp", s.config.Addr)
+	if err != nil {
+		return err
+	}
+	if s.conn, err = net.ListenUDP("udp", addr); err != nil {
+		return err
+	}
+	s.wg.Add(1)
+	go s.loop()
+
+	log.Info("Started fast block announcements", "addr", s.conn.LocalAddr(), "peers", len(s.peers))
+	return nil
+}
+
+// Stop closes the UDP socket, implementing node.Lifecycle.
+func (s *Server) Stop() error {
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	s.wg.Wait()
+	return nil
+}
+
+// LocalAddr returns the address the sidechannel is listening on.
+func (s *Server) LocalAddr() net.Addr {
+	return s.conn.LocalAddr()
+}
+
+// Announce sends the header of a newly sealed block to all the pool nodes.
+func (s *Server) Announce(header *types.Header, td *big.Int) {
+	if s.conn == nil {
+		return
+	}
+	s.markSeen(header.Hash())
+
+	packet, err := s.encode(&Announcement{Sender: s.self, Header: header, TD: td})
+	if err != nil {
+		log.Warn("Failed to encode fast announcement", "number", header.Number, "err", err)
+		return
+	}
+	for _, peer := range s.peers {
+		if _, err := s.conn.WriteToUDP(packet, peer); err != nil {
+			log.Debug("Failed to send fast announcement", "peer", peer, "err", err)
+			continue
+		}
+		announceOutMeter.Mark(1)
+	}
+}
+
+// loop reads the announcements of the pool nodes until the socket is closed.
+func (s *Server) loop() {
+	defer s.wg.Done()
+
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, from, err := s.conn.ReadFromUDP(buf)
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			log.Debug("Failed to read fast announcement", "err", err)
+			continue
+		}
+		if !s.allowed[from.IP.String()] {
+			announceDropMeter.Mark(1)
+			continue
+		}
+		ann, err := s.decode(buf[:n])
+		if err != nil {
+			log.Debug("Dropped fast announcement", "from", from, "err", err)
+			announceDropMeter.Mark(1)
+			continue
+		}
+		if !s.markSeen(ann.Header.Hash()) {
+			announceDoubleMeter.Mark(1)
+			continue
+		}
+		announceInMeter.Mark(1)
+		s.deliver(ann)
+	}
+}
+
+// markSeen records a block as announced, returning whether it's the first time.
+func (s *Server) markSeen(hash common.Hash) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.seen.Contains(hash) {
+		return false
+	}
+	s.seen.Add(hash, struct{}{})
+	return true
+}
+
+// encode packs an announcement into an authenticated datagram.
+func (s *Server) encode(ann *Announcement) ([]byte, error) {
+	payload, err := rlp.EncodeToBytes(ann)
+	if err != nil {
+		return nil, err
+	}
+	if macSize+len(payload) > maxPacketSize {
+		return nil, errOversizedAnnounce
+	}
+	return append(s.mac(payload), payload...), nil
+}
+
+// decode authenticates and unpacks an announcement datagram.
+func (s *Server) decode(packet []byte) (*Announcement, error) {
+	if len(packet) < macSize {
+		return nil, errUnauthenticated
+	}
+	mac, payload := packet[:macSize], packet[macSize:]
+	if !hmac.Equal(mac, s.mac(payload)) {
+		return nil, errUnauthenticated
+	}
+	ann := new(Announcement)
+	if err := rlp.DecodeBytes(payload, ann); err != nil {
+		return nil, err
+	}
+	if err := ann.Header.SanityCheck(); err != nil {
+		return nil, err
+	}
+	if tdlen := ann.TD.BitLen(); tdlen > 100 {
+		return nil, fmt.Errorf("too large block TD: bitlen %d", tdlen)
+	}
+	return ann, nil
+}
+
+// mac computes the authentication code of a payload with the shared secret.
+func (s *Server) mac(payload []byte) []byte {
+	h := hmac.New(sha256.New, []byte(s.config.Secret))
+	h.Write(payload)
+	return h.Sum(nil)
+}
@@ -0,0 +1,91 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package fastannounce
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+// newTestServer starts a sidechannel on a random local port, delivering the
+// announcements into the returned channel.
+func newTestServer(t *testing.T, secret string, peers ...string) (*Server, chan *Announcement) {
+	t.Helper()
+
+	sink := make(chan *Announcement, 4)
+	srv, err := New(Config{Addr: "127.0.0.1:0", Peers: peers, Secret: secret}, enode.ID{0x01}, func(ann *Announcement) { sink <- ann })
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	t.Cleanup(func() { srv.Stop() })
+	return srv, sink
+}
+
+// Tests that announcements are delivered once to the pool nodes sharing the
+// secret, and dropped by the ones not sharing it.
+func TestAnnounce(t *testing.T) {
+	// The receivers only accept datagrams from the IPs of their configured peers
+	good, goodSink := newTestServer(t, "secret", "127.0.0.1:1")
+	bad, badSink := newTestServer(t, "other", "127.0.0.1:1")
+
+	sender, err := New(Config{Addr: "127.0.0.1:0", Peers: []string{good.LocalAddr().String(), bad.LocalAddr().String()}, Secret: "secret"}, enode.ID{0x02}, nil)
+	if err != nil {
+		t.Fatalf("failed to create sender: %v", err)
+	}
+	if err := sender.Start(); err != nil {
+		t.Fatalf("failed to start sender: %v", err)
+	}
+	defer sender.Stop()
+
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(2), Extra: []byte("pool")}
+	sender.Announce(header, big.NewInt(3))
+
+	select {
+	case ann := <-goodSink:
+		if ann.Header.Hash() != header.Hash() {
+			t.Fatalf("announced header mismatch: have %x, want %x", ann.Header.Hash(), header.Hash())
+		}
+		if ann.Sender != (enode.ID{0x02}) {
+			t.Fatalf("announcement sender mismatch: have %v, want %v", ann.Sender, enode.ID{0x02})
+		}
+		if ann.TD.Cmp(big.NewInt(3)) != 0 {
+			t.Fatalf("announced td mismatch: have %v, want %v", ann.TD, 3)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("announcement not delivered")
+	}
+	// Repeated announcements are delivered only once
+	sender.Announce(header, big.NewInt(3))
+
+	select {
+	case <-goodSink:
+		t.Fatalf("repeated announcement delivered")
+	case <-badSink:
+		t.Fatalf("unauthenticated announcement delivered")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// Tests that the sidechannel refuses to run without a shared secret.
+func TestMissingSecret(t *testing.T) {
+	if _, err := New(Config{Addr: "127.0.0.1:0"}, enode.ID{}, nil); err != errMissingSecret {
+		t.Fatalf("error mismatch: have %v, want %v", err, errMissingSecret)
+	}
+}
@@ -14,6 +14,7 @@ package filters
 
 import (
 	"context"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -24,11 +25,21 @@ import (
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/misc"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
+// defaultLogsPageSize is the number of logs returned by a page of a paginated
+// log query if no result limit is configured.
+const defaultLogsPageSize = 10000
+
+var (
+	errExceedLogLimits = errors.New("query exceeds the log limits of the node, use eth_getLogsPage")
+	errInvalidCursor   = errors.New("invalid log query cursor")
+)
+
 // filter is a helper struct that holds meta information over the filter type
 // and associated subscription in the event system.
 type filter struct {
@@ -174,6 +185,80 @@ func (api *FilterAPI) NewPendingTransactions(ctx context.Context, fullTx *bool)
 	return rpcSub, nil
 }
 
+// PendingTxCriteria filters the transactions streamed by the pending transaction
+// body subscription. Empty address lists match any transaction.
+type PendingTxCriteria struct {
+	To     []common.Address `json:"to"`     // Recipients to match, contract creations never match
+	From   []common.Address `json:"from"`   // Senders to match
+	MinTip *hexutil.Big     `json:"minTip"` // Minimum effective tip at the pending base fee
+}
+
+// matches returns whether the transaction passes the criteria.
+func (crit *PendingTxCriteria) matches(tx *types.Transaction, signer types.Signer, baseFee *big.Int) bool {
+	if crit == nil {
+		return true
+	}
+	if len(crit.To) > 0 {
+		if tx.To() == nil || !includes(crit.To, *tx.To()) {
+			return false
+		}
+	}
+	if len(crit.From) > 0 {
+		from, err := types.Sender(signer, tx)
+		if err != nil || !includes(crit.From, from) {
+			return false
+		}
+	}
+	if crit.MinTip != nil && tx.EffectiveGasTipIntCmp((*big.Int)(crit.MinTip), baseFee) < 0 {
+		return false
+	}
+	return true
+}
+
+// PendingTransactionsWithBody creates a subscription that is triggered each time
+// a transaction passing the criteria enters the transaction pool, streaming the
+// full transaction instead of its hash.
+func (api *FilterAPI) PendingTransactionsWithBody(ctx context.Context, crit *PendingTxCriteria) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		txs := make(chan []*types.Transaction, 128)
+		pendingTxSub := api.events.SubscribePendingTxs(txs)
+		chainConfig := api.sys.backend.ChainConfig()
+		signer := types.LatestSigner(chainConfig)
+
+		for {
+			select {
+			case txs := <-txs:
+				latest := api.sys.backend.CurrentHeader()
+
+				var baseFee *big.Int
+				if chainConfig.IsLondon(new(big.Int).Add(latest.Number, common.Big1)) {
+					baseFee = misc.CalcBaseFee(chainConfig, latest)
+				}
+				for _, tx := range txs {
+					if crit.matches(tx, signer, baseFee) {
+						notifier.Notify(rpcSub.ID, ethapi.NewRPCPendingTransaction(tx, latest, chainConfig))
+					}
+				}
+			case <-rpcSub.Err():
+				pendingTxSub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				pendingTxSub.Unsubscribe()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
 // NewBlockFilter creates a filter that fetches blocks that are imported into the chain.
 // It is part of the filter package since polling goes with eth_getFilterChanges.
 func (api *FilterAPI) NewBlockFilter() rpc.ID {
@@ -208,46 +293,74 @@ func (api *FilterAPI) NewBlockFilter() rpc.ID {
 }
 
 // NewHeads send a notification each time a new (header) block is appended to the chain.
-func (api *FilterAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+// The optional settings choose how notifications are buffered for a slow subscriber
+// and the block to replay the headers of the chain from.
+func (api *FilterAPI) NewHeads(ctx context.Context, opts *SubscriptionOptions) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
 	}
-
+	stream, err := api.newStream(notifier, opts)
+	if err != nil {
+		return nil, err
+	}
+	stream.fetch = func(ctx context.Context, number uint64) ([]notification, error) {
+		header, err := api.sys.backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
+		if err != nil {
+			return nil, err
+		}
+		if header == nil {
+			return nil, fmt.Errorf("block #%d not found", number)
+		}
+		return []notification{{number: number, value: header}}, nil
+	}
 	rpcSub := notifier.CreateSubscription()
+	stream.notify = func(value interface{}) error {
+		return notifier.Notify(rpcSub.ID, value)
+	}
 
 	go func() {
 		headers := make(chan *types.Header)
 		headersSub := api.events.SubscribeNewHeads(headers)
+		defer headersSub.Unsubscribe()
 
-		for {
-			select {
-			case h := <-headers:
-				notifier.Notify(rpcSub.ID, h)
-			case <-rpcSub.Err():
-				headersSub.Unsubscribe()
-				return
-			case <-notifier.Closed():
-				headersSub.Unsubscribe()
-				return
-			}
-		}
+		streamEvents(stream, headers, func(h *types.Header) []notification {
+			return []notification{{number: h.Number.Uint64(), value: h}}
+		}, rpcSub.Err(), notifier.Closed())
 	}()
 
 	return rpcSub, nil
 }
 
 // Logs creates a subscription that fires for all new log that match the given filter criteria.
-func (api *FilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc.Subscription, error) {
+// The optional settings choose how notifications are buffered for a slow subscriber
+// and the block to replay the matching logs of the chain from.
+func (api *FilterAPI) Logs(ctx context.Context, crit FilterCriteria, opts *SubscriptionOptions) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
 	}
-
+	stream, err := api.newStream(notifier, opts)
+	if err != nil {
+		return nil, err
+	}
+	if (!stream.live || stream.policy == PolicyPause) && crit.ToBlock != nil && crit.ToBlock.Int64() == rpc.PendingBlockNumber.Int64() {
+		return nil, errResumePending
+	}
+	stream.fetch = func(ctx context.Context, number uint64) ([]notification, error) {
+		logs, err := api.sys.NewRangeFilter(int64(number), int64(number), crit.Addresses, crit.Topics).Logs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return logNotifications(logs), nil
+	}
 	var (
 		rpcSub      = notifier.CreateSubscription()
 		matchedLogs = make(chan []*types.Log)
 	)
+	stream.notify = func(value interface{}) error {
+		return notifier.Notify(rpcSub.ID, value)
+	}
 
 	logsSub, err := api.events.SubscribeLogs(ethereum.FilterQuery(crit), matchedLogs)
 	if err != nil {
@@ -255,26 +368,36 @@ func (api *FilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc.Subsc
 	}
 
 	go func() {
-		for {
-			select {
-			case logs := <-matchedLogs:
-				for _, log := range logs {
-					log := log
-					notifier.Notify(rpcSub.ID, &log)
-				}
-			case <-rpcSub.Err(): // client send an unsubscribe request
-				logsSub.Unsubscribe()
-				return
-			case <-notifier.Closed(): // connection dropped
-				logsSub.Unsubscribe()
-				return
-			}
-		}
+		defer logsSub.Unsubscribe()
+		streamEvents(stream, matchedLogs, logNotifications, rpcSub.Err(), notifier.Closed())
 	}()
 
 	return rpcSub, nil
 }
 
+// newStream creates the stream delivering the notifications of a subscription.
+func (api *FilterAPI) newStream(notifier *rpc.Notifier, opts *SubscriptionOptions) (*subscriptionStream, error) {
+	head := func() uint64 {
+		return api.sys.backend.CurrentHeader().Number.Uint64()
+	}
+	stream, err := newSubscriptionStream(opts, head())
+	if err != nil {
+		return nil, err
+	}
+	stream.head = head
+	stream.disconnect = notifier.Disconnect
+	return stream, nil
+}
+
+// logNotifications converts a batch of logs into subscription notifications.
+func logNotifications(logs []*types.Log) []notification {
+	items := make([]notification, len(logs))
+	for i, log := range logs {
+		items[i] = notification{number: log.BlockNumber, value: log, removed: log.Removed}
+	}
+	return items
+}
+
 // FilterCriteria represents a request to create a new filter.
 // Same as ethereum.FilterQuery but with UnmarshalJSON() method.
 type FilterCriteria ethereum.FilterQuery
@@ -324,31 +447,76 @@ func (api *FilterAPI) NewFilter(crit FilterCriteria) (rpc.ID, error) {
 
 // GetLogs returns logs matching the given argument that are stored within the state.
 func (api *FilterAPI) GetLogs(ctx context.Context, crit FilterCriteria) ([]*types.Log, error) {
-	var filter *Filter
-	if crit.BlockHash != nil {
-		// Block filter requested, construct a single-shot filter
-		filter = api.sys.NewBlockFilter(*crit.BlockHash, crit.Addresses, crit.Topics)
-	} else {
-		// Convert the RPC block numbers into internal representations
-		begin := rpc.LatestBlockNumber.Int64()
-		if crit.FromBlock != nil {
-			begin = crit.FromBlock.Int64()
-		}
-		end := rpc.LatestBlockNumber.Int64()
-		if crit.ToBlock != nil {
-			end = crit.ToBlock.Int64()
-		}
-		// Construct the range filter
-		filter = api.sys.NewRangeFilter(begin, end, crit.Addresses, crit.Topics)
-	}
+	filter := api.criteriaFilter(crit)
+	filter.SetLimits(api.sys.cfg.MaxLogResults, api.sys.cfg.MaxBlockRange)
+
 	// Run the filter and return all the logs
 	logs, err := filter.Logs(ctx)
 	if err != nil {
 		return nil, err
 	}
+	if filter.Next() != nil {
+		return nil, errExceedLogLimits
+	}
 	return returnLogs(logs), err
 }
 
+// LogsPage is a page of the results of a paginated log query.
+type LogsPage struct {
+	Logs   []*types.Log  `json:"logs"`
+	Cursor hexutil.Bytes `json:"cursor,omitempty"` // Continuation token of the query, absent on the last page
+}
+
+// GetLogsPage returns logs matching the given argument like GetLogs, but stops
+// at the log limits of the node instead of failing. If the results were cut
+// short, the page carries a cursor to pass in to continue the query from.
+func (api *FilterAPI) GetLogsPage(ctx context.Context, crit FilterCriteria, cursor *hexutil.Bytes) (*LogsPage, error) {
+	filter := api.criteriaFilter(crit)
+
+	limit := api.sys.cfg.MaxLogResults
+	if limit == 0 {
+		limit = defaultLogsPageSize
+	}
+	filter.SetLimits(limit, api.sys.cfg.MaxBlockRange)
+	if cursor != nil {
+		if len(*cursor) != 12 {
+			return nil, errInvalidCursor
+		}
+		filter.SetCursor(LogCursor{
+			Block: binary.BigEndian.Uint64(*cursor),
+			Index: uint(binary.BigEndian.Uint32((*cursor)[8:])),
+		})
+	}
+	logs, err := filter.Logs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	page := &LogsPage{Logs: returnLogs(logs)}
+	if next := filter.Next(); next != nil {
+		page.Cursor = binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint64(nil, next.Block), uint32(next.Index))
+	}
+	return page, nil
+}
+
+// criteriaFilter constructs a single-shot filter of the given criteria.
+func (api *FilterAPI) criteriaFilter(crit FilterCriteria) *Filter {
+	if crit.BlockHash != nil {
+		// Block filter requested, construct a single-shot filter
+		return api.sys.NewBlockFilter(*crit.BlockHash, crit.Addresses, crit.Topics)
+	}
+	// Convert the RPC block numbers into internal representations
+	begin := rpc.LatestBlockNumber.Int64()
+	if crit.FromBlock != nil {
+		begin = crit.FromBlock.Int64()
+	}
+	end := rpc.LatestBlockNumber.Int64()
+	if crit.ToBlock != nil {
+		end = crit.ToBlock.Int64()
+	}
+	// Construct the range filter
+	return api.sys.NewRangeFilter(begin, end, crit.Addresses, crit.Topics)
+}
+
 // UninstallFilter removes the filter with the given filter id.
 func (api *FilterAPI) UninstallFilter(id rpc.ID) bool {
 	api.filtersMu.Lock()
@@ -375,28 +543,17 @@ func (api *FilterAPI) GetFilterLogs(ctx context.Context, id rpc.ID) ([]*types.Lo
 		return nil, fmt.Errorf("filter not found")
 	}
 
-	var filter *Filter
-	if f.crit.BlockHash != nil {
-		// Block filter requested, construct a single-shot filter
-		filter = api.sys.NewBlockFilter(*f.crit.BlockHash, f.crit.Addresses, f.crit.Topics)
-	} else {
-		// Convert the RPC block numbers into internal representations
-		begin := rpc.LatestBlockNumber.Int64()
-		if f.crit.FromBlock != nil {
-			begin = f.crit.FromBlock.Int64()
-		}
-		end := rpc.LatestBlockNumber.Int64()
-		if f.crit.ToBlock != nil {
-			end = f.crit.ToBlock.Int64()
-		}
-		// Construct the range filter
-		filter = api.sys.NewRangeFilter(begin, end, f.crit.Addresses, f.crit.Topics)
-	}
+	filter := api.criteriaFilter(f.crit)
+	filter.SetLimits(api.sys.cfg.MaxLogResults, api.sys.cfg.MaxBlockRange)
+
 	// Run the filter and return all the logs
 	logs, err := filter.Logs(ctx)
 	if err != nil {
 		return nil, err
 	}
+	if filter.Next() != nil {
+		return nil, errExceedLogLimits
+	}
 	return returnLogs(logs), nil
 }
 
@@ -15,9 +15,13 @@ package filters
 import (
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
@@ -179,3 +183,43 @@ func TestUnmarshalJSONNewFilterArgs(t *testing.T) {
 		t.Fatalf("expected 0 topics, got %d topics", len(test7.Topics[2]))
 	}
 }
+
+func TestPendingTxCriteria(t *testing.T) {
+	var (
+		key, _  = crypto.GenerateKey()
+		sender  = crypto.PubkeyToAddress(key.PublicKey)
+		target  = common.HexToAddress("0xb794f5ea0ba39494ce83a213fffba74279579268")
+		signer  = types.LatestSignerForChainID(big.NewInt(1))
+		baseFee = big.NewInt(100)
+	)
+	tx, _ := types.SignNewTx(key, signer, &types.DynamicFeeTx{
+		ChainID:   big.NewInt(1),
+		To:        &target,
+		GasTipCap: big.NewInt(20),
+		GasFeeCap: big.NewInt(110),
+		Gas:       21000,
+	})
+	create, _ := types.SignNewTx(key, signer, &types.LegacyTx{GasPrice: big.NewInt(200), Gas: 53000})
+
+	tests := []struct {
+		crit *PendingTxCriteria
+		tx   *types.Transaction
+		want bool
+	}{
+		{nil, tx, true},
+		{&PendingTxCriteria{}, tx, true},
+		{&PendingTxCriteria{To: []common.Address{target}}, tx, true},
+		{&PendingTxCriteria{To: []common.Address{sender}}, tx, false},
+		{&PendingTxCriteria{To: []common.Address{target}}, create, false},
+		{&PendingTxCriteria{From: []common.Address{sender}}, tx, true},
+		{&PendingTxCriteria{From: []common.Address{target}}, tx, false},
+		{&PendingTxCriteria{MinTip: (*hexutil.Big)(big.NewInt(10))}, tx, true},
+		{&PendingTxCriteria{MinTip: (*hexutil.Big)(big.NewInt(11))}, tx, false},
+		{&PendingTxCriteria{MinTip: (*hexutil.Big)(big.NewInt(100))}, create, true},
+	}
+	for i, tt := range tests {
+		if have := tt.crit.matches(tt.tx, signer, baseFee); have != tt.want {
+			t.Errorf("test %d: match mismatch: have %v, want %v", i, have, tt.want)
+		}
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"context"
 	"errors"
 	"math/big"
+	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/bloombits"
@@ -23,6 +24,17 @@ import (
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
+// parallelMatchers is the number of bloom sections matched concurrently by a
+// range filter.
+const parallelMatchers = 4
+
+// LogCursor is the position of a log in the chain, at which a query truncated
+// by its limits continues.
+type LogCursor struct {
+	Block uint64 // Number of the block containing the next log
+	Index uint   // Index of the next log within its block
+}
+
 // Filter can be used to retrieve and filter logs.
 type Filter struct {
 	sys *FilterSystem
@@ -32,8 +44,15 @@ type Filter struct {
 
 	block      *common.Hash // Block hash if filtering a single block
 	begin, end int64        // Range interval if filtering multiple blocks
+	cursor     *LogCursor   // Position of the first log to return, if continuing a query
 
-	matcher *bloombits.Matcher
+	bloomFilters [][][]byte // Flattened bloombits filter of the criteria
+	bloomSize    uint64     // Number of blocks per bloom section
+
+	maxLogs   int        // Maximum number of logs to return, 0 if unlimited
+	maxBlocks uint64     // Maximum number of blocks to search, 0 if unlimited
+	last      uint64     // Last block of the queried range, even if cut short
+	next      *LogCursor // Position to continue from if the limits were hit
 }
 
 // NewRangeFilter creates a new filter which uses a bloom filter on blocks to
@@ -62,13 +81,38 @@ func (sys *FilterSystem) NewRangeFilter(begin, end int64, addresses []common.Add
 	// Create a generic filter and convert it into a range filter
 	filter := newFilter(sys, addresses, topics)
 
-	filter.matcher = bloombits.NewMatcher(size, filters)
+	filter.bloomFilters = filters
+	filter.bloomSize = size
 	filter.begin = begin
 	filter.end = end
 
 	return filter
 }
 
+// SetLimits bounds the number of logs returned and the number of blocks
+// searched by the filter. Hitting either limit truncates the results, with
+// Next returning the position to continue from. Zero means no limit.
+func (f *Filter) SetLimits(maxLogs int, maxBlocks uint64) {
+	f.maxLogs = maxLogs
+	f.maxBlocks = maxBlocks
+}
+
+// SetCursor moves the start of a range filter to the position returned by a
+// previous, truncated run of the same query.
+func (f *Filter) SetCursor(cursor LogCursor) {
+	f.begin = int64(cursor.Block)
+	f.cursor = &cursor
+}
+
+// Next returns the position at which the logs of a run truncated by the limits
+// continue, or nil if the run completed.
+func (f *Filter) Next() *LogCursor {
+	if f.next == nil || f.next.Block > f.last {
+		return nil
+	}
+	return f.next
+}
+
 // NewBlockFilter creates a new filter which directly inspects the contents of
 // a block to figure out whether it is interesting or not.
 func (sys *FilterSystem) NewBlockFilter(block common.Hash, addresses []common.Address, topics [][]common.Hash) *Filter {
@@ -100,7 +144,13 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 		if header == nil {
 			return nil, errors.New("unknown block")
 		}
-		return f.blockLogs(ctx, header)
+		found, err := f.blockLogs(ctx, header)
+		if err != nil {
+			return nil, err
+		}
+		f.last = header.Number.Uint64()
+		logs, _ := f.appendLogs(nil, found, f.last)
+		return logs, nil
 	}
 	// Short-cut if all we care about is pending logs
 	if f.begin == rpc.PendingBlockNumber.Int64() {
@@ -149,6 +199,13 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 	if f.end, err = resolveSpecial(f.end); err != nil {
 		return nil, err
 	}
+	// Cut the range short if it spans more blocks than allowed
+	f.last = uint64(f.end)
+	if f.maxBlocks > 0 && f.begin <= f.end && uint64(f.end-f.begin) >= f.maxBlocks {
+		f.end = f.begin + int64(f.maxBlocks) - 1
+		f.next = &LogCursor{Block: uint64(f.end) + 1}
+		pending = false
+	}
 	// Gather all indexed logs, and finish with non indexed ones
 	var (
 		logs           []*types.Log
@@ -156,34 +213,148 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 		size, sections = f.sys.backend.BloomStatus()
 	)
 	if indexed := sections * size; indexed > uint64(f.begin) {
+		var full bool
 		if indexed > end {
-			logs, err = f.indexedLogs(ctx, end)
+			logs, full, err = f.indexedLogs(ctx, logs, end)
 		} else {
-			logs, err = f.indexedLogs(ctx, indexed-1)
+			logs, full, err = f.indexedLogs(ctx, logs, indexed-1)
 		}
-		if err != nil {
+		if err != nil || full {
 			return logs, err
 		}
 	}
-	rest, err := f.unindexedLogs(ctx, end)
-	logs = append(logs, rest...)
+	logs, full, err := f.unindexedLogs(ctx, logs, end)
+	if err != nil || full {
+		return logs, err
+	}
 	if pending {
 		pendingLogs, err := f.pendingLogs()
 		if err != nil {
 			return nil, err
 		}
 		logs = append(logs, pendingLogs...)
+		if f.maxLogs > 0 && len(logs) > f.maxLogs {
+			// Pending logs can't be continued from, drop the excess
+			logs = logs[:f.maxLogs]
+		}
 	}
-	return logs, err
+	return logs, nil
+}
+
+// appendLogs adds the logs found in a block to the results, dropping the ones
+// preceding the cursor the filter started from. If the result limit is hit,
+// the excess logs are dropped, the position of the next one is recorded and
+// true is returned.
+func (f *Filter) appendLogs(logs []*types.Log, found []*types.Log, number uint64) ([]*types.Log, bool) {
+	if f.cursor != nil && f.cursor.Block == number {
+		var kept []*types.Log
+		for _, log := range found {
+			if log.Index >= f.cursor.Index {
+				kept = append(kept, log)
+			}
+		}
+		found = kept
+	}
+	if f.maxLogs == 0 || len(logs)+len(found) < f.maxLogs {
+		return append(logs, found...), false
+	}
+	var (
+		n    = f.maxLogs - len(logs)
+		next = LogCursor{Block: number + 1}
+	)
+	if n < len(found) {
+		next = LogCursor{Block: number, Index: found[n].Index}
+	}
+	f.next = &next
+	return append(logs, found[:n]...), true
+}
+
+// matchSegment is a section aligned part of the range of a filter, matched by
+// its own bloombits matcher.
+type matchSegment struct {
+	begin, end uint64
+	numbers    []uint64      // Numbers of the blocks potentially matching the filter
+	err        error         // Failure matching the segment
+	done       chan struct{} // Closed when the segment has been matched
 }
 
 // indexedLogs returns the logs matching the filter criteria based on the bloom
-// bits indexed available locally or via the network.
-func (f *Filter) indexedLogs(ctx context.Context, end uint64) ([]*types.Log, error) {
-	// Create a matcher session and request servicing from the backend
+// bits indexed available locally or via the network. The range is split into
+// bloom sections matched concurrently, their logs being collected in order.
+func (f *Filter) indexedLogs(ctx context.Context, logs []*types.Log, end uint64) ([]*types.Log, bool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var segments []*matchSegment
+	for begin := uint64(f.begin); begin <= end; {
+		last := (begin/f.bloomSize+1)*f.bloomSize - 1
+		if last > end {
+			last = end
+		}
+		segments = append(segments, &matchSegment{begin: begin, end: last, done: make(chan struct{})})
+		begin = last + 1
+	}
+	// Match the segments in the background, a limited number at a time
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		slots := make(chan struct{}, parallelMatchers)
+		for _, segment := range segments {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			wg.Add(1)
+			go func(segment *matchSegment) {
+				defer wg.Done()
+				defer func() { <-slots }()
+				defer close(segment.done)
+
+				segment.numbers, segment.err = f.matchSegment(ctx, segment.begin, segment.end)
+			}(segment)
+		}
+	}()
+	// Pull the logs of the matched blocks, segment by segment
+	for _, segment := range segments {
+		select {
+		case <-segment.done:
+		case <-ctx.Done():
+			return logs, false, ctx.Err()
+		}
+		if segment.err != nil {
+			return logs, false, segment.err
+		}
+		for _, number := range segment.numbers {
+			// Retrieve the suggested block and pull any truly matching logs
+			header, err := f.sys.backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
+			if header == nil || err != nil {
+				return logs, false, err
+			}
+			found, err := f.checkMatches(ctx, header)
+			if err != nil {
+				return logs, false, err
+			}
+			var full bool
+			if logs, full = f.appendLogs(logs, found, number); full {
+				return logs, true, nil
+			}
+		}
+		f.begin = int64(segment.end) + 1
+	}
+	return logs, false, nil
+}
+
+// matchSegment runs a bloombits matcher over a range of blocks, returning the
+// numbers of the blocks potentially matching the filter.
+func (f *Filter) matchSegment(ctx context.Context, begin, end uint64) ([]uint64, error) {
 	matches := make(chan uint64, 64)
 
-	session, err := f.matcher.Start(ctx, uint64(f.begin), end, matches)
+	session, err := bloombits.NewMatcher(f.bloomSize, f.bloomFilters).Start(ctx, begin, end, matches)
 	if err != nil {
 		return nil, err
 	}
@@ -191,59 +362,43 @@ func (f *Filter) indexedLogs(ctx context.Context, end uint64) ([]*types.Log, err
 
 	f.sys.backend.ServiceFilter(ctx, session)
 
-	// Iterate over the matches until exhausted or context closed
-	var logs []*types.Log
-
+	var numbers []uint64
 	for {
 		select {
 		case number, ok := <-matches:
 			// Abort if all matches have been fulfilled
 			if !ok {
-				err := session.Error()
-				if err == nil {
-					f.begin = int64(end) + 1
-				}
-				return logs, err
-			}
-			f.begin = int64(number) + 1
-
-			// Retrieve the suggested block and pull any truly matching logs
-			header, err := f.sys.backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
-			if header == nil || err != nil {
-				return logs, err
+				return numbers, session.Error()
 			}
-			found, err := f.checkMatches(ctx, header)
-			if err != nil {
-				return logs, err
-			}
-			logs = append(logs, found...)
+			numbers = append(numbers, number)
 
 		case <-ctx.Done():
-			return logs, ctx.Err()
+			return numbers, ctx.Err()
 		}
 	}
 }
 
 // unindexedLogs returns the logs matching the filter criteria based on raw block
 // iteration and bloom matching.
-func (f *Filter) unindexedLogs(ctx context.Context, end uint64) ([]*types.Log, error) {
-	var logs []*types.Log
-
+func (f *Filter) unindexedLogs(ctx context.Context, logs []*types.Log, end uint64) ([]*types.Log, bool, error) {
 	for ; f.begin <= int64(end); f.begin++ {
 		if f.begin%10 == 0 && ctx.Err() != nil {
-			return logs, ctx.Err()
+			return logs, false, ctx.Err()
 		}
 		header, err := f.sys.backend.HeaderByNumber(ctx, rpc.BlockNumber(f.begin))
 		if header == nil || err != nil {
-			return logs, err
+			return logs, false, err
 		}
 		found, err := f.blockLogs(ctx, header)
 		if err != nil {
-			return logs, err
+			return logs, false, err
+		}
+		var full bool
+		if logs, full = f.appendLogs(logs, found, uint64(f.begin)); full {
+			return logs, true, nil
 		}
-		logs = append(logs, found...)
 	}
-	return logs, nil
+	return logs, false, nil
 }
 
 // blockLogs returns the logs matching the filter criteria within a single block.
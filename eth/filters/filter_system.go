@@ -37,8 +37,10 @@ import (
 
 // Config represents the configuration of the filter system.
 type Config struct {
-	LogCacheSize int           // maximum number of cached blocks (default: 32)
-	Timeout      time.Duration // how long filters stay active (default: 5min)
+	LogCacheSize  int           // maximum number of cached blocks (default: 32)
+	Timeout       time.Duration // how long filters stay active (default: 5min)
+	MaxLogResults int           // maximum number of logs returned by a query (0 = unlimited)
+	MaxBlockRange uint64        // maximum number of blocks searched by a query (0 = unlimited)
 }
 
 func (cfg Config) withDefaults() Config {
@@ -19,8 +19,10 @@ import (
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/bloombits"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -235,3 +237,142 @@ func TestFilters(t *testing.T) {
 		}
 	}
 }
+
+// TestFilterLimits tests that queries truncated by the result and block range
+// limits continue from their cursors, across indexed and unindexed blocks.
+func TestFilterLimits(t *testing.T) {
+	var (
+		db, _      = rawdb.NewLevelDBDatabase(t.TempDir(), 0, 0, "", false)
+		backend, _ = newTestFilterSystem(t, db, Config{})
+		key1, _    = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr       = crypto.PubkeyToAddress(key1.PublicKey)
+		topic      = common.BytesToHash([]byte("topic"))
+		size       = params.BloomBitsBlocks
+
+		gspec = &core.Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   core.GenesisAlloc{addr: {Balance: big.NewInt(1000000)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		// Number of logs emitted at each block, spanning two indexed sections
+		// and the unindexed tail
+		emits = map[uint64]int{10: 2, size - 1: 1, size: 3, 2*size - 100: 1, 2*size + 3: 2}
+	)
+	defer db.Close()
+
+	_, chain, receipts := core.GenerateChainWithGenesis(gspec, ethash.NewFaker(), int(2*size+10), func(i int, gen *core.BlockGen) {
+		count := emits[uint64(i)+1]
+		if count == 0 {
+			return
+		}
+		receipt := types.NewReceipt(nil, false, 0)
+		for j := 0; j < count; j++ {
+			receipt.Logs = append(receipt.Logs, &types.Log{Address: addr, Topics: []common.Hash{topic}})
+		}
+		gen.AddUncheckedReceipt(receipt)
+		gen.AddUncheckedTx(types.NewTransaction(uint64(i), common.HexToAddress("0x1"), big.NewInt(1), 1, gen.BaseFee(), nil))
+	})
+	gspec.MustCommit(db)
+	for i, block := range chain {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(db, block.Hash())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+	// Index the bloom bits of the first two sections, uncompressed as the test
+	// backend serves them as is
+	for section := uint64(0); section < 2; section++ {
+		gen, err := bloombits.NewGenerator(uint(size))
+		if err != nil {
+			t.Fatalf("failed to create generator: %v", err)
+		}
+		for i := section * size; i < (section+1)*size; i++ {
+			header := rawdb.ReadHeader(db, rawdb.ReadCanonicalHash(db, i), i)
+			gen.AddBloom(uint(i-section*size), header.Bloom)
+		}
+		head := rawdb.ReadCanonicalHash(db, (section+1)*size-1)
+		for i := 0; i < types.BloomBitLength; i++ {
+			bits, err := gen.Bitset(uint(i))
+			if err != nil {
+				t.Fatalf("failed to retrieve bitset: %v", err)
+			}
+			rawdb.WriteBloomBits(db, uint(i), section, head, bits)
+		}
+	}
+	backend.sections = 2
+
+	var want []LogCursor
+	for number := uint64(0); number <= 2*size+10; number++ {
+		for i := 0; i < emits[number]; i++ {
+			want = append(want, LogCursor{Block: number, Index: uint(i)})
+		}
+	}
+	for _, limits := range []struct {
+		logs   int
+		blocks uint64
+	}{
+		{0, 0}, {1, 0}, {2, 0}, {4, 0}, {0, 100}, {0, size}, {2, 3000},
+	} {
+		var (
+			sys    = NewFilterSystem(backend, Config{})
+			have   []LogCursor
+			cursor *LogCursor
+		)
+		for runs := 0; ; runs++ {
+			if runs > len(want)+int(2*size+10) {
+				t.Fatalf("limits %+v: query doesn't terminate", limits)
+			}
+			filter := sys.NewRangeFilter(0, -1, []common.Address{addr}, [][]common.Hash{{topic}})
+			filter.SetLimits(limits.logs, limits.blocks)
+			if cursor != nil {
+				filter.SetCursor(*cursor)
+			}
+			logs, err := filter.Logs(context.Background())
+			if err != nil {
+				t.Fatalf("limits %+v: query failed: %v", limits, err)
+			}
+			if limits.logs > 0 && len(logs) > limits.logs {
+				t.Fatalf("limits %+v: too many logs: have %d", limits, len(logs))
+			}
+			for _, log := range logs {
+				have = append(have, LogCursor{Block: log.BlockNumber, Index: log.Index})
+			}
+			if cursor = filter.Next(); cursor == nil {
+				break
+			}
+		}
+		if !reflect.DeepEqual(have, want) {
+			t.Errorf("limits %+v: logs mismatch: have %v, want %v", limits, have, want)
+		}
+	}
+	// Check that the API pages through the limited results, but refuses to
+	// return them from plain queries
+	api := NewFilterAPI(NewFilterSystem(backend, Config{MaxLogResults: 3}), false)
+	crit := FilterCriteria{FromBlock: big.NewInt(0), Addresses: []common.Address{addr}}
+	if _, err := api.GetLogs(context.Background(), crit); err != errExceedLogLimits {
+		t.Fatalf("limit error mismatch: have %v, want %v", err, errExceedLogLimits)
+	}
+	var (
+		have   []LogCursor
+		cursor *hexutil.Bytes
+	)
+	for {
+		page, err := api.GetLogsPage(context.Background(), crit, cursor)
+		if err != nil {
+			t.Fatalf("page query failed: %v", err)
+		}
+		for _, log := range page.Logs {
+			have = append(have, LogCursor{Block: log.BlockNumber, Index: log.Index})
+		}
+		if page.Cursor == nil {
+			break
+		}
+		cursor = &page.Cursor
+	}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("paged logs mismatch: have %v, want %v", have, want)
+	}
+	if _, err := api.GetLogsPage(context.Background(), crit, &hexutil.Bytes{0x01}); err != errInvalidCursor {
+		t.Errorf("cursor error mismatch: have %v, want %v", err, errInvalidCursor)
+	}
+}
@@ -0,0 +1,276 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package filters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// Buffering policies of subscriptions, deciding what happens to the events of a
+// subscriber falling behind its notifications.
+const (
+	PolicyDropOldest = "drop-oldest" // Drop the oldest undelivered notifications
+	PolicyDisconnect = "disconnect"  // Close the connection of the subscriber
+	PolicyPause      = "pause"       // Stop streaming and replay the missed blocks once caught up
+)
+
+const (
+	// defaultSubscriptionBuffer is the number of undelivered notifications a
+	// subscription buffers before its policy kicks in, if not specified.
+	defaultSubscriptionBuffer = 1024
+
+	// maxSubscriptionBuffer is the largest buffer a subscription may request.
+	maxSubscriptionBuffer = 16384
+
+	// maxResumeBlocks is the number of blocks below the head a subscription may
+	// resume from.
+	maxResumeBlocks = 1024
+)
+
+var (
+	errUnknownPolicy  = errors.New("unknown subscription buffering policy")
+	errResumeTooOld   = fmt.Errorf("resume block more than %d blocks below the head", maxResumeBlocks)
+	errResumePending  = errors.New("pending logs cannot be paused or resumed")
+	errInvalidBufSize = fmt.Errorf("subscription buffer size above %d", maxSubscriptionBuffer)
+)
+
+// SubscriptionOptions are the delivery settings of a newHeads or logs
+// subscription.
+type SubscriptionOptions struct {
+	Policy     string          `json:"policy"`     // Buffering policy, drop-oldest if empty
+	BufferSize int             `json:"bufferSize"` // Undelivered notifications buffered before the policy applies
+	FromBlock  *hexutil.Uint64 `json:"fromBlock"`  // Block to replay the events of before streaming new ones
+}
+
+// notification is an undelivered notification of a subscription, tagged with
+// the number of the block it belongs to.
+type notification struct {
+	number  uint64
+	value   interface{}
+	removed bool // Retracts an earlier notification, never replayed
+}
+
+// subscriptionStream delivers the notifications of a subscription, buffering
+// them for slow subscribers according to its policy. When paused or resuming,
+// the stream replays the notifications of the chain block by block instead of
+// the events, switching back once it caught up with them.
+type subscriptionStream struct {
+	policy string
+	size   int
+
+	head       func() uint64                                                    // Number of the current head block
+	fetch      func(ctx context.Context, number uint64) ([]notification, error) // Notifications of a canonical block
+	notify     func(value interface{}) error                                    // Sends a notification to the subscriber
+	disconnect func()                                                           // Drops the subscriber
+
+	queue []notification // Notifications awaiting delivery
+	live  bool           // Whether events are streamed, or blocks replayed
+	next  uint64         // Next block to replay if not live
+	stash []notification // Events received while replaying, streamed once caught up
+}
+
+// newSubscriptionStream validates the options of a subscription, creating the
+// stream delivering its notifications.
+func newSubscriptionStream(opts *SubscriptionOptions, head uint64) (*subscriptionStream, error) {
+	s := &subscriptionStream{
+		policy: PolicyDropOldest,
+		size:   defaultSubscriptionBuffer,
+		live:   true,
+	}
+	if opts == nil {
+		return s, nil
+	}
+	switch opts.Policy {
+	case "":
+	case PolicyDropOldest, PolicyDisconnect, PolicyPause:
+		s.policy = opts.Policy
+	default:
+		return nil, fmt.Errorf("%w: %q", errUnknownPolicy, opts.Policy)
+	}
+	if opts.BufferSize > maxSubscriptionBuffer {
+		return nil, errInvalidBufSize
+	}
+	if opts.BufferSize > 0 {
+		s.size = opts.BufferSize
+	}
+	if opts.FromBlock != nil {
+		from := uint64(*opts.FromBlock)
+		if from+maxResumeBlocks < head {
+			return nil, errResumeTooOld
+		}
+		s.live, s.next = false, from
+	}
+	return s, nil
+}
+
+// streamEvents runs the stream of a subscription, converting the events it
+// receives into notifications until the subscription ends.
+func streamEvents[T any](s *subscriptionStream, events <-chan T, convert func(T) []notification, done <-chan error, closed <-chan interface{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Deliver the notifications on their own goroutine, writes to a slow
+	// subscriber block until the connection times out
+	var (
+		deliver = make(chan interface{})
+		failed  = make(chan struct{})
+	)
+	defer close(deliver)
+
+	go func() {
+		defer close(failed)
+		for value := range deliver {
+			if err := s.notify(value); err != nil {
+				return
+			}
+		}
+	}()
+	for {
+		var (
+			send  chan interface{}
+			value interface{}
+		)
+		if !s.live {
+			s.resume()
+		}
+		if len(s.queue) > 0 {
+			send, value = deliver, s.queue[0].value
+		}
+		if !s.live && len(s.queue) < s.size && s.next <= s.head() {
+			// Replaying and not yet caught up with the chain, fetch the next
+			// block unless anything else is ready
+			select {
+			case ev := <-events:
+				if !s.push(convert(ev)) {
+					s.disconnect()
+					return
+				}
+			case send <- value:
+				s.queue[0] = notification{}
+				s.queue = s.queue[1:]
+			case <-done:
+				return
+			case <-closed:
+				return
+			case <-failed:
+				return
+			default:
+				if err := s.replay(ctx); err != nil {
+					log.Debug("Failed to replay subscription events", "number", s.next, "err", err)
+					s.disconnect()
+					return
+				}
+			}
+			continue
+		}
+		select {
+		case ev := <-events:
+			if !s.push(convert(ev)) {
+				s.disconnect()
+				return
+			}
+		case send <- value:
+			s.queue[0] = notification{}
+			s.queue = s.queue[1:]
+		case <-done:
+			return
+		case <-closed:
+			return
+		case <-failed:
+			return
+		}
+	}
+}
+
+// push adds the notifications of an event to the stream, applying the policy
+// if the subscriber fell behind. It returns false if the subscriber has to be
+// disconnected.
+func (s *subscriptionStream) push(items []notification) bool {
+	if len(items) == 0 {
+		return true
+	}
+	if !s.live {
+		s.stashEvents(items)
+		return true
+	}
+	if len(s.queue) == 0 || len(s.queue)+len(items) <= s.size {
+		s.queue = append(s.queue, items...)
+		return true
+	}
+	switch s.policy {
+	case PolicyDisconnect:
+		log.Debug("Disconnecting slow subscriber", "pending", len(s.queue))
+		return false
+
+	case PolicyPause:
+		// Pause the stream, replaying the chain from the first event missed
+		s.live, s.next = false, items[0].number
+		for _, item := range items {
+			if item.number < s.next {
+				s.next = item.number
+			}
+		}
+		s.stashEvents(items)
+
+	default:
+		s.queue = append(s.queue, items...)
+		if drop := len(s.queue) - s.size; drop > 0 {
+			log.Debug("Dropping notifications of slow subscriber", "dropped", drop)
+			s.queue = append(s.queue[:0:0], s.queue[drop:]...)
+		}
+	}
+	return true
+}
+
+// stashEvents holds on to the notifications of events received while replaying,
+// to switch back to streaming once the replay reaches them. Events not fitting
+// into the buffer are discarded, the replay catching up with later ones.
+func (s *subscriptionStream) stashEvents(items []notification) {
+	for _, item := range items {
+		if item.removed || item.number < s.next {
+			continue
+		}
+		if len(s.stash) == s.size {
+			s.stash = nil
+		}
+		s.stash = append(s.stash, item)
+	}
+}
+
+// replay queues the notifications of the next block of the chain.
+func (s *subscriptionStream) replay(ctx context.Context) error {
+	items, err := s.fetch(ctx, s.next)
+	if err != nil {
+		return err
+	}
+	s.queue = append(s.queue, items...)
+	s.next++
+	return nil
+}
+
+// resume turns the stream live again if the replay caught up with the stashed
+// events and the subscriber has room for them.
+func (s *subscriptionStream) resume() {
+	for len(s.stash) > 0 && s.stash[0].number < s.next {
+		s.stash = s.stash[1:]
+	}
+	if len(s.stash) > 0 && s.stash[0].number == s.next && len(s.queue)+len(s.stash) <= s.size {
+		s.queue = append(s.queue, s.stash...)
+		s.live, s.stash = true, nil
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package filters
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// streamTester drives a subscription stream with block number events, its
+// subscriber blocking in each notification until released.
+type streamTester struct {
+	t      *testing.T
+	head   atomic.Uint64
+	events chan uint64
+	done   chan error
+
+	entered      chan interface{}
+	release      chan struct{}
+	disconnected chan struct{}
+	exited       chan struct{}
+}
+
+func newStreamTester(t *testing.T, opts *SubscriptionOptions, head uint64) *streamTester {
+	st := &streamTester{
+		t:            t,
+		events:       make(chan uint64),
+		done:         make(chan error),
+		entered:      make(chan interface{}),
+		release:      make(chan struct{}),
+		disconnected: make(chan struct{}),
+		exited:       make(chan struct{}),
+	}
+	st.head.Store(head)
+
+	s, err := newSubscriptionStream(opts, head)
+	if err != nil {
+		t.Fatalf("failed to create stream: %v", err)
+	}
+	s.head = st.head.Load
+	s.fetch = func(ctx context.Context, number uint64) ([]notification, error) {
+		return []notification{{number: number, value: number}}, nil
+	}
+	s.notify = func(value interface{}) error {
+		st.entered <- value
+		<-st.release
+		return nil
+	}
+	s.disconnect = func() { close(st.disconnected) }
+
+	go func() {
+		defer close(st.exited)
+		streamEvents(s, st.events, func(number uint64) []notification {
+			return []notification{{number: number, value: number}}
+		}, st.done, nil)
+	}()
+	return st
+}
+
+// emit sends the event of a new head block.
+func (st *streamTester) emit(number uint64) {
+	st.head.Store(number)
+	select {
+	case st.events <- number:
+	case <-time.After(time.Second):
+		st.t.Fatalf("event %d not consumed", number)
+	}
+}
+
+// receive checks the next notifications received by the subscriber.
+func (st *streamTester) receive(want ...uint64) {
+	st.t.Helper()
+
+	var have []uint64
+	for range want {
+		select {
+		case value := <-st.entered:
+			have = append(have, value.(uint64))
+			st.release <- struct{}{}
+		case <-time.After(time.Second):
+			st.t.Fatalf("notifications missing: have %v, want %v", have, want)
+		}
+	}
+	if !reflect.DeepEqual(have, want) {
+		st.t.Fatalf("notifications mismatch: have %v, want %v", have, want)
+	}
+}
+
+// stall waits for the subscriber to block in the delivery of a notification.
+func (st *streamTester) stall(want uint64) {
+	st.t.Helper()
+
+	select {
+	case value := <-st.entered:
+		if value.(uint64) != want {
+			st.t.Fatalf("stalled notification mismatch: have %v, want %d", value, want)
+		}
+	case <-time.After(time.Second):
+		st.t.Fatalf("notification %d not delivered", want)
+	}
+}
+
+func (st *streamTester) stop() {
+	close(st.done)
+	for {
+		select {
+		case <-st.entered:
+			st.release <- struct{}{}
+		case <-st.exited:
+			return
+		}
+	}
+}
+
+func TestStreamDropOldest(t *testing.T) {
+	st := newStreamTester(t, &SubscriptionOptions{BufferSize: 2}, 0)
+	defer st.stop()
+
+	st.emit(1)
+	st.stall(1)
+	for n := uint64(2); n <= 5; n++ {
+		st.emit(n)
+	}
+	st.release <- struct{}{}
+	st.receive(4, 5)
+	st.emit(6)
+	st.receive(6)
+}
+
+func TestStreamDisconnect(t *testing.T) {
+	st := newStreamTester(t, &SubscriptionOptions{Policy: PolicyDisconnect, BufferSize: 1}, 0)
+
+	st.emit(1)
+	st.stall(1)
+	st.emit(2)
+	st.emit(3)
+	select {
+	case <-st.disconnected:
+	case <-time.After(time.Second):
+		t.Fatal("slow subscriber not disconnected")
+	}
+	st.release <- struct{}{}
+	<-st.exited
+}
+
+func TestStreamPause(t *testing.T) {
+	st := newStreamTester(t, &SubscriptionOptions{Policy: PolicyPause, BufferSize: 2}, 0)
+	defer st.stop()
+
+	st.emit(1)
+	st.stall(1)
+	for n := uint64(2); n <= 8; n++ {
+		st.emit(n)
+	}
+	// The missed blocks are replayed, switching back to events once caught up
+	st.release <- struct{}{}
+	st.receive(2, 3, 4, 5, 6, 7, 8)
+	st.emit(9)
+	st.emit(10)
+	st.receive(9, 10)
+}
+
+func TestStreamResume(t *testing.T) {
+	st := newStreamTester(t, &SubscriptionOptions{FromBlock: (*hexutil.Uint64)(new(uint64))}, 3)
+	defer st.stop()
+
+	st.receive(0, 1, 2, 3)
+	st.emit(4)
+	st.emit(5)
+	st.receive(4, 5)
+}
+
+func TestSubscriptionOptions(t *testing.T) {
+	from := hexutil.Uint64(10)
+	for i, tt := range []struct {
+		opts *SubscriptionOptions
+		head uint64
+		err  error
+	}{
+		{nil, 0, nil},
+		{&SubscriptionOptions{Policy: PolicyPause, BufferSize: 10}, 0, nil},
+		{&SubscriptionOptions{Policy: "block"}, 0, errUnknownPolicy},
+		{&SubscriptionOptions{BufferSize: maxSubscriptionBuffer + 1}, 0, errInvalidBufSize},
+		{&SubscriptionOptions{FromBlock: &from}, 10 + maxResumeBlocks, nil},
+		{&SubscriptionOptions{FromBlock: &from}, 11 + maxResumeBlocks, errResumeTooOld},
+	} {
+		if _, err := newSubscriptionStream(tt.opts, tt.head); !errors.Is(err, tt.err) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
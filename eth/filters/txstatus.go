@@ -0,0 +1,209 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package filters
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// defaultTxConfirmations is the number of blocks on top of its own a transaction
+// is considered final after, unless the subscriber asks for another depth.
+const defaultTxConfirmations = 12
+
+// Lifecycle states of a transaction reported by the txStatus subscription.
+const (
+	TxStatusKnown     = "known"     // In the pool, waiting for an earlier nonce or for funds
+	TxStatusPending   = "pending"   // In the pool, executable
+	TxStatusIncluded  = "included"  // In a canonical block
+	TxStatusReplaced  = "replaced"  // Evicted from the pool, another transaction took its nonce
+	TxStatusDropped   = "dropped"   // Evicted from the pool, its nonce is still free
+	TxStatusFinalized = "finalized" // Included deep enough not to be reorged anymore
+)
+
+var errTxStatusUnsupported = errors.New("transaction status subscriptions not supported")
+
+// TxPoolBackend is implemented by filter backends with a transaction pool, needed
+// by the transaction status subscription.
+type TxPoolBackend interface {
+	GetTransaction(ctx context.Context, txHash common.Hash) (*types.Transaction, common.Hash, uint64, uint64, error)
+	GetPoolTransaction(txHash common.Hash) *types.Transaction
+	GetPoolNonce(ctx context.Context, addr common.Address) (uint64, error)
+	TxPoolContentFrom(addr common.Address) (types.Transactions, types.Transactions)
+}
+
+// TxStatusOptions are the settings of a txStatus subscription.
+type TxStatusOptions struct {
+	Confirmations *hexutil.Uint64 `json:"confirmations"` // Blocks to wait for before reporting finality, 12 if empty
+}
+
+// TxStatus is a notification of the txStatus subscription, sent whenever the
+// transaction moves to another state of its lifecycle.
+type TxStatus struct {
+	Hash             common.Hash     `json:"hash"`
+	Status           string          `json:"status"`
+	BlockHash        *common.Hash    `json:"blockHash,omitempty"`
+	BlockNumber      *hexutil.Uint64 `json:"blockNumber,omitempty"`
+	TransactionIndex *hexutil.Uint64 `json:"transactionIndex,omitempty"`
+	Confirmations    *hexutil.Uint64 `json:"confirmations,omitempty"`
+}
+
+// txTracker follows the lifecycle of a transaction, remembering its body once
+// seen so that it can tell a replaced transaction from a dropped one.
+type txTracker struct {
+	backend       Backend
+	pool          TxPoolBackend
+	hash          common.Hash
+	confirmations uint64
+
+	tx     *types.Transaction
+	sender common.Address
+	last   *TxStatus
+}
+
+// update resolves the current state of the transaction at the given head,
+// returning it if it differs from the last one reported.
+func (t *txTracker) update(ctx context.Context, head *types.Header) *TxStatus {
+	status := t.status(ctx, head)
+	if status == nil {
+		return nil
+	}
+	if last := t.last; last != nil && last.Status == status.Status && (last.BlockHash == nil || *last.BlockHash == *status.BlockHash) {
+		return nil
+	}
+	t.last = status
+	return status
+}
+
+// status resolves the current state of the transaction at the given head, nil
+// if the transaction was never seen.
+func (t *txTracker) status(ctx context.Context, head *types.Header) *TxStatus {
+	if tx, blockHash, number, index, err := t.pool.GetTransaction(ctx, t.hash); err == nil && tx != nil && blockHash != (common.Hash{}) {
+		t.remember(tx)
+
+		var confirmations uint64
+		if head.Number.Uint64() >= number {
+			confirmations = head.Number.Uint64() - number + 1
+		}
+		state := TxStatusIncluded
+		if confirmations >= t.confirmations {
+			state = TxStatusFinalized
+		} else if final, _ := t.backend.HeaderByNumber(ctx, rpc.FinalizedBlockNumber); final != nil && final.Number.Uint64() >= number {
+			state = TxStatusFinalized
+		}
+		return &TxStatus{
+			Hash:             t.hash,
+			Status:           state,
+			BlockHash:        &blockHash,
+			BlockNumber:      (*hexutil.Uint64)(&number),
+			TransactionIndex: (*hexutil.Uint64)(&index),
+			Confirmations:    (*hexutil.Uint64)(&confirmations),
+		}
+	}
+	if tx := t.pool.GetPoolTransaction(t.hash); tx != nil {
+		t.remember(tx)
+
+		state := TxStatusKnown
+		pending, _ := t.pool.TxPoolContentFrom(t.sender)
+		for _, ptx := range pending {
+			if ptx.Hash() == t.hash {
+				state = TxStatusPending
+				break
+			}
+		}
+		return &TxStatus{Hash: t.hash, Status: state}
+	}
+	if t.tx == nil {
+		return nil
+	}
+	// Known before but gone now, check whether its nonce was taken
+	state := TxStatusDropped
+	if nonce, err := t.pool.GetPoolNonce(ctx, t.sender); err == nil && nonce > t.tx.Nonce() {
+		state = TxStatusReplaced
+	}
+	return &TxStatus{Hash: t.hash, Status: state}
+}
+
+// remember stores the body and the sender of the transaction once seen.
+func (t *txTracker) remember(tx *types.Transaction) {
+	if t.tx != nil {
+		return
+	}
+	sender, err := types.Sender(types.LatestSigner(t.backend.ChainConfig()), tx)
+	if err != nil {
+		return
+	}
+	t.tx, t.sender = tx, sender
+}
+
+// TxStatus creates a subscription reporting the lifecycle of a transaction: it
+// being known to the pool, pending, included into a block, replaced or dropped
+// from the pool and finally buried deep enough in the chain. A notification is
+// sent on every state change, including reorgs moving the transaction into
+// another block, after which the subscription stays silent.
+func (api *FilterAPI) TxStatus(ctx context.Context, hash common.Hash, opts *TxStatusOptions) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	pool, ok := api.sys.backend.(TxPoolBackend)
+	if !ok {
+		return nil, errTxStatusUnsupported
+	}
+	tracker := &txTracker{
+		backend:       api.sys.backend,
+		pool:          pool,
+		hash:          hash,
+		confirmations: defaultTxConfirmations,
+	}
+	if opts != nil && opts.Confirmations != nil {
+		tracker.confirmations = uint64(*opts.Confirmations)
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		var (
+			headers = make(chan *types.Header, 16)
+			txs     = make(chan []*types.Transaction, 128)
+			headSub = api.events.SubscribeNewHeads(headers)
+			txSub   = api.events.SubscribePendingTxs(txs)
+		)
+		defer headSub.Unsubscribe()
+		defer txSub.Unsubscribe()
+
+		head := api.sys.backend.CurrentHeader()
+		for {
+			if status := tracker.update(context.Background(), head); status != nil {
+				notifier.Notify(rpcSub.ID, status)
+				if status.Status == TxStatusFinalized {
+					return
+				}
+			}
+			select {
+			case head = <-headers:
+			case <-txs:
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
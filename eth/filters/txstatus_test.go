@@ -0,0 +1,121 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package filters
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// testPoolBackend is a filter backend with a transaction pool holding up to one
+// transaction and a chain including up to one.
+type testPoolBackend struct {
+	*testBackend
+
+	pooled  *types.Transaction
+	pending bool
+	nonce   uint64
+
+	included    *types.Transaction
+	blockHash   common.Hash
+	blockNumber uint64
+}
+
+func (b *testPoolBackend) GetTransaction(ctx context.Context, hash common.Hash) (*types.Transaction, common.Hash, uint64, uint64, error) {
+	if b.included != nil && b.included.Hash() == hash {
+		return b.included, b.blockHash, b.blockNumber, 0, nil
+	}
+	return nil, common.Hash{}, 0, 0, nil
+}
+
+func (b *testPoolBackend) GetPoolTransaction(hash common.Hash) *types.Transaction {
+	if b.pooled != nil && b.pooled.Hash() == hash {
+		return b.pooled
+	}
+	return nil
+}
+
+func (b *testPoolBackend) GetPoolNonce(ctx context.Context, addr common.Address) (uint64, error) {
+	return b.nonce, nil
+}
+
+func (b *testPoolBackend) TxPoolContentFrom(addr common.Address) (types.Transactions, types.Transactions) {
+	if b.pooled == nil {
+		return nil, nil
+	}
+	if b.pending {
+		return types.Transactions{b.pooled}, nil
+	}
+	return nil, types.Transactions{b.pooled}
+}
+
+func TestTxTracker(t *testing.T) {
+	var (
+		key, _  = crypto.GenerateKey()
+		signer  = types.LatestSigner(params.TestChainConfig)
+		tx      = types.MustSignNewTx(key, signer, &types.LegacyTx{Nonce: 3, Gas: 21000, GasPrice: big.NewInt(1)})
+		backend = &testPoolBackend{testBackend: &testBackend{db: rawdb.NewMemoryDatabase()}}
+		tracker = &txTracker{backend: backend, pool: backend, hash: tx.Hash(), confirmations: 4}
+	)
+	head := func(number int64) *types.Header {
+		return &types.Header{Number: big.NewInt(number)}
+	}
+	check := func(status *TxStatus, want string, confirmations uint64) {
+		t.Helper()
+		if want == "" {
+			if status != nil {
+				t.Fatalf("unexpected status %+v", status)
+			}
+			return
+		}
+		if status == nil || status.Status != want {
+			t.Fatalf("status mismatch: have %+v, want %s", status, want)
+		}
+		if confirmations > 0 && (status.Confirmations == nil || uint64(*status.Confirmations) != confirmations) {
+			t.Fatalf("confirmations mismatch: have %+v, want %d", status, confirmations)
+		}
+	}
+	check(tracker.update(context.Background(), head(1)), "", 0)
+
+	backend.pooled = tx
+	check(tracker.update(context.Background(), head(1)), TxStatusKnown, 0)
+	check(tracker.update(context.Background(), head(1)), "", 0)
+
+	backend.pending = true
+	check(tracker.update(context.Background(), head(1)), TxStatusPending, 0)
+
+	backend.pooled, backend.included, backend.blockHash, backend.blockNumber = nil, tx, common.Hash{0x01}, 2
+	check(tracker.update(context.Background(), head(2)), TxStatusIncluded, 1)
+	check(tracker.update(context.Background(), head(3)), "", 0)
+
+	// Reorged into another block, then out of the chain and the pool
+	backend.blockHash, backend.blockNumber = common.Hash{0x02}, 3
+	check(tracker.update(context.Background(), head(3)), TxStatusIncluded, 1)
+
+	backend.included = nil
+	check(tracker.update(context.Background(), head(4)), TxStatusDropped, 0)
+
+	backend.nonce = 4
+	check(tracker.update(context.Background(), head(4)), TxStatusReplaced, 0)
+
+	backend.included = tx
+	check(tracker.update(context.Background(), head(5)), TxStatusIncluded, 3)
+	check(tracker.update(context.Background(), head(6)), TxStatusFinalized, 4)
+}
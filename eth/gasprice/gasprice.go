@@ -17,6 +17,7 @@ import (
 	"math/big"
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/lru"
@@ -43,6 +44,10 @@ type Config struct {
 	Default          *big.Int `toml:",omitempty"`
 	MaxPrice         *big.Int `toml:",omitempty"`
 	IgnorePrice      *big.Int `toml:",omitempty"`
+
+	PoolAware      bool // Whether to also sample the pending pool and the mining template
+	PoolPercentile int  // Percentile of the tips of the pending transactions fitting the next block
+	PoolDepth      int  // Blocks worth of pending gas at which the pool sample replaces the historic one
 }
 
 // OracleBackend includes all necessary background APIs for oracle.
@@ -70,6 +75,13 @@ type Oracle struct {
 	maxHeaderHistory, maxBlockHistory uint64
 
 	historyCache *lru.Cache[cacheKey, processedFees]
+
+	pool                      PoolBackend // Transaction pool sampled in pool aware mode, nil otherwise
+	poolPercentile, poolDepth int
+	poolLock                  sync.Mutex
+	poolHead                  common.Hash // Head block of the last pool aware suggestion
+	poolTime                  time.Time   // Time of the last pool aware suggestion
+	poolPrice                 *big.Int    // Last pool aware suggestion
 }
 
 // NewOracle returns a new gasprice oracle which can recommend suitable
@@ -111,6 +123,26 @@ func NewOracle(backend OracleBackend, params Config) *Oracle {
 		log.Warn("Sanitizing invalid gasprice oracle max block history", "provided", params.MaxBlockHistory, "updated", maxBlockHistory)
 	}
 
+	var pool PoolBackend
+	if params.PoolAware {
+		if pool, _ = backend.(PoolBackend); pool == nil {
+			log.Warn("Gasprice oracle backend has no transaction pool, ignoring pool aware mode")
+		}
+	}
+	poolPercent := params.PoolPercentile
+	if poolPercent < 0 {
+		poolPercent = 0
+		log.Warn("Sanitizing invalid gasprice oracle pool percentile", "provided", params.PoolPercentile, "updated", poolPercent)
+	} else if poolPercent > 100 {
+		poolPercent = 100
+		log.Warn("Sanitizing invalid gasprice oracle pool percentile", "provided", params.PoolPercentile, "updated", poolPercent)
+	}
+	poolDepth := params.PoolDepth
+	if pool != nil && poolDepth < 1 {
+		poolDepth = 1
+		log.Warn("Sanitizing invalid gasprice oracle pool depth", "provided", params.PoolDepth, "updated", poolDepth)
+	}
+
 	cache := lru.NewCache[cacheKey, processedFees](2048)
 	headEvent := make(chan core.ChainHeadEvent, 1)
 	backend.SubscribeChainHeadEvent(headEvent)
@@ -134,6 +166,9 @@ func NewOracle(backend OracleBackend, params Config) *Oracle {
 		maxHeaderHistory: maxHeaderHistory,
 		maxBlockHistory:  maxBlockHistory,
 		historyCache:     cache,
+		pool:             pool,
+		poolPercentile:   poolPercent,
+		poolDepth:        poolDepth,
 	}
 }
 
@@ -144,6 +179,16 @@ func NewOracle(backend OracleBackend, params Config) *Oracle {
 // necessary to add the basefee to the returned number to fall back to the legacy
 // behavior.
 func (oracle *Oracle) SuggestTipCap(ctx context.Context) (*big.Int, error) {
+	price, err := oracle.suggestHistoricTipCap(ctx)
+	if err != nil || oracle.pool == nil {
+		return price, err
+	}
+	return oracle.adjustForPool(ctx, price)
+}
+
+// suggestHistoricTipCap returns a tip cap based on the transactions included in
+// the recent blocks.
+func (oracle *Oracle) suggestHistoricTipCap(ctx context.Context) (*big.Int, error) {
 	head, _ := oracle.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
 	headHash := head.Hash()
 
@@ -34,7 +34,8 @@ const testHead = 32
 
 type testBackend struct {
 	chain   *core.BlockChain
-	pending bool // pending block available
+	pending bool               // pending block available
+	pool    types.Transactions // pending pool content
 }
 
 func (b *testBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
@@ -101,6 +102,10 @@ func (b *testBackend) PendingBlockAndReceipts() (*types.Block, types.Receipts) {
 	return nil, nil
 }
 
+func (b *testBackend) GetPoolTransactions() (types.Transactions, error) {
+	return b.pool, nil
+}
+
 func (b *testBackend) ChainConfig() *params.ChainConfig {
 	return b.chain.Config()
 }
@@ -209,3 +214,49 @@ func TestSuggestTipCap(t *testing.T) {
 		}
 	}
 }
+
+func TestSuggestTipCapPoolAware(t *testing.T) {
+	backend := newTestBackend(t, big.NewInt(0), false)
+	defer backend.teardown()
+
+	// Fill two blocks worth of pending gas, with tips of 100G, 90G, ... 30G. The
+	// top four fit the next block.
+	limit := backend.chain.CurrentHeader().GasLimit
+	for i := 0; i < 8; i++ {
+		backend.pool = append(backend.pool, types.NewTx(&types.DynamicFeeTx{
+			Nonce:     uint64(i),
+			Gas:       limit / 4,
+			GasFeeCap: big.NewInt(1000 * params.GWei),
+			GasTipCap: big.NewInt(int64(100-10*i) * params.GWei),
+		}))
+	}
+	var cases = []struct {
+		depth  int
+		pool   types.Transactions
+		expect *big.Int
+	}{
+		// The pool sample is 70G, 80G, 90G, 100G, the historic one 30G
+		{2, backend.pool, big.NewInt(80 * params.GWei)},
+		{4, backend.pool, big.NewInt(55 * params.GWei)},
+		{2, backend.pool[4:], big.NewInt(35 * params.GWei)}, // Sampling 30G, 40G, 50G, 60G at half weight
+		{2, nil, big.NewInt(30 * params.GWei)},
+	}
+	for i, c := range cases {
+		backend.pool = c.pool
+		oracle := NewOracle(backend, Config{
+			Blocks:         3,
+			Percentile:     60,
+			Default:        big.NewInt(params.GWei),
+			PoolAware:      true,
+			PoolPercentile: 50,
+			PoolDepth:      c.depth,
+		})
+		got, err := oracle.SuggestTipCap(context.Background())
+		if err != nil {
+			t.Fatalf("test %d: failed to retrieve recommended gas price: %v", i, err)
+		}
+		if got.Cmp(c.expect) != 0 {
+			t.Errorf("test %d: gas price mismatch, want %d, got %d", i, c.expect, got)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package gasprice
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// poolRecheck is the time a pool aware suggestion is reused for, so that RPC
+// bursts don't iterate the pool on every call.
+const poolRecheck = time.Second
+
+// PoolBackend is implemented by oracle backends with a transaction pool, needed
+// by the pool aware mode.
+type PoolBackend interface {
+	GetPoolTransactions() (types.Transactions, error)
+}
+
+// adjustForPool moves the historic tip cap suggestion towards the tips paid by
+// the pending transactions competing for the next block. The deeper the pool,
+// the more weight the pending transactions get: at the configured depth they
+// replace the historic sample entirely, so that spikes are followed before they
+// make it into blocks.
+func (oracle *Oracle) adjustForPool(ctx context.Context, historic *big.Int) (*big.Int, error) {
+	head, err := oracle.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if err != nil {
+		return historic, err
+	}
+	oracle.poolLock.Lock()
+	defer oracle.poolLock.Unlock()
+
+	if oracle.poolHead == head.Hash() && time.Since(oracle.poolTime) < poolRecheck {
+		return new(big.Int).Set(oracle.poolPrice), nil
+	}
+	price := historic
+	if sample, depth := oracle.samplePool(head); len(sample) > 0 {
+		sort.Sort(bigIntArray(sample))
+		pending := sample[(len(sample)-1)*oracle.poolPercentile/100]
+
+		// price = historic + (pending - historic) * depth / (gasLimit * poolDepth)
+		full := new(big.Int).Mul(new(big.Int).SetUint64(head.GasLimit), big.NewInt(int64(oracle.poolDepth)))
+		weight := new(big.Int).SetUint64(depth)
+		if weight.Cmp(full) > 0 {
+			weight = full
+		}
+		delta := new(big.Int).Sub(pending, historic)
+		delta.Mul(delta, weight)
+		delta.Quo(delta, full)
+		price = new(big.Int).Add(historic, delta)
+	}
+	if price.Cmp(oracle.maxPrice) > 0 {
+		price = new(big.Int).Set(oracle.maxPrice)
+	}
+	oracle.poolHead, oracle.poolTime, oracle.poolPrice = head.Hash(), time.Now(), price
+	return new(big.Int).Set(price), nil
+}
+
+// samplePool returns the tips of the pending transactions fitting the block
+// after head, highest paying first, along with the gas of all the pending
+// transactions able to pay the base fee. The miner's current template is
+// sampled ahead of the pool, being the best guess of the next block.
+func (oracle *Oracle) samplePool(head *types.Header) ([]*big.Int, uint64) {
+	var baseFee *big.Int
+	if config := oracle.backend.ChainConfig(); config.IsLondon(new(big.Int).Add(head.Number, common.Big1)) {
+		baseFee = misc.CalcBaseFee(config, head)
+	}
+	var (
+		seen   = make(map[common.Hash]struct{})
+		sample []*big.Int
+		gas    uint64 // Gas of the sampled transactions
+		depth  uint64 // Gas of all the includable transactions
+	)
+	add := func(tx *types.Transaction) {
+		if _, ok := seen[tx.Hash()]; ok {
+			return
+		}
+		seen[tx.Hash()] = struct{}{}
+
+		tip, err := tx.EffectiveGasTip(baseFee)
+		if err != nil {
+			return // Can't pay the base fee, not competing for the next block
+		}
+		depth += tx.Gas()
+		if tip.Cmp(oracle.ignorePrice) < 0 || gas+tx.Gas() > head.GasLimit {
+			return
+		}
+		sample = append(sample, tip)
+		gas += tx.Gas()
+	}
+	if block, _ := oracle.backend.PendingBlockAndReceipts(); block != nil && block.ParentHash() == head.Hash() {
+		for _, tx := range block.Transactions() {
+			add(tx)
+		}
+	}
+	txs, err := oracle.pool.GetPoolTransactions()
+	if err != nil {
+		return sample, depth
+	}
+	// Nonce ordering within accounts is disregarded, the tips only approximate
+	// the order the miner would pick the transactions in
+	txs = append(types.Transactions{}, txs...)
+	sort.SliceStable(txs, func(i, j int) bool {
+		return txs[i].EffectiveGasTipValue(baseFee).Cmp(txs[j].EffectiveGasTipValue(baseFee)) > 0
+	})
+	for _, tx := range txs {
+		add(tx)
+	}
+	return sample, depth
+}
@@ -27,7 +27,9 @@ import (
 	"github.com/ethereum/go-ethereum/core/forkid"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/eth/downloader"
+	"github.com/ethereum/go-ethereum/eth/fastannounce"
 	"github.com/ethereum/go-ethereum/eth/fetcher"
+	"github.com/ethereum/go-ethereum/eth/protocols/compact"
 	"github.com/ethereum/go-ethereum/eth/protocols/eth"
 	"github.com/ethereum/go-ethereum/eth/protocols/snap"
 	"github.com/ethereum/go-ethereum/ethdb"
@@ -83,11 +85,12 @@ type handlerConfig struct {
 	EventMux       *event.TypeMux            // Legacy event mux, deprecate for `feed`
 	Checkpoint     *params.TrustedCheckpoint // Hard coded checkpoint for sync challenges
 	RequiredBlocks map[uint64]common.Hash    // Hard coded map of required block hashes for sync challenges
+	SyncCheckpoint *downloader.Checkpoint    // Operator trusted checkpoint for sync challenges and header verification
+	AutoPrune      bool                      // Whether to periodically disconnect useless peers
 }
 
 type handler struct {
-	networkID  uint64
-	forkFilter forkid.Filter // Fork ID filter, constant across the lifetime of the node
+	networkID uint64
 
 	snapSync  uint32 // Flag whether snap sync is enabled (gets disabled if we already have blocks)
 	acceptTxs uint32 // Flag whether we're considered synchronised (enables transaction processing)
@@ -113,6 +116,14 @@ type handler struct {
 
 	requiredBlocks map[uint64]common.Hash
 
+	announcer *fastannounce.Server // Optional sidechannel announcing mined blocks to pool nodes
+	sightings *blockSightings      // First sightings of recent blocks for the propagation stats
+	shortTxs  *shortTxIndex        // Short ids of the pooled transactions for compact block reconstruction
+	autoPrune bool                 // Whether to periodically disconnect useless peers
+
+	bodyFetches map[common.Hash]struct{} // Blocks with a body retrieval in flight, outside of the block fetcher
+	bodyLock    sync.Mutex               // Lock protecting the in flight body retrievals
+
 	// channels for fetcher, syncer, txsyncLoop
 	quitSync chan struct{}
 
@@ -129,7 +140,6 @@ func newHandler(config *handlerConfig) (*handler, error) {
 	}
 	h := &handler{
 		networkID:      config.Network,
-		forkFilter:     forkid.NewFilter(config.Chain),
 		eventMux:       config.EventMux,
 		database:       config.Database,
 		txpool:         config.TxPool,
@@ -137,6 +147,10 @@ func newHandler(config *handlerConfig) (*handler, error) {
 		peers:          newPeerSet(),
 		merger:         config.Merger,
 		requiredBlocks: config.RequiredBlocks,
+		sightings:      newBlockSightings(),
+		shortTxs:       newShortTxIndex(config.TxPool),
+		bodyFetches:    make(map[common.Hash]struct{}),
+		autoPrune:      config.AutoPrune,
 		quitSync:       make(chan struct{}),
 	}
 	if config.Sync == downloader.FullSync {
@@ -167,6 +181,11 @@ func newHandler(config *handlerConfig) (*handler, error) {
 		h.checkpointNumber = (config.Checkpoint.SectionIndex+1)*params.CHTFrequency - 1
 		h.checkpointHash = config.Checkpoint.SectionHead
 	}
+	// An operator trusted checkpoint supersedes the hardcoded one
+	if config.SyncCheckpoint != nil {
+		h.checkpointNumber = config.SyncCheckpoint.Number
+		h.checkpointHash = config.SyncCheckpoint.Hash
+	}
 	// If sync succeeds, pass a callback to potentially disable snap sync mode
 	// and enable transaction propagation.
 	success := func() {
@@ -188,7 +207,7 @@ func newHandler(config *handlerConfig) (*handler, error) {
 		}
 	}
 	// Construct the downloader (long sync)
-	h.downloader = downloader.New(h.checkpointNumber, config.Database, h.eventMux, h.chain, nil, h.removePeer, success)
+	h.downloader = downloader.New(h.checkpointNumber, config.SyncCheckpoint, config.Database, h.eventMux, h.chain, nil, h.removePeer, success)
 	if ttd := h.chain.Config().TerminalTotalDifficulty; ttd != nil {
 		if h.chain.Config().TerminalTotalDifficultyPassed {
 			log.Info("Chain post-merge, sync via beacon client")
@@ -327,8 +346,10 @@ func (h *handler) runEthPeer(peer *eth.Peer, handler eth.Handler) error {
 		number  = head.Number.Uint64()
 		td      = h.chain.GetTd(hash, number)
 	)
+	// The fork ID and filter are derived anew on every handshake to follow forks
+	// scheduled at runtime.
 	forkID := forkid.NewID(h.chain.Config(), genesis.Hash(), number, head.Time)
-	if err := peer.Handshake(h.networkID, td, hash, genesis.Hash(), forkID, h.forkFilter); err != nil {
+	if err := peer.Handshake(h.networkID, td, hash, genesis.Hash(), forkID, forkid.NewFilter(h.chain)); err != nil {
 		peer.Log().Debug("Ethereum handshake failed", "err", err)
 		return err
 	}
@@ -496,6 +517,22 @@ func (h *handler) runSnapExtension(peer *snap.Peer, handler snap.Handler) error
 	return handler(peer)
 }
 
+// runCompactExtension registers a `compact` peer and starts handling inbound
+// messages. The compact blocks are imported through the `eth` peer of the same
+// id, so they are dropped while that is not (or no longer) registered.
+func (h *handler) runCompactExtension(peer *compact.Peer, handler compact.Handler) error {
+	h.peerWG.Add(1)
+	defer h.peerWG.Done()
+
+	if err := h.peers.registerCompactExtension(peer); err != nil {
+		peer.Log().Debug("Compact extension registration failed", "err", err)
+		return err
+	}
+	defer h.peers.unregisterCompactExtension(peer.ID())
+
+	return handler(peer)
+}
+
 // removePeer requests disconnection of a peer.
 func (h *handler) removePeer(id string) {
 	peer := h.peers.peer(id)
@@ -544,6 +581,11 @@ func (h *handler) Start(maxPeers int) {
 	h.txsSub = h.txpool.SubscribeNewTxsEvent(h.txsCh)
 	go h.txBroadcastLoop()
 
+	// index the transactions already pooled for compact block reconstruction
+	for _, txs := range h.txpool.Pending(false) {
+		h.shortTxs.add(txs)
+	}
+
 	// broadcast mined blocks
 	h.wg.Add(1)
 	h.minedBlockSub = h.eventMux.Subscribe(core.NewMinedBlockEvent{})
@@ -552,6 +594,12 @@ func (h *handler) Start(maxPeers int) {
 	// start sync handlers
 	h.wg.Add(1)
 	go h.chainSync.loop()
+
+	// prune peers useless in propagating blocks
+	if h.autoPrune {
+		h.wg.Add(1)
+		go h.pruneLoop()
+	}
 }
 
 func (h *handler) Stop() {
@@ -588,7 +636,7 @@ func (h *handler) BroadcastBlock(block *types.Block, propagate bool) {
 		}
 	}
 	hash := block.Hash()
-	peers := h.peers.peersWithoutBlock(hash)
+	h.sightBlock(hash, "")
 
 	// If propagation is requested, send to a subset of the peer
 	if propagate {
@@ -600,39 +648,42 @@ func (h *handler) BroadcastBlock(block *types.Block, propagate bool) {
 			log.Error("Propagating dangling block", "number", block.Number(), "hash", hash)
 			return
 		}
-		// // Send the block to a subset of our peers
-		// transfer := peers[:int(math.Sqrt(float64(len(peers))))]
-		// for _, peer := range transfer {
-		// 	peer.AsyncSendNewBlock(block, td)
-		// }
-
-		// Send the block to trusted peers
-		log.Info("Sending full block to trusted peer", "number", block.Number(), "hash", hash)
-		for _, peer := range peers {
-			if peer.Peer.Info().Network.Trusted {
-				log.Info("Sending full block to trusted peer", "number", block.Number(), "hash", hash)
-				peer.AsyncSendNewBlock(block, td)
-			}
+		// Push the block to the prioritized peers first, then to a subset of the rest
+		prio, rest := h.peers.peersWithoutBlockPrioritized(hash)
+		for _, peer := range prio {
+			h.propagateBlock(peer, block, td)
 		}
-		// Send the block to a subset of our peers
-		transfer := peers[:int(math.Sqrt(float64(len(peers))))]
+		transfer := rest[:int(math.Sqrt(float64(len(rest))))]
 		for _, peer := range transfer {
-			if !peer.Peer.Info().Network.Trusted {
-				peer.AsyncSendNewBlock(block, td)
-			}
+			h.propagateBlock(peer, block, td)
 		}
-		log.Info("Propagated block", "hash", hash, "recipients", len(transfer), "duration", common.PrettyDuration(time.Since(block.ReceivedAt)))
+		log.Info("Propagated block", "hash", hash, "prioritized", len(prio), "recipients", len(prio)+len(transfer), "duration", common.PrettyDuration(time.Since(block.ReceivedAt)))
 		return
 	}
+	peers := h.peers.peersWithoutBlock(hash)
+
 	// Otherwise if the block is indeed in out own chain, announce it
 	if h.chain.HasBlock(hash, block.NumberU64()) {
 		for _, peer := range peers {
 			peer.AsyncSendNewBlockHash(block)
+			peer.propagation.relay()
 		}
 		log.Trace("Announced block", "hash", hash, "recipients", len(peers), "duration", common.PrettyDuration(time.Since(block.ReceivedAt)))
 	}
 }
 
+// propagateBlock pushes a block to a peer, compactly if it runs the `compact`
+// extension, otherwise in full.
+func (h *handler) propagateBlock(peer *ethPeer, block *types.Block, td *big.Int) {
+	if ext := h.peers.compactPeer(peer.ID()); ext != nil {
+		peer.MarkBlock(block.Hash())
+		ext.AsyncSendNewCompactBlock(block, td)
+	} else {
+		peer.AsyncSendNewBlock(block, td)
+	}
+	peer.propagation.relay()
+}
+
 // BroadcastTransactions will propagate a batch of transactions
 // - To a square root of all peers
 // - And, separately, as announcements to all peers which are not known to
@@ -682,6 +733,11 @@ func (h *handler) minedBroadcastLoop() {
 
 	for obj := range h.minedBlockSub.Chan() {
 		if ev, ok := obj.Data.(core.NewMinedBlockEvent); ok {
+			if h.announcer != nil {
+				if td := h.chain.GetTd(ev.Block.Hash(), ev.Block.NumberU64()); td != nil {
+					h.announcer.Announce(ev.Block.Header(), td) // Beat devp2p to the pool nodes
+				}
+			}
 			h.BroadcastBlock(ev.Block, true)  // First propagate block to peers
 			h.BroadcastBlock(ev.Block, false) // Only then announce to the rest
 		}
@@ -694,6 +750,7 @@ func (h *handler) txBroadcastLoop() {
 	for {
 		select {
 		case event := <-h.txsCh:
+			h.shortTxs.add(event.Txs)
 			h.BroadcastTransactions(event.Txs)
 		case <-h.txsSub.Err():
 			return
@@ -0,0 +1,179 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package eth
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/protocols/compact"
+	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// minShortTxIndex is the number of short transaction ids to accumulate before
+// dropping the ones no longer pooled.
+const minShortTxIndex = 16384
+
+var (
+	compactHitMeter  = metrics.NewRegisteredMeter("eth/compact/hits", nil)   // Compact blocks reconstructed from the pool
+	compactMissMeter = metrics.NewRegisteredMeter("eth/compact/misses", nil) // Compact blocks falling back to the full body
+)
+
+// compactHandler implements the compact.Backend interface to handle the various
+// network packets that are sent as broadcasts.
+type compactHandler handler
+
+func (h *compactHandler) Chain() *core.BlockChain { return h.chain }
+
+// RunPeer is invoked when a peer joins on the `compact` protocol.
+func (h *compactHandler) RunPeer(peer *compact.Peer, hand compact.Handler) error {
+	return (*handler)(h).runCompactExtension(peer, hand)
+}
+
+// PeerInfo retrieves all known `compact` information about a peer.
+func (h *compactHandler) PeerInfo(id enode.ID) interface{} {
+	if p := h.peers.compactPeer(id.String()); p != nil {
+		return &compactPeerInfo{Version: p.Version()}
+	}
+	return nil
+}
+
+// Handle is invoked from a peer's message handler when it receives a new remote
+// message that the handler couldn't consume and serve itself.
+func (h *compactHandler) Handle(peer *compact.Peer, packet compact.Packet) error {
+	switch packet := packet.(type) {
+	case *compact.NewCompactBlockPacket:
+		// Blocks are imported on behalf of the `eth` peer, drop them if that is
+		// not registered (yet)
+		ep := h.peers.peer(peer.ID())
+		if ep == nil {
+			return nil
+		}
+		hash := packet.Header.Hash()
+		ep.MarkBlock(hash)
+		(*handler)(h).sightBlock(hash, peer.ID())
+
+		return h.handleCompactBlockBroadcast(ep.Peer, packet)
+
+	default:
+		return fmt.Errorf("unexpected compact packet type: %T", packet)
+	}
+}
+
+// handleCompactBlockBroadcast is invoked when a peer transmits a compact block.
+// The block is reconstructed from the transaction pool, falling back to
+// retrieving the full body from the peer if any of the transactions are missing.
+func (h *compactHandler) handleCompactBlockBroadcast(peer *eth.Peer, packet *compact.NewCompactBlockPacket) error {
+	// Drop all incoming blocks from the p2p network if the chain already entered
+	// the pos stage, and skip the reconstruction of already known ones
+	if h.merger.PoSFinalized() {
+		return nil
+	}
+	if h.chain.HasBlock(packet.Header.Hash(), packet.Header.Number.Uint64()) {
+		return nil
+	}
+	if block := reconstructCompactBlock(packet, h.shortTxs); block != nil {
+		compactHitMeter.Mark(1)
+
+		block.ReceivedAt = time.Now()
+		block.ReceivedFrom = peer
+		return (*ethHandler)(h).handleBlockBroadcast(peer, block, packet.TD)
+	}
+	compactMissMeter.Mark(1)
+	(*ethHandler)(h).fetchBlockBody(peer, packet.Header, packet.TD)
+	return nil
+}
+
+// reconstructCompactBlock assembles a compact block from the pooled transactions,
+// returning nil if any of them are missing.
+func reconstructCompactBlock(packet *compact.NewCompactBlockPacket, index *shortTxIndex) *types.Block {
+	txs := index.transactions(packet.TxIDs)
+	if txs == nil {
+		return nil
+	}
+	// Short id collisions are caught by the transaction root
+	if types.DeriveSha(txs, trie.NewStackTrie(nil)) != packet.Header.TxHash {
+		return nil
+	}
+	return types.NewBlockWithHeader(packet.Header).WithBody(txs, packet.Uncles)
+}
+
+// shortTxIndex maps the short ids of the transactions entering the pool to their
+// hashes, so compact blocks can be reconstructed without walking and indexing
+// the entire pool for every block.
+type shortTxIndex struct {
+	pool   txPool                            // Transaction pool to resolve the hashes from
+	hashes map[compact.ShortTxID]common.Hash // Hashes of the pooled transactions by short id
+	limit  int                               // Number of ids to accumulate before dropping stale ones
+	lock   sync.Mutex                        // Lock protecting the index
+}
+
+// newShortTxIndex creates an empty short id index over a transaction pool.
+func newShortTxIndex(pool txPool) *shortTxIndex {
+	return &shortTxIndex{
+		pool:   pool,
+		hashes: make(map[compact.ShortTxID]common.Hash),
+		limit:  minShortTxIndex,
+	}
+}
+
+// add indexes a batch of transactions entering the pool. Once the index grows
+// beyond its limit, the ids of the transactions no longer pooled are dropped.
+func (idx *shortTxIndex) add(txs types.Transactions) {
+	idx.lock.Lock()
+	defer idx.lock.Unlock()
+
+	for _, tx := range txs {
+		hash := tx.Hash()
+		idx.hashes[compact.NewShortTxID(hash)] = hash
+	}
+	if len(idx.hashes) > idx.limit {
+		for id, hash := range idx.hashes {
+			if !idx.pool.Has(hash) {
+				delete(idx.hashes, id)
+			}
+		}
+		// Avoid pruning on every batch if the pool itself outgrew the limit
+		idx.limit = minShortTxIndex
+		if 2*len(idx.hashes) > idx.limit {
+			idx.limit = 2 * len(idx.hashes)
+		}
+	}
+}
+
+// transactions resolves a list of short ids into pooled transactions, returning
+// nil if any of them are unknown or no longer pooled.
+func (idx *shortTxIndex) transactions(ids []compact.ShortTxID) types.Transactions {
+	idx.lock.Lock()
+	defer idx.lock.Unlock()
+
+	txs := make(types.Transactions, len(ids))
+	for i, id := range ids {
+		hash, ok := idx.hashes[id]
+		if !ok {
+			return nil
+		}
+		if txs[i] = idx.pool.Get(hash); txs[i] == nil {
+			delete(idx.hashes, id)
+			return nil
+		}
+	}
+	return txs
+}
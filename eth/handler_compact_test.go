@@ -0,0 +1,73 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package eth
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/protocols/compact"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// Tests that compact blocks are reconstructed from the indexed transactions of
+// the pool, and rejected if any are missing or the short ids collide.
+func TestCompactBlockReconstruction(t *testing.T) {
+	signer := types.HomesteadSigner{}
+	txs := make(types.Transactions, 3)
+	for i := range txs {
+		txs[i], _ = types.SignTx(types.NewTransaction(uint64(i), common.Address{}, big.NewInt(1), params.TxGas, big.NewInt(1), nil), signer, testKey)
+	}
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(1)}
+	block := types.NewBlock(header, txs, nil, nil, trie.NewStackTrie(nil))
+	packet := compact.NewCompactBlockPacketFromBlock(block, big.NewInt(2))
+
+	// Only two transactions pooled, the reconstruction fails
+	pool := newTestTxPool()
+	index := newShortTxIndex(pool)
+
+	pool.AddRemotes(txs[:2])
+	index.add(txs[:2])
+	if reconstructCompactBlock(packet, index) != nil {
+		t.Fatalf("reconstructed compact block with missing transaction")
+	}
+	// All transactions pooled, the reconstruction succeeds
+	pool.AddRemotes(txs[2:])
+	index.add(txs[2:])
+
+	rebuilt := reconstructCompactBlock(packet, index)
+	if rebuilt == nil {
+		t.Fatalf("failed to reconstruct compact block")
+	}
+	if rebuilt.Hash() != block.Hash() || rebuilt.TxHash() != block.TxHash() {
+		t.Fatalf("reconstructed block mismatch: have %x, want %x", rebuilt.Hash(), block.Hash())
+	}
+	// A short id collision is caught by the transaction root
+	forged := *packet
+	forged.TxIDs = append([]compact.ShortTxID{}, packet.TxIDs...)
+	forged.TxIDs[0], forged.TxIDs[1] = forged.TxIDs[1], forged.TxIDs[0]
+	if reconstructCompactBlock(&forged, index) != nil {
+		t.Fatalf("reconstructed compact block with mismatching transactions")
+	}
+	// A transaction dropped from the pool fails the reconstruction
+	pool.lock.Lock()
+	delete(pool.pool, txs[1].Hash())
+	pool.lock.Unlock()
+
+	if reconstructCompactBlock(packet, index) != nil {
+		t.Fatalf("reconstructed compact block with dropped transaction")
+	}
+}
@@ -21,8 +21,22 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/fastannounce"
 	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+const (
+	// blockBodyTimeout is the maximum time allotted for a peer to return the body
+	// of a block whose header is already known.
+	blockBodyTimeout = 5 * time.Second
+
+	// maxBodyFetches is the maximum number of block body retrievals in flight
+	// outside of the block fetcher. Blocks beyond it are left to the regular
+	// announcement path.
+	maxBodyFetches = 16
 )
 
 // ethHandler implements the eth.Backend interface to handle the various network
@@ -61,6 +75,7 @@ func (h *ethHandler) Handle(peer *eth.Peer, packet eth.Packet) error {
 		return h.handleBlockAnnounces(peer, hashes, numbers)
 
 	case *eth.NewBlockPacket:
+		(*handler)(h).sightBlock(packet.Block.Hash(), peer.ID())
 		return h.handleBlockBroadcast(peer, packet.Block, packet.TD)
 
 	case *eth.NewPooledTransactionHashesPacket66:
@@ -102,6 +117,9 @@ func (h *ethHandler) handleBlockAnnounces(peer *eth.Peer, hashes []common.Hash,
 			unknownNumbers = append(unknownNumbers, numbers[i])
 		}
 	}
+	for _, hash := range hashes {
+		(*handler)(h).sightBlock(hash, peer.ID())
+	}
 	for i := 0; i < len(unknownHashes); i++ {
 		h.blockFetcher.Notify(peer.ID(), unknownHashes[i], unknownNumbers[i], time.Now(), peer.RequestOneHeader, peer.RequestBodies)
 	}
@@ -135,3 +153,95 @@ func (h *ethHandler) handleBlockBroadcast(peer *eth.Peer, block *types.Block, td
 	}
 	return nil
 }
+
+// handleFastAnnouncement is invoked by the announcement sidechannel when a pool
+// node announces a newly sealed block, retrieving the body from the announcing
+// node over devp2p ahead of the regular propagation.
+func (h *ethHandler) handleFastAnnouncement(ann *fastannounce.Announcement) {
+	if h.merger.PoSFinalized() {
+		return
+	}
+	if h.chain.HasBlock(ann.Header.Hash(), ann.Header.Number.Uint64()) {
+		return
+	}
+	peer := h.peers.peer(ann.Sender.String())
+	if peer == nil {
+		log.Debug("Fast announcement from unconnected node", "id", ann.Sender, "number", ann.Header.Number, "hash", ann.Header.Hash())
+		return
+	}
+	(*handler)(h).sightBlock(ann.Header.Hash(), peer.ID())
+	h.fetchBlockBody(peer.Peer, ann.Header, ann.TD)
+}
+
+// fetchBlockBody asynchronously retrieves the body of a block whose header is
+// already known, such as a compact block which could not be reconstructed from
+// the transaction pool, and schedules the block for import. A block is only
+// retrieved once at a time, and only up to maxBodyFetches blocks concurrently.
+func (h *ethHandler) fetchBlockBody(peer *eth.Peer, header *types.Header, td *big.Int) {
+	hash := header.Hash()
+
+	h.bodyLock.Lock()
+	if _, ok := h.bodyFetches[hash]; ok {
+		h.bodyLock.Unlock()
+		return
+	}
+	if len(h.bodyFetches) >= maxBodyFetches {
+		h.bodyLock.Unlock()
+		peer.Log().Debug("Too many block body retrievals", "number", header.Number, "hash", hash)
+		return
+	}
+	h.bodyFetches[hash] = struct{}{}
+	h.bodyLock.Unlock()
+
+	go func() {
+		defer func() {
+			h.bodyLock.Lock()
+			delete(h.bodyFetches, hash)
+			h.bodyLock.Unlock()
+		}()
+		h.retrieveBlockBody(peer, header, td)
+	}()
+}
+
+// retrieveBlockBody requests the body of a block from a peer, waiting for it to
+// arrive and scheduling the block for import.
+func (h *ethHandler) retrieveBlockBody(peer *eth.Peer, header *types.Header, td *big.Int) {
+	hash := header.Hash()
+
+	resCh := make(chan *eth.Response)
+	req, err := peer.RequestBodies([]common.Hash{hash}, resCh)
+	if err != nil {
+		return
+	}
+	defer req.Close()
+
+	timeout := time.NewTimer(blockBodyTimeout)
+	defer timeout.Stop()
+
+	select {
+	case res := <-resCh:
+		res.Done <- nil
+
+		// Ignoring withdrawals here, since blocks are not propagated post-merge.
+		txs, uncles, _ := res.Res.(*eth.BlockBodiesPacket).Unpack()
+		if len(txs) != 1 {
+			peer.Log().Debug("Block body not delivered", "number", header.Number, "hash", hash)
+			return
+		}
+		if root := types.DeriveSha(types.Transactions(txs[0]), trie.NewStackTrie(nil)); root != header.TxHash {
+			log.Warn("Retrieved block has invalid body", "have", root, "exp", header.TxHash)
+			return
+		}
+		if uncleHash := types.CalcUncleHash(uncles[0]); uncleHash != header.UncleHash {
+			log.Warn("Retrieved block has invalid uncles", "have", uncleHash, "exp", header.UncleHash)
+			return
+		}
+		block := types.NewBlockWithHeader(header).WithBody(txs[0], uncles[0])
+		block.ReceivedAt = time.Now()
+		block.ReceivedFrom = peer
+		h.handleBlockBroadcast(peer, block, td)
+
+	case <-timeout.C:
+		peer.Log().Debug("Block body retrieval timed out", "number", header.Number, "hash", hash)
+	}
+}
@@ -609,7 +609,17 @@ func TestBroadcastBlock16Peers(t *testing.T)  { testBroadcastBlock(t, 16, 4) }
 func TestBroadcastBloc26Peers(t *testing.T)   { testBroadcastBlock(t, 26, 5) }
 func TestBroadcastBlock100Peers(t *testing.T) { testBroadcastBlock(t, 100, 10) }
 
+// Tests that blocks are pushed to all trusted propagation peers and a sqrt
+// number of the remaining peers.
+func TestBroadcastBlockPrioritized1Of4Peers(t *testing.T)  { testBroadcastBlockPrioritized(t, 4, 1, 2) }
+func TestBroadcastBlockPrioritized3Of4Peers(t *testing.T)  { testBroadcastBlockPrioritized(t, 4, 3, 4) }
+func TestBroadcastBlockPrioritized4Of20Peers(t *testing.T) { testBroadcastBlockPrioritized(t, 20, 4, 8) }
+
 func testBroadcastBlock(t *testing.T, peers, bcasts int) {
+	testBroadcastBlockPrioritized(t, peers, 0, bcasts)
+}
+
+func testBroadcastBlockPrioritized(t *testing.T, peers, prio, bcasts int) {
 	t.Parallel()
 
 	// Create a source handler to broadcast blocks from and a number of sinks
@@ -617,6 +627,10 @@ func testBroadcastBlock(t *testing.T, peers, bcasts int) {
 	source := newTestHandlerWithBlocks(1)
 	defer source.close()
 
+	for i := 0; i < prio; i++ {
+		source.handler.peers.addPropagationPeer(enode.ID{byte(i)}.String())
+	}
+
 	sinks := make([]*testEthHandler, peers)
 	for i := 0; i < len(sinks); i++ {
 		sinks[i] = new(testEthHandler)
@@ -0,0 +1,55 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package health
+
+import (
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/p2p"
+)
+
+// ethBackend is the Backend of a full node.
+type ethBackend struct {
+	eth    *eth.Ethereum
+	server *p2p.Server
+}
+
+// NewBackend creates the backend checking the given full node, connected to the
+// network through the given server.
+func NewBackend(eth *eth.Ethereum, server *p2p.Server) Backend {
+	return &ethBackend{eth: eth, server: server}
+}
+
+func (b *ethBackend) CurrentHeader() *types.Header { return b.eth.BlockChain().CurrentHeader() }
+func (b *ethBackend) PeerCount() int               { return b.server.PeerCount() }
+func (b *ethBackend) Sealing() bool                { return b.eth.IsMining() }
+
+func (b *ethBackend) SyncProgress() ethereum.SyncProgress {
+	return b.eth.Downloader().Progress()
+}
+
+// DatasetReady reports whether the mining dataset of the given block is ready,
+// always true if not sealing with ethash.
+func (b *ethBackend) DatasetReady(block uint64) bool {
+	engine := b.eth.Engine()
+	if inner, ok := engine.(*beacon.Beacon); ok {
+		engine = inner.InnerEngine()
+	}
+	if engine, ok := engine.(*ethash.Ethash); ok {
+		return engine.DatasetReady(block)
+	}
+	return true
+}
@@ -0,0 +1,173 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+// Package health implements the health and readiness checks of a node, served
+// over HTTP for orchestrators and load balancers to route traffic, getwork in
+// particular, only to the nodes able to serve it.
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Config are the thresholds of the checks.
+type Config struct {
+	MinPeers      int           // Number of peers required to be healthy
+	MaxBlockAge   time.Duration // Age of the head block beyond which the node is unhealthy, zero to disable
+	MaxBlockLag   uint64        // Blocks the head may lag behind the highest known one to be ready
+	RequireDAG    bool          // Whether readiness requires the mining dataset of the next block
+	RequireSealer bool          // Whether readiness requires the node to be sealing
+}
+
+// DefaultConfig contains the default thresholds of the checks.
+var DefaultConfig = Config{
+	MinPeers:    1,
+	MaxBlockAge: 5 * time.Minute,
+	MaxBlockLag: 8,
+}
+
+// Backend is the node access the checks require.
+type Backend interface {
+	CurrentHeader() *types.Header
+	SyncProgress() ethereum.SyncProgress
+	PeerCount() int
+	Sealing() bool
+	DatasetReady(block uint64) bool
+}
+
+// Status is the outcome of the checks, along with the node state they were run
+// against.
+type Status struct {
+	Healthy  bool     `json:"healthy"`
+	Ready    bool     `json:"ready"`
+	Problems []string `json:"problems,omitempty"` // Failed checks, health ones first
+
+	Syncing      bool           `json:"syncing"`
+	CurrentBlock hexutil.Uint64 `json:"currentBlock"`
+	HighestBlock hexutil.Uint64 `json:"highestBlock"`
+	BlockAge     hexutil.Uint64 `json:"blockAge"` // Seconds since the head block was mined
+	Peers        int            `json:"peers"`
+	DAGReady     bool           `json:"dagReady"` // Whether the mining dataset of the next block is generated
+	Sealing      bool           `json:"sealing"`
+}
+
+// Checker runs the health and readiness checks of a node. A node is healthy if
+// it's connected to enough peers and following a live chain, and ready if it's
+// also in sync and, as configured, prepared to seal blocks.
+type Checker struct {
+	backend Backend
+	config  Config
+	now     func() time.Time
+}
+
+// New creates a checker of the node behind the given backend.
+func New(backend Backend, config Config) *Checker {
+	return &Checker{
+		backend: backend,
+		config:  config,
+		now:     time.Now,
+	}
+}
+
+// Status runs the checks against the current state of the node.
+func (c *Checker) Status() *Status {
+	var (
+		head     = c.backend.CurrentHeader()
+		progress = c.backend.SyncProgress()
+		status   = &Status{
+			CurrentBlock: hexutil.Uint64(head.Number.Uint64()),
+			HighestBlock: hexutil.Uint64(head.Number.Uint64()),
+			Peers:        c.backend.PeerCount(),
+			DAGReady:     c.backend.DatasetReady(head.Number.Uint64() + 1),
+			Sealing:      c.backend.Sealing(),
+		}
+	)
+	if progress.HighestBlock > progress.CurrentBlock {
+		status.Syncing = true
+		status.HighestBlock = hexutil.Uint64(progress.HighestBlock)
+	}
+	if now := uint64(c.now().Unix()); now > head.Time {
+		status.BlockAge = hexutil.Uint64(now - head.Time)
+	}
+	// Run the health checks, failing the readiness ones too
+	if status.Peers < c.config.MinPeers {
+		status.Problems = append(status.Problems, fmt.Sprintf("%d peers, want at least %d", status.Peers, c.config.MinPeers))
+	}
+	if age := time.Duration(status.BlockAge) * time.Second; c.config.MaxBlockAge > 0 && age > c.config.MaxBlockAge {
+		status.Problems = append(status.Problems, fmt.Sprintf("head block %v old, want at most %v", age, c.config.MaxBlockAge))
+	}
+	status.Healthy = len(status.Problems) == 0
+
+	// Run the readiness checks
+	if lag := uint64(status.HighestBlock - status.CurrentBlock); lag > c.config.MaxBlockLag {
+		status.Problems = append(status.Problems, fmt.Sprintf("head %d blocks behind, want at most %d", lag, c.config.MaxBlockLag))
+	}
+	if c.config.RequireDAG && !status.DAGReady {
+		status.Problems = append(status.Problems, "mining dataset not generated")
+	}
+	if c.config.RequireSealer && !status.Sealing {
+		status.Problems = append(status.Problems, "not sealing")
+	}
+	status.Ready = len(status.Problems) == 0
+	return status
+}
+
+// HealthHandler returns the HTTP handler answering with the status of the node,
+// 200 OK if healthy and 503 Service Unavailable otherwise.
+func (c *Checker) HealthHandler() http.Handler {
+	return c.handler(func(status *Status) bool { return status.Healthy })
+}
+
+// ReadyHandler returns the HTTP handler answering with the status of the node,
+// 200 OK if ready and 503 Service Unavailable otherwise.
+func (c *Checker) ReadyHandler() http.Handler {
+	return c.handler(func(status *Status) bool { return status.Ready })
+}
+
+// handler returns an HTTP handler answering with the status of the node, the
+// response code depending on whether it passed the given checks.
+func (c *Checker) handler(passed func(*Status) bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		status := c.Status()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if passed(status) {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		if r.Method == http.MethodGet {
+			json.NewEncoder(w).Encode(status)
+		}
+	})
+}
+
+// APIs returns the RPC APIs exposing the status of the node.
+func (c *Checker) APIs() []rpc.API {
+	return []rpc.API{{
+		Namespace: "admin",
+		Service:   &API{c},
+	}}
+}
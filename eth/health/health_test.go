@@ -0,0 +1,116 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package health
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+type testBackend struct {
+	head     *types.Header
+	progress ethereum.SyncProgress
+	peers    int
+	sealing  bool
+	dataset  bool
+}
+
+func (b *testBackend) CurrentHeader() *types.Header        { return b.head }
+func (b *testBackend) SyncProgress() ethereum.SyncProgress { return b.progress }
+func (b *testBackend) PeerCount() int                      { return b.peers }
+func (b *testBackend) Sealing() bool                       { return b.sealing }
+func (b *testBackend) DatasetReady(block uint64) bool      { return b.dataset }
+
+func TestStatus(t *testing.T) {
+	now := time.Unix(1000, 0)
+	config := Config{MinPeers: 2, MaxBlockAge: time.Minute, MaxBlockLag: 4, RequireDAG: true, RequireSealer: true}
+
+	tests := []struct {
+		modify   func(b *testBackend)
+		healthy  bool
+		ready    bool
+		problems int
+	}{
+		{func(b *testBackend) {}, true, true, 0},
+		{func(b *testBackend) { b.peers = 1 }, false, false, 1},
+		{func(b *testBackend) { b.head.Time = 900 }, false, false, 1},
+		{func(b *testBackend) { b.progress = ethereum.SyncProgress{CurrentBlock: 100, HighestBlock: 104} }, true, true, 0},
+		{func(b *testBackend) { b.progress = ethereum.SyncProgress{CurrentBlock: 100, HighestBlock: 105} }, true, false, 1},
+		{func(b *testBackend) { b.dataset = false }, true, false, 1},
+		{func(b *testBackend) { b.sealing = false }, true, false, 1},
+		{func(b *testBackend) { b.peers, b.sealing = 0, false }, false, false, 2},
+	}
+	for i, tt := range tests {
+		backend := &testBackend{
+			head:    &types.Header{Number: big.NewInt(100), Time: 990},
+			peers:   2,
+			sealing: true,
+			dataset: true,
+		}
+		tt.modify(backend)
+
+		c := New(backend, config)
+		c.now = func() time.Time { return now }
+
+		status := c.Status()
+		if status.Healthy != tt.healthy || status.Ready != tt.ready || len(status.Problems) != tt.problems {
+			t.Errorf("test %d: status mismatch: have %+v", i, status)
+		}
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	backend := &testBackend{
+		head:    &types.Header{Number: big.NewInt(100), Time: uint64(time.Now().Unix())},
+		peers:   1,
+		dataset: true,
+	}
+	config := DefaultConfig
+	config.RequireSealer = true
+	c := New(backend, config)
+
+	check := func(handler http.Handler, method string, code int) *Status {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, "/", nil))
+		if rec.Code != code {
+			t.Fatalf("%s response code mismatch: have %d, want %d", method, rec.Code, code)
+		}
+		if method != http.MethodGet {
+			return nil
+		}
+		status := new(Status)
+		if err := json.NewDecoder(rec.Body).Decode(status); err != nil {
+			t.Fatalf("failed to decode status: %v", err)
+		}
+		return status
+	}
+	if status := check(c.HealthHandler(), http.MethodGet, http.StatusOK); !status.Healthy || status.Ready {
+		t.Errorf("health status mismatch: %+v", status)
+	}
+	if status := check(c.ReadyHandler(), http.MethodGet, http.StatusServiceUnavailable); len(status.Problems) != 1 {
+		t.Errorf("readiness problems mismatch: %v", status.Problems)
+	}
+	check(c.ReadyHandler(), http.MethodHead, http.StatusServiceUnavailable)
+	check(c.HealthHandler(), http.MethodPost, http.StatusMethodNotAllowed)
+
+	backend.sealing = true
+	check(c.ReadyHandler(), http.MethodGet, http.StatusOK)
+}
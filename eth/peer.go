@@ -20,19 +20,23 @@ import (
 // ethPeerInfo represents a short summary of the `eth` sub-protocol metadata known
 // about a connected peer.
 type ethPeerInfo struct {
-	Version uint `json:"version"` // Ethereum protocol version negotiated
+	Version     uint             `json:"version"`     // Ethereum protocol version negotiated
+	Propagation *propagationInfo `json:"propagation"` // Block propagation latency and usefulness
 }
 
 // ethPeer is a wrapper around eth.Peer to maintain a few extra metadata.
 type ethPeer struct {
 	*eth.Peer
 	snapExt *snapPeer // Satellite `snap` connection
+
+	propagation propagationStats // Block announcement timeliness of the peer
 }
 
 // info gathers and returns some `eth` protocol metadata known about a peer.
 func (p *ethPeer) info() *ethPeerInfo {
 	return &ethPeerInfo{
-		Version: p.Version(),
+		Version:     p.Version(),
+		Propagation: p.propagation.info(),
 	}
 }
 
@@ -53,3 +57,9 @@ func (p *snapPeer) info() *snapPeerInfo {
 		Version: p.Version(),
 	}
 }
+
+// compactPeerInfo represents a short summary of the `compact` sub-protocol
+// metadata known about a connected peer.
+type compactPeerInfo struct {
+	Version uint `json:"version"` // Compact block protocol version negotiated
+}
@@ -18,6 +18,7 @@ import (
 	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/eth/protocols/compact"
 	"github.com/ethereum/go-ethereum/eth/protocols/eth"
 	"github.com/ethereum/go-ethereum/eth/protocols/snap"
 	"github.com/ethereum/go-ethereum/p2p"
@@ -39,10 +40,14 @@ var (
 	// errSnapWithoutEth is returned if a peer attempts to connect only on the
 	// snap protocol without advertising the eth main protocol.
 	errSnapWithoutEth = errors.New("peer connected on snap without compatible eth support")
+
+	// errCompactWithoutEth is returned if a peer attempts to connect only on the
+	// compact protocol without advertising the eth main protocol.
+	errCompactWithoutEth = errors.New("peer connected on compact without compatible eth support")
 )
 
 // peerSet represents the collection of active peers currently participating in
-// the `eth` protocol, with or without the `snap` extension.
+// the `eth` protocol, with or without the `snap` and `compact` extensions.
 type peerSet struct {
 	peers     map[string]*ethPeer // Peers connected on the `eth` protocol
 	snapPeers int                 // Number of `snap` compatible peers for connection prioritization
@@ -50,6 +55,10 @@ type peerSet struct {
 	snapWait map[string]chan *snap.Peer // Peers connected on `eth` waiting for their snap extension
 	snapPend map[string]*snap.Peer      // Peers connected on the `snap` protocol, but not yet on `eth`
 
+	compactPeers map[string]*compact.Peer // Peers connected on the `compact` protocol, tracked apart from `eth`
+
+	propagation map[string]struct{} // Peers pushed new blocks in full ahead of general gossip
+
 	lock   sync.RWMutex
 	closed bool
 }
@@ -60,6 +69,9 @@ func newPeerSet() *peerSet {
 		peers:    make(map[string]*ethPeer),
 		snapWait: make(map[string]chan *snap.Peer),
 		snapPend: make(map[string]*snap.Peer),
+
+		compactPeers: make(map[string]*compact.Peer),
+		propagation:  make(map[string]struct{}),
 	}
 }
 
@@ -127,6 +139,46 @@ func (ps *peerSet) waitSnapExtension(peer *eth.Peer) (*snap.Peer, error) {
 	return <-wait, nil
 }
 
+// registerCompactExtension starts tracking a peer connected on the `compact`
+// protocol. Unlike `snap`, the extension is not bound to the `eth` peer, but is
+// looked up by its id when propagating blocks.
+func (ps *peerSet) registerCompactExtension(peer *compact.Peer) error {
+	// Reject the peer if it advertises `compact` without `eth` as `compact` is
+	// only a satellite protocol meaningful with the `eth` protocol
+	if !peer.RunningCap(eth.ProtocolName, eth.ProtocolVersions) {
+		return errCompactWithoutEth
+	}
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	if ps.closed {
+		return errPeerSetClosed
+	}
+	id := peer.ID()
+	if _, ok := ps.compactPeers[id]; ok {
+		return errPeerAlreadyRegistered
+	}
+	ps.compactPeers[id] = peer
+	return nil
+}
+
+// unregisterCompactExtension stops tracking a peer on the `compact` protocol.
+func (ps *peerSet) unregisterCompactExtension(id string) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	delete(ps.compactPeers, id)
+}
+
+// compactPeer retrieves the `compact` extension of the peer with the given id,
+// or nil if it does not run the protocol.
+func (ps *peerSet) compactPeer(id string) *compact.Peer {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	return ps.compactPeers[id]
+}
+
 // registerPeer injects a new `eth` peer into the working set, or returns an error
 // if the peer is already known.
 func (ps *peerSet) registerPeer(peer *eth.Peer, ext *snap.Peer) error {
@@ -192,6 +244,57 @@ func (ps *peerSet) peersWithoutBlock(hash common.Hash) []*ethPeer {
 	return list
 }
 
+// peersWithoutBlockPrioritized retrieves the peers that do not have a given block,
+// split into the ones to push it to first and the rest. Trusted propagation
+// peers and trusted peers of the p2p server are prioritized.
+func (ps *peerSet) peersWithoutBlockPrioritized(hash common.Hash) ([]*ethPeer, []*ethPeer) {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	var prio, rest []*ethPeer
+	for id, p := range ps.peers {
+		if p.KnownBlock(hash) {
+			continue
+		}
+		if _, ok := ps.propagation[id]; ok || p.Peer.Info().Network.Trusted {
+			prio = append(prio, p)
+		} else {
+			rest = append(rest, p)
+		}
+	}
+	return prio, rest
+}
+
+// addPropagationPeer marks a peer, connected or not, to be pushed new blocks in
+// full ahead of general gossip.
+func (ps *peerSet) addPropagationPeer(id string) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	ps.propagation[id] = struct{}{}
+}
+
+// removePropagationPeer demotes a peer to receiving blocks via general gossip.
+func (ps *peerSet) removePropagationPeer(id string) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	delete(ps.propagation, id)
+}
+
+// propagationPeers returns the ids of the peers marked for prioritized block
+// propagation.
+func (ps *peerSet) propagationPeers() []string {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	ids := make([]string, 0, len(ps.propagation))
+	for id := range ps.propagation {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
 // peersWithoutTransaction retrieves a list of peers that do not have a given
 // transaction in their set of known hashes.
 func (ps *peerSet) peersWithoutTransaction(hash common.Hash) []*ethPeer {
@@ -207,6 +310,29 @@ func (ps *peerSet) peersWithoutTransaction(hash common.Hash) []*ethPeer {
 	return list
 }
 
+// all returns all the registered peers.
+func (ps *peerSet) all() []*ethPeer {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	list := make([]*ethPeer, 0, len(ps.peers))
+	for _, p := range ps.peers {
+		list = append(list, p)
+	}
+	return list
+}
+
+// sightBlock records a newly sighted block in the propagation stats of all the
+// registered peers.
+func (ps *peerSet) sightBlock() {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	for _, p := range ps.peers {
+		p.propagation.sight()
+	}
+}
+
 // len returns if the current number of `eth` peers in the set. Since the `snap`
 // peers are tied to the existence of an `eth` connection, that will always be a
 // subset of `eth`.
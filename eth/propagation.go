@@ -0,0 +1,210 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package eth
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p"
+)
+
+const (
+	// sightingCacheSize is the number of recent blocks whose first sighting is
+	// remembered to measure the announcement delays of peers.
+	sightingCacheSize = 1024
+
+	// delayAverageWeight is the weight of a new announcement delay in the moving
+	// average of a peer.
+	delayAverageWeight = 0.1
+
+	// pruneInterval is the time between two useless peer prunings, each of them
+	// disconnecting at most one peer.
+	pruneInterval = time.Minute
+
+	// pruneMinSightings is the number of blocks a peer must have had the chance
+	// to announce before it's scored for pruning.
+	pruneMinSightings = 16
+
+	// pruneThreshold is the usefulness score below which a peer is pruned.
+	pruneThreshold = 0.1
+)
+
+// blockSightings tracks when recent blocks were first sighted by the node.
+type blockSightings struct {
+	lock  sync.Mutex
+	first lru.BasicLRU[common.Hash, time.Time]
+}
+
+func newBlockSightings() *blockSightings {
+	return &blockSightings{first: lru.NewBasicLRU[common.Hash, time.Time](sightingCacheSize)}
+}
+
+// sight records a block as seen, returning when it was first seen and whether
+// this is the first sighting.
+func (s *blockSightings) sight(hash common.Hash, now time.Time) (time.Time, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if first, ok := s.first.Get(hash); ok {
+		return first, false
+	}
+	s.first.Add(hash, now)
+	return now, true
+}
+
+// propagationInfo represents a short summary of the block propagation of a peer.
+type propagationInfo struct {
+	Sighted   uint64                `json:"sighted"`   // Blocks first sighted since the peer connected
+	Announced uint64                `json:"announced"` // Blocks announced or propagated by the peer
+	First     uint64                `json:"first"`     // Blocks first sighted from the peer
+	Relayed   uint64                `json:"relayed"`   // Blocks sent to the peer before it announced them
+	Delay     common.PrettyDuration `json:"delay"`     // Moving average of the announcement delay after first sight
+	Score     float64               `json:"score"`     // Usefulness of the peer in propagating blocks, 0 to 1
+}
+
+// propagationStats tracks how timely a peer announces new blocks relative to
+// their first sighting by the node.
+type propagationStats struct {
+	lock      sync.Mutex
+	sighted   uint64
+	announced uint64
+	first     uint64
+	relayed   uint64
+	delay     time.Duration
+}
+
+// sight records a block first sighted while the peer is connected.
+func (s *propagationStats) sight() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.sighted++
+}
+
+// announce records a block announced by the peer the given time after its first
+// sighting, which may be the announcement itself.
+func (s *propagationStats) announce(delay time.Duration, first bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.announced == 0 {
+		s.delay = delay
+	} else {
+		s.delay += time.Duration(delayAverageWeight * float64(delay-s.delay))
+	}
+	s.announced++
+	if first {
+		s.first++
+	}
+}
+
+// relay records a block sent to the peer, which it thus won't announce.
+func (s *propagationStats) relay() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.relayed++
+}
+
+// score returns the usefulness of the peer, the share of the blocks it had the
+// chance to announce discounted by its average delay, and whether enough of
+// them were sighted for the score to be meaningful.
+func (s *propagationStats) score() (float64, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.scoreLocked()
+}
+
+// scoreLocked is score, assuming the lock is held.
+func (s *propagationStats) scoreLocked() (float64, bool) {
+	if s.sighted <= s.relayed {
+		return 1, false
+	}
+	chances := s.sighted - s.relayed
+	coverage := float64(s.announced) / float64(chances)
+	if coverage > 1 {
+		coverage = 1
+	}
+	return coverage / (1 + s.delay.Seconds()), chances >= pruneMinSightings
+}
+
+// info gathers and returns a summary of the block propagation of the peer.
+func (s *propagationStats) info() *propagationInfo {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	score, _ := s.scoreLocked()
+	return &propagationInfo{
+		Sighted:   s.sighted,
+		Announced: s.announced,
+		First:     s.first,
+		Relayed:   s.relayed,
+		Delay:     common.PrettyDuration(s.delay),
+		Score:     score,
+	}
+}
+
+// sightBlock records the sighting of a block, announced or propagated by the
+// peer with the given id, or originating locally if empty.
+func (h *handler) sightBlock(hash common.Hash, from string) {
+	now := time.Now()
+	first, fresh := h.sightings.sight(hash, now)
+	if fresh {
+		h.peers.sightBlock()
+	}
+	if from == "" {
+		return
+	}
+	if peer := h.peers.peer(from); peer != nil {
+		peer.propagation.announce(now.Sub(first), fresh)
+	}
+}
+
+// pruneLoop periodically disconnects the least useful peer in propagating
+// blocks, if below the prune threshold. Trusted and static peers are exempt.
+func (h *handler) pruneLoop() {
+	defer h.wg.Done()
+
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			var (
+				worst      *ethPeer
+				worstScore = pruneThreshold
+			)
+			for _, peer := range h.peers.all() {
+				if info := peer.Peer.Info(); info.Network.Trusted || info.Network.Static {
+					continue
+				}
+				if score, ok := peer.propagation.score(); ok && score < worstScore {
+					worst, worstScore = peer, score
+				}
+			}
+			if worst != nil {
+				log.Info("Pruning useless peer", "id", worst.ID(), "name", worst.Name(), "score", worstScore)
+				worst.Disconnect(p2p.DiscUselessPeer)
+			}
+
+		case <-h.quitSync:
+			return
+		}
+	}
+}
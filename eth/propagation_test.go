@@ -0,0 +1,61 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package eth
+
+import (
+	"testing"
+	"time"
+)
+
+// Tests that peers are scored by the share of the blocks they had the chance to
+// announce, discounted by their average delay, and only once enough blocks were
+// sighted.
+func TestPropagationScore(t *testing.T) {
+	var (
+		timely  propagationStats // Announces every block instantly
+		late    propagationStats // Announces every block a second late
+		silent  propagationStats // Never announces anything
+		relayed propagationStats // Always receives the blocks from us
+	)
+	for i := 0; i < pruneMinSightings; i++ {
+		for _, stats := range []*propagationStats{&timely, &late, &silent, &relayed} {
+			stats.sight()
+		}
+		timely.announce(0, true)
+		late.announce(time.Second, false)
+		relayed.relay()
+	}
+	tests := []struct {
+		name  string
+		stats *propagationStats
+		score float64
+		ok    bool
+	}{
+		{"timely", &timely, 1, true},
+		{"late", &late, 0.5, true},
+		{"silent", &silent, 0, true},
+		{"relayed", &relayed, 1, false},
+	}
+	for _, tt := range tests {
+		score, ok := tt.stats.score()
+		if score != tt.score || ok != tt.ok {
+			t.Errorf("%s: score mismatch: have %v/%v, want %v/%v", tt.name, score, ok, tt.score, tt.ok)
+		}
+	}
+	// Too few sightings leave the peer unscored
+	var fresh propagationStats
+	fresh.sight()
+	if _, ok := fresh.score(); ok {
+		t.Errorf("peer scored after a single sighting")
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package compact
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p"
+)
+
+// maxQueuedBlocks is the maximum number of block propagations to queue up before
+// dropping broadcasts.
+const maxQueuedBlocks = 4
+
+// blockPropagation is a block propagation event, waiting for its turn in the
+// broadcast queue.
+type blockPropagation struct {
+	block *types.Block
+	td    *big.Int
+}
+
+// Peer is a collection of relevant information we have about a `compact` peer.
+type Peer struct {
+	id string // Unique ID for the peer, cached
+
+	*p2p.Peer                   // The embedded P2P package peer
+	rw        p2p.MsgReadWriter // Input/output streams for compact
+	version   uint              // Protocol version negotiated
+
+	queuedBlocks chan *blockPropagation // Queue of blocks to broadcast to the peer
+
+	logger log.Logger    // Contextual logger with the peer id injected
+	term   chan struct{} // Termination channel to stop the broadcaster
+}
+
+// NewPeer create a wrapper for a network connection and negotiated  protocol
+// version.
+func NewPeer(version uint, p *p2p.Peer, rw p2p.MsgReadWriter) *Peer {
+	id := p.ID().String()
+	peer := &Peer{
+		id:           id,
+		Peer:         p,
+		rw:           rw,
+		version:      version,
+		queuedBlocks: make(chan *blockPropagation, maxQueuedBlocks),
+		logger:       log.New("peer", id[:8]),
+		term:         make(chan struct{}),
+	}
+	go peer.broadcastBlocks()
+	return peer
+}
+
+// Close signals the broadcast goroutine to terminate. Only ever call this if
+// you created the peer yourself via NewPeer. Otherwise let whoever created it
+// clean it up!
+func (p *Peer) Close() {
+	close(p.term)
+}
+
+// ID retrieves the peer's unique identifier.
+func (p *Peer) ID() string {
+	return p.id
+}
+
+// Version retrieves the peer's negotiated `compact` protocol version.
+func (p *Peer) Version() uint {
+	return p.version
+}
+
+// Log overrides the P2P logger with the higher level one containing only the id.
+func (p *Peer) Log() log.Logger {
+	return p.logger
+}
+
+// SendNewCompactBlock propagates a block to a remote peer, leaving it to
+// reconstruct the transactions from its pool.
+func (p *Peer) SendNewCompactBlock(block *types.Block, td *big.Int) error {
+	return p2p.Send(p.rw, NewCompactBlockMsg, NewCompactBlockPacketFromBlock(block, td))
+}
+
+// AsyncSendNewCompactBlock queues a block for compact propagation to a remote
+// peer. If the peer's broadcast queue is full, the event is silently dropped.
+func (p *Peer) AsyncSendNewCompactBlock(block *types.Block, td *big.Int) {
+	select {
+	case p.queuedBlocks <- &blockPropagation{block: block, td: td}:
+	default:
+		p.Log().Debug("Dropping compact block propagation", "number", block.NumberU64(), "hash", block.Hash())
+	}
+}
+
+// broadcastBlocks is a write loop that sends the queued compact blocks to the
+// remote peer, without locking up node internals.
+func (p *Peer) broadcastBlocks() {
+	for {
+		select {
+		case prop := <-p.queuedBlocks:
+			if err := p.SendNewCompactBlock(prop.block, prop.td); err != nil {
+				return
+			}
+			p.Log().Trace("Propagated compact block", "number", prop.block.Number(), "hash", prop.block.Hash(), "td", prop.td)
+
+		case <-p.term:
+			return
+		}
+	}
+}
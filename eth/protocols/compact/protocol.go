@@ -0,0 +1,108 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package compact
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Constants to match up protocol versions and messages
+const (
+	COMPACT1 = 1
+)
+
+// ProtocolName is the official short name of the `compact` protocol used during
+// devp2p capability negotiation.
+const ProtocolName = "compact"
+
+// ProtocolVersions are the supported versions of the `compact` protocol (first
+// is primary).
+var ProtocolVersions = []uint{COMPACT1}
+
+// protocolLengths are the number of implemented message corresponding to
+// different protocol versions.
+var protocolLengths = map[uint]uint64{COMPACT1: 1}
+
+// maxMessageSize is the maximum cap on the size of a protocol message.
+const maxMessageSize = 10 * 1024 * 1024
+
+const (
+	NewCompactBlockMsg = 0x00
+)
+
+var (
+	errMsgTooLarge    = errors.New("message too long")
+	errDecode         = errors.New("invalid message")
+	errInvalidMsgCode = errors.New("invalid message code")
+)
+
+// Packet represents a p2p message in the `compact` protocol.
+type Packet interface {
+	Name() string // Name returns a string corresponding to the message type.
+	Kind() byte   // Kind returns the message type.
+}
+
+// ShortTxID is the truncated hash identifying a transaction within a compact
+// block.
+type ShortTxID [8]byte
+
+// NewShortTxID truncates a transaction hash into its short id.
+func NewShortTxID(hash common.Hash) ShortTxID {
+	var id ShortTxID
+	copy(id[:], hash[:])
+	return id
+}
+
+// NewCompactBlockPacket is the network packet for the compact block propagation
+// message, carrying the transactions by their short ids for the remote side to
+// reconstruct the block from its transaction pool.
+type NewCompactBlockPacket struct {
+	Header *types.Header
+	Uncles []*types.Header
+	TxIDs  []ShortTxID
+	TD     *big.Int
+}
+
+// NewCompactBlockPacketFromBlock packs a block into a compact one.
+func NewCompactBlockPacketFromBlock(block *types.Block, td *big.Int) *NewCompactBlockPacket {
+	txs := block.Transactions()
+	ids := make([]ShortTxID, len(txs))
+	for i, tx := range txs {
+		ids[i] = NewShortTxID(tx.Hash())
+	}
+	return &NewCompactBlockPacket{
+		Header: block.Header(),
+		Uncles: block.Uncles(),
+		TxIDs:  ids,
+		TD:     td,
+	}
+}
+
+// sanityCheck verifies that the values are reasonable, as a DoS protection
+func (request *NewCompactBlockPacket) sanityCheck() error {
+	if err := request.Header.SanityCheck(); err != nil {
+		return err
+	}
+	if tdlen := request.TD.BitLen(); tdlen > 100 {
+		return fmt.Errorf("too large block TD: bitlen %d", tdlen)
+	}
+	return nil
+}
+
+func (*NewCompactBlockPacket) Name() string { return "NewCompactBlock" }
+func (*NewCompactBlockPacket) Kind() byte   { return NewCompactBlockMsg }
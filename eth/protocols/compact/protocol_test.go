@@ -0,0 +1,58 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package compact
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// Tests that compact blocks carry the short ids of the block transactions and
+// survive an RLP round trip.
+func TestCompactBlockPacket(t *testing.T) {
+	txs := types.Transactions{
+		types.NewTransaction(0, common.Address{1}, big.NewInt(1), 21000, big.NewInt(1), nil),
+		types.NewTransaction(1, common.Address{2}, big.NewInt(2), 21000, big.NewInt(1), nil),
+	}
+	block := types.NewBlock(&types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(1)}, txs, nil, nil, trie.NewStackTrie(nil))
+
+	packet := NewCompactBlockPacketFromBlock(block, big.NewInt(3))
+	for i, tx := range txs {
+		if have, want := packet.TxIDs[i], NewShortTxID(tx.Hash()); have != want {
+			t.Errorf("tx %d: short id mismatch: have %x, want %x", i, have, want)
+		}
+	}
+	blob, err := rlp.EncodeToBytes(packet)
+	if err != nil {
+		t.Fatalf("failed to encode packet: %v", err)
+	}
+	decoded := new(NewCompactBlockPacket)
+	if err := rlp.DecodeBytes(blob, decoded); err != nil {
+		t.Fatalf("failed to decode packet: %v", err)
+	}
+	if decoded.Header.Hash() != block.Hash() {
+		t.Errorf("header mismatch: have %x, want %x", decoded.Header.Hash(), block.Hash())
+	}
+	if !reflect.DeepEqual(decoded.TxIDs, packet.TxIDs) || decoded.TD.Cmp(packet.TD) != 0 {
+		t.Errorf("packet mismatch: have %v, want %v", decoded, packet)
+	}
+	if err := decoded.sanityCheck(); err != nil {
+		t.Errorf("sanity check failed: %v", err)
+	}
+}
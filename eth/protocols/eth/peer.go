@@ -169,6 +169,13 @@ func (p *Peer) markBlock(hash common.Hash) {
 	p.knownBlocks.Add(hash)
 }
 
+// MarkBlock marks a block as known for the peer, ensuring that it will never
+// be propagated to this particular peer. It is used when the block is exchanged
+// on a satellite protocol, outside of `eth`.
+func (p *Peer) MarkBlock(hash common.Hash) {
+	p.markBlock(hash)
+}
+
 // markTransaction marks a transaction as known for the peer, ensuring that it
 // will never be propagated to this particular peer.
 func (p *Peer) markTransaction(hash common.Hash) {
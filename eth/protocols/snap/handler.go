@@ -26,6 +26,7 @@ import (
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/ethereum/go-ethereum/p2p/enr"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/trie"
 )
 
@@ -114,6 +115,8 @@ func MakeProtocols(backend Backend, dnsdisc enode.Iterator) []p2p.Protocol {
 // Handle is the callback invoked to manage the life cycle of a `snap` peer.
 // When this function terminates, the peer is disconnected.
 func Handle(backend Backend, peer *Peer) error {
+	defer serveLimits.drop(peer.id)
+
 	for {
 		if err := HandleMessage(backend, peer); err != nil {
 			peer.Log().Debug("Message handling failed in `snap`", "err", err)
@@ -135,6 +138,12 @@ func HandleMessage(backend Backend, peer *Peer) error {
 		return fmt.Errorf("%w: %v > %v", errMsgTooLarge, msg.Size, maxMessageSize)
 	}
 	defer msg.Discard()
+
+	// Hold back requests to keep within the serve limits
+	switch msg.Code {
+	case GetAccountRangeMsg, GetStorageRangesMsg, GetByteCodesMsg, GetTrieNodesMsg:
+		serveLimits.wait(peer.id)
+	}
 	start := time.Now()
 	// Track the amount of time it takes to serve the request and run the handler
 	if metrics.Enabled {
@@ -160,7 +169,7 @@ func HandleMessage(backend Backend, peer *Peer) error {
 		accounts, proofs := ServiceGetAccountRangeQuery(backend.Chain(), &req)
 
 		// Send back anything accumulated (or empty in case of errors)
-		return p2p.Send(peer.rw, AccountRangeMsg, &AccountRangePacket{
+		return serve(peer, AccountRangeMsg, start, &AccountRangePacket{
 			ID:       req.ID,
 			Accounts: accounts,
 			Proof:    proofs,
@@ -192,7 +201,7 @@ func HandleMessage(backend Backend, peer *Peer) error {
 		slots, proofs := ServiceGetStorageRangesQuery(backend.Chain(), &req)
 
 		// Send back anything accumulated (or empty in case of errors)
-		return p2p.Send(peer.rw, StorageRangesMsg, &StorageRangesPacket{
+		return serve(peer, StorageRangesMsg, start, &StorageRangesPacket{
 			ID:    req.ID,
 			Slots: slots,
 			Proof: proofs,
@@ -226,7 +235,7 @@ func HandleMessage(backend Backend, peer *Peer) error {
 		codes := ServiceGetByteCodesQuery(backend.Chain(), &req)
 
 		// Send back anything accumulated (or empty in case of errors)
-		return p2p.Send(peer.rw, ByteCodesMsg, &ByteCodesPacket{
+		return serve(peer, ByteCodesMsg, start, &ByteCodesPacket{
 			ID:    req.ID,
 			Codes: codes,
 		})
@@ -253,7 +262,7 @@ func HandleMessage(backend Backend, peer *Peer) error {
 			return err
 		}
 		// Send back anything accumulated (or empty in case of errors)
-		return p2p.Send(peer.rw, TrieNodesMsg, &TrieNodesPacket{
+		return serve(peer, TrieNodesMsg, start, &TrieNodesPacket{
 			ID:    req.ID,
 			Nodes: nodes,
 		})
@@ -273,6 +282,17 @@ func HandleMessage(backend Backend, peer *Peer) error {
 	}
 }
 
+// serve sends the response to a request of the peer, charging the time spent
+// serving it since start and its size to the serve limits.
+func serve(peer *Peer, code uint64, start time.Time, packet interface{}) error {
+	blob, err := rlp.EncodeToBytes(packet)
+	if err != nil {
+		return err
+	}
+	serveLimits.charge(peer.id, len(blob), time.Since(start))
+	return p2p.Send(peer.rw, code, rlp.RawValue(blob))
+}
+
 // ServiceGetAccountRangeQuery assembles the response to an account range query.
 // It is exposed to allow external packages to test protocol behavior.
 func ServiceGetAccountRangeQuery(chain *core.BlockChain, req *GetAccountRangePacket) ([]*AccountData, [][]byte) {
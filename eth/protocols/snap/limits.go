@@ -0,0 +1,214 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package snap
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// maxServeDelay is the longest a request is held back to keep within the serve
+// limits. Requests are served regardless afterwards, before the remote side
+// times out and the work would be in vain.
+const maxServeDelay = 2 * time.Second
+
+var (
+	serveTimeMeter     = metrics.NewRegisteredMeter("snap/serve/time", nil)     // Nanoseconds spent serving requests
+	serveBytesMeter    = metrics.NewRegisteredMeter("snap/serve/bytes", nil)    // Bytes of responses served
+	serveThrottleMeter = metrics.NewRegisteredMeter("snap/serve/throttle", nil) // Nanoseconds requests were held back
+)
+
+// ServeLimits are the budgets of serving snap requests, keeping the serving of
+// many syncing peers from degrading the mining latency. The CPU budgets are the
+// share of a core the serving may take, e.g. 0.5 for half of one. Zero leaves a
+// budget unlimited.
+type ServeLimits struct {
+	PeerBandwidth   uint64  `json:"peerBandwidth"`   // Bytes per second served to a single peer
+	GlobalBandwidth uint64  `json:"globalBandwidth"` // Bytes per second served to all peers
+	PeerCPU         float64 `json:"peerCPU"`         // Share of a core spent serving a single peer
+	GlobalCPU       float64 `json:"globalCPU"`       // Share of a core spent serving all peers
+}
+
+// bucket is a token bucket refilled at a constant rate, holding at most a
+// second worth of tokens. It may go into debt, which is repaid before further
+// requests are served.
+type bucket struct {
+	rate  float64 // Tokens added per second, zero if unlimited
+	level float64
+	last  time.Time
+}
+
+// refill tops up the bucket for the time elapsed since the last refill.
+func (b *bucket) refill(now time.Time) {
+	if !b.last.IsZero() {
+		b.level += b.rate * now.Sub(b.last).Seconds()
+	}
+	if b.level > b.rate {
+		b.level = b.rate
+	}
+	b.last = now
+}
+
+// wait returns the time until the debt of the bucket is repaid.
+func (b *bucket) wait(now time.Time) time.Duration {
+	if b.rate == 0 {
+		return 0
+	}
+	b.refill(now)
+	if b.level >= 0 {
+		return 0
+	}
+	return time.Duration(-b.level / b.rate * float64(time.Second))
+}
+
+// charge takes the given number of tokens from the bucket.
+func (b *bucket) charge(now time.Time, amount float64) {
+	if b.rate == 0 {
+		return
+	}
+	b.refill(now)
+	b.level -= amount
+}
+
+// budget is a bandwidth and a CPU time bucket.
+type budget struct {
+	bytes bucket
+	time  bucket // Nanoseconds of serving
+}
+
+func newBudget(bandwidth uint64, cpu float64) *budget {
+	return &budget{
+		bytes: bucket{rate: float64(bandwidth), level: float64(bandwidth)},
+		time:  bucket{rate: cpu * float64(time.Second), level: cpu * float64(time.Second)},
+	}
+}
+
+// wait returns the time until both buckets are out of debt.
+func (b *budget) wait(now time.Time) time.Duration {
+	delay := b.bytes.wait(now)
+	if d := b.time.wait(now); d > delay {
+		delay = d
+	}
+	return delay
+}
+
+// charge takes the served bytes and time from the buckets.
+func (b *budget) charge(now time.Time, bytes int, elapsed time.Duration) {
+	b.bytes.charge(now, float64(bytes))
+	b.time.charge(now, float64(elapsed))
+}
+
+// serveLimiter shapes the serving of requests to the per-peer and the global
+// budgets, holding back requests until the budgets spent are replenished.
+type serveLimiter struct {
+	lock   sync.Mutex
+	limits ServeLimits
+	global *budget
+	peers  map[string]*budget
+}
+
+func newServeLimiter() *serveLimiter {
+	return &serveLimiter{
+		global: newBudget(0, 0),
+		peers:  make(map[string]*budget),
+	}
+}
+
+// serveLimits is the singleton limiter of serving snap requests.
+var serveLimits = newServeLimiter()
+
+// SetServeLimits replaces the budgets of serving snap requests, starting the
+// new budgets afresh.
+func SetServeLimits(limits ServeLimits) error {
+	if limits.PeerCPU < 0 || limits.GlobalCPU < 0 {
+		return errors.New("negative cpu budget")
+	}
+	serveLimits.set(limits)
+	return nil
+}
+
+// GetServeLimits returns the budgets of serving snap requests.
+func GetServeLimits() ServeLimits {
+	serveLimits.lock.Lock()
+	defer serveLimits.lock.Unlock()
+
+	return serveLimits.limits
+}
+
+func (l *serveLimiter) set(limits ServeLimits) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.limits = limits
+	l.global = newBudget(limits.GlobalBandwidth, limits.GlobalCPU)
+	l.peers = make(map[string]*budget)
+}
+
+// peer returns the budget of a peer, creating it if needed. It assumes the lock
+// is held.
+func (l *serveLimiter) peer(id string) *budget {
+	b := l.peers[id]
+	if b == nil {
+		b = newBudget(l.limits.PeerBandwidth, l.limits.PeerCPU)
+		l.peers[id] = b
+	}
+	return b
+}
+
+// delay returns how long to hold back a request of the peer for the budgets to
+// recover, capped at maxServeDelay.
+func (l *serveLimiter) delay(id string, now time.Time) time.Duration {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	delay := l.global.wait(now)
+	if d := l.peer(id).wait(now); d > delay {
+		delay = d
+	}
+	if delay > maxServeDelay {
+		delay = maxServeDelay
+	}
+	return delay
+}
+
+// wait holds back the request of a peer until the budgets allow serving it.
+func (l *serveLimiter) wait(id string) {
+	if delay := l.delay(id, time.Now()); delay > 0 {
+		serveThrottleMeter.Mark(int64(delay))
+		time.Sleep(delay)
+	}
+}
+
+// charge takes a served response from the budgets of the peer.
+func (l *serveLimiter) charge(id string, bytes int, elapsed time.Duration) {
+	serveTimeMeter.Mark(int64(elapsed))
+	serveBytesMeter.Mark(int64(bytes))
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := time.Now()
+	l.global.charge(now, bytes, elapsed)
+	l.peer(id).charge(now, bytes, elapsed)
+}
+
+// drop forgets the budget of a disconnected peer.
+func (l *serveLimiter) drop(id string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	delete(l.peers, id)
+}
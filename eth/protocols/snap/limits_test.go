@@ -0,0 +1,67 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package snap
+
+import (
+	"testing"
+	"time"
+)
+
+// Tests that the serve limiter holds back requests of peers overdrawing their
+// own or the global budget until the spent budget is replenished.
+func TestServeLimiter(t *testing.T) {
+	l := newServeLimiter()
+	l.set(ServeLimits{PeerBandwidth: 1000, GlobalBandwidth: 3000, PeerCPU: 0.5})
+
+	now := time.Now()
+	if delay := l.delay("a", now); delay != 0 {
+		t.Fatalf("fresh peer delayed: %v", delay)
+	}
+	// Overdraw the bandwidth of peer a by half a second worth of bytes
+	l.lock.Lock()
+	l.global.charge(now, 1500, 0)
+	l.peer("a").charge(now, 1500, 0)
+	l.lock.Unlock()
+
+	if delay := l.delay("a", now); delay != 500*time.Millisecond {
+		t.Fatalf("overdrawn peer delay mismatch: have %v, want %v", delay, 500*time.Millisecond)
+	}
+	if delay := l.delay("b", now); delay != 0 {
+		t.Fatalf("other peer delayed: %v", delay)
+	}
+	if delay := l.delay("a", now.Add(time.Second)); delay != 0 {
+		t.Fatalf("replenished peer delayed: %v", delay)
+	}
+	// Overdraw the cpu budget of peer b past the maximum delay
+	l.lock.Lock()
+	l.peer("b").charge(now, 0, 3*time.Second)
+	l.lock.Unlock()
+
+	if delay := l.delay("b", now); delay != maxServeDelay {
+		t.Fatalf("delay not capped: have %v, want %v", delay, maxServeDelay)
+	}
+	// Overdraw the global budget, holding back every peer
+	l.lock.Lock()
+	l.global.charge(now, 6000, 0)
+	l.lock.Unlock()
+
+	if delay := l.delay("c", now); delay == 0 {
+		t.Fatalf("peer not delayed by global budget")
+	}
+	// Dropping the peer discards its spent budget
+	l.drop("b")
+	l.set(ServeLimits{PeerCPU: 0.5})
+	if delay := l.delay("b", now); delay != 0 {
+		t.Fatalf("dropped peer delayed: %v", delay)
+	}
+}
@@ -0,0 +1,275 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+// Package rebroadcast implements a service resurrecting the locally submitted
+// transactions staying unmined, by propagating them anew and optionally
+// replacing them with fee bumped ones.
+package rebroadcast
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Config are the settings of the rebroadcaster.
+type Config struct {
+	Blocks    uint64 // Blocks a local transaction may stay unmined before it's rebroadcast
+	BumpAfter uint64 // Rebroadcasts before a fee bumped replacement is signed, zero to never bump
+	PriceBump uint64 // Percentage to bump the fees of a replacement by
+}
+
+// DefaultConfig contains the default settings of the rebroadcaster, bumping
+// fees by the minimum the transaction pool accepts for replacements.
+var DefaultConfig = Config{
+	Blocks:    10,
+	PriceBump: 10,
+}
+
+// Backend is the node access the rebroadcaster requires.
+type Backend interface {
+	ChainConfig() *params.ChainConfig
+	CurrentHeader() *types.Header
+	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
+	AccountManager() *accounts.Manager
+
+	TxPoolLocals() []common.Address
+	TxPoolContentFrom(addr common.Address) (types.Transactions, types.Transactions)
+	SendTx(ctx context.Context, tx *types.Transaction) error
+	BroadcastTransactions(txs types.Transactions)
+}
+
+// Escalator replaces a transaction staying unmined after the given number of
+// rebroadcasts, returning nil to keep rebroadcasting it unchanged.
+type Escalator func(tx *types.Transaction, from common.Address, attempts uint64) (*types.Transaction, error)
+
+// tracked is a pending local transaction watched for inclusion.
+type tracked struct {
+	from     common.Address
+	since    uint64 // Number of the head the transaction was last propagated at
+	attempts uint64 // Number of rebroadcasts made so far
+}
+
+// Rebroadcaster watches the pending local transactions on every new chain head
+// and rebroadcasts the ones unmined for the configured number of blocks. The
+// broadcast only reaches peers not known to have the transaction already,
+// which are the ones connected since or having forgotten it. Transactions still
+// unmined after the configured number of rebroadcasts are escalated.
+type Rebroadcaster struct {
+	backend Backend
+	config  Config
+
+	lock     sync.Mutex
+	escalate Escalator
+	txs      map[common.Hash]*tracked
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// New creates a rebroadcaster of the local transactions of the given backend.
+// If the config sets BumpAfter, escalation signs fee bumped replacements with
+// the node's account manager.
+func New(backend Backend, config Config) *Rebroadcaster {
+	r := &Rebroadcaster{
+		backend: backend,
+		config:  config,
+		txs:     make(map[common.Hash]*tracked),
+		quit:    make(chan struct{}),
+	}
+	if config.BumpAfter > 0 {
+		r.escalate = r.bumpFees
+	}
+	return r
+}
+
+// SetEscalator replaces the escalation of the transactions staying unmined,
+// nil disabling it.
+func (r *Rebroadcaster) SetEscalator(escalate Escalator) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.escalate = escalate
+}
+
+// Start implements node.Lifecycle, starting to watch the local transactions.
+func (r *Rebroadcaster) Start() error {
+	r.wg.Add(1)
+	go r.loop()
+	return nil
+}
+
+// Stop implements node.Lifecycle, terminating the rebroadcasts.
+func (r *Rebroadcaster) Stop() error {
+	close(r.quit)
+	r.wg.Wait()
+	return nil
+}
+
+// loop checks the local transactions on every new chain head.
+func (r *Rebroadcaster) loop() {
+	defer r.wg.Done()
+
+	heads := make(chan core.ChainHeadEvent, 16)
+	sub := r.backend.SubscribeChainHeadEvent(heads)
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-heads:
+			r.update(ev.Block.NumberU64())
+		case <-sub.Err():
+			return
+		case <-r.quit:
+			return
+		}
+	}
+}
+
+// update tracks the pending local transactions at the given head, forgetting
+// the ones included or dropped meanwhile, and rebroadcasts or escalates the
+// ones unmined for too long.
+func (r *Rebroadcaster) update(head uint64) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var (
+		pending = make(map[common.Hash]struct{})
+		stale   types.Transactions
+	)
+	for _, from := range r.backend.TxPoolLocals() {
+		txs, _ := r.backend.TxPoolContentFrom(from)
+		for _, tx := range txs {
+			hash := tx.Hash()
+			pending[hash] = struct{}{}
+
+			t := r.txs[hash]
+			if t == nil {
+				r.txs[hash] = &tracked{from: from, since: head}
+				continue
+			}
+			if head < t.since+r.config.Blocks {
+				continue
+			}
+			if t.attempts >= r.config.BumpAfter && r.escalate != nil {
+				replacement, err := r.escalate(tx, from, t.attempts)
+				if err != nil {
+					log.Warn("Failed to escalate unmined transaction", "hash", hash, "err", err)
+				} else if replacement != nil {
+					if err := r.backend.SendTx(context.Background(), replacement); err != nil {
+						log.Warn("Failed to replace unmined transaction", "hash", hash, "err", err)
+					} else {
+						log.Info("Replaced unmined transaction", "hash", hash, "replacement", replacement.Hash(), "attempts", t.attempts)
+						delete(pending, hash)
+						pending[replacement.Hash()] = struct{}{}
+						r.txs[replacement.Hash()] = &tracked{from: from, since: head}
+						continue
+					}
+				}
+			}
+			t.since, t.attempts = head, t.attempts+1
+			stale = append(stale, tx)
+		}
+	}
+	for hash := range r.txs {
+		if _, ok := pending[hash]; !ok {
+			delete(r.txs, hash)
+		}
+	}
+	if len(stale) > 0 {
+		log.Info("Rebroadcasting unmined local transactions", "count", len(stale), "head", head)
+		r.backend.BroadcastTransactions(stale)
+	}
+}
+
+// bumpFees is the default escalation, replacing the transaction with one of
+// the fees bumped by the configured percentage, signed by the sender's wallet
+// in the account manager. The wallet needs to be unlocked for this to succeed.
+func (r *Rebroadcaster) bumpFees(tx *types.Transaction, from common.Address, attempts uint64) (*types.Transaction, error) {
+	replacement, err := Bump(tx, r.config.PriceBump)
+	if err != nil {
+		return nil, err
+	}
+	account := accounts.Account{Address: from}
+	wallet, err := r.backend.AccountManager().Find(account)
+	if err != nil {
+		return nil, err
+	}
+	var chainID *big.Int
+	if tx.Protected() {
+		chainID = r.backend.ChainConfig().ChainID
+	}
+	return wallet.SignTx(account, replacement, chainID)
+}
+
+// Bump returns an unsigned copy of the transaction with its fees raised by the
+// given percentage, suitable to replace it in the transaction pool.
+func Bump(tx *types.Transaction, percent uint64) (*types.Transaction, error) {
+	var replacement types.TxData
+	switch tx.Type() {
+	case types.LegacyTxType:
+		replacement = &types.LegacyTx{
+			Nonce:    tx.Nonce(),
+			GasPrice: bump(tx.GasPrice(), percent),
+			Gas:      tx.Gas(),
+			To:       tx.To(),
+			Value:    tx.Value(),
+			Data:     tx.Data(),
+		}
+	case types.AccessListTxType:
+		replacement = &types.AccessListTx{
+			ChainID:    tx.ChainId(),
+			Nonce:      tx.Nonce(),
+			GasPrice:   bump(tx.GasPrice(), percent),
+			Gas:        tx.Gas(),
+			To:         tx.To(),
+			Value:      tx.Value(),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+		}
+	case types.DynamicFeeTxType:
+		replacement = &types.DynamicFeeTx{
+			ChainID:    tx.ChainId(),
+			Nonce:      tx.Nonce(),
+			GasTipCap:  bump(tx.GasTipCap(), percent),
+			GasFeeCap:  bump(tx.GasFeeCap(), percent),
+			Gas:        tx.Gas(),
+			To:         tx.To(),
+			Value:      tx.Value(),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+		}
+	default:
+		return nil, errors.New("unsupported transaction type")
+	}
+	return types.NewTx(replacement), nil
+}
+
+// bump raises a fee by the given percentage, by at least one wei for the pool
+// to accept the replacement.
+func bump(fee *big.Int, percent uint64) *big.Int {
+	bumped := new(big.Int).Mul(fee, new(big.Int).SetUint64(100+percent))
+	bumped.Div(bumped, big.NewInt(100))
+	if bumped.Cmp(fee) <= 0 {
+		bumped.Add(fee, common.Big1)
+	}
+	return bumped
+}
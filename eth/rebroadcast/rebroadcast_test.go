@@ -0,0 +1,139 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package rebroadcast
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// testBackend is a transaction pool of a single local sender, replacing its
+// transactions on submission.
+type testBackend struct {
+	am      *accounts.Manager
+	from    common.Address
+	pending types.Transactions
+
+	broadcasts []types.Transactions
+}
+
+func (b *testBackend) ChainConfig() *params.ChainConfig { return params.AllEthashProtocolChanges }
+func (b *testBackend) CurrentHeader() *types.Header     { return nil }
+func (b *testBackend) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
+	return nil
+}
+func (b *testBackend) AccountManager() *accounts.Manager { return b.am }
+func (b *testBackend) TxPoolLocals() []common.Address    { return []common.Address{b.from} }
+func (b *testBackend) TxPoolContentFrom(addr common.Address) (types.Transactions, types.Transactions) {
+	return b.pending, nil
+}
+func (b *testBackend) SendTx(ctx context.Context, tx *types.Transaction) error {
+	for i, pending := range b.pending {
+		if pending.Nonce() == tx.Nonce() {
+			b.pending[i] = tx
+			return nil
+		}
+	}
+	b.pending = append(b.pending, tx)
+	return nil
+}
+func (b *testBackend) BroadcastTransactions(txs types.Transactions) {
+	b.broadcasts = append(b.broadcasts, txs)
+}
+
+func TestRebroadcast(t *testing.T) {
+	ks := keystore.NewKeyStore(t.TempDir(), keystore.LightScryptN, keystore.LightScryptP)
+	key, _ := crypto.GenerateKey()
+	account, err := ks.ImportECDSA(key, "")
+	if err != nil {
+		t.Fatalf("failed to import key: %v", err)
+	}
+	if err := ks.Unlock(account, ""); err != nil {
+		t.Fatalf("failed to unlock account: %v", err)
+	}
+	am := accounts.NewManager(&accounts.Config{}, ks)
+	defer am.Close()
+
+	signer := types.LatestSigner(params.AllEthashProtocolChanges)
+	tx := types.MustSignNewTx(key, signer, &types.DynamicFeeTx{
+		ChainID:   params.AllEthashProtocolChanges.ChainID,
+		Nonce:     0,
+		GasTipCap: big.NewInt(100),
+		GasFeeCap: big.NewInt(1000),
+		Gas:       params.TxGas,
+		To:        &common.Address{},
+	})
+	backend := &testBackend{am: am, from: account.Address, pending: types.Transactions{tx}}
+	r := New(backend, Config{Blocks: 10, BumpAfter: 2, PriceBump: 10})
+
+	// Rebroadcast every 10 blocks twice, then replace the transaction
+	for _, head := range []uint64{1, 5, 10, 11, 20, 21, 30} {
+		r.update(head)
+	}
+	if len(backend.broadcasts) != 2 {
+		t.Fatalf("rebroadcast count mismatch: have %d, want 2", len(backend.broadcasts))
+	}
+	r.update(31)
+	if len(backend.broadcasts) != 2 {
+		t.Fatalf("escalated transaction rebroadcast")
+	}
+	replacement := backend.pending[0]
+	if replacement.Hash() == tx.Hash() {
+		t.Fatalf("transaction not replaced")
+	}
+	if replacement.GasTipCap().Cmp(big.NewInt(110)) != 0 || replacement.GasFeeCap().Cmp(big.NewInt(1100)) != 0 {
+		t.Errorf("replacement fees mismatch: have %v/%v, want 110/1100", replacement.GasTipCap(), replacement.GasFeeCap())
+	}
+	if from, err := types.Sender(signer, replacement); err != nil || from != account.Address {
+		t.Errorf("replacement sender mismatch: have %v (%v), want %v", from, err, account.Address)
+	}
+	if _, ok := r.txs[tx.Hash()]; ok || len(r.txs) != 1 {
+		t.Errorf("replaced transaction still tracked")
+	}
+	// The replacement is rebroadcast anew, mined transactions are forgotten
+	r.update(41)
+	if len(backend.broadcasts) != 3 || backend.broadcasts[2][0].Hash() != replacement.Hash() {
+		t.Fatalf("replacement not rebroadcast")
+	}
+	backend.pending = nil
+	r.update(42)
+	if len(r.txs) != 0 {
+		t.Errorf("mined transaction still tracked")
+	}
+}
+
+func TestBump(t *testing.T) {
+	tests := []struct {
+		fee, percent, want int64
+	}{
+		{100, 10, 110},
+		{1000, 12, 1120},
+		{5, 10, 6},
+		{0, 10, 1},
+	}
+	for i, tt := range tests {
+		if have := bump(big.NewInt(tt.fee), uint64(tt.percent)); have.Int64() != tt.want {
+			t.Errorf("test %d: bumped fee mismatch: have %v, want %d", i, have, tt.want)
+		}
+	}
+}
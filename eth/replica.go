@@ -0,0 +1,152 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// replicaPollInterval is the time between two refreshes of a read-only
+	// datadir while no head announcements are received from its owner.
+	replicaPollInterval = 3 * time.Second
+
+	// replicaDialTimeout is the maximum time allowed to connect and subscribe
+	// to the head announcements of the owner of a read-only datadir.
+	replicaDialTimeout = 5 * time.Second
+)
+
+// replicaFollower keeps a chain opened from a read-only datadir in sync with
+// the node owning it. The owner's new heads are announced over its IPC endpoint,
+// on which the shared database is refreshed and the head reloaded, invalidating
+// the stale caches. The database is polled while the owner cannot be reached.
+type replicaFollower struct {
+	endpoint string
+	db       ethdb.Database
+	chain    *core.BlockChain
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newReplicaFollower creates a follower of the node listening on the given IPC
+// endpoint, refreshing the database and the chain built on top of it.
+func newReplicaFollower(endpoint string, db ethdb.Database, chain *core.BlockChain) *replicaFollower {
+	return &replicaFollower{
+		endpoint: endpoint,
+		db:       db,
+		chain:    chain,
+		quit:     make(chan struct{}),
+	}
+}
+
+// start launches the background following of the owner.
+func (f *replicaFollower) start() {
+	f.wg.Add(1)
+	go f.loop()
+}
+
+// stop terminates the follower, waiting for an ongoing refresh to finish.
+func (f *replicaFollower) stop() {
+	close(f.quit)
+	f.wg.Wait()
+}
+
+// loop subscribes to the head announcements of the owner, falling back to
+// polling the database until the subscription succeeds.
+func (f *replicaFollower) loop() {
+	defer f.wg.Done()
+
+	poll := time.NewTicker(replicaPollInterval)
+	defer poll.Stop()
+
+	for {
+		client, sub, heads, err := f.subscribe()
+		if err != nil {
+			log.Debug("Failed to follow datadir owner, polling", "endpoint", f.endpoint, "err", err)
+			select {
+			case <-poll.C:
+				f.refresh()
+				continue
+			case <-f.quit:
+				return
+			}
+		}
+		log.Info("Following datadir owner", "endpoint", f.endpoint)
+		f.refresh() // catch up with any heads missed while unsubscribed
+
+	follow:
+		for {
+			select {
+			case <-heads:
+				// Coalesce announcements queued up during a slow refresh
+				for drained := false; !drained; {
+					select {
+					case <-heads:
+					default:
+						drained = true
+					}
+				}
+				f.refresh()
+			case err := <-sub.Err():
+				log.Warn("Lost datadir owner", "endpoint", f.endpoint, "err", err)
+				break follow
+			case <-f.quit:
+				sub.Unsubscribe()
+				client.Close()
+				return
+			}
+		}
+		client.Close()
+	}
+}
+
+// subscribe connects to the owner and subscribes to its new heads.
+func (f *replicaFollower) subscribe() (*rpc.Client, *rpc.ClientSubscription, chan json.RawMessage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), replicaDialTimeout)
+	defer cancel()
+
+	client, err := rpc.DialContext(ctx, f.endpoint)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	heads := make(chan json.RawMessage, 16)
+	sub, err := client.EthSubscribe(ctx, heads, "newHeads")
+	if err != nil {
+		client.Close()
+		return nil, nil, nil, err
+	}
+	return client, sub, heads, nil
+}
+
+// refresh catches up the database with the writes of the owner and reloads the
+// chain head from it.
+func (f *replicaFollower) refresh() {
+	if r, ok := f.db.(ethdb.Refresher); ok {
+		if err := r.Refresh(); err != nil {
+			log.Warn("Failed to refresh read-only datadir", "err", err)
+			return
+		}
+	}
+	if err := f.chain.ReloadHead(); err != nil {
+		log.Warn("Failed to reload chain head", "err", err)
+	}
+}
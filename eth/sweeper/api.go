@@ -0,0 +1,137 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package sweeper
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// maxAuditResults is the maximum number of sweeps returned by an audit trail
+// query, longer trails have to be paged through.
+const maxAuditResults = 1000
+
+// API exposes the sweeper over RPC.
+type API struct {
+	s *Sweeper
+}
+
+// AuditFilter are the criteria of an audit trail query.
+type AuditFilter struct {
+	Deposit *common.Address `json:"deposit"` // Deposit address to return the sweeps of, any if unset
+	After   *hexutil.Uint64 `json:"after"`   // ID of the sweep to return the ones following
+	Count   *hexutil.Uint64 `json:"count"`   // Maximum number of sweeps to return
+}
+
+// Status is the configuration and state of the sweeper.
+type Status struct {
+	Deposits  []common.Address `json:"deposits"`
+	Cold      common.Address   `json:"cold"`
+	Threshold *hexutil.Big     `json:"threshold"`
+	Reserve   *hexutil.Big     `json:"reserve"`
+	Interval  hexutil.Uint64   `json:"interval"` // Seconds between scheduled sweeps
+	NextSweep hexutil.Uint64   `json:"nextSweep"`
+	Sweeps    hexutil.Uint64   `json:"sweeps"`
+	Swept     *hexutil.Big     `json:"swept"` // Total amount of the sweeps sent
+}
+
+// Status returns the configuration of the sweeper and the totals of its audit
+// trail.
+func (api *API) Status() *Status {
+	s := api.s
+
+	s.lock.Lock()
+	next := s.next
+	s.lock.Unlock()
+
+	s.audit.lock.RLock()
+	defer s.audit.lock.RUnlock()
+
+	swept := new(big.Int)
+	for _, sweep := range s.audit.sweeps {
+		if sweep.Hash != nil {
+			swept.Add(swept, sweep.Amount.ToInt())
+		}
+	}
+	return &Status{
+		Deposits:  s.config.Deposits,
+		Cold:      s.config.Cold,
+		Threshold: (*hexutil.Big)(s.config.Threshold),
+		Reserve:   (*hexutil.Big)(s.config.Reserve),
+		Interval:  hexutil.Uint64(s.config.Interval.Seconds()),
+		NextSweep: hexutil.Uint64(next.Unix()),
+		Sweeps:    hexutil.Uint64(len(s.audit.sweeps)),
+		Swept:     (*hexutil.Big)(swept),
+	}
+}
+
+// Sweep sweeps the deposit addresses right away, out of schedule, returning the
+// audit records of the transfers attempted.
+func (api *API) Sweep(ctx context.Context) ([]*Sweep, error) {
+	sweeps, err := api.s.sweep(true)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*Sweep, 0, len(sweeps))
+	for _, sweep := range sweeps {
+		cpy := *sweep
+		api.resolve(ctx, &cpy)
+		results = append(results, &cpy)
+	}
+	return results, nil
+}
+
+// AuditTrail returns the recorded sweeps matching the filter in the order they
+// were made, along with the current status of their transfers.
+func (api *API) AuditTrail(ctx context.Context, filter *AuditFilter) []*Sweep {
+	var (
+		deposit *common.Address
+		after   *uint64
+		count   = maxAuditResults
+	)
+	if filter != nil {
+		deposit = filter.Deposit
+		if filter.After != nil {
+			after = (*uint64)(filter.After)
+		}
+		if filter.Count != nil && uint64(*filter.Count) < uint64(count) {
+			count = int(*filter.Count)
+		}
+	}
+	sweeps := api.s.audit.query(deposit, after, count)
+	for _, sweep := range sweeps {
+		api.resolve(ctx, sweep)
+	}
+	return sweeps
+}
+
+// resolve fills the status of the transfer of a sweep.
+func (api *API) resolve(ctx context.Context, sweep *Sweep) {
+	if sweep.Hash == nil {
+		sweep.Status = "failed"
+		return
+	}
+	if tx, _, number, _, err := api.s.backend.GetTransaction(ctx, *sweep.Hash); err == nil && tx != nil {
+		sweep.Status = "included"
+		sweep.BlockNumber = (*hexutil.Uint64)(&number)
+		return
+	}
+	if api.s.backend.GetPoolTransaction(*sweep.Hash) != nil {
+		sweep.Status = "pending"
+		return
+	}
+	sweep.Status = "dropped"
+}
@@ -0,0 +1,147 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package sweeper
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// Sweep is the audit record of a sweep of a deposit address.
+type Sweep struct {
+	ID      hexutil.Uint64 `json:"id"`
+	Time    hexutil.Uint64 `json:"time"` // Unix time the sweep was made at
+	Manual  bool           `json:"manual"`
+	Deposit common.Address `json:"deposit"`
+	Cold    common.Address `json:"cold"`
+	Head    hexutil.Uint64 `json:"head"`    // Number of the block the balance was read at
+	Balance *hexutil.Big   `json:"balance"` // Balance of the deposit address before the sweep
+	Amount  *hexutil.Big   `json:"amount,omitempty"`
+	MaxFee  *hexutil.Big   `json:"maxFee,omitempty"` // Fee paid at most by the transfer
+	Nonce   hexutil.Uint64 `json:"nonce"`
+	Hash    *common.Hash   `json:"hash,omitempty"`  // Transfer transaction, if sent
+	Error   string         `json:"error,omitempty"` // Reason the sweep failed
+
+	// Fields resolved on query, not journaled
+	Status      string          `json:"status,omitempty"` // One of pending, included, dropped or failed
+	BlockNumber *hexutil.Uint64 `json:"blockNumber,omitempty"`
+}
+
+// auditTrail is the record of all sweeps made, journaled to disk as JSON lines.
+type auditTrail struct {
+	lock   sync.RWMutex
+	sweeps []*Sweep
+	next   uint64   // ID of the next sweep
+	file   *os.File // Journal the sweeps are appended to, nil if not persisted
+}
+
+// newAuditTrail creates the audit trail, restoring the sweeps journaled at the
+// path and appending the new ones to it.
+func newAuditTrail(path string) (*auditTrail, error) {
+	audit := new(auditTrail)
+	if path == "" {
+		return audit, nil
+	}
+	if file, err := os.Open(path); err == nil {
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(nil, 1024*1024)
+		for scanner.Scan() {
+			sweep := new(Sweep)
+			if err := json.Unmarshal(scanner.Bytes(), sweep); err != nil {
+				log.Warn("Skipping corrupt sweep journal entry", "path", path, "err", err)
+				continue
+			}
+			audit.sweeps = append(audit.sweeps, sweep)
+			audit.next = uint64(sweep.ID) + 1
+		}
+		file.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		log.Info("Loaded sweep journal", "sweeps", len(audit.sweeps))
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	audit.file = file
+	return audit, nil
+}
+
+// add assigns the next ID to a sweep and records it.
+func (a *auditTrail) add(sweep *Sweep) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	sweep.ID = hexutil.Uint64(a.next)
+	a.next++
+	a.sweeps = append(a.sweeps, sweep)
+
+	if a.file == nil {
+		return nil
+	}
+	blob, err := json.Marshal(sweep)
+	if err != nil {
+		return err
+	}
+	if _, err := a.file.Write(append(blob, '\n')); err != nil {
+		return err
+	}
+	return a.file.Sync()
+}
+
+// query returns copies of the sweeps following the given ID, of the deposit
+// address if set, at most count of them.
+func (a *auditTrail) query(deposit *common.Address, after *uint64, count int) []*Sweep {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	sweeps := make([]*Sweep, 0)
+	for _, sweep := range a.sweeps {
+		if len(sweeps) >= count {
+			break
+		}
+		if after != nil && uint64(sweep.ID) <= *after {
+			continue
+		}
+		if deposit != nil && sweep.Deposit != *deposit {
+			continue
+		}
+		cpy := *sweep
+		sweeps = append(sweeps, &cpy)
+	}
+	return sweeps
+}
+
+// close closes the journal.
+func (a *auditTrail) close() error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.file == nil {
+		return nil
+	}
+	err := a.file.Close()
+	a.file = nil
+	return err
+}
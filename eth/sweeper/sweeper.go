@@ -0,0 +1,289 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+// Package sweeper implements a service moving the funds collected on deposit
+// addresses, typically the hot wallets of a mining pool, to a cold address on a
+// schedule, keeping an audit trail of every sweep.
+package sweeper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// sweepTimeout is the time allowed for a sweep of all deposit addresses, remote
+// signers included.
+const sweepTimeout = time.Minute
+
+// Config are the settings of the sweeper.
+type Config struct {
+	Deposits  []common.Address // Deposit addresses to sweep
+	Cold      common.Address   // Address the deposits are swept to
+	Threshold *big.Int         // Balance above which a deposit address is swept
+	Reserve   *big.Int         // Balance left on a swept deposit address, on top of the fees
+	Interval  time.Duration    // Time between scheduled sweeps
+	Journal   string           // Disk path of the audit trail, empty to keep it in memory only
+}
+
+// DefaultConfig contains the default settings of the sweeper.
+var DefaultConfig = Config{
+	Threshold: big.NewInt(params.Ether),
+	Reserve:   new(big.Int),
+	Interval:  10 * time.Minute,
+	Journal:   "sweeps.jsonl",
+}
+
+// Backend is the node access the sweeper requires.
+type Backend interface {
+	ChainConfig() *params.ChainConfig
+	StateAndHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*state.StateDB, *types.Header, error)
+	GetPoolNonce(ctx context.Context, addr common.Address) (uint64, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	AccountManager() *accounts.Manager
+	SendTx(ctx context.Context, tx *types.Transaction) error
+	GetTransaction(ctx context.Context, hash common.Hash) (*types.Transaction, common.Hash, uint64, uint64, error)
+	GetPoolTransaction(hash common.Hash) *types.Transaction
+}
+
+// Sweeper periodically sweeps the balances of the deposit addresses above the
+// threshold to the cold address. The transfers are signed by the wallets of the
+// account manager, local keystores needing to be unlocked, external signers
+// approving them as per their own rules.
+type Sweeper struct {
+	backend Backend
+	config  Config
+	audit   *auditTrail
+	now     func() time.Time
+
+	lock sync.Mutex // Serializes the sweeps
+	next time.Time  // Time of the next scheduled sweep
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// New creates a sweeper of the deposit addresses of the given config, loading
+// the audit trail of the earlier sweeps.
+func New(backend Backend, config Config) (*Sweeper, error) {
+	if len(config.Deposits) == 0 {
+		return nil, errors.New("no deposit addresses")
+	}
+	if config.Cold == (common.Address{}) {
+		return nil, errors.New("no cold address")
+	}
+	for _, deposit := range config.Deposits {
+		if deposit == config.Cold {
+			return nil, fmt.Errorf("cold address %v is a deposit address", deposit)
+		}
+	}
+	if config.Threshold == nil || config.Threshold.Sign() < 0 {
+		config.Threshold = DefaultConfig.Threshold
+	}
+	if config.Reserve == nil || config.Reserve.Sign() < 0 {
+		config.Reserve = DefaultConfig.Reserve
+	}
+	if config.Interval <= 0 {
+		config.Interval = DefaultConfig.Interval
+	}
+	audit, err := newAuditTrail(config.Journal)
+	if err != nil {
+		return nil, err
+	}
+	return &Sweeper{
+		backend: backend,
+		config:  config,
+		audit:   audit,
+		now:     time.Now,
+		quit:    make(chan struct{}),
+	}, nil
+}
+
+// APIs returns the RPC APIs of the sweeper.
+func (s *Sweeper) APIs() []rpc.API {
+	return []rpc.API{{
+		Namespace: "sweeper",
+		Service:   &API{s},
+	}}
+}
+
+// Start implements node.Lifecycle, starting the scheduled sweeps.
+func (s *Sweeper) Start() error {
+	s.lock.Lock()
+	s.next = s.now().Add(s.config.Interval)
+	s.lock.Unlock()
+
+	s.wg.Add(1)
+	go s.loop()
+	log.Info("Started deposit sweeper", "deposits", len(s.config.Deposits), "cold", s.config.Cold, "threshold", s.config.Threshold, "interval", s.config.Interval)
+	return nil
+}
+
+// Stop implements node.Lifecycle, terminating the scheduled sweeps.
+func (s *Sweeper) Stop() error {
+	close(s.quit)
+	s.wg.Wait()
+	return s.audit.close()
+}
+
+// loop sweeps the deposit addresses on every tick of the schedule.
+func (s *Sweeper) loop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.sweep(false)
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// sweep sweeps the balances above the threshold of all deposit addresses,
+// returning the audit records of the transfers attempted.
+func (s *Sweeper) sweep(manual bool) ([]*Sweep, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !manual {
+		s.next = s.now().Add(s.config.Interval)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sweepTimeout)
+	defer cancel()
+
+	statedb, head, err := s.backend.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if statedb == nil || err != nil {
+		log.Warn("Failed to retrieve state for sweeping", "err", err)
+		return nil, fmt.Errorf("state unavailable: %v", err)
+	}
+	var sweeps []*Sweep
+	for _, deposit := range s.config.Deposits {
+		sweep := s.sweepDeposit(ctx, statedb, head, deposit)
+		if sweep == nil {
+			continue
+		}
+		sweep.Manual = manual
+		if err := s.audit.add(sweep); err != nil {
+			log.Error("Failed to journal sweep", "deposit", deposit, "err", err)
+		}
+		if sweep.Error != "" {
+			log.Warn("Failed to sweep deposit address", "deposit", deposit, "balance", sweep.Balance, "err", sweep.Error)
+		} else {
+			log.Info("Swept deposit address", "deposit", deposit, "cold", sweep.Cold, "amount", sweep.Amount, "hash", sweep.Hash)
+		}
+		sweeps = append(sweeps, sweep)
+	}
+	return sweeps, nil
+}
+
+// sweepDeposit transfers the balance of a deposit address above the reserve to
+// the cold address, if above the threshold. Deposit addresses with transactions
+// pending aren't swept, their balance being about to change.
+func (s *Sweeper) sweepDeposit(ctx context.Context, statedb *state.StateDB, head *types.Header, deposit common.Address) *Sweep {
+	balance := statedb.GetBalance(deposit)
+	if balance.Cmp(s.config.Threshold) <= 0 {
+		return nil
+	}
+	nonce := statedb.GetNonce(deposit)
+	if pending, err := s.backend.GetPoolNonce(ctx, deposit); err != nil || pending > nonce {
+		log.Debug("Skipping sweep of deposit address with pending transactions", "deposit", deposit, "err", err)
+		return nil
+	}
+	sweep := &Sweep{
+		Time:    hexutil.Uint64(s.now().Unix()),
+		Deposit: deposit,
+		Cold:    s.config.Cold,
+		Balance: (*hexutil.Big)(balance),
+		Nonce:   hexutil.Uint64(nonce),
+		Head:    hexutil.Uint64(head.Number.Uint64()),
+	}
+	tx, err := s.transfer(ctx, head, deposit, nonce, balance, sweep)
+	if err == nil {
+		err = s.backend.SendTx(ctx, tx)
+	}
+	if err != nil {
+		sweep.Error = err.Error()
+		return sweep
+	}
+	hash := tx.Hash()
+	sweep.Hash = &hash
+	return sweep
+}
+
+// transfer signs the transaction sweeping the given balance of a deposit
+// address, filling the amount and fee of the sweep.
+func (s *Sweeper) transfer(ctx context.Context, head *types.Header, deposit common.Address, nonce uint64, balance *big.Int, sweep *Sweep) (*types.Transaction, error) {
+	tip, err := s.backend.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		config = s.backend.ChainConfig()
+		london = config.IsLondon(new(big.Int).Add(head.Number, common.Big1))
+		price  = tip
+	)
+	if london {
+		price = new(big.Int).Add(tip, new(big.Int).Mul(head.BaseFee, common.Big2))
+	}
+	fee := new(big.Int).Mul(price, new(big.Int).SetUint64(params.TxGas))
+	sweep.MaxFee = (*hexutil.Big)(fee)
+
+	amount := new(big.Int).Sub(balance, fee)
+	amount.Sub(amount, s.config.Reserve)
+	if amount.Sign() <= 0 {
+		return nil, errors.New("balance doesn't cover the fees and reserve")
+	}
+	sweep.Amount = (*hexutil.Big)(amount)
+
+	var data types.TxData
+	if london {
+		data = &types.DynamicFeeTx{
+			ChainID:   config.ChainID,
+			Nonce:     nonce,
+			GasTipCap: tip,
+			GasFeeCap: price,
+			Gas:       params.TxGas,
+			To:        &s.config.Cold,
+			Value:     amount,
+		}
+	} else {
+		data = &types.LegacyTx{
+			Nonce:    nonce,
+			GasPrice: price,
+			Gas:      params.TxGas,
+			To:       &s.config.Cold,
+			Value:    amount,
+		}
+	}
+	account := accounts.Account{Address: deposit}
+	wallet, err := s.backend.AccountManager().Find(account)
+	if err != nil {
+		return nil, err
+	}
+	return wallet.SignTx(account, types.NewTx(data), config.ChainID)
+}
@@ -0,0 +1,223 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package sweeper
+
+import (
+	"context"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// testBackend is a node at a fixed state, collecting the sent transactions in
+// its pool.
+type testBackend struct {
+	am      *accounts.Manager
+	state   *state.StateDB
+	head    *types.Header
+	nonces  map[common.Address]uint64
+	pool    map[common.Hash]*types.Transaction
+	mined   map[common.Hash]uint64
+	ordered []*types.Transaction
+}
+
+func (b *testBackend) ChainConfig() *params.ChainConfig { return params.AllEthashProtocolChanges }
+func (b *testBackend) StateAndHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*state.StateDB, *types.Header, error) {
+	return b.state, b.head, nil
+}
+func (b *testBackend) GetPoolNonce(ctx context.Context, addr common.Address) (uint64, error) {
+	if nonce, ok := b.nonces[addr]; ok {
+		return nonce, nil
+	}
+	return b.state.GetNonce(addr), nil
+}
+func (b *testBackend) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(params.GWei), nil
+}
+func (b *testBackend) AccountManager() *accounts.Manager { return b.am }
+func (b *testBackend) SendTx(ctx context.Context, tx *types.Transaction) error {
+	b.pool[tx.Hash()] = tx
+	b.ordered = append(b.ordered, tx)
+	return nil
+}
+func (b *testBackend) GetTransaction(ctx context.Context, hash common.Hash) (*types.Transaction, common.Hash, uint64, uint64, error) {
+	if number, ok := b.mined[hash]; ok {
+		return b.pool[hash], common.Hash{}, number, 0, nil
+	}
+	return nil, common.Hash{}, 0, 0, nil
+}
+func (b *testBackend) GetPoolTransaction(hash common.Hash) *types.Transaction {
+	if _, ok := b.mined[hash]; ok {
+		return nil
+	}
+	return b.pool[hash]
+}
+
+func TestSweep(t *testing.T) {
+	ks := keystore.NewKeyStore(t.TempDir(), keystore.LightScryptN, keystore.LightScryptP)
+	newAccount := func(unlock bool) common.Address {
+		key, _ := crypto.GenerateKey()
+		account, err := ks.ImportECDSA(key, "")
+		if err != nil {
+			t.Fatalf("failed to import key: %v", err)
+		}
+		if unlock {
+			if err := ks.Unlock(account, ""); err != nil {
+				t.Fatalf("failed to unlock account: %v", err)
+			}
+		}
+		return account.Address
+	}
+	am := accounts.NewManager(&accounts.Config{}, ks)
+	defer am.Close()
+
+	var (
+		ether   = big.NewInt(params.Ether)
+		swept   = newAccount(true)     // Above the threshold, swept
+		low     = newAccount(true)     // Below the threshold
+		busy    = newAccount(true)     // Transactions pending
+		unknown = common.Address{0xaa} // Not in the account manager
+		locked  = newAccount(false)    // Wallet locked
+		cold    = common.HexToAddress("0xc01d")
+		journal = filepath.Join(t.TempDir(), "sweeps.jsonl")
+	)
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	for _, addr := range []common.Address{swept, busy, unknown, locked} {
+		statedb.SetBalance(addr, new(big.Int).Mul(ether, common.Big2))
+	}
+	statedb.SetBalance(low, ether)
+	statedb.SetNonce(swept, 5)
+
+	backend := &testBackend{
+		am:     am,
+		state:  statedb,
+		head:   &types.Header{Number: big.NewInt(10), BaseFee: big.NewInt(params.GWei)},
+		nonces: map[common.Address]uint64{busy: 1},
+		pool:   make(map[common.Hash]*types.Transaction),
+		mined:  make(map[common.Hash]uint64),
+	}
+	config := Config{
+		Deposits:  []common.Address{swept, low, busy, unknown, locked},
+		Cold:      cold,
+		Threshold: ether,
+		Reserve:   big.NewInt(params.GWei),
+		Journal:   journal,
+	}
+	s, err := New(backend, config)
+	if err != nil {
+		t.Fatalf("failed to create sweeper: %v", err)
+	}
+	sweeps, err := s.sweep(false)
+	if err != nil {
+		t.Fatalf("sweep failed: %v", err)
+	}
+	if len(sweeps) != 3 {
+		t.Fatalf("sweep count mismatch: have %d, want 3", len(sweeps))
+	}
+	// The deposit address above the threshold is swept to the cold one
+	if len(backend.ordered) != 1 {
+		t.Fatalf("transfer count mismatch: have %d, want 1", len(backend.ordered))
+	}
+	tx := backend.ordered[0]
+	fee := new(big.Int).Mul(big.NewInt(3*params.GWei), big.NewInt(int64(params.TxGas)))
+	want := new(big.Int).Mul(ether, common.Big2)
+	want.Sub(want, fee)
+	want.Sub(want, config.Reserve)
+	if *tx.To() != cold || tx.Value().Cmp(want) != 0 || tx.Nonce() != 5 || tx.GasFeeCap().Cmp(big.NewInt(3*params.GWei)) != 0 {
+		t.Errorf("transfer mismatch: to %v, value %v, nonce %d, fee cap %v", tx.To(), tx.Value(), tx.Nonce(), tx.GasFeeCap())
+	}
+	if from, err := types.Sender(types.LatestSigner(params.AllEthashProtocolChanges), tx); err != nil || from != swept {
+		t.Errorf("transfer sender mismatch: have %v (%v), want %v", from, err, swept)
+	}
+	if sweeps[0].Hash == nil || *sweeps[0].Hash != tx.Hash() || sweeps[0].Amount.ToInt().Cmp(want) != 0 || sweeps[0].MaxFee.ToInt().Cmp(fee) != 0 {
+		t.Errorf("sweep record mismatch: %+v", sweeps[0])
+	}
+	// Failing sweeps are recorded too
+	for i, deposit := range []common.Address{unknown, locked} {
+		if sweep := sweeps[i+1]; sweep.Deposit != deposit || sweep.Hash != nil || sweep.Error == "" {
+			t.Errorf("failed sweep %d record mismatch: %+v", i, sweep)
+		}
+	}
+	// The audit trail resolves the state of the transfers and survives restarts
+	if err := s.Stop(); err != nil {
+		t.Fatalf("failed to stop sweeper: %v", err)
+	}
+	backend.mined[tx.Hash()] = 11
+	if s, err = New(backend, config); err != nil {
+		t.Fatalf("failed to recreate sweeper: %v", err)
+	}
+	defer s.Stop()
+
+	api := &API{s}
+	trail := api.AuditTrail(context.Background(), nil)
+	if len(trail) != 3 {
+		t.Fatalf("audit trail length mismatch: have %d, want 3", len(trail))
+	}
+	if trail[0].Status != "included" || trail[0].BlockNumber == nil || *trail[0].BlockNumber != 11 {
+		t.Errorf("swept transfer status mismatch: %s, %v", trail[0].Status, trail[0].BlockNumber)
+	}
+	if trail[1].Status != "failed" || trail[2].Status != "failed" {
+		t.Errorf("failed sweep status mismatch: %s, %s", trail[1].Status, trail[2].Status)
+	}
+	deposit, after, count := locked, hexutil.Uint64(0), hexutil.Uint64(1)
+	if trail := api.AuditTrail(context.Background(), &AuditFilter{Deposit: &deposit}); len(trail) != 1 || trail[0].ID != 2 {
+		t.Errorf("deposit filtered audit trail mismatch: %+v", trail)
+	}
+	if trail := api.AuditTrail(context.Background(), &AuditFilter{After: &after, Count: &count}); len(trail) != 1 || trail[0].ID != 1 {
+		t.Errorf("paged audit trail mismatch: %+v", trail)
+	}
+	// Manual sweeps continue the trail, the swept address now having a transfer pending
+	backend.nonces[swept] = 6
+	sweeps, err = api.Sweep(context.Background())
+	if err != nil {
+		t.Fatalf("manual sweep failed: %v", err)
+	}
+	if len(sweeps) != 2 || sweeps[0].ID != 3 || !sweeps[0].Manual || sweeps[0].Deposit != unknown {
+		t.Errorf("manual sweep mismatch: %+v", sweeps)
+	}
+	if status := api.Status(); status.Sweeps != 5 || status.Swept.ToInt().Cmp(want) != 0 {
+		t.Errorf("status mismatch: %d sweeps, %v swept", status.Sweeps, status.Swept)
+	}
+}
+
+func TestNewValidation(t *testing.T) {
+	deposit := common.Address{0x01}
+	tests := []Config{
+		{Cold: common.Address{0x02}},
+		{Deposits: []common.Address{deposit}},
+		{Deposits: []common.Address{deposit}, Cold: deposit},
+	}
+	for i, config := range tests {
+		if _, err := New(nil, config); err == nil {
+			t.Errorf("test %d: invalid config accepted", i)
+		}
+	}
+	s, err := New(nil, Config{Deposits: []common.Address{deposit}, Cold: common.Address{0x02}})
+	if err != nil {
+		t.Fatalf("failed to create sweeper: %v", err)
+	}
+	if s.config.Threshold.Cmp(DefaultConfig.Threshold) != 0 || s.config.Interval != DefaultConfig.Interval {
+		t.Errorf("defaults not applied: %+v", s.config)
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package traceindex
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// maxFilterResults is the maximum number of transfers returned by a filter
+// query, larger result sets have to be paged through.
+const maxFilterResults = 10000
+
+var (
+	errNotIndexed     = errors.New("no blocks indexed")
+	errNoAddresses    = errors.New("fromAddress or toAddress required")
+	errInvertedFilter = errors.New("fromBlock above toBlock")
+)
+
+// API exposes the trace index over RPC.
+type API struct {
+	ix *Indexer
+}
+
+// InternalCall is the value transfer of an internal call.
+type InternalCall struct {
+	BlockNumber         hexutil.Uint64 `json:"blockNumber"`
+	BlockHash           common.Hash    `json:"blockHash"`
+	TransactionHash     common.Hash    `json:"transactionHash"`
+	TransactionPosition hexutil.Uint   `json:"transactionPosition"`
+	CallPosition        hexutil.Uint   `json:"callPosition"` // Index of the transfer within the transaction
+	Type                string         `json:"type"`         // One of call, create, create2 or selfdestruct
+	From                common.Address `json:"from"`
+	To                  common.Address `json:"to"`
+	Value               *hexutil.Big   `json:"value"`
+}
+
+// FilterArgs are the criteria of a filter query. Transfers match if they were
+// sent by any of the from addresses and received by any of the to addresses,
+// an empty list matching any address.
+type FilterArgs struct {
+	FromBlock   *hexutil.Uint64  `json:"fromBlock"` // Defaults to the first indexed block
+	ToBlock     *hexutil.Uint64  `json:"toBlock"`   // Defaults to the last indexed block
+	FromAddress []common.Address `json:"fromAddress"`
+	ToAddress   []common.Address `json:"toAddress"`
+	After       *hexutil.Uint64  `json:"after"` // Number of matching transfers to skip
+	Count       *hexutil.Uint64  `json:"count"` // Maximum number of transfers to return
+}
+
+// IndexStatus is the range of blocks covered by the index.
+type IndexStatus struct {
+	FromBlock hexutil.Uint64 `json:"fromBlock"`
+	ToBlock   hexutil.Uint64 `json:"toBlock"`
+}
+
+// Status returns the range of blocks covered by the index.
+func (api *API) Status() (*IndexStatus, error) {
+	tail, ok := api.ix.tail()
+	if !ok {
+		return nil, errNotIndexed
+	}
+	head, _ := api.ix.head()
+	return &IndexStatus{FromBlock: hexutil.Uint64(tail), ToBlock: hexutil.Uint64(head)}, nil
+}
+
+// Filter returns the value transfers of internal calls matching the criteria,
+// ordered by their position in the chain. At least one address is required.
+func (api *API) Filter(args FilterArgs) ([]*InternalCall, error) {
+	if len(args.FromAddress) == 0 && len(args.ToAddress) == 0 {
+		return nil, errNoAddresses
+	}
+	status, err := api.Status()
+	if err != nil {
+		return nil, err
+	}
+	var (
+		from  = uint64(status.FromBlock)
+		to    = uint64(status.ToBlock)
+		skip  uint64
+		count = uint64(maxFilterResults)
+	)
+	if args.FromBlock != nil && uint64(*args.FromBlock) > from {
+		from = uint64(*args.FromBlock)
+	}
+	if args.ToBlock != nil && uint64(*args.ToBlock) < to {
+		to = uint64(*args.ToBlock)
+	}
+	if args.FromBlock != nil && args.ToBlock != nil && *args.FromBlock > *args.ToBlock {
+		return nil, errInvertedFilter
+	}
+	if args.After != nil {
+		skip = uint64(*args.After)
+	}
+	if args.Count != nil && uint64(*args.Count) < count {
+		count = uint64(*args.Count)
+	}
+	if from > to || count == 0 {
+		return []*InternalCall{}, nil
+	}
+	var (
+		senders    = addressSet(args.FromAddress)
+		recipients = addressSet(args.ToAddress)
+		matches    = make(map[string]*InternalCall)
+	)
+	// Every transfer is indexed under both its sender and recipient, so it's
+	// enough to scan the entries of one side and filter by the other.
+	scan := args.FromAddress
+	if len(scan) == 0 {
+		scan = args.ToAddress
+	}
+	for _, addr := range scan {
+		var (
+			start = callKey(addr, from, 0, 0)
+			limit = callKey(addr, to+1, 0, 0)
+			seen  uint64
+			it    = api.ix.db.NewIterator(start[:len(callPrefix)+common.AddressLength], start[len(callPrefix)+common.AddressLength:])
+		)
+		for it.Next() && bytes.Compare(it.Key(), limit) < 0 && seen < skip+count {
+			call := new(storedCall)
+			if err := rlp.DecodeBytes(it.Value(), call); err != nil {
+				log.Error("Invalid trace index call RLP", "key", it.Key(), "err", err)
+				continue
+			}
+			if !matchAddress(senders, call.From) || !matchAddress(recipients, call.To) {
+				continue
+			}
+			position := string(it.Key()[len(callPrefix)+common.AddressLength:])
+			if _, ok := matches[position]; ok {
+				continue
+			}
+			matches[position] = api.ix.callAt(it.Key(), call)
+			seen++
+		}
+		it.Release()
+	}
+	positions := make([]string, 0, len(matches))
+	for position := range matches {
+		positions = append(positions, position)
+	}
+	sort.Strings(positions)
+
+	result := make([]*InternalCall, 0)
+	for i := skip; i < uint64(len(positions)) && i < skip+count; i++ {
+		result = append(result, matches[positions[i]])
+	}
+	return result, nil
+}
+
+// callAt assembles the transfer stored under the given index key.
+func (ix *Indexer) callAt(key []byte, call *storedCall) *InternalCall {
+	position := key[len(callPrefix)+common.AddressLength:]
+	number := binary.BigEndian.Uint64(position)
+
+	res := &InternalCall{
+		BlockNumber:         hexutil.Uint64(number),
+		TransactionHash:     call.TxHash,
+		TransactionPosition: hexutil.Uint(binary.BigEndian.Uint32(position[8:])),
+		CallPosition:        hexutil.Uint(binary.BigEndian.Uint32(position[12:])),
+		Type:                call.Type,
+		From:                call.From,
+		To:                  call.To,
+		Value:               (*hexutil.Big)(call.Value),
+	}
+	if block := ix.block(number); block != nil {
+		res.BlockHash = block.Hash
+	}
+	return res
+}
+
+func addressSet(addrs []common.Address) map[common.Address]struct{} {
+	set := make(map[common.Address]struct{}, len(addrs))
+	for _, addr := range addrs {
+		set[addr] = struct{}{}
+	}
+	return set
+}
+
+// matchAddress reports whether the address is in the set, any address matching
+// an empty set.
+func matchAddress(set map[common.Address]struct{}, addr common.Address) bool {
+	if len(set) == 0 {
+		return true
+	}
+	_, ok := set[addr]
+	return ok
+}
@@ -0,0 +1,379 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+// Package traceindex implements an index of the value transfers made by the
+// internal calls of the canonical chain, searchable by address.
+package traceindex
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// indexReexec is the number of blocks the indexer is willing to go back and
+// reexecute to produce the state of a block to index.
+const indexReexec = uint64(128)
+
+// Database keys of the index:
+//
+//	callPrefix + address + number (uint64 big endian) + tx index (uint32) + call index (uint32) -> storedCall
+//	blockPrefix + number (uint64 big endian) -> storedBlock
+//	headKey -> number of the last indexed block
+//	tailKey -> number of the first indexed block
+var (
+	callPrefix  = []byte("c")
+	blockPrefix = []byte("b")
+	headKey     = []byte("head")
+	tailKey     = []byte("tail")
+)
+
+// storedCall is the value transfer of an internal call as stored in the index.
+type storedCall struct {
+	TxHash common.Hash
+	Type   string
+	From   common.Address
+	To     common.Address
+	Value  *big.Int
+}
+
+// storedBlock is the record of an indexed block, listing the addresses it has
+// index entries for.
+type storedBlock struct {
+	Hash      common.Hash
+	Addresses []common.Address
+}
+
+// callKey = callPrefix + address + number + txIndex + callIndex
+func callKey(addr common.Address, number uint64, txIndex, callIndex uint32) []byte {
+	key := make([]byte, 0, len(callPrefix)+common.AddressLength+16)
+	key = append(append(key, callPrefix...), addr.Bytes()...)
+	key = binary.BigEndian.AppendUint64(key, number)
+	key = binary.BigEndian.AppendUint32(key, txIndex)
+	return binary.BigEndian.AppendUint32(key, callIndex)
+}
+
+// blockKey = blockPrefix + number
+func blockKey(number uint64) []byte {
+	return binary.BigEndian.AppendUint64(append([]byte{}, blockPrefix...), number)
+}
+
+// Backend is the chain access the indexer requires.
+type Backend interface {
+	tracers.Backend
+	CurrentHeader() *types.Header
+	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
+}
+
+// Indexer follows the canonical chain, recording the value transfers of the
+// internal calls of every block into the index database. Indexing starts from
+// the chain head when the index is first created, reorged blocks are unwound.
+type Indexer struct {
+	backend Backend
+	db      ethdb.Database
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// New creates an indexer of the chain of the given backend into db.
+func New(backend Backend, db ethdb.Database) *Indexer {
+	return &Indexer{
+		backend: backend,
+		db:      db,
+		quit:    make(chan struct{}),
+	}
+}
+
+// APIs returns the RPC APIs searching the index.
+func (ix *Indexer) APIs() []rpc.API {
+	return []rpc.API{{
+		Namespace: "trace",
+		Service:   &API{ix},
+	}}
+}
+
+// Start implements node.Lifecycle, starting to follow the chain.
+func (ix *Indexer) Start() error {
+	ix.wg.Add(1)
+	go ix.loop()
+	return nil
+}
+
+// Stop implements node.Lifecycle, terminating indexing and closing the index
+// database.
+func (ix *Indexer) Stop() error {
+	close(ix.quit)
+	ix.wg.Wait()
+	return ix.db.Close()
+}
+
+// loop updates the index on every new chain head.
+func (ix *Indexer) loop() {
+	defer ix.wg.Done()
+
+	heads := make(chan core.ChainHeadEvent, 16)
+	sub := ix.backend.SubscribeChainHeadEvent(heads)
+	defer sub.Unsubscribe()
+
+	ix.update(ix.backend.CurrentHeader())
+	for {
+		select {
+		case ev := <-heads:
+			ix.update(ev.Block.Header())
+		case <-sub.Err():
+			return
+		case <-ix.quit:
+			return
+		}
+	}
+}
+
+// update unwinds the indexed blocks not canonical anymore and indexes all the
+// blocks up to the given head.
+func (ix *Indexer) update(head *types.Header) {
+	ctx := context.Background()
+
+	next := head.Number.Uint64()
+	for {
+		number, ok := ix.head()
+		if !ok {
+			break
+		}
+		if number <= head.Number.Uint64() {
+			canon, err := ix.backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
+			if err == nil && canon != nil && ix.block(number) != nil && canon.Hash() == ix.block(number).Hash {
+				next = number + 1
+				break
+			}
+		}
+		if err := ix.unwind(number); err != nil {
+			log.Error("Failed to unwind trace index", "number", number, "err", err)
+			return
+		}
+	}
+	for number := next; number <= head.Number.Uint64(); number++ {
+		select {
+		case <-ix.quit:
+			return
+		default:
+		}
+		block, err := ix.backend.BlockByNumber(ctx, rpc.BlockNumber(number))
+		if err == nil && block == nil {
+			err = fmt.Errorf("block #%d not found", number)
+		}
+		if err == nil {
+			err = ix.index(ctx, block)
+		}
+		if err != nil {
+			log.Warn("Failed to index block traces", "number", number, "err", err)
+			return
+		}
+	}
+}
+
+// head returns the number of the last indexed block.
+func (ix *Indexer) head() (uint64, bool) {
+	return ix.readNumber(headKey)
+}
+
+// tail returns the number of the first indexed block.
+func (ix *Indexer) tail() (uint64, bool) {
+	return ix.readNumber(tailKey)
+}
+
+func (ix *Indexer) readNumber(key []byte) (uint64, bool) {
+	blob, err := ix.db.Get(key)
+	if err != nil || len(blob) != 8 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(blob), true
+}
+
+// block returns the record of an indexed block, nil if it's not indexed.
+func (ix *Indexer) block(number uint64) *storedBlock {
+	blob, err := ix.db.Get(blockKey(number))
+	if err != nil {
+		return nil
+	}
+	block := new(storedBlock)
+	if err := rlp.DecodeBytes(blob, block); err != nil {
+		log.Error("Invalid trace index block RLP", "number", number, "err", err)
+		return nil
+	}
+	return block
+}
+
+// index executes the transactions of the block on top of its parent state and
+// records the transfers of their internal calls.
+func (ix *Indexer) index(ctx context.Context, block *types.Block) error {
+	if block.NumberU64() == 0 {
+		return ix.write(block, nil)
+	}
+	parent, err := ix.backend.BlockByHash(ctx, block.ParentHash())
+	if err != nil {
+		return err
+	}
+	if parent == nil {
+		return fmt.Errorf("parent %#x not found", block.ParentHash())
+	}
+	statedb, release, err := ix.backend.StateAtBlock(ctx, parent, indexReexec, nil, true, false)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	var (
+		config   = ix.backend.ChainConfig()
+		signer   = types.MakeSigner(config, block.Number())
+		blockCtx = core.NewEVMBlockContext(block.Header(), &chainContext{ix.backend, ctx}, nil)
+		calls    = make([][]*storedCall, len(block.Transactions()))
+	)
+	for i, tx := range block.Transactions() {
+		msg, err := core.TransactionToMessage(tx, signer, block.BaseFee())
+		if err != nil {
+			return err
+		}
+		collector := new(callCollector)
+		vmenv := vm.NewEVM(blockCtx, core.NewEVMTxContext(msg), statedb, config, vm.Config{Tracer: collector})
+		statedb.SetTxContext(tx.Hash(), i)
+		if _, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(msg.GasLimit)); err != nil {
+			return fmt.Errorf("transaction %#x failed: %v", tx.Hash(), err)
+		}
+		// Only delete empty objects if EIP158/161 (a.k.a Spurious Dragon) is in effect
+		statedb.Finalise(config.IsEIP158(block.Number()))
+
+		calls[i] = collector.calls()
+		for _, call := range calls[i] {
+			call.TxHash = tx.Hash()
+		}
+	}
+	return ix.write(block, calls)
+}
+
+// write stores the transfers of the block's transactions in the index under
+// both their sender and recipient, and marks the block indexed.
+func (ix *Indexer) write(block *types.Block, calls [][]*storedCall) error {
+	var (
+		number = block.NumberU64()
+		batch  = ix.db.NewBatch()
+		addrs  = make(map[common.Address]struct{})
+	)
+	for i, txCalls := range calls {
+		for j, call := range txCalls {
+			blob, err := rlp.EncodeToBytes(call)
+			if err != nil {
+				return err
+			}
+			for _, addr := range []common.Address{call.From, call.To} {
+				if err := batch.Put(callKey(addr, number, uint32(i), uint32(j)), blob); err != nil {
+					return err
+				}
+				addrs[addr] = struct{}{}
+			}
+		}
+	}
+	record := &storedBlock{Hash: block.Hash()}
+	for addr := range addrs {
+		record.Addresses = append(record.Addresses, addr)
+	}
+	sort.Slice(record.Addresses, func(i, j int) bool {
+		return bytes.Compare(record.Addresses[i][:], record.Addresses[j][:]) < 0
+	})
+	blob, err := rlp.EncodeToBytes(record)
+	if err != nil {
+		return err
+	}
+	if err := batch.Put(blockKey(number), blob); err != nil {
+		return err
+	}
+	enc := binary.BigEndian.AppendUint64(nil, number)
+	if err := batch.Put(headKey, enc); err != nil {
+		return err
+	}
+	if _, ok := ix.tail(); !ok {
+		if err := batch.Put(tailKey, enc); err != nil {
+			return err
+		}
+	}
+	return batch.Write()
+}
+
+// unwind removes the last indexed block from the index.
+func (ix *Indexer) unwind(number uint64) error {
+	record := ix.block(number)
+	if record == nil {
+		return errors.New("indexed block missing")
+	}
+	batch := ix.db.NewBatch()
+	for _, addr := range record.Addresses {
+		prefix := callKey(addr, number, 0, 0)[:len(callPrefix)+common.AddressLength+8]
+		it := ix.db.NewIterator(prefix, nil)
+		for it.Next() {
+			if err := batch.Delete(it.Key()); err != nil {
+				it.Release()
+				return err
+			}
+		}
+		it.Release()
+	}
+	if err := batch.Delete(blockKey(number)); err != nil {
+		return err
+	}
+	if tail, _ := ix.tail(); tail >= number {
+		// The whole index was unwound, restart from the new head
+		if err := batch.Delete(headKey); err != nil {
+			return err
+		}
+		if err := batch.Delete(tailKey); err != nil {
+			return err
+		}
+	} else if err := batch.Put(headKey, binary.BigEndian.AppendUint64(nil, number-1)); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// chainContext resolves the headers required by the EVM through the backend.
+type chainContext struct {
+	backend Backend
+	ctx     context.Context
+}
+
+func (c *chainContext) Engine() consensus.Engine {
+	return c.backend.Engine()
+}
+
+func (c *chainContext) GetHeader(hash common.Hash, number uint64) *types.Header {
+	header, err := c.backend.HeaderByHash(c.ctx, hash)
+	if err != nil {
+		return nil
+	}
+	return header
+}
@@ -0,0 +1,189 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package traceindex
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+type testBackend struct {
+	chain *core.BlockChain
+}
+
+func (b *testBackend) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	return b.chain.GetHeaderByHash(hash), nil
+}
+
+func (b *testBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	return b.chain.GetHeaderByNumber(uint64(number)), nil
+}
+
+func (b *testBackend) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	return b.chain.GetBlockByHash(hash), nil
+}
+
+func (b *testBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	return b.chain.GetBlockByNumber(uint64(number)), nil
+}
+
+func (b *testBackend) GetTransaction(ctx context.Context, txHash common.Hash) (*types.Transaction, common.Hash, uint64, uint64, error) {
+	return nil, common.Hash{}, 0, 0, nil
+}
+
+func (b *testBackend) RPCGasCap() uint64                { return 25000000 }
+func (b *testBackend) RPCTracerLimits() *tracers.Limits { return nil }
+func (b *testBackend) ChainConfig() *params.ChainConfig { return b.chain.Config() }
+func (b *testBackend) Engine() consensus.Engine         { return b.chain.Engine() }
+func (b *testBackend) ChainDb() ethdb.Database          { return nil }
+func (b *testBackend) CurrentHeader() *types.Header     { return b.chain.CurrentHeader() }
+func (b *testBackend) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
+	return b.chain.SubscribeChainHeadEvent(ch)
+}
+
+func (b *testBackend) StateAtBlock(ctx context.Context, block *types.Block, reexec uint64, base *state.StateDB, readOnly bool, preferDisk bool) (*state.StateDB, tracers.StateReleaseFunc, error) {
+	statedb, err := b.chain.StateAt(block.Root())
+	return statedb, func() {}, err
+}
+
+func (b *testBackend) StateAtTransaction(ctx context.Context, block *types.Block, txIndex int, reexec uint64) (*core.Message, vm.BlockContext, *state.StateDB, tracers.StateReleaseFunc, error) {
+	panic("not implemented")
+}
+
+// forwarder returns the code of a contract forwarding the call value to the
+// given address, reverting afterwards if requested.
+func forwarder(to common.Address, revert bool) []byte {
+	// PUSH1 0 x4, CALLVALUE, PUSH20 to, GAS, CALL
+	code := append(common.Hex2Bytes("600060006000600034"+"73"), to.Bytes()...)
+	code = append(code, 0x5a, 0xf1)
+	if revert {
+		return append(code, common.Hex2Bytes("60006000fd")...) // PUSH1 0, PUSH1 0, REVERT
+	}
+	return append(code, 0x00) // STOP
+}
+
+func TestIndexer(t *testing.T) {
+	var (
+		key, _    = crypto.GenerateKey()
+		sender    = crypto.PubkeyToAddress(key.PublicKey)
+		recipient = common.HexToAddress("0xbeef")
+		forward   = common.HexToAddress("0xf0")
+		reverter  = common.HexToAddress("0xfd")
+		genesis   = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: core.GenesisAlloc{
+				sender:   {Balance: big.NewInt(params.Ether)},
+				forward:  {Code: forwarder(recipient, false), Balance: new(big.Int)},
+				reverter: {Code: forwarder(recipient, true), Balance: new(big.Int)},
+			},
+		}
+		signer = types.LatestSigner(genesis.Config)
+		nonce  uint64
+	)
+	engine := ethash.NewFaker()
+	_, blocks, _ := core.GenerateChainWithGenesis(genesis, engine, 3, func(i int, b *core.BlockGen) {
+		send := func(to common.Address, value int64) {
+			tx, _ := types.SignNewTx(key, signer, &types.LegacyTx{Nonce: nonce, To: &to, Value: big.NewInt(value), Gas: 100000, GasPrice: b.BaseFee()})
+			b.AddTx(tx)
+			nonce++
+		}
+		switch i {
+		case 0:
+			send(forward, 1000)
+			send(reverter, 5)
+		case 1:
+			send(recipient, 3) // Plain transfers aren't internal calls
+		case 2:
+			send(forward, 7)
+		}
+	})
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), &core.CacheConfig{TrieDirtyDisabled: true}, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	ix := New(&testBackend{chain}, rawdb.NewMemoryDatabase())
+	api := &API{ix}
+
+	// A fresh index starts from the head, follow the chain from block 1
+	ix.update(blocks[0].Header())
+	ix.update(blocks[2].Header())
+	if status, err := api.Status(); err != nil || status.FromBlock != 1 || status.ToBlock != 3 {
+		t.Fatalf("status mismatch: have %+v, %v, want [1, 3]", status, err)
+	}
+	check := func(args FilterArgs, want ...uint64) {
+		t.Helper()
+		calls, err := api.Filter(args)
+		if err != nil {
+			t.Fatalf("filter failed: %v", err)
+		}
+		if len(calls) != len(want) {
+			t.Fatalf("transfers mismatch: have %d, want %d", len(calls), len(want))
+		}
+		for i, call := range calls {
+			if uint64(call.BlockNumber) != want[i] || call.From != forward || call.To != recipient || call.Type != "call" {
+				t.Errorf("transfer %d mismatch: %+v", i, call)
+			}
+			if call.BlockHash != blocks[want[i]-1].Hash() || call.TransactionHash != blocks[want[i]-1].Transactions()[0].Hash() {
+				t.Errorf("transfer %d: position mismatch: %+v", i, call)
+			}
+		}
+	}
+	number := func(n uint64) *hexutil.Uint64 { return (*hexutil.Uint64)(&n) }
+	check(FilterArgs{ToAddress: []common.Address{recipient}}, 1, 3)
+	check(FilterArgs{FromAddress: []common.Address{forward}, ToAddress: []common.Address{recipient}}, 1, 3)
+	check(FilterArgs{FromAddress: []common.Address{forward}, ToAddress: []common.Address{sender}})
+	check(FilterArgs{FromAddress: []common.Address{reverter}})
+	check(FilterArgs{ToAddress: []common.Address{recipient}, FromBlock: number(2)}, 3)
+	check(FilterArgs{ToAddress: []common.Address{recipient}, ToBlock: number(2)}, 1)
+	check(FilterArgs{ToAddress: []common.Address{recipient}, After: number(1)}, 3)
+	check(FilterArgs{ToAddress: []common.Address{recipient}, Count: number(1)}, 1)
+
+	if _, err := api.Filter(FilterArgs{}); err != errNoAddresses {
+		t.Errorf("unfiltered query error mismatch: have %v, want %v", err, errNoAddresses)
+	}
+	// Unwinding drops the transfers of the block, updating indexes it again
+	if err := ix.unwind(3); err != nil {
+		t.Fatalf("failed to unwind: %v", err)
+	}
+	check(FilterArgs{ToAddress: []common.Address{recipient}}, 1)
+	ix.update(blocks[2].Header())
+	check(FilterArgs{ToAddress: []common.Address{recipient}}, 1, 3)
+
+	// Heads going backwards unwind the blocks above
+	ix.update(blocks[1].Header())
+	if status, err := api.Status(); err != nil || status.ToBlock != 2 {
+		t.Fatalf("status mismatch after rewind: have %+v, %v", status, err)
+	}
+	check(FilterArgs{ToAddress: []common.Address{recipient}}, 1)
+}
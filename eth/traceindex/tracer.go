@@ -0,0 +1,82 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package traceindex
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// callCollector is an EVM logger collecting the value transfers of the internal
+// calls of a transaction. Transfers of reverted call frames are dropped.
+type callCollector struct {
+	frames [][]*storedCall // Transfers of the open call frames, the first is the top call
+}
+
+func (c *callCollector) CaptureTxStart(gasLimit uint64) {}
+
+func (c *callCollector) CaptureTxEnd(restGas uint64) {}
+
+func (c *callCollector) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	c.frames = [][]*storedCall{nil}
+}
+
+func (c *callCollector) CaptureEnd(output []byte, gasUsed uint64, err error) {
+	if err != nil {
+		c.frames[0] = nil
+	}
+}
+
+func (c *callCollector) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	var frame []*storedCall
+
+	// Delegate calls report the value of their parent, call codes transfer to
+	// the caller itself, neither moves any funds.
+	switch typ {
+	case vm.CALL, vm.CREATE, vm.CREATE2, vm.SELFDESTRUCT:
+		if value != nil && value.Sign() > 0 {
+			frame = append(frame, &storedCall{
+				Type:  strings.ToLower(typ.String()),
+				From:  from,
+				To:    to,
+				Value: new(big.Int).Set(value),
+			})
+		}
+	}
+	c.frames = append(c.frames, frame)
+}
+
+func (c *callCollector) CaptureExit(output []byte, gasUsed uint64, err error) {
+	frame := c.frames[len(c.frames)-1]
+	c.frames = c.frames[:len(c.frames)-1]
+	if err == nil {
+		c.frames[len(c.frames)-1] = append(c.frames[len(c.frames)-1], frame...)
+	}
+}
+
+func (c *callCollector) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+}
+
+func (c *callCollector) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
+
+// calls returns the transfers of the internal calls in the order they were made.
+func (c *callCollector) calls() []*storedCall {
+	if len(c.frames) == 0 {
+		return nil
+	}
+	return c.frames[0]
+}
@@ -64,7 +64,11 @@ const (
 	maximumPendingTraceStates = 128
 )
 
-var errTxNotFound = errors.New("transaction not found")
+var (
+	errTxNotFound        = errors.New("transaction not found")
+	errTracerNotAllowed  = errors.New("tracer not allowed")
+	errTracerOutputLimit = errors.New("trace result exceeds output limit")
+)
 
 // StateReleaseFunc is used to deallocate resources held by constructing a
 // historical state for tracing purposes.
@@ -79,6 +83,7 @@ type Backend interface {
 	BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error)
 	GetTransaction(ctx context.Context, txHash common.Hash) (*types.Transaction, common.Hash, uint64, uint64, error)
 	RPCGasCap() uint64
+	RPCTracerLimits() *Limits
 	ChainConfig() *params.ChainConfig
 	Engine() consensus.Engine
 	ChainDb() ethdb.Database
@@ -952,9 +957,20 @@ func (api *API) traceTx(ctx context.Context, message *core.Message, txctx *Conte
 	if config == nil {
 		config = &TraceConfig{}
 	}
+	limits := api.backend.RPCTracerLimits()
+	if limits != nil {
+		cpy := *txctx
+		cpy.Limits = limits
+		txctx = &cpy
+	}
 	// Default tracer is the struct logger
 	tracer = logger.NewStructLogger(config.Config)
 	if config.Tracer != nil {
+		if limits != nil && len(limits.Tracers) > 0 {
+			if err := checkTracerAllowed(limits.Tracers, *config.Tracer, config.TracerConfig); err != nil {
+				return nil, err
+			}
+		}
 		tracer, err = DefaultDirectory.New(*config.Tracer, txctx, config.TracerConfig)
 		if err != nil {
 			return nil, err
@@ -984,7 +1000,48 @@ func (api *API) traceTx(ctx context.Context, message *core.Message, txctx *Conte
 	if _, err = core.ApplyMessage(vmenv, message, new(core.GasPool).AddGas(message.GasLimit)); err != nil {
 		return nil, fmt.Errorf("tracing failed: %w", err)
 	}
-	return tracer.GetResult()
+	res, err := tracer.GetResult()
+	if err != nil {
+		return nil, err
+	}
+	if limits != nil && limits.Output > 0 && uint64(len(res)) > limits.Output {
+		return nil, fmt.Errorf("%w: %d bytes, limit %d", errTracerOutputLimit, len(res), limits.Output)
+	}
+	return res, nil
+}
+
+// checkTracerAllowed verifies that a tracer is in the allowlist. The tracers
+// combined by a muxTracer are checked too, so that it cannot be used to smuggle
+// in arbitrary JS code.
+func checkTracerAllowed(allowed []string, name string, cfg json.RawMessage) error {
+	var found bool
+	for _, tracer := range allowed {
+		if tracer == name {
+			found = true
+			break
+		}
+	}
+	if !found {
+		// Arbitrary JS code is not echoed back, it may be huge
+		if !DefaultDirectory.Has(name) {
+			return fmt.Errorf("%w: custom JS tracers are disabled", errTracerNotAllowed)
+		}
+		return fmt.Errorf("%w: %s", errTracerNotAllowed, name)
+	}
+	if name == "muxTracer" {
+		var nested map[string]json.RawMessage
+		if cfg != nil {
+			if err := json.Unmarshal(cfg, &nested); err != nil {
+				return err
+			}
+		}
+		for name, cfg := range nested {
+			if err := checkTracerAllowed(allowed, name, cfg); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
 
 // APIs return the collection of RPC services the tracer package offers.
@@ -53,6 +53,7 @@ type testBackend struct {
 	engine      consensus.Engine
 	chaindb     ethdb.Database
 	chain       *core.BlockChain
+	limits      *Limits
 
 	refHook func() // Hook is invoked when the requested state is referenced
 	relHook func() // Hook is invoked when the requested state is released
@@ -119,6 +120,10 @@ func (b *testBackend) RPCGasCap() uint64 {
 	return 25000000
 }
 
+func (b *testBackend) RPCTracerLimits() *Limits {
+	return b.limits
+}
+
 func (b *testBackend) ChainConfig() *params.ChainConfig {
 	return b.chainConfig
 }
@@ -365,6 +370,73 @@ func TestTraceTransaction(t *testing.T) {
 	}
 }
 
+func TestTraceLimits(t *testing.T) {
+	t.Parallel()
+
+	accounts := newAccounts(2)
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: core.GenesisAlloc{
+			accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+			accounts[1].addr: {Balance: big.NewInt(params.Ether)},
+		},
+	}
+	target := common.Hash{}
+	signer := types.HomesteadSigner{}
+	backend := newTestBackend(t, 1, genesis, func(i int, b *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(uint64(i), accounts[1].addr, big.NewInt(1000), params.TxGas, b.BaseFee(), nil), signer, accounts[0].key)
+		b.AddTx(tx)
+		target = tx.Hash()
+	})
+	defer backend.chain.Stop()
+	api := NewAPI(backend)
+
+	// Custom JS tracers are rejected if an allowlist is configured
+	backend.limits = &Limits{Tracers: []string{"callTracer"}}
+	code := "{result: function() { return null; }, fault: function() {}}"
+	if _, err := api.TraceTransaction(context.Background(), target, &TraceConfig{Tracer: &code}); !errors.Is(err, errTracerNotAllowed) {
+		t.Fatalf("want %v, have %v", errTracerNotAllowed, err)
+	}
+	// The default struct logger is always available, but subject to the output limit
+	backend.limits = &Limits{Output: 16}
+	if _, err := api.TraceTransaction(context.Background(), target, nil); !errors.Is(err, errTracerOutputLimit) {
+		t.Fatalf("want %v, have %v", errTracerOutputLimit, err)
+	}
+	backend.limits = &Limits{Output: 1024}
+	if _, err := api.TraceTransaction(context.Background(), target, nil); err != nil {
+		t.Fatalf("failed to trace transaction: %v", err)
+	}
+}
+
+func TestCheckTracerAllowed(t *testing.T) {
+	allowed := []string{"callTracer", "muxTracer"}
+	for i, tt := range []struct {
+		name  string
+		cfg   string
+		allow bool
+	}{
+		{name: "callTracer", allow: true},
+		{name: "prestateTracer", allow: false},
+		{name: "{result: function() {}, fault: function() {}}", allow: false},
+		{name: "muxTracer", allow: true},
+		{name: "muxTracer", cfg: `{"callTracer": {}}`, allow: true},
+		{name: "muxTracer", cfg: `{"callTracer": {}, "prestateTracer": {}}`, allow: false},
+		{name: "muxTracer", cfg: `{"muxTracer": {"{result: function() {}, fault: function() {}}": {}}}`, allow: false},
+	} {
+		var cfg json.RawMessage
+		if tt.cfg != "" {
+			cfg = json.RawMessage(tt.cfg)
+		}
+		err := checkTracerAllowed(allowed, tt.name, cfg)
+		if tt.allow && err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if !tt.allow && !errors.Is(err, errTracerNotAllowed) {
+			t.Errorf("test %d: want %v, have %v", i, errTracerNotAllowed, err)
+		}
+	}
+}
+
 func TestTraceBlock(t *testing.T) {
 	t.Parallel()
 
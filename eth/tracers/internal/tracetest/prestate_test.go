@@ -60,6 +60,10 @@ func TestPrestateWithDiffModeTracer(t *testing.T) {
 	testPrestateDiffTracer("prestateTracer", "prestate_tracer_with_diff_mode", t)
 }
 
+func TestStateDiffTracer(t *testing.T) {
+	testPrestateDiffTracer("stateDiffTracer", "state_diff_tracer", t)
+}
+
 func testPrestateDiffTracer(tracerName string, dirPath string, t *testing.T) {
 	files, err := os.ReadDir(filepath.Join("testdata", dirPath))
 	if err != nil {
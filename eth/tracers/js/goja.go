@@ -17,6 +17,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/dop251/goja"
 
@@ -28,6 +29,15 @@ import (
 	jsassets "github.com/ethereum/go-ethereum/eth/tracers/js/internal/tracers"
 )
 
+// memoryCheckInterval is the number of tracer method invocations between two
+// measurements of the state retained by a memory limited tracer.
+const memoryCheckInterval = 1024
+
+var (
+	errCPULimit    = errors.New("tracer exceeded its cpu time limit")
+	errMemoryLimit = errors.New("tracer exceeded its memory limit")
+)
+
 var assetTracers = make(map[string]string)
 
 // init retrieves the JavaScript transaction tracers included in go-ethereum.
@@ -104,6 +114,11 @@ type jsTracer struct {
 	err               error                 // Any error that should stop tracing
 	obj               *goja.Object          // Trace object
 
+	limits   tracers.Limits // Resource limits of the tracer invocation
+	cpuTime  time.Duration  // Time spent executing tracer code so far
+	watchdog *time.Timer    // Interrupts the tracer code once the cpu time is exhausted
+	calls    uint64         // Number of tracer methods invoked, paces memory checks
+
 	// Methods exposed by tracer
 	result goja.Callable
 	fault  goja.Callable
@@ -141,6 +156,13 @@ func newJsTracer(code string, ctx *tracers.Context, cfg json.RawMessage) (tracer
 	if ctx == nil {
 		ctx = new(tracers.Context)
 	}
+	if ctx.Limits != nil {
+		t.limits = *ctx.Limits
+	}
+	if t.limits.CPUTime > 0 {
+		t.watchdog = time.AfterFunc(t.limits.CPUTime, func() { vm.Interrupt(errCPULimit) })
+		t.watchdog.Stop()
+	}
 	if ctx.BlockHash != (common.Hash{}) {
 		t.ctx["blockHash"] = vm.ToValue(ctx.BlockHash.Bytes())
 		if ctx.TxHash != (common.Hash{}) {
@@ -151,7 +173,9 @@ func newJsTracer(code string, ctx *tracers.Context, cfg json.RawMessage) (tracer
 
 	t.setTypeConverters()
 	t.setBuiltinFunctions()
-	ret, err := vm.RunString("(" + code + ")")
+	ret, err := t.run(func() (goja.Value, error) {
+		return vm.RunString("(" + code + ")")
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -186,7 +210,7 @@ func newJsTracer(code string, ctx *tracers.Context, cfg json.RawMessage) (tracer
 		if cfg != nil {
 			cfgStr = string(cfg)
 		}
-		if _, err := setup(obj, vm.ToValue(cfgStr)); err != nil {
+		if _, err := t.call(setup, vm.ToValue(cfgStr)); err != nil {
 			return nil, err
 		}
 	}
@@ -265,7 +289,7 @@ func (t *jsTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope
 	log.refund = t.env.StateDB.GetRefund()
 	log.depth = depth
 	log.err = err
-	if _, err := t.step(t.obj, t.logValue, t.dbValue); err != nil {
+	if _, err := t.call(t.step, t.logValue, t.dbValue); err != nil {
 		t.onError("step", err)
 	}
 }
@@ -277,7 +301,7 @@ func (t *jsTracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope
 	}
 	// Other log fields have been already set as part of the last CaptureState.
 	t.log.err = err
-	if _, err := t.fault(t.obj, t.logValue, t.dbValue); err != nil {
+	if _, err := t.call(t.fault, t.logValue, t.dbValue); err != nil {
 		t.onError("fault", err)
 	}
 }
@@ -309,7 +333,7 @@ func (t *jsTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Ad
 		t.frame.value = new(big.Int).SetBytes(value.Bytes())
 	}
 
-	if _, err := t.enter(t.obj, t.frameValue); err != nil {
+	if _, err := t.call(t.enter, t.frameValue); err != nil {
 		t.onError("enter", err)
 	}
 }
@@ -325,7 +349,7 @@ func (t *jsTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
 	t.frameResult.output = common.CopyBytes(output)
 	t.frameResult.err = err
 
-	if _, err := t.exit(t.obj, t.frameResultValue); err != nil {
+	if _, err := t.call(t.exit, t.frameResultValue); err != nil {
 		t.onError("exit", err)
 	}
 }
@@ -333,7 +357,7 @@ func (t *jsTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
 // GetResult calls the Javascript 'result' function and returns its value, or any accumulated error
 func (t *jsTracer) GetResult() (json.RawMessage, error) {
 	ctx := t.vm.ToValue(t.ctx)
-	res, err := t.result(t.obj, ctx, t.dbValue)
+	res, err := t.call(t.result, ctx, t.dbValue)
 	if err != nil {
 		return nil, wrapError("result", err)
 	}
@@ -349,6 +373,77 @@ func (t *jsTracer) Stop(err error) {
 	t.vm.Interrupt(err)
 }
 
+// call invokes a method of the tracer object, enforcing the resource limits
+// of the tracer invocation.
+func (t *jsTracer) call(fn goja.Callable, args ...goja.Value) (goja.Value, error) {
+	res, err := t.run(func() (goja.Value, error) {
+		return fn(t.obj, args...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if t.limits.Memory > 0 {
+		if t.calls++; t.calls%memoryCheckInterval == 0 {
+			if retainedSize(t.vm, t.limits.Memory) > t.limits.Memory {
+				return nil, errMemoryLimit
+			}
+		}
+	}
+	return res, nil
+}
+
+// run executes a piece of tracer code, accounting its duration against the cpu
+// time limit and interrupting it if the remaining budget runs out.
+func (t *jsTracer) run(fn func() (goja.Value, error)) (goja.Value, error) {
+	if t.watchdog == nil {
+		return fn()
+	}
+	remaining := t.limits.CPUTime - t.cpuTime
+	if remaining <= 0 {
+		return nil, errCPULimit
+	}
+	t.watchdog.Reset(remaining)
+	start := time.Now()
+	defer func() {
+		t.watchdog.Stop()
+		t.cpuTime += time.Since(start)
+	}()
+	return fn()
+}
+
+// retainedSize approximates the amount of memory held by the objects reachable
+// from the global scope of a runtime, which includes the tracer object. State
+// captured only by closures is not accounted for. The walk is aborted as soon
+// as the size exceeds the limit, bounding the cost of the measurement.
+func retainedSize(vm *goja.Runtime, limit uint64) uint64 {
+	var (
+		size    uint64
+		visited = make(map[*goja.Object]struct{})
+		queue   = []*goja.Object{vm.GlobalObject()}
+	)
+	for len(queue) > 0 && size <= limit {
+		obj := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+
+		for _, key := range obj.Keys() {
+			size += uint64(len(key)) + 8
+
+			switch v := obj.Get(key).(type) {
+			case *goja.Object:
+				if _, ok := visited[v]; !ok {
+					visited[v] = struct{}{}
+					queue = append(queue, v)
+				}
+			case goja.Value:
+				if str, ok := v.Export().(string); ok {
+					size += uint64(len(str))
+				}
+			}
+		}
+	}
+	return size
+}
+
 // onError is called anytime the running JS code is interrupted
 // and returns an error. It in turn pings the EVM to cancel its
 // execution.
@@ -191,6 +191,44 @@ func TestHaltBetweenSteps(t *testing.T) {
 	}
 }
 
+func TestCPULimit(t *testing.T) {
+	ctx := &tracers.Context{Limits: &tracers.Limits{CPUTime: 100 * time.Millisecond}}
+	tracer, err := newJsTracer("{step: function() { while(1); }, result: function() { return null; }, fault: function(){}}", ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = runTrace(tracer, testCtx(), params.TestChainConfig, nil); err == nil || !strings.Contains(err.Error(), errCPULimit.Error()) {
+		t.Errorf("Expected cpu limit error, got %v", err)
+	}
+	// Runaway code in the tracer constructor must be interrupted too
+	if _, err = newJsTracer("(function() { while(1); })()", ctx, nil); err == nil || !strings.Contains(err.Error(), errCPULimit.Error()) {
+		t.Errorf("Expected cpu limit error, got %v", err)
+	}
+}
+
+func TestMemoryLimit(t *testing.T) {
+	var (
+		code = "{data: [], step: function() { this.data.push('x'.repeat(1024)); }, result: function() { return this.data.length; }, fault: function(){}}"
+		loop = []byte{byte(vm.JUMPDEST), byte(vm.PUSH1), 0x0, byte(vm.JUMP)}
+	)
+	// Loop until the gas runs out, invoking step a few thousand times
+	tracer, err := newJsTracer(code, &tracers.Context{Limits: &tracers.Limits{Memory: 64 * 1024}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = runTrace(tracer, testCtx(), params.TestChainConfig, loop); err == nil || !strings.Contains(err.Error(), errMemoryLimit.Error()) {
+		t.Errorf("Expected memory limit error, got %v", err)
+	}
+	// The same tracer within its limits should succeed
+	tracer, err = newJsTracer(code, &tracers.Context{Limits: &tracers.Limits{Memory: 64 * 1024 * 1024}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = runTrace(tracer, testCtx(), params.TestChainConfig, loop); err == nil || !strings.Contains(err.Error(), "out of gas") {
+		t.Errorf("Expected out of gas error, got %v", err)
+	}
+}
+
 // testNoStepExec tests a regular value transfer (no exec), and accessing the statedb
 // in 'result'
 func TestNoStepExec(t *testing.T) {
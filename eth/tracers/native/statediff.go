@@ -0,0 +1,132 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package native
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("stateDiffTracer", newStateDiffTracer, false)
+}
+
+// accountDiff is the change of an account caused by a transaction. Unchanged
+// fields are omitted, changed ones report both their value before and after.
+type accountDiff struct {
+	Balance *balanceDiff                 `json:"balance,omitempty"`
+	Code    *codeDiff                    `json:"code,omitempty"`
+	Created bool                         `json:"created,omitempty"`
+	Deleted bool                         `json:"deleted,omitempty"`
+	Nonce   *nonceDiff                   `json:"nonce,omitempty"`
+	Storage map[common.Hash]*storageDiff `json:"storage,omitempty"`
+}
+
+type balanceDiff struct {
+	From *hexutil.Big `json:"from"`
+	To   *hexutil.Big `json:"to"`
+}
+
+type nonceDiff struct {
+	From hexutil.Uint64 `json:"from"`
+	To   hexutil.Uint64 `json:"to"`
+}
+
+type codeDiff struct {
+	From hexutil.Bytes `json:"from"`
+	To   hexutil.Bytes `json:"to"`
+}
+
+type storageDiff struct {
+	From common.Hash `json:"from"`
+	To   common.Hash `json:"to"`
+}
+
+// stateDiffTracer reports the accounts modified by a transaction, with their
+// balance, nonce, code and storage slots before and after it. Accounts and
+// slots are gathered the same way the prestate tracer does.
+type stateDiffTracer struct {
+	*prestateTracer
+	diff map[common.Address]*accountDiff
+}
+
+func newStateDiffTracer(ctx *tracers.Context, cfg json.RawMessage) (tracers.Tracer, error) {
+	t, err := newPrestateTracer(ctx, json.RawMessage(`{"diffMode":true}`))
+	if err != nil {
+		return nil, err
+	}
+	return &stateDiffTracer{
+		prestateTracer: t.(*prestateTracer),
+		diff:           make(map[common.Address]*accountDiff),
+	}, nil
+}
+
+// CaptureTxEnd compares the touched accounts against their state prior to the
+// transaction.
+func (t *stateDiffTracer) CaptureTxEnd(restGas uint64) {
+	for addr, pre := range t.pre {
+		var (
+			diff    = new(accountDiff)
+			balance = new(big.Int)
+			nonce   uint64
+			code    []byte
+		)
+		// Self-destructed accounts are wiped once the transaction is finalised
+		if diff.Deleted = t.env.StateDB.HasSuicided(addr); !diff.Deleted {
+			balance = t.env.StateDB.GetBalance(addr)
+			nonce = t.env.StateDB.GetNonce(addr)
+			code = t.env.StateDB.GetCode(addr)
+		}
+		if balance.Cmp(pre.Balance) != 0 {
+			diff.Balance = &balanceDiff{From: (*hexutil.Big)(pre.Balance), To: (*hexutil.Big)(new(big.Int).Set(balance))}
+		}
+		if nonce != pre.Nonce {
+			diff.Nonce = &nonceDiff{From: hexutil.Uint64(pre.Nonce), To: hexutil.Uint64(nonce)}
+		}
+		if !bytes.Equal(code, pre.Code) {
+			diff.Code = &codeDiff{From: pre.Code, To: code}
+		}
+		for key, val := range pre.Storage {
+			var post common.Hash
+			if !diff.Deleted {
+				post = t.env.StateDB.GetState(addr, key)
+			}
+			if post != val {
+				if diff.Storage == nil {
+					diff.Storage = make(map[common.Hash]*storageDiff)
+				}
+				diff.Storage[key] = &storageDiff{From: val, To: post}
+			}
+		}
+		if diff.Balance == nil && diff.Nonce == nil && diff.Code == nil && diff.Storage == nil {
+			continue
+		}
+		diff.Created = !pre.exists() && !diff.Deleted
+		t.diff[addr] = diff
+	}
+}
+
+// GetResult returns the json-encoded changes of the modified accounts, and any
+// error arising from the encoding or forceful termination (via `Stop`).
+func (t *stateDiffTracer) GetResult() (json.RawMessage, error) {
+	res, err := json.Marshal(t.diff)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(res), t.reason
+}
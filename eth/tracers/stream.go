@@ -0,0 +1,196 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package tracers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// maxTraceStreamBytes is the maximum amount of trace results a single
+	// stream emits before it's cut off, leaving a cursor to resume from.
+	maxTraceStreamBytes = 64 * 1024 * 1024
+)
+
+var errTraceFrameTooLarge = errors.New("trace result exceeds stream size cap")
+
+// StreamTraceConfig is the config for streaming the traces of a block.
+type StreamTraceConfig struct {
+	TraceConfig
+	Cursor *hexutil.Uint   // Index of the first transaction to trace, zero if unset
+	Limit  *hexutil.Uint64 // Maximum trace bytes to stream, capped by the server
+}
+
+// txTraceFrame is the trace of a single transaction emitted by a block trace
+// stream.
+type txTraceFrame struct {
+	TxIndex hexutil.Uint    `json:"txIndex"`
+	TxHash  common.Hash     `json:"txHash"`
+	Result  json.RawMessage `json:"result,omitempty"` // Trace results produced by the tracer
+	Error   string          `json:"error,omitempty"`  // Trace failure produced by the tracer
+}
+
+// traceStreamEnd is the last frame emitted by a block trace stream. If the
+// stream was cut off by the size cap, the cursor is the index of the first
+// transaction not traced yet, to be passed to a new stream to resume from. A
+// stream ended by a failed trace is incomplete without a cursor.
+type traceStreamEnd struct {
+	Block    hexutil.Uint64 `json:"block"`
+	Hash     common.Hash    `json:"hash"`
+	Complete bool           `json:"complete"`
+	Cursor   *hexutil.Uint  `json:"cursor,omitempty"`
+}
+
+// TraceBlockByNumberStream traces the transactions of the requested block like
+// TraceBlockByNumber, but emits the trace of every transaction as a separate
+// notification as soon as it's produced. The stream ends with a frame telling
+// whether all transactions were traced, or the cursor to resume from if the
+// stream exceeded its size cap.
+func (api *API) TraceBlockByNumberStream(ctx context.Context, number rpc.BlockNumber, config *StreamTraceConfig) (*rpc.Subscription, error) {
+	block, err := api.blockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	frames, err := api.traceBlockStream(ctx, block, config, notifier.Closed())
+	if err != nil {
+		return nil, err
+	}
+	sub := notifier.CreateSubscription()
+	go func() {
+		for frame := range frames {
+			notifier.Notify(sub.ID, frame)
+		}
+	}()
+	return sub, nil
+}
+
+// traceBlockStream prepares the state of the block before the cursor and traces
+// the remaining transactions in the background, emitting a frame per trace and
+// the end frame on the returned channel. Tracing is aborted in case the closed
+// signal is received.
+func (api *API) traceBlockStream(ctx context.Context, block *types.Block, config *StreamTraceConfig, closed <-chan interface{}) (chan interface{}, error) {
+	if block.NumberU64() == 0 {
+		return nil, errors.New("genesis is not traceable")
+	}
+	if config == nil {
+		config = &StreamTraceConfig{}
+	}
+	var (
+		txs    = block.Transactions()
+		cursor int
+		limit  = uint64(maxTraceStreamBytes)
+		reexec = defaultTraceReexec
+	)
+	if config.Cursor != nil {
+		cursor = int(*config.Cursor)
+	}
+	if cursor > len(txs) {
+		return nil, fmt.Errorf("cursor %d out of range for block with %d transactions", cursor, len(txs))
+	}
+	if config.Limit != nil && uint64(*config.Limit) < limit {
+		limit = uint64(*config.Limit)
+	}
+	if config.Reexec != nil {
+		reexec = *config.Reexec
+	}
+	frames := make(chan interface{})
+	if cursor == len(txs) {
+		go func() {
+			defer close(frames)
+			select {
+			case frames <- &traceStreamEnd{Block: hexutil.Uint64(block.NumberU64()), Hash: block.Hash(), Complete: true}:
+			case <-closed:
+			}
+		}()
+		return frames, nil
+	}
+	_, blockCtx, statedb, release, err := api.backend.StateAtTransaction(ctx, block, cursor, reexec)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		defer close(frames)
+		defer release()
+
+		var (
+			ctx    = context.Background()
+			signer = types.MakeSigner(api.backend.ChainConfig(), block.Number())
+			is158  = api.backend.ChainConfig().IsEIP158(block.Number())
+			sent   uint64
+			end    = &traceStreamEnd{Block: hexutil.Uint64(block.NumberU64()), Hash: block.Hash(), Complete: true}
+		)
+		for i := cursor; i < len(txs); i++ {
+			msg, _ := core.TransactionToMessage(txs[i], signer, block.BaseFee())
+			txctx := &Context{
+				BlockHash:   block.Hash(),
+				BlockNumber: block.Number(),
+				TxIndex:     i,
+				TxHash:      txs[i].Hash(),
+			}
+			frame := &txTraceFrame{TxIndex: hexutil.Uint(i), TxHash: txs[i].Hash()}
+			res, err := api.traceTx(ctx, msg, txctx, blockCtx, statedb, &config.TraceConfig)
+			if err != nil {
+				// The state of later transactions is unknown, end the stream
+				frame.Error = err.Error()
+				end.Complete = false
+				select {
+				case frames <- frame:
+				case <-closed:
+					return
+				}
+				break
+			}
+			if frame.Result, err = json.Marshal(res); err != nil {
+				frame.Result, frame.Error = nil, err.Error()
+			}
+			// Cut the stream off before exceeding the size cap. A single trace
+			// over the cap is replaced by an error, otherwise it could never be
+			// resumed past.
+			if size := uint64(len(frame.Result)); sent+size > limit {
+				if i > cursor {
+					next := hexutil.Uint(i)
+					end.Complete, end.Cursor = false, &next
+					break
+				}
+				frame.Result, frame.Error = nil, errTraceFrameTooLarge.Error()
+			}
+			sent += uint64(len(frame.Result))
+
+			select {
+			case frames <- frame:
+			case <-closed:
+				return
+			}
+			// Only delete empty objects if EIP158/161 (a.k.a Spurious Dragon) is in effect
+			statedb.Finalise(is158)
+		}
+		select {
+		case frames <- end:
+		case <-closed:
+		}
+	}()
+	return frames, nil
+}
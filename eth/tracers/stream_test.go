@@ -0,0 +1,118 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package tracers
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestTraceBlockStream(t *testing.T) {
+	t.Parallel()
+
+	// Initialize test accounts
+	accounts := newAccounts(2)
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: core.GenesisAlloc{
+			accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+		},
+	}
+	signer := types.HomesteadSigner{}
+	backend := newTestBackend(t, 1, genesis, func(i int, b *core.BlockGen) {
+		for nonce := uint64(0); nonce < 5; nonce++ {
+			tx, _ := types.SignTx(types.NewTransaction(nonce, accounts[1].addr, big.NewInt(1000), params.TxGas, b.BaseFee(), nil), signer, accounts[0].key)
+			b.AddTx(tx)
+		}
+	})
+	defer backend.chain.Stop()
+	api := NewAPI(backend)
+
+	block, _ := api.blockByNumber(context.Background(), rpc.BlockNumber(1))
+	single := `{"gas":21000,"failed":false,"returnValue":"","structLogs":[]}`
+
+	var (
+		cursor = func(n uint) *hexutil.Uint { return (*hexutil.Uint)(&n) }
+		limit  = func(n int) *hexutil.Uint64 { l := hexutil.Uint64(n); return &l }
+	)
+	for i, tt := range []struct {
+		config *StreamTraceConfig
+		traced []int // Indexes of the traced transactions
+		errors int   // Number of traces replaced by an error
+		cursor *hexutil.Uint
+	}{
+		{config: nil, traced: []int{0, 1, 2, 3, 4}},
+		{config: &StreamTraceConfig{Cursor: cursor(3)}, traced: []int{3, 4}},
+		{config: &StreamTraceConfig{Cursor: cursor(5)}},
+		{config: &StreamTraceConfig{Limit: limit(2 * len(single))}, traced: []int{0, 1}, cursor: cursor(2)},
+		{config: &StreamTraceConfig{Cursor: cursor(2), Limit: limit(2 * len(single))}, traced: []int{2, 3}, cursor: cursor(4)},
+		{config: &StreamTraceConfig{Cursor: cursor(4), Limit: limit(2 * len(single))}, traced: []int{4}},
+		{config: &StreamTraceConfig{Limit: limit(1)}, traced: []int{0}, errors: 1, cursor: cursor(1)},
+	} {
+		frames, err := api.traceBlockStream(context.Background(), block, tt.config, nil)
+		if err != nil {
+			t.Fatalf("test %d: failed to start stream: %v", i, err)
+		}
+		var (
+			traced []int
+			errs   int
+			end    *traceStreamEnd
+		)
+		for frame := range frames {
+			switch frame := frame.(type) {
+			case *txTraceFrame:
+				if end != nil {
+					t.Fatalf("test %d: frame after end of stream", i)
+				}
+				traced = append(traced, int(frame.TxIndex))
+				if frame.TxHash != block.Transactions()[frame.TxIndex].Hash() {
+					t.Errorf("test %d: frame %d: tx hash mismatch", i, frame.TxIndex)
+				}
+				if frame.Error != "" {
+					errs++
+				} else if string(frame.Result) != single {
+					t.Errorf("test %d: frame %d: result mismatch: have %s, want %s", i, frame.TxIndex, frame.Result, single)
+				}
+			case *traceStreamEnd:
+				end = frame
+			}
+		}
+		if fmt.Sprint(traced) != fmt.Sprint(tt.traced) {
+			t.Errorf("test %d: traced transactions mismatch: have %v, want %v", i, traced, tt.traced)
+		}
+		if errs != tt.errors {
+			t.Errorf("test %d: error frames mismatch: have %d, want %d", i, errs, tt.errors)
+		}
+		if end == nil {
+			t.Fatalf("test %d: missing end of stream", i)
+		}
+		if end.Hash != block.Hash() || end.Complete != (tt.cursor == nil) {
+			t.Errorf("test %d: end of stream mismatch: %+v", i, end)
+		}
+		if have, want := fmt.Sprint(end.Cursor), fmt.Sprint(tt.cursor); have != want {
+			t.Errorf("test %d: cursor mismatch: have %s, want %s", i, have, want)
+		}
+	}
+	// Cursors past the last transaction are rejected
+	if _, err := api.traceBlockStream(context.Background(), block, &StreamTraceConfig{Cursor: cursor(6)}, nil); err == nil {
+		t.Error("stream started from out of range cursor")
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/vm"
@@ -29,6 +30,17 @@ type Context struct {
 	BlockNumber *big.Int    // Number of the block the tx is contained within (zero if dangling tx or call)
 	TxIndex     int         // Index of the transaction within a block (zero if dangling tx or call)
 	TxHash      common.Hash // Hash of the transaction being traced (zero if dangling call)
+	Limits      *Limits     // Resource limits of the tracer invocation (nil if unlimited)
+}
+
+// Limits bounds the resources a single tracer invocation may consume, protecting
+// the node from user supplied tracers that would otherwise hang or exhaust it.
+// Zero values mean no limit.
+type Limits struct {
+	CPUTime time.Duration // Cumulative time the tracer code may spend executing
+	Memory  uint64        // Approximate amount of state the tracer may retain, in bytes
+	Output  uint64        // Size of the encoded tracer result, in bytes
+	Tracers []string      // Named tracers callers may use, arbitrary JS code is rejected if set
 }
 
 // Tracer interface extends vm.EVMLogger and additionally
@@ -82,6 +94,12 @@ func (d *directory) New(name string, ctx *Context, cfg json.RawMessage) (Tracer,
 	return d.jsEval(name, ctx, cfg)
 }
 
+// Has returns whether a tracer of the given name is registered.
+func (d *directory) Has(name string) bool {
+	_, ok := d.elems[name]
+	return ok
+}
+
 // IsJS will return true if the given tracer will evaluate
 // JS code. Because code evaluation has high overhead, this
 // info will be used in determining fast and slow code paths.
@@ -0,0 +1,193 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package transferindex
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// maxQueryResults is the maximum number of transfers returned by a query,
+// larger result sets have to be paged through.
+const maxQueryResults = 10000
+
+var (
+	errNotIndexed    = errors.New("no blocks indexed")
+	errInvertedRange = errors.New("fromBlock above toBlock")
+)
+
+// API exposes the transfer index over RPC.
+type API struct {
+	ix *Indexer
+}
+
+// Transfer is a token transfer announced by a Transfer event.
+type Transfer struct {
+	BlockNumber      hexutil.Uint64 `json:"blockNumber"`
+	BlockHash        common.Hash    `json:"blockHash"`
+	TransactionHash  common.Hash    `json:"transactionHash"`
+	TransactionIndex hexutil.Uint   `json:"transactionIndex"`
+	LogIndex         hexutil.Uint   `json:"logIndex"`
+	Token            common.Address `json:"token"`
+	From             common.Address `json:"from"`
+	To               common.Address `json:"to"`
+	Value            *hexutil.Big   `json:"value"`
+}
+
+// QueryArgs are the criteria of a transfer query.
+type QueryArgs struct {
+	FromBlock *hexutil.Uint64  `json:"fromBlock"` // Defaults to the first indexed block
+	ToBlock   *hexutil.Uint64  `json:"toBlock"`   // Defaults to the last indexed block
+	Tokens    []common.Address `json:"tokens"`    // Tokens to return transfers of, any if empty (address queries only)
+	After     *hexutil.Uint64  `json:"after"`     // Number of matching transfers to skip
+	Count     *hexutil.Uint64  `json:"count"`     // Maximum number of transfers to return
+}
+
+// IndexStatus is the range of blocks covered by the index.
+type IndexStatus struct {
+	FromBlock hexutil.Uint64 `json:"fromBlock"`
+	ToBlock   hexutil.Uint64 `json:"toBlock"`
+}
+
+// Status returns the range of blocks covered by the index.
+func (api *API) Status() (*IndexStatus, error) {
+	tail, ok := api.ix.tail()
+	if !ok {
+		return nil, errNotIndexed
+	}
+	head, _ := api.ix.head()
+	return &IndexStatus{FromBlock: hexutil.Uint64(tail), ToBlock: hexutil.Uint64(head)}, nil
+}
+
+// GetByToken returns the transfers of a token within the block range of the
+// query, ordered by their position in the chain.
+func (api *API) GetByToken(token common.Address, args QueryArgs) ([]*Transfer, error) {
+	return api.query(tokenPrefix, token, args, func(position, value []byte) *Transfer {
+		return api.ix.decode(token, position, value)
+	})
+}
+
+// GetByAddress returns the transfers sent or received by an address within the
+// block range of the query, ordered by their position in the chain.
+func (api *API) GetByAddress(addr common.Address, args QueryArgs) ([]*Transfer, error) {
+	tokens := make(map[common.Address]struct{}, len(args.Tokens))
+	for _, token := range args.Tokens {
+		tokens[token] = struct{}{}
+	}
+	return api.query(addressPrefix, addr, args, func(position, value []byte) *Transfer {
+		token := common.BytesToAddress(value)
+		if len(tokens) > 0 {
+			if _, ok := tokens[token]; !ok {
+				return nil
+			}
+		}
+		return api.ix.transferAt(token, position)
+	})
+}
+
+// query iterates the index entries of an address within the block range of the
+// query, resolving the transfer of each through the given function. Entries
+// resolving to nil don't match the query.
+func (api *API) query(prefix []byte, addr common.Address, args QueryArgs, resolve func(position, value []byte) *Transfer) ([]*Transfer, error) {
+	status, err := api.Status()
+	if err != nil {
+		return nil, err
+	}
+	if args.FromBlock != nil && args.ToBlock != nil && *args.FromBlock > *args.ToBlock {
+		return nil, errInvertedRange
+	}
+	var (
+		from  = uint64(status.FromBlock)
+		to    = uint64(status.ToBlock)
+		skip  uint64
+		count = uint64(maxQueryResults)
+	)
+	if args.FromBlock != nil && uint64(*args.FromBlock) > from {
+		from = uint64(*args.FromBlock)
+	}
+	if args.ToBlock != nil && uint64(*args.ToBlock) < to {
+		to = uint64(*args.ToBlock)
+	}
+	if args.After != nil {
+		skip = uint64(*args.After)
+	}
+	if args.Count != nil && uint64(*args.Count) < count {
+		count = uint64(*args.Count)
+	}
+	result := make([]*Transfer, 0)
+	if from > to || count == 0 {
+		return result, nil
+	}
+	var (
+		start = positionKey(prefix, addr, from, 0)
+		limit = positionKey(prefix, addr, to+1, 0)
+		it    = api.ix.db.NewIterator(start[:len(prefix)+common.AddressLength], start[len(prefix)+common.AddressLength:])
+	)
+	defer it.Release()
+
+	for it.Next() && bytes.Compare(it.Key(), limit) < 0 && uint64(len(result)) < count {
+		transfer := resolve(it.Key()[len(prefix)+common.AddressLength:], it.Value())
+		if transfer == nil {
+			continue
+		}
+		if skip > 0 {
+			skip--
+			continue
+		}
+		result = append(result, transfer)
+	}
+	return result, nil
+}
+
+// transferAt retrieves the transfer of a token stored at the given position.
+func (ix *Indexer) transferAt(token common.Address, position []byte) *Transfer {
+	blob, err := ix.db.Get(positionKey(tokenPrefix, token, binary.BigEndian.Uint64(position), binary.BigEndian.Uint32(position[8:])))
+	if err != nil {
+		log.Error("Missing transfer index entry", "token", token, "position", hexutil.Bytes(position))
+		return nil
+	}
+	return ix.decode(token, position, blob)
+}
+
+// decode assembles the transfer of a token stored at the given position.
+func (ix *Indexer) decode(token common.Address, position []byte, blob []byte) *Transfer {
+	var (
+		number   = binary.BigEndian.Uint64(position)
+		logIndex = binary.BigEndian.Uint32(position[8:])
+		transfer = new(storedTransfer)
+	)
+	if err := rlp.DecodeBytes(blob, transfer); err != nil {
+		log.Error("Invalid transfer index RLP", "token", token, "number", number, "index", logIndex, "err", err)
+		return nil
+	}
+	res := &Transfer{
+		BlockNumber:      hexutil.Uint64(number),
+		TransactionHash:  transfer.TxHash,
+		TransactionIndex: hexutil.Uint(transfer.TxIndex),
+		LogIndex:         hexutil.Uint(logIndex),
+		Token:            token,
+		From:             transfer.From,
+		To:               transfer.To,
+		Value:            (*hexutil.Big)(transfer.Value),
+	}
+	if block := ix.block(number); block != nil {
+		res.BlockHash = block.Hash
+	}
+	return res
+}
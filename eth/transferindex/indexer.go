@@ -0,0 +1,385 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+// Package transferindex implements an index of the token transfers of the
+// canonical chain, as announced by ERC-20 Transfer events, searchable by token
+// and by address.
+package transferindex
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// maxUpdateBlocks is the number of blocks indexed before checking for a new
+// chain head, so that catching up never holds up the chain head feed.
+const maxUpdateBlocks = 1024
+
+// transferTopic is the topic of Transfer(address,address,uint256) events.
+var transferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// Database keys of the index:
+//
+//	tokenPrefix + token + number (uint64 big endian) + log index (uint32) -> storedTransfer
+//	addressPrefix + address + number (uint64 big endian) + log index (uint32) -> token
+//	blockPrefix + number (uint64 big endian) -> storedBlock
+//	headKey -> number of the last indexed block
+//	tailKey -> number of the first indexed block
+var (
+	tokenPrefix   = []byte("t")
+	addressPrefix = []byte("a")
+	blockPrefix   = []byte("b")
+	headKey       = []byte("head")
+	tailKey       = []byte("tail")
+)
+
+// storedTransfer is a token transfer as stored in the index.
+type storedTransfer struct {
+	TxHash  common.Hash
+	TxIndex uint64
+	From    common.Address
+	To      common.Address
+	Value   *big.Int
+}
+
+// storedBlock is the record of an indexed block, listing the tokens and the
+// addresses it has index entries for.
+type storedBlock struct {
+	Hash      common.Hash
+	Tokens    []common.Address
+	Addresses []common.Address
+}
+
+// positionKey = prefix + address + number + logIndex
+func positionKey(prefix []byte, addr common.Address, number uint64, logIndex uint32) []byte {
+	key := make([]byte, 0, len(prefix)+common.AddressLength+12)
+	key = append(append(key, prefix...), addr.Bytes()...)
+	key = binary.BigEndian.AppendUint64(key, number)
+	return binary.BigEndian.AppendUint32(key, logIndex)
+}
+
+// blockKey = blockPrefix + number
+func blockKey(number uint64) []byte {
+	return binary.BigEndian.AppendUint64(append([]byte{}, blockPrefix...), number)
+}
+
+// Backend is the chain access the indexer requires.
+type Backend interface {
+	HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error)
+	GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error)
+	CurrentHeader() *types.Header
+	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
+}
+
+// Indexer follows the canonical chain, recording the token transfers of every
+// block into the index database. Indexing starts from the configured block when
+// the index is first created, reorged blocks are unwound.
+type Indexer struct {
+	backend Backend
+	db      ethdb.Database
+	start   uint64
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// New creates an indexer of the chain of the given backend into db, starting
+// from the given block number if the index is empty.
+func New(backend Backend, db ethdb.Database, start uint64) *Indexer {
+	return &Indexer{
+		backend: backend,
+		db:      db,
+		start:   start,
+		quit:    make(chan struct{}),
+	}
+}
+
+// APIs returns the RPC APIs searching the index.
+func (ix *Indexer) APIs() []rpc.API {
+	return []rpc.API{{
+		Namespace: "transfer",
+		Service:   &API{ix},
+	}}
+}
+
+// Start implements node.Lifecycle, starting to follow the chain.
+func (ix *Indexer) Start() error {
+	ix.wg.Add(1)
+	go ix.loop()
+	return nil
+}
+
+// Stop implements node.Lifecycle, terminating indexing and closing the index
+// database.
+func (ix *Indexer) Stop() error {
+	close(ix.quit)
+	ix.wg.Wait()
+	return ix.db.Close()
+}
+
+// loop updates the index on every new chain head. While catching up, new heads
+// are picked up between batches of indexed blocks.
+func (ix *Indexer) loop() {
+	defer ix.wg.Done()
+
+	heads := make(chan core.ChainHeadEvent, 16)
+	sub := ix.backend.SubscribeChainHeadEvent(heads)
+	defer sub.Unsubscribe()
+
+	head := ix.backend.CurrentHeader()
+	for {
+		if ix.update(head) {
+			select {
+			case ev := <-heads:
+				head = ev.Block.Header()
+			case <-sub.Err():
+				return
+			case <-ix.quit:
+				return
+			}
+			continue
+		}
+		select {
+		case ev := <-heads:
+			head = ev.Block.Header()
+		case <-sub.Err():
+			return
+		case <-ix.quit:
+			return
+		default:
+		}
+	}
+}
+
+// update unwinds the indexed blocks not canonical anymore and indexes a batch
+// of the blocks up to the given head. It returns whether the index caught up
+// with the head, or failed and has to wait for the next one.
+func (ix *Indexer) update(head *types.Header) bool {
+	ctx := context.Background()
+
+	next := ix.start
+	for {
+		number, ok := ix.head()
+		if !ok {
+			break
+		}
+		if number <= head.Number.Uint64() {
+			canon, err := ix.backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
+			if err == nil && canon != nil && ix.block(number) != nil && canon.Hash() == ix.block(number).Hash {
+				next = number + 1
+				break
+			}
+		}
+		if err := ix.unwind(number); err != nil {
+			log.Error("Failed to unwind transfer index", "number", number, "err", err)
+			return true
+		}
+	}
+	for number := next; number <= head.Number.Uint64(); number++ {
+		if number-next == maxUpdateBlocks {
+			return false
+		}
+		select {
+		case <-ix.quit:
+			return true
+		default:
+		}
+		if err := ix.index(ctx, number); err != nil {
+			log.Warn("Failed to index block transfers", "number", number, "err", err)
+			return true
+		}
+	}
+	return true
+}
+
+// head returns the number of the last indexed block.
+func (ix *Indexer) head() (uint64, bool) {
+	return ix.readNumber(headKey)
+}
+
+// tail returns the number of the first indexed block.
+func (ix *Indexer) tail() (uint64, bool) {
+	return ix.readNumber(tailKey)
+}
+
+func (ix *Indexer) readNumber(key []byte) (uint64, bool) {
+	blob, err := ix.db.Get(key)
+	if err != nil || len(blob) != 8 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(blob), true
+}
+
+// block returns the record of an indexed block, nil if it's not indexed.
+func (ix *Indexer) block(number uint64) *storedBlock {
+	blob, err := ix.db.Get(blockKey(number))
+	if err != nil {
+		return nil
+	}
+	block := new(storedBlock)
+	if err := rlp.DecodeBytes(blob, block); err != nil {
+		log.Error("Invalid transfer index block RLP", "number", number, "err", err)
+		return nil
+	}
+	return block
+}
+
+// index records the token transfers logged by the receipts of the canonical
+// block with the given number.
+func (ix *Indexer) index(ctx context.Context, number uint64) error {
+	header, err := ix.backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
+	if err != nil {
+		return err
+	}
+	if header == nil {
+		return fmt.Errorf("block #%d not found", number)
+	}
+	receipts, err := ix.backend.GetReceipts(ctx, header.Hash())
+	if err != nil {
+		return err
+	}
+	var (
+		batch  = ix.db.NewBatch()
+		tokens = make(map[common.Address]struct{})
+		addrs  = make(map[common.Address]struct{})
+	)
+	for _, receipt := range receipts {
+		for _, entry := range receipt.Logs {
+			transfer := decodeTransfer(entry)
+			if transfer == nil {
+				continue
+			}
+			blob, err := rlp.EncodeToBytes(transfer)
+			if err != nil {
+				return err
+			}
+			if err := batch.Put(positionKey(tokenPrefix, entry.Address, number, uint32(entry.Index)), blob); err != nil {
+				return err
+			}
+			tokens[entry.Address] = struct{}{}
+
+			for _, addr := range []common.Address{transfer.From, transfer.To} {
+				if err := batch.Put(positionKey(addressPrefix, addr, number, uint32(entry.Index)), entry.Address.Bytes()); err != nil {
+					return err
+				}
+				addrs[addr] = struct{}{}
+			}
+		}
+	}
+	blob, err := rlp.EncodeToBytes(&storedBlock{
+		Hash:      header.Hash(),
+		Tokens:    sortedAddresses(tokens),
+		Addresses: sortedAddresses(addrs),
+	})
+	if err != nil {
+		return err
+	}
+	if err := batch.Put(blockKey(number), blob); err != nil {
+		return err
+	}
+	enc := binary.BigEndian.AppendUint64(nil, number)
+	if err := batch.Put(headKey, enc); err != nil {
+		return err
+	}
+	if _, ok := ix.tail(); !ok {
+		if err := batch.Put(tailKey, enc); err != nil {
+			return err
+		}
+	}
+	return batch.Write()
+}
+
+// unwind removes the last indexed block from the index.
+func (ix *Indexer) unwind(number uint64) error {
+	record := ix.block(number)
+	if record == nil {
+		return errors.New("indexed block missing")
+	}
+	batch := ix.db.NewBatch()
+	for _, entries := range []struct {
+		prefix []byte
+		addrs  []common.Address
+	}{
+		{tokenPrefix, record.Tokens},
+		{addressPrefix, record.Addresses},
+	} {
+		for _, addr := range entries.addrs {
+			prefix := positionKey(entries.prefix, addr, number, 0)[:len(entries.prefix)+common.AddressLength+8]
+			it := ix.db.NewIterator(prefix, nil)
+			for it.Next() {
+				if err := batch.Delete(it.Key()); err != nil {
+					it.Release()
+					return err
+				}
+			}
+			it.Release()
+		}
+	}
+	if err := batch.Delete(blockKey(number)); err != nil {
+		return err
+	}
+	if tail, _ := ix.tail(); tail >= number {
+		// The whole index was unwound, restart from scratch
+		if err := batch.Delete(headKey); err != nil {
+			return err
+		}
+		if err := batch.Delete(tailKey); err != nil {
+			return err
+		}
+	} else if err := batch.Put(headKey, binary.BigEndian.AppendUint64(nil, number-1)); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// decodeTransfer returns the transfer announced by a log, or nil if the log is
+// not an ERC-20 Transfer event. ERC-721 transfers share the event signature but
+// carry the token id as a third indexed topic, so they are not matched.
+func decodeTransfer(entry *types.Log) *storedTransfer {
+	if len(entry.Topics) != 3 || entry.Topics[0] != transferTopic || len(entry.Data) != 32 {
+		return nil
+	}
+	return &storedTransfer{
+		TxHash:  entry.TxHash,
+		TxIndex: uint64(entry.TxIndex),
+		From:    common.BytesToAddress(entry.Topics[1].Bytes()),
+		To:      common.BytesToAddress(entry.Topics[2].Bytes()),
+		Value:   new(big.Int).SetBytes(entry.Data),
+	}
+}
+
+func sortedAddresses(set map[common.Address]struct{}) []common.Address {
+	addrs := make([]common.Address, 0, len(set))
+	for addr := range set {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i][:], addrs[j][:]) < 0
+	})
+	return addrs
+}
@@ -0,0 +1,188 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package transferindex
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+type testBackend struct {
+	chain *core.BlockChain
+}
+
+func (b *testBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	return b.chain.GetHeaderByNumber(uint64(number)), nil
+}
+
+func (b *testBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	return b.chain.GetReceiptsByHash(hash), nil
+}
+
+func (b *testBackend) CurrentHeader() *types.Header { return b.chain.CurrentHeader() }
+func (b *testBackend) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
+	return b.chain.SubscribeChainHeadEvent(ch)
+}
+
+// emitter returns the code of a contract logging a Transfer event from the
+// caller to the address in the first calldata word, of the amount in the second.
+func emitter() []byte {
+	code := common.Hex2Bytes("602035600052" + "600035" + "33" + "7f") // MSTORE(0, CALLDATALOAD(32)), CALLDATALOAD(0), CALLER, PUSH32
+	code = append(code, transferTopic.Bytes()...)
+	return append(code, common.Hex2Bytes("60206000a300")...) // PUSH1 32, PUSH1 0, LOG3, STOP
+}
+
+func TestIndexer(t *testing.T) {
+	var (
+		key, _    = crypto.GenerateKey()
+		sender    = crypto.PubkeyToAddress(key.PublicKey)
+		recipient = common.HexToAddress("0xbeef")
+		tokenA    = common.HexToAddress("0xa0")
+		tokenB    = common.HexToAddress("0xb0")
+		genesis   = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: core.GenesisAlloc{
+				sender: {Balance: big.NewInt(params.Ether)},
+				tokenA: {Code: emitter(), Balance: new(big.Int)},
+				tokenB: {Code: emitter(), Balance: new(big.Int)},
+			},
+		}
+		signer = types.LatestSigner(genesis.Config)
+		nonce  uint64
+	)
+	engine := ethash.NewFaker()
+	_, blocks, _ := core.GenerateChainWithGenesis(genesis, engine, 3, func(i int, b *core.BlockGen) {
+		send := func(token common.Address, value int64) {
+			data := append(common.LeftPadBytes(recipient.Bytes(), 32), common.LeftPadBytes(big.NewInt(value).Bytes(), 32)...)
+			tx, _ := types.SignNewTx(key, signer, &types.LegacyTx{Nonce: nonce, To: &token, Gas: 100000, GasPrice: b.BaseFee(), Data: data})
+			b.AddTx(tx)
+			nonce++
+		}
+		switch i {
+		case 0:
+			send(tokenA, 1000)
+			send(tokenB, 5)
+		case 2:
+			send(tokenA, 7)
+		}
+	})
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	ix := New(&testBackend{chain}, rawdb.NewMemoryDatabase(), 1)
+	api := &API{ix}
+
+	if !ix.update(blocks[2].Header()) {
+		t.Fatalf("index didn't catch up")
+	}
+	if status, err := api.Status(); err != nil || status.FromBlock != 1 || status.ToBlock != 3 {
+		t.Fatalf("status mismatch: have %+v, %v, want [1, 3]", status, err)
+	}
+	type want struct {
+		number uint64
+		token  common.Address
+		value  int64
+	}
+	check := func(transfers []*Transfer, err error, wants ...want) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("query failed: %v", err)
+		}
+		if len(transfers) != len(wants) {
+			t.Fatalf("transfers mismatch: have %d, want %d", len(transfers), len(wants))
+		}
+		for i, transfer := range transfers {
+			w := wants[i]
+			if uint64(transfer.BlockNumber) != w.number || transfer.Token != w.token || transfer.Value.ToInt().Int64() != w.value {
+				t.Errorf("transfer %d mismatch: %+v", i, transfer)
+			}
+			if transfer.From != sender || transfer.To != recipient {
+				t.Errorf("transfer %d: parties mismatch: %+v", i, transfer)
+			}
+			block := blocks[w.number-1]
+			tx := block.Transactions()[transfer.TransactionIndex]
+			if transfer.BlockHash != block.Hash() || transfer.TransactionHash != tx.Hash() || *tx.To() != w.token {
+				t.Errorf("transfer %d: position mismatch: %+v", i, transfer)
+			}
+		}
+	}
+	number := func(n uint64) *hexutil.Uint64 { return (*hexutil.Uint64)(&n) }
+
+	transfers, err := api.GetByToken(tokenA, QueryArgs{})
+	check(transfers, err, want{1, tokenA, 1000}, want{3, tokenA, 7})
+	transfers, err = api.GetByToken(tokenB, QueryArgs{})
+	check(transfers, err, want{1, tokenB, 5})
+	transfers, err = api.GetByToken(tokenA, QueryArgs{FromBlock: number(2)})
+	check(transfers, err, want{3, tokenA, 7})
+	transfers, err = api.GetByAddress(recipient, QueryArgs{})
+	check(transfers, err, want{1, tokenA, 1000}, want{1, tokenB, 5}, want{3, tokenA, 7})
+	transfers, err = api.GetByAddress(sender, QueryArgs{Tokens: []common.Address{tokenA}})
+	check(transfers, err, want{1, tokenA, 1000}, want{3, tokenA, 7})
+	transfers, err = api.GetByAddress(recipient, QueryArgs{ToBlock: number(2), After: number(1)})
+	check(transfers, err, want{1, tokenB, 5})
+	transfers, err = api.GetByAddress(recipient, QueryArgs{Count: number(1)})
+	check(transfers, err, want{1, tokenA, 1000})
+	transfers, err = api.GetByAddress(tokenA, QueryArgs{})
+	check(transfers, err)
+
+	if _, err := api.GetByToken(tokenA, QueryArgs{FromBlock: number(3), ToBlock: number(1)}); err != errInvertedRange {
+		t.Errorf("inverted range error mismatch: have %v, want %v", err, errInvertedRange)
+	}
+	// Heads going backwards unwind the blocks above, updating indexes them again
+	ix.update(blocks[1].Header())
+	if status, err := api.Status(); err != nil || status.ToBlock != 2 {
+		t.Fatalf("status mismatch after rewind: have %+v, %v", status, err)
+	}
+	transfers, err = api.GetByAddress(recipient, QueryArgs{})
+	check(transfers, err, want{1, tokenA, 1000}, want{1, tokenB, 5})
+
+	ix.update(blocks[2].Header())
+	transfers, err = api.GetByToken(tokenA, QueryArgs{})
+	check(transfers, err, want{1, tokenA, 1000}, want{3, tokenA, 7})
+}
+
+func TestDecodeTransfer(t *testing.T) {
+	var (
+		from  = common.BytesToHash(common.HexToAddress("0xf0").Bytes())
+		to    = common.BytesToHash(common.HexToAddress("0x70").Bytes())
+		value = common.LeftPadBytes([]byte{42}, 32)
+	)
+	if transfer := decodeTransfer(&types.Log{Topics: []common.Hash{transferTopic, from, to}, Data: value}); transfer == nil || transfer.Value.Int64() != 42 {
+		t.Errorf("ERC-20 transfer not decoded: %+v", transfer)
+	}
+	// ERC-721 transfers carry the token id as an indexed topic
+	if transfer := decodeTransfer(&types.Log{Topics: []common.Hash{transferTopic, from, to, common.BytesToHash(value)}}); transfer != nil {
+		t.Errorf("ERC-721 transfer decoded: %+v", transfer)
+	}
+	if transfer := decodeTransfer(&types.Log{Topics: []common.Hash{{0x01}, from, to}, Data: value}); transfer != nil {
+		t.Errorf("unrelated event decoded: %+v", transfer)
+	}
+}
@@ -51,6 +51,14 @@ type Compacter interface {
 	Compact(start []byte, limit []byte) error
 }
 
+// Refresher wraps the Refresh method of a read-only replica of a data store
+// that is being written by another process.
+type Refresher interface {
+	// Refresh catches up with the writes made by the owner of the data store
+	// since the replica was opened or last refreshed.
+	Refresh() error
+}
+
 // KeyValueStore contains all the methods required to allow handling different
 // key-value data stores backing the high level database.
 type KeyValueStore interface {
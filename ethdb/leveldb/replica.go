@@ -0,0 +1,434 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+//go:build !js
+// +build !js
+
+package leveldb
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/errors"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+// errReplicaReadOnly is returned when attempting to modify a replica.
+var errReplicaReadOnly = errors.New("leveldb replica is read-only")
+
+// Replica is a read-only view of a LevelDB database held open by another
+// process. Unlike a read-only database, it doesn't contend for the lock of the
+// database, and can be refreshed to catch up with the changes of the owner.
+type Replica struct {
+	fn      string
+	options *opt.Options
+
+	current *replicaInstance // Newest opened version of the database
+	closed  bool             // Whether the replica was closed
+	lock    sync.Mutex       // Protects the current instance and the reference counts
+
+	log log.Logger // Contextual logger tracking the database path
+}
+
+// replicaInstance is a version of the database opened by a replica, closed once
+// replaced by a newer one and no longer referenced by any reader.
+type replicaInstance struct {
+	db      *leveldb.DB
+	refs    int  // Number of running reads, iterators and snapshots
+	retired bool // Whether the instance was replaced by a newer one
+}
+
+// NewReplica opens a read-only replica of the LevelDB database at the given
+// path, which may be concurrently written by its owner process.
+func NewReplica(file string, cache int, handles int) (*Replica, error) {
+	if cache < minCache {
+		cache = minCache
+	}
+	if handles < minHandles {
+		handles = minHandles
+	}
+	options := configureOptions(func(options *opt.Options) {
+		options.OpenFilesCacheCapacity = handles
+		options.BlockCacheCapacity = cache / 2 * opt.MiB
+		options.ErrorIfMissing = true
+		options.ReadOnly = true
+	})
+	r := &Replica{
+		fn:      file,
+		options: options,
+		log:     log.New("database", file),
+	}
+	db, err := r.open()
+	if err != nil {
+		return nil, err
+	}
+	r.current = &replicaInstance{db: db}
+	r.log.Info("Opened database replica", "cache", cache, "handles", handles)
+	return r, nil
+}
+
+// open opens the current version of the database.
+func (r *Replica) open() (*leveldb.DB, error) {
+	return leveldb.Open(&replicaStorage{path: r.fn}, r.options)
+}
+
+// Refresh reopens the database to catch up with the changes of its owner. The
+// readers of the previous version keep using it until they finish.
+func (r *Replica) Refresh() error {
+	db, err := r.open()
+	if err != nil {
+		return err
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.closed {
+		db.Close()
+		return leveldb.ErrClosed
+	}
+	r.retire(r.current)
+	r.current = &replicaInstance{db: db}
+	return nil
+}
+
+// acquire references the current version of the database for reading.
+func (r *Replica) acquire() (*replicaInstance, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.closed {
+		return nil, leveldb.ErrClosed
+	}
+	r.current.refs++
+	return r.current, nil
+}
+
+// release dereferences a version of the database, closing it if it's retired
+// and no longer used.
+func (r *Replica) release(inst *replicaInstance) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	inst.refs--
+	if inst.retired && inst.refs == 0 {
+		inst.db.Close()
+	}
+}
+
+// retire marks a version of the database as replaced, closing it if unused.
+// The lock is assumed to be held.
+func (r *Replica) retire(inst *replicaInstance) {
+	inst.retired = true
+	if inst.refs == 0 {
+		inst.db.Close()
+	}
+}
+
+// Close closes the replica. The running iterators and snapshots keep their
+// version of the database open until released.
+func (r *Replica) Close() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	r.retire(r.current)
+	return nil
+}
+
+// Has retrieves if a key is present in the key-value store.
+func (r *Replica) Has(key []byte) (bool, error) {
+	inst, err := r.acquire()
+	if err != nil {
+		return false, err
+	}
+	defer r.release(inst)
+
+	return inst.db.Has(key, nil)
+}
+
+// Get retrieves the given key if it's present in the key-value store.
+func (r *Replica) Get(key []byte) ([]byte, error) {
+	inst, err := r.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer r.release(inst)
+
+	return inst.db.Get(key, nil)
+}
+
+// Put is not supported, the replica is read-only.
+func (r *Replica) Put(key []byte, value []byte) error {
+	return errReplicaReadOnly
+}
+
+// Delete is not supported, the replica is read-only.
+func (r *Replica) Delete(key []byte) error {
+	return errReplicaReadOnly
+}
+
+// NewBatch creates a batch which fails to be written, the replica being
+// read-only.
+func (r *Replica) NewBatch() ethdb.Batch {
+	return &replicaBatch{batch{b: new(leveldb.Batch)}}
+}
+
+// NewBatchWithSize creates a batch which fails to be written, the replica being
+// read-only.
+func (r *Replica) NewBatchWithSize(size int) ethdb.Batch {
+	return &replicaBatch{batch{b: leveldb.MakeBatch(size)}}
+}
+
+// NewIterator creates a binary-alphabetical iterator over a subset of database
+// content with a particular key prefix, starting at a particular initial key
+// (or after, if it does not exist). The iterator is not affected by refreshes.
+func (r *Replica) NewIterator(prefix []byte, start []byte) ethdb.Iterator {
+	inst, err := r.acquire()
+	if err != nil {
+		return &replicaIterator{err: err}
+	}
+	return &replicaIterator{
+		Iterator: inst.db.NewIterator(bytesPrefixRange(prefix, start), nil),
+		release:  func() { r.release(inst) },
+	}
+}
+
+// NewSnapshot creates a database snapshot based on the current version of the
+// database, unaffected by the following refreshes.
+func (r *Replica) NewSnapshot() (ethdb.Snapshot, error) {
+	inst, err := r.acquire()
+	if err != nil {
+		return nil, err
+	}
+	snap, err := inst.db.GetSnapshot()
+	if err != nil {
+		r.release(inst)
+		return nil, err
+	}
+	return &replicaSnapshot{snapshot: snapshot{db: snap}, release: func() { r.release(inst) }}, nil
+}
+
+// Stat returns a particular internal stat of the database.
+func (r *Replica) Stat(property string) (string, error) {
+	inst, err := r.acquire()
+	if err != nil {
+		return "", err
+	}
+	defer r.release(inst)
+
+	return inst.db.GetProperty(property)
+}
+
+// Compact is not supported, the replica is read-only.
+func (r *Replica) Compact(start []byte, limit []byte) error {
+	return errReplicaReadOnly
+}
+
+// Path returns the path to the database directory.
+func (r *Replica) Path() string {
+	return r.fn
+}
+
+// replicaBatch is a batch of a replica, which fails to be written.
+type replicaBatch struct {
+	batch
+}
+
+// Write fails, the replica being read-only.
+func (b *replicaBatch) Write() error {
+	return errReplicaReadOnly
+}
+
+// replicaIterator wraps an iterator of a replica, dereferencing its version of
+// the database once released.
+type replicaIterator struct {
+	ethdb.Iterator
+	release func()
+	once    sync.Once
+	err     error // Error opening the iterator
+}
+
+func (it *replicaIterator) Next() bool {
+	if it.Iterator == nil {
+		return false
+	}
+	return it.Iterator.Next()
+}
+
+func (it *replicaIterator) Error() error {
+	if it.Iterator == nil {
+		return it.err
+	}
+	return it.Iterator.Error()
+}
+
+func (it *replicaIterator) Key() []byte {
+	if it.Iterator == nil {
+		return nil
+	}
+	return it.Iterator.Key()
+}
+
+func (it *replicaIterator) Value() []byte {
+	if it.Iterator == nil {
+		return nil
+	}
+	return it.Iterator.Value()
+}
+
+func (it *replicaIterator) Release() {
+	if it.Iterator == nil {
+		return
+	}
+	it.Iterator.Release()
+	it.once.Do(it.release)
+}
+
+// replicaSnapshot wraps a snapshot of a replica, dereferencing its version of
+// the database once released.
+type replicaSnapshot struct {
+	snapshot
+	release func()
+	once    sync.Once
+}
+
+func (snap *replicaSnapshot) Release() {
+	snap.snapshot.Release()
+	snap.once.Do(snap.release)
+}
+
+// replicaStorage is a read-only LevelDB storage which doesn't lock the database
+// directory, the lock being held by the owner of the database.
+type replicaStorage struct {
+	path string
+}
+
+type replicaLocker struct{}
+
+func (replicaLocker) Unlock() {}
+
+// Lock is a noop, the owner of the database holding the lock.
+func (s *replicaStorage) Lock() (storage.Locker, error) { return replicaLocker{}, nil }
+
+// Log discards the storage logs.
+func (s *replicaStorage) Log(str string) {}
+
+// SetMeta is not supported, the storage is read-only.
+func (s *replicaStorage) SetMeta(fd storage.FileDesc) error { return errReplicaReadOnly }
+
+// GetMeta returns the manifest the CURRENT file points to.
+func (s *replicaStorage) GetMeta() (storage.FileDesc, error) {
+	blob, err := os.ReadFile(filepath.Join(s.path, "CURRENT"))
+	if err != nil {
+		return storage.FileDesc{}, err
+	}
+	fd, ok := parseFileName(string(bytes.TrimSuffix(blob, []byte{'\n'})))
+	if !ok || fd.Type != storage.TypeManifest {
+		return storage.FileDesc{}, &errors.ErrCorrupted{Err: fmt.Errorf("invalid CURRENT file: %q", blob)}
+	}
+	return fd, nil
+}
+
+// List returns the files of the given types.
+func (s *replicaStorage) List(ft storage.FileType) ([]storage.FileDesc, error) {
+	entries, err := os.ReadDir(s.path)
+	if err != nil {
+		return nil, err
+	}
+	var fds []storage.FileDesc
+	for _, entry := range entries {
+		if fd, ok := parseFileName(entry.Name()); ok && fd.Type&ft != 0 {
+			fds = append(fds, fd)
+		}
+	}
+	return fds, nil
+}
+
+// Open opens a file of the database for reading.
+func (s *replicaStorage) Open(fd storage.FileDesc) (storage.Reader, error) {
+	f, err := os.Open(filepath.Join(s.path, fileName(fd)))
+	if os.IsNotExist(err) && fd.Type == storage.TypeTable {
+		// Fall back to the legacy table file extension
+		f, err = os.Open(filepath.Join(s.path, fmt.Sprintf("%06d.sst", fd.Num)))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Create is not supported, the storage is read-only.
+func (s *replicaStorage) Create(fd storage.FileDesc) (storage.Writer, error) {
+	return nil, errReplicaReadOnly
+}
+
+// Remove is not supported, the storage is read-only.
+func (s *replicaStorage) Remove(fd storage.FileDesc) error { return errReplicaReadOnly }
+
+// Rename is not supported, the storage is read-only.
+func (s *replicaStorage) Rename(oldfd, newfd storage.FileDesc) error { return errReplicaReadOnly }
+
+// Close is a noop, the files being closed by the database.
+func (s *replicaStorage) Close() error { return nil }
+
+// fileName returns the name of a database file, following the LevelDB naming
+// scheme.
+func fileName(fd storage.FileDesc) string {
+	switch fd.Type {
+	case storage.TypeManifest:
+		return fmt.Sprintf("MANIFEST-%06d", fd.Num)
+	case storage.TypeJournal:
+		return fmt.Sprintf("%06d.log", fd.Num)
+	case storage.TypeTable:
+		return fmt.Sprintf("%06d.ldb", fd.Num)
+	default:
+		return fmt.Sprintf("%06d.tmp", fd.Num)
+	}
+}
+
+// parseFileName parses the name of a database file, following the LevelDB
+// naming scheme.
+func parseFileName(name string) (storage.FileDesc, bool) {
+	var (
+		fd   storage.FileDesc
+		tail string
+	)
+	if _, err := fmt.Sscanf(name, "%d.%s", &fd.Num, &tail); err == nil {
+		switch tail {
+		case "log":
+			fd.Type = storage.TypeJournal
+		case "ldb", "sst":
+			fd.Type = storage.TypeTable
+		case "tmp":
+			fd.Type = storage.TypeTemp
+		default:
+			return fd, false
+		}
+		return fd, true
+	}
+	if n, _ := fmt.Sscanf(name, "MANIFEST-%d%s", &fd.Num, &tail); n == 1 {
+		fd.Type = storage.TypeManifest
+		return fd, true
+	}
+	return fd, false
+}
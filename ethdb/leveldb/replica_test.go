@@ -0,0 +1,86 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package leveldb
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// Tests that a replica can be opened while the database is held open by its
+// owner, and catches up with the changes of the owner once refreshed.
+func TestReplica(t *testing.T) {
+	dir := t.TempDir()
+
+	db, err := New(dir, 16, 16, "", false)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Put([]byte("k1"), []byte("v1")); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	replica, err := NewReplica(dir, 16, 16)
+	if err != nil {
+		t.Fatalf("failed to open replica: %v", err)
+	}
+	defer replica.Close()
+
+	if value, err := replica.Get([]byte("k1")); err != nil || !bytes.Equal(value, []byte("v1")) {
+		t.Fatalf("value mismatch: have %q/%v, want %q", value, err, "v1")
+	}
+	if err := replica.Put([]byte("k2"), []byte("v2")); err != errReplicaReadOnly {
+		t.Fatalf("write error mismatch: have %v, want %v", err, errReplicaReadOnly)
+	}
+	// Modify the database, and check that the replica only sees it once refreshed
+	if err := db.Put([]byte("k2"), []byte("v2")); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	if has, _ := replica.Has([]byte("k2")); has {
+		t.Fatalf("change visible before refresh")
+	}
+	it := replica.NewIterator(nil, nil)
+	defer it.Release()
+
+	if err := replica.Refresh(); err != nil {
+		t.Fatalf("failed to refresh replica: %v", err)
+	}
+	if value, err := replica.Get([]byte("k2")); err != nil || !bytes.Equal(value, []byte("v2")) {
+		t.Fatalf("value mismatch: have %q/%v, want %q", value, err, "v2")
+	}
+	// The iterator opened before the refresh is unaffected by it
+	var keys []string
+	for it.Next() {
+		keys = append(keys, string(it.Key()))
+	}
+	if len(keys) != 1 || keys[0] != "k1" {
+		t.Fatalf("iterated keys mismatch: have %v, want [k1]", keys)
+	}
+	// Overflow the write buffer of the database, flushing it into tables
+	value := make([]byte, 1024)
+	for i := 0; i < 8192; i++ {
+		if err := db.Put([]byte(fmt.Sprintf("key-%d", i)), value); err != nil {
+			t.Fatalf("failed to write: %v", err)
+		}
+	}
+	if err := replica.Refresh(); err != nil {
+		t.Fatalf("failed to refresh replica: %v", err)
+	}
+	for _, key := range []string{"k1", "key-0", "key-8191"} {
+		if has, err := replica.Has([]byte(key)); !has || err != nil {
+			t.Fatalf("key %q missing after refresh: %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,375 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package remotekv
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	// errReadOnly is returned for the key-value store modifications, which are
+	// reserved to the remote node.
+	errReadOnly = errors.New("remote store is read-only")
+
+	// errNotSupported is returned for the ancient store modifications, which are
+	// reserved to the remote node.
+	errNotSupported = errors.New("operation not supported by remote store")
+)
+
+// Database is a read-only database backed by the key-value service of a remote
+// node, sharing both its key-value store and its ancient store. The remote node
+// keeps modifying the store concurrently.
+type Database struct {
+	remote *rpc.Client
+}
+
+// New creates a database on top of the key-value service reachable via the given
+// client. The client is closed along with the database.
+func New(client *rpc.Client) *Database {
+	return &Database{remote: client}
+}
+
+// Has retrieves if a key is present in the key-value store.
+func (db *Database) Has(key []byte) (bool, error) {
+	var has bool
+	err := db.remote.Call(&has, Namespace+"_has", hexutil.Bytes(key))
+	return has, err
+}
+
+// Get retrieves the given key if it's present in the key-value store.
+func (db *Database) Get(key []byte) ([]byte, error) {
+	var value hexutil.Bytes
+	if err := db.remote.Call(&value, Namespace+"_get", hexutil.Bytes(key)); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// Put is not supported, the key-value store is read-only.
+func (db *Database) Put(key []byte, value []byte) error {
+	return errReadOnly
+}
+
+// Delete is not supported, the key-value store is read-only.
+func (db *Database) Delete(key []byte) error {
+	return errReadOnly
+}
+
+// NewBatch creates a write-only key-value store buffering changes, which fail
+// to be written to the read-only remote store.
+func (db *Database) NewBatch() ethdb.Batch {
+	return new(batch)
+}
+
+// NewBatchWithSize creates a write-only database batch with pre-allocated buffer.
+func (db *Database) NewBatchWithSize(size int) ethdb.Batch {
+	return new(batch)
+}
+
+// NewIterator creates a binary-alphabetical iterator over a subset of the
+// remote store with a particular key prefix, starting at a particular initial
+// key (or after, if it does not exist). Entries are retrieved in pages, so the
+// iteration is not isolated from concurrent modifications.
+func (db *Database) NewIterator(prefix []byte, start []byte) ethdb.Iterator {
+	return &iterator{
+		db:     db,
+		prefix: common.CopyBytes(prefix),
+		start:  common.CopyBytes(start),
+		pos:    -1,
+	}
+}
+
+// NewSnapshot creates a snapshot of the current state of the remote store.
+func (db *Database) NewSnapshot() (ethdb.Snapshot, error) {
+	var id hexutil.Uint64
+	if err := db.remote.Call(&id, Namespace+"_newSnapshot"); err != nil {
+		return nil, err
+	}
+	return &snapshot{db: db, id: id}, nil
+}
+
+// Stat returns a particular internal stat of the remote store.
+func (db *Database) Stat(property string) (string, error) {
+	var stat string
+	err := db.remote.Call(&stat, Namespace+"_stat", property)
+	return stat, err
+}
+
+// Compact is a noop, the remote node maintains its own store.
+func (db *Database) Compact(start []byte, limit []byte) error {
+	return nil
+}
+
+// HasAncient returns an indicator whether the specified data exists in the
+// remote ancient store.
+func (db *Database) HasAncient(kind string, number uint64) (bool, error) {
+	var has bool
+	err := db.remote.Call(&has, Namespace+"_hasAncient", kind, number)
+	return has, err
+}
+
+// Ancient retrieves an ancient binary blob from the remote ancient store.
+func (db *Database) Ancient(kind string, number uint64) ([]byte, error) {
+	var blob hexutil.Bytes
+	if err := db.remote.Call(&blob, Namespace+"_ancient", kind, number); err != nil {
+		return nil, err
+	}
+	return blob, nil
+}
+
+// AncientRange retrieves multiple items in sequence from the remote ancient
+// store.
+func (db *Database) AncientRange(kind string, start, count, maxBytes uint64) ([][]byte, error) {
+	var blobs []hexutil.Bytes
+	if err := db.remote.Call(&blobs, Namespace+"_ancientRange", kind, start, count, maxBytes); err != nil {
+		return nil, err
+	}
+	items := make([][]byte, len(blobs))
+	for i, blob := range blobs {
+		items[i] = blob
+	}
+	return items, nil
+}
+
+// Ancients returns the ancient item numbers in the remote ancient store.
+func (db *Database) Ancients() (uint64, error) {
+	var ancients uint64
+	err := db.remote.Call(&ancients, Namespace+"_ancients")
+	return ancients, err
+}
+
+// Tail returns the number of first stored item in the remote ancient store.
+func (db *Database) Tail() (uint64, error) {
+	var tail uint64
+	err := db.remote.Call(&tail, Namespace+"_tail")
+	return tail, err
+}
+
+// AncientSize returns the ancient size of the specified category.
+func (db *Database) AncientSize(kind string) (uint64, error) {
+	var size uint64
+	err := db.remote.Call(&size, Namespace+"_ancientSize", kind)
+	return size, err
+}
+
+// ReadAncients runs the given read operation on the remote ancient store. The
+// reads are not isolated from the writes of the remote node.
+func (db *Database) ReadAncients(fn func(ethdb.AncientReaderOp) error) (err error) {
+	return fn(db)
+}
+
+// ModifyAncients is not supported, the ancient store is read-only.
+func (db *Database) ModifyAncients(func(ethdb.AncientWriteOp) error) (int64, error) {
+	return 0, errNotSupported
+}
+
+// TruncateHead is not supported, the ancient store is read-only.
+func (db *Database) TruncateHead(n uint64) error {
+	return errNotSupported
+}
+
+// TruncateTail is not supported, the ancient store is read-only.
+func (db *Database) TruncateTail(n uint64) error {
+	return errNotSupported
+}
+
+// Sync is a noop, the remote node flushes its own ancient store.
+func (db *Database) Sync() error {
+	return nil
+}
+
+// MigrateTable is not supported, the ancient store is read-only.
+func (db *Database) MigrateTable(kind string, convert func([]byte) ([]byte, error)) error {
+	return errNotSupported
+}
+
+// AncientDatadir is not supported, the ancient store is not local.
+func (db *Database) AncientDatadir() (string, error) {
+	return "", errNotSupported
+}
+
+// Close closes the connection to the remote store.
+func (db *Database) Close() error {
+	db.remote.Close()
+	return nil
+}
+
+// op is a single write operation of a batch.
+type op struct {
+	key    []byte
+	value  []byte
+	delete bool
+}
+
+// batch is a write-only key-value store buffering changes, which can be replayed
+// but not written to the read-only remote store.
+type batch struct {
+	ops  []op
+	size int
+}
+
+// Put inserts the given value into the batch for later committing.
+func (b *batch) Put(key, value []byte) error {
+	b.ops = append(b.ops, op{key: common.CopyBytes(key), value: common.CopyBytes(value)})
+	b.size += len(key) + len(value)
+	return nil
+}
+
+// Delete inserts a key removal into the batch for later committing.
+func (b *batch) Delete(key []byte) error {
+	b.ops = append(b.ops, op{key: common.CopyBytes(key), delete: true})
+	b.size += len(key)
+	return nil
+}
+
+// ValueSize retrieves the amount of data queued up for writing.
+func (b *batch) ValueSize() int {
+	return b.size
+}
+
+// Write is not supported for non-empty batches, the remote store is read-only.
+func (b *batch) Write() error {
+	if len(b.ops) == 0 {
+		return nil
+	}
+	return errReadOnly
+}
+
+// Reset resets the batch for reuse.
+func (b *batch) Reset() {
+	b.ops = b.ops[:0]
+	b.size = 0
+}
+
+// Replay replays the batch contents.
+func (b *batch) Replay(w ethdb.KeyValueWriter) error {
+	for _, op := range b.ops {
+		if op.delete {
+			if err := w.Delete(op.key); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := w.Put(op.key, op.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// iterator walks the entries of the remote store, retrieving them in pages.
+type iterator struct {
+	db     *Database
+	prefix []byte
+	start  []byte // Start of the next page, prefix not included
+
+	entries []Entry // Current page of entries
+	pos     int     // Position of the current entry in the page
+	done    bool    // Whether the last page was retrieved
+	err     error
+}
+
+// Next moves the iterator to the next key/value pair. It returns whether the
+// iterator is exhausted.
+func (it *iterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if it.pos+1 < len(it.entries) {
+		it.pos++
+		return true
+	}
+	if it.done {
+		it.entries, it.pos = nil, -1
+		return false
+	}
+	var entries []Entry
+	if err := it.db.remote.Call(&entries, Namespace+"_iterate", hexutil.Bytes(it.prefix), hexutil.Bytes(it.start), maxIterateItems); err != nil {
+		it.err, it.entries, it.pos = err, nil, -1
+		return false
+	}
+	if len(entries) == 0 {
+		it.done, it.entries, it.pos = true, nil, -1
+		return false
+	}
+	// Continue after the last key of the page, the smallest greater key being
+	// the last key with a zero byte appended
+	last := entries[len(entries)-1].Key
+	it.start = append(common.CopyBytes(last[len(it.prefix):]), 0)
+	it.entries, it.pos = entries, 0
+	return true
+}
+
+// Error returns any accumulated error. Exhausting all the key/value pairs is
+// not considered to be an error.
+func (it *iterator) Error() error {
+	return it.err
+}
+
+// Key returns the key of the current key/value pair, or nil if done.
+func (it *iterator) Key() []byte {
+	if it.pos < 0 || it.pos >= len(it.entries) {
+		return nil
+	}
+	return it.entries[it.pos].Key
+}
+
+// Value returns the value of the current key/value pair, or nil if done.
+func (it *iterator) Value() []byte {
+	if it.pos < 0 || it.pos >= len(it.entries) {
+		return nil
+	}
+	return it.entries[it.pos].Value
+}
+
+// Release releases associated resources.
+func (it *iterator) Release() {
+	it.entries, it.pos = nil, -1
+}
+
+// snapshot is a snapshot of the remote store, held by the remote node until
+// released.
+type snapshot struct {
+	db   *Database
+	id   hexutil.Uint64
+	once sync.Once
+}
+
+// Has retrieves if a key is present in the snapshot.
+func (snap *snapshot) Has(key []byte) (bool, error) {
+	var has bool
+	err := snap.db.remote.Call(&has, Namespace+"_snapshotHas", snap.id, hexutil.Bytes(key))
+	return has, err
+}
+
+// Get retrieves the given key if it's present in the snapshot.
+func (snap *snapshot) Get(key []byte) ([]byte, error) {
+	var value hexutil.Bytes
+	if err := snap.db.remote.Call(&value, Namespace+"_snapshotGet", snap.id, hexutil.Bytes(key)); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// Release releases the snapshot on the remote node.
+func (snap *snapshot) Release() {
+	snap.once.Do(func() {
+		snap.db.remote.Call(nil, Namespace+"_releaseSnapshot", snap.id)
+	})
+}
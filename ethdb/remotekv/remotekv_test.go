@@ -0,0 +1,224 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package remotekv
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// newTestDatabase creates a remote store backed by an in-process service over
+// a fresh memory database, returned to populate the store.
+func newTestDatabase(t testing.TB) (ethdb.KeyValueStore, *memorydb.Database) {
+	backing := memorydb.New()
+	server := rpc.NewServer()
+	if err := server.RegisterName(Namespace, NewService(backing)); err != nil {
+		t.Fatalf("failed to register service: %v", err)
+	}
+	t.Cleanup(server.Stop)
+	return New(rpc.DialInProc(server)), backing
+}
+
+// Tests that the remote store serves the entries of the backing store, but
+// rejects modifications.
+func TestRemoteDB(t *testing.T) {
+	db, backing := newTestDatabase(t)
+	defer db.Close()
+
+	backing.Put([]byte("key"), []byte("value"))
+	if has, err := db.Has([]byte("key")); err != nil || !has {
+		t.Fatalf("key not found: %v", err)
+	}
+	if value, err := db.Get([]byte("key")); err != nil || string(value) != "value" {
+		t.Fatalf("value mismatch: have %q/%v, want %q", value, err, "value")
+	}
+	if _, err := db.Get([]byte("missing")); err == nil {
+		t.Fatalf("missing key retrieved")
+	}
+	snap, err := db.NewSnapshot()
+	if err != nil {
+		t.Fatalf("failed to create snapshot: %v", err)
+	}
+	defer snap.Release()
+
+	backing.Put([]byte("key"), []byte("changed"))
+	if value, err := snap.Get([]byte("key")); err != nil || string(value) != "value" {
+		t.Fatalf("snapshot value mismatch: have %q/%v, want %q", value, err, "value")
+	}
+	if err := db.Put([]byte("key"), []byte("other")); err != errReadOnly {
+		t.Errorf("put error mismatch: have %v, want %v", err, errReadOnly)
+	}
+	if err := db.Delete([]byte("key")); err != errReadOnly {
+		t.Errorf("delete error mismatch: have %v, want %v", err, errReadOnly)
+	}
+	batch := db.NewBatch()
+	batch.Put([]byte("key"), []byte("other"))
+	if err := batch.Write(); err != errReadOnly {
+		t.Errorf("batch write error mismatch: have %v, want %v", err, errReadOnly)
+	}
+	if value, _ := backing.Get([]byte("key")); string(value) != "changed" {
+		t.Errorf("backing store modified: have %q", value)
+	}
+}
+
+// Tests that iterations spanning several pages return every entry once.
+func TestRemoteDBPagedIteration(t *testing.T) {
+	db, backing := newTestDatabase(t)
+	defer db.Close()
+
+	count := 3*maxIterateItems + 7
+	for i := 0; i < count; i++ {
+		backing.Put([]byte{0x01, byte(i >> 8), byte(i)}, []byte{byte(i)})
+	}
+	backing.Put([]byte{0x02}, []byte{0x02})
+
+	it := db.NewIterator([]byte{0x01}, nil)
+	defer it.Release()
+
+	var n int
+	for ; it.Next(); n++ {
+		if want := []byte{0x01, byte(n >> 8), byte(n)}; string(it.Key()) != string(want) {
+			t.Fatalf("entry %d: key mismatch: have %x, want %x", n, it.Key(), want)
+		}
+	}
+	if err := it.Error(); err != nil {
+		t.Fatalf("iteration failed: %v", err)
+	}
+	if n != count {
+		t.Fatalf("entry count mismatch: have %d, want %d", n, count)
+	}
+}
+
+// Tests that the number of held snapshots is capped and that snapshots not
+// released by their clients expire once idle.
+func TestSnapshotLimits(t *testing.T) {
+	service := NewService(memorydb.New())
+	service.idleTimeout = 50 * time.Millisecond
+
+	var ids []uint64
+	for i := 0; i < maxSnapshots; i++ {
+		id, err := service.NewSnapshot()
+		if err != nil {
+			t.Fatalf("snapshot %d: failed to create: %v", i, err)
+		}
+		ids = append(ids, uint64(id))
+	}
+	if _, err := service.NewSnapshot(); err != errTooManySnapshots {
+		t.Fatalf("snapshot beyond the limit error mismatch: have %v, want %v", err, errTooManySnapshots)
+	}
+	service.ReleaseSnapshot(hexutil.Uint64(ids[0]))
+	if _, err := service.NewSnapshot(); err != nil {
+		t.Fatalf("failed to create snapshot after releasing one: %v", err)
+	}
+	// Keep one snapshot in use while the others expire
+	held := func() int {
+		service.lock.Lock()
+		defer service.lock.Unlock()
+		return len(service.snapshots)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for held() > 1 {
+		if _, err := service.SnapshotHas(hexutil.Uint64(ids[1]), []byte("key")); err != nil {
+			t.Fatalf("used snapshot expired: %v", err)
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("idle snapshots not expired: %d held", held())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := service.SnapshotHas(hexutil.Uint64(ids[1]), []byte("key")); err != nil {
+		t.Fatalf("used snapshot expired: %v", err)
+	}
+}
+
+// testAncients is a memory database with a single table ancient store.
+type testAncients struct {
+	*memorydb.Database
+	items    [][]byte
+	maxBytes uint64 // Byte limit of the last range request
+}
+
+func (db *testAncients) HasAncient(kind string, number uint64) (bool, error) {
+	return number < uint64(len(db.items)), nil
+}
+
+func (db *testAncients) Ancient(kind string, number uint64) ([]byte, error) {
+	if number >= uint64(len(db.items)) {
+		return nil, errors.New("out of bounds")
+	}
+	return db.items[number], nil
+}
+
+func (db *testAncients) AncientRange(kind string, start, count, maxBytes uint64) ([][]byte, error) {
+	db.maxBytes = maxBytes
+	if start+count > uint64(len(db.items)) {
+		return nil, errors.New("out of bounds")
+	}
+	return db.items[start : start+count], nil
+}
+
+func (db *testAncients) Ancients() (uint64, error)               { return uint64(len(db.items)), nil }
+func (db *testAncients) Tail() (uint64, error)                   { return 0, nil }
+func (db *testAncients) AncientSize(kind string) (uint64, error) { return 0, nil }
+
+func (db *testAncients) ReadAncients(fn func(ethdb.AncientReaderOp) error) error {
+	return fn(db)
+}
+
+// Tests that the ancient store of the remote database is readable, but not
+// writable.
+func TestRemoteDBAncients(t *testing.T) {
+	ancients := &testAncients{
+		Database: memorydb.New(),
+		items:    [][]byte{{0x00}, {0x01}, {0x02}, {0x03}},
+	}
+	server := rpc.NewServer()
+	if err := server.RegisterName(Namespace, NewService(ancients)); err != nil {
+		t.Fatalf("failed to register service: %v", err)
+	}
+	defer server.Stop()
+
+	db := New(rpc.DialInProc(server))
+	defer db.Close()
+
+	if n, err := db.Ancients(); err != nil || n != uint64(len(ancients.items)) {
+		t.Fatalf("ancient count mismatch: have %d/%v, want %d", n, err, len(ancients.items))
+	}
+	for i, want := range ancients.items {
+		if blob, err := db.Ancient("test", uint64(i)); err != nil || !bytes.Equal(blob, want) {
+			t.Errorf("item %d: blob mismatch: have %x/%v, want %x", i, blob, err, want)
+		}
+	}
+	if items, err := db.AncientRange("test", 1, 2, 0); err != nil || len(items) != 2 || !bytes.Equal(items[1], ancients.items[2]) {
+		t.Errorf("ancient range mismatch: have %x, %v", items, err)
+	}
+	if ancients.maxBytes != maxIterateBytes {
+		t.Errorf("unlimited ancient range not capped: have %d bytes, want %d", ancients.maxBytes, maxIterateBytes)
+	}
+	if _, err := db.AncientRange("test", 1, 2, 1<<40); err != nil || ancients.maxBytes != maxIterateBytes {
+		t.Errorf("oversized ancient range not capped: have %d bytes, want %d: %v", ancients.maxBytes, maxIterateBytes, err)
+	}
+	if _, err := db.Ancient("test", 4); err == nil {
+		t.Errorf("out of bounds item retrieved")
+	}
+	if err := db.TruncateHead(0); err != errNotSupported {
+		t.Errorf("ancient truncation error mismatch: have %v, want %v", err, errNotSupported)
+	}
+}
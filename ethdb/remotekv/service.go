@@ -0,0 +1,279 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+// Package remotekv implements a key-value store shared read-only over RPC,
+// allowing several nodes, such as read-heavy RPC replicas, to be backed by the
+// database of a single node.
+package remotekv
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+const (
+	// Namespace is the RPC namespace the key-value service is registered under.
+	Namespace = "kv"
+
+	// maxIterateItems is the maximum number of entries returned by a single
+	// iteration request.
+	maxIterateItems = 1024
+
+	// maxIterateBytes is the soft limit of the key and value bytes returned by a
+	// single iteration or ancient range request.
+	maxIterateBytes = 2 * 1024 * 1024
+
+	// maxSnapshots is the maximum number of snapshots held for clients at once.
+	// Held snapshots keep the store from compacting the data they reference.
+	maxSnapshots = 64
+
+	// snapshotIdleTimeout is the time after which a snapshot not accessed by its
+	// client is released, in case the client went away without releasing it.
+	snapshotIdleTimeout = 5 * time.Minute
+)
+
+var (
+	errUnknownSnapshot  = errors.New("unknown snapshot")
+	errTooManySnapshots = errors.New("too many snapshots")
+	errNoAncients       = errors.New("ancient store not available")
+)
+
+// Entry is a single key-value pair returned by an iteration.
+type Entry struct {
+	Key   hexutil.Bytes `json:"key"`
+	Value hexutil.Bytes `json:"value"`
+}
+
+// Service exposes a key-value store read-only over RPC, along with its ancient
+// store if the key-value store has one attached.
+type Service struct {
+	db       ethdb.KeyValueStore
+	ancients ethdb.AncientReader // Ancient store of the database, nil if none
+
+	snapshots   map[uint64]*heldSnapshot // Snapshots created and not yet released by clients
+	nextID      uint64                   // Identifier of the next snapshot
+	idleTimeout time.Duration            // Time after which unused snapshots are released
+	lock        sync.Mutex               // Protects the snapshots
+}
+
+// heldSnapshot is a snapshot held for a client.
+type heldSnapshot struct {
+	snap  ethdb.Snapshot
+	used  time.Time   // Last time the client accessed the snapshot
+	timer *time.Timer // Releases the snapshot once idle for too long
+}
+
+// NewService creates a service exposing the given key-value store.
+func NewService(db ethdb.KeyValueStore) *Service {
+	ancients, _ := db.(ethdb.AncientReader)
+	return &Service{
+		db:          db,
+		ancients:    ancients,
+		snapshots:   make(map[uint64]*heldSnapshot),
+		idleTimeout: snapshotIdleTimeout,
+	}
+}
+
+// Has retrieves if a key is present in the store.
+func (s *Service) Has(key hexutil.Bytes) (bool, error) {
+	return s.db.Has(key)
+}
+
+// Get retrieves the given key if it's present in the store.
+func (s *Service) Get(key hexutil.Bytes) (hexutil.Bytes, error) {
+	return s.db.Get(key)
+}
+
+// Iterate returns the entries with the given key prefix in binary-alphabetical
+// order, starting at the given key (prefix not included) or after. At most limit
+// entries are returned, fewer if the response would grow too large, and an empty
+// result means the iteration is exhausted.
+func (s *Service) Iterate(prefix hexutil.Bytes, start hexutil.Bytes, limit int) ([]Entry, error) {
+	if limit <= 0 || limit > maxIterateItems {
+		limit = maxIterateItems
+	}
+	it := s.db.NewIterator(prefix, start)
+	defer it.Release()
+
+	var (
+		entries []Entry
+		size    int
+	)
+	for len(entries) < limit && size < maxIterateBytes && it.Next() {
+		entry := Entry{
+			Key:   common.CopyBytes(it.Key()),
+			Value: common.CopyBytes(it.Value()),
+		}
+		entries = append(entries, entry)
+		size += len(entry.Key) + len(entry.Value)
+	}
+	return entries, it.Error()
+}
+
+// Stat returns a particular internal stat of the store.
+func (s *Service) Stat(property string) (string, error) {
+	return s.db.Stat(property)
+}
+
+// NewSnapshot creates a snapshot of the current state of the store, returning
+// its identifier. The snapshot must be released by the client, otherwise it is
+// released once not accessed for a while. At most 64 snapshots are held at once.
+func (s *Service) NewSnapshot() (hexutil.Uint64, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.snapshots) >= maxSnapshots {
+		return 0, errTooManySnapshots
+	}
+	snap, err := s.db.NewSnapshot()
+	if err != nil {
+		return 0, err
+	}
+	id := s.nextID
+	s.nextID++
+	s.snapshots[id] = &heldSnapshot{
+		snap:  snap,
+		used:  time.Now(),
+		timer: time.AfterFunc(s.idleTimeout, func() { s.expireSnapshot(id) }),
+	}
+	return hexutil.Uint64(id), nil
+}
+
+// SnapshotHas retrieves if a key is present in a snapshot.
+func (s *Service) SnapshotHas(id hexutil.Uint64, key hexutil.Bytes) (bool, error) {
+	snap, err := s.snapshot(id)
+	if err != nil {
+		return false, err
+	}
+	return snap.Has(key)
+}
+
+// SnapshotGet retrieves the given key if it's present in a snapshot.
+func (s *Service) SnapshotGet(id hexutil.Uint64, key hexutil.Bytes) (hexutil.Bytes, error) {
+	snap, err := s.snapshot(id)
+	if err != nil {
+		return nil, err
+	}
+	return snap.Get(key)
+}
+
+// ReleaseSnapshot releases a snapshot. Unknown snapshots are ignored.
+func (s *Service) ReleaseSnapshot(id hexutil.Uint64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if held, ok := s.snapshots[uint64(id)]; ok {
+		held.timer.Stop()
+		held.snap.Release()
+		delete(s.snapshots, uint64(id))
+	}
+}
+
+// expireSnapshot releases a snapshot if it was not accessed within the idle
+// timeout, rescheduling the check otherwise.
+func (s *Service) expireSnapshot(id uint64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	held, ok := s.snapshots[id]
+	if !ok {
+		return
+	}
+	if idle := time.Since(held.used); idle < s.idleTimeout {
+		held.timer.Reset(s.idleTimeout - idle)
+		return
+	}
+	held.snap.Release()
+	delete(s.snapshots, id)
+}
+
+// snapshot retrieves a snapshot created by a client, marking it used.
+func (s *Service) snapshot(id hexutil.Uint64) (ethdb.Snapshot, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	held, ok := s.snapshots[uint64(id)]
+	if !ok {
+		return nil, errUnknownSnapshot
+	}
+	held.used = time.Now()
+	return held.snap, nil
+}
+
+// HasAncient returns an indicator whether the specified data exists in the
+// ancient store.
+func (s *Service) HasAncient(kind string, number uint64) (bool, error) {
+	if s.ancients == nil {
+		return false, errNoAncients
+	}
+	return s.ancients.HasAncient(kind, number)
+}
+
+// Ancient retrieves an ancient binary blob from the ancient store.
+func (s *Service) Ancient(kind string, number uint64) (hexutil.Bytes, error) {
+	if s.ancients == nil {
+		return nil, errNoAncients
+	}
+	return s.ancients.Ancient(kind, number)
+}
+
+// AncientRange retrieves multiple items in sequence from the ancient store. At
+// most 1024 items are returned, fewer if the response would grow too large.
+func (s *Service) AncientRange(kind string, start, count, maxBytes uint64) ([]hexutil.Bytes, error) {
+	if s.ancients == nil {
+		return nil, errNoAncients
+	}
+	if count > maxIterateItems {
+		count = maxIterateItems
+	}
+	if maxBytes == 0 || maxBytes > maxIterateBytes {
+		maxBytes = maxIterateBytes
+	}
+	items, err := s.ancients.AncientRange(kind, start, count, maxBytes)
+	if err != nil {
+		return nil, err
+	}
+	blobs := make([]hexutil.Bytes, len(items))
+	for i, item := range items {
+		blobs[i] = item
+	}
+	return blobs, nil
+}
+
+// Ancients returns the ancient item numbers in the ancient store.
+func (s *Service) Ancients() (uint64, error) {
+	if s.ancients == nil {
+		return 0, errNoAncients
+	}
+	return s.ancients.Ancients()
+}
+
+// Tail returns the number of first stored item in the ancient store.
+func (s *Service) Tail() (uint64, error) {
+	if s.ancients == nil {
+		return 0, errNoAncients
+	}
+	return s.ancients.Tail()
+}
+
+// AncientSize returns the ancient size of the specified category.
+func (s *Service) AncientSize(kind string) (uint64, error) {
+	if s.ancients == nil {
+		return 0, errNoAncients
+	}
+	return s.ancients.AncientSize(kind)
+}
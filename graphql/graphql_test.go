@@ -360,6 +360,63 @@ func TestGraphQLConcurrentResolvers(t *testing.T) {
 	}
 }
 
+// Tests that the uncle rate is measured across the most recent canonical blocks.
+func TestGraphQLUncleRate(t *testing.T) {
+	var (
+		genesis = &core.Genesis{
+			Config:     params.AllEthashProtocolChanges,
+			GasLimit:   11500000,
+			Difficulty: big.NewInt(1048576),
+		}
+		stack = createNode(t)
+	)
+	defer stack.Close()
+
+	handler, _ := newGQLService(t, stack, genesis, 4, func(i int, gen *core.BlockGen) {
+		if i == 3 {
+			// Block 4 includes blocks 2 and 3 as uncle headers (with modified extra data).
+			b2 := gen.PrevBlock(1).Header()
+			b2.Extra = []byte("foo")
+			gen.AddUncle(b2)
+			b3 := gen.PrevBlock(2).Header()
+			b3.Extra = []byte("foo")
+			gen.AddUncle(b3)
+		}
+	})
+	if err := stack.Start(); err != nil {
+		t.Fatalf("could not start node: %v", err)
+	}
+	for i, tt := range []struct {
+		body string
+		want string
+	}{
+		{
+			body: "{ uncleRate { from to uncles rate } }",
+			want: `{"uncleRate":{"from":"0x0","to":"0x4","uncles":"0x2","rate":0.4}}`,
+		},
+		{
+			body: "{ uncleRate(blocks: 2) { from to uncles rate } }",
+			want: `{"uncleRate":{"from":"0x3","to":"0x4","uncles":"0x2","rate":1}}`,
+		},
+		{
+			body: "{ uncleRate(blocks: 1) { from to uncles rate } }",
+			want: `{"uncleRate":{"from":"0x4","to":"0x4","uncles":"0x2","rate":2}}`,
+		},
+	} {
+		res := handler.Schema.Exec(context.Background(), tt.body, "", map[string]interface{}{})
+		if res.Errors != nil {
+			t.Fatalf("failed to execute query for testcase #%d: %v", i, res.Errors)
+		}
+		have, err := json.Marshal(res.Data)
+		if err != nil {
+			t.Fatalf("failed to encode graphql response for testcase #%d: %s", i, err)
+		}
+		if string(have) != tt.want {
+			t.Errorf("response unmatch for testcase #%d.\nExpected:\n%s\nGot:\n%s\n", i, tt.want, have)
+		}
+	}
+}
+
 func createNode(t *testing.T) *node.Node {
 	stack, err := node.New(&node.Config{
 		HTTPHost:     "127.0.0.1",
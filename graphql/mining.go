@@ -0,0 +1,228 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	defaultUncleRateBlocks = 100  // Blocks the uncle rate is measured over by default
+	maxUncleRateBlocks     = 1024 // Maximum blocks the uncle rate is measured over
+)
+
+var errNoEthash = errors.New("ethash engine not running")
+
+// ethash returns the API of the node's ethash engine, unwrapping the beacon
+// engine if needed.
+func (r *Resolver) ethash() (*ethash.API, error) {
+	engine := r.backend.Engine()
+	if b, ok := engine.(*beacon.Beacon); ok {
+		engine = b.InnerEngine()
+	}
+	e, ok := engine.(*ethash.Ethash)
+	if !ok {
+		return nil, errNoEthash
+	}
+	return ethash.NewAPI(e, nil), nil
+}
+
+// Block returns the pending block assembled by the miner.
+func (p *Pending) Block(ctx context.Context) *Block {
+	block, receipts := p.r.backend.PendingBlockAndReceipts()
+	if block == nil {
+		return nil
+	}
+	if receipts == nil {
+		// Avoid looking up receipts of the pending block by hash
+		receipts = make(types.Receipts, 0)
+	}
+	numberOrHash := rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
+	return &Block{
+		r:            p.r,
+		numberOrHash: &numberOrHash,
+		hash:         block.Hash(),
+		header:       block.Header(),
+		block:        block,
+		receipts:     receipts,
+	}
+}
+
+// Work is the package of the block being sealed by the ethash remote sealer.
+type Work struct {
+	work ethash.WorkPackage
+}
+
+func (w *Work) PowHash() common.Hash    { return common.HexToHash(w.work[0]) }
+func (w *Work) SeedHash() common.Hash   { return common.HexToHash(w.work[1]) }
+func (w *Work) Target() common.Hash     { return common.HexToHash(w.work[2]) }
+func (w *Work) ParentHash() common.Hash { return common.HexToHash(w.work[4]) }
+
+func (w *Work) Number() (hexutil.Uint64, error)           { return w.uint64(3) }
+func (w *Work) GasLimit() (hexutil.Uint64, error)         { return w.uint64(5) }
+func (w *Work) GasUsed() (hexutil.Uint64, error)          { return w.uint64(6) }
+func (w *Work) TransactionCount() (hexutil.Uint64, error) { return w.uint64(7) }
+func (w *Work) UncleCount() (hexutil.Uint64, error)       { return w.uint64(8) }
+
+// uint64 decodes the hex encoded quantity of the work package at index i.
+func (w *Work) uint64(i int) (hexutil.Uint64, error) {
+	n, err := hexutil.DecodeUint64(w.work[i])
+	return hexutil.Uint64(n), err
+}
+
+func (w *Work) HeaderRLP() (*hexutil.Bytes, error) {
+	if w.work[9] == "" {
+		return nil, nil
+	}
+	blob, err := hexutil.Decode(w.work[9])
+	if err != nil {
+		return nil, err
+	}
+	return (*hexutil.Bytes)(&blob), nil
+}
+
+// MinerShares is the share ledger of a remote miner.
+type MinerShares struct {
+	id       common.Hash
+	stats    ethash.MinerStats
+	reported hexutil.Uint64
+}
+
+func (m *MinerShares) ID() common.Hash                  { return m.id }
+func (m *MinerShares) Accepted() hexutil.Uint64         { return m.stats.Accepted }
+func (m *MinerShares) Stale() hexutil.Uint64            { return m.stats.Stale }
+func (m *MinerShares) Invalid() hexutil.Uint64          { return m.stats.Invalid }
+func (m *MinerShares) StaleAccepted() hexutil.Uint64    { return m.stats.StaleAccepted }
+func (m *MinerShares) LastSubmit() hexutil.Uint64       { return m.stats.LastSubmit }
+func (m *MinerShares) Hashrate() hexutil.Uint64         { return m.stats.Hashrate }
+func (m *MinerShares) ReportedHashrate() hexutil.Uint64 { return m.reported }
+
+// ShareStats are the solutions submitted to the ethash remote sealer.
+type ShareStats struct {
+	api      *ethash.API
+	snapshot ethash.StatsSnapshot
+}
+
+func (s *ShareStats) Hashrate() hexutil.Uint64      { return s.snapshot.Hashrate }
+func (s *ShareStats) Accepted() hexutil.Uint64      { return s.snapshot.Submits.Accepted }
+func (s *ShareStats) Stale() hexutil.Uint64         { return s.snapshot.Submits.Stale }
+func (s *ShareStats) Invalid() hexutil.Uint64       { return s.snapshot.Submits.Invalid }
+func (s *ShareStats) Duplicate() hexutil.Uint64     { return s.snapshot.Submits.Duplicate }
+func (s *ShareStats) Unknown() hexutil.Uint64       { return s.snapshot.Submits.Unknown }
+func (s *ShareStats) Dropped() hexutil.Uint64       { return s.snapshot.Submits.Dropped }
+func (s *ShareStats) Shares() hexutil.Uint64        { return s.snapshot.Submits.Shares }
+func (s *ShareStats) StaleAccepted() hexutil.Uint64 { return s.snapshot.Submits.StaleAccepted }
+
+// Miners returns the share ledgers of the miners active in the snapshot,
+// ordered by id.
+func (s *ShareStats) Miners(ctx context.Context) ([]*MinerShares, error) {
+	ids := make([]common.Hash, 0, len(s.snapshot.Miners))
+	for id := range s.snapshot.Miners {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return bytes.Compare(ids[i][:], ids[j][:]) < 0 })
+
+	miners := make([]*MinerShares, 0, len(ids))
+	for _, id := range ids {
+		stats, err := s.api.GetMinerStats(id)
+		if err != nil {
+			return nil, err
+		}
+		miners = append(miners, &MinerShares{id: id, stats: stats, reported: s.snapshot.Miners[id]})
+	}
+	return miners, nil
+}
+
+// UncleRate is the inclusion of uncles across a range of canonical blocks.
+type UncleRate struct {
+	from, to uint64
+	uncles   uint64
+}
+
+func (u *UncleRate) From() hexutil.Uint64   { return hexutil.Uint64(u.from) }
+func (u *UncleRate) To() hexutil.Uint64     { return hexutil.Uint64(u.to) }
+func (u *UncleRate) Uncles() hexutil.Uint64 { return hexutil.Uint64(u.uncles) }
+
+func (u *UncleRate) Rate() float64 {
+	return float64(u.uncles) / float64(u.to-u.from+1)
+}
+
+func (r *Resolver) Work(ctx context.Context) (*Work, error) {
+	api, err := r.ethash()
+	if err != nil {
+		return nil, err
+	}
+	work, err := api.GetWork()
+	if err != nil {
+		return nil, err
+	}
+	return &Work{work: work}, nil
+}
+
+func (r *Resolver) ShareStats(ctx context.Context) (*ShareStats, error) {
+	api, err := r.ethash()
+	if err != nil {
+		return nil, err
+	}
+	return &ShareStats{api: api, snapshot: api.GetStatsSnapshot()}, nil
+}
+
+func (r *Resolver) UncleRate(ctx context.Context, args struct{ Blocks *int32 }) (*UncleRate, error) {
+	count := uint64(defaultUncleRateBlocks)
+	if args.Blocks != nil {
+		if *args.Blocks <= 0 {
+			return nil, errors.New("blocks must be positive")
+		}
+		count = uint64(*args.Blocks)
+	}
+	if count > maxUncleRateBlocks {
+		count = maxUncleRateBlocks
+	}
+	head := r.backend.CurrentHeader().Number.Uint64()
+	if count > head+1 {
+		count = head + 1
+	}
+	rate := &UncleRate{from: head + 1 - count, to: head}
+	for number := rate.from; number <= rate.to; number++ {
+		header, err := r.backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
+		if err != nil {
+			return nil, err
+		}
+		if header == nil {
+			return nil, errors.New("header not found")
+		}
+		// Skip fetching the bodies of blocks without uncles
+		if header.UncleHash == types.EmptyUncleHash {
+			continue
+		}
+		block, err := r.backend.BlockByHash(ctx, header.Hash())
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			return nil, errors.New("block not found")
+		}
+		rate.uncles += uint64(len(block.Uncles()))
+	}
+	return rate, nil
+}
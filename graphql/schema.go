@@ -323,6 +323,92 @@ const schema string = `
       # EstimateGas estimates the amount of gas that will be required for
       # successful execution of a transaction for the pending state.
       estimateGas(data: CallData!): Long!
+      # Block is the pending block assembled by the miner, or null if the node
+      # is not assembling one.
+      block: Block
+    }
+
+    # Work is the package of the block being sealed by the ethash remote sealer.
+    type Work {
+        # PowHash is the header pow-hash miners search a nonce for.
+        powHash: Bytes32!
+        # SeedHash is the seed hash of the mining dataset.
+        seedHash: Bytes32!
+        # Target is the boundary condition a solution has to meet, 2^256/difficulty.
+        target: Bytes32!
+        # Number is the number of the block being sealed.
+        number: Long!
+        # ParentHash is the hash of the parent of the block being sealed.
+        parentHash: Bytes32!
+        # GasLimit is the gas limit of the block being sealed.
+        gasLimit: Long!
+        # GasUsed is the gas used by the transactions of the block being sealed.
+        gasUsed: Long!
+        # TransactionCount is the number of transactions of the block being sealed.
+        transactionCount: Long!
+        # UncleCount is the number of uncles of the block being sealed.
+        uncleCount: Long!
+        # HeaderRLP is the RLP encoded header with empty extraNonce bytes, or
+        # null if it could not be encoded.
+        headerRLP: Bytes
+    }
+
+    # MinerShares is the share ledger of a remote miner.
+    type MinerShares {
+        # ID is the id the miner submits its solutions with.
+        id: Bytes32!
+        # Accepted is the number of accepted shares.
+        accepted: Long!
+        # Stale is the number of shares for outdated work.
+        stale: Long!
+        # Invalid is the number of invalid, duplicate and unknown work shares.
+        invalid: Long!
+        # StaleAccepted is the number of shares for replaced work sealed as
+        # potential uncles.
+        staleAccepted: Long!
+        # LastSubmit is the unix timestamp of the last submission, zero if never seen.
+        lastSubmit: Long!
+        # Hashrate is estimated from the difficulty of the accepted shares.
+        hashrate: Long!
+        # ReportedHashrate is the hash rate last submitted by the miner itself.
+        reportedHashrate: Long!
+    }
+
+    # ShareStats are the solutions submitted to the ethash remote sealer.
+    type ShareStats {
+        # Hashrate is the local plus remote hash rate.
+        hashrate: Long!
+        # Accepted is the number of solutions sealing a block.
+        accepted: Long!
+        # Stale is the number of solutions for outdated work.
+        stale: Long!
+        # Invalid is the number of solutions failing verification.
+        invalid: Long!
+        # Duplicate is the number of solutions submitted more than once.
+        duplicate: Long!
+        # Unknown is the number of solutions for work never issued.
+        unknown: Long!
+        # Dropped is the number of solutions dropped under load.
+        dropped: Long!
+        # Shares is the number of solutions meeting a share target only.
+        shares: Long!
+        # StaleAccepted is the number of solutions for replaced work sealed as
+        # potential uncles.
+        staleAccepted: Long!
+        # Miners is the share ledger of every active remote miner.
+        miners: [MinerShares!]!
+    }
+
+    # UncleRate is the inclusion of uncles across a range of canonical blocks.
+    type UncleRate {
+        # From is the first block of the range, inclusive.
+        from: Long!
+        # To is the last block of the range, inclusive.
+        to: Long!
+        # Uncles is the number of uncles included by the blocks of the range.
+        uncles: Long!
+        # Rate is the average number of uncles included per block.
+        rate: Float!
     }
 
     type Query {
@@ -348,6 +434,14 @@ const schema string = `
         syncing: SyncState
         # ChainID returns the current chain ID for transaction replay protection.
         chainID: BigInt!
+        # Work returns the package of the block being sealed by the ethash
+        # remote sealer, or null if there is no work.
+        work: Work
+        # ShareStats returns the solutions submitted to the ethash remote sealer.
+        shareStats: ShareStats!
+        # UncleRate returns the uncle inclusion of the most recent blocks,
+        # defaulting to 100 and capped at 1024.
+        uncleRate(blocks: Int): UncleRate!
     }
 
     type Mutation {
@@ -0,0 +1,477 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+// Package grpcgw implements a gRPC gateway to the JSON-RPC methods used by mining
+// pool backends: the block, account and transaction methods of the eth namespace,
+// the work package methods eth_getWork, eth_submitWork and eth_submitHashrate,
+// txpool_status and the newHeads subscription.
+//
+// The gateway is deliberately limited to these calls. The ethash namespace and
+// the newPendingTransactions and logs subscriptions are only served over
+// JSON-RPC.
+package grpcgw
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative gateway.proto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/rpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// apiKeyMetadata is the gRPC metadata key carrying the API key of a call.
+var apiKeyMetadata = strings.ToLower(rpc.APIKeyHeader)
+
+// gatewayServer is the service implementation of the gateway, as described by
+// gateway.proto.
+type gatewayServer interface {
+	BlockNumber(context.Context, *BlockNumberRequest) (*BlockNumberReply, error)
+	GetBalance(context.Context, *AccountRequest) (*BalanceReply, error)
+	GetTransactionCount(context.Context, *AccountRequest) (*TransactionCountReply, error)
+	SendRawTransaction(context.Context, *SendRawTransactionRequest) (*TransactionHashReply, error)
+	GetTransactionReceipt(context.Context, *TransactionReceiptRequest) (*TransactionReceiptReply, error)
+	GetWork(context.Context, *GetWorkRequest) (*WorkReply, error)
+	SubmitWork(context.Context, *SubmitWorkRequest) (*SubmitReply, error)
+	SubmitHashrate(context.Context, *SubmitHashrateRequest) (*SubmitReply, error)
+	TxPoolStatus(context.Context, *TxPoolStatusRequest) (*TxPoolStatusReply, error)
+	SubscribeNewHeads(*SubscribeNewHeadsRequest, grpc.ServerStream) error
+}
+
+// unaryHandler creates the gRPC handler of a unary method of the gateway.
+func unaryHandler[Req any, Res any](name string, call func(gatewayServer, context.Context, *Req) (*Res, error)) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			req := new(Req)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			if interceptor == nil {
+				return call(srv.(gatewayServer), ctx, req)
+			}
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/bitnet.gateway.Gateway/" + name}
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				return call(srv.(gatewayServer), ctx, req.(*Req))
+			}
+			return interceptor(ctx, req, info, handler)
+		},
+	}
+}
+
+// serviceDesc describes the Gateway service of gateway.proto.
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: "bitnet.gateway.Gateway",
+	HandlerType: (*gatewayServer)(nil),
+	Methods: []grpc.MethodDesc{
+		unaryHandler("BlockNumber", gatewayServer.BlockNumber),
+		unaryHandler("GetBalance", gatewayServer.GetBalance),
+		unaryHandler("GetTransactionCount", gatewayServer.GetTransactionCount),
+		unaryHandler("SendRawTransaction", gatewayServer.SendRawTransaction),
+		unaryHandler("GetTransactionReceipt", gatewayServer.GetTransactionReceipt),
+		unaryHandler("GetWork", gatewayServer.GetWork),
+		unaryHandler("SubmitWork", gatewayServer.SubmitWork),
+		unaryHandler("SubmitHashrate", gatewayServer.SubmitHashrate),
+		unaryHandler("TxPoolStatus", gatewayServer.TxPoolStatus),
+	},
+	Streams: []grpc.StreamDesc{{
+		StreamName: "SubscribeNewHeads",
+		Handler: func(srv interface{}, stream grpc.ServerStream) error {
+			req := new(SubscribeNewHeadsRequest)
+			if err := stream.RecvMsg(req); err != nil {
+				return err
+			}
+			return srv.(gatewayServer).SubscribeNewHeads(req, stream)
+		},
+		ServerStreams: true,
+	}},
+	Metadata: "grpcgw/gateway.proto",
+}
+
+// gateway forwards gRPC requests to the node's in-process RPC client, enforcing
+// the modules, API keys and rate limits of the HTTP server.
+type gateway struct {
+	client  *rpc.Client
+	modules map[string]bool  // Namespaces offered over HTTP, nil if all
+	keys    *rpc.AuthKeys    // API keys permitted to call, nil if unrestricted
+	limiter *rpc.RateLimiter // Rate limiter of calls, nil if unlimited
+}
+
+// moduleSet returns the set of the given modules, nil if empty as all modules
+// are offered then.
+func moduleSet(modules []string) map[string]bool {
+	if len(modules) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(modules))
+	for _, module := range modules {
+		set[module] = true
+	}
+	return set
+}
+
+// authorize checks whether the client of a gRPC call may call the JSON-RPC
+// method it maps to.
+func (g *gateway) authorize(ctx context.Context, method string) error {
+	if g.modules != nil {
+		if namespace, _, _ := strings.Cut(method, "_"); !g.modules[namespace] {
+			return status.Errorf(codes.Unimplemented, "the method %s does not exist/is not available", method)
+		}
+	}
+	info := rpc.PeerInfo{Transport: "grpc"}
+	if p, ok := peer.FromContext(ctx); ok {
+		info.RemoteAddr = p.Addr.String()
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if keys := md.Get(apiKeyMetadata); len(keys) > 0 {
+			info.HTTP.APIKey = keys[0]
+		}
+	}
+	if g.keys != nil && !g.keys.Allowed(method, info) {
+		return status.Errorf(codes.PermissionDenied, "unauthorized to call %s", method)
+	}
+	if g.limiter != nil {
+		if wait, ok := g.limiter.Allow(method, info); !ok {
+			return status.Errorf(codes.ResourceExhausted, "rate limit of %s exceeded, retry after %v", method, wait)
+		}
+	}
+	return nil
+}
+
+// call authorizes and invokes a JSON-RPC method, decoding its result.
+func (g *gateway) call(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	if err := g.authorize(ctx, method); err != nil {
+		return err
+	}
+	if err := g.client.CallContext(ctx, result, method, args...); err != nil {
+		return rpcStatus(err)
+	}
+	return nil
+}
+
+// rpcStatus converts a JSON-RPC error to a gRPC status error.
+func rpcStatus(err error) error {
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		return status.Error(codes.Unknown, rpcErr.Error())
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// hash validates a 32 byte hash argument.
+func hash(name string, b []byte) (common.Hash, error) {
+	if len(b) != common.HashLength {
+		return common.Hash{}, status.Errorf(codes.InvalidArgument, "invalid %s length %d", name, len(b))
+	}
+	return common.BytesToHash(b), nil
+}
+
+// bigBytes returns the big-endian bytes of an optional big integer.
+func bigBytes(n *hexutil.Big) []byte {
+	if n == nil {
+		return nil
+	}
+	return (*big.Int)(n).Bytes()
+}
+
+func (g *gateway) BlockNumber(ctx context.Context, req *BlockNumberRequest) (*BlockNumberReply, error) {
+	var number hexutil.Uint64
+	if err := g.call(ctx, &number, "eth_blockNumber"); err != nil {
+		return nil, err
+	}
+	return &BlockNumberReply{Number: uint64(number)}, nil
+}
+
+// account validates the address and block of an account request.
+func account(req *AccountRequest) (common.Address, string, error) {
+	if len(req.Address) != common.AddressLength {
+		return common.Address{}, "", status.Errorf(codes.InvalidArgument, "invalid address length %d", len(req.Address))
+	}
+	block := req.Block
+	if block == "" {
+		block = "latest"
+	}
+	return common.BytesToAddress(req.Address), block, nil
+}
+
+func (g *gateway) GetBalance(ctx context.Context, req *AccountRequest) (*BalanceReply, error) {
+	addr, block, err := account(req)
+	if err != nil {
+		return nil, err
+	}
+	var balance hexutil.Big
+	if err := g.call(ctx, &balance, "eth_getBalance", addr, block); err != nil {
+		return nil, err
+	}
+	return &BalanceReply{Balance: bigBytes(&balance)}, nil
+}
+
+func (g *gateway) GetTransactionCount(ctx context.Context, req *AccountRequest) (*TransactionCountReply, error) {
+	addr, block, err := account(req)
+	if err != nil {
+		return nil, err
+	}
+	var nonce hexutil.Uint64
+	if err := g.call(ctx, &nonce, "eth_getTransactionCount", addr, block); err != nil {
+		return nil, err
+	}
+	return &TransactionCountReply{Nonce: uint64(nonce)}, nil
+}
+
+func (g *gateway) SendRawTransaction(ctx context.Context, req *SendRawTransactionRequest) (*TransactionHashReply, error) {
+	var hash common.Hash
+	if err := g.call(ctx, &hash, "eth_sendRawTransaction", hexutil.Bytes(req.Transaction)); err != nil {
+		return nil, err
+	}
+	return &TransactionHashReply{Hash: hash.Bytes()}, nil
+}
+
+func (g *gateway) GetTransactionReceipt(ctx context.Context, req *TransactionReceiptRequest) (*TransactionReceiptReply, error) {
+	txhash, err := hash("transaction hash", req.Hash)
+	if err != nil {
+		return nil, err
+	}
+	var receipt *struct {
+		Status            hexutil.Uint64 `json:"status"`
+		BlockHash         common.Hash    `json:"blockHash"`
+		BlockNumber       hexutil.Uint64 `json:"blockNumber"`
+		GasUsed           hexutil.Uint64 `json:"gasUsed"`
+		EffectiveGasPrice *hexutil.Big   `json:"effectiveGasPrice"`
+	}
+	if err := g.call(ctx, &receipt, "eth_getTransactionReceipt", txhash); err != nil {
+		return nil, err
+	}
+	if receipt == nil {
+		return &TransactionReceiptReply{}, nil
+	}
+	return &TransactionReceiptReply{
+		Found:             true,
+		Status:            uint64(receipt.Status),
+		BlockHash:         receipt.BlockHash.Bytes(),
+		BlockNumber:       uint64(receipt.BlockNumber),
+		GasUsed:           uint64(receipt.GasUsed),
+		EffectiveGasPrice: bigBytes(receipt.EffectiveGasPrice),
+	}, nil
+}
+
+func (g *gateway) GetWork(ctx context.Context, req *GetWorkRequest) (*WorkReply, error) {
+	var work []string
+	if err := g.call(ctx, &work, "eth_getWork"); err != nil {
+		return nil, err
+	}
+	if len(work) < 4 {
+		return nil, status.Errorf(codes.Internal, "invalid work package of %d items", len(work))
+	}
+	var (
+		reply  = new(WorkReply)
+		number hexutil.Uint64
+		err    error
+	)
+	if reply.HeaderHash, err = hexutil.Decode(work[0]); err == nil {
+		if reply.SeedHash, err = hexutil.Decode(work[1]); err == nil {
+			if reply.Target, err = hexutil.Decode(work[2]); err == nil {
+				err = number.UnmarshalText([]byte(work[3]))
+			}
+		}
+	}
+	if err == nil && len(work) > 9 && work[9] != "" {
+		reply.HeaderRlp, err = hexutil.Decode(work[9])
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "invalid work package: %v", err)
+	}
+	reply.Number = uint64(number)
+	return reply, nil
+}
+
+func (g *gateway) SubmitWork(ctx context.Context, req *SubmitWorkRequest) (*SubmitReply, error) {
+	header, err := hash("header hash", req.HeaderHash)
+	if err != nil {
+		return nil, err
+	}
+	digest, err := hash("mix digest", req.MixDigest)
+	if err != nil {
+		return nil, err
+	}
+	var accepted bool
+	if err := g.call(ctx, &accepted, "eth_submitWork", types.EncodeNonce(req.Nonce), header, digest); err != nil {
+		return nil, err
+	}
+	return &SubmitReply{Accepted: accepted}, nil
+}
+
+func (g *gateway) SubmitHashrate(ctx context.Context, req *SubmitHashrateRequest) (*SubmitReply, error) {
+	id, err := hash("miner id", req.Id)
+	if err != nil {
+		return nil, err
+	}
+	var accepted bool
+	if err := g.call(ctx, &accepted, "eth_submitHashrate", hexutil.Uint64(req.Rate), id); err != nil {
+		return nil, err
+	}
+	return &SubmitReply{Accepted: accepted}, nil
+}
+
+func (g *gateway) TxPoolStatus(ctx context.Context, req *TxPoolStatusRequest) (*TxPoolStatusReply, error) {
+	var stats map[string]hexutil.Uint64
+	if err := g.call(ctx, &stats, "txpool_status"); err != nil {
+		return nil, err
+	}
+	return &TxPoolStatusReply{Pending: uint64(stats["pending"]), Queued: uint64(stats["queued"])}, nil
+}
+
+// rpcHeader is the subset of the JSON-RPC representation of headers streamed by
+// the gateway.
+type rpcHeader struct {
+	Hash       common.Hash    `json:"hash"`
+	ParentHash common.Hash    `json:"parentHash"`
+	Number     *hexutil.Big   `json:"number"`
+	Difficulty *hexutil.Big   `json:"difficulty"`
+	Time       hexutil.Uint64 `json:"timestamp"`
+	Coinbase   common.Address `json:"miner"`
+	GasLimit   hexutil.Uint64 `json:"gasLimit"`
+	GasUsed    hexutil.Uint64 `json:"gasUsed"`
+	BaseFee    *hexutil.Big   `json:"baseFeePerGas"`
+}
+
+func (g *gateway) SubscribeNewHeads(req *SubscribeNewHeadsRequest, stream grpc.ServerStream) error {
+	ctx := stream.Context()
+	if err := g.authorize(ctx, "eth_subscribe"); err != nil {
+		return err
+	}
+	heads := make(chan *rpcHeader, 16)
+	sub, err := g.client.EthSubscribe(ctx, heads, "newHeads")
+	if err != nil {
+		return rpcStatus(err)
+	}
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case head := <-heads:
+			var number uint64
+			if head.Number != nil {
+				number = (*big.Int)(head.Number).Uint64()
+			}
+			err := stream.SendMsg(&Header{
+				Hash:       head.Hash.Bytes(),
+				ParentHash: head.ParentHash.Bytes(),
+				Number:     number,
+				Difficulty: bigBytes(head.Difficulty),
+				Time:       uint64(head.Time),
+				Coinbase:   head.Coinbase.Bytes(),
+				GasLimit:   uint64(head.GasLimit),
+				GasUsed:    uint64(head.GasUsed),
+				BaseFee:    bigBytes(head.BaseFee),
+			})
+			if err != nil {
+				return err
+			}
+		case err := <-sub.Err():
+			if err == nil {
+				return nil
+			}
+			return rpcStatus(err)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Config are the settings of the gRPC gateway.
+type Config struct {
+	Addr    string // Listening address
+	TLSCert string // TLS certificate file, plaintext if empty
+	TLSKey  string // TLS private key file of the certificate
+}
+
+// Service is the gRPC gateway of a node, started and stopped with it.
+type Service struct {
+	stack  *node.Node
+	config Config
+	client *rpc.Client
+	server *grpc.Server
+}
+
+// New registers a gRPC gateway with the node. It enforces the modules offered
+// over HTTP, and the API keys and rate limits configured for the HTTP and
+// WebSocket servers.
+func New(stack *node.Node, config Config) (*Service, error) {
+	if (config.TLSCert == "") != (config.TLSKey == "") {
+		return nil, errors.New("gRPC TLS requires both a certificate and a key")
+	}
+	s := &Service{stack: stack, config: config}
+	stack.RegisterLifecycle(s)
+	return s, nil
+}
+
+// Start implements node.Lifecycle, starting the gRPC server.
+func (s *Service) Start() error {
+	var opts []grpc.ServerOption
+	if s.config.TLSCert != "" {
+		creds, err := credentials.NewServerTLSFromFile(s.config.TLSCert, s.config.TLSKey)
+		if err != nil {
+			return fmt.Errorf("failed to load gRPC TLS credentials: %v", err)
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+	client, err := s.stack.Attach()
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", s.config.Addr)
+	if err != nil {
+		client.Close()
+		return err
+	}
+	keys, limiter := s.stack.RPCLimits()
+
+	s.client = client
+	s.server = grpc.NewServer(opts...)
+	s.server.RegisterService(&serviceDesc, &gateway{
+		client:  client,
+		modules: moduleSet(s.stack.Config().HTTPModules),
+		keys:    keys,
+		limiter: limiter,
+	})
+	go s.server.Serve(listener)
+
+	log.Info("Started gRPC gateway", "addr", listener.Addr(), "tls", s.config.TLSCert != "", "authkeys", keys != nil, "ratelimit", limiter != nil)
+	return nil
+}
+
+// Stop implements node.Lifecycle, stopping the gRPC server.
+func (s *Service) Stop() error {
+	if s.server != nil {
+		s.server.Stop()
+		s.client.Close()
+	}
+	return nil
+}
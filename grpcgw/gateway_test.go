@@ -0,0 +1,214 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package grpcgw
+
+import (
+	"context"
+	"io"
+	"math/big"
+	"net"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type testEthService struct{}
+
+func (testEthService) BlockNumber() hexutil.Uint64 { return 7 }
+
+func (testEthService) GetWork() ([11]string, error) {
+	return [11]string{"0x" + common.Bytes2Hex(common.Hash{1}.Bytes()), "0x" + common.Bytes2Hex(common.Hash{2}.Bytes()), "0x" + common.Bytes2Hex(common.Hash{3}.Bytes()), "0x8"}, nil
+}
+
+func (testEthService) SubmitWork(nonce types.BlockNonce, hash, digest common.Hash) bool {
+	return nonce.Uint64() == 42 && hash == common.Hash{1}
+}
+
+func (testEthService) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, _ := rpc.NotifierFromContext(ctx)
+	sub := notifier.CreateSubscription()
+	go func() {
+		for i := int64(0); i < 3; i++ {
+			notifier.Notify(sub.ID, map[string]interface{}{
+				"hash":       common.Hash{byte(i)},
+				"number":     (*hexutil.Big)(big.NewInt(i)),
+				"difficulty": (*hexutil.Big)(big.NewInt(1000)),
+			})
+		}
+	}()
+	return sub, nil
+}
+
+type testTxPoolService struct{}
+
+func (testTxPoolService) Status() map[string]hexutil.Uint {
+	return map[string]hexutil.Uint{"pending": 2, "queued": 1}
+}
+
+// newTestGateway serves a gateway to test JSON-RPC services with the given
+// modules, API keys and rate limits, returning the client of the gateway.
+func newTestGateway(t *testing.T, modules []string, keys *rpc.AuthKeys, limiter *rpc.RateLimiter) *testClient {
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", testEthService{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.RegisterName("txpool", testTxPoolService{}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Stop)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gw := grpc.NewServer()
+	gw.RegisterService(&serviceDesc, &gateway{client: rpc.DialInProc(server), modules: moduleSet(modules), keys: keys, limiter: limiter})
+	go gw.Serve(listener)
+	t.Cleanup(gw.Stop)
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &testClient{conn}
+}
+
+// testClient invokes the typed gateway methods used by the tests.
+type testClient struct{ conn *grpc.ClientConn }
+
+func (c *testClient) BlockNumber(ctx context.Context, req *BlockNumberRequest) (*BlockNumberReply, error) {
+	res := new(BlockNumberReply)
+	return res, c.conn.Invoke(ctx, "/bitnet.gateway.Gateway/BlockNumber", req, res)
+}
+
+func (c *testClient) GetWork(ctx context.Context, req *GetWorkRequest) (*WorkReply, error) {
+	res := new(WorkReply)
+	return res, c.conn.Invoke(ctx, "/bitnet.gateway.Gateway/GetWork", req, res)
+}
+
+func (c *testClient) SubmitWork(ctx context.Context, req *SubmitWorkRequest) (*SubmitReply, error) {
+	res := new(SubmitReply)
+	return res, c.conn.Invoke(ctx, "/bitnet.gateway.Gateway/SubmitWork", req, res)
+}
+
+func (c *testClient) TxPoolStatus(ctx context.Context, req *TxPoolStatusRequest) (*TxPoolStatusReply, error) {
+	res := new(TxPoolStatusReply)
+	return res, c.conn.Invoke(ctx, "/bitnet.gateway.Gateway/TxPoolStatus", req, res)
+}
+
+func (c *testClient) SubscribeNewHeads(ctx context.Context, req *SubscribeNewHeadsRequest) (grpc.ClientStream, error) {
+	desc := &grpc.StreamDesc{StreamName: "SubscribeNewHeads", ServerStreams: true}
+	stream, err := c.conn.NewStream(ctx, desc, "/bitnet.gateway.Gateway/SubscribeNewHeads")
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.SendMsg(req); err != nil {
+		return nil, err
+	}
+	return stream, stream.CloseSend()
+}
+
+func TestGatewayCall(t *testing.T) {
+	client := newTestGateway(t, nil, nil, nil)
+	ctx := context.Background()
+
+	number, err := client.BlockNumber(ctx, &BlockNumberRequest{})
+	if err != nil || number.Number != 7 {
+		t.Fatalf("block number mismatch: have %v, %v, want 7", number, err)
+	}
+	work, err := client.GetWork(ctx, &GetWorkRequest{})
+	if err != nil {
+		t.Fatalf("failed to get work: %v", err)
+	}
+	if common.BytesToHash(work.HeaderHash) != (common.Hash{1}) || common.BytesToHash(work.Target) != (common.Hash{3}) || work.Number != 8 {
+		t.Errorf("work mismatch: have %v", work)
+	}
+	res, err := client.SubmitWork(ctx, &SubmitWorkRequest{Nonce: 42, HeaderHash: work.HeaderHash, MixDigest: make([]byte, 32)})
+	if err != nil || !res.Accepted {
+		t.Errorf("work submission rejected: %v, %v", res, err)
+	}
+	if _, err := client.SubmitWork(ctx, &SubmitWorkRequest{Nonce: 42, HeaderHash: work.HeaderHash[:4]}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("truncated hash: have %v, want invalid argument", err)
+	}
+	pool, err := client.TxPoolStatus(ctx, &TxPoolStatusRequest{})
+	if err != nil || pool.Pending != 2 || pool.Queued != 1 {
+		t.Errorf("pool status mismatch: have %v, %v", pool, err)
+	}
+}
+
+func TestGatewaySubscribe(t *testing.T) {
+	client := newTestGateway(t, nil, nil, nil)
+
+	stream, err := client.SubscribeNewHeads(context.Background(), &SubscribeNewHeadsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		head := new(Header)
+		if err := stream.RecvMsg(head); err != nil {
+			if err == io.EOF {
+				t.Fatalf("stream ended after %d headers", i)
+			}
+			t.Fatalf("failed to receive header %d: %v", i, err)
+		}
+		if head.Number != uint64(i) || common.BytesToHash(head.Hash) != (common.Hash{byte(i)}) {
+			t.Errorf("header %d mismatch: have %v", i, head)
+		}
+		if new(big.Int).SetBytes(head.Difficulty).Int64() != 1000 {
+			t.Errorf("header %d difficulty mismatch: have %x", i, head.Difficulty)
+		}
+	}
+}
+
+// Tests that the gateway enforces the API keys and rate limits of the node.
+func TestGatewayLimits(t *testing.T) {
+	keys := rpc.NewAuthKeys(map[string][]string{"miner": {"eth_blockNumber"}})
+	limiter := rpc.NewRateLimiter(map[string]rpc.RateLimit{"eth_blockNumber": {Rate: 1, Burst: 1}})
+	client := newTestGateway(t, nil, keys, limiter)
+
+	if _, err := client.BlockNumber(context.Background(), &BlockNumberRequest{}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("call without key: have %v, want permission denied", err)
+	}
+	ctx := metadata.AppendToOutgoingContext(context.Background(), apiKeyMetadata, "miner")
+	if _, err := client.TxPoolStatus(ctx, &TxPoolStatusRequest{}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("call outside the key permissions: have %v, want permission denied", err)
+	}
+	if _, err := client.BlockNumber(ctx, &BlockNumberRequest{}); err != nil {
+		t.Errorf("permitted call failed: %v", err)
+	}
+	if _, err := client.BlockNumber(ctx, &BlockNumberRequest{}); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("call beyond the rate limit: have %v, want resource exhausted", err)
+	}
+}
+
+// Tests that the gateway only serves the modules offered over HTTP.
+func TestGatewayModules(t *testing.T) {
+	client := newTestGateway(t, []string{"eth"}, nil, nil)
+	ctx := context.Background()
+
+	if _, err := client.BlockNumber(ctx, &BlockNumberRequest{}); err != nil {
+		t.Errorf("call of an offered module failed: %v", err)
+	}
+	if _, err := client.TxPoolStatus(ctx, &TxPoolStatusRequest{}); status.Code(err) != codes.Unimplemented {
+		t.Errorf("call of a module not offered: have %v, want unimplemented", err)
+	}
+}
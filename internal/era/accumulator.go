@@ -0,0 +1,98 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package era
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"math/bits"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ComputeAccumulator calculates the SSZ hash tree root of the header records of
+// an epoch, each record being the block hash and the total difficulty of the
+// block. The root commits to the whole epoch, allowing the files to be shared
+// over untrusted channels and verified against a handful of known roots.
+func ComputeAccumulator(hashes []common.Hash, tds []*big.Int) (common.Hash, error) {
+	if len(hashes) != len(tds) {
+		return common.Hash{}, fmt.Errorf("mismatching header records: %d hashes, %d tds", len(hashes), len(tds))
+	}
+	if len(hashes) > MaxEra1Size {
+		return common.Hash{}, fmt.Errorf("too many header records: have %d, max %d", len(hashes), MaxEra1Size)
+	}
+	leaves := make([][32]byte, len(hashes))
+	for i := range hashes {
+		td, err := uint256LE(tds[i])
+		if err != nil {
+			return common.Hash{}, err
+		}
+		leaves[i] = sha256.Sum256(append(hashes[i].Bytes(), td[:]...))
+	}
+	root := merkleize(leaves, MaxEra1Size)
+
+	// Mix in the length of the record list, as SSZ lists do
+	var length [32]byte
+	binary.LittleEndian.PutUint64(length[:8], uint64(len(hashes)))
+	return sha256.Sum256(append(root[:], length[:]...)), nil
+}
+
+// merkleize computes the root of the binary merkle tree over the given chunks,
+// padded with zero chunks up to the limit.
+func merkleize(chunks [][32]byte, limit int) [32]byte {
+	depth := bits.Len(uint(limit - 1))
+
+	zeros := make([][32]byte, depth+1)
+	for i := 1; i <= depth; i++ {
+		zeros[i] = sha256.Sum256(append(zeros[i-1][:], zeros[i-1][:]...))
+	}
+	layer := chunks
+	for d := 0; d < depth; d++ {
+		if len(layer)%2 == 1 {
+			layer = append(layer, zeros[d])
+		}
+		next := make([][32]byte, len(layer)/2)
+		for i := range next {
+			next[i] = sha256.Sum256(append(layer[2*i][:], layer[2*i+1][:]...))
+		}
+		layer = next
+	}
+	if len(layer) == 0 {
+		return zeros[depth]
+	}
+	return layer[0]
+}
+
+// uint256LE encodes a non-negative integer as a 32 byte little endian value.
+func uint256LE(v *big.Int) ([32]byte, error) {
+	var out [32]byte
+	if v.Sign() < 0 || v.BitLen() > 256 {
+		return out, fmt.Errorf("invalid total difficulty: %v", v)
+	}
+	v.FillBytes(out[:])
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out, nil
+}
+
+// uint256FromLE decodes a 32 byte little endian value.
+func uint256FromLE(b []byte) *big.Int {
+	be := make([]byte, len(b))
+	for i := range b {
+		be[len(b)-1-i] = b[i]
+	}
+	return new(big.Int).SetBytes(be)
+}
@@ -0,0 +1,97 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package era
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// headerSize is the size of an e2store entry header: a 2 byte type, a 4 byte
+// little endian value length and 2 reserved zero bytes.
+const headerSize = 8
+
+var errReservedNotZero = errors.New("reserved bytes of e2store entry not zero")
+
+// Entry is a single type-length-value record of an e2store file.
+type Entry struct {
+	Type  uint16
+	Value []byte
+}
+
+// Writer appends e2store entries to an output stream.
+type Writer struct {
+	w io.Writer
+}
+
+// NewWriter creates an e2store writer on top of the given stream.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Write appends an entry of the given type, returning the number of bytes
+// written including the entry header.
+func (w *Writer) Write(typ uint16, value []byte) (int, error) {
+	var header [headerSize]byte
+	binary.LittleEndian.PutUint16(header[:2], typ)
+	binary.LittleEndian.PutUint32(header[2:6], uint32(len(value)))
+
+	n, err := w.w.Write(header[:])
+	if err != nil {
+		return n, err
+	}
+	m, err := w.w.Write(value)
+	return n + m, err
+}
+
+// Reader reads e2store entries from random positions of an input.
+type Reader struct {
+	r io.ReaderAt
+}
+
+// NewReader creates an e2store reader on top of the given input.
+func NewReader(r io.ReaderAt) *Reader {
+	return &Reader{r: r}
+}
+
+// ReadMetadataAt reads the header of the entry at the given offset, returning
+// its type and value length.
+func (r *Reader) ReadMetadataAt(off int64) (uint16, uint32, error) {
+	var header [headerSize]byte
+	if _, err := r.r.ReadAt(header[:], off); err != nil {
+		return 0, 0, err
+	}
+	if header[6] != 0 || header[7] != 0 {
+		return 0, 0, errReservedNotZero
+	}
+	return binary.LittleEndian.Uint16(header[:2]), binary.LittleEndian.Uint32(header[2:6]), nil
+}
+
+// ReadAt reads the entry at the given offset, returning it along with its total
+// size including the entry header.
+func (r *Reader) ReadAt(off int64) (*Entry, int64, error) {
+	typ, length, err := r.ReadMetadataAt(off)
+	if err != nil {
+		return nil, 0, err
+	}
+	value := make([]byte, length)
+	if _, err := r.r.ReadAt(value, off+headerSize); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, 0, fmt.Errorf("truncated e2store entry at %d: %w", off, err)
+	}
+	return &Entry{Type: typ, Value: value}, headerSize + int64(length), nil
+}
@@ -0,0 +1,490 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+// Package era implements the era1 archive format of historical chain data.
+//
+// An era1 file is an e2store file holding a fixed-size epoch of consecutive
+// blocks, each stored as its snappy compressed header, body and receipts along
+// with its total difficulty. The blocks are followed by the accumulator, the
+// hash tree root of the (block hash, total difficulty) records of the epoch,
+// and by an index of the block offsets:
+//
+//	era1 := Version | block-tuple* | Accumulator | BlockIndex
+//	block-tuple := CompressedHeader | CompressedBody | CompressedReceipts | TotalDifficulty
+//	BlockIndex := starting-number | offset* | count
+//
+// As an epoch is immutable once written, the files and their accumulators can
+// be distributed over untrusted channels, such as torrents, to bootstrap the
+// chain history of new nodes.
+package era
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/golang/snappy"
+)
+
+// Entry types of the era1 format.
+const (
+	TypeVersion            uint16 = 0x3265
+	TypeCompressedHeader   uint16 = 0x03
+	TypeCompressedBody     uint16 = 0x04
+	TypeCompressedReceipts uint16 = 0x05
+	TypeTotalDifficulty    uint16 = 0x06
+	TypeAccumulator        uint16 = 0x07
+	TypeBlockIndex         uint16 = 0x3266
+)
+
+// MaxEra1Size is the number of blocks in an epoch, and thus the maximum number
+// of blocks in a single era1 file.
+const MaxEra1Size = 8192
+
+var (
+	errEmptyEpoch     = errors.New("no blocks added to era1 file")
+	errEpochFull      = errors.New("era1 file full")
+	errNotContiguous  = errors.New("non contiguous block added to era1 file")
+	errMissingVersion = errors.New("era1 file missing version entry")
+)
+
+// Filename returns the canonical name of the era1 file of an epoch, ending with
+// the first bytes of its accumulator root.
+func Filename(network string, epoch int, root common.Hash) string {
+	return fmt.Sprintf("%s-%05d-%s.era1", network, epoch, common.Bytes2Hex(root[:4]))
+}
+
+// ReadDir returns the era1 files of a network in the given directory, sorted by
+// epoch. The epochs must be contiguous, starting at zero.
+func ReadDir(dir, network string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		files  []string
+		epochs []int
+	)
+	for _, entry := range entries {
+		name := entry.Name()
+		if filepath.Ext(name) != ".era1" || !strings.HasPrefix(name, network+"-") {
+			continue
+		}
+		var epoch int
+		if _, err := fmt.Sscanf(strings.TrimPrefix(name, network+"-"), "%05d-", &epoch); err != nil {
+			return nil, fmt.Errorf("malformed era1 filename %q: %v", name, err)
+		}
+		files, epochs = append(files, name), append(epochs, epoch)
+	}
+	sort.Sort(byEpoch{files, epochs})
+	for i, epoch := range epochs {
+		if epoch != i {
+			return nil, fmt.Errorf("missing era1 file for epoch %d", i)
+		}
+	}
+	return files, nil
+}
+
+// byEpoch sorts era1 filenames by their parsed epochs.
+type byEpoch struct {
+	files  []string
+	epochs []int
+}
+
+func (s byEpoch) Len() int           { return len(s.files) }
+func (s byEpoch) Less(i, j int) bool { return s.epochs[i] < s.epochs[j] }
+func (s byEpoch) Swap(i, j int) {
+	s.files[i], s.files[j] = s.files[j], s.files[i]
+	s.epochs[i], s.epochs[j] = s.epochs[j], s.epochs[i]
+}
+
+// Builder writes the blocks of an epoch into an era1 file.
+type Builder struct {
+	w       *Writer
+	written int64
+
+	start   *uint64
+	offsets []int64
+	hashes  []common.Hash
+	tds     []*big.Int
+}
+
+// NewBuilder creates an era1 builder writing into the given stream.
+func NewBuilder(w io.Writer) *Builder {
+	return &Builder{w: NewWriter(w)}
+}
+
+// Add appends a block with its receipts and total difficulty to the file.
+func (b *Builder) Add(block *types.Block, receipts types.Receipts, td *big.Int) error {
+	header, err := rlp.EncodeToBytes(block.Header())
+	if err != nil {
+		return err
+	}
+	body, err := rlp.EncodeToBytes(block.Body())
+	if err != nil {
+		return err
+	}
+	rcpts, err := rlp.EncodeToBytes(receipts)
+	if err != nil {
+		return err
+	}
+	return b.AddRLP(header, body, rcpts, block.NumberU64(), block.Hash(), td)
+}
+
+// AddRLP appends an already encoded block with its receipts and total
+// difficulty to the file.
+func (b *Builder) AddRLP(header, body, receipts []byte, number uint64, hash common.Hash, td *big.Int) error {
+	if len(b.offsets) >= MaxEra1Size {
+		return errEpochFull
+	}
+	if b.start == nil {
+		if _, err := b.write(TypeVersion, nil); err != nil {
+			return err
+		}
+		b.start = &number
+	} else if number != *b.start+uint64(len(b.offsets)) {
+		return errNotContiguous
+	}
+	tdval, err := uint256LE(td)
+	if err != nil {
+		return err
+	}
+	b.offsets = append(b.offsets, b.written)
+	b.hashes = append(b.hashes, hash)
+	b.tds = append(b.tds, new(big.Int).Set(td))
+
+	for _, entry := range []struct {
+		typ   uint16
+		value []byte
+	}{
+		{TypeCompressedHeader, header},
+		{TypeCompressedBody, body},
+		{TypeCompressedReceipts, receipts},
+	} {
+		if _, err := b.writeCompressed(entry.typ, entry.value); err != nil {
+			return err
+		}
+	}
+	_, err = b.write(TypeTotalDifficulty, tdval[:])
+	return err
+}
+
+// Finalize writes the accumulator and the block index, returning the root of
+// the accumulator.
+func (b *Builder) Finalize() (common.Hash, error) {
+	if b.start == nil {
+		return common.Hash{}, errEmptyEpoch
+	}
+	root, err := ComputeAccumulator(b.hashes, b.tds)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if _, err := b.write(TypeAccumulator, root[:]); err != nil {
+		return common.Hash{}, err
+	}
+	// The index offsets are relative to the start of the index entry
+	var (
+		count = len(b.offsets)
+		index = make([]byte, 16+8*count)
+		base  = b.written
+	)
+	binary.LittleEndian.PutUint64(index, *b.start)
+	for i, offset := range b.offsets {
+		binary.LittleEndian.PutUint64(index[8+8*i:], uint64(offset-base))
+	}
+	binary.LittleEndian.PutUint64(index[8+8*count:], uint64(count))
+
+	if _, err := b.write(TypeBlockIndex, index); err != nil {
+		return common.Hash{}, err
+	}
+	return root, nil
+}
+
+// write appends an entry, tracking the size of the file.
+func (b *Builder) write(typ uint16, value []byte) (int, error) {
+	n, err := b.w.Write(typ, value)
+	b.written += int64(n)
+	return n, err
+}
+
+// writeCompressed appends an entry with its value snappy compressed in the
+// framed format.
+func (b *Builder) writeCompressed(typ uint16, value []byte) (int, error) {
+	var buf bytes.Buffer
+	w := snappy.NewBufferedWriter(&buf)
+	if _, err := w.Write(value); err != nil {
+		return 0, err
+	}
+	if err := w.Close(); err != nil {
+		return 0, err
+	}
+	return b.write(typ, buf.Bytes())
+}
+
+// ReadAtCloser is the input an era1 file is read from.
+type ReadAtCloser interface {
+	io.ReaderAt
+	io.Closer
+}
+
+// Era is a read-only era1 file.
+type Era struct {
+	f ReadAtCloser
+	r *Reader
+
+	start   uint64
+	offsets []int64 // Absolute offsets of the block tuples
+}
+
+// Open opens an era1 file from disk.
+func Open(path string) (*Era, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	e, err := From(f, stat.Size())
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return e, nil
+}
+
+// From reads an era1 file of the given size from the input, loading its block
+// index.
+func From(f ReadAtCloser, size int64) (*Era, error) {
+	r := NewReader(f)
+	if typ, _, err := r.ReadMetadataAt(0); err != nil {
+		return nil, err
+	} else if typ != TypeVersion {
+		return nil, errMissingVersion
+	}
+	// The block count is the last field of the trailing block index
+	if size < headerSize+16 {
+		return nil, fmt.Errorf("era1 file too small: %d bytes", size)
+	}
+	var buf [8]byte
+	if _, err := f.ReadAt(buf[:], size-8); err != nil {
+		return nil, err
+	}
+	count := binary.LittleEndian.Uint64(buf[:])
+	if count == 0 || count > MaxEra1Size {
+		return nil, fmt.Errorf("invalid era1 block count %d", count)
+	}
+	base := size - headerSize - 16 - 8*int64(count)
+	if base < 0 {
+		return nil, fmt.Errorf("era1 block index out of bounds")
+	}
+	entry, _, err := r.ReadAt(base)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Type != TypeBlockIndex || len(entry.Value) != 16+8*int(count) {
+		return nil, fmt.Errorf("malformed era1 block index")
+	}
+	e := &Era{
+		f:       f,
+		r:       r,
+		start:   binary.LittleEndian.Uint64(entry.Value),
+		offsets: make([]int64, count),
+	}
+	for i := range e.offsets {
+		e.offsets[i] = base + int64(binary.LittleEndian.Uint64(entry.Value[8+8*i:]))
+		if e.offsets[i] < 0 || e.offsets[i] >= base {
+			return nil, fmt.Errorf("era1 block offset %d out of bounds", i)
+		}
+	}
+	return e, nil
+}
+
+// Close closes the underlying input.
+func (e *Era) Close() error {
+	return e.f.Close()
+}
+
+// Start returns the number of the first block in the file.
+func (e *Era) Start() uint64 {
+	return e.start
+}
+
+// Count returns the number of blocks in the file.
+func (e *Era) Count() uint64 {
+	return uint64(len(e.offsets))
+}
+
+// GetBlockByNumber returns the block with the given number from the file.
+func (e *Era) GetBlockByNumber(number uint64) (*types.Block, error) {
+	entries, err := e.tuple(number)
+	if err != nil {
+		return nil, err
+	}
+	header := new(types.Header)
+	if err := decodeCompressed(entries[0], TypeCompressedHeader, header); err != nil {
+		return nil, err
+	}
+	body := new(types.Body)
+	if err := decodeCompressed(entries[1], TypeCompressedBody, body); err != nil {
+		return nil, err
+	}
+	block := types.NewBlockWithHeader(header).WithBody(body.Transactions, body.Uncles)
+	if body.Withdrawals != nil {
+		block = block.WithWithdrawals(body.Withdrawals)
+	}
+	return block, nil
+}
+
+// GetReceiptsByNumber returns the receipts of the block with the given number
+// from the file. Only the consensus fields of the receipts are populated.
+func (e *Era) GetReceiptsByNumber(number uint64) (types.Receipts, error) {
+	entries, err := e.tuple(number)
+	if err != nil {
+		return nil, err
+	}
+	var receipts types.Receipts
+	if err := decodeCompressed(entries[2], TypeCompressedReceipts, &receipts); err != nil {
+		return nil, err
+	}
+	return receipts, nil
+}
+
+// GetTotalDifficulty returns the total difficulty of the block with the given
+// number from the file.
+func (e *Era) GetTotalDifficulty(number uint64) (*big.Int, error) {
+	entries, err := e.tuple(number)
+	if err != nil {
+		return nil, err
+	}
+	if entries[3].Type != TypeTotalDifficulty || len(entries[3].Value) != 32 {
+		return nil, fmt.Errorf("malformed total difficulty of block %d", number)
+	}
+	return uint256FromLE(entries[3].Value), nil
+}
+
+// Accumulator returns the accumulator root stored in the file.
+func (e *Era) Accumulator() (common.Hash, error) {
+	// The accumulator directly precedes the block index
+	last := e.offsets[len(e.offsets)-1]
+	entries, err := e.entries(last, 5)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if entries[4].Type != TypeAccumulator || len(entries[4].Value) != common.HashLength {
+		return common.Hash{}, fmt.Errorf("malformed era1 accumulator")
+	}
+	return common.BytesToHash(entries[4].Value), nil
+}
+
+// Verify recomputes the accumulator from the blocks in the file and checks it
+// against the stored one, returning the root. The block hashes are derived from
+// the headers, so a successful verification authenticates the whole content,
+// given that the root is trusted.
+func (e *Era) Verify() (common.Hash, error) {
+	var (
+		hashes = make([]common.Hash, 0, len(e.offsets))
+		tds    = make([]*big.Int, 0, len(e.offsets))
+	)
+	for i := range e.offsets {
+		number := e.start + uint64(i)
+		block, err := e.GetBlockByNumber(number)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		if block.NumberU64() != number {
+			return common.Hash{}, fmt.Errorf("block number mismatch: have %d, want %d", block.NumberU64(), number)
+		}
+		if hash := types.DeriveSha(block.Transactions(), trieHasher()); hash != block.TxHash() {
+			return common.Hash{}, fmt.Errorf("block %d transaction root mismatch: have %x, want %x", number, hash, block.TxHash())
+		}
+		if hash := types.CalcUncleHash(block.Uncles()); hash != block.UncleHash() {
+			return common.Hash{}, fmt.Errorf("block %d uncle root mismatch: have %x, want %x", number, hash, block.UncleHash())
+		}
+		receipts, err := e.GetReceiptsByNumber(number)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		if hash := types.DeriveSha(receipts, trieHasher()); hash != block.ReceiptHash() {
+			return common.Hash{}, fmt.Errorf("block %d receipt root mismatch: have %x, want %x", number, hash, block.ReceiptHash())
+		}
+		td, err := e.GetTotalDifficulty(number)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		hashes, tds = append(hashes, block.Hash()), append(tds, td)
+	}
+	want, err := e.Accumulator()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	have, err := ComputeAccumulator(hashes, tds)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if have != want {
+		return common.Hash{}, fmt.Errorf("accumulator mismatch: have %x, want %x", have, want)
+	}
+	return have, nil
+}
+
+// trieHasher returns a hasher for deriving the transaction and receipt roots.
+func trieHasher() types.TrieHasher {
+	return trie.NewStackTrie(nil)
+}
+
+// tuple reads the four entries of the block with the given number.
+func (e *Era) tuple(number uint64) ([]*Entry, error) {
+	if number < e.start || number-e.start >= uint64(len(e.offsets)) {
+		return nil, fmt.Errorf("block %d out of era1 range [%d, %d)", number, e.start, e.start+uint64(len(e.offsets)))
+	}
+	return e.entries(e.offsets[number-e.start], 4)
+}
+
+// entries reads the given number of consecutive entries, starting at offset.
+func (e *Era) entries(offset int64, n int) ([]*Entry, error) {
+	entries := make([]*Entry, n)
+	for i := range entries {
+		entry, size, err := e.r.ReadAt(offset)
+		if err != nil {
+			return nil, err
+		}
+		entries[i], offset = entry, offset+size
+	}
+	return entries, nil
+}
+
+// decodeCompressed decompresses and RLP decodes the value of an entry of the
+// expected type.
+func decodeCompressed(entry *Entry, typ uint16, val interface{}) error {
+	if entry.Type != typ {
+		return fmt.Errorf("unexpected era1 entry type: have %#04x, want %#04x", entry.Type, typ)
+	}
+	blob, err := io.ReadAll(snappy.NewReader(bytes.NewReader(entry.Value)))
+	if err != nil {
+		return err
+	}
+	return rlp.DecodeBytes(blob, val)
+}
@@ -0,0 +1,154 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package era
+
+import (
+	"bytes"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// makeChain creates a chain of linked blocks with one transaction and receipt
+// each, along with their total difficulties.
+func makeChain(start uint64, n int) ([]*types.Block, []types.Receipts, []*big.Int) {
+	var (
+		blocks   []*types.Block
+		receipts []types.Receipts
+		tds      []*big.Int
+		parent   common.Hash
+		td       = big.NewInt(int64(start))
+	)
+	for i := 0; i < n; i++ {
+		number := start + uint64(i)
+		tx := types.NewTransaction(number, common.Address{0xaa}, big.NewInt(1), 21000, big.NewInt(1), nil)
+		receipt := &types.Receipt{
+			Status:            types.ReceiptStatusSuccessful,
+			CumulativeGasUsed: 21000,
+			Logs:              []*types.Log{{Address: common.Address{0xbb}, Data: []byte{byte(i)}}},
+		}
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+
+		header := &types.Header{ParentHash: parent, Number: new(big.Int).SetUint64(number), Difficulty: big.NewInt(2), GasLimit: 8_000_000}
+		block := types.NewBlock(header, []*types.Transaction{tx}, nil, []*types.Receipt{receipt}, trie.NewStackTrie(nil))
+
+		td = new(big.Int).Add(td, block.Difficulty())
+		blocks, receipts, tds = append(blocks, block), append(receipts, types.Receipts{receipt}), append(tds, td)
+		parent = block.Hash()
+	}
+	return blocks, receipts, tds
+}
+
+// Tests that blocks written into an era1 file are read back intact, and that
+// the file verifies against its accumulator.
+func TestEraRoundtrip(t *testing.T) {
+	blocks, receipts, tds := makeChain(128, 128)
+
+	var (
+		buf     bytes.Buffer
+		builder = NewBuilder(&buf)
+	)
+	for i, block := range blocks {
+		if err := builder.Add(block, receipts[i], tds[i]); err != nil {
+			t.Fatalf("failed to add block %d: %v", block.NumberU64(), err)
+		}
+	}
+	root, err := builder.Finalize()
+	if err != nil {
+		t.Fatalf("failed to finalize era1 file: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), Filename("test", 0, root))
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("failed to write era1 file: %v", err)
+	}
+	e, err := Open(path)
+	if err != nil {
+		t.Fatalf("failed to open era1 file: %v", err)
+	}
+	defer e.Close()
+
+	if e.Start() != 128 || e.Count() != 128 {
+		t.Fatalf("era1 range mismatch: have [%d, +%d), want [128, +128)", e.Start(), e.Count())
+	}
+	for i, want := range blocks {
+		block, err := e.GetBlockByNumber(want.NumberU64())
+		if err != nil {
+			t.Fatalf("failed to read block %d: %v", want.NumberU64(), err)
+		}
+		if block.Hash() != want.Hash() {
+			t.Errorf("block %d hash mismatch: have %x, want %x", want.NumberU64(), block.Hash(), want.Hash())
+		}
+		rcpts, err := e.GetReceiptsByNumber(want.NumberU64())
+		if err != nil {
+			t.Fatalf("failed to read receipts %d: %v", want.NumberU64(), err)
+		}
+		if have := types.DeriveSha(rcpts, trie.NewStackTrie(nil)); have != want.ReceiptHash() {
+			t.Errorf("block %d receipt root mismatch: have %x, want %x", want.NumberU64(), have, want.ReceiptHash())
+		}
+		td, err := e.GetTotalDifficulty(want.NumberU64())
+		if err != nil {
+			t.Fatalf("failed to read total difficulty %d: %v", want.NumberU64(), err)
+		}
+		if td.Cmp(tds[i]) != 0 {
+			t.Errorf("block %d total difficulty mismatch: have %v, want %v", want.NumberU64(), td, tds[i])
+		}
+	}
+	if _, err := e.GetBlockByNumber(256); err == nil {
+		t.Errorf("out of range block read succeeded")
+	}
+	have, err := e.Verify()
+	if err != nil {
+		t.Fatalf("failed to verify era1 file: %v", err)
+	}
+	if have != root {
+		t.Fatalf("accumulator mismatch: have %x, want %x", have, root)
+	}
+	files, err := ReadDir(filepath.Dir(path), "test")
+	if err != nil || len(files) != 1 || files[0] != filepath.Base(path) {
+		t.Fatalf("era1 directory listing mismatch: have %v (%v), want [%s]", files, err, filepath.Base(path))
+	}
+}
+
+// Tests that the builder refuses gaps in the added blocks.
+func TestEraNonContiguous(t *testing.T) {
+	blocks, receipts, tds := makeChain(0, 3)
+
+	builder := NewBuilder(new(bytes.Buffer))
+	if err := builder.Add(blocks[0], receipts[0], tds[0]); err != nil {
+		t.Fatalf("failed to add block: %v", err)
+	}
+	if err := builder.Add(blocks[2], receipts[2], tds[2]); err != errNotContiguous {
+		t.Fatalf("error mismatch: have %v, want %v", err, errNotContiguous)
+	}
+}
+
+// Tests that the accumulator commits to the total difficulties and to the
+// number of records, not only to the block hashes.
+func TestAccumulator(t *testing.T) {
+	hashes := []common.Hash{{0x01}, {0x02}}
+
+	a, _ := ComputeAccumulator(hashes, []*big.Int{big.NewInt(1), big.NewInt(2)})
+	b, _ := ComputeAccumulator(hashes, []*big.Int{big.NewInt(1), big.NewInt(3)})
+	c, _ := ComputeAccumulator(append(hashes, common.Hash{}), []*big.Int{big.NewInt(1), big.NewInt(2), new(big.Int)})
+	if a == b || a == c || b == c {
+		t.Fatalf("accumulator collision: %x, %x, %x", a, b, c)
+	}
+	if _, err := ComputeAccumulator(hashes, []*big.Int{big.NewInt(-1), big.NewInt(2)}); err == nil {
+		t.Fatalf("negative total difficulty accepted")
+	}
+}
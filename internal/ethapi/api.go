@@ -13,11 +13,13 @@
 package ethapi
 
 import (
+	"bytes"
 	"context"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"math/big"
+	"sort"
 	"strings"
 	"time"
 
@@ -28,6 +30,7 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/scwallet"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/lru"
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/consensus/misc"
@@ -182,8 +185,9 @@ func (s *TxPoolAPI) Content() map[string]map[string]map[string]*RPCTransaction {
 	return content
 }
 
-// ContentFrom returns the transactions contained within the transaction pool.
-func (s *TxPoolAPI) ContentFrom(addr common.Address) map[string]map[string]*RPCTransaction {
+// ContentFrom returns the transactions contained within the transaction pool
+// sent by the given account, optionally narrowed down by a filter.
+func (s *TxPoolAPI) ContentFrom(addr common.Address, filter *TxPoolFilter) map[string]map[string]*RPCTransaction {
 	content := make(map[string]map[string]*RPCTransaction, 2)
 	pending, queue := s.b.TxPoolContentFrom(addr)
 	curHeader := s.b.CurrentHeader()
@@ -191,14 +195,18 @@ func (s *TxPoolAPI) ContentFrom(addr common.Address) map[string]map[string]*RPCT
 	// Build the pending transactions
 	dump := make(map[string]*RPCTransaction, len(pending))
 	for _, tx := range pending {
-		dump[fmt.Sprintf("%d", tx.Nonce())] = NewRPCPendingTransaction(tx, curHeader, s.b.ChainConfig())
+		if filter.matches(tx, curHeader) {
+			dump[fmt.Sprintf("%d", tx.Nonce())] = NewRPCPendingTransaction(tx, curHeader, s.b.ChainConfig())
+		}
 	}
 	content["pending"] = dump
 
 	// Build the queued transactions
 	dump = make(map[string]*RPCTransaction, len(queue))
 	for _, tx := range queue {
-		dump[fmt.Sprintf("%d", tx.Nonce())] = NewRPCPendingTransaction(tx, curHeader, s.b.ChainConfig())
+		if filter.matches(tx, curHeader) {
+			dump[fmt.Sprintf("%d", tx.Nonce())] = NewRPCPendingTransaction(tx, curHeader, s.b.ChainConfig())
+		}
 	}
 	content["queued"] = dump
 
@@ -223,18 +231,11 @@ func (s *TxPoolAPI) Inspect() map[string]map[string]map[string]string {
 	}
 	pending, queue := s.b.TxPoolContent()
 
-	// Define a formatter to flatten a transaction into a string
-	var format = func(tx *types.Transaction) string {
-		if to := tx.To(); to != nil {
-			return fmt.Sprintf("%s: %v wei + %v gas × %v wei", tx.To().Hex(), tx.Value(), tx.Gas(), tx.GasPrice())
-		}
-		return fmt.Sprintf("contract creation: %v wei + %v gas × %v wei", tx.Value(), tx.Gas(), tx.GasPrice())
-	}
 	// Flatten the pending transactions
 	for account, txs := range pending {
 		dump := make(map[string]string)
 		for _, tx := range txs {
-			dump[fmt.Sprintf("%d", tx.Nonce())] = format(tx)
+			dump[fmt.Sprintf("%d", tx.Nonce())] = inspectTx(tx)
 		}
 		content["pending"][account.Hex()] = dump
 	}
@@ -242,13 +243,108 @@ func (s *TxPoolAPI) Inspect() map[string]map[string]map[string]string {
 	for account, txs := range queue {
 		dump := make(map[string]string)
 		for _, tx := range txs {
-			dump[fmt.Sprintf("%d", tx.Nonce())] = format(tx)
+			dump[fmt.Sprintf("%d", tx.Nonce())] = inspectTx(tx)
 		}
 		content["queued"][account.Hex()] = dump
 	}
 	return content
 }
 
+// inspectTx flattens a transaction into an easily inspectable string.
+func inspectTx(tx *types.Transaction) string {
+	if to := tx.To(); to != nil {
+		return fmt.Sprintf("%s: %v wei + %v gas × %v wei", tx.To().Hex(), tx.Value(), tx.Gas(), tx.GasPrice())
+	}
+	return fmt.Sprintf("contract creation: %v wei + %v gas × %v wei", tx.Value(), tx.Gas(), tx.GasPrice())
+}
+
+// maxTxPoolPage is the maximum number of transactions a single page of the
+// transaction pool may hold.
+const maxTxPoolPage = 1000
+
+// TxPoolFilter narrows down the transactions of the pool returned. Unset fields
+// match any transaction.
+type TxPoolFilter struct {
+	MinTip *hexutil.Big    `json:"minTip"` // Minimum effective tip at the current base fee
+	To     *common.Address `json:"to"`     // Recipient of the transaction
+}
+
+// matches reports whether the transaction passes the filter, a nil filter
+// passing all of them.
+func (f *TxPoolFilter) matches(tx *types.Transaction, head *types.Header) bool {
+	if f == nil {
+		return true
+	}
+	if f.To != nil && (tx.To() == nil || *tx.To() != *f.To) {
+		return false
+	}
+	if f.MinTip != nil && tx.EffectiveGasTipValue(head.BaseFee).Cmp(f.MinTip.ToInt()) < 0 {
+		return false
+	}
+	return true
+}
+
+// TxPoolEntry is a flattened transaction of a transaction pool page.
+type TxPoolEntry struct {
+	Status  string         `json:"status"`
+	From    common.Address `json:"from"`
+	Nonce   hexutil.Uint64 `json:"nonce"`
+	Hash    common.Hash    `json:"hash"`
+	Summary string         `json:"summary"`
+}
+
+// TxPoolPage is a window of the transactions in the pool matching a filter,
+// along with the total number of them.
+type TxPoolPage struct {
+	Total        hexutil.Uint   `json:"total"`
+	Transactions []*TxPoolEntry `json:"transactions"`
+}
+
+// InspectPaged returns a window of the flattened transactions in the pool, so
+// huge pools can be walked without retrieving them at once. Pending transactions
+// are ordered before queued ones, each by sender and nonce, keeping the order
+// stable while the pool is unchanged.
+func (s *TxPoolAPI) InspectPaged(offset, limit hexutil.Uint, filter *TxPoolFilter) (*TxPoolPage, error) {
+	if limit == 0 || limit > maxTxPoolPage {
+		return nil, fmt.Errorf("limit must be within 1-%d", maxTxPoolPage)
+	}
+	var (
+		pending, queue = s.b.TxPoolContent()
+		curHeader      = s.b.CurrentHeader()
+		page           = &TxPoolPage{Transactions: []*TxPoolEntry{}}
+	)
+	for _, set := range []struct {
+		status  string
+		content map[common.Address]types.Transactions
+	}{{"pending", pending}, {"queued", queue}} {
+		accounts := make([]common.Address, 0, len(set.content))
+		for account := range set.content {
+			accounts = append(accounts, account)
+		}
+		sort.Slice(accounts, func(i, j int) bool {
+			return bytes.Compare(accounts[i][:], accounts[j][:]) < 0
+		})
+		for _, account := range accounts {
+			for _, tx := range set.content[account] {
+				if !filter.matches(tx, curHeader) {
+					continue
+				}
+				if page.Total >= offset && len(page.Transactions) < int(limit) {
+					page.Transactions = append(page.Transactions, &TxPoolEntry{
+						Status:  set.status,
+						From:    account,
+						Nonce:   hexutil.Uint64(tx.Nonce()),
+						Hash:    tx.Hash(),
+						Summary: inspectTx(tx),
+					})
+				}
+				page.Total++
+			}
+		}
+	}
+	return page, nil
+}
+
 // EthereumAccountAPI provides an API to access accounts managed by this node.
 // It offers only methods that can retrieve accounts.
 type EthereumAccountAPI struct {
@@ -599,11 +695,16 @@ func (s *PersonalAccountAPI) Unpair(ctx context.Context, url string, pin string)
 // BlockChainAPI provides an API to access Ethereum blockchain data.
 type BlockChainAPI struct {
 	b Backend
+
+	accessLists *lru.Cache[accessListKey, types.AccessList] // Access lists seeding the ones of similar calls
 }
 
 // NewBlockChainAPI creates a new Ethereum blockchain API.
 func NewBlockChainAPI(b Backend) *BlockChainAPI {
-	return &BlockChainAPI{b}
+	return &BlockChainAPI{
+		b:           b,
+		accessLists: lru.NewCache[accessListKey, types.AccessList](accessListCacheSize),
+	}
 }
 
 // ChainId is the EIP-155 replay-protection chain id for the current Ethereum chain config.
@@ -772,6 +873,14 @@ func (s *BlockChainAPI) GetBlockByNumber(ctx context.Context, number rpc.BlockNu
 	return nil, err
 }
 
+// GetFinalizedBlock returns the latest finalized block, as set by the consensus
+// layer or derived from the head by the soft finality depth. When fullTx is true
+// all transactions in the block are returned in full detail, otherwise only the
+// transaction hash is returned.
+func (s *BlockChainAPI) GetFinalizedBlock(ctx context.Context, fullTx bool) (map[string]interface{}, error) {
+	return s.GetBlockByNumber(ctx, rpc.FinalizedBlockNumber, fullTx)
+}
+
 // GetBlockByHash returns the requested block. When fullTx is true all transactions in the block are returned in full
 // detail, otherwise only the transaction hash is returned.
 func (s *BlockChainAPI) GetBlockByHash(ctx context.Context, hash common.Hash, fullTx bool) (map[string]interface{}, error) {
@@ -951,6 +1060,36 @@ func (diff *BlockOverrides) Apply(blockCtx *vm.BlockContext) {
 	}
 }
 
+// MakeHeader returns a copy of the given header with the fields overridden.
+func (diff *BlockOverrides) MakeHeader(header *types.Header) *types.Header {
+	if diff == nil {
+		return header
+	}
+	h := types.CopyHeader(header)
+	if diff.Number != nil {
+		h.Number = diff.Number.ToInt()
+	}
+	if diff.Difficulty != nil {
+		h.Difficulty = diff.Difficulty.ToInt()
+	}
+	if diff.Time != nil {
+		h.Time = uint64(*diff.Time)
+	}
+	if diff.GasLimit != nil {
+		h.GasLimit = uint64(*diff.GasLimit)
+	}
+	if diff.Coinbase != nil {
+		h.Coinbase = *diff.Coinbase
+	}
+	if diff.Random != nil {
+		h.MixDigest = *diff.Random
+	}
+	if diff.BaseFee != nil {
+		h.BaseFee = diff.BaseFee.ToInt()
+	}
+	return h
+}
+
 func DoCall(ctx context.Context, b Backend, args TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *StateOverride, timeout time.Duration, globalGasCap uint64) (*core.ExecutionResult, error) {
 	defer func(start time.Time) { log.Debug("Executing EVM call finished", "runtime", time.Since(start)) }(time.Now())
 
@@ -961,6 +1100,12 @@ func DoCall(ctx context.Context, b Backend, args TransactionArgs, blockNrOrHash
 	if err := overrides.Apply(state); err != nil {
 		return nil, err
 	}
+	return doCall(ctx, b, args, state, header, timeout, globalGasCap)
+}
+
+// doCall executes the given message call on top of the given state, leaving the
+// state modified by the call.
+func doCall(ctx context.Context, b Backend, args TransactionArgs, state *state.StateDB, header *types.Header, timeout time.Duration, globalGasCap uint64) (*core.ExecutionResult, error) {
 	// Setup context so it may be cancelled the call has completed
 	// or, in case of unmetered gas, setup a context with a timeout.
 	var cancel context.CancelFunc
@@ -1054,6 +1199,94 @@ func (s *BlockChainAPI) Call(ctx context.Context, args TransactionArgs, blockNrO
 	return result.Return(), result.Err
 }
 
+// maxCallManyCalls is the maximum number of calls executed by a single
+// eth_callMany request.
+const maxCallManyCalls = 256
+
+// BatchCall is a message call of a batch, along with the state and block
+// overrides applied before executing it.
+type BatchCall struct {
+	TransactionArgs
+	StateOverrides *StateOverride  `json:"stateOverrides"`
+	BlockOverrides *BlockOverrides `json:"blockOverrides"`
+}
+
+// BatchCallResult is the outcome of a message call of a batch. Failed calls
+// report their error, with the revert data as return value if reverted.
+type BatchCallResult struct {
+	ReturnValue hexutil.Bytes  `json:"returnValue"`
+	GasUsed     hexutil.Uint64 `json:"gasUsed"`
+	Error       string         `json:"error,omitempty"`
+}
+
+// CallMany executes the given message calls in order on the state of the given
+// block, each one on top of the state left by the previous calls. The state and
+// block overrides are applied cumulatively: the ones given along with the batch
+// first, then the ones of each call before executing it, all of them staying in
+// effect for the rest of the batch.
+//
+// A call failing doesn't abort the batch, the error being reported in its result
+// instead. The EVM timeout is shared by all calls of the batch.
+func (s *BlockChainAPI) CallMany(ctx context.Context, calls []BatchCall, blockNrOrHash rpc.BlockNumberOrHash, overrides *StateOverride, blockOverrides *BlockOverrides) ([]BatchCallResult, error) {
+	if len(calls) == 0 {
+		return nil, errors.New("empty call batch")
+	}
+	if len(calls) > maxCallManyCalls {
+		return nil, fmt.Errorf("too many calls: have %d, max %d", len(calls), maxCallManyCalls)
+	}
+	state, header, err := s.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	if err := overrides.Apply(state); err != nil {
+		return nil, err
+	}
+	header = blockOverrides.MakeHeader(header)
+
+	var (
+		timeout  = s.b.RPCEVMTimeout()
+		deadline = time.Now().Add(timeout)
+		results  = make([]BatchCallResult, len(calls))
+	)
+	for i, call := range calls {
+		if err := call.StateOverrides.Apply(state); err != nil {
+			return nil, fmt.Errorf("call %d: %w", i, err)
+		}
+		header = call.BlockOverrides.MakeHeader(header)
+
+		// Hand the remaining time of the batch to the call, if limited
+		remaining := time.Duration(0)
+		if timeout > 0 {
+			if remaining = time.Until(deadline); remaining <= 0 {
+				return nil, fmt.Errorf("execution aborted (timeout = %v)", timeout)
+			}
+		}
+		state.SetTxContext(common.Hash{}, i)
+		result, err := doCall(ctx, s.b, call.TransactionArgs, state, header, remaining, s.b.RPCGasCap())
+		if err != nil {
+			// Abort the batch once out of time, the call being invalid otherwise
+			if ctx.Err() != nil || (timeout > 0 && time.Now().After(deadline)) {
+				return nil, err
+			}
+			results[i].Error = err.Error()
+			continue
+		}
+		state.Finalise(true)
+
+		results[i].GasUsed = hexutil.Uint64(result.UsedGas)
+		switch {
+		case len(result.Revert()) > 0:
+			results[i].ReturnValue = result.Revert()
+			results[i].Error = newRevertError(result).Error()
+		case result.Err != nil:
+			results[i].Error = result.Err.Error()
+		default:
+			results[i].ReturnValue = result.Return()
+		}
+	}
+	return results, nil
+}
+
 func DoEstimateGas(ctx context.Context, b Backend, args TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, gasCap uint64) (hexutil.Uint64, error) {
 	// Binary search the gas requirement, as it may be higher than the amount used
 	var (
@@ -1401,6 +1634,24 @@ type accessListResult struct {
 	GasUsed    hexutil.Uint64    `json:"gasUsed"`
 }
 
+const (
+	// accessListCacheSize is the number of generated access lists kept around to
+	// seed the generation of the ones of similar calls.
+	accessListCacheSize = 1024
+
+	// accessListPrefix is the number of calldata bytes, the method selector,
+	// telling apart the calls to a contract sharing a cached access list.
+	accessListPrefix = 4
+)
+
+// accessListKey identifies the calls sharing a cached access list: the ones to
+// the same contract and method, on the state of the same block.
+type accessListKey struct {
+	block  common.Hash
+	to     common.Address
+	prefix string
+}
+
 // CreateAccessList creates a EIP-2930 type AccessList for the given transaction.
 // Reexec and BlockNrOrHash can be specified to create the accessList on top of a certain state.
 func (s *BlockChainAPI) CreateAccessList(ctx context.Context, args TransactionArgs, blockNrOrHash *rpc.BlockNumberOrHash) (*accessListResult, error) {
@@ -1408,7 +1659,7 @@ func (s *BlockChainAPI) CreateAccessList(ctx context.Context, args TransactionAr
 	if blockNrOrHash != nil {
 		bNrOrHash = *blockNrOrHash
 	}
-	acl, gasUsed, vmerr, err := AccessList(ctx, s.b, bNrOrHash, args)
+	acl, gasUsed, vmerr, err := accessList(ctx, s.b, bNrOrHash, args, s.accessLists)
 	if err != nil {
 		return nil, err
 	}
@@ -1419,10 +1670,42 @@ func (s *BlockChainAPI) CreateAccessList(ctx context.Context, args TransactionAr
 	return result, nil
 }
 
+// CreateAccessListMany creates the EIP-2930 access lists of the given transactions,
+// each one independently on top of the state of the given block. A transaction
+// failing doesn't abort the batch, the error being reported in its result instead.
+func (s *BlockChainAPI) CreateAccessListMany(ctx context.Context, calls []TransactionArgs, blockNrOrHash *rpc.BlockNumberOrHash) ([]*accessListResult, error) {
+	if len(calls) == 0 {
+		return nil, errors.New("empty call batch")
+	}
+	if len(calls) > maxCallManyCalls {
+		return nil, fmt.Errorf("too many calls: have %d, max %d", len(calls), maxCallManyCalls)
+	}
+	results := make([]*accessListResult, len(calls))
+	for i, args := range calls {
+		result, err := s.CreateAccessList(ctx, args, blockNrOrHash)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			result = &accessListResult{Error: err.Error()}
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
 // AccessList creates an access list for the given transaction.
 // If the accesslist creation fails an error is returned.
 // If the transaction itself fails, an vmErr is returned.
 func AccessList(ctx context.Context, b Backend, blockNrOrHash rpc.BlockNumberOrHash, args TransactionArgs) (acl types.AccessList, gasUsed uint64, vmErr error, err error) {
+	return accessList(ctx, b, blockNrOrHash, args, nil)
+}
+
+// accessList creates an access list for the given transaction, like AccessList.
+// If a cache is given, the access list generated for a similar call is applied
+// to the first execution, the generation converging right away if the call
+// touches the same accounts and slots. The result is the same either way.
+func accessList(ctx context.Context, b Backend, blockNrOrHash rpc.BlockNumberOrHash, args TransactionArgs, cache *lru.Cache[accessListKey, types.AccessList]) (acl types.AccessList, gasUsed uint64, vmErr error, err error) {
 	// Retrieve the execution context
 	db, header, err := b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
 	if db == nil || err != nil {
@@ -1453,6 +1736,24 @@ func AccessList(ctx context.Context, b Backend, blockNrOrHash rpc.BlockNumberOrH
 	if args.AccessList != nil {
 		prevTracer = logger.NewAccessListTracer(*args.AccessList, args.from(), to, precompiles)
 	}
+	// Look up the access list of a similar call to seed the generation with. Calls
+	// bringing their own access list to expand are left alone.
+	var (
+		key  accessListKey
+		seed *logger.AccessListTracer
+	)
+	if cache != nil && args.AccessList == nil {
+		data := args.data()
+		if len(data) > accessListPrefix {
+			data = data[:accessListPrefix]
+		}
+		key = accessListKey{block: header.Hash(), to: to, prefix: string(data)}
+		if cached, ok := cache.Get(key); ok {
+			seed = logger.NewAccessListTracer(cached, args.from(), to, precompiles)
+		}
+	} else {
+		cache = nil
+	}
 	for {
 		// Retrieve the current access list to expand
 		accessList := prevTracer.AccessList()
@@ -1460,8 +1761,12 @@ func AccessList(ctx context.Context, b Backend, blockNrOrHash rpc.BlockNumberOrH
 
 		// Copy the original db so we don't modify it
 		statedb := db.Copy()
-		// Set the accesslist to the last al
-		args.AccessList = &accessList
+		// Set the accesslist to the last al, or to the seed on the first run
+		applied := accessList
+		if seed != nil {
+			applied = seed.AccessList()
+		}
+		args.AccessList = &applied
 		msg, err := args.ToMessage(b.RPCGasCap(), header.BaseFee)
 		if err != nil {
 			return nil, 0, nil, err
@@ -1478,7 +1783,17 @@ func AccessList(ctx context.Context, b Backend, blockNrOrHash rpc.BlockNumberOrH
 		if err != nil {
 			return nil, 0, nil, fmt.Errorf("failed to apply transaction: %v err: %v", args.toTransaction().Hash(), err)
 		}
-		if tracer.Equal(prevTracer) {
+		// The seed is final if the transaction touched exactly the seeded entries,
+		// otherwise carry on expanding what it touched
+		if seed != nil {
+			if tracer.Equal(seed) {
+				return applied, res.UsedGas, res.Err, nil
+			}
+			seed = nil
+		} else if tracer.Equal(prevTracer) {
+			if cache != nil {
+				cache.Add(key, accessList)
+			}
 			return accessList, res.UsedGas, res.Err, nil
 		}
 		prevTracer = tracer
@@ -1630,12 +1945,54 @@ func (s *TransactionAPI) GetTransactionReceipt(ctx context.Context, hash common.
 	// Derive the sender.
 	bigblock := new(big.Int).SetUint64(blockNumber)
 	signer := types.MakeSigner(s.b.ChainConfig(), bigblock)
+	return marshalReceipt(receipt, blockHash, blockNumber, signer, tx, index), nil
+}
+
+// GetBlockReceipts returns the receipts of all transactions in the block with
+// the given number or hash.
+func (s *TransactionAPI) GetBlockReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]map[string]interface{}, error) {
+	var (
+		block    *types.Block
+		receipts types.Receipts
+		err      error
+	)
+	if number, ok := blockNrOrHash.Number(); ok && number == rpc.PendingBlockNumber {
+		// Receipts of the pending block are only known by the miner
+		block, receipts = s.b.PendingBlockAndReceipts()
+	} else {
+		block, err = s.b.BlockByNumberOrHash(ctx, blockNrOrHash)
+		if block != nil && err == nil {
+			if receipts, err = s.b.GetReceipts(ctx, block.Hash()); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if block == nil || err != nil {
+		// When the block doesn't exist, the RPC method should return JSON null
+		// as per specification.
+		return nil, nil
+	}
+	txs := block.Transactions()
+	if len(txs) != len(receipts) {
+		return nil, fmt.Errorf("receipts length mismatch: %d vs %d", len(txs), len(receipts))
+	}
+	signer := types.MakeSigner(s.b.ChainConfig(), block.Number())
+
+	result := make([]map[string]interface{}, len(receipts))
+	for i, receipt := range receipts {
+		result[i] = marshalReceipt(receipt, block.Hash(), block.NumberU64(), signer, txs[i], uint64(i))
+	}
+	return result, nil
+}
+
+// marshalReceipt marshals a transaction receipt into a JSON object.
+func marshalReceipt(receipt *types.Receipt, blockHash common.Hash, blockNumber uint64, signer types.Signer, tx *types.Transaction, index uint64) map[string]interface{} {
 	from, _ := types.Sender(signer, tx)
 
 	fields := map[string]interface{}{
 		"blockHash":         blockHash,
 		"blockNumber":       hexutil.Uint64(blockNumber),
-		"transactionHash":   hash,
+		"transactionHash":   tx.Hash(),
 		"transactionIndex":  hexutil.Uint64(index),
 		"from":              from,
 		"to":                tx.To(),
@@ -1662,7 +2019,7 @@ func (s *TransactionAPI) GetTransactionReceipt(ctx context.Context, hash common.
 	if receipt.ContractAddress != (common.Address{}) {
 		fields["contractAddress"] = receipt.ContractAddress
 	}
-	return fields, nil
+	return fields
 }
 
 // sign is a helper function that signs a transaction with the private key of the given address.
@@ -13,14 +13,23 @@
 package ethapi
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"math/big"
+	"reflect"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 func TestTransaction_RoundTripRpcJSON(t *testing.T) {
@@ -153,3 +162,212 @@ func allTransactionTypes(addr common.Address, config *params.ChainConfig) []type
 		},
 	}
 }
+
+// txPoolBackend serves a fixed transaction pool content.
+type txPoolBackend struct {
+	*backendMock
+	pending, queued map[common.Address]types.Transactions
+}
+
+func (b *txPoolBackend) TxPoolContent() (map[common.Address]types.Transactions, map[common.Address]types.Transactions) {
+	return b.pending, b.queued
+}
+
+func TestTxPoolInspectPaged(t *testing.T) {
+	var (
+		alice = common.Address{0x01}
+		bob   = common.Address{0x02}
+		sink  = common.Address{0xff}
+	)
+	tx := func(nonce uint64, tip int64, to *common.Address) *types.Transaction {
+		return types.NewTx(&types.DynamicFeeTx{Nonce: nonce, GasTipCap: big.NewInt(tip), GasFeeCap: big.NewInt(100), Gas: 21000, To: to})
+	}
+	api := NewTxPoolAPI(&txPoolBackend{
+		backendMock: newBackendMock(),
+		pending: map[common.Address]types.Transactions{
+			bob:   {tx(0, 1, &sink), tx(1, 5, &sink)},
+			alice: {tx(0, 5, nil), tx(1, 95, &alice)},
+		},
+		queued: map[common.Address]types.Transactions{
+			alice: {tx(3, 5, &sink)},
+		},
+	})
+	tests := []struct {
+		offset, limit hexutil.Uint
+		filter        *TxPoolFilter
+		total         hexutil.Uint
+		want          []string // status/sender/nonce of the page
+	}{
+		{0, 10, nil, 5, []string{"pending/1/0", "pending/1/1", "pending/2/0", "pending/2/1", "queued/1/3"}},
+		{1, 2, nil, 5, []string{"pending/1/1", "pending/2/0"}},
+		{4, 2, nil, 5, []string{"queued/1/3"}},
+		{9, 2, nil, 5, []string{}},
+		{0, 10, &TxPoolFilter{To: &sink}, 3, []string{"pending/2/0", "pending/2/1", "queued/1/3"}},
+		// Alice's 95 tip is capped at 90 by the fee cap and the base fee of 10
+		{0, 10, &TxPoolFilter{MinTip: (*hexutil.Big)(big.NewInt(5))}, 4, []string{"pending/1/0", "pending/1/1", "pending/2/1", "queued/1/3"}},
+		{0, 10, &TxPoolFilter{MinTip: (*hexutil.Big)(big.NewInt(91))}, 0, []string{}},
+	}
+	for i, tt := range tests {
+		page, err := api.InspectPaged(tt.offset, tt.limit, tt.filter)
+		if err != nil {
+			t.Fatalf("test %d: inspection failed: %v", i, err)
+		}
+		if page.Total != tt.total {
+			t.Errorf("test %d: total mismatch: have %d, want %d", i, page.Total, tt.total)
+		}
+		have := make([]string, 0, len(page.Transactions))
+		for _, entry := range page.Transactions {
+			have = append(have, fmt.Sprintf("%s/%x/%d", entry.Status, entry.From[0], entry.Nonce))
+		}
+		if fmt.Sprint(have) != fmt.Sprint(tt.want) {
+			t.Errorf("test %d: page mismatch: have %v, want %v", i, have, tt.want)
+		}
+	}
+	if _, err := api.InspectPaged(0, 0, nil); err == nil {
+		t.Errorf("empty page accepted")
+	}
+	if _, err := api.InspectPaged(0, maxTxPoolPage+1, nil); err == nil {
+		t.Errorf("oversized page accepted")
+	}
+}
+
+// callBackend executes message calls on top of a fixed state.
+type callBackend struct {
+	*backendMock
+	state *state.StateDB
+}
+
+func (b *callBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error) {
+	return b.state, b.current, nil
+}
+
+func (b *callBackend) GetEVM(ctx context.Context, msg *core.Message, state *state.StateDB, header *types.Header, vmConfig *vm.Config) (*vm.EVM, func() error, error) {
+	blockCtx := core.NewEVMBlockContext(header, nil, &header.Coinbase)
+	return vm.NewEVM(blockCtx, core.NewEVMTxContext(msg), state, b.config, *vmConfig), state.Error, nil
+}
+
+// Tests that the calls of a batch are executed on top of each other, with the
+// state and block overrides applied cumulatively.
+func TestCallMany(t *testing.T) {
+	var (
+		counter  = common.Address{0x01} // Increments and returns slot 0
+		numberer = common.Address{0x02} // Returns the block number
+		reverter = common.Address{0x03} // Reverts
+	)
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	api := NewBlockChainAPI(&callBackend{backendMock: newBackendMock(), state: statedb})
+
+	code := func(hex string) OverrideAccount {
+		code := hexutil.Bytes(common.FromHex(hex))
+		return OverrideAccount{Code: &code}
+	}
+	slot := func(value byte) *StateOverride {
+		return &StateOverride{counter: OverrideAccount{StateDiff: &map[common.Hash]common.Hash{{}: {31: value}}}}
+	}
+	call := func(to common.Address) BatchCall {
+		return BatchCall{TransactionArgs: TransactionArgs{To: &to}}
+	}
+	calls := []BatchCall{call(counter), call(counter), call(numberer), call(numberer), call(reverter), call(counter), call(counter)}
+	calls[2].BlockOverrides = &BlockOverrides{Number: (*hexutil.Big)(big.NewInt(2000))}
+	calls[5].StateOverrides = slot(10)
+
+	results, err := api.CallMany(context.Background(), calls, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), &StateOverride{
+		counter:  code("0x6000546001018060005560005260206000f3"),
+		numberer: code("0x4360005260206000f3"),
+		reverter: code("0x60006000fd"),
+	}, nil)
+	if err != nil {
+		t.Fatalf("batch failed: %v", err)
+	}
+	want := []uint64{1, 2, 2000, 2000, 0, 11, 12}
+	for i, result := range results {
+		if i == 4 {
+			if result.Error != "execution reverted" {
+				t.Errorf("call %d: error mismatch: have %q", i, result.Error)
+			}
+			continue
+		}
+		if result.Error != "" {
+			t.Errorf("call %d: unexpected error: %v", i, result.Error)
+			continue
+		}
+		if have := new(big.Int).SetBytes(result.ReturnValue).Uint64(); have != want[i] {
+			t.Errorf("call %d: result mismatch: have %d, want %d", i, have, want[i])
+		}
+	}
+	if _, err := api.CallMany(context.Background(), nil, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), nil, nil); err == nil {
+		t.Errorf("empty batch accepted")
+	}
+}
+
+// countingBackend counts the message calls executed through it.
+type countingBackend struct {
+	*callBackend
+	calls int
+}
+
+func (b *countingBackend) GetEVM(ctx context.Context, msg *core.Message, state *state.StateDB, header *types.Header, vmConfig *vm.Config) (*vm.EVM, func() error, error) {
+	b.calls++
+	return b.callBackend.GetEVM(ctx, msg, state, header, vmConfig)
+}
+
+// Tests that access lists generated for calls to the same method seed the ones
+// of later calls, without affecting the results.
+func TestCreateAccessListCache(t *testing.T) {
+	var (
+		sender   = common.Address{0xee}
+		contract = common.Address{0x01} // Loads the slot in the first argument
+		selector = common.FromHex("0xaabbccdd")
+	)
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.SetBalance(sender, big.NewInt(params.Ether))
+	statedb.SetCode(contract, common.FromHex("0x6004355400"))
+
+	backend := &countingBackend{callBackend: &callBackend{backendMock: newBackendMock(), state: statedb}}
+	api := NewBlockChainAPI(backend)
+
+	load := func(slot byte) TransactionArgs {
+		var (
+			gas  = hexutil.Uint64(100000)
+			data = hexutil.Bytes(append(common.CopyBytes(selector), common.Hash{31: slot}.Bytes()...))
+		)
+		return TransactionArgs{From: &sender, To: &contract, Gas: &gas, Input: &data}
+	}
+	tests := []struct {
+		args  TransactionArgs
+		slot  byte
+		calls int
+	}{
+		{load(1), 1, 2}, // Generated from scratch, expanded and confirmed
+		{load(1), 1, 1}, // Seeded with the right list, confirmed right away
+		{load(2), 2, 2}, // Seeded with the wrong list, regenerated
+		{load(2), 2, 1},
+	}
+	for i, tt := range tests {
+		backend.calls = 0
+		result, err := api.CreateAccessList(context.Background(), tt.args, nil)
+		if err != nil {
+			t.Fatalf("test %d: access list creation failed: %v", i, err)
+		}
+		want := types.AccessList{{Address: contract, StorageKeys: []common.Hash{{31: tt.slot}}}}
+		if !reflect.DeepEqual(*result.Accesslist, want) {
+			t.Errorf("test %d: access list mismatch: have %v, want %v", i, *result.Accesslist, want)
+		}
+		if backend.calls != tt.calls {
+			t.Errorf("test %d: executions mismatch: have %d, want %d", i, backend.calls, tt.calls)
+		}
+	}
+	results, err := api.CreateAccessListMany(context.Background(), []TransactionArgs{load(1), load(2), load(3)}, nil)
+	if err != nil {
+		t.Fatalf("bulk access list creation failed: %v", err)
+	}
+	for i, result := range results {
+		want := types.AccessList{{Address: contract, StorageKeys: []common.Hash{{31: byte(i + 1)}}}}
+		if result.Error != "" || !reflect.DeepEqual(*result.Accesslist, want) {
+			t.Errorf("bulk call %d: result mismatch: have %v (%s), want %v", i, *result.Accesslist, result.Error, want)
+		}
+	}
+	if _, err := api.CreateAccessListMany(context.Background(), nil, nil); err == nil {
+		t.Errorf("empty batch accepted")
+	}
+}
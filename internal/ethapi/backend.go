@@ -119,6 +119,9 @@ func GetAPIs(apiBackend Backend) []rpc.API {
 		}, {
 			Namespace: "personal",
 			Service:   NewPersonalAccountAPI(apiBackend, nonceLock),
+		}, {
+			Namespace: "pool",
+			Service:   NewPoolAPI(apiBackend, nonceLock),
 		},
 	}
 }
@@ -0,0 +1,305 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package ethapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	// maxPayouts is the maximum number of payouts a single batch may hold.
+	maxPayouts = 10000
+
+	// defaultDisperseSize is the number of recipients paid by a single disperse
+	// contract call, unless configured otherwise.
+	defaultDisperseSize = 200
+
+	// maxPayoutBatches is the number of recent batches whose status is kept.
+	maxPayoutBatches = 1024
+)
+
+// disperseABI is the interface of the widely deployed disperse contract paying
+// out ether to many recipients at once.
+const disperseABI = `[{"name":"disperseEther","type":"function","stateMutability":"payable","inputs":[{"name":"recipients","type":"address[]"},{"name":"values","type":"uint256[]"}],"outputs":[]}]`
+
+var disperse, _ = abi.JSON(strings.NewReader(disperseABI))
+
+// Payout is an amount to pay to an address.
+type Payout struct {
+	To     common.Address `json:"to"`
+	Amount *hexutil.Big   `json:"amount"`
+}
+
+// PayoutBatchArgs are the payouts of a batch and how to send them. Without a
+// disperse contract every recipient is paid by a plain transfer, otherwise by
+// calls of the contract paying up to DisperseSize recipients each. Fee fields
+// left unset are filled as for eth_sendTransaction.
+type PayoutBatchArgs struct {
+	From                 common.Address  `json:"from"`
+	Payouts              []Payout        `json:"payouts"`
+	Disperse             *common.Address `json:"disperse"`
+	DisperseSize         *hexutil.Uint   `json:"disperseSize"`
+	GasPrice             *hexutil.Big    `json:"gasPrice"`
+	MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas"`
+}
+
+// PayoutTx is the status of a transaction of a payout batch.
+type PayoutTx struct {
+	Hash        common.Hash     `json:"hash"`
+	Recipients  hexutil.Uint    `json:"recipients"`
+	Amount      *hexutil.Big    `json:"amount"`
+	Status      string          `json:"status"` // One of pending, included, failed or dropped
+	BlockNumber *hexutil.Uint64 `json:"blockNumber"`
+}
+
+// PayoutBatch is the status handle of a payout batch.
+type PayoutBatch struct {
+	ID           hexutil.Uint64 `json:"id"`
+	Transactions []*PayoutTx    `json:"transactions"`
+	Complete     bool           `json:"complete"` // Whether all transactions were included successfully
+}
+
+// PoolAPI offers the mining pool operator methods paying out the miners.
+type PoolAPI struct {
+	b         Backend
+	nonceLock *AddrLocker
+
+	lock    sync.Mutex
+	nextID  uint64
+	batches map[uint64][]*PayoutTx
+}
+
+// NewPoolAPI creates a new pool payout API.
+func NewPoolAPI(b Backend, nonceLock *AddrLocker) *PoolAPI {
+	return &PoolAPI{
+		b:         b,
+		nonceLock: nonceLock,
+		batches:   make(map[uint64][]*PayoutTx),
+	}
+}
+
+// packPayouts assembles the unsigned transactions paying out the batch. Payouts
+// to the same address are merged and empty ones skipped. Recipients are paid in
+// address order, keeping the packing deterministic.
+func packPayouts(args PayoutBatchArgs) ([]TransactionArgs, []int, error) {
+	if len(args.Payouts) > maxPayouts {
+		return nil, nil, fmt.Errorf("batch exceeds %d payouts", maxPayouts)
+	}
+	amounts := make(map[common.Address]*big.Int)
+	for _, payout := range args.Payouts {
+		if payout.Amount == nil || payout.Amount.ToInt().Sign() < 0 {
+			return nil, nil, fmt.Errorf("invalid payout amount to %s", payout.To)
+		}
+		if amount := amounts[payout.To]; amount != nil {
+			amount.Add(amount, payout.Amount.ToInt())
+		} else {
+			amounts[payout.To] = new(big.Int).Set(payout.Amount.ToInt())
+		}
+	}
+	recipients := make([]common.Address, 0, len(amounts))
+	for addr, amount := range amounts {
+		if amount.Sign() > 0 {
+			recipients = append(recipients, addr)
+		}
+	}
+	if len(recipients) == 0 {
+		return nil, nil, errors.New("no payouts")
+	}
+	sort.Slice(recipients, func(i, j int) bool {
+		return bytes.Compare(recipients[i][:], recipients[j][:]) < 0
+	})
+	var (
+		txs    []TransactionArgs
+		counts []int
+	)
+	tx := func(to common.Address, value *big.Int, data []byte) TransactionArgs {
+		tx := TransactionArgs{
+			From:                 &args.From,
+			To:                   &to,
+			Value:                (*hexutil.Big)(value),
+			GasPrice:             args.GasPrice,
+			MaxFeePerGas:         args.MaxFeePerGas,
+			MaxPriorityFeePerGas: args.MaxPriorityFeePerGas,
+		}
+		if data != nil {
+			tx.Input = (*hexutil.Bytes)(&data)
+		}
+		return tx
+	}
+	if args.Disperse == nil {
+		for _, addr := range recipients {
+			txs = append(txs, tx(addr, amounts[addr], nil))
+			counts = append(counts, 1)
+		}
+		return txs, counts, nil
+	}
+	size := defaultDisperseSize
+	if args.DisperseSize != nil && *args.DisperseSize > 0 {
+		size = int(*args.DisperseSize)
+	}
+	for start := 0; start < len(recipients); start += size {
+		end := start + size
+		if end > len(recipients) {
+			end = len(recipients)
+		}
+		var (
+			values = make([]*big.Int, 0, end-start)
+			total  = new(big.Int)
+		)
+		for _, addr := range recipients[start:end] {
+			values = append(values, amounts[addr])
+			total.Add(total, amounts[addr])
+		}
+		data, err := disperse.Pack("disperseEther", recipients[start:end], values)
+		if err != nil {
+			return nil, nil, err
+		}
+		txs = append(txs, tx(*args.Disperse, total, data))
+		counts = append(counts, end-start)
+	}
+	return txs, counts, nil
+}
+
+// BuildPayoutBatch returns the unsigned transactions paying out the batch, with
+// all defaults filled in but the nonce, for signing elsewhere, e.g. by the
+// owners of a multisig wallet.
+func (api *PoolAPI) BuildPayoutBatch(ctx context.Context, args PayoutBatchArgs) ([]TransactionArgs, error) {
+	txs, _, err := packPayouts(args)
+	if err != nil {
+		return nil, err
+	}
+	for i := range txs {
+		if err := txs[i].setDefaults(ctx, api.b); err != nil {
+			return nil, fmt.Errorf("payout transaction %d: %v", i, err)
+		}
+		txs[i].Nonce = nil
+	}
+	return txs, nil
+}
+
+// SendPayoutBatch signs the transactions paying out the batch with the sender's
+// wallet in the account manager and submits them, returning the status handle
+// of the batch to follow their inclusion with. If submission fails midway, the
+// transactions sent so far are still tracked by the batch the error names.
+func (api *PoolAPI) SendPayoutBatch(ctx context.Context, args PayoutBatchArgs) (*PayoutBatch, error) {
+	txs, counts, err := packPayouts(args)
+	if err != nil {
+		return nil, err
+	}
+	account := accounts.Account{Address: args.From}
+	wallet, err := api.b.AccountManager().Find(account)
+	if err != nil {
+		return nil, err
+	}
+	// Hold the nonce lock for the whole batch, the nonces need to be successive
+	api.nonceLock.LockAddr(args.From)
+	defer api.nonceLock.UnlockAddr(args.From)
+
+	var sent []*PayoutTx
+	for i := range txs {
+		if err = txs[i].setDefaults(ctx, api.b); err != nil {
+			break
+		}
+		var signed *types.Transaction
+		if signed, err = wallet.SignTx(account, txs[i].toTransaction(), api.b.ChainConfig().ChainID); err != nil {
+			break
+		}
+		if _, err = SubmitTransaction(ctx, api.b, signed); err != nil {
+			break
+		}
+		sent = append(sent, &PayoutTx{
+			Hash:       signed.Hash(),
+			Recipients: hexutil.Uint(counts[i]),
+			Amount:     (*hexutil.Big)(signed.Value()),
+			Status:     "pending",
+		})
+	}
+	if len(sent) == 0 {
+		return nil, err
+	}
+	id := api.track(sent)
+	if err != nil {
+		return nil, fmt.Errorf("payout batch %d interrupted after %d of %d transactions: %v", id, len(sent), len(txs), err)
+	}
+	return api.GetPayoutBatch(ctx, hexutil.Uint64(id))
+}
+
+// track stores a new batch, forgetting the oldest one if too many are kept.
+func (api *PoolAPI) track(txs []*PayoutTx) uint64 {
+	api.lock.Lock()
+	defer api.lock.Unlock()
+
+	id := api.nextID
+	api.nextID++
+
+	api.batches[id] = txs
+	if id >= maxPayoutBatches {
+		delete(api.batches, id-maxPayoutBatches)
+	}
+	return id
+}
+
+// GetPayoutBatch returns the current status of a payout batch.
+func (api *PoolAPI) GetPayoutBatch(ctx context.Context, id hexutil.Uint64) (*PayoutBatch, error) {
+	api.lock.Lock()
+	txs, ok := api.batches[uint64(id)]
+	api.lock.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown payout batch %d", id)
+	}
+	batch := &PayoutBatch{ID: id, Complete: true}
+	for _, tx := range txs {
+		status := *tx
+		status.BlockNumber = nil
+
+		found, blockHash, number, index, err := api.b.GetTransaction(ctx, tx.Hash)
+		switch {
+		case err != nil:
+			return nil, err
+		case found != nil:
+			receipts, err := api.b.GetReceipts(ctx, blockHash)
+			if err != nil {
+				return nil, err
+			}
+			status.Status = "included"
+			if index < uint64(len(receipts)) && receipts[index].Status == types.ReceiptStatusFailed {
+				status.Status = "failed"
+			}
+			status.BlockNumber = (*hexutil.Uint64)(&number)
+		case api.b.GetPoolTransaction(tx.Hash) != nil:
+			status.Status = "pending"
+		default:
+			status.Status = "dropped"
+		}
+		if status.Status != "included" {
+			batch.Complete = false
+		}
+		batch.Transactions = append(batch.Transactions, &status)
+	}
+	return batch, nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package ethapi
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func TestPackPayouts(t *testing.T) {
+	var (
+		from     = common.Address{0xff}
+		contract = common.Address{0xd1}
+		size     = hexutil.Uint(2)
+		payouts  = []Payout{
+			{To: common.Address{0x03}, Amount: (*hexutil.Big)(big.NewInt(30))},
+			{To: common.Address{0x01}, Amount: (*hexutil.Big)(big.NewInt(10))},
+			{To: common.Address{0x02}, Amount: (*hexutil.Big)(big.NewInt(0))},
+			{To: common.Address{0x03}, Amount: (*hexutil.Big)(big.NewInt(5))},
+			{To: common.Address{0x04}, Amount: (*hexutil.Big)(big.NewInt(40))},
+		}
+	)
+	// Plain transfers merge the payouts of a recipient and skip empty ones
+	txs, counts, err := packPayouts(PayoutBatchArgs{From: from, Payouts: payouts})
+	if err != nil {
+		t.Fatalf("failed to pack transfers: %v", err)
+	}
+	want := []struct {
+		to    common.Address
+		value int64
+	}{{common.Address{0x01}, 10}, {common.Address{0x03}, 35}, {common.Address{0x04}, 40}}
+	if len(txs) != len(want) {
+		t.Fatalf("transfer count mismatch: have %d, want %d", len(txs), len(want))
+	}
+	for i, tx := range txs {
+		if *tx.To != want[i].to || tx.Value.ToInt().Int64() != want[i].value || counts[i] != 1 || tx.data() != nil {
+			t.Errorf("transfer %d mismatch: have %x/%v, want %x/%d", i, *tx.To, tx.Value, want[i].to, want[i].value)
+		}
+	}
+	// Disperse calls pay out the recipients in chunks
+	txs, counts, err = packPayouts(PayoutBatchArgs{From: from, Payouts: payouts, Disperse: &contract, DisperseSize: &size})
+	if err != nil {
+		t.Fatalf("failed to pack disperse calls: %v", err)
+	}
+	if len(txs) != 2 || counts[0] != 2 || counts[1] != 1 {
+		t.Fatalf("disperse call packing mismatch: have %d calls of %v recipients", len(txs), counts)
+	}
+	if *txs[0].To != contract || txs[0].Value.ToInt().Int64() != 45 || txs[1].Value.ToInt().Int64() != 40 {
+		t.Errorf("disperse call values mismatch: have %v, %v", txs[0].Value, txs[1].Value)
+	}
+	args, err := disperse.Methods["disperseEther"].Inputs.Unpack(txs[0].data()[4:])
+	if err != nil {
+		t.Fatalf("failed to unpack disperse call: %v", err)
+	}
+	if recipients := args[0].([]common.Address); len(recipients) != 2 || recipients[0] != (common.Address{0x01}) || recipients[1] != (common.Address{0x03}) {
+		t.Errorf("disperse recipients mismatch: have %x", recipients)
+	}
+	if values := args[1].([]*big.Int); len(values) != 2 || values[0].Int64() != 10 || values[1].Int64() != 35 {
+		t.Errorf("disperse values mismatch: have %v", values)
+	}
+	// Batches without payouts or with negative ones are rejected
+	if _, _, err := packPayouts(PayoutBatchArgs{From: from, Payouts: payouts[2:3]}); err == nil {
+		t.Errorf("empty batch accepted")
+	}
+	if _, _, err := packPayouts(PayoutBatchArgs{From: from, Payouts: []Payout{{To: common.Address{0x01}, Amount: (*hexutil.Big)(big.NewInt(-1))}}}); err == nil {
+		t.Errorf("negative payout accepted")
+	}
+}
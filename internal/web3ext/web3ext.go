@@ -26,6 +26,12 @@ var Modules = map[string]string{
 	"txpool":   TxpoolJs,
 	"les":      LESJs,
 	"vflux":    VfluxJs,
+	"trace":    TraceJs,
+	"pool":     PoolJs,
+	"transfer": TransferJs,
+	"rent":     RentJs,
+	"dev":      DevJs,
+	"sweeper":  SweeperJs,
 }
 
 const CliqueJs = `
@@ -109,6 +115,16 @@ web3._extend({
 			call: 'ethash_submitHashrate',
 			params: 2,
 		}),
+		new web3._extend.Method({
+			name: 'getWorkers',
+			call: 'ethash_getWorkers',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'getNotifyHealth',
+			call: 'ethash_getNotifyHealth',
+			params: 0
+		}),
 	]
 });
 `
@@ -148,6 +164,93 @@ web3._extend({
 			call: 'admin_importChain',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'setServeLimits',
+			call: 'admin_setServeLimits',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'serveLimits',
+			call: 'admin_serveLimits',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'setVerifyConcurrency',
+			call: 'admin_setVerifyConcurrency',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'verifyConcurrency',
+			call: 'admin_verifyConcurrency',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'nodeHealth',
+			call: 'admin_nodeHealth',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'addTrustedPropagationPeer',
+			call: 'admin_addTrustedPropagationPeer',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'removeTrustedPropagationPeer',
+			call: 'admin_removeTrustedPropagationPeer',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'trustedPropagationPeers',
+			call: 'admin_trustedPropagationPeers',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'pruneState',
+			call: 'admin_pruneState',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'pruneStatus',
+			call: 'admin_pruneStatus',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'setHead',
+			call: 'admin_setHead',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'banBlockHash',
+			call: 'admin_banBlockHash',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'unbanBlockHash',
+			call: 'admin_unbanBlockHash',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'bannedBlockHashes',
+			call: 'admin_bannedBlockHashes',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'reloadChainConfig',
+			call: 'admin_reloadChainConfig',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'setFinalityDepth',
+			call: 'admin_setFinalityDepth',
+			params: 1,
+			inputFormatter: [web3._extend.utils.fromDecimal]
+		}),
+		new web3._extend.Method({
+			name: 'finalityDepth',
+			call: 'admin_finalityDepth',
+			params: 0,
+			outputFormatter: web3._extend.utils.toDecimal
+		}),
 		new web3._extend.Method({
 			name: 'sleepBlocks',
 			call: 'admin_sleepBlocks',
@@ -185,6 +288,26 @@ web3._extend({
 			name: 'stopWS',
 			call: 'admin_stopWS'
 		}),
+		new web3._extend.Method({
+			name: 'rpcStats',
+			call: 'admin_rpcStats'
+		}),
+		new web3._extend.Method({
+			name: 'setAllowlist',
+			call: 'admin_setAllowlist',
+			params: 1,
+		}),
+		new web3._extend.Method({
+			name: 'setDenylist',
+			call: 'admin_setDenylist',
+			params: 1,
+		}),
+		new web3._extend.Method({
+			name: 'replaceTransaction',
+			call: 'admin_replaceTransaction',
+			params: 2,
+			inputFormatter: [null, null],
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
@@ -195,6 +318,10 @@ web3._extend({
 			name: 'peers',
 			getter: 'admin_peers'
 		}),
+		new web3._extend.Property({
+			name: 'peerStats',
+			getter: 'admin_peerStats'
+		}),
 		new web3._extend.Property({
 			name: 'datadir',
 			getter: 'admin_datadir'
@@ -491,6 +618,17 @@ web3._extend({
 			call: 'debug_setTrieFlushInterval',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'getReorgHistory',
+			call: 'debug_getReorgHistory',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'rebuildBlockTemplate',
+			call: 'debug_rebuildBlockTemplate',
+			params: 3,
+			inputFormatter: [null, web3._extend.utils.fromDecimal, null]
+		}),
 	],
 	properties: []
 });
@@ -542,6 +680,21 @@ web3._extend({
 			params: 1,
 			inputFormatter: [web3._extend.formatters.inputTransactionFormatter]
 		}),
+		new web3._extend.Method({
+			name: 'getDifficultyHistory',
+			call: 'eth_getDifficultyHistory',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'estimateNetworkHashrate',
+			call: 'eth_estimateNetworkHashrate',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'suggestTipByPercentile',
+			call: 'eth_suggestTipByPercentile',
+			params: 2
+		}),
 		new web3._extend.Method({
 			name: 'getHeaderByNumber',
 			call: 'eth_getHeaderByNumber',
@@ -565,6 +718,12 @@ web3._extend({
 			params: 2,
 			inputFormatter: [null, function (val) { return !!val; }]
 		}),
+		new web3._extend.Method({
+			name: 'getBlockReceipts',
+			call: 'eth_getBlockReceipts',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
 		new web3._extend.Method({
 			name: 'getRawTransaction',
 			call: 'eth_getRawTransactionByHash',
@@ -590,6 +749,12 @@ web3._extend({
 			params: 2,
 			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter],
 		}),
+		new web3._extend.Method({
+			name: 'createAccessListMany',
+			call: 'eth_createAccessListMany',
+			params: 2,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter],
+		}),
 		new web3._extend.Method({
 			name: 'feeHistory',
 			call: 'eth_feeHistory',
@@ -601,12 +766,54 @@ web3._extend({
 			call: 'eth_getLogs',
 			params: 1,
 		}),
+		new web3._extend.Method({
+			name: 'getLogsPage',
+			call: 'eth_getLogsPage',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'getFinalizedBlock',
+			call: 'eth_getFinalizedBlock',
+			params: 1,
+			inputFormatter: [function (val) { return !!val; }]
+		}),
+		new web3._extend.Method({
+			name: 'getPendingBlock',
+			call: 'eth_getPendingBlock',
+			params: 1,
+			inputFormatter: [function (val) { return !!val; }]
+		}),
+		new web3._extend.Method({
+			name: 'getBlockWitness',
+			call: 'eth_getBlockWitness',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getAccountsByBalance',
+			call: 'eth_getAccountsByBalance',
+			params: 3,
+			inputFormatter: [web3._extend.utils.fromDecimal, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'getStorageRange',
+			call: 'eth_getStorageRange',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, null]
+		}),
 		new web3._extend.Method({
 			name: 'call',
 			call: 'eth_call',
 			params: 3,
 			inputFormatter: [web3._extend.formatters.inputCallFormatter, web3._extend.formatters.inputDefaultBlockNumberFormatter, null],
 		}),
+		new web3._extend.Method({
+			name: 'callMany',
+			call: 'eth_callMany',
+			params: 4,
+			inputFormatter: [null, web3._extend.formatters.inputDefaultBlockNumberFormatter, null, null],
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
@@ -655,6 +862,11 @@ web3._extend({
 			call: 'miner_setExtra',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'setExtraTemplate',
+			call: 'miner_setExtraTemplate',
+			params: 3
+		}),
 		new web3._extend.Method({
 			name: 'setGasPrice',
 			call: 'miner_setGasPrice',
@@ -667,11 +879,43 @@ web3._extend({
 			params: 1,
 			inputFormatter: [web3._extend.utils.fromDecimal]
 		}),
+		new web3._extend.Method({
+			name: 'setGasLimitTarget',
+			call: 'miner_setGasLimitTarget',
+			params: 2,
+			inputFormatter: [web3._extend.utils.fromDecimal, web3._extend.utils.fromDecimal]
+		}),
 		new web3._extend.Method({
 			name: 'setRecommitInterval',
 			call: 'miner_setRecommitInterval',
 			params: 1,
 		}),
+		new web3._extend.Method({
+			name: 'setUnclePolicy',
+			call: 'miner_setUnclePolicy',
+			params: 1,
+		}),
+		new web3._extend.Method({
+			name: 'setCoinbaseRotation',
+			call: 'miner_setCoinbaseRotation',
+			params: 3,
+			inputFormatter: [null, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'coinbaseRotation',
+			call: 'miner_coinbaseRotation',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'setPriorityAddresses',
+			call: 'miner_setPriorityAddresses',
+			params: 1,
+		}),
+		new web3._extend.Method({
+			name: 'setPriorityTxs',
+			call: 'miner_setPriorityTxs',
+			params: 1,
+		}),
 		new web3._extend.Method({
 			name: 'getHashrate',
 			call: 'miner_getHashrate'
@@ -791,6 +1035,11 @@ web3._extend({
 			call: 'txpool_contentFrom',
 			params: 1,
 		}),
+		new web3._extend.Method({
+			name: 'inspectPaged',
+			call: 'txpool_inspectPaged',
+			params: 3,
+		}),
 	]
 });
 `
@@ -879,3 +1128,159 @@ web3._extend({
 	]
 });
 `
+
+const TraceJs = `
+web3._extend({
+	property: 'trace',
+	methods:
+	[
+		new web3._extend.Method({
+			name: 'filter',
+			call: 'trace_filter',
+			params: 1
+		}),
+	],
+	properties:
+	[
+		new web3._extend.Property({
+			name: 'status',
+			getter: 'trace_status'
+		}),
+	]
+});
+`
+
+const PoolJs = `
+web3._extend({
+	property: 'pool',
+	methods:
+	[
+		new web3._extend.Method({
+			name: 'sendPayoutBatch',
+			call: 'pool_sendPayoutBatch',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'buildPayoutBatch',
+			call: 'pool_buildPayoutBatch',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getPayoutBatch',
+			call: 'pool_getPayoutBatch',
+			params: 1
+		}),
+	]
+});
+`
+
+const TransferJs = `
+web3._extend({
+	property: 'transfer',
+	methods:
+	[
+		new web3._extend.Method({
+			name: 'getByToken',
+			call: 'transfer_getByToken',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'getByAddress',
+			call: 'transfer_getByAddress',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null]
+		}),
+	],
+	properties:
+	[
+		new web3._extend.Property({
+			name: 'status',
+			getter: 'transfer_status'
+		}),
+	]
+});
+`
+
+const RentJs = `
+web3._extend({
+	property: 'rent',
+	methods:
+	[
+		new web3._extend.Method({
+			name: 'createLease',
+			call: 'rent_createLease',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.utils.fromDecimal, null]
+		}),
+		new web3._extend.Method({
+			name: 'cancelLease',
+			call: 'rent_cancelLease',
+			params: 1,
+			inputFormatter: [web3._extend.utils.fromDecimal]
+		}),
+		new web3._extend.Method({
+			name: 'getLease',
+			call: 'rent_getLease',
+			params: 1,
+			inputFormatter: [web3._extend.utils.fromDecimal]
+		}),
+	],
+	properties:
+	[
+		new web3._extend.Property({
+			name: 'leases',
+			getter: 'rent_leases'
+		}),
+	]
+});
+`
+
+const DevJs = `
+web3._extend({
+	property: 'dev',
+	methods:
+	[
+		new web3._extend.Method({
+			name: 'fundAccount',
+			call: 'dev_fundAccount',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.utils.fromDecimal]
+		}),
+		new web3._extend.Method({
+			name: 'mineBlocks',
+			call: 'dev_mineBlocks',
+			params: 1,
+			inputFormatter: [web3._extend.utils.fromDecimal],
+			outputFormatter: web3._extend.utils.toDecimal
+		}),
+	]
+});
+`
+
+const SweeperJs = `
+web3._extend({
+	property: 'sweeper',
+	methods:
+	[
+		new web3._extend.Method({
+			name: 'sweep',
+			call: 'sweeper_sweep',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'auditTrail',
+			call: 'sweeper_auditTrail',
+			params: 1,
+			inputFormatter: [null]
+		}),
+	],
+	properties:
+	[
+		new web3._extend.Property({
+			name: 'status',
+			getter: 'sweeper_status'
+		}),
+	]
+});
+`
@@ -15,6 +15,7 @@ package les
 import (
 	"errors"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
@@ -130,13 +131,16 @@ func (api *LightServerAPI) clientInfo(peer *clientPeer, balance vfs.ReadOnlyBala
 		info["connectionTime"] = float64(mclock.Now()-peer.connectedAt) / float64(time.Second)
 		info["capacity"] = peer.getCapacity()
 		info["pricing/negBalance"] = nb
+		bucket := peer.getBucketParams()
+		info["bucket/rate"] = bucket.rate
+		info["bucket/burst"] = bucket.burst
 	}
 	return info
 }
 
 // setParams either sets the given parameters for a single connected client (if specified)
 // or the default parameters applicable to clients connected in the future
-func (api *LightServerAPI) setParams(params map[string]interface{}, client *clientPeer, posFactors, negFactors *vfs.PriceFactors) (updateFactors bool, err error) {
+func (api *LightServerAPI) setParams(params map[string]interface{}, client *clientPeer, posFactors, negFactors *vfs.PriceFactors, bucket *bucketParams) (updateFactors, updateBucket bool, err error) {
 	defParams := client == nil
 	for name, value := range params {
 		errValue := func() error {
@@ -164,6 +168,20 @@ func (api *LightServerAPI) setParams(params map[string]interface{}, client *clie
 			setFactor(&negFactors.CapacityFactor)
 		case name == "pricing/negative/requestCostFactor":
 			setFactor(&negFactors.RequestFactor)
+		case name == "bucket/rate":
+			if val, ok := value.(float64); ok && val >= 0 {
+				bucket.rate = val
+				updateBucket = true
+			} else {
+				err = errValue()
+			}
+		case name == "bucket/burst":
+			if val, ok := value.(float64); ok && val >= 0 && val <= math.MaxInt32 {
+				bucket.burst = int(val)
+				updateBucket = true
+			} else {
+				err = errValue()
+			}
 		case !defParams && name == "capacity":
 			if capacity, ok := value.(float64); ok && uint64(capacity) >= api.server.minCapacity {
 				_, err = api.server.clientPool.SetCapacity(client.Node(), uint64(capacity), 0, false)
@@ -196,10 +214,14 @@ func (api *LightServerAPI) SetClientParams(nodes []string, params map[string]int
 		}
 		if peer := api.server.peers.peer(id); peer != nil {
 			posFactors, negFactors := peer.balance.GetPriceFactors()
-			update, e := api.setParams(params, peer, &posFactors, &negFactors)
+			bucket := peer.getBucketParams()
+			update, updateBucket, e := api.setParams(params, peer, &posFactors, &negFactors, &bucket)
 			if update {
 				peer.balance.SetPriceFactors(posFactors, negFactors)
 			}
+			if updateBucket {
+				peer.setBucketParams(bucket)
+			}
 			if e != nil {
 				err = e
 			}
@@ -212,10 +234,17 @@ func (api *LightServerAPI) SetClientParams(nodes []string, params map[string]int
 
 // SetDefaultParams sets the default parameters applicable to clients connected in the future
 func (api *LightServerAPI) SetDefaultParams(params map[string]interface{}) error {
-	update, err := api.setParams(params, nil, &api.defaultPosFactors, &api.defaultNegFactors)
+	api.server.bucketLock.Lock()
+	defer api.server.bucketLock.Unlock()
+
+	bucket := api.server.bucketDefaults
+	update, updateBucket, err := api.setParams(params, nil, &api.defaultPosFactors, &api.defaultNegFactors, &bucket)
 	if update {
 		api.server.clientPool.SetDefaultFactors(api.defaultPosFactors, api.defaultNegFactors)
 	}
+	if updateBucket {
+		api.server.bucketDefaults = bucket
+	}
 	return err
 }
 
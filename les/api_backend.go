@@ -296,6 +296,19 @@ func (b *LesApiBackend) RPCEVMTimeout() time.Duration {
 	return b.eth.config.RPCEVMTimeout
 }
 
+func (b *LesApiBackend) RPCTracerLimits() *tracers.Limits {
+	config := b.eth.config
+	if config.RPCTracerCPUTime == 0 && config.RPCTracerMemory == 0 && config.RPCTracerOutput == 0 && len(config.RPCTracers) == 0 {
+		return nil
+	}
+	return &tracers.Limits{
+		CPUTime: config.RPCTracerCPUTime,
+		Memory:  config.RPCTracerMemory,
+		Output:  config.RPCTracerOutput,
+		Tracers: config.RPCTracers,
+	}
+}
+
 func (b *LesApiBackend) RPCTxFeeCap() float64 {
 	return b.eth.config.RPCTxFeeCap
 }
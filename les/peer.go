@@ -37,6 +37,7 @@ import (
 	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
+	"golang.org/x/time/rate"
 )
 
 var (
@@ -776,6 +777,10 @@ type clientPeer struct {
 	server      bool
 	errCh       chan error
 	fcClient    *flowcontrol.ClientNode // Server side mirror token bucket.
+
+	bucketLock   sync.Mutex    // Lock protecting the request bucket parameters
+	bucket       *rate.Limiter // Local request rate limit, nil if unlimited
+	bucketParams bucketParams
 }
 
 func newClientPeer(version int, network uint64, p *p2p.Peer, rw p2p.MsgReadWriter) *clientPeer {
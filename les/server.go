@@ -14,6 +14,7 @@ package les
 
 import (
 	"crypto/ecdsa"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common/mclock"
@@ -21,10 +22,12 @@ import (
 	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/eth/ethconfig"
 	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/les/flowcontrol"
 	vfs "github.com/ethereum/go-ethereum/les/vflux/server"
 	"github.com/ethereum/go-ethereum/light"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/miner"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/enode"
@@ -49,6 +52,12 @@ type ethBackend interface {
 	TxPool() *txpool.TxPool
 }
 
+// minerBackend is implemented by backends running a miner, whose sealed blocks
+// light client serving backs off for.
+type minerBackend interface {
+	Miner() *miner.Miner
+}
+
 type LesServer struct {
 	lesCommons
 
@@ -70,6 +79,12 @@ type LesServer struct {
 	threadsIdle              int // Request serving threads count when system is idle.
 	threadsBusy              int // Request serving threads count when system is busy(block insertion).
 
+	sealGuard     time.Duration                                         // Time to back off for around local block broadcasts
+	sealingEvents func(ch chan<- miner.SealingEvent) event.Subscription // Sealing events of the local miner, nil if none
+
+	bucketLock     sync.Mutex   // Lock protecting the default request bucket
+	bucketDefaults bucketParams // Request rate limit of clients connecting in the future
+
 	p2pSrv *p2p.Server
 }
 
@@ -105,8 +120,12 @@ func NewLesServer(node *node.Node, e ethBackend, config *ethconfig.Config) (*Les
 		servingQueue: newServingQueue(int64(time.Millisecond*10), float64(config.LightServ)/100),
 		threadsBusy:  config.LightServ/100 + 1,
 		threadsIdle:  threads,
+		sealGuard:    config.LightSealGuard,
 		p2pSrv:       node.Server(),
 	}
+	if m, ok := e.(minerBackend); ok && config.LightSealGuard > 0 {
+		srv.sealingEvents = m.Miner().SubscribeSealingEvent
+	}
 	issync := e.Synced
 	if config.LightNoSyncServe {
 		issync = func() bool { return true }
@@ -245,13 +264,25 @@ func (s *LesServer) capacityManagement() {
 	totalCapacity := s.fcManager.SubscribeTotalCapacity(totalCapacityCh)
 	s.clientPool.SetLimits(uint64(s.config.LightPeers), totalCapacity)
 
+	sealingCh := make(chan miner.SealingEvent, 100)
+	if s.sealingEvents != nil {
+		sub := s.sealingEvents(sealingCh)
+		defer sub.Unsubscribe()
+	}
+	sealTimer := time.NewTimer(0)
+	defer sealTimer.Stop()
+	<-sealTimer.C
+
 	var (
-		busy         bool
+		processing   bool
+		sealing      bool
+		guard        = sealGuard{margin: s.sealGuard}
 		freePeers    uint64
 		blockProcess mclock.AbsTime
+		sealBackoff  time.Time
 	)
 	updateRecharge := func() {
-		if busy {
+		if processing || sealing {
 			s.servingQueue.setThreads(s.threadsBusy)
 			s.fcManager.SetRechargeCurve(flowcontrol.PieceWiseLinear{{0, 0}, {totalRecharge, totalRecharge}})
 		} else {
@@ -261,10 +292,32 @@ func (s *LesServer) capacityManagement() {
 	}
 	updateRecharge()
 
+	// updateSealing backs off serving within the window around the expected
+	// broadcast of a locally sealed block.
+	updateSealing := func() {
+		now := time.Now()
+		if active := guard.active(now); active != sealing {
+			if sealing = active; sealing {
+				sealBackoff = now
+			} else {
+				sealBackoffTimer.Update(now.Sub(sealBackoff))
+			}
+			updateRecharge()
+		}
+		sealTimer.Stop()
+		if wait, ok := guard.next(now); ok {
+			sealTimer.Reset(wait)
+		}
+	}
 	for {
 		select {
-		case busy = <-processCh:
-			if busy {
+		case ev := <-sealingCh:
+			guard.update(ev)
+			updateSealing()
+		case <-sealTimer.C:
+			updateSealing()
+		case processing = <-processCh:
+			if processing {
 				blockProcess = mclock.Now()
 			} else {
 				blockProcessingTimer.Update(time.Duration(mclock.Now() - blockProcess))
@@ -0,0 +1,143 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package les
+
+import (
+	"math"
+	"time"
+
+	"github.com/ethereum/go-ethereum/miner"
+	"golang.org/x/time/rate"
+)
+
+// sealGuard tracks the windows around the expected broadcasts of the blocks
+// sealed locally, during which serving light clients backs off so that block
+// propagation doesn't compete with request serving for resources.
+type sealGuard struct {
+	margin      time.Duration // Time before (and at most after) a broadcast to back off for
+	from, until time.Time     // Current back off window, zero if none
+}
+
+// update records a sealing event of the miner.
+func (g *sealGuard) update(ev miner.SealingEvent) {
+	if ev.Broadcast.IsZero() {
+		g.from, g.until = time.Time{}, time.Time{}
+		return
+	}
+	// The broadcast completing is announced, but don't rely on it: back off at
+	// most for the margin past the expected broadcast.
+	g.from, g.until = ev.Broadcast.Add(-g.margin), ev.Broadcast.Add(g.margin)
+}
+
+// active returns whether serving has to back off at the given time.
+func (g *sealGuard) active(now time.Time) bool {
+	return !g.from.IsZero() && !now.Before(g.from) && now.Before(g.until)
+}
+
+// next returns the time left until the guard may change state, false if it
+// won't change without a new sealing event.
+func (g *sealGuard) next(now time.Time) (time.Duration, bool) {
+	switch {
+	case g.from.IsZero() || !now.Before(g.until):
+		return 0, false
+	case now.Before(g.from):
+		return g.from.Sub(now), true
+	default:
+		return g.until.Sub(now), true
+	}
+}
+
+// bucketParams are the parameters of the token bucket limiting the request rate
+// of a client.
+type bucketParams struct {
+	rate  float64 // Requests per second, unlimited if zero
+	burst int     // Requests allowed at once, the rate rounded up if zero
+}
+
+// limits returns the rate and burst of the token bucket.
+func (b bucketParams) limits() (rate.Limit, int) {
+	if b.rate == 0 {
+		return rate.Inf, 0
+	}
+	burst := b.burst
+	if burst == 0 {
+		burst = int(math.Ceil(b.rate))
+	}
+	return rate.Limit(b.rate), burst
+}
+
+// newRequestBucket creates the token bucket limiting the request rate of a newly
+// connected client according to the default client parameters.
+func (s *LesServer) newRequestBucket() (*rate.Limiter, bucketParams) {
+	s.bucketLock.Lock()
+	defer s.bucketLock.Unlock()
+
+	return rate.NewLimiter(s.bucketDefaults.limits()), s.bucketDefaults
+}
+
+// setBucketParams updates the token bucket limiting the request rate of a client.
+func (p *clientPeer) setBucketParams(params bucketParams) {
+	p.bucketLock.Lock()
+	defer p.bucketLock.Unlock()
+
+	p.bucketParams = params
+	if p.bucket != nil {
+		limit, burst := params.limits()
+		p.bucket.SetLimit(limit)
+		p.bucket.SetBurst(burst)
+	}
+}
+
+// getBucketParams returns the parameters of the token bucket limiting the request
+// rate of a client.
+func (p *clientPeer) getBucketParams() bucketParams {
+	p.bucketLock.Lock()
+	defer p.bucketLock.Unlock()
+
+	return p.bucketParams
+}
+
+// takeRequestToken waits for the request bucket of the client to allow another
+// request, returning false if the request has to be dropped instead. Waiting
+// holds up reading further messages of the client only.
+func (h *serverHandler) takeRequestToken(p *clientPeer) bool {
+	if p.bucket == nil {
+		return true
+	}
+	r := p.bucket.Reserve()
+	if !r.OK() {
+		return false
+	}
+	delay := r.Delay()
+	if delay == 0 {
+		return true
+	}
+	if delay == rate.InfDuration || delay > maxBucketWait {
+		r.Cancel()
+		return false
+	}
+	clientThrottleMeter.Mark(1)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-h.closeCh:
+		return false
+	}
+}
+
+// maxBucketWait is the longest time a request is held back by the request bucket
+// of a client before being dropped.
+const maxBucketWait = 10 * time.Second
@@ -0,0 +1,111 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package les
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/miner"
+	"golang.org/x/time/rate"
+)
+
+func TestSealGuard(t *testing.T) {
+	var (
+		now   = time.Now()
+		guard = sealGuard{margin: 100 * time.Millisecond}
+	)
+	if guard.active(now) {
+		t.Fatalf("guard active without sealing")
+	}
+	if _, ok := guard.next(now); ok {
+		t.Fatalf("guard scheduled without sealing")
+	}
+	// A broadcast expected later backs off once within the margin
+	guard.update(miner.SealingEvent{Broadcast: now.Add(time.Second)})
+	if guard.active(now) {
+		t.Errorf("guard active ahead of the margin")
+	}
+	if wait, ok := guard.next(now); !ok || wait != 900*time.Millisecond {
+		t.Errorf("guard wakeup mismatch: have %v, %v, want %v", wait, ok, 900*time.Millisecond)
+	}
+	if !guard.active(now.Add(950 * time.Millisecond)) {
+		t.Errorf("guard inactive within the margin")
+	}
+	if wait, ok := guard.next(now.Add(950 * time.Millisecond)); !ok || wait != 150*time.Millisecond {
+		t.Errorf("guard expiry mismatch: have %v, %v, want %v", wait, ok, 150*time.Millisecond)
+	}
+	// Without the broadcast being reported done, the guard expires past the margin
+	if guard.active(now.Add(1100 * time.Millisecond)) {
+		t.Errorf("guard active past the margin")
+	}
+	if _, ok := guard.next(now.Add(1100 * time.Millisecond)); ok {
+		t.Errorf("expired guard scheduled")
+	}
+	// A completed broadcast lifts the guard
+	guard.update(miner.SealingEvent{Broadcast: now})
+	if !guard.active(now) {
+		t.Errorf("guard inactive at broadcast")
+	}
+	guard.update(miner.SealingEvent{})
+	if guard.active(now) {
+		t.Errorf("guard active after broadcast")
+	}
+}
+
+func TestBucketParams(t *testing.T) {
+	var tests = []struct {
+		params bucketParams
+		limit  rate.Limit
+		burst  int
+	}{
+		{bucketParams{}, rate.Inf, 0},
+		{bucketParams{burst: 5}, rate.Inf, 0},
+		{bucketParams{rate: 2.5}, 2.5, 3},
+		{bucketParams{rate: 10, burst: 20}, 10, 20},
+	}
+	for i, tt := range tests {
+		if limit, burst := tt.params.limits(); limit != tt.limit || burst != tt.burst {
+			t.Errorf("test %d: limits mismatch: have %v/%d, want %v/%d", i, limit, burst, tt.limit, tt.burst)
+		}
+	}
+}
+
+func TestRequestBucket(t *testing.T) {
+	var (
+		h = &serverHandler{closeCh: make(chan struct{})}
+		p = &clientPeer{bucket: rate.NewLimiter(bucketParams{}.limits())}
+	)
+	for i := 0; i < 100; i++ {
+		if !h.takeRequestToken(p) {
+			t.Fatalf("unlimited request %d dropped", i)
+		}
+	}
+	// Requests past the burst are held back at the configured rate
+	p.setBucketParams(bucketParams{rate: 20, burst: 2})
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if !h.takeRequestToken(p) {
+			t.Fatalf("limited request %d dropped", i)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("limited requests not held back: took %v", elapsed)
+	}
+	// Requests which would wait too long are dropped
+	p.setBucketParams(bucketParams{rate: 0.001, burst: 1})
+	p.bucket.Allow()
+	if h.takeRequestToken(p) {
+		t.Errorf("request beyond the maximum wait accepted")
+	}
+}
@@ -131,6 +131,7 @@ func (h *serverHandler) handle(p *clientPeer) error {
 	// Setup flow control mechanism for the peer
 	p.fcClient = flowcontrol.NewClientNode(h.server.fcManager, p.fcParams)
 	defer p.fcClient.Disconnect()
+	p.bucket, p.bucketParams = h.server.newRequestBucket()
 
 	// Reject light clients if server is not synced. Put this checking here, so
 	// that "non-synced" les-server peers are still allowed to keep the connection.
@@ -192,6 +193,11 @@ func (h *serverHandler) beforeHandle(p *clientPeer, reqID, responseCount uint64,
 		p.fcClient.OneTimeCost(inSizeCost)
 		return nil, 0
 	}
+	// Hold the request back if the client exceeds its local rate limit
+	if !h.takeRequestToken(p) {
+		p.fcClient.OneTimeCost(inSizeCost)
+		return nil, 0
+	}
 	maxCost := p.fcCosts.getMaxCost(msg.Code, reqCnt)
 	accepted, bufShort, priority := p.fcClient.AcceptRequest(reqID, responseCount, maxCost)
 	if !accepted {
@@ -0,0 +1,255 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package miner
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// maxBundles is the maximum number of bundles retained in the private pool.
+const maxBundles = 1024
+
+var (
+	errBundleEmpty    = errors.New("bundle has no transactions")
+	errBundleStale    = errors.New("bundle targets a past block")
+	errBundlePoolFull = errors.New("bundle pool full")
+	errBundleKnown    = errors.New("bundle already known")
+)
+
+// Bundle is an atomic sequence of transactions submitted by a searcher. The
+// transactions are kept in a private pool, never gossiped to the network, and
+// only included back to back by this node's miner, in full or not at all.
+type Bundle struct {
+	Txs               types.Transactions // Transactions in inclusion order
+	BlockNumber       uint64             // Number of the block the bundle targets
+	MinTimestamp      uint64             // Minimum block timestamp, zero if unbounded
+	MaxTimestamp      uint64             // Maximum block timestamp, zero if unbounded
+	RevertingTxHashes []common.Hash      // Transactions allowed to revert
+}
+
+// Hash returns the identifier of the bundle, the hash of its transaction hashes.
+func (b *Bundle) Hash() common.Hash {
+	hashes := make([]byte, 0, len(b.Txs)*common.HashLength)
+	for _, tx := range b.Txs {
+		hashes = append(hashes, tx.Hash().Bytes()...)
+	}
+	return crypto.Keccak256Hash(hashes)
+}
+
+// includable returns whether the bundle may be included in the given block.
+func (b *Bundle) includable(header *types.Header) bool {
+	if b.BlockNumber != header.Number.Uint64() {
+		return false
+	}
+	if b.MinTimestamp != 0 && header.Time < b.MinTimestamp {
+		return false
+	}
+	if b.MaxTimestamp != 0 && header.Time > b.MaxTimestamp {
+		return false
+	}
+	return true
+}
+
+// mayRevert returns whether the given transaction of the bundle is allowed to
+// revert without invalidating the bundle.
+func (b *Bundle) mayRevert(hash common.Hash) bool {
+	for _, allowed := range b.RevertingTxHashes {
+		if allowed == hash {
+			return true
+		}
+	}
+	return false
+}
+
+// bundlePool is the private pool of bundles awaiting inclusion.
+type bundlePool struct {
+	lock    sync.Mutex
+	bundles []*Bundle // Bundles in arrival order
+}
+
+// add inserts a bundle into the pool, dropping the ones no longer includable
+// on top of the given chain head.
+func (p *bundlePool) add(bundle *Bundle, head uint64) error {
+	if len(bundle.Txs) == 0 {
+		return errBundleEmpty
+	}
+	if bundle.BlockNumber <= head {
+		return errBundleStale
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.prune(head)
+	if len(p.bundles) >= maxBundles {
+		return errBundlePoolFull
+	}
+	hash := bundle.Hash()
+	for _, known := range p.bundles {
+		if known.BlockNumber == bundle.BlockNumber && known.Hash() == hash {
+			return errBundleKnown
+		}
+	}
+	p.bundles = append(p.bundles, bundle)
+	return nil
+}
+
+// pending returns the bundles includable in the given block, in arrival order.
+func (p *bundlePool) pending(header *types.Header) []*Bundle {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.prune(header.Number.Uint64() - 1)
+
+	var bundles []*Bundle
+	for _, bundle := range p.bundles {
+		if bundle.includable(header) {
+			bundles = append(bundles, bundle)
+		}
+	}
+	return bundles
+}
+
+// prune drops the bundles targeting blocks up to the given head. The caller
+// must hold the lock.
+func (p *bundlePool) prune(head uint64) {
+	bundles := p.bundles[:0]
+	for _, bundle := range p.bundles {
+		if bundle.BlockNumber > head {
+			bundles = append(bundles, bundle)
+		}
+	}
+	for i := len(bundles); i < len(p.bundles); i++ {
+		p.bundles[i] = nil
+	}
+	p.bundles = bundles
+}
+
+// commitBundles applies the given bundles of the private pool to the sealing
+// block. Bundles failing to apply in full are left out entirely.
+func (w *worker) commitBundles(env *environment, bundles []*Bundle) {
+	if env.gasPool == nil {
+		env.gasPool = new(core.GasPool).AddGas(env.header.GasLimit)
+	}
+	for _, bundle := range bundles {
+		if err := w.commitBundle(env, bundle); err != nil {
+			log.Debug("Bundle skipped", "hash", bundle.Hash(), "err", err)
+		}
+	}
+}
+
+// commitBundle applies all transactions of a bundle to the sealing block,
+// reverting all of them if any fails to apply or reverts without being allowed
+// to. The state is copied up front as journal snapshots don't survive across
+// transactions.
+func (w *worker) commitBundle(env *environment, bundle *Bundle) error {
+	var (
+		saved   = env.state.Copy()
+		gas     = env.gasPool.Gas()
+		gasUsed = env.header.GasUsed
+		txs     = len(env.txs)
+		tcount  = env.tcount
+	)
+	revert := func() {
+		env.state.StopPrefetcher()
+		env.state = saved
+		env.gasPool.SetGas(gas)
+		env.header.GasUsed = gasUsed
+		env.txs, env.receipts = env.txs[:txs], env.receipts[:txs]
+		env.tcount = tcount
+	}
+	for _, tx := range bundle.Txs {
+		if tx.Protected() && !w.chainConfig().IsEIP155(env.header.Number) {
+			revert()
+			return errors.New("replay protection not active")
+		}
+		env.state.SetTxContext(tx.Hash(), env.tcount)
+		if _, err := w.commitTransaction(env, tx); err != nil {
+			revert()
+			return err
+		}
+		env.tcount++
+		if env.receipts[len(env.receipts)-1].Status == types.ReceiptStatusFailed && !bundle.mayRevert(tx.Hash()) {
+			revert()
+			return errors.New("transaction reverted")
+		}
+	}
+	return nil
+}
+
+// BundleTxResult is the outcome of a single transaction of a simulated bundle.
+type BundleTxResult struct {
+	TxHash       common.Hash
+	GasUsed      uint64
+	Reverted     bool
+	CoinbaseDiff *big.Int // Coinbase balance increase caused by the transaction
+}
+
+// BundleSimulation is the outcome of simulating a bundle on top of the chain
+// head.
+type BundleSimulation struct {
+	Results          []BundleTxResult
+	CoinbaseDiff     *big.Int // Coinbase balance increase caused by the bundle
+	TotalGasUsed     uint64
+	StateBlockNumber uint64 // Number of the block the bundle was executed on top of
+}
+
+// simulateBundle executes a bundle on top of the current chain head, without
+// adding it to the pool. Simulation fails if any transaction fails to apply,
+// while reverting transactions are reported.
+func (w *worker) simulateBundle(bundle *Bundle, timestamp uint64) (*BundleSimulation, error) {
+	if len(bundle.Txs) == 0 {
+		return nil, errBundleEmpty
+	}
+	env, err := w.prepareWork(&generateParams{
+		timestamp: timestamp,
+		coinbase:  w.etherbase(),
+		noUncle:   true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer env.discard()
+
+	env.gasPool = new(core.GasPool).AddGas(env.header.GasLimit)
+	sim := &BundleSimulation{
+		StateBlockNumber: env.header.Number.Uint64() - 1,
+	}
+	for _, tx := range bundle.Txs {
+		balance := env.state.GetBalance(env.coinbase)
+
+		env.state.SetTxContext(tx.Hash(), env.tcount)
+		if _, err := w.commitTransaction(env, tx); err != nil {
+			return nil, err
+		}
+		env.tcount++
+
+		receipt := env.receipts[len(env.receipts)-1]
+		sim.Results = append(sim.Results, BundleTxResult{
+			TxHash:       tx.Hash(),
+			GasUsed:      receipt.GasUsed,
+			Reverted:     receipt.Status == types.ReceiptStatusFailed,
+			CoinbaseDiff: new(big.Int).Sub(env.state.GetBalance(env.coinbase), balance),
+		})
+		sim.TotalGasUsed += receipt.GasUsed
+	}
+	sim.CoinbaseDiff = env.profit()
+	return sim, nil
+}
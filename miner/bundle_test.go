@@ -0,0 +1,141 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package miner
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// bundleTransfer creates a signed transfer from the test bank to the test user.
+func bundleTransfer(nonce uint64, value int64) *types.Transaction {
+	return types.MustSignNewTx(testBankKey, types.LatestSigner(ethashChainConfig), &types.LegacyTx{
+		Nonce:    nonce,
+		To:       &testUserAddress,
+		Value:    big.NewInt(value),
+		Gas:      params.TxGas,
+		GasPrice: big.NewInt(10 * params.InitialBaseFee),
+	})
+}
+
+// Tests that the bundle pool rejects unusable bundles and only hands out the
+// ones includable in a given block.
+func TestBundlePool(t *testing.T) {
+	pool := new(bundlePool)
+
+	if err := pool.add(&Bundle{BlockNumber: 5}, 1); err != errBundleEmpty {
+		t.Errorf("empty bundle error mismatch: have %v, want %v", err, errBundleEmpty)
+	}
+	if err := pool.add(&Bundle{Txs: types.Transactions{bundleTransfer(0, 1)}, BlockNumber: 1}, 1); err != errBundleStale {
+		t.Errorf("stale bundle error mismatch: have %v, want %v", err, errBundleStale)
+	}
+	var (
+		now   = uint64(time.Now().Unix())
+		early = &Bundle{Txs: types.Transactions{bundleTransfer(0, 1)}, BlockNumber: 2}
+		late  = &Bundle{Txs: types.Transactions{bundleTransfer(0, 2)}, BlockNumber: 2, MinTimestamp: now + 60}
+		next  = &Bundle{Txs: types.Transactions{bundleTransfer(0, 3)}, BlockNumber: 3}
+	)
+	for _, bundle := range []*Bundle{early, late, next} {
+		if err := pool.add(bundle, 1); err != nil {
+			t.Fatalf("failed to add bundle: %v", err)
+		}
+	}
+	if err := pool.add(early, 1); err != errBundleKnown {
+		t.Errorf("duplicate bundle error mismatch: have %v, want %v", err, errBundleKnown)
+	}
+	pending := pool.pending(&types.Header{Number: big.NewInt(2), Time: now})
+	if len(pending) != 1 || pending[0] != early {
+		t.Errorf("pending bundles mismatch: have %v, want %v", pending, []*Bundle{early})
+	}
+	// Bundles targeting past blocks are dropped once the chain moves on
+	pool.pending(&types.Header{Number: big.NewInt(3), Time: now})
+	if len(pool.bundles) != 1 || pool.bundles[0] != next {
+		t.Errorf("pruned pool mismatch: have %v, want %v", pool.bundles, []*Bundle{next})
+	}
+}
+
+// Tests that bundles are included in full or not at all.
+func TestCommitBundles(t *testing.T) {
+	engine := ethash.NewFaker()
+	defer engine.Close()
+
+	w, _ := newTestWorker(t, ethashChainConfig, engine, rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+
+	good := &Bundle{Txs: types.Transactions{bundleTransfer(0, 1000), bundleTransfer(1, 1000)}, BlockNumber: 1}
+	gapped := &Bundle{Txs: types.Transactions{bundleTransfer(2, 1000), bundleTransfer(7, 1000)}, BlockNumber: 1}
+	for _, bundle := range []*Bundle{good, gapped} {
+		if err := w.bundles.add(bundle, 0); err != nil {
+			t.Fatalf("failed to add bundle: %v", err)
+		}
+	}
+	env, err := w.prepareWork(&generateParams{timestamp: uint64(time.Now().Unix()), coinbase: common.Address{0xcb}})
+	if err != nil {
+		t.Fatalf("failed to prepare work: %v", err)
+	}
+	defer env.discard()
+
+	w.commitBundles(env, w.bundles.pending(env.header))
+	if env.tcount != 2 || len(env.txs) != 2 || len(env.receipts) != 2 {
+		t.Fatalf("included transactions mismatch: have %d, want 2", env.tcount)
+	}
+	if env.header.GasUsed != 2*params.TxGas {
+		t.Errorf("gas used mismatch: have %d, want %d", env.header.GasUsed, 2*params.TxGas)
+	}
+	if balance := env.state.GetBalance(testUserAddress); balance.Cmp(big.NewInt(2000)) != 0 {
+		t.Errorf("user balance mismatch: have %v, want 2000", balance)
+	}
+}
+
+// Tests that simulating a bundle reports the coinbase payment of every
+// transaction without adding the bundle to the pool.
+func TestSimulateBundle(t *testing.T) {
+	engine := ethash.NewFaker()
+	defer engine.Close()
+
+	w, _ := newTestWorker(t, ethashChainConfig, engine, rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+	w.setEtherbase(common.Address{0xcb})
+
+	bundle := &Bundle{Txs: types.Transactions{bundleTransfer(0, 1000), bundleTransfer(1, 1000)}}
+	sim, err := w.simulateBundle(bundle, uint64(time.Now().Unix()))
+	if err != nil {
+		t.Fatalf("failed to simulate bundle: %v", err)
+	}
+	if len(sim.Results) != 2 || sim.TotalGasUsed != 2*params.TxGas || sim.StateBlockNumber != 0 {
+		t.Fatalf("simulation mismatch: results %d, gas %d, state %d", len(sim.Results), sim.TotalGasUsed, sim.StateBlockNumber)
+	}
+	sum := new(big.Int)
+	for _, result := range sim.Results {
+		if result.Reverted || result.CoinbaseDiff.Sign() <= 0 {
+			t.Errorf("transaction %x result mismatch: reverted %v, coinbase diff %v", result.TxHash, result.Reverted, result.CoinbaseDiff)
+		}
+		sum.Add(sum, result.CoinbaseDiff)
+	}
+	if sum.Cmp(sim.CoinbaseDiff) != 0 {
+		t.Errorf("coinbase diff mismatch: have %v, want %v", sim.CoinbaseDiff, sum)
+	}
+	if len(w.bundles.bundles) != 0 {
+		t.Error("simulated bundle added to the pool")
+	}
+	if _, err := w.simulateBundle(&Bundle{Txs: types.Transactions{bundleTransfer(5, 1)}}, uint64(time.Now().Unix())); err == nil {
+		t.Error("bundle with inapplicable transaction simulated")
+	}
+}
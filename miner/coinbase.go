@@ -0,0 +1,198 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package miner
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// Coinbase rotation policies.
+const (
+	RotationRoundRobin = "round-robin" // Every address in turn
+	RotationWeighted   = "weighted"    // Addresses in proportion to their weights
+)
+
+var (
+	errRotationZeroAddress = errors.New("zero coinbase address in rotation")
+	errRotationWeights     = errors.New("weighted rotation requires a positive weight per address")
+)
+
+// CoinbaseRotation is the status of the rotation of the coinbase of the sealed
+// blocks over several payout addresses.
+type CoinbaseRotation struct {
+	Policy    string           `json:"policy"`
+	Addresses []common.Address `json:"addresses"`
+	Weights   []uint64         `json:"weights"`
+	Active    common.Address   `json:"active"` // Coinbase of the current sealing work
+	Sealed    []hexutil.Uint64 `json:"sealed"` // Blocks sealed per address since the rotation was set
+}
+
+// coinbaseRotator switches the coinbase of the sealing work to the next payout
+// address after every block sealed for the active one. The addresses are picked
+// by smooth weighted round robin, spreading them evenly even if weighted, with
+// unit weights for plain round robin.
+type coinbaseRotator struct {
+	lock      sync.Mutex
+	policy    string
+	addresses []common.Address
+	weights   []int64
+	current   []int64 // Running weights of the smooth weighted round robin
+	sealed    []uint64
+	active    int // Index of the active address, -1 if not rotating
+}
+
+func newCoinbaseRotator() *coinbaseRotator {
+	return &coinbaseRotator{active: -1}
+}
+
+// set replaces the rotation, starting over from the first pick. An empty list
+// of addresses disables the rotation.
+func (r *coinbaseRotator) set(addresses []common.Address, policy string, weights []uint64) error {
+	if len(addresses) == 0 {
+		r.lock.Lock()
+		defer r.lock.Unlock()
+
+		r.policy, r.addresses, r.weights, r.current, r.sealed, r.active = "", nil, nil, nil, nil, -1
+		log.Info("Disabled coinbase rotation")
+		return nil
+	}
+	seen := make(map[common.Address]struct{}, len(addresses))
+	for _, addr := range addresses {
+		if addr == (common.Address{}) {
+			return errRotationZeroAddress
+		}
+		if _, ok := seen[addr]; ok {
+			return fmt.Errorf("duplicate coinbase %v in rotation", addr)
+		}
+		seen[addr] = struct{}{}
+	}
+	units := make([]int64, len(addresses))
+	switch policy {
+	case "", RotationRoundRobin:
+		policy = RotationRoundRobin
+		for i := range units {
+			units[i] = 1
+		}
+	case RotationWeighted:
+		if len(weights) != len(addresses) {
+			return errRotationWeights
+		}
+		for i, weight := range weights {
+			if weight == 0 || weight > 1<<32 {
+				return errRotationWeights
+			}
+			units[i] = int64(weight)
+		}
+	default:
+		return fmt.Errorf("unknown coinbase rotation policy %q", policy)
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.policy = policy
+	r.addresses = append([]common.Address{}, addresses...)
+	r.weights = units
+	r.current = make([]int64, len(addresses))
+	r.sealed = make([]uint64, len(addresses))
+	r.pick()
+
+	log.Info("Enabled coinbase rotation", "policy", policy, "addresses", len(addresses), "active", r.addresses[r.active])
+	return nil
+}
+
+// pick selects the next active address. The lock must be held.
+func (r *coinbaseRotator) pick() {
+	var total int64
+	r.active = 0
+	for i, weight := range r.weights {
+		r.current[i] += weight
+		total += weight
+		if r.current[i] > r.current[r.active] {
+			r.active = i
+		}
+	}
+	r.current[r.active] -= total
+}
+
+// coinbase returns the active address of the rotation, or the fallback if the
+// rotation is disabled.
+func (r *coinbaseRotator) coinbase(fallback common.Address) common.Address {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.active < 0 {
+		return fallback
+	}
+	return r.addresses[r.active]
+}
+
+// contains returns whether the address takes part in the rotation.
+func (r *coinbaseRotator) contains(addr common.Address) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for _, a := range r.addresses {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// sealedBlock counts a block sealed for the given coinbase, rotating to the
+// next address if it was the active one. Blocks of stale work sealed for other
+// addresses of the rotation are counted without rotating.
+func (r *coinbaseRotator) sealedBlock(coinbase common.Address) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for i, addr := range r.addresses {
+		if addr != coinbase {
+			continue
+		}
+		r.sealed[i]++
+		if i == r.active {
+			r.pick()
+			log.Debug("Rotated coinbase", "sealed", coinbase, "next", r.addresses[r.active])
+		}
+		return
+	}
+}
+
+// status returns the current rotation, nil if disabled.
+func (r *coinbaseRotator) status() *CoinbaseRotation {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.active < 0 {
+		return nil
+	}
+	status := &CoinbaseRotation{
+		Policy:    r.policy,
+		Addresses: append([]common.Address{}, r.addresses...),
+		Weights:   make([]uint64, len(r.weights)),
+		Active:    r.addresses[r.active],
+		Sealed:    make([]hexutil.Uint64, len(r.sealed)),
+	}
+	for i := range r.addresses {
+		status.Weights[i] = uint64(r.weights[i])
+		status.Sealed[i] = hexutil.Uint64(r.sealed[i])
+	}
+	return status
+}
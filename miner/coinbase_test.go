@@ -0,0 +1,109 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package miner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+)
+
+// Tests that the rotation is validated and picks the addresses in turn, in
+// proportion to their weights for the weighted policy.
+func TestCoinbaseRotationPolicy(t *testing.T) {
+	var (
+		r    = newCoinbaseRotator()
+		a, b = common.Address{0xaa}, common.Address{0xbb}
+	)
+	for i, invalid := range []struct {
+		addresses []common.Address
+		policy    string
+		weights   []uint64
+	}{
+		{[]common.Address{a, {}}, RotationRoundRobin, nil},
+		{[]common.Address{a, a}, RotationRoundRobin, nil},
+		{[]common.Address{a, b}, "random", nil},
+		{[]common.Address{a, b}, RotationWeighted, []uint64{1}},
+		{[]common.Address{a, b}, RotationWeighted, []uint64{1, 0}},
+	} {
+		if err := r.set(invalid.addresses, invalid.policy, invalid.weights); err == nil {
+			t.Errorf("invalid rotation %d accepted", i)
+		}
+	}
+	if have := r.coinbase(common.Address{0x01}); have != (common.Address{0x01}) {
+		t.Fatalf("coinbase without rotation mismatch: have %x", have)
+	}
+	check := func(want ...common.Address) {
+		t.Helper()
+		for i, addr := range want {
+			if have := r.coinbase(common.Address{}); have != addr {
+				t.Fatalf("pick %d mismatch: have %x, want %x", i, have, addr)
+			}
+			r.sealedBlock(addr)
+		}
+	}
+	if err := r.set([]common.Address{a, b}, "", nil); err != nil {
+		t.Fatalf("failed to set round robin rotation: %v", err)
+	}
+	check(a, b, a, b)
+
+	if err := r.set([]common.Address{a, b}, RotationWeighted, []uint64{3, 1}); err != nil {
+		t.Fatalf("failed to set weighted rotation: %v", err)
+	}
+	check(a, a, b, a, a, a, b, a)
+
+	// Blocks of stale work are counted without rotating
+	r.sealedBlock(b)
+	status := r.status()
+	if status.Active != a || status.Sealed[0] != 6 || status.Sealed[1] != 3 {
+		t.Fatalf("rotation status mismatch: %+v", status)
+	}
+	if err := r.set(nil, "", nil); err != nil || r.status() != nil || r.contains(a) {
+		t.Fatalf("rotation not disabled: %v", err)
+	}
+}
+
+// Tests that the coinbase of the sealed blocks rotates after every block.
+func TestCoinbaseRotationSealing(t *testing.T) {
+	engine := ethash.NewFaker()
+	defer engine.Close()
+
+	w, _ := newTestWorker(t, ethashChainConfig, engine, rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+
+	a, b := common.Address{0xaa}, common.Address{0xbb}
+	if err := w.setCoinbaseRotation([]common.Address{a, b}, RotationRoundRobin, nil); err != nil {
+		t.Fatalf("failed to set rotation: %v", err)
+	}
+	sub := w.mux.Subscribe(core.NewMinedBlockEvent{})
+	defer sub.Unsubscribe()
+
+	// Seal a single block per height, the fake engine seals every task
+	w.noempty.Store(true)
+	w.start()
+	for i, want := range []common.Address{a, b, a, b} {
+		select {
+		case ev := <-sub.Chan():
+			block := ev.Data.(core.NewMinedBlockEvent).Block
+			if block.Coinbase() != want {
+				t.Fatalf("block %d coinbase mismatch: have %x, want %x", i, block.Coinbase(), want)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatalf("block %d not mined", i)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package miner
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"text/template"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// ExtraFields are the variables available to block extra data templates.
+type ExtraFields struct {
+	WorkerID string // Identifier of this mining node within its pool
+	PoolTag  string // Tag of the pool the blocks are mined for
+	Height   uint64 // Number of the block being assembled
+}
+
+// extraTemplate is a block extra data template resolved at block assembly.
+type extraTemplate struct {
+	tmpl     *template.Template
+	workerID string
+	poolTag  string
+}
+
+// IsExtraTemplate returns whether the given extra data contains template
+// actions to be resolved at block assembly instead of being used verbatim.
+func IsExtraTemplate(extra []byte) bool {
+	return bytes.Contains(extra, []byte("{{"))
+}
+
+// newExtraTemplate parses an extra data template, rejecting those which may
+// resolve beyond the maximum extra data size.
+func newExtraTemplate(text string, workerID, poolTag string) (*extraTemplate, error) {
+	tmpl, err := template.New("extra").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	t := &extraTemplate{tmpl: tmpl, workerID: workerID, poolTag: poolTag}
+
+	// Resolve at the widest height, extra data never grows beyond it
+	extra, err := t.render(math.MaxUint64)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(extra)) > params.MaximumExtraDataSize {
+		return nil, fmt.Errorf("extra exceeds max length. %d > %v", len(extra), params.MaximumExtraDataSize)
+	}
+	return t, nil
+}
+
+// render resolves the template for the block at the given height.
+func (t *extraTemplate) render(height uint64) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, ExtraFields{WorkerID: t.workerID, PoolTag: t.poolTag, Height: height}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
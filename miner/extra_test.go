@@ -0,0 +1,60 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package miner
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+)
+
+func TestExtraTemplate(t *testing.T) {
+	engine := ethash.NewFaker()
+	defer engine.Close()
+
+	w, _ := newTestWorker(t, ethashChainConfig, engine, rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+
+	// Invalid templates or ones growing beyond the extra data limit are rejected
+	if _, err := newExtraTemplate("{{.Missing}}", "rig", "pool"); err == nil {
+		t.Error("template with unknown variable accepted")
+	}
+	if _, err := newExtraTemplate("{{.PoolTag}}/{{.Height}}", "rig", strings.Repeat("x", 20)); err == nil {
+		t.Error("template exceeding the extra data limit accepted")
+	}
+	tmpl, err := newExtraTemplate("{{.PoolTag}}/{{.WorkerID}}/{{.Height}}", "rig", "pool")
+	if err != nil {
+		t.Fatalf("failed to parse template: %v", err)
+	}
+	w.setExtraTemplate(tmpl)
+
+	env, err := w.prepareWork(&generateParams{timestamp: uint64(time.Now().Unix())})
+	if err != nil {
+		t.Fatalf("failed to prepare work: %v", err)
+	}
+	if have, want := string(env.header.Extra), "pool/rig/1"; have != want {
+		t.Errorf("extra mismatch: have %q, want %q", have, want)
+	}
+	// Plain extra data replaces the template
+	w.setExtra([]byte("plain"))
+	env, err = w.prepareWork(&generateParams{timestamp: uint64(time.Now().Unix())})
+	if err != nil {
+		t.Fatalf("failed to prepare work: %v", err)
+	}
+	if have, want := string(env.header.Extra), "plain"; have != want {
+		t.Errorf("extra mismatch: have %q, want %q", have, want)
+	}
+}
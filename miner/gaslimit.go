@@ -0,0 +1,46 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package miner
+
+import (
+	"math/big"
+)
+
+// gasTarget ramps the desired gas limit linearly from the chain head's toward
+// a target over a number of blocks. The proposed limits still only move by the
+// protocol's 1/1024 bound per block, so short ramps may take longer.
+type gasTarget struct {
+	from   uint64 // Gas limit of the chain head when the target was set
+	start  uint64 // Number of the chain head when the target was set
+	target uint64 // Gas limit to end up at
+	blocks uint64 // Number of blocks to ramp over
+}
+
+// desired returns the gas limit to strive for in the block at the given height.
+func (t *gasTarget) desired(number uint64) uint64 {
+	if number <= t.start {
+		return t.from
+	}
+	elapsed := number - t.start
+	if elapsed >= t.blocks {
+		return t.target
+	}
+	// Interpolate in big integers, the product may overflow otherwise
+	var (
+		from     = new(big.Int).SetUint64(t.from)
+		distance = new(big.Int).Sub(new(big.Int).SetUint64(t.target), from)
+	)
+	distance.Mul(distance, new(big.Int).SetUint64(elapsed))
+	distance.Quo(distance, new(big.Int).SetUint64(t.blocks))
+	return from.Add(from, distance).Uint64()
+}
@@ -0,0 +1,72 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package miner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+)
+
+func TestGasTargetRamp(t *testing.T) {
+	tests := []struct {
+		target gasTarget
+		number uint64
+		want   uint64
+	}{
+		{gasTarget{from: 1000, start: 10, target: 2000, blocks: 10}, 9, 1000},
+		{gasTarget{from: 1000, start: 10, target: 2000, blocks: 10}, 10, 1000},
+		{gasTarget{from: 1000, start: 10, target: 2000, blocks: 10}, 13, 1300},
+		{gasTarget{from: 1000, start: 10, target: 2000, blocks: 10}, 20, 2000},
+		{gasTarget{from: 1000, start: 10, target: 2000, blocks: 10}, 100, 2000},
+		{gasTarget{from: 2000, start: 10, target: 1000, blocks: 4}, 11, 1750},
+		{gasTarget{from: 2000, start: 10, target: 1000, blocks: 0}, 11, 1000},
+	}
+	for i, tt := range tests {
+		if have := tt.target.desired(tt.number); have != tt.want {
+			t.Errorf("test %d: desired gas limit mismatch: have %d, want %d", i, have, tt.want)
+		}
+	}
+}
+
+func TestSetGasTarget(t *testing.T) {
+	engine := ethash.NewFaker()
+	defer engine.Close()
+
+	w, _ := newTestWorker(t, ethashChainConfig, engine, rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+
+	head := w.chain.CurrentBlock()
+	w.setGasTarget(head.GasLimit*2, 1000)
+
+	env, err := w.prepareWork(&generateParams{timestamp: uint64(time.Now().Unix())})
+	if err != nil {
+		t.Fatalf("failed to prepare work: %v", err)
+	}
+	want := core.CalcGasLimit(head.GasLimit, head.GasLimit+head.GasLimit/1000)
+	if env.header.GasLimit != want {
+		t.Errorf("gas limit mismatch: have %d, want %d", env.header.GasLimit, want)
+	}
+	// Setting a gas ceiling drops the ramp
+	w.setGasCeil(testConfig.GasCeil)
+	env, err = w.prepareWork(&generateParams{timestamp: uint64(time.Now().Unix())})
+	if err != nil {
+		t.Fatalf("failed to prepare work: %v", err)
+	}
+	if want := core.CalcGasLimit(head.GasLimit, testConfig.GasCeil); env.header.GasLimit != want {
+		t.Errorf("gas limit mismatch: have %d, want %d", env.header.GasLimit, want)
+	}
+}
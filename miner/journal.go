@@ -0,0 +1,233 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package miner
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// templateJournalSize is the number of recent block template constructions
+// retained for replay, covering a few minutes at the default recommit interval.
+const templateJournalSize = 128
+
+var errTemplateNotJournaled = errors.New("block template not journaled")
+
+// txCandidates are the transactions considered for a block template, by the
+// phase they are included in.
+type txCandidates struct {
+	bundles  []*Bundle
+	priority map[common.Address]types.Transactions
+	locals   map[common.Address]types.Transactions
+	remotes  map[common.Address]types.Transactions
+}
+
+// copy returns a copy of the candidates safe to be included, as including the
+// transactions consumes the maps. The transactions themselves are immutable.
+func (c *txCandidates) copy() *txCandidates {
+	copyTxs := func(txs map[common.Address]types.Transactions) map[common.Address]types.Transactions {
+		cpy := make(map[common.Address]types.Transactions, len(txs))
+		for addr, list := range txs {
+			cpy[addr] = list
+		}
+		return cpy
+	}
+	return &txCandidates{
+		bundles:  c.bundles,
+		priority: copyTxs(c.priority),
+		locals:   copyTxs(c.locals),
+		remotes:  copyTxs(c.remotes),
+	}
+}
+
+// hash returns the hash identifying the set of candidate transactions, the
+// keccak of their sorted hashes.
+func (c *txCandidates) hash() common.Hash {
+	var hashes []common.Hash
+	for _, bundle := range c.bundles {
+		for _, tx := range bundle.Txs {
+			hashes = append(hashes, tx.Hash())
+		}
+	}
+	for _, txs := range []map[common.Address]types.Transactions{c.priority, c.locals, c.remotes} {
+		for _, list := range txs {
+			for _, tx := range list {
+				hashes = append(hashes, tx.Hash())
+			}
+		}
+	}
+	sort.Slice(hashes, func(i, j int) bool {
+		return bytes.Compare(hashes[i][:], hashes[j][:]) < 0
+	})
+	hasher := crypto.NewKeccakState()
+	for _, hash := range hashes {
+		hasher.Write(hash[:])
+	}
+	var set common.Hash
+	hasher.Read(set[:])
+	return set
+}
+
+// journaledTemplate are the inputs and the outcome of a block template
+// construction. Transactions carry the time they were first seen, so including
+// them again breaks price ties the same way.
+type journaledTemplate struct {
+	header     *types.Header // Header as prepared, before including transactions
+	uncles     []*types.Header
+	coinbase   common.Address
+	candidates *txCandidates
+	txSet      common.Hash
+	txLimit    int // Number of transactions the interrupted assembly included, zero if complete
+
+	template *BlockTemplate // External template selected over the local assembly, nil if none
+	provider string
+
+	txs    []common.Hash // Transactions of the constructed template, in order
+	profit *big.Int      // Coinbase profit reported for the constructed template
+}
+
+// templateJournal is the ring of the recent block template constructions.
+type templateJournal struct {
+	lock    sync.Mutex
+	entries []*journaledTemplate // Oldest first
+}
+
+// add records a template construction, dropping the oldest above the limit.
+func (j *templateJournal) add(entry *journaledTemplate) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	if len(j.entries) == templateJournalSize {
+		j.entries[0] = nil
+		j.entries = j.entries[1:]
+	}
+	j.entries = append(j.entries, entry)
+}
+
+// find returns the latest template construction on top of a parent at a
+// timestamp from a set of candidate transactions, nil if not journaled.
+func (j *templateJournal) find(parent common.Hash, timestamp uint64, txSet common.Hash) *journaledTemplate {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	for i := len(j.entries) - 1; i >= 0; i-- {
+		entry := j.entries[i]
+		if entry.header.ParentHash == parent && entry.header.Time == timestamp && entry.txSet == txSet {
+			return entry
+		}
+	}
+	return nil
+}
+
+// TemplateRebuild is the outcome of replaying a journaled block template
+// construction, along with the figures reported when it was constructed.
+type TemplateRebuild struct {
+	Header       *types.Header  // Header of the rebuilt template, without state root
+	Coinbase     common.Address // Fee recipient the profit is credited to
+	Source       string         // "local", or the name of the external template provider
+	Interrupted  bool           // Whether the assembly was interrupted, limiting the transactions
+	Transactions []common.Hash  // Transactions of the rebuilt template, in order
+	Profit       *big.Int       // Coinbase profit of the rebuilt template
+
+	ReportedTransactions []common.Hash // Transactions of the template as constructed
+	ReportedProfit       *big.Int      // Coinbase profit reported for the template as constructed
+}
+
+// Matches returns whether the rebuilt template reproduces the reported one.
+func (r *TemplateRebuild) Matches() bool {
+	if r.Profit.Cmp(r.ReportedProfit) != 0 || len(r.Transactions) != len(r.ReportedTransactions) {
+		return false
+	}
+	for i, hash := range r.Transactions {
+		if hash != r.ReportedTransactions[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// rebuildTemplate replays the journaled block template construction on top of
+// a parent at a timestamp from a set of candidate transactions: the local
+// assembly, and the external template selected over it if any.
+func (w *worker) rebuildTemplate(parent common.Hash, timestamp uint64, txSet common.Hash) (*TemplateRebuild, error) {
+	entry := w.journal.find(parent, timestamp, txSet)
+	if entry == nil {
+		return nil, errTemplateNotJournaled
+	}
+	local, err := w.replayEnv(entry)
+	if err != nil {
+		return nil, err
+	}
+	defer local.discard()
+
+	local.txLimit = entry.txLimit
+	if err := w.commitCandidates(nil, local, entry.candidates.copy()); err != nil {
+		return nil, err
+	}
+	var (
+		best   = local
+		source = "local"
+	)
+	if entry.template != nil {
+		env, err := w.replayEnv(entry)
+		if err != nil {
+			return nil, err
+		}
+		defer env.discard()
+
+		if err := w.commitTemplate(env, entry.template); err != nil {
+			return nil, err
+		}
+		if env.profit().Cmp(local.profit()) > 0 {
+			best, source = env, entry.provider
+		}
+	}
+	rebuild := &TemplateRebuild{
+		Header:               types.CopyHeader(best.header),
+		Coinbase:             best.coinbase,
+		Source:               source,
+		Interrupted:          entry.txLimit > 0,
+		Transactions:         make([]common.Hash, len(best.txs)),
+		Profit:               best.profit(),
+		ReportedTransactions: entry.txs,
+		ReportedProfit:       new(big.Int).Set(entry.profit),
+	}
+	for i, tx := range best.txs {
+		rebuild.Transactions[i] = tx.Hash()
+	}
+	return rebuild, nil
+}
+
+// replayEnv creates the environment of a journaled template construction.
+func (w *worker) replayEnv(entry *journaledTemplate) (*environment, error) {
+	parent := w.chain.GetHeader(entry.header.ParentHash, entry.header.Number.Uint64()-1)
+	if parent == nil {
+		return nil, errors.New("missing parent")
+	}
+	env, err := w.makeEnv(parent, types.CopyHeader(entry.header), entry.coinbase)
+	if err != nil {
+		return nil, err
+	}
+	for _, uncle := range entry.uncles {
+		env.uncles[uncle.Hash()] = uncle
+	}
+	env.replay = true
+	return env, nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package miner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Tests that block template constructions are journaled and replay into the
+// same transaction order and profit, interrupted assemblies included.
+func TestRebuildTemplate(t *testing.T) {
+	engine := ethash.NewFaker()
+	defer engine.Close()
+
+	w, b := newTestWorker(t, ethashChainConfig, engine, rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+
+	w.commitWork(nil, true, time.Now().Unix())
+	if len(w.journal.entries) != 1 {
+		t.Fatalf("journaled constructions mismatch: have %d, want 1", len(w.journal.entries))
+	}
+	var (
+		entry  = w.journal.entries[0]
+		parent = b.chain.CurrentBlock().Hash()
+	)
+	rebuild, err := w.rebuildTemplate(parent, entry.header.Time, entry.txSet)
+	if err != nil {
+		t.Fatalf("failed to rebuild template: %v", err)
+	}
+	if !rebuild.Matches() || rebuild.Source != "local" || rebuild.Interrupted {
+		t.Errorf("rebuild mismatch: %+v", rebuild)
+	}
+	if len(rebuild.Transactions) != 1 || rebuild.Transactions[0] != pendingTxs[0].Hash() || rebuild.Profit.Sign() <= 0 {
+		t.Errorf("rebuilt template mismatch: txs %v, profit %v", rebuild.Transactions, rebuild.Profit)
+	}
+	if _, err := w.rebuildTemplate(parent, entry.header.Time+1, entry.txSet); err != errTemplateNotJournaled {
+		t.Errorf("unjournaled template error mismatch: have %v, want %v", err, errTemplateNotJournaled)
+	}
+	// An interrupted assembly is replayed up to the interruption only
+	candidates := &txCandidates{locals: map[common.Address]types.Transactions{testBankAddress: {pendingTxs[0], newTxs[0]}}}
+	interrupted := *entry
+	interrupted.candidates, interrupted.txSet, interrupted.txLimit = candidates, candidates.hash(), 1
+	w.journal.add(&interrupted)
+
+	rebuild, err = w.rebuildTemplate(parent, entry.header.Time, interrupted.txSet)
+	if err != nil {
+		t.Fatalf("failed to rebuild interrupted template: %v", err)
+	}
+	if !rebuild.Matches() || !rebuild.Interrupted || len(rebuild.Transactions) != 1 {
+		t.Errorf("interrupted rebuild mismatch: %+v", rebuild)
+	}
+	complete := interrupted
+	complete.txLimit = 0
+	w.journal.add(&complete)
+
+	rebuild, err = w.rebuildTemplate(parent, entry.header.Time, interrupted.txSet)
+	if err != nil {
+		t.Fatalf("failed to rebuild complete template: %v", err)
+	}
+	if rebuild.Matches() || len(rebuild.Transactions) != 2 {
+		t.Errorf("complete rebuild mismatch: %+v", rebuild)
+	}
+	// The replays consume copies, the journaled candidates are left intact
+	if txs := candidates.locals[testBankAddress]; len(txs) != 2 {
+		t.Errorf("journaled candidates consumed: have %d, want 2", len(txs))
+	}
+}
+
+// Tests that the candidate set hash doesn't depend on the inclusion phase or
+// the order of the transactions.
+func TestTxCandidatesHash(t *testing.T) {
+	var (
+		a = &txCandidates{locals: map[common.Address]types.Transactions{testBankAddress: {pendingTxs[0], newTxs[0]}}}
+		b = &txCandidates{
+			bundles: []*Bundle{{Txs: types.Transactions{newTxs[0]}}},
+			remotes: map[common.Address]types.Transactions{testBankAddress: {pendingTxs[0]}},
+		}
+		c = &txCandidates{locals: map[common.Address]types.Transactions{testBankAddress: {pendingTxs[0]}}}
+	)
+	if a.hash() != b.hash() {
+		t.Errorf("equal sets hash differently")
+	}
+	if a.hash() == c.hash() {
+		t.Errorf("different sets hash equally")
+	}
+}
@@ -0,0 +1,383 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package miner
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	// leaseGrace is the time after the end of a lease during which solutions of
+	// its work are still credited to it, covering work handed out before the
+	// block template switched back.
+	leaseGrace = time.Minute
+
+	// maxLeasesRetained is the number of finished leases kept for settlement.
+	maxLeasesRetained = 256
+)
+
+var (
+	errLeaseCoinbase = errors.New("lease without coinbase")
+	errLeaseDuration = errors.New("lease without duration")
+	errUnknownLease  = errors.New("unknown lease")
+	errLeaseFinished = errors.New("lease already finished")
+
+	// gigahash is the unit of work lease prices are quoted in.
+	gigahash = big.NewInt(1_000_000_000)
+)
+
+// LeaseTerms are the payment terms of a lease.
+type LeaseTerms struct {
+	Price *hexutil.Big `json:"price"` // Wei per gigahash of delivered work
+	Fee   *hexutil.Big `json:"fee"`   // Flat wei for the whole lease
+}
+
+// LeaseBlock is a block sealed for the coinbase of a lease.
+type LeaseBlock struct {
+	Number     hexutil.Uint64 `json:"number"`
+	Hash       common.Hash    `json:"hash"`
+	Difficulty *hexutil.Big   `json:"difficulty"`
+}
+
+// Lease is the record of a lease of the sealing work to an external coinbase,
+// along with the work delivered under it.
+type Lease struct {
+	ID        hexutil.Uint64 `json:"id"`
+	Coinbase  common.Address `json:"coinbase"`
+	Start     hexutil.Uint64 `json:"start"` // Unix timestamp
+	End       hexutil.Uint64 `json:"end"`   // Unix timestamp
+	Cancelled bool           `json:"cancelled"`
+	Terms     LeaseTerms     `json:"terms"`
+
+	Shares hexutil.Uint64 `json:"shares"` // Valid solutions submitted for the leased work
+	Work   *hexutil.Big   `json:"work"`   // Summed difficulty of the solutions
+	Blocks []LeaseBlock   `json:"blocks"` // Blocks sealed for the coinbase
+	Due    *hexutil.Big   `json:"due"`    // Payment owed for the delivered work
+}
+
+// lease is a scheduled period of sealing for an external coinbase.
+type lease struct {
+	id         uint64
+	coinbase   common.Address
+	start, end time.Time
+	cancelled  bool
+	price, fee *big.Int
+
+	shares uint64
+	work   *big.Int
+	blocks []LeaseBlock
+}
+
+// export converts the lease into its RPC representation.
+func (l *lease) export() *Lease {
+	due := new(big.Int).Mul(l.price, l.work)
+	due.Div(due, gigahash)
+	due.Add(due, l.fee)
+
+	return &Lease{
+		ID:        hexutil.Uint64(l.id),
+		Coinbase:  l.coinbase,
+		Start:     hexutil.Uint64(l.start.Unix()),
+		End:       hexutil.Uint64(l.end.Unix()),
+		Cancelled: l.cancelled,
+		Terms: LeaseTerms{
+			Price: (*hexutil.Big)(new(big.Int).Set(l.price)),
+			Fee:   (*hexutil.Big)(new(big.Int).Set(l.fee)),
+		},
+		Shares: hexutil.Uint64(l.shares),
+		Work:   (*hexutil.Big)(new(big.Int).Set(l.work)),
+		Blocks: append([]LeaseBlock{}, l.blocks...),
+		Due:    (*hexutil.Big)(due),
+	}
+}
+
+// leaseBook schedules the leases of the sealing work back to back and credits
+// the solutions delivered for them. Leases are only kept in memory.
+type leaseBook struct {
+	lock    sync.Mutex
+	leases  []*lease // Leases ordered by start time
+	nextID  uint64
+	updated chan struct{} // Notification of schedule changes
+}
+
+func newLeaseBook() *leaseBook {
+	return &leaseBook{
+		nextID:  1,
+		updated: make(chan struct{}, 1),
+	}
+}
+
+// create schedules a lease of the given duration, starting as soon as all the
+// leases scheduled before it ended.
+func (b *leaseBook) create(coinbase common.Address, duration time.Duration, terms LeaseTerms, now time.Time) (*Lease, error) {
+	if coinbase == (common.Address{}) {
+		return nil, errLeaseCoinbase
+	}
+	if duration < time.Second {
+		return nil, errLeaseDuration
+	}
+	l := &lease{
+		coinbase: coinbase,
+		start:    now.Truncate(time.Second),
+		price:    new(big.Int),
+		fee:      new(big.Int),
+		work:     new(big.Int),
+	}
+	if terms.Price != nil {
+		l.price.Set(terms.Price.ToInt())
+	}
+	if terms.Fee != nil {
+		l.fee.Set(terms.Fee.ToInt())
+	}
+	if l.price.Sign() < 0 || l.fee.Sign() < 0 {
+		return nil, errors.New("negative lease payment")
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if n := len(b.leases); n > 0 && b.leases[n-1].end.After(l.start) {
+		l.start = b.leases[n-1].end
+	}
+	l.end = l.start.Add(duration.Truncate(time.Second))
+	l.id = b.nextID
+	b.nextID++
+	b.leases = append(b.leases, l)
+	b.prune(now)
+	b.notify()
+
+	log.Info("Scheduled sealing lease", "id", l.id, "coinbase", l.coinbase, "start", l.start, "end", l.end)
+	return l.export(), nil
+}
+
+// cancel ends an active lease immediately, or drops a scheduled one before it
+// started. The leases scheduled after it keep their periods.
+func (b *leaseBook) cancel(id uint64, now time.Time) (*Lease, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	l := b.lookup(id)
+	if l == nil {
+		return nil, errUnknownLease
+	}
+	if !l.end.After(now) {
+		return nil, errLeaseFinished
+	}
+	if l.start.After(now) {
+		l.end = l.start
+	} else {
+		l.end = now
+	}
+	l.cancelled = true
+	b.notify()
+
+	log.Info("Cancelled sealing lease", "id", l.id, "coinbase", l.coinbase)
+	return l.export(), nil
+}
+
+// get retrieves the lease with the given id.
+func (b *leaseBook) get(id uint64) (*Lease, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	l := b.lookup(id)
+	if l == nil {
+		return nil, errUnknownLease
+	}
+	return l.export(), nil
+}
+
+// list retrieves all the retained leases, ordered by their start.
+func (b *leaseBook) list() []*Lease {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	leases := make([]*Lease, 0, len(b.leases))
+	for _, l := range b.leases {
+		leases = append(leases, l.export())
+	}
+	return leases
+}
+
+// coinbase returns the coinbase of the lease active at the given time, or the
+// fallback if none is.
+func (b *leaseBook) coinbase(now time.Time, fallback common.Address) common.Address {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	for _, l := range b.leases {
+		if !l.start.After(now) && l.end.After(now) {
+			return l.coinbase
+		}
+	}
+	return fallback
+}
+
+// next returns the next time a lease starts or ends after the given one, zero
+// if there's none.
+func (b *leaseBook) next(now time.Time) time.Time {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	var next time.Time
+	for _, l := range b.leases {
+		for _, t := range []time.Time{l.start, l.end} {
+			if t.After(now) && (next.IsZero() || t.Before(next)) {
+				next = t
+			}
+		}
+	}
+	return next
+}
+
+// creditShare credits a valid solution to the lease its work was made for.
+func (b *leaseBook) creditShare(ev ethash.ShareEvent, now time.Time) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if l := b.crediting(ev.Coinbase, now); l != nil && ev.Difficulty != nil {
+		l.shares++
+		l.work.Add(l.work, ev.Difficulty)
+	}
+}
+
+// creditBlock records a block sealed for the coinbase of a lease.
+func (b *leaseBook) creditBlock(block *types.Block, now time.Time) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if l := b.crediting(block.Coinbase(), now); l != nil {
+		l.blocks = append(l.blocks, LeaseBlock{
+			Number:     hexutil.Uint64(block.NumberU64()),
+			Hash:       block.Hash(),
+			Difficulty: (*hexutil.Big)(block.Difficulty()),
+		})
+	}
+}
+
+// crediting returns the latest started lease of the coinbase which is active or
+// ended within the grace period. The lock must be held.
+func (b *leaseBook) crediting(coinbase common.Address, now time.Time) *lease {
+	for i := len(b.leases) - 1; i >= 0; i-- {
+		l := b.leases[i]
+		if l.coinbase != coinbase || l.start.After(now) || l.start.Equal(l.end) {
+			continue
+		}
+		if now.Before(l.end.Add(leaseGrace)) {
+			return l
+		}
+		return nil
+	}
+	return nil
+}
+
+// lookup finds a lease by id. The lock must be held.
+func (b *leaseBook) lookup(id uint64) *lease {
+	i := sort.Search(len(b.leases), func(i int) bool { return b.leases[i].id >= id })
+	if i < len(b.leases) && b.leases[i].id == id {
+		return b.leases[i]
+	}
+	return nil
+}
+
+// prune drops the oldest finished leases beyond the retention limit. The lock
+// must be held.
+func (b *leaseBook) prune(now time.Time) {
+	var finished int
+	for _, l := range b.leases {
+		if now.Before(l.end.Add(leaseGrace)) {
+			break
+		}
+		finished++
+	}
+	if drop := finished - maxLeasesRetained; drop > 0 {
+		b.leases = append(b.leases[:0], b.leases[drop:]...)
+	}
+}
+
+// notify signals a schedule change to the lease loop. The lock must be held.
+func (b *leaseBook) notify() {
+	select {
+	case b.updated <- struct{}{}:
+	default:
+	}
+}
+
+// leaseLoop regenerates the sealing work whenever a lease starts or ends, or the
+// schedule changes, and credits the solutions submitted to the remote sealer to
+// the leases.
+func (w *worker) leaseLoop() {
+	defer w.wg.Done()
+
+	var (
+		shares = make(chan ethash.ShareEvent, 64)
+		sub    event.Subscription
+	)
+	engine := w.engine
+	if wrapper, ok := engine.(interface{ InnerEngine() consensus.Engine }); ok {
+		engine = wrapper.InnerEngine()
+	}
+	if source, ok := engine.(interface {
+		SubscribeShareEvent(ch chan<- ethash.ShareEvent) event.Subscription
+	}); ok {
+		sub = source.SubscribeShareEvent(shares)
+		defer sub.Unsubscribe()
+	}
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	<-timer.C // discard the initial tick
+
+	// refresh regenerates the work for the current lease, arming the timer for
+	// the next lease change
+	refresh := func() {
+		select {
+		case w.startCh <- struct{}{}:
+		default:
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		if next := w.leases.next(time.Now()); !next.IsZero() {
+			timer.Reset(time.Until(next))
+		}
+	}
+	for {
+		select {
+		case <-w.leases.updated:
+			refresh()
+
+		case <-timer.C:
+			refresh()
+
+		case ev := <-shares:
+			w.leases.creditShare(ev, time.Now())
+
+		case <-w.exitCh:
+			return
+		}
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package miner
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestLeaseSchedule(t *testing.T) {
+	var (
+		book     = newLeaseBook()
+		now      = time.Unix(1000, 0)
+		own      = common.Address{0x01}
+		renterA  = common.Address{0xaa}
+		renterB  = common.Address{0xbb}
+		terms    = LeaseTerms{Price: (*hexutil.Big)(big.NewInt(3)), Fee: (*hexutil.Big)(big.NewInt(100))}
+		coinbase = func(at int64) common.Address { return book.coinbase(time.Unix(at, 0), own) }
+	)
+	if _, err := book.create(common.Address{}, time.Minute, terms, now); err != errLeaseCoinbase {
+		t.Errorf("lease without coinbase error mismatch: have %v, want %v", err, errLeaseCoinbase)
+	}
+	if _, err := book.create(renterA, 0, terms, now); err != errLeaseDuration {
+		t.Errorf("lease without duration error mismatch: have %v, want %v", err, errLeaseDuration)
+	}
+	a, err := book.create(renterA, time.Minute, terms, now)
+	if err != nil || a.Start != 1000 || a.End != 1060 {
+		t.Fatalf("first lease mismatch: have %+v, %v", a, err)
+	}
+	// Leases are scheduled back to back
+	b, err := book.create(renterB, time.Minute, LeaseTerms{}, now)
+	if err != nil || b.Start != 1060 || b.End != 1120 {
+		t.Fatalf("second lease mismatch: have %+v, %v", b, err)
+	}
+	for at, want := range map[int64]common.Address{999: own, 1000: renterA, 1059: renterA, 1060: renterB, 1119: renterB, 1120: own} {
+		if have := coinbase(at); have != want {
+			t.Errorf("coinbase at %d mismatch: have %x, want %x", at, have, want)
+		}
+	}
+	if next := book.next(time.Unix(1000, 0)); next.Unix() != 1060 {
+		t.Errorf("next change mismatch: have %d, want 1060", next.Unix())
+	}
+	if next := book.next(time.Unix(1120, 0)); !next.IsZero() {
+		t.Errorf("change scheduled after the last lease: %v", next)
+	}
+	// Cancelling the active lease ends it, the scheduled one is kept as is
+	if _, err := book.cancel(uint64(a.ID), time.Unix(1030, 0)); err != nil {
+		t.Fatalf("failed to cancel lease: %v", err)
+	}
+	if have := coinbase(1030); have != own {
+		t.Errorf("coinbase after cancel mismatch: have %x, want %x", have, own)
+	}
+	if have := coinbase(1060); have != renterB {
+		t.Errorf("scheduled lease coinbase mismatch: have %x, want %x", have, renterB)
+	}
+	if _, err := book.cancel(uint64(a.ID), time.Unix(1031, 0)); err != errLeaseFinished {
+		t.Errorf("repeated cancel error mismatch: have %v, want %v", err, errLeaseFinished)
+	}
+	// Cancelling a scheduled lease drops its whole period
+	if _, err := book.cancel(uint64(b.ID), time.Unix(1031, 0)); err != nil {
+		t.Fatalf("failed to cancel scheduled lease: %v", err)
+	}
+	if have := coinbase(1060); have != own {
+		t.Errorf("cancelled lease coinbase mismatch: have %x, want %x", have, own)
+	}
+	if _, err := book.get(42); err != errUnknownLease {
+		t.Errorf("unknown lease error mismatch: have %v, want %v", err, errUnknownLease)
+	}
+	if leases := book.list(); len(leases) != 2 || !leases[0].Cancelled || !leases[1].Cancelled {
+		t.Errorf("lease list mismatch: have %+v", leases)
+	}
+}
+
+func TestLeaseSettlement(t *testing.T) {
+	var (
+		book   = newLeaseBook()
+		now    = time.Unix(1000, 0)
+		renter = common.Address{0xaa}
+		terms  = LeaseTerms{Price: (*hexutil.Big)(big.NewInt(5)), Fee: (*hexutil.Big)(big.NewInt(100))}
+	)
+	lease, err := book.create(renter, time.Minute, terms, now)
+	if err != nil {
+		t.Fatalf("failed to create lease: %v", err)
+	}
+	share := func(coinbase common.Address, difficulty int64, at int64) {
+		book.creditShare(ethash.ShareEvent{Coinbase: coinbase, Difficulty: big.NewInt(difficulty)}, time.Unix(at, 0))
+	}
+	share(renter, 1_000_000_000, 999)                // Before the lease
+	share(renter, 2_000_000_000, 1010)               // During the lease
+	share(common.Address{0x01}, 1_000_000_000, 1020) // Work of another coinbase
+	share(renter, 1_000_000_000, 1060+30)            // Within the grace period
+	share(renter, 1_000_000_000, 1060+120)           // Past the grace period
+
+	block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(7), Coinbase: renter, Difficulty: big.NewInt(3_000_000_000)})
+	book.creditBlock(block, time.Unix(1040, 0))
+
+	have, err := book.get(uint64(lease.ID))
+	if err != nil {
+		t.Fatalf("failed to retrieve lease: %v", err)
+	}
+	if have.Shares != 2 || have.Work.ToInt().Int64() != 3_000_000_000 {
+		t.Errorf("delivered work mismatch: have %d shares of %v", have.Shares, have.Work)
+	}
+	if len(have.Blocks) != 1 || have.Blocks[0].Hash != block.Hash() || have.Blocks[0].Number != 7 {
+		t.Errorf("sealed blocks mismatch: have %+v", have.Blocks)
+	}
+	// 3 gigahashes at 5 wei each on top of the flat fee
+	if due := have.Due.ToInt().Int64(); due != 115 {
+		t.Errorf("amount due mismatch: have %d, want %d", due, 115)
+	}
+}
+
+func TestLeasePruning(t *testing.T) {
+	var (
+		book = newLeaseBook()
+		now  = time.Unix(1000, 0)
+	)
+	for i := 0; i < maxLeasesRetained+10; i++ {
+		if _, err := book.create(common.Address{0xaa}, time.Second, LeaseTerms{}, now); err != nil {
+			t.Fatalf("failed to create lease %d: %v", i, err)
+		}
+	}
+	// Creating a lease long after all ended drops the oldest finished ones
+	later := now.Add(time.Hour)
+	if _, err := book.create(common.Address{0xbb}, time.Second, LeaseTerms{}, later); err != nil {
+		t.Fatalf("failed to create lease: %v", err)
+	}
+	leases := book.list()
+	if len(leases) != maxLeasesRetained+1 {
+		t.Fatalf("retained leases mismatch: have %d, want %d", len(leases), maxLeasesRetained+1)
+	}
+	if leases[0].ID != 11 {
+		t.Errorf("oldest retained lease mismatch: have %d, want 11", leases[0].ID)
+	}
+	if _, err := book.get(1); err != errUnknownLease {
+		t.Errorf("pruned lease still retrievable: %v", err)
+	}
+}
+
+// Tests that the sealing work switches to the coinbase of a lease as soon as
+// it's created, and back when it's cancelled.
+func TestLeaseWorkSwitch(t *testing.T) {
+	engine := ethash.NewFaker()
+	defer engine.Close()
+
+	w, _ := newTestWorker(t, ethashChainConfig, engine, rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+
+	coinbases := make(chan common.Address, 64)
+	w.newTaskHook = func(task *task) {
+		select {
+		case coinbases <- task.block.Coinbase():
+		default:
+		}
+	}
+	w.skipSealHook = func(task *task) bool { return true }
+	w.start()
+
+	wait := func(want common.Address) {
+		t.Helper()
+		timeout := time.After(3 * time.Second)
+		for {
+			select {
+			case coinbase := <-coinbases:
+				if coinbase == want {
+					return
+				}
+			case <-timeout:
+				t.Fatalf("no work sealed for %x", want)
+			}
+		}
+	}
+	wait(testBankAddress)
+
+	renter := common.Address{0xaa}
+	lease, err := w.leases.create(renter, time.Hour, LeaseTerms{}, time.Now())
+	if err != nil {
+		t.Fatalf("failed to create lease: %v", err)
+	}
+	wait(renter)
+
+	if _, err := w.leases.cancel(uint64(lease.ID), time.Now()); err != nil {
+		t.Fatalf("failed to cancel lease: %v", err)
+	}
+	wait(testBankAddress)
+}
@@ -41,15 +41,23 @@ type Backend interface {
 
 // Config is the configuration parameters of mining.
 type Config struct {
-	Etherbase  common.Address `toml:",omitempty"` // Public address for block mining rewards
-	Notify     []string       `toml:",omitempty"` // HTTP URL list to be notified of new work packages (only useful in ethash).
-	NotifyFull bool           `toml:",omitempty"` // Notify with pending block headers instead of work packages
-	ExtraData  hexutil.Bytes  `toml:",omitempty"` // Block extra data set by the miner
-	GasFloor   uint64         // Target gas floor for mined blocks.
-	GasCeil    uint64         // Target gas ceiling for mined blocks.
-	GasPrice   *big.Int       // Minimum gas price for mining a transaction
-	Recommit   time.Duration  // The time interval for miner to re-create mining work.
-	Noverify   bool           // Disable remote mining solution verification(only useful in ethash).
+	Etherbase        common.Address `toml:",omitempty"` // Public address for block mining rewards
+	Notify           []string       `toml:",omitempty"` // HTTP URL list to be notified of new work packages (only useful in ethash).
+	NotifyFull       bool           `toml:",omitempty"` // Notify with pending block headers instead of work packages
+	NotifyStructured bool           `toml:",omitempty"` // Notify with versioned envelopes of the typed work and header
+	NotifySecret     string         `toml:",omitempty"` // Secret keying the HMAC signature of the notifications
+	ExtraData        hexutil.Bytes  `toml:",omitempty"` // Block extra data set by the miner, may be a template
+	WorkerID         string         `toml:",omitempty"` // Worker identifier resolved in extra data templates
+	PoolTag          string         `toml:",omitempty"` // Pool tag resolved in extra data templates
+	GasFloor         uint64         // Target gas floor for mined blocks.
+	GasCeil          uint64         // Target gas ceiling for mined blocks.
+	GasPrice         *big.Int       // Minimum gas price for mining a transaction
+	Recommit         time.Duration  // The time interval for miner to re-create mining work.
+	Noverify         bool           // Disable remote mining solution verification(only useful in ethash).
+
+	UnclePolicy UnclePolicy // Selection of the uncles included in mined blocks
+
+	GetWorkUpstreams []string `toml:",omitempty"` // Full nodes getwork is proxied to when following the header chain only
 
 	NewPayloadTimeout time.Duration // The maximum time allowance for creating a new payload
 }
@@ -80,7 +88,7 @@ type Miner struct {
 	wg sync.WaitGroup
 }
 
-func New(eth Backend, config *Config, chainConfig *params.ChainConfig, mux *event.TypeMux, engine consensus.Engine, isLocalBlock func(header *types.Header) bool) *Miner {
+func New(eth Backend, config *Config, mux *event.TypeMux, engine consensus.Engine, isLocalBlock func(header *types.Header) bool) *Miner {
 	miner := &Miner{
 		mux:     mux,
 		eth:     eth,
@@ -88,7 +96,7 @@ func New(eth Backend, config *Config, chainConfig *params.ChainConfig, mux *even
 		exitCh:  make(chan struct{}),
 		startCh: make(chan struct{}),
 		stopCh:  make(chan struct{}),
-		worker:  newWorker(config, chainConfig, engine, eth, mux, isLocalBlock, true),
+		worker:  newWorker(config, engine, eth, mux, isLocalBlock, true),
 	}
 	miner.wg.Add(1)
 	go miner.update()
@@ -183,6 +191,9 @@ func (miner *Miner) Hashrate() uint64 {
 }
 
 func (miner *Miner) SetExtra(extra []byte) error {
+	if IsExtraTemplate(extra) {
+		return miner.SetExtraTemplate(string(extra), miner.worker.config.WorkerID, miner.worker.config.PoolTag)
+	}
 	if uint64(len(extra)) > params.MaximumExtraDataSize {
 		return fmt.Errorf("extra exceeds max length. %d > %v", len(extra), params.MaximumExtraDataSize)
 	}
@@ -190,6 +201,18 @@ func (miner *Miner) SetExtra(extra []byte) error {
 	return nil
 }
 
+// SetExtraTemplate sets a template resolved into the extra data of every
+// assembled block, with the {{.WorkerID}}, {{.PoolTag}} and {{.Height}}
+// variables available.
+func (miner *Miner) SetExtraTemplate(text string, workerID, poolTag string) error {
+	tmpl, err := newExtraTemplate(text, workerID, poolTag)
+	if err != nil {
+		return err
+	}
+	miner.worker.setExtraTemplate(tmpl)
+	return nil
+}
+
 // SetRecommitInterval sets the interval for sealing work resubmitting.
 func (miner *Miner) SetRecommitInterval(interval time.Duration) {
 	miner.worker.setRecommitInterval(interval)
@@ -214,6 +237,13 @@ func (miner *Miner) PendingBlockAndReceipts() (*types.Block, types.Receipts) {
 	return miner.worker.pendingBlockAndReceipts()
 }
 
+// SuggestTip returns the tip needed to be included within the given number of
+// blocks with the given probability, forecast from the inclusion cutoffs of the
+// recently built pending block templates.
+func (miner *Miner) SuggestTip(probability float64, blocks uint64) (*big.Int, error) {
+	return miner.worker.tips.suggest(probability, blocks)
+}
+
 func (miner *Miner) SetEtherbase(addr common.Address) {
 	miner.worker.setEtherbase(addr)
 }
@@ -224,6 +254,67 @@ func (miner *Miner) SetGasCeil(ceil uint64) {
 	miner.worker.setGasCeil(ceil)
 }
 
+// SetGasLimitTarget gradually moves the gas limit toward the target over the
+// given number of blocks, replacing the gas ceiling until it is set again.
+func (miner *Miner) SetGasLimitTarget(target, blocks uint64) error {
+	if target < params.MinGasLimit {
+		return fmt.Errorf("gas limit target below minimum. %d < %v", target, params.MinGasLimit)
+	}
+	miner.worker.setGasTarget(target, blocks)
+	return nil
+}
+
+// SetUnclePolicy sets the policy selecting the uncles included in mined blocks.
+func (miner *Miner) SetUnclePolicy(policy UnclePolicy) error {
+	return miner.worker.setUnclePolicy(policy)
+}
+
+// UnclePolicy returns the policy selecting the uncles included in mined blocks.
+func (miner *Miner) UnclePolicy() UnclePolicy {
+	return miner.worker.getUnclePolicy()
+}
+
+// SetCoinbaseRotation spreads the rewards of the sealed blocks over the given
+// payout addresses, switching the coinbase after every block sealed for the
+// active one according to the policy. Weights are only used by the weighted
+// policy. An empty list of addresses disables the rotation. Leases of the
+// sealing work take precedence over the rotation.
+func (miner *Miner) SetCoinbaseRotation(addresses []common.Address, policy string, weights []uint64) error {
+	return miner.worker.setCoinbaseRotation(addresses, policy, weights)
+}
+
+// CoinbaseRotation returns the status of the coinbase rotation, nil if disabled.
+func (miner *Miner) CoinbaseRotation() *CoinbaseRotation {
+	return miner.worker.rotation.status()
+}
+
+// IsRotatedCoinbase returns whether the address takes part in the coinbase
+// rotation.
+func (miner *Miner) IsRotatedCoinbase(addr common.Address) bool {
+	return miner.worker.rotation.contains(addr)
+}
+
+// CreateLease schedules a lease of the sealing work to the given coinbase for
+// the given duration, starting once the leases scheduled before it ended.
+func (miner *Miner) CreateLease(coinbase common.Address, duration time.Duration, terms LeaseTerms) (*Lease, error) {
+	return miner.worker.leases.create(coinbase, duration, terms, time.Now())
+}
+
+// CancelLease ends an active lease or drops a scheduled one.
+func (miner *Miner) CancelLease(id uint64) (*Lease, error) {
+	return miner.worker.leases.cancel(id, time.Now())
+}
+
+// Lease retrieves a lease along with the work delivered under it.
+func (miner *Miner) Lease(id uint64) (*Lease, error) {
+	return miner.worker.leases.get(id)
+}
+
+// Leases retrieves the scheduled, active and recently finished leases.
+func (miner *Miner) Leases() []*Lease {
+	return miner.worker.leases.list()
+}
+
 // EnablePreseal turns on the preseal mining feature. It's enabled by default.
 // Note this function shouldn't be exposed to API, it's unnecessary for users
 // (miners) to actually know the underlying detail. It's only for outside project
@@ -241,6 +332,89 @@ func (miner *Miner) DisablePreseal() {
 	miner.worker.disablePreseal()
 }
 
+// RegisterTemplateProvider adds an external block builder whose templates are
+// sealed instead of the locally assembled block whenever they pay the coinbase
+// more. Node services can register providers through the Ethereum backend's
+// miner once constructed.
+func (miner *Miner) RegisterTemplateProvider(provider BlockTemplateProvider) {
+	miner.worker.registerTemplateProvider(provider)
+}
+
+// SetPriorityAddresses replaces the set of senders whose transactions are
+// ordered first in block assembly, regardless of their tip.
+func (miner *Miner) SetPriorityAddresses(addrs []common.Address) {
+	miner.worker.setPriorityAddresses(addrs)
+}
+
+// SetPriorityTxs replaces the set of transactions ordered first in block
+// assembly, regardless of their tip.
+func (miner *Miner) SetPriorityTxs(hashes []common.Hash) {
+	miner.worker.setPriorityTxs(hashes)
+}
+
+// SendBundle adds a bundle to the private pool, to be included by this node's
+// miner only in the block it targets.
+func (miner *Miner) SendBundle(bundle *Bundle) error {
+	return miner.worker.bundles.add(bundle, miner.eth.BlockChain().CurrentBlock().Number.Uint64())
+}
+
+// CallBundle simulates a bundle on top of the chain head in a block with the
+// given timestamp, without adding it to the private pool.
+func (miner *Miner) CallBundle(bundle *Bundle, timestamp uint64) (*BundleSimulation, error) {
+	return miner.worker.simulateBundle(bundle, timestamp)
+}
+
+// SimulateBlock executes the given transactions as a hypothetical block on top
+// of a parent, evaluating the coinbase profit the way block templates are.
+func (miner *Miner) SimulateBlock(txs types.Transactions, params *SimulationParams) (*BlockSimulation, error) {
+	return miner.worker.simulateBlock(txs, params)
+}
+
+// RebuildBlockTemplate replays the journaled construction of the block template
+// on top of a parent at a timestamp from a set of candidate transactions, for
+// its transaction order and coinbase profit to be audited.
+func (miner *Miner) RebuildBlockTemplate(parent common.Hash, timestamp uint64, txSet common.Hash) (*TemplateRebuild, error) {
+	return miner.worker.rebuildTemplate(parent, timestamp, txSet)
+}
+
+// SealingEvent is posted when the broadcast of a sealed block becomes expected
+// and when it's over, so that latency insensitive work can back off meanwhile.
+type SealingEvent struct {
+	Broadcast time.Time // Expected broadcast time of the sealed block, zero once done
+}
+
+// SealingWork is the block the miner is sealing, as handed to the consensus
+// engine and the remote sealers.
+type SealingWork struct {
+	Block     *types.Block
+	Receipts  types.Receipts
+	SealHash  common.Hash
+	Fees      *big.Int    // Priority fees paid by the transactions
+	Profit    *big.Int    // Coinbase balance increase by the transactions, fees and direct payments
+	TxSetHash common.Hash // Hash of the candidate transactions, identifying the construction for replay
+	CreatedAt time.Time
+}
+
+// SealingWork returns the block the miner is currently sealing, nil if it is not
+// mining. Unlike the pending block, this is the exact block a found solution
+// seals, external block templates included.
+func (miner *Miner) SealingWork() *SealingWork {
+	return miner.worker.sealingWork()
+}
+
+// UncleCandidates returns the header of the block the miner is sealing and the
+// side blocks it considers as its uncles, including the ones it can't include.
+func (miner *Miner) UncleCandidates() (*types.Header, []UncleCandidate, error) {
+	return miner.worker.uncleCandidates()
+}
+
+// SubscribeSealingEvent starts delivering the sealing events of the miner to the
+// given channel. Blocks are expected to be broadcast as soon as sealed, unless
+// the engine holds them until their timestamp.
+func (miner *Miner) SubscribeSealingEvent(ch chan<- SealingEvent) event.Subscription {
+	return miner.worker.sealingFeed.Subscribe(ch)
+}
+
 // SubscribePendingLogs starts delivering logs from pending transactions
 // to the given channel.
 func (miner *Miner) SubscribePendingLogs(ch chan<- []*types.Log) event.Subscription {
@@ -0,0 +1,248 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package miner
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var (
+	errTemplateEmpty      = errors.New("template has no transactions")
+	errTemplateOverclaims = errors.New("template pays less than claimed")
+)
+
+// BlockTemplate is a block body proposed by an external builder, to be sealed
+// on top of the parent it was requested for.
+type BlockTemplate struct {
+	Transactions types.Transactions // Transactions in inclusion order
+	Profit       *big.Int           // Coinbase profit claimed by the builder, nil if unclaimed
+}
+
+// BlockTemplateProvider is an external block builder (à la MEV-Boost) proposing
+// full block templates to the sealing pipeline. Templates are competing with
+// the locally assembled block and the one paying the coinbase most is sealed.
+type BlockTemplateProvider interface {
+	// Name identifies the provider in logs.
+	Name() string
+
+	// BlockTemplate returns the provider's best template on top of the given
+	// parent paying the given coinbase, or nil if it has none. It is called on
+	// the sealing path and should return promptly.
+	BlockTemplate(parent *types.Header, coinbase common.Address) (*BlockTemplate, error)
+}
+
+// registerTemplateProvider adds an external block builder to consult whenever
+// new sealing work is generated.
+func (w *worker) registerTemplateProvider(provider BlockTemplateProvider) {
+	w.providersMu.Lock()
+	defer w.providersMu.Unlock()
+
+	w.providers = append(w.providers, provider)
+}
+
+// templateProviders returns the currently registered external block builders.
+func (w *worker) templateProviders() []BlockTemplateProvider {
+	w.providersMu.RLock()
+	defer w.providersMu.RUnlock()
+
+	return append([]BlockTemplateProvider(nil), w.providers...)
+}
+
+// selectTemplate returns the environment paying the coinbase most among the
+// locally filled one and the templates of all registered providers. Templates
+// failing validation are dropped, as are the environments not selected.
+func (w *worker) selectTemplate(local *environment, genParams *generateParams) *environment {
+	providers := w.templateProviders()
+	if len(providers) == 0 {
+		return local
+	}
+	parent := w.chain.GetHeader(local.header.ParentHash, local.header.Number.Uint64()-1)
+	if parent == nil {
+		return local
+	}
+	var (
+		best   = local
+		profit = local.profit()
+		source = "local"
+	)
+	for _, provider := range providers {
+		template, err := provider.BlockTemplate(parent, genParams.coinbase)
+		if err != nil {
+			log.Debug("Failed to retrieve block template", "provider", provider.Name(), "err", err)
+			continue
+		}
+		if template == nil {
+			continue
+		}
+		env, err := w.applyTemplate(genParams, parent, template)
+		if err != nil {
+			log.Warn("Rejected invalid block template", "provider", provider.Name(), "err", err)
+			continue
+		}
+		env.template, env.provider = template, provider.Name()
+		if have := env.profit(); have.Cmp(profit) > 0 {
+			if best != local {
+				best.discard()
+			}
+			best, profit, source = env, have, provider.Name()
+		} else {
+			env.discard()
+		}
+	}
+	if best != local {
+		local.discard()
+		log.Info("Selected external block template", "provider", source, "txs", best.tcount, "profit", profit)
+	}
+	return best
+}
+
+// applyTemplate executes the transactions of a template on top of the given
+// parent, validating that all of them apply and that the template pays the
+// profit its builder claimed.
+func (w *worker) applyTemplate(genParams *generateParams, parent *types.Header, template *BlockTemplate) (*environment, error) {
+	if len(template.Transactions) == 0 {
+		return nil, errTemplateEmpty
+	}
+	params := *genParams
+	params.parentHash = parent.Hash()
+
+	env, err := w.prepareWork(&params)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.commitTemplate(env, template); err != nil {
+		env.discard()
+		return nil, err
+	}
+	if template.Profit != nil && env.profit().Cmp(template.Profit) < 0 {
+		env.discard()
+		return nil, fmt.Errorf("%w: have %v, claimed %v", errTemplateOverclaims, env.profit(), template.Profit)
+	}
+	return env, nil
+}
+
+// commitTemplate applies all transactions of a template to the sealing block,
+// failing if any of them doesn't apply.
+func (w *worker) commitTemplate(env *environment, template *BlockTemplate) error {
+	env.gasPool = new(core.GasPool).AddGas(env.header.GasLimit)
+	for i, tx := range template.Transactions {
+		if tx.Protected() && !w.chainConfig().IsEIP155(env.header.Number) {
+			return fmt.Errorf("transaction %d (%x): replay protection not active", i, tx.Hash())
+		}
+		env.state.SetTxContext(tx.Hash(), env.tcount)
+		if _, err := w.commitTransaction(env, tx); err != nil {
+			return fmt.Errorf("transaction %d (%x): %w", i, tx.Hash(), err)
+		}
+		env.tcount++
+	}
+	return nil
+}
+
+// SimulationParams are the settings of a simulated block. Unset fields default
+// to the values the miner would use when sealing on top of the parent.
+type SimulationParams struct {
+	Parent     common.Hash     // Block to build upon, the chain head if zero
+	Timestamp  uint64          // Block timestamp, the current time if zero
+	Coinbase   *common.Address // Fee recipient, the miner's etherbase if nil
+	GasLimit   *uint64         // Block gas limit
+	BaseFee    *big.Int        // Block base fee, ignored before London
+	Difficulty *big.Int        // Block difficulty
+	Random     common.Hash     // Block randomness, after the merge
+
+	// Tracer optionally returns the tracer of each simulated transaction.
+	Tracer func(index int, tx *types.Transaction) vm.EVMLogger
+}
+
+// BlockSimulation is the outcome of executing a list of transactions as a
+// hypothetical block.
+type BlockSimulation struct {
+	Header        *types.Header  // Header of the simulated block, without state root
+	Receipts      types.Receipts // Receipts of the transactions
+	CoinbaseDiffs []*big.Int     // Coinbase balance increase caused by each transaction
+	Profit        *big.Int       // Coinbase balance increase caused by the block, rewards excluded
+}
+
+// simulateBlock executes the given transactions as a block on top of a parent,
+// evaluating it the same way block templates are. Simulation fails if any of
+// the transactions fails to apply, while reverting transactions are reported
+// in their receipt.
+func (w *worker) simulateBlock(txs types.Transactions, params *SimulationParams) (*BlockSimulation, error) {
+	if len(txs) == 0 {
+		return nil, errTemplateEmpty
+	}
+	genParams := &generateParams{
+		timestamp:  params.Timestamp,
+		forceTime:  params.Timestamp != 0,
+		parentHash: params.Parent,
+		coinbase:   w.etherbase(),
+		random:     params.Random,
+		noUncle:    true,
+	}
+	if genParams.timestamp == 0 {
+		genParams.timestamp = uint64(time.Now().Unix())
+	}
+	if params.Coinbase != nil {
+		genParams.coinbase = *params.Coinbase
+	}
+	env, err := w.prepareWork(genParams)
+	if err != nil {
+		return nil, err
+	}
+	defer env.discard()
+
+	if params.GasLimit != nil {
+		env.header.GasLimit = *params.GasLimit
+	}
+	if params.BaseFee != nil && env.header.BaseFee != nil {
+		env.header.BaseFee = new(big.Int).Set(params.BaseFee)
+	}
+	if params.Difficulty != nil {
+		env.header.Difficulty = new(big.Int).Set(params.Difficulty)
+	}
+	env.gasPool = new(core.GasPool).AddGas(env.header.GasLimit)
+
+	sim := &BlockSimulation{
+		Receipts:      make(types.Receipts, 0, len(txs)),
+		CoinbaseDiffs: make([]*big.Int, 0, len(txs)),
+	}
+	for i, tx := range txs {
+		if tx.Protected() && !w.chainConfig().IsEIP155(env.header.Number) {
+			return nil, fmt.Errorf("transaction %d (%x): replay protection not active", i, tx.Hash())
+		}
+		vmConfig := *w.chain.GetVMConfig()
+		if params.Tracer != nil {
+			vmConfig.Tracer = params.Tracer(i, tx)
+		}
+		balance := env.state.GetBalance(env.coinbase)
+
+		env.state.SetTxContext(tx.Hash(), i)
+		receipt, err := core.ApplyTransaction(w.chainConfig(), w.chain, &env.coinbase, env.gasPool, env.state, env.header, tx, &env.header.GasUsed, vmConfig)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d (%x): %w", i, tx.Hash(), err)
+		}
+		sim.Receipts = append(sim.Receipts, receipt)
+		sim.CoinbaseDiffs = append(sim.CoinbaseDiffs, new(big.Int).Sub(env.state.GetBalance(env.coinbase), balance))
+	}
+	sim.Header = types.CopyHeader(env.header)
+	sim.Profit = env.profit()
+	return sim, nil
+}
@@ -0,0 +1,157 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package miner
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers/logger"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// testTemplateProvider is a block builder always proposing the same template.
+type testTemplateProvider struct {
+	name     string
+	template *BlockTemplate
+}
+
+func (p *testTemplateProvider) Name() string { return p.name }
+
+func (p *testTemplateProvider) BlockTemplate(parent *types.Header, coinbase common.Address) (*BlockTemplate, error) {
+	return p.template, nil
+}
+
+// Tests that the most profitable valid template is selected over the locally
+// assembled block, while invalid and overclaiming templates are rejected.
+func TestSelectTemplate(t *testing.T) {
+	engine := ethash.NewFaker()
+	defer engine.Close()
+
+	w, _ := newTestWorker(t, ethashChainConfig, engine, rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+
+	var (
+		coinbase  = common.Address{0xcb}
+		genParams = &generateParams{timestamp: uint64(time.Now().Unix()), coinbase: coinbase}
+		signer    = types.LatestSigner(ethashChainConfig)
+	)
+	transfer := func(nonce uint64, value int64, price int64) *types.Transaction {
+		return types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{
+			Nonce:    nonce,
+			To:       &testUserAddress,
+			Value:    big.NewInt(value),
+			Gas:      params.TxGas,
+			GasPrice: big.NewInt(price),
+		})
+	}
+	rich := transfer(0, 5000, 10*params.InitialBaseFee)
+	w.registerTemplateProvider(&testTemplateProvider{name: "gapped", template: &BlockTemplate{Transactions: types.Transactions{transfer(5, 1, 100*params.InitialBaseFee)}}})
+	w.registerTemplateProvider(&testTemplateProvider{name: "liar", template: &BlockTemplate{Transactions: types.Transactions{rich}, Profit: big.NewInt(params.Ether)}})
+	w.registerTemplateProvider(&testTemplateProvider{name: "rich", template: &BlockTemplate{Transactions: types.Transactions{rich}}})
+
+	local, err := w.prepareWork(genParams)
+	if err != nil {
+		t.Fatalf("failed to prepare work: %v", err)
+	}
+	if err := w.fillTransactions(nil, local); err != nil {
+		t.Fatalf("failed to fill transactions: %v", err)
+	}
+	if local.tcount != 1 || local.profit().Sign() <= 0 {
+		t.Fatalf("unexpected local work: txs %d, profit %v", local.tcount, local.profit())
+	}
+	best := w.selectTemplate(local, genParams)
+	defer best.discard()
+
+	if best == local {
+		t.Fatal("local work selected over more profitable template")
+	}
+	if balance := best.state.GetBalance(testUserAddress); balance.Cmp(big.NewInt(5000)) != 0 {
+		t.Errorf("selected template mismatch: user balance %v, want 5000", balance)
+	}
+	// Rejections are reported by the validation itself
+	parent := w.chain.CurrentBlock()
+	if _, err := w.applyTemplate(genParams, parent, &BlockTemplate{}); err != errTemplateEmpty {
+		t.Errorf("empty template error mismatch: have %v, want %v", err, errTemplateEmpty)
+	}
+	if _, err := w.applyTemplate(genParams, parent, &BlockTemplate{Transactions: types.Transactions{rich}, Profit: big.NewInt(params.Ether)}); !errors.Is(err, errTemplateOverclaims) {
+		t.Errorf("overclaiming template error mismatch: have %v, want %v", err, errTemplateOverclaims)
+	}
+}
+
+// countingTracer counts the transactions it traced.
+type countingTracer struct {
+	vm.EVMLogger
+	txs *int
+}
+
+func (t *countingTracer) CaptureTxStart(gasLimit uint64) { *t.txs++ }
+
+// Tests that simulating a block reports the receipts and coinbase profit of the
+// transactions with the requested header settings, tracing each of them.
+func TestSimulateBlock(t *testing.T) {
+	engine := ethash.NewFaker()
+	defer engine.Close()
+
+	w, _ := newTestWorker(t, ethashChainConfig, engine, rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+
+	var (
+		coinbase = common.Address{0xcb}
+		gasLimit = uint64(5_000_000)
+		traced   int
+	)
+	simParams := &SimulationParams{
+		Coinbase: &coinbase,
+		GasLimit: &gasLimit,
+		Tracer: func(index int, tx *types.Transaction) vm.EVMLogger {
+			return &countingTracer{EVMLogger: logger.NewStructLogger(nil), txs: &traced}
+		},
+	}
+	sim, err := w.simulateBlock(types.Transactions{bundleTransfer(0, 1000), bundleTransfer(1, 1000)}, simParams)
+	if err != nil {
+		t.Fatalf("failed to simulate block: %v", err)
+	}
+	if sim.Header.Number.Uint64() != 1 || sim.Header.Coinbase != coinbase || sim.Header.GasLimit != gasLimit {
+		t.Errorf("header mismatch: number %d, coinbase %x, gas limit %d", sim.Header.Number, sim.Header.Coinbase, sim.Header.GasLimit)
+	}
+	if len(sim.Receipts) != 2 || sim.Header.GasUsed != 2*params.TxGas || traced != 2 {
+		t.Fatalf("simulation mismatch: receipts %d, gas %d, traced %d", len(sim.Receipts), sim.Header.GasUsed, traced)
+	}
+	sum := new(big.Int)
+	for i, diff := range sim.CoinbaseDiffs {
+		if sim.Receipts[i].Status != types.ReceiptStatusSuccessful || diff.Sign() <= 0 {
+			t.Errorf("transaction %d result mismatch: status %d, coinbase diff %v", i, sim.Receipts[i].Status, diff)
+		}
+		sum.Add(sum, diff)
+	}
+	if sum.Cmp(sim.Profit) != 0 {
+		t.Errorf("profit mismatch: have %v, want %v", sim.Profit, sum)
+	}
+	if head := w.chain.CurrentBlock(); head.Number.Uint64() != 0 {
+		t.Errorf("simulation modified the chain")
+	}
+	if _, err := w.simulateBlock(types.Transactions{bundleTransfer(5, 1)}, &SimulationParams{}); err == nil {
+		t.Error("block with inapplicable transaction simulated")
+	}
+	if _, err := w.simulateBlock(nil, &SimulationParams{}); err != errTemplateEmpty {
+		t.Errorf("empty block error mismatch: have %v, want %v", err, errTemplateEmpty)
+	}
+}
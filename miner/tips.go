@@ -0,0 +1,107 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package miner
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// maxTipSamples is the number of recent pending block heights whose inclusion
+// cutoff is retained for forecasting.
+const maxTipSamples = 1024
+
+var errNoTipSamples = errors.New("no pending block templates sampled yet")
+
+// tipSample is the lowest tip that made it into a pending block template of a
+// height, zero if the template had room left for any transaction.
+type tipSample struct {
+	number uint64
+	cutoff *big.Int
+}
+
+// tipHistory tracks the inclusion cutoffs of the recently built pending block
+// templates. Templates are rebuilt on every recommit, only the latest one of a
+// height is kept so every block weighs the same.
+type tipHistory struct {
+	lock    sync.Mutex
+	samples []tipSample
+}
+
+// record samples the inclusion cutoff of a pending block template. Only the
+// transactions picked by price are considered, bundles, prioritized and local
+// transactions before them are included regardless of their tip.
+func (h *tipHistory) record(env *environment) {
+	cutoff := new(big.Int)
+	if env.gasPool != nil && env.gasPool.Gas() < params.TxGas {
+		market := env.txs[env.marketStart:]
+		if len(market) == 0 {
+			return // Full of unpriced transactions, nothing to learn
+		}
+		cutoff = nil
+		for _, tx := range market {
+			tip := tx.EffectiveGasTipValue(env.header.BaseFee)
+			if cutoff == nil || tip.Cmp(cutoff) < 0 {
+				cutoff = tip
+			}
+		}
+		if cutoff.Sign() < 0 {
+			cutoff = new(big.Int)
+		}
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	number := env.header.Number.Uint64()
+	if n := len(h.samples); n > 0 && h.samples[n-1].number == number {
+		h.samples[n-1].cutoff = cutoff
+		return
+	}
+	h.samples = append(h.samples, tipSample{number: number, cutoff: cutoff})
+	if len(h.samples) > maxTipSamples {
+		h.samples = h.samples[len(h.samples)-maxTipSamples:]
+	}
+}
+
+// suggest returns the tip needed to be included within the given number of
+// blocks with the given probability. Treating blocks as independent draws from
+// the sampled cutoffs, the tip needs to beat a single block's cutoff with the
+// probability 1-(1-p)^(1/blocks), which is the matching quantile of them.
+func (h *tipHistory) suggest(probability float64, blocks uint64) (*big.Int, error) {
+	h.lock.Lock()
+	cutoffs := make([]*big.Int, len(h.samples))
+	for i, sample := range h.samples {
+		cutoffs[i] = sample.cutoff
+	}
+	h.lock.Unlock()
+
+	if len(cutoffs) == 0 {
+		return nil, errNoTipSamples
+	}
+	sort.Slice(cutoffs, func(i, j int) bool { return cutoffs[i].Cmp(cutoffs[j]) < 0 })
+
+	perBlock := 1 - math.Pow(1-probability, 1/float64(blocks))
+	index := int(math.Ceil(perBlock*float64(len(cutoffs)))) - 1
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(cutoffs) {
+		index = len(cutoffs) - 1
+	}
+	return new(big.Int).Set(cutoffs[index]), nil
+}
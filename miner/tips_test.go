@@ -0,0 +1,82 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package miner
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// tipTemplate creates a pending block template of the given height including
+// transactions of the given tips, full unless room is left.
+func tipTemplate(number uint64, full bool, marketStart int, tips ...int64) *environment {
+	env := &environment{
+		header:      &types.Header{Number: new(big.Int).SetUint64(number), BaseFee: big.NewInt(params.GWei)},
+		gasPool:     new(core.GasPool).AddGas(params.TxGas),
+		marketStart: marketStart,
+	}
+	if full {
+		env.gasPool.SubGas(1)
+	}
+	for i, tip := range tips {
+		env.txs = append(env.txs, types.NewTx(&types.DynamicFeeTx{
+			Nonce:     uint64(i),
+			GasTipCap: big.NewInt(tip),
+			GasFeeCap: big.NewInt(params.GWei + tip),
+			Gas:       params.TxGas,
+		}))
+	}
+	return env
+}
+
+func TestTipHistory(t *testing.T) {
+	h := new(tipHistory)
+	if _, err := h.suggest(0.5, 1); err != errNoTipSamples {
+		t.Fatalf("suggestion without samples: have %v, want %v", err, errNoTipSamples)
+	}
+	h.record(tipTemplate(1, false, 0, 5))     // Room left, anything goes
+	h.record(tipTemplate(2, true, 0, 30, 10)) // Replaced by the later template
+	h.record(tipTemplate(2, true, 0, 30, 20))
+	h.record(tipTemplate(3, true, 1, 1, 40)) // Leading unpriced transaction ignored
+	h.record(tipTemplate(4, true, 1, 1))     // Nothing priced, skipped
+
+	if len(h.samples) != 3 {
+		t.Fatalf("sample count mismatch: have %d, want 3", len(h.samples))
+	}
+	tests := []struct {
+		probability float64
+		blocks      uint64
+		want        int64
+	}{
+		{0.3, 1, 0},
+		{0.5, 1, 20},
+		{0.9, 1, 40},
+		{1, 1, 40},
+		{0.9, 3, 20}, // 0.536 per block
+		{0.9, 10, 0}, // 0.206 per block
+		{0.01, 100, 0},
+	}
+	for i, tt := range tests {
+		have, err := h.suggest(tt.probability, tt.blocks)
+		if err != nil {
+			t.Fatalf("test %d: suggestion failed: %v", i, err)
+		}
+		if have.Int64() != tt.want {
+			t.Errorf("test %d: tip mismatch: have %d, want %d", i, have, tt.want)
+		}
+	}
+}
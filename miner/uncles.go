@@ -0,0 +1,171 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package miner
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// maxProtocolUncles is the maximum number of uncles a block may include.
+const maxProtocolUncles = 2
+
+// Uncle inclusion strategies.
+const (
+	UnclesOwnFirst = "own-first" // Include locally mined uncles before remote ones
+	UnclesOwnOnly  = "own-only"  // Include locally mined uncles only
+	UnclesNone     = "none"      // Include no uncles, sparing their processing latency
+)
+
+var (
+	uncleIncludedMeter        = metrics.NewRegisteredMeter("miner/uncles/included", nil)
+	uncleForfeitedMeter       = metrics.NewRegisteredMeter("miner/uncles/forfeited", nil)
+	uncleRewardCapturedMeter  = metrics.NewRegisteredMeter("miner/uncles/reward/captured", nil)  // Gwei
+	uncleRewardForfeitedMeter = metrics.NewRegisteredMeter("miner/uncles/reward/forfeited", nil) // Gwei
+)
+
+// UnclePolicy controls the selection of the uncles included in mined blocks.
+type UnclePolicy struct {
+	Strategy  string `json:"strategy"`  // Inclusion strategy, own-first if empty
+	MaxUncles int    `json:"maxUncles"` // Maximum uncles per block, the protocol's maximum if zero
+}
+
+// validate checks the policy for unknown strategies and impossible caps.
+func (p UnclePolicy) validate() error {
+	switch p.Strategy {
+	case "", UnclesOwnFirst, UnclesOwnOnly, UnclesNone:
+	default:
+		return fmt.Errorf("unknown uncle strategy %q", p.Strategy)
+	}
+	if p.MaxUncles < 0 || p.MaxUncles > maxProtocolUncles {
+		return fmt.Errorf("invalid uncle cap %d, have to be within [0, %d]", p.MaxUncles, maxProtocolUncles)
+	}
+	return nil
+}
+
+// allows returns whether the policy admits another uncle into a block already
+// including the given number of them.
+func (p UnclePolicy) allows(local bool, included int) bool {
+	if p.Strategy == UnclesNone || (p.Strategy == UnclesOwnOnly && !local) {
+		return false
+	}
+	limit := p.MaxUncles
+	if limit == 0 {
+		limit = maxProtocolUncles
+	}
+	return included < limit
+}
+
+// includeUncle adds a side block to the uncles of the sealing block if valid and
+// admitted by the policy, returning whether it was added. Valid uncles for which
+// the block had room but the policy didn't admit are counted as forfeited.
+func (w *worker) includeUncle(env *environment, policy UnclePolicy, uncle *types.Header, local bool) bool {
+	if len(env.uncles)+env.forfeited >= maxProtocolUncles {
+		return false
+	}
+	if err := w.checkUncle(env, uncle); err != nil {
+		log.Trace("Possible uncle rejected", "hash", uncle.Hash(), "reason", err)
+		return false
+	}
+	if !policy.allows(local, len(env.uncles)) {
+		env.forfeited++
+		return false
+	}
+	log.Debug("Committing new uncle to block", "hash", uncle.Hash())
+	env.uncles[uncle.Hash()] = uncle
+	return true
+}
+
+// reportUncles meters the uncles included in a mined block and the ones the
+// uncle policy forfeited, along with the inclusion rewards involved.
+func reportUncles(config *params.ChainConfig, block *types.Block, forfeited int) {
+	included := len(block.Uncles())
+	uncleIncludedMeter.Mark(int64(included))
+	uncleForfeitedMeter.Mark(int64(forfeited))
+
+	if config.Ethash == nil {
+		return
+	}
+	reward := new(big.Int).Div(ethash.UncleInclusionReward(config, block.Number()), big.NewInt(params.GWei))
+	uncleRewardCapturedMeter.Mark(reward.Int64() * int64(included))
+	uncleRewardForfeitedMeter.Mark(reward.Int64() * int64(forfeited))
+}
+
+// UncleCandidate is a side block the miner considers as an uncle of the block it
+// is sealing.
+type UncleCandidate struct {
+	Header   *types.Header
+	Local    bool  // Whether the block was mined locally
+	Included bool  // Whether the sealing block includes the uncle
+	Err      error // Why the sealing block can't include the uncle, nil if it can
+}
+
+// uncleCandidates are the uncle candidates of a sealing block.
+type uncleCandidates struct {
+	header     *types.Header
+	candidates []UncleCandidate
+	err        error
+}
+
+// uncleCandidates returns the header of the current sealing block and the side
+// blocks considered as its uncles.
+func (w *worker) uncleCandidates() (*types.Header, []UncleCandidate, error) {
+	res := make(chan *uncleCandidates, 1)
+	select {
+	case w.uncleCandidatesCh <- res:
+		result := <-res
+		return result.header, result.candidates, result.err
+	case <-w.exitCh:
+		return nil, nil, errors.New("miner closed")
+	}
+}
+
+// collectUncleCandidates checks the known side blocks against the current
+// sealing block, newest first. It must be called from the main loop owning them.
+func (w *worker) collectUncleCandidates() *uncleCandidates {
+	env := w.current
+	if env == nil {
+		return &uncleCandidates{err: errors.New("no sealing block")}
+	}
+	var candidates []UncleCandidate
+	collect := func(blocks map[common.Hash]*types.Block, local bool) {
+		for hash, block := range blocks {
+			candidate := UncleCandidate{Header: block.Header(), Local: local}
+			if _, ok := env.uncles[hash]; ok {
+				candidate.Included = true
+			} else {
+				candidate.Err = w.checkUncle(env, candidate.Header)
+			}
+			candidates = append(candidates, candidate)
+		}
+	}
+	collect(w.localUncles, true)
+	collect(w.remoteUncles, false)
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if n := candidates[i].Header.Number.Cmp(candidates[j].Header.Number); n != 0 {
+			return n > 0
+		}
+		return candidates[i].Header.Hash().Big().Cmp(candidates[j].Header.Hash().Big()) < 0
+	})
+	return &uncleCandidates{header: types.CopyHeader(env.header), candidates: candidates}
+}
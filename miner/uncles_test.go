@@ -0,0 +1,161 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package miner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestUnclePolicy(t *testing.T) {
+	var tests = []struct {
+		policy  UnclePolicy
+		invalid bool
+		local   []bool // Admission of a local uncle into blocks including 0, 1 and 2 uncles
+		remote  []bool // Admission of a remote uncle into blocks including 0, 1 and 2 uncles
+	}{
+		{policy: UnclePolicy{}, local: []bool{true, true, false}, remote: []bool{true, true, false}},
+		{policy: UnclePolicy{Strategy: UnclesOwnFirst, MaxUncles: 1}, local: []bool{true, false, false}, remote: []bool{true, false, false}},
+		{policy: UnclePolicy{Strategy: UnclesOwnOnly}, local: []bool{true, true, false}, remote: []bool{false, false, false}},
+		{policy: UnclePolicy{Strategy: UnclesNone}, local: []bool{false, false, false}, remote: []bool{false, false, false}},
+		{policy: UnclePolicy{Strategy: "greedy"}, invalid: true},
+		{policy: UnclePolicy{MaxUncles: 3}, invalid: true},
+		{policy: UnclePolicy{MaxUncles: -1}, invalid: true},
+	}
+	for i, tt := range tests {
+		if err := tt.policy.validate(); (err != nil) != tt.invalid {
+			t.Errorf("test %d: validation mismatch: have %v, want invalid %v", i, err, tt.invalid)
+		}
+		if tt.invalid {
+			continue
+		}
+		for count := 0; count <= maxProtocolUncles; count++ {
+			if have := tt.policy.allows(true, count); have != tt.local[count] {
+				t.Errorf("test %d: local uncle admission with %d uncles mismatch: have %v, want %v", i, count, have, tt.local[count])
+			}
+			if have := tt.policy.allows(false, count); have != tt.remote[count] {
+				t.Errorf("test %d: remote uncle admission with %d uncles mismatch: have %v, want %v", i, count, have, tt.remote[count])
+			}
+		}
+	}
+}
+
+func TestIncludeUncle(t *testing.T) {
+	engine := ethash.NewFaker()
+	defer engine.Close()
+
+	w, b := newTestWorker(t, ethashChainConfig, engine, rawdb.NewMemoryDatabase(), 1)
+	defer w.close()
+
+	if err := w.setUnclePolicy(UnclePolicy{Strategy: "greedy"}); err == nil {
+		t.Fatalf("invalid uncle policy accepted")
+	}
+	for _, policy := range []UnclePolicy{{}, {Strategy: UnclesOwnOnly}, {Strategy: UnclesNone}} {
+		env, err := w.prepareWork(&generateParams{timestamp: uint64(b.chain.CurrentBlock().Time + 1), noUncle: true})
+		if err != nil {
+			t.Fatalf("failed to prepare work: %v", err)
+		}
+		// The uncle of the test backend is a remote one, only the default policy takes it
+		want := policy.Strategy == ""
+		if have := w.includeUncle(env, policy, b.uncleBlock.Header(), false); have != want {
+			t.Errorf("policy %+v: remote uncle inclusion mismatch: have %v, want %v", policy, have, want)
+		}
+		if want && (len(env.uncles) != 1 || env.forfeited != 0) {
+			t.Errorf("policy %+v: uncles mismatch: have %d included, %d forfeited", policy, len(env.uncles), env.forfeited)
+		}
+		if !want && (len(env.uncles) != 0 || env.forfeited != 1) {
+			t.Errorf("policy %+v: uncles mismatch: have %d included, %d forfeited", policy, len(env.uncles), env.forfeited)
+		}
+		// Invalid uncles are neither included, nor forfeited
+		forfeited := env.forfeited
+		if w.includeUncle(env, UnclePolicy{}, b.chain.CurrentBlock(), false) || env.forfeited != forfeited {
+			t.Errorf("policy %+v: invalid uncle accounted", policy)
+		}
+	}
+}
+
+// Tests that the side blocks known to the worker are reported as uncle
+// candidates of the sealing block, whether it includes them or not.
+func TestUncleCandidates(t *testing.T) {
+	engine := ethash.NewFaker()
+	defer engine.Close()
+
+	w, b := newTestWorker(t, ethashChainConfig, engine, rawdb.NewMemoryDatabase(), 1)
+	defer w.close()
+
+	if _, _, err := w.uncleCandidates(); err == nil {
+		t.Fatalf("uncle candidates reported without a sealing block")
+	}
+	w.skipSealHook = func(task *task) bool { return true }
+	w.setUnclePolicy(UnclePolicy{Strategy: UnclesNone})
+	w.start()
+
+	// candidates waits for the worker to report the given number of candidates
+	candidates := func(n int) (*types.Header, []UncleCandidate) {
+		for i := 0; ; i++ {
+			header, candidates, err := w.uncleCandidates()
+			if err == nil && len(candidates) == n {
+				return header, candidates
+			}
+			if i == 100 {
+				t.Fatalf("candidates not reported: have %d, want %d: %v", len(candidates), n, err)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	header, _ := candidates(0)
+	if header.Number.Uint64() != 2 {
+		t.Fatalf("sealing block number mismatch: have %d, want 2", header.Number)
+	}
+	// A valid side block rejected by the policy is not yet included
+	w.postSideBlock(core.ChainSideEvent{Block: b.uncleBlock})
+	if _, have := candidates(1); have[0].Header.Hash() != b.uncleBlock.Hash() || have[0].Local || have[0].Included || have[0].Err != nil {
+		t.Errorf("pending uncle mismatch: have %x included %v err %v", have[0].Header.Hash(), have[0].Included, have[0].Err)
+	}
+	// A side block already in the chain is reported with the reason it's invalid
+	w.postSideBlock(core.ChainSideEvent{Block: b.chain.GetBlockByNumber(1)})
+	_, have := candidates(2)
+	for _, c := range have {
+		if c.Header.Hash() == b.uncleBlock.Hash() {
+			continue
+		}
+		if c.Included || c.Err == nil {
+			t.Errorf("invalid uncle reported eligible: included %v err %v", c.Included, c.Err)
+		}
+	}
+	// Side blocks admitted by the policy are marked included
+	w.setUnclePolicy(UnclePolicy{})
+	uncle := b.newRandomUncle()
+	w.postSideBlock(core.ChainSideEvent{Block: uncle})
+	for i := 0; ; i++ {
+		_, have := candidates(3)
+		included := false
+		for _, c := range have {
+			if c.Header.Hash() == uncle.Hash() {
+				included = c.Included
+			}
+		}
+		if included {
+			break
+		}
+		if i == 100 {
+			t.Fatalf("admitted uncle not reported included")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
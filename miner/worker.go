@@ -90,11 +90,21 @@ type environment struct {
 	tcount    int                     // tx count in cycle
 	gasPool   *core.GasPool           // available gas used to pack transactions
 	coinbase  common.Address
+	balance   *big.Int // coinbase balance before any transaction was applied
 
 	header   *types.Header
 	txs      []*types.Transaction
 	receipts []*types.Receipt
 	uncles   map[common.Hash]*types.Header
+
+	forfeited   int // valid uncles not included due to the uncle policy
+	marketStart int // index of the first transaction picked by price
+
+	txSet    common.Hash    // hash of the candidate transactions, zero if not filled from the pool
+	txLimit  int            // maximum number of transactions to include, zero if unlimited
+	replay   bool           // whether the environment replays a journaled construction
+	template *BlockTemplate // external template the transactions are from, nil if assembled locally
+	provider string         // name of the provider of the external template
 }
 
 // copy creates a deep copy of environment.
@@ -106,8 +116,17 @@ func (env *environment) copy() *environment {
 		family:    env.family.Clone(),
 		tcount:    env.tcount,
 		coinbase:  env.coinbase,
+		balance:   env.balance,
 		header:    types.CopyHeader(env.header),
 		receipts:  copyReceipts(env.receipts),
+
+		forfeited:   env.forfeited,
+		marketStart: env.marketStart,
+		txSet:       env.txSet,
+		txLimit:     env.txLimit,
+		replay:      env.replay,
+		template:    env.template,
+		provider:    env.provider,
 	}
 	if env.gasPool != nil {
 		gasPool := *env.gasPool
@@ -124,6 +143,12 @@ func (env *environment) copy() *environment {
 	return cpy
 }
 
+// profit returns the coinbase balance increase by the applied transactions,
+// covering both the priority fees and any direct transfers.
+func (env *environment) profit() *big.Int {
+	return new(big.Int).Sub(env.state.GetBalance(env.coinbase), env.balance)
+}
+
 // unclelist returns the contained uncles as the list format.
 func (env *environment) unclelist() []*types.Header {
 	var uncles []*types.Header
@@ -148,6 +173,9 @@ type task struct {
 	receipts  []*types.Receipt
 	state     *state.StateDB
 	block     *types.Block
+	profit    *big.Int    // Coinbase balance increase by the transactions, rewards excluded
+	forfeited int         // Valid uncles not included due to the uncle policy
+	txSet     common.Hash // Hash of the candidate transactions of the block
 	createdAt time.Time
 }
 
@@ -187,14 +215,14 @@ type intervalAdjust struct {
 // worker is the main object which takes care of submitting new work to consensus engine
 // and gathering the sealing result.
 type worker struct {
-	config      *Config
-	chainConfig *params.ChainConfig
-	engine      consensus.Engine
-	eth         Backend
-	chain       *core.BlockChain
+	config *Config
+	engine consensus.Engine
+	eth    Backend
+	chain  *core.BlockChain
 
 	// Feeds
 	pendingLogsFeed event.Feed
+	sealingFeed     event.Feed
 
 	// Subscriptions
 	mux          *event.TypeMux
@@ -208,6 +236,7 @@ type worker struct {
 	// Channels
 	newWorkCh          chan *newWorkReq
 	getWorkCh          chan *getWorkReq
+	uncleCandidatesCh  chan chan *uncleCandidates
 	taskCh             chan *task
 	resultCh           chan types.SealResult
 	startCh            chan struct{}
@@ -222,12 +251,30 @@ type worker struct {
 	remoteUncles map[common.Hash]*types.Block // A set of side blocks as the possible uncle blocks.
 	unconfirmed  *unconfirmedBlocks           // A set of locally mined blocks pending canonicalness confirmations.
 
-	mu       sync.RWMutex // The lock used to protect the coinbase and extra fields
-	coinbase common.Address
-	extra    []byte
+	mu        sync.RWMutex // The lock used to protect the coinbase and extra fields
+	coinbase  common.Address
+	extra     []byte
+	extraTmpl *extraTemplate // Template resolved into the extra field, overriding extra if set
+	gasTarget *gasTarget     // Gas limit ramp, overriding the configured gas ceiling if set
+
+	unclePolicy UnclePolicy      // Selection of the uncles included in sealing blocks
+	leases      *leaseBook       // Leases of the sealing work to external coinbases
+	rotation    *coinbaseRotator // Rotation of the coinbase over several payout addresses
+
+	providersMu sync.RWMutex            // The lock used to protect the template providers
+	providers   []BlockTemplateProvider // External block builders proposing templates
+
+	bundles *bundlePool      // Private pool of searcher bundles, never gossiped
+	tips    *tipHistory      // Inclusion cutoffs of the recent pending block templates
+	journal *templateJournal // Inputs of the recent block template constructions, for replay
+
+	priorityMu    sync.RWMutex                // The lock used to protect the priority sets below
+	priorityAddrs map[common.Address]struct{} // Senders whose transactions are ordered first
+	priorityTxs   map[common.Hash]struct{}    // Transactions ordered first, with their sender's predecessors
 
 	pendingMu    sync.RWMutex
 	pendingTasks map[common.Hash]*task
+	sealing      *task // Task last handed to the consensus engine
 
 	snapshotMu       sync.RWMutex // The lock used to protect the snapshots below
 	snapshotBlock    *types.Block
@@ -266,10 +313,15 @@ type worker struct {
 	resubmitHook func(time.Duration, time.Duration) // Method to call upon updating resubmitting interval.
 }
 
-func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus.Engine, eth Backend, mux *event.TypeMux, isLocalBlock func(header *types.Header) bool, init bool) *worker {
+// chainConfig returns the chain config in effect, replaced by the chain when
+// forks are scheduled at runtime.
+func (w *worker) chainConfig() *params.ChainConfig {
+	return w.chain.Config()
+}
+
+func newWorker(config *Config, engine consensus.Engine, eth Backend, mux *event.TypeMux, isLocalBlock func(header *types.Header) bool, init bool) *worker {
 	worker := &worker{
 		config:             config,
-		chainConfig:        chainConfig,
 		engine:             engine,
 		eth:                eth,
 		chain:              eth.BlockChain(),
@@ -281,11 +333,17 @@ func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus
 		coinbase:           config.Etherbase,
 		extra:              config.ExtraData,
 		pendingTasks:       make(map[common.Hash]*task),
+		bundles:            new(bundlePool),
+		tips:               new(tipHistory),
+		journal:            new(templateJournal),
+		leases:             newLeaseBook(),
+		rotation:           newCoinbaseRotator(),
 		txsCh:              make(chan core.NewTxsEvent, txChanSize),
 		chainHeadCh:        make(chan core.ChainHeadEvent, chainHeadChanSize),
 		chainSideCh:        make(chan core.ChainSideEvent, chainSideChanSize),
 		newWorkCh:          make(chan *newWorkReq),
 		getWorkCh:          make(chan *getWorkReq),
+		uncleCandidatesCh:  make(chan chan *uncleCandidates),
 		taskCh:             make(chan *task),
 		resultCh:           make(chan types.SealResult, resultQueueSize),
 		startCh:            make(chan struct{}, 1),
@@ -318,11 +376,19 @@ func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus
 	}
 	worker.newpayloadTimeout = newpayloadTimeout
 
-	worker.wg.Add(4)
+	// Sanitize the uncle policy, falling back to including any uncles.
+	if err := config.UnclePolicy.validate(); err != nil {
+		log.Warn("Sanitizing invalid uncle policy", "err", err)
+	} else {
+		worker.unclePolicy = config.UnclePolicy
+	}
+
+	worker.wg.Add(5)
 	go worker.mainLoop()
 	go worker.newWorkLoop(recommit)
 	go worker.resultLoop()
 	go worker.taskLoop()
+	go worker.leaseLoop()
 
 	// Submit first work to initialize pending state.
 	if init {
@@ -338,6 +404,19 @@ func (w *worker) setEtherbase(addr common.Address) {
 	w.coinbase = addr
 }
 
+// setCoinbaseRotation replaces the rotation of the coinbase, regenerating the
+// sealing work for the new active coinbase.
+func (w *worker) setCoinbaseRotation(addresses []common.Address, policy string, weights []uint64) error {
+	if err := w.rotation.set(addresses, policy, weights); err != nil {
+		return err
+	}
+	select {
+	case w.startCh <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
 // etherbase retrieves the configured etherbase address.
 func (w *worker) etherbase() common.Address {
 	w.mu.RLock()
@@ -345,10 +424,49 @@ func (w *worker) etherbase() common.Address {
 	return w.coinbase
 }
 
+// setUnclePolicy sets the policy of uncle inclusion, taking effect from the next
+// sealing block.
+func (w *worker) setUnclePolicy(policy UnclePolicy) error {
+	if err := policy.validate(); err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.unclePolicy = policy
+	return nil
+}
+
+// getUnclePolicy retrieves the policy of uncle inclusion.
+func (w *worker) getUnclePolicy() UnclePolicy {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.unclePolicy
+}
+
 func (w *worker) setGasCeil(ceil uint64) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 	w.config.GasCeil = ceil
+	w.gasTarget = nil
+}
+
+// setGasTarget ramps the gas limit toward the target over the given number of
+// blocks following the current chain head.
+func (w *worker) setGasTarget(target, blocks uint64) {
+	head := w.chain.CurrentBlock()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.gasTarget = &gasTarget{from: head.GasLimit, start: head.Number.Uint64(), target: target, blocks: blocks}
+}
+
+// gasCeil returns the gas limit to strive for in the block at the given height.
+// It assumes the mu lock is held.
+func (w *worker) gasCeil(number uint64) uint64 {
+	if w.gasTarget != nil {
+		return w.gasTarget.desired(number)
+	}
+	return w.config.GasCeil
 }
 
 // setExtra sets the content used to initialize the block extra field.
@@ -356,6 +474,15 @@ func (w *worker) setExtra(extra []byte) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 	w.extra = extra
+	w.extraTmpl = nil
+}
+
+// setExtraTemplate sets the template resolved into the block extra field.
+func (w *worker) setExtraTemplate(tmpl *extraTemplate) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.extra = nil
+	w.extraTmpl = tmpl
 }
 
 // setRecommitInterval updates the interval for miner sealing work recommitting.
@@ -366,6 +493,66 @@ func (w *worker) setRecommitInterval(interval time.Duration) {
 	}
 }
 
+// setPriorityAddresses replaces the set of senders whose transactions are
+// ordered first in block assembly.
+func (w *worker) setPriorityAddresses(addrs []common.Address) {
+	set := make(map[common.Address]struct{}, len(addrs))
+	for _, addr := range addrs {
+		set[addr] = struct{}{}
+	}
+	w.priorityMu.Lock()
+	defer w.priorityMu.Unlock()
+	w.priorityAddrs = set
+}
+
+// setPriorityTxs replaces the set of transactions ordered first in block
+// assembly.
+func (w *worker) setPriorityTxs(hashes []common.Hash) {
+	set := make(map[common.Hash]struct{}, len(hashes))
+	for _, hash := range hashes {
+		set[hash] = struct{}{}
+	}
+	w.priorityMu.Lock()
+	defer w.priorityMu.Unlock()
+	w.priorityTxs = set
+}
+
+// splitPriority moves the pending transactions pinned by the operator out of
+// the given set. Prioritized senders are moved as a whole, while prioritized
+// transactions are moved along with their sender's lower nonce transactions,
+// needed to include them.
+func (w *worker) splitPriority(pending map[common.Address]types.Transactions) map[common.Address]types.Transactions {
+	w.priorityMu.RLock()
+	defer w.priorityMu.RUnlock()
+
+	priority := make(map[common.Address]types.Transactions)
+	if len(w.priorityAddrs) == 0 && len(w.priorityTxs) == 0 {
+		return priority
+	}
+	for account, txs := range pending {
+		if _, ok := w.priorityAddrs[account]; ok {
+			priority[account] = txs
+			delete(pending, account)
+			continue
+		}
+		last := -1
+		for i, tx := range txs {
+			if _, ok := w.priorityTxs[tx.Hash()]; ok {
+				last = i
+			}
+		}
+		if last >= 0 {
+			priority[account] = txs[:last+1]
+			if rest := txs[last+1:]; len(rest) > 0 {
+				pending[account] = rest
+			} else {
+				delete(pending, account)
+			}
+		}
+	}
+	return priority
+}
+
 // disablePreseal disables pre-sealing feature
 func (w *worker) disablePreseal() {
 	w.noempty.Store(true)
@@ -395,6 +582,30 @@ func (w *worker) pendingBlock() *types.Block {
 	return w.snapshotBlock
 }
 
+// sealingWork returns the block currently being sealed, nil if the worker is not
+// sealing or the block was outdated by a new chain head.
+func (w *worker) sealingWork() *SealingWork {
+	if !w.isRunning() {
+		return nil
+	}
+	w.pendingMu.RLock()
+	task := w.sealing
+	w.pendingMu.RUnlock()
+
+	if task == nil || task.block.ParentHash() != w.chain.CurrentBlock().Hash() {
+		return nil
+	}
+	return &SealingWork{
+		Block:     task.block,
+		Receipts:  task.receipts,
+		SealHash:  w.engine.SealHash(task.block.Header()),
+		Fees:      totalFees(task.block, task.receipts),
+		Profit:    new(big.Int).Set(task.profit),
+		TxSetHash: task.txSet,
+		CreatedAt: task.createdAt,
+	}
+}
+
 // pendingBlockAndReceipts returns pending block and corresponding receipts.
 func (w *worker) pendingBlockAndReceipts() (*types.Block, types.Receipts) {
 	// return a snapshot to avoid contention on currentMu mutex
@@ -502,7 +713,7 @@ func (w *worker) newWorkLoop(recommit time.Duration) {
 		case <-timer.C:
 			// If sealing is running resubmit a new work cycle periodically to pull in
 			// higher priced transactions. Disable this overhead for pending blocks.
-			if w.isRunning() && (w.chainConfig.Clique == nil || w.chainConfig.Clique.Period > 0) {
+			if clique := w.chainConfig().Clique; w.isRunning() && (clique == nil || clique.Period > 0) {
 				// Short circuit if no new transaction arrives.
 				if w.newTxs.Load() == 0 {
 					timer.Reset(recommit)
@@ -576,6 +787,9 @@ func (w *worker) mainLoop() {
 				block: block,
 				fees:  fees,
 			}
+		case res := <-w.uncleCandidatesCh:
+			res <- w.collectUncleCandidates()
+
 		case ev := <-w.chainSideCh:
 			// Short circuit for duplicate side blocks
 			if _, exist := w.localUncles[ev.Block.Hash()]; exist {
@@ -590,12 +804,13 @@ func (w *worker) mainLoop() {
 			} else {
 				w.remoteUncles[ev.Block.Hash()] = ev.Block
 			}
-			// If our sealing block contains less than 2 uncle blocks,
-			// add the new uncle block if valid and regenerate a new
-			// sealing block for higher profit.
-			if w.isRunning() && w.current != nil && len(w.current.uncles) < 2 {
+			// If our sealing block has room for the new uncle block, add
+			// it if valid and admitted by the uncle policy and regenerate
+			// a new sealing block for higher profit.
+			if w.isRunning() && w.current != nil {
 				start := time.Now()
-				if err := w.commitUncle(w.current, ev.Block.Header()); err == nil {
+				local := w.isLocalBlock != nil && w.isLocalBlock(ev.Block.Header())
+				if w.includeUncle(w.current, w.getUnclePolicy(), ev.Block.Header(), local) {
 					w.commit(w.current.copy(), nil, true, start)
 				}
 			}
@@ -642,7 +857,7 @@ func (w *worker) mainLoop() {
 				// Special case, if the consensus engine is 0 period clique(dev mode),
 				// submit sealing work here since all empty submission will be rejected
 				// by clique. Of course the advance sealing(empty submission) is disabled.
-				if w.chainConfig.Clique != nil && w.chainConfig.Clique.Period == 0 {
+				if clique := w.chainConfig().Clique; clique != nil && clique.Period == 0 {
 					w.commitWork(nil, true, time.Now().Unix())
 				}
 			}
@@ -692,6 +907,10 @@ func (w *worker) taskLoop() {
 			interrupt()
 			stopCh, prev = make(chan struct{}), sealHash
 
+			w.pendingMu.Lock()
+			w.sealing = task
+			w.pendingMu.Unlock()
+
 			if w.skipSealHook != nil && w.skipSealHook(task) {
 				continue
 			}
@@ -699,10 +918,16 @@ func (w *worker) taskLoop() {
 			w.pendingTasks[sealHash] = task
 			w.pendingMu.Unlock()
 
+			// Engines holding blocks until their timestamp (e.g. clique) make
+			// the broadcast time predictable, announce it ahead.
+			if at := time.Unix(int64(task.block.Time()), 0); at.After(time.Now()) {
+				w.sealingFeed.Send(SealingEvent{Broadcast: at})
+			}
 			if err := w.engine.Seal(w.chain, task.block, w.resultCh, stopCh); err != nil {
 				log.Warn("Block sealing failed", "err", err)
 				w.pendingMu.Lock()
 				delete(w.pendingTasks, sealHash)
+				w.sealing = nil
 				w.pendingMu.Unlock()
 			}
 		case <-w.exitCh:
@@ -744,6 +969,7 @@ func (w *worker) resultLoop() {
 				log.Error("Block found but no relative pending task", "number", block.Number(), "sealhash", sealhash, "hash", hash)
 				continue
 			}
+			w.sealingFeed.Send(SealingEvent{Broadcast: time.Now()})
 			// Different block could share same sealhash, deep copy here to prevent write-write conflict.
 			var (
 				receipts = make([]*types.Receipt, len(task.receipts))
@@ -771,17 +997,23 @@ func (w *worker) resultLoop() {
 				logs = append(logs, receipt.Logs...)
 			}
 
+			// Rotate the coinbase before the new head triggers the next work
+			w.rotation.sealedBlock(block.Coinbase())
+
 			// Broadcast the block and announce chain insertion event
 			w.mux.Post(core.NewMinedBlockEvent{Block: block})
 
 			// Commit block and state to database.
 			_, err := w.chain.WriteBlockAndSetHead(block, receipts, logs, task.state, true)
+			w.sealingFeed.Send(SealingEvent{})
 			if err != nil {
 				log.Error("Failed writing block to chain", "err", err)
 				continue
 			}
 			log.Info("Successfully sealed new block", "number", block.Number(), "sealhash", sealhash, "hash", hash,
 				"elapsed", common.PrettyDuration(time.Since(task.createdAt)))
+			reportUncles(w.chainConfig(), block, task.forfeited)
+			w.leases.creditBlock(block, time.Now())
 
 			// Insert the block into the set of pending ones to resultLoop for confirmations
 			w.unconfirmed.Insert(block.NumberU64(), block.Hash())
@@ -804,9 +1036,10 @@ func (w *worker) makeEnv(parent *types.Header, header *types.Header, coinbase co
 
 	// Note the passed coinbase may be different with header.Coinbase.
 	env := &environment{
-		signer:    types.MakeSigner(w.chainConfig, header.Number),
+		signer:    types.MakeSigner(w.chainConfig(), header.Number),
 		state:     state,
 		coinbase:  coinbase,
+		balance:   state.GetBalance(coinbase),
 		ancestors: mapset.NewSet[common.Hash](),
 		family:    mapset.NewSet[common.Hash](),
 		header:    header,
@@ -825,8 +1058,9 @@ func (w *worker) makeEnv(parent *types.Header, header *types.Header, coinbase co
 	return env, nil
 }
 
-// commitUncle adds the given block to uncle block set, returns error if failed to add.
-func (w *worker) commitUncle(env *environment, uncle *types.Header) error {
+// checkUncle returns an error if the given block can't be an uncle of the
+// sealing block.
+func (w *worker) checkUncle(env *environment, uncle *types.Header) error {
 	if w.isTTDReached(env.header) {
 		return errors.New("ignore uncle for beacon block")
 	}
@@ -843,7 +1077,6 @@ func (w *worker) commitUncle(env *environment, uncle *types.Header) error {
 	if env.family.Contains(hash) {
 		return errors.New("uncle already included")
 	}
-	env.uncles[hash] = uncle
 	return nil
 }
 
@@ -861,6 +1094,7 @@ func (w *worker) updateSnapshot(env *environment) {
 	)
 	w.snapshotReceipts = copyReceipts(env.receipts)
 	w.snapshotState = env.state.Copy()
+	w.tips.record(env)
 }
 
 func (w *worker) commitTransaction(env *environment, tx *types.Transaction) ([]*types.Log, error) {
@@ -868,7 +1102,7 @@ func (w *worker) commitTransaction(env *environment, tx *types.Transaction) ([]*
 		snap = env.state.Snapshot()
 		gp   = env.gasPool.Gas()
 	)
-	receipt, err := core.ApplyTransaction(w.chainConfig, w.chain, &env.coinbase, env.gasPool, env.state, env.header, tx, &env.header.GasUsed, *w.chain.GetVMConfig())
+	receipt, err := core.ApplyTransaction(w.chainConfig(), w.chain, &env.coinbase, env.gasPool, env.state, env.header, tx, &env.header.GasUsed, *w.chain.GetVMConfig())
 	if err != nil {
 		env.state.RevertToSnapshot(snap)
 		env.gasPool.SetGas(gp)
@@ -894,6 +1128,10 @@ func (w *worker) commitTransactions(env *environment, txs *types.TransactionsByP
 				return signalToErr(signal)
 			}
 		}
+		// Replays of interrupted assemblies stop where the assembly was interrupted
+		if env.txLimit > 0 && len(env.txs) >= env.txLimit {
+			break
+		}
 		// If we don't have enough gas for any further transactions then we're done.
 		if env.gasPool.Gas() < params.TxGas {
 			log.Trace("Not enough gas for further transactions", "have", env.gasPool, "want", params.TxGas)
@@ -910,8 +1148,8 @@ func (w *worker) commitTransactions(env *environment, txs *types.TransactionsByP
 
 		// Check whether the tx is replay protected. If we're not in the EIP155 hf
 		// phase, start ignoring the sender until we do.
-		if tx.Protected() && !w.chainConfig.IsEIP155(env.header.Number) {
-			log.Trace("Ignoring reply protected transaction", "hash", tx.Hash(), "eip155", w.chainConfig.EIP155Block)
+		if tx.Protected() && !w.chainConfig().IsEIP155(env.header.Number) {
+			log.Trace("Ignoring reply protected transaction", "hash", tx.Hash(), "eip155", w.chainConfig().EIP155Block)
 
 			txs.Pop()
 			continue
@@ -939,7 +1177,7 @@ func (w *worker) commitTransactions(env *environment, txs *types.TransactionsByP
 			txs.Pop()
 		}
 	}
-	if !w.isRunning() && len(coalescedLogs) > 0 {
+	if !w.isRunning() && !env.replay && len(coalescedLogs) > 0 {
 		// We don't push the pendingLogsEvent while we are sealing. The reason is that
 		// when we are sealing, the worker will regenerate a sealing block every 3 seconds.
 		// In order to avoid pushing the repeated pendingLog, we disable the pending log pushing.
@@ -998,12 +1236,19 @@ func (w *worker) prepareWork(genParams *generateParams) (*environment, error) {
 	header := &types.Header{
 		ParentHash: parent.Hash(),
 		Number:     new(big.Int).Add(parent.Number, common.Big1),
-		GasLimit:   core.CalcGasLimit(parent.GasLimit, w.config.GasCeil),
+		GasLimit:   core.CalcGasLimit(parent.GasLimit, w.gasCeil(parent.Number.Uint64()+1)),
 		Time:       timestamp,
 		Coinbase:   genParams.coinbase,
 	}
 	// Set the extra field.
-	if len(w.extra) != 0 {
+	if w.extraTmpl != nil {
+		extra, err := w.extraTmpl.render(header.Number.Uint64())
+		if err != nil {
+			log.Warn("Failed to resolve extra data template", "err", err)
+		} else {
+			header.Extra = extra
+		}
+	} else if len(w.extra) != 0 {
 		header.Extra = w.extra
 	}
 	// Set the randomness field from the beacon chain if it's available.
@@ -1011,11 +1256,11 @@ func (w *worker) prepareWork(genParams *generateParams) (*environment, error) {
 		header.MixDigest = genParams.random
 	}
 	// Set baseFee and GasLimit if we are on an EIP-1559 chain
-	if w.chainConfig.IsLondon(header.Number) {
-		header.BaseFee = misc.CalcBaseFee(w.chainConfig, parent)
-		if !w.chainConfig.IsLondon(parent.Number) {
-			parentGasLimit := parent.GasLimit * w.chainConfig.ElasticityMultiplier()
-			header.GasLimit = core.CalcGasLimit(parentGasLimit, w.config.GasCeil)
+	if config := w.chainConfig(); config.IsLondon(header.Number) {
+		header.BaseFee = misc.CalcBaseFee(config, parent)
+		if !config.IsLondon(parent.Number) {
+			parentGasLimit := parent.GasLimit * config.ElasticityMultiplier(header.Number)
+			header.GasLimit = core.CalcGasLimit(parentGasLimit, w.gasCeil(header.Number.Uint64()))
 		}
 	}
 	// Run the consensus preparation with the default or customized consensus engine.
@@ -1033,21 +1278,15 @@ func (w *worker) prepareWork(genParams *generateParams) (*environment, error) {
 	}
 	// Accumulate the uncles for the sealing work only if it's allowed.
 	if !genParams.noUncle {
-		commitUncles := func(blocks map[common.Hash]*types.Block) {
-			for hash, uncle := range blocks {
-				if len(env.uncles) == 2 {
-					break
-				}
-				if err := w.commitUncle(env, uncle.Header()); err != nil {
-					log.Trace("Possible uncle rejected", "hash", hash, "reason", err)
-				} else {
-					log.Debug("Committing new uncle to block", "hash", hash)
-				}
+		policy := w.getUnclePolicy()
+		commitUncles := func(blocks map[common.Hash]*types.Block, local bool) {
+			for _, uncle := range blocks {
+				w.includeUncle(env, policy, uncle.Header(), local)
 			}
 		}
 		// Prefer to locally generated uncle
-		commitUncles(w.localUncles)
-		commitUncles(w.remoteUncles)
+		commitUncles(w.localUncles, true)
+		commitUncles(w.remoteUncles, false)
 	}
 	return env, nil
 }
@@ -1056,9 +1295,15 @@ func (w *worker) prepareWork(genParams *generateParams) (*environment, error) {
 // into the given sealing block. The transaction selection and ordering strategy can
 // be customized with the plugin in the future.
 func (w *worker) fillTransactions(interrupt *atomic.Int32, env *environment) error {
-	// Split the pending transactions into locals and remotes
-	// Fill the block with all available pending transactions.
+	return w.commitCandidates(interrupt, env, w.txCandidates(env))
+}
+
+// txCandidates retrieves the transactions considered for the given sealing
+// block: the includable bundles and the pending transactions of the txpool,
+// split into priority, local and remote ones.
+func (w *worker) txCandidates(env *environment) *txCandidates {
 	pending := w.eth.TxPool().Pending(true)
+	priorityTxs := w.splitPriority(pending)
 	localTxs, remoteTxs := make(map[common.Address]types.Transactions), pending
 	for _, account := range w.eth.TxPool().Locals() {
 		if txs := remoteTxs[account]; len(txs) > 0 {
@@ -1066,14 +1311,35 @@ func (w *worker) fillTransactions(interrupt *atomic.Int32, env *environment) err
 			localTxs[account] = txs
 		}
 	}
-	if len(localTxs) > 0 {
-		txs := types.NewTransactionsByPriceAndNonce(env.signer, localTxs, env.header.BaseFee)
+	return &txCandidates{
+		bundles:  w.bundles.pending(env.header),
+		priority: priorityTxs,
+		locals:   localTxs,
+		remotes:  remoteTxs,
+	}
+}
+
+// commitCandidates fills the candidate transactions into the given sealing
+// block, consuming the candidate maps.
+func (w *worker) commitCandidates(interrupt *atomic.Int32, env *environment, candidates *txCandidates) error {
+	// Private bundles go first, they are what searchers pay to be included for
+	w.commitBundles(env, candidates.bundles)
+
+	if len(candidates.priority) > 0 {
+		txs := types.NewTransactionsByPriceAndNonce(env.signer, candidates.priority, env.header.BaseFee)
+		if err := w.commitTransactions(env, txs, interrupt); err != nil {
+			return err
+		}
+	}
+	if len(candidates.locals) > 0 {
+		txs := types.NewTransactionsByPriceAndNonce(env.signer, candidates.locals, env.header.BaseFee)
 		if err := w.commitTransactions(env, txs, interrupt); err != nil {
 			return err
 		}
 	}
-	if len(remoteTxs) > 0 {
-		txs := types.NewTransactionsByPriceAndNonce(env.signer, remoteTxs, env.header.BaseFee)
+	env.marketStart = len(env.txs)
+	if len(candidates.remotes) > 0 {
+		txs := types.NewTransactionsByPriceAndNonce(env.signer, candidates.remotes, env.header.BaseFee)
 		if err := w.commitTransactions(env, txs, interrupt); err != nil {
 			return err
 		}
@@ -1116,16 +1382,17 @@ func (w *worker) commitWork(interrupt *atomic.Int32, noempty bool, timestamp int
 	// Set the coinbase if the worker is running or it's required
 	var coinbase common.Address
 	if w.isRunning() {
-		coinbase = w.etherbase()
+		coinbase = w.leases.coinbase(time.Now(), w.rotation.coinbase(w.etherbase()))
 		if coinbase == (common.Address{}) {
 			log.Error("Refusing to mine without etherbase")
 			return
 		}
 	}
-	work, err := w.prepareWork(&generateParams{
+	genParams := &generateParams{
 		timestamp: uint64(timestamp),
 		coinbase:  coinbase,
-	})
+	}
+	work, err := w.prepareWork(genParams)
 	if err != nil {
 		return
 	}
@@ -1134,8 +1401,23 @@ func (w *worker) commitWork(interrupt *atomic.Int32, noempty bool, timestamp int
 	if !noempty && !w.noempty.Load() {
 		w.commit(work.copy(), nil, false, start)
 	}
-	// Fill pending transactions from the txpool into the block.
-	err = w.fillTransactions(interrupt, work)
+	// Fill pending transactions from the txpool into the block, journaling the
+	// inputs for the construction to be replayable
+	var (
+		candidates = w.txCandidates(work)
+		entry      = &journaledTemplate{
+			header:     types.CopyHeader(work.header),
+			uncles:     work.unclelist(),
+			coinbase:   work.coinbase,
+			candidates: candidates.copy(),
+			txSet:      candidates.hash(),
+		}
+	)
+	work.txSet = entry.txSet
+	err = w.commitCandidates(interrupt, work, candidates)
+	if err != nil {
+		entry.txLimit = len(work.txs)
+	}
 	switch {
 	case err == nil:
 		// The entire block is filled, decrease resubmit interval in case
@@ -1163,6 +1445,19 @@ func (w *worker) commitWork(interrupt *atomic.Int32, noempty bool, timestamp int
 		work.discard()
 		return
 	}
+	// Swap in the most profitable external template, if any beats local assembly
+	if coinbase != (common.Address{}) {
+		work = w.selectTemplate(work, genParams)
+		work.txSet = entry.txSet
+	}
+	entry.template, entry.provider = work.template, work.provider
+	entry.txs = make([]common.Hash, len(work.txs))
+	for i, tx := range work.txs {
+		entry.txs[i] = tx.Hash()
+	}
+	entry.profit = work.profit()
+	w.journal.add(entry)
+
 	// Submit the generated block for consensus sealing.
 	w.commit(work.copy(), w.fullTaskHook, true, start)
 
@@ -1186,6 +1481,7 @@ func (w *worker) commit(env *environment, interval func(), update bool, start ti
 		// Create a local environment copy, avoid the data race with snapshot state.
 		// https://github.com/ethereum/go-ethereum/issues/24299
 		env := env.copy()
+		profit := env.profit() // Before finalization credits the block rewards
 		// Withdrawals are set to nil here, because this is only called in PoW.
 		block, err := w.engine.FinalizeAndAssemble(w.chain, env.header, env.state, env.txs, env.unclelist(), env.receipts, nil)
 		if err != nil {
@@ -1194,7 +1490,7 @@ func (w *worker) commit(env *environment, interval func(), update bool, start ti
 		// If we're post merge, just ignore
 		if !w.isTTDReached(block.Header()) {
 			select {
-			case w.taskCh <- &task{receipts: env.receipts, state: env.state, block: block, createdAt: time.Now()}:
+			case w.taskCh <- &task{receipts: env.receipts, state: env.state, block: block, profit: profit, forfeited: env.forfeited, txSet: env.txSet, createdAt: time.Now()}:
 				w.unconfirmed.Shift(block.NumberU64() - 1)
 
 				fees := totalFees(block, env.receipts)
@@ -199,7 +199,7 @@ func (b *testWorkerBackend) newRandomTx(creation bool) *types.Transaction {
 func newTestWorker(t *testing.T, chainConfig *params.ChainConfig, engine consensus.Engine, db ethdb.Database, blocks int) (*worker, *testWorkerBackend) {
 	backend := newTestWorkerBackend(t, chainConfig, engine, db, blocks)
 	backend.txPool.AddLocals(pendingTxs)
-	w := newWorker(testConfig, chainConfig, engine, backend, new(event.TypeMux), nil, false)
+	w := newWorker(testConfig, engine, backend, new(event.TypeMux), nil, false)
 	w.setEtherbase(testBankAddress)
 	return w, backend
 }
@@ -315,6 +315,55 @@ func testEmptyWork(t *testing.T, chainConfig *params.ChainConfig, engine consens
 	}
 }
 
+func TestSealingWork(t *testing.T) {
+	engine := ethash.NewFaker()
+	defer engine.Close()
+
+	w, _ := newTestWorker(t, ethashChainConfig, engine, rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+	w.setEtherbase(common.Address{0x10})
+
+	if work := w.sealingWork(); work != nil {
+		t.Fatalf("sealing work reported before start: %v", work.Block.Number())
+	}
+	full := make(chan struct{}, 1)
+	w.newTaskHook = func(task *task) {
+		if len(task.receipts) > 0 {
+			select {
+			case full <- struct{}{}:
+			default:
+			}
+		}
+	}
+	w.skipSealHook = func(task *task) bool { return true }
+	w.start()
+
+	select {
+	case <-full:
+	case <-time.After(3 * time.Second):
+		t.Fatal("new task timeout")
+	}
+	var work *SealingWork
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if work = w.sealingWork(); work != nil && len(work.Block.Transactions()) > 0 {
+			break
+		}
+	}
+	if work == nil || len(work.Block.Transactions()) != 1 || len(work.Receipts) != 1 {
+		t.Fatalf("sealing work mismatch: %+v", work)
+	}
+	if work.SealHash != engine.SealHash(work.Block.Header()) {
+		t.Errorf("seal hash mismatch: have %x", work.SealHash)
+	}
+	if want := totalFees(work.Block, work.Receipts); work.Fees.Cmp(want) != 0 || work.Profit.Cmp(want) != 0 {
+		t.Errorf("earnings mismatch: fees %v, profit %v, want %v", work.Fees, work.Profit, want)
+	}
+	w.stop()
+	if work := w.sealingWork(); work != nil {
+		t.Errorf("sealing work reported after stop")
+	}
+}
+
 func TestStreamUncleBlock(t *testing.T) {
 	ethash := ethash.NewFaker()
 	defer ethash.Close()
@@ -660,3 +709,40 @@ func testGetSealingWork(t *testing.T, chainConfig *params.ChainConfig, engine co
 		}
 	}
 }
+
+// Tests that the operator pinned senders and transactions are split off the
+// pending set, along with the transactions needed to include them.
+func TestSplitPriority(t *testing.T) {
+	w, _ := newTestWorker(t, ethashChainConfig, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+
+	var (
+		signer   = types.LatestSigner(ethashChainConfig)
+		payer    = common.Address{0x01}
+		bankTxs  types.Transactions
+		payerTxs = types.Transactions{types.NewTx(&types.LegacyTx{Nonce: 0})}
+	)
+	for i := uint64(0); i < 3; i++ {
+		bankTxs = append(bankTxs, types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{Nonce: i, Gas: params.TxGas, GasPrice: big.NewInt(1)}))
+	}
+	pending := map[common.Address]types.Transactions{testBankAddress: bankTxs, payer: payerTxs}
+	if priority := w.splitPriority(pending); len(priority) != 0 {
+		t.Fatalf("transactions prioritized without priority set: %v", priority)
+	}
+	w.setPriorityAddresses([]common.Address{payer})
+	w.setPriorityTxs([]common.Hash{bankTxs[1].Hash()})
+
+	priority := w.splitPriority(pending)
+	if len(priority[payer]) != 1 {
+		t.Errorf("prioritized sender mismatch: have %d txs, want 1", len(priority[payer]))
+	}
+	if txs := priority[testBankAddress]; len(txs) != 2 || txs[1] != bankTxs[1] {
+		t.Errorf("prioritized transaction mismatch: have %d txs, want 2", len(txs))
+	}
+	if _, ok := pending[payer]; ok {
+		t.Error("prioritized sender left pending")
+	}
+	if txs := pending[testBankAddress]; len(txs) != 1 || txs[0] != bankTxs[2] {
+		t.Errorf("remaining transactions mismatch: have %v", txs)
+	}
+}
@@ -0,0 +1,352 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+// Package workproxy implements a getwork proxy, serving local miners with the
+// mining work of upstream full nodes. It lets nodes following only the header
+// chain feed the mining hardware next to them with low latency.
+package workproxy
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// refreshInterval is the interval at which the work is refetched from the
+	// upstreams, picking up the transactions included meanwhile.
+	refreshInterval = time.Second
+
+	// upstreamTimeout is the timeout of the calls made to the upstreams.
+	upstreamTimeout = 2 * time.Second
+
+	// upstreamBackoff is the time a failed upstream is skipped for.
+	upstreamBackoff = 10 * time.Second
+
+	// maxTrackedWork is the number of recent work packages tracked to route the
+	// solutions to the upstream having handed out the work.
+	maxTrackedWork = 128
+)
+
+var errNoUpstream = errors.New("no upstream available")
+
+// HeaderChain is the local header chain the proxy follows, used to refresh the
+// work as soon as a new block is seen and to detect upstreams lagging behind.
+type HeaderChain interface {
+	CurrentHeader() *types.Header
+	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
+}
+
+// upstream is a full node the work is fetched from.
+type upstream struct {
+	url    string
+	client *rpc.Client // Connection to the node, nil until dialed
+	failed time.Time   // Time of the last failure, zero if healthy
+}
+
+// Proxy serves the getwork protocol by forwarding it to upstream full nodes,
+// caching the latest work package in between.
+type Proxy struct {
+	chain     HeaderChain
+	upstreams []*upstream
+
+	lock    sync.Mutex
+	work    [4]string                 // Latest work package
+	fetched time.Time                 // Time the latest work package was fetched at
+	origin  map[common.Hash]*upstream // Upstreams having handed out the recent work packages
+	order   []common.Hash             // Recent work packages, oldest first
+	dial    func(string) (*rpc.Client, error)
+
+	refreshCh chan struct{} // Requests refreshing the work out of schedule
+	quit      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// New creates a getwork proxy forwarding to the upstream nodes at the given RPC
+// endpoints, in order of preference.
+func New(chain HeaderChain, urls []string) (*Proxy, error) {
+	if len(urls) == 0 {
+		return nil, errors.New("no getwork upstreams configured")
+	}
+	p := &Proxy{
+		chain:     chain,
+		origin:    make(map[common.Hash]*upstream),
+		dial:      rpc.Dial,
+		refreshCh: make(chan struct{}, 1),
+		quit:      make(chan struct{}),
+	}
+	for _, url := range urls {
+		p.upstreams = append(p.upstreams, &upstream{url: url})
+	}
+	return p, nil
+}
+
+// APIs returns the getwork RPC API of the proxy.
+func (p *Proxy) APIs() []rpc.API {
+	return []rpc.API{{
+		Namespace: "eth",
+		Service:   &API{p},
+	}}
+}
+
+// Start implements node.Lifecycle, starting to refresh the work.
+func (p *Proxy) Start() error {
+	p.wg.Add(1)
+	go p.loop()
+	return nil
+}
+
+// Stop implements node.Lifecycle, terminating the proxy.
+func (p *Proxy) Stop() error {
+	close(p.quit)
+	p.wg.Wait()
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for _, up := range p.upstreams {
+		if up.client != nil {
+			up.client.Close()
+		}
+	}
+	return nil
+}
+
+// loop refreshes the work on every new local head and periodically in between.
+func (p *Proxy) loop() {
+	defer p.wg.Done()
+
+	heads := make(chan core.ChainHeadEvent, 16)
+	sub := p.chain.SubscribeChainHeadEvent(heads)
+	defer sub.Unsubscribe()
+
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-heads:
+			p.refresh()
+		case <-p.refreshCh:
+			p.refresh()
+		case <-ticker.C:
+			p.refresh()
+		case <-sub.Err():
+			return
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+// getWork returns the latest work package, fetching a new one if the cached one
+// is outdated or builds on a block not above the local head.
+func (p *Proxy) getWork() ([4]string, error) {
+	p.lock.Lock()
+	work, fetched := p.work, p.fetched
+	p.lock.Unlock()
+
+	if !fetched.IsZero() && time.Since(fetched) < refreshInterval && !p.stale(work) {
+		return work, nil
+	}
+	return p.refresh()
+}
+
+// stale returns whether the work package is for a block not above the local
+// head, meaning that its upstream is lagging behind.
+func (p *Proxy) stale(work [4]string) bool {
+	number, err := hexutil.DecodeUint64(work[3])
+	if err != nil {
+		return false // Upstream doesn't report the block number
+	}
+	return number <= p.chain.CurrentHeader().Number.Uint64()
+}
+
+// refresh fetches a new work package from the first healthy upstream with fresh
+// work. Lagging upstreams are used only if none has fresh work.
+func (p *Proxy) refresh() ([4]string, error) {
+	var (
+		fallback   [4]string
+		fallbackUp *upstream
+	)
+	for _, up := range p.healthy() {
+		var work [4]string
+		if err := p.call(up, &work, "eth_getWork"); err != nil {
+			continue
+		}
+		if p.stale(work) {
+			log.Debug("Upstream work is stale", "upstream", up.url, "number", work[3])
+			if fallbackUp == nil {
+				fallback, fallbackUp = work, up
+			}
+			continue
+		}
+		p.track(work, up)
+		return work, nil
+	}
+	if fallbackUp != nil {
+		p.track(fallback, fallbackUp)
+		return fallback, nil
+	}
+	return [4]string{}, errNoUpstream
+}
+
+// track records a work package as the latest one, along with its upstream.
+func (p *Proxy) track(work [4]string, up *upstream) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.work, p.fetched = work, time.Now()
+
+	hash := common.HexToHash(work[0])
+	if _, ok := p.origin[hash]; !ok {
+		p.order = append(p.order, hash)
+		if len(p.order) > maxTrackedWork {
+			delete(p.origin, p.order[0])
+			p.order = p.order[1:]
+		}
+	}
+	p.origin[hash] = up
+}
+
+// submitWork forwards a solution to the upstream having handed out the work, or
+// to all upstreams if unknown, returning whether it was accepted.
+func (p *Proxy) submitWork(nonce types.BlockNonce, hash, digest common.Hash) bool {
+	p.lock.Lock()
+	origin := p.origin[hash]
+	p.lock.Unlock()
+
+	targets := p.healthy()
+	if origin != nil {
+		targets = []*upstream{origin}
+	}
+	for _, up := range targets {
+		var accepted bool
+		if err := p.call(up, &accepted, "eth_submitWork", nonce, hash, digest); err != nil || !accepted {
+			continue
+		}
+		// The upstream moves on to the next block, follow it right away
+		select {
+		case p.refreshCh <- struct{}{}:
+		default:
+		}
+		return true
+	}
+	return false
+}
+
+// submitHashrate forwards a hashrate report to all healthy upstreams, returning
+// whether any accepted it.
+func (p *Proxy) submitHashrate(rate hexutil.Uint64, id common.Hash) bool {
+	var accepted bool
+	for _, up := range p.healthy() {
+		var ok bool
+		if err := p.call(up, &ok, "eth_submitHashrate", rate, id); err == nil && ok {
+			accepted = true
+		}
+	}
+	return accepted
+}
+
+// healthy returns the upstreams not failing recently in order of preference, or
+// all of them if all are failing.
+func (p *Proxy) healthy() []*upstream {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var ups []*upstream
+	for _, up := range p.upstreams {
+		if up.failed.IsZero() || time.Since(up.failed) > upstreamBackoff {
+			ups = append(ups, up)
+		}
+	}
+	if len(ups) == 0 {
+		return p.upstreams
+	}
+	return ups
+}
+
+// call invokes an RPC method of an upstream, dialing it if not yet connected and
+// marking it failed on error.
+func (p *Proxy) call(up *upstream, result interface{}, method string, args ...interface{}) error {
+	p.lock.Lock()
+	client := up.client
+	p.lock.Unlock()
+
+	if client == nil {
+		c, err := p.dial(up.url)
+		if err != nil {
+			p.fail(up, err)
+			return err
+		}
+		p.lock.Lock()
+		if up.client == nil {
+			up.client = c
+		} else {
+			c.Close()
+		}
+		client = up.client
+		p.lock.Unlock()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), upstreamTimeout)
+	defer cancel()
+
+	if err := client.CallContext(ctx, result, method, args...); err != nil {
+		p.fail(up, err)
+		return err
+	}
+	p.lock.Lock()
+	up.failed = time.Time{}
+	p.lock.Unlock()
+	return nil
+}
+
+// fail marks an upstream failed, skipping it for a while.
+func (p *Proxy) fail(up *upstream, err error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if up.failed.IsZero() {
+		log.Warn("Getwork upstream failed", "upstream", up.url, "err", err)
+	}
+	up.failed = time.Now()
+}
+
+// API is the getwork RPC API served by the proxy, replacing the one of the
+// local consensus engine.
+type API struct {
+	p *Proxy
+}
+
+// GetWork returns the work package of an upstream node for the next block.
+func (api *API) GetWork() ([4]string, error) {
+	return api.p.getWork()
+}
+
+// SubmitWork forwards a proof-of-work solution to the upstream node having
+// handed out the work. It returns whether the solution was accepted.
+func (api *API) SubmitWork(nonce types.BlockNonce, hash, digest common.Hash) bool {
+	return api.p.submitWork(nonce, hash, digest)
+}
+
+// SubmitHashrate forwards the hashrate of a local miner to the upstream nodes.
+func (api *API) SubmitHashrate(rate hexutil.Uint64, id common.Hash) bool {
+	return api.p.submitHashrate(rate, id)
+}
@@ -0,0 +1,157 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package workproxy
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// testChain is a header chain with a settable head.
+type testChain struct {
+	head *types.Header
+	feed event.Feed
+}
+
+func (c *testChain) CurrentHeader() *types.Header { return c.head }
+func (c *testChain) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
+	return c.feed.Subscribe(ch)
+}
+
+// testUpstream is a full node handing out a fixed work package.
+type testUpstream struct {
+	lock      sync.Mutex
+	work      [4]string
+	down      bool
+	solutions []common.Hash
+	hashrates int
+}
+
+func (u *testUpstream) GetWork() ([4]string, error) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	if u.down {
+		return [4]string{}, errors.New("down")
+	}
+	return u.work, nil
+}
+
+func (u *testUpstream) SubmitWork(nonce types.BlockNonce, hash, digest common.Hash) bool {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	if common.HexToHash(u.work[0]) != hash {
+		return false
+	}
+	u.solutions = append(u.solutions, hash)
+	return true
+}
+
+func (u *testUpstream) SubmitHashrate(rate hexutil.Uint64, id common.Hash) bool {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	u.hashrates++
+	return true
+}
+
+func newTestProxy(t *testing.T, head uint64, upstreams map[string]*testUpstream, order ...string) (*Proxy, *testChain) {
+	chain := &testChain{head: &types.Header{Number: new(big.Int).SetUint64(head)}}
+	proxy, err := New(chain, order)
+	if err != nil {
+		t.Fatalf("failed to create proxy: %v", err)
+	}
+	proxy.dial = func(url string) (*rpc.Client, error) {
+		up, ok := upstreams[url]
+		if !ok {
+			return nil, errors.New("unreachable")
+		}
+		srv := rpc.NewServer()
+		if err := srv.RegisterName("eth", up); err != nil {
+			t.Fatalf("failed to register upstream: %v", err)
+		}
+		return rpc.DialInProc(srv), nil
+	}
+	return proxy, chain
+}
+
+func work(hash byte, number uint64) [4]string {
+	return [4]string{common.Hash{hash}.Hex(), common.Hash{}.Hex(), common.Hash{}.Hex(), hexutil.EncodeUint64(number)}
+}
+
+func TestProxyFailover(t *testing.T) {
+	var (
+		primary   = &testUpstream{work: work(0x01, 11), down: true}
+		secondary = &testUpstream{work: work(0x02, 11)}
+		upstreams = map[string]*testUpstream{"primary": primary, "secondary": secondary}
+	)
+	proxy, _ := newTestProxy(t, 10, upstreams, "unreachable", "primary", "secondary")
+	defer proxy.Stop()
+
+	have, err := proxy.getWork()
+	if err != nil || have != secondary.work {
+		t.Fatalf("work mismatch: have %v, %v, want %v", have, err, secondary.work)
+	}
+	// Solutions are routed to the upstream having handed out the work
+	if !proxy.submitWork(types.BlockNonce{}, common.Hash{0x02}, common.Hash{}) {
+		t.Fatalf("solution rejected")
+	}
+	if len(secondary.solutions) != 1 || len(primary.solutions) != 0 {
+		t.Errorf("solution misrouted: primary %d, secondary %d", len(primary.solutions), len(secondary.solutions))
+	}
+	if proxy.submitWork(types.BlockNonce{}, common.Hash{0x03}, common.Hash{}) {
+		t.Errorf("unknown solution accepted")
+	}
+	if !proxy.submitHashrate(100, common.Hash{}) || secondary.hashrates != 1 {
+		t.Errorf("hashrate not forwarded")
+	}
+}
+
+func TestProxyStaleWork(t *testing.T) {
+	var (
+		lagging   = &testUpstream{work: work(0x01, 10)}
+		fresh     = &testUpstream{work: work(0x02, 11)}
+		upstreams = map[string]*testUpstream{"lagging": lagging, "fresh": fresh}
+	)
+	proxy, chain := newTestProxy(t, 10, upstreams, "lagging", "fresh")
+	defer proxy.Stop()
+
+	// Work of upstreams lagging behind the local head is skipped
+	if have, err := proxy.getWork(); err != nil || have != fresh.work {
+		t.Fatalf("work mismatch: have %v, %v, want %v", have, err, fresh.work)
+	}
+	// Cached work turning stale with a new local head is refetched, falling back
+	// to stale work if no upstream has fresh one
+	chain.head = &types.Header{Number: big.NewInt(11)}
+	if have, err := proxy.getWork(); err != nil || have != lagging.work {
+		t.Fatalf("fallback work mismatch: have %v, %v, want %v", have, err, lagging.work)
+	}
+	lagging.work = work(0x03, 12)
+	if have, err := proxy.getWork(); err != nil || have != lagging.work {
+		t.Fatalf("refreshed work mismatch: have %v, %v, want %v", have, err, lagging.work)
+	}
+	lagging.down, fresh.down = true, true
+	proxy.lock.Lock()
+	proxy.fetched = proxy.fetched.Add(-refreshInterval)
+	proxy.lock.Unlock()
+	if _, err := proxy.getWork(); err != errNoUpstream {
+		t.Fatalf("error mismatch: have %v, want %v", err, errNoUpstream)
+	}
+}
@@ -172,6 +172,7 @@ func (api *adminAPI) StartHTTP(host *string, port *int, cors *string, apis *stri
 		CorsAllowedOrigins: api.node.config.HTTPCors,
 		Vhosts:             api.node.config.HTTPVirtualHosts,
 		Modules:            api.node.config.HTTPModules,
+		tracer:             api.node.rpcTracer,
 	}
 	if cors != nil {
 		config.CorsAllowedOrigins = nil
@@ -245,6 +246,7 @@ func (api *adminAPI) StartWS(host *string, port *int, allowedOrigins *string, ap
 	config := wsConfig{
 		Modules: api.node.config.WSModules,
 		Origins: api.node.config.WSOrigins,
+		tracer:  api.node.rpcTracer,
 		// ExposeAll: api.node.config.WSExposeAll,
 	}
 	if apis != nil {
@@ -283,6 +285,13 @@ func (api *adminAPI) StopWS() (bool, error) {
 	return true, nil
 }
 
+// RPCStats returns the call statistics of every RPC method served over HTTP,
+// WebSocket and IPC since the node started, with the latency percentiles of the
+// most recent calls.
+func (api *adminAPI) RPCStats() map[string]*rpc.MethodStats {
+	return api.node.rpcTracer.Stats()
+}
+
 // Peers retrieves all the information we know about each individual peer at the
 // protocol granularity.
 func (api *adminAPI) Peers() ([]*p2p.PeerInfo, error) {
@@ -293,6 +302,16 @@ func (api *adminAPI) Peers() ([]*p2p.PeerInfo, error) {
 	return server.PeersInfo(), nil
 }
 
+// PeerStats retrieves the rolling statistics of the connected peers: traffic
+// per message type and the block propagation latencies and usefulness scores.
+func (api *adminAPI) PeerStats() ([]*p2p.PeerStats, error) {
+	server := api.node.Server()
+	if server == nil {
+		return nil, ErrNodeStopped
+	}
+	return server.PeersStats(), nil
+}
+
 // NodeInfo retrieves all the information we know about the host node at the
 // protocol granularity.
 func (api *adminAPI) NodeInfo() (*p2p.NodeInfo, error) {
@@ -19,6 +19,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -74,6 +75,15 @@ type Config struct {
 	// ExternalSigner specifies an external URI for a clef-type signer
 	ExternalSigner string `toml:",omitempty"`
 
+	// ExternalSignerCert and ExternalSignerKey are the client certificate the
+	// node authenticates to an HTTPS external signer with, ExternalSignerCA the
+	// authority the signer's certificate is verified against and
+	// ExternalSignerToken a bearer token sent along every request.
+	ExternalSignerCert  string `toml:",omitempty"`
+	ExternalSignerKey   string `toml:",omitempty"`
+	ExternalSignerCA    string `toml:",omitempty"`
+	ExternalSignerToken string `toml:",omitempty"`
+
 	// UseLightweightKDF lowers the memory and CPU requirements of the key store
 	// scrypt KDF at the expense of security.
 	UseLightweightKDF bool `toml:",omitempty"`
@@ -199,7 +209,48 @@ type Config struct {
 	// EnablePersonal enables the deprecated personal namespace.
 	EnablePersonal bool `toml:"-"`
 
+	// RPCRateLimits are the method=rate[:burst] limits of calls per client over
+	// HTTP and WebSocket, see rpc.ParseRateLimits. Empty disables rate limiting.
+	RPCRateLimits string `toml:",omitempty"`
+
+	// RPCAuthKeys is the path of the API keys file restricting calls over HTTP
+	// and WebSocket, see rpc.LoadAuthKeys. Empty leaves calls unrestricted.
+	RPCAuthKeys string `toml:",omitempty"`
+
+	// RPCBatchBudget is the maximum total weight of the calls of a batch request
+	// over HTTP and WebSocket, zero leaves batches unlimited.
+	RPCBatchBudget uint64 `toml:",omitempty"`
+
+	// RPCBatchWeights are the method=weight costs of calls in batch requests, see
+	// rpc.ParseBatchWeights. Methods not listed weigh one.
+	RPCBatchWeights string `toml:",omitempty"`
+
+	// RPCBatchPartial executes the calls of over budget batches up to the budget
+	// instead of rejecting the whole batch.
+	RPCBatchPartial bool `toml:",omitempty"`
+
+	// RPCTraceCalls logs every call served over HTTP, WebSocket and IPC.
+	RPCTraceCalls bool `toml:",omitempty"`
+
+	// RPCSlowCallThreshold logs the calls served taking longer than it, zero
+	// disables the slow call log.
+	RPCSlowCallThreshold time.Duration `toml:",omitempty"`
+
 	DBEngine string `toml:",omitempty"`
+
+	// DBRemote is the url of the key-value service of the node whose chain
+	// database is shared, when DBEngine is "remote".
+	DBRemote string `toml:",omitempty"`
+
+	// AncientCompression is the compression of newly created compressed tables
+	// of the ancient store, "snappy" (default) or "zstd". Existing tables keep
+	// theirs until converted offline.
+	AncientCompression string `toml:",omitempty"`
+
+	// DataDirReadOnly opens the data directory of a running node without locking
+	// it, sharing its chain database read-only. Local writes are kept in memory
+	// and the database needs to be refreshed to observe the owner's progress.
+	DataDirReadOnly bool `toml:",omitempty"`
 }
 
 // IPCEndpoint resolves an IPC endpoint based on a configured value, taking into
@@ -229,7 +280,7 @@ func (c *Config) IPCEndpoint() string {
 
 // NodeDB returns the path to the discovery node database.
 func (c *Config) NodeDB() string {
-	if c.DataDir == "" {
+	if c.DataDir == "" || c.DataDirReadOnly {
 		return "" // ephemeral
 	}
 	return c.ResolvePath(datadirNodeDatabase)
@@ -25,6 +25,8 @@ var (
 	ErrNodeRunning    = errors.New("node already running")
 	ErrServiceUnknown = errors.New("unknown service")
 
+	errNotReplica = errors.New("database not opened from a read-only datadir")
+
 	datadirInUseErrnos = map[uint]bool{11: true, 32: true, 35: true}
 )
 
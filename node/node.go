@@ -51,14 +51,17 @@ type Node struct {
 	state         int           // Tracks state of node lifecycle
 
 	lock          sync.Mutex
-	lifecycles    []Lifecycle // All registered backends, services, and auxiliary services that have a lifecycle
-	rpcAPIs       []rpc.API   // List of APIs currently provided by the node
-	http          *httpServer //
-	ws            *httpServer //
-	httpAuth      *httpServer //
-	wsAuth        *httpServer //
-	ipc           *ipcServer  // Stores information about the ipc http server
-	inprocHandler *rpc.Server // In-process RPC request handler to process the API requests
+	lifecycles    []Lifecycle        // All registered backends, services, and auxiliary services that have a lifecycle
+	rpcAPIs       []rpc.API          // List of APIs currently provided by the node
+	http          *httpServer        //
+	ws            *httpServer        //
+	httpAuth      *httpServer        //
+	wsAuth        *httpServer        //
+	ipc           *ipcServer         // Stores information about the ipc http server
+	inprocHandler *rpc.Server        // In-process RPC request handler to process the API requests
+	rpcTracer     *rpc.RequestTracer // Tracer of the calls served over HTTP, WebSocket and IPC
+	rpcAuthKeys   *rpc.AuthKeys      // API keys permitted to call over HTTP and WebSocket, nil if unrestricted
+	rpcLimiter    *rpc.RateLimiter   // Rate limiter shared by HTTP and WebSocket, nil if unlimited
 
 	databases map[*closeTrackingDB]struct{} // All open databases
 }
@@ -101,6 +104,7 @@ func New(conf *Config) (*Node, error) {
 	node := &Node{
 		config:        conf,
 		inprocHandler: rpc.NewServer(),
+		rpcTracer:     rpc.NewRequestTracer(conf.RPCTraceCalls, conf.RPCSlowCallThreshold),
 		eventmux:      new(event.TypeMux),
 		log:           conf.Logger,
 		stop:          make(chan struct{}),
@@ -148,6 +152,7 @@ func New(conf *Config) (*Node, error) {
 	node.ws = newHTTPServer(node.log, rpc.DefaultHTTPTimeouts)
 	node.wsAuth = newHTTPServer(node.log, rpc.DefaultHTTPTimeouts)
 	node.ipc = newIPCServer(node.log, conf.IPCEndpoint())
+	node.ipc.tracer = node.rpcTracer
 
 	return node, nil
 }
@@ -314,6 +319,10 @@ func (n *Node) openDataDir() error {
 	if err := os.MkdirAll(instdir, 0700); err != nil {
 		return err
 	}
+	// A read-only instance shares the directory with the node owning it.
+	if n.config.DataDirReadOnly {
+		return nil
+	}
 	// Lock the instance directory to prevent concurrent use by another instance as well as
 	// accidental use of the instance directory as a database.
 	n.dirLock = flock.New(filepath.Join(instdir, "LOCK"))
@@ -397,7 +406,35 @@ func (n *Node) startRPC() error {
 	var (
 		servers           []*httpServer
 		openAPIs, allAPIs = n.getAPIs()
+		rateLimiter       *rpc.RateLimiter
+		authKeys          *rpc.AuthKeys
+		batchLimits       *rpc.BatchLimits
 	)
+	// Share one rate limiter between HTTP and WS, limiting clients on both
+	if n.config.RPCRateLimits != "" {
+		limits, err := rpc.ParseRateLimits(n.config.RPCRateLimits)
+		if err != nil {
+			return err
+		}
+		rateLimiter = rpc.NewRateLimiter(limits)
+	}
+	if n.config.RPCAuthKeys != "" {
+		var err error
+		if authKeys, err = rpc.LoadAuthKeys(n.config.RPCAuthKeys); err != nil {
+			return err
+		}
+	}
+	n.rpcAuthKeys, n.rpcLimiter = authKeys, rateLimiter
+
+	if n.config.RPCBatchBudget > 0 {
+		batchLimits = &rpc.BatchLimits{Budget: n.config.RPCBatchBudget, Partial: n.config.RPCBatchPartial}
+		if n.config.RPCBatchWeights != "" {
+			var err error
+			if batchLimits.Weights, err = rpc.ParseBatchWeights(n.config.RPCBatchWeights); err != nil {
+				return err
+			}
+		}
+	}
 
 	initHttp := func(server *httpServer, port int) error {
 		if err := server.setListenAddr(n.config.HTTPHost, port); err != nil {
@@ -408,6 +445,10 @@ func (n *Node) startRPC() error {
 			Vhosts:             n.config.HTTPVirtualHosts,
 			Modules:            n.config.HTTPModules,
 			prefix:             n.config.HTTPPathPrefix,
+			rateLimiter:        rateLimiter,
+			authKeys:           authKeys,
+			batchLimits:        batchLimits,
+			tracer:             n.rpcTracer,
 		}); err != nil {
 			return err
 		}
@@ -421,9 +462,13 @@ func (n *Node) startRPC() error {
 			return err
 		}
 		if err := server.enableWS(openAPIs, wsConfig{
-			Modules: n.config.WSModules,
-			Origins: n.config.WSOrigins,
-			prefix:  n.config.WSPathPrefix,
+			Modules:     n.config.WSModules,
+			Origins:     n.config.WSOrigins,
+			prefix:      n.config.WSPathPrefix,
+			rateLimiter: rateLimiter,
+			authKeys:    authKeys,
+			batchLimits: batchLimits,
+			tracer:      n.rpcTracer,
 		}); err != nil {
 			return err
 		}
@@ -605,6 +650,16 @@ func (n *Node) Attach() (*rpc.Client, error) {
 	return rpc.DialInProc(n.inprocHandler), nil
 }
 
+// RPCLimits returns the API keys and the rate limiter of the calls served over
+// HTTP and WebSocket, for other servers of the node's APIs to enforce as well.
+// Either is nil if not configured, and both are only set once the node started.
+func (n *Node) RPCLimits() (*rpc.AuthKeys, *rpc.RateLimiter) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	return n.rpcAuthKeys, n.rpcLimiter
+}
+
 // RPCHandler returns the in-process RPC request handler.
 func (n *Node) RPCHandler() (*rpc.Server, error) {
 	n.lock.Lock()
@@ -705,8 +760,14 @@ func (n *Node) OpenDatabase(name string, cache, handles int, namespace string, r
 	if n.config.DataDir == "" {
 		db = rawdb.NewMemoryDatabase()
 	} else {
+		// Only the chain database is shared by a remote node, the auxiliary
+		// ones are local
+		engine := n.config.DBEngine
+		if engine == "remote" {
+			engine = ""
+		}
 		db, err = rawdb.Open(rawdb.OpenOptions{
-			Type:      n.config.DBEngine,
+			Type:      engine,
 			Directory: n.ResolvePath(name),
 			Namespace: namespace,
 			Cache:     cache,
@@ -734,17 +795,26 @@ func (n *Node) OpenDatabaseWithFreezer(name string, cache, handles int, ancient
 	}
 	var db ethdb.Database
 	var err error
-	if n.config.DataDir == "" {
+	switch {
+	case n.config.DataDir == "":
 		db = rawdb.NewMemoryDatabase()
-	} else {
+	case n.config.DataDirReadOnly:
+		db, err = rawdb.NewReplicaDatabase(n.ResolvePath(name), n.ResolveAncient(name, ancient), cache, handles, namespace)
+	default:
+		var compression rawdb.FreezerCompression
+		if compression, err = rawdb.ParseFreezerCompression(n.config.AncientCompression); err != nil {
+			return nil, err
+		}
 		db, err = rawdb.Open(rawdb.OpenOptions{
-			Type:              n.config.DBEngine,
-			Directory:         n.ResolvePath(name),
-			AncientsDirectory: n.ResolveAncient(name, ancient),
-			Namespace:         namespace,
-			Cache:             cache,
-			Handles:           handles,
-			ReadOnly:          readonly,
+			Type:               n.config.DBEngine,
+			Remote:             n.config.DBRemote,
+			Directory:          n.ResolvePath(name),
+			AncientsDirectory:  n.ResolveAncient(name, ancient),
+			Namespace:          namespace,
+			Cache:              cache,
+			Handles:            handles,
+			ReadOnly:           readonly,
+			AncientCompression: compression,
 		})
 	}
 
@@ -785,6 +855,15 @@ func (db *closeTrackingDB) Close() error {
 	return db.Database.Close()
 }
 
+// Refresh implements ethdb.Refresher, catching up a database opened from a
+// read-only data directory with the writes of the node owning it.
+func (db *closeTrackingDB) Refresh() error {
+	if r, ok := db.Database.(ethdb.Refresher); ok {
+		return r.Refresh()
+	}
+	return errNotReplica
+}
+
 // wrapDatabase ensures the database will be auto-closed when Node is closed.
 func (n *Node) wrapDatabase(db ethdb.Database) ethdb.Database {
 	wrapper := &closeTrackingDB{db, n}
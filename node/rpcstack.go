@@ -36,16 +36,24 @@ type httpConfig struct {
 	Modules            []string
 	CorsAllowedOrigins []string
 	Vhosts             []string
-	prefix             string // path prefix on which to mount http handler
-	jwtSecret          []byte // optional JWT secret
+	prefix             string             // path prefix on which to mount http handler
+	jwtSecret          []byte             // optional JWT secret
+	rateLimiter        *rpc.RateLimiter   // optional rate limiter of calls
+	authKeys           *rpc.AuthKeys      // optional API keys permitted to call
+	batchLimits        *rpc.BatchLimits   // optional cost limits of batch requests
+	tracer             *rpc.RequestTracer // optional tracer of calls
 }
 
 // wsConfig is the JSON-RPC/Websocket configuration
 type wsConfig struct {
-	Origins   []string
-	Modules   []string
-	prefix    string // path prefix on which to mount ws handler
-	jwtSecret []byte // optional JWT secret
+	Origins     []string
+	Modules     []string
+	prefix      string             // path prefix on which to mount ws handler
+	jwtSecret   []byte             // optional JWT secret
+	rateLimiter *rpc.RateLimiter   // optional rate limiter of calls
+	authKeys    *rpc.AuthKeys      // optional API keys permitted to call
+	batchLimits *rpc.BatchLimits   // optional cost limits of batch requests
+	tracer      *rpc.RequestTracer // optional tracer of calls
 }
 
 type rpcHandler struct {
@@ -296,6 +304,18 @@ func (h *httpServer) enableRPC(apis []rpc.API, config httpConfig) error {
 	if err := RegisterApis(apis, config.Modules, srv); err != nil {
 		return err
 	}
+	if config.rateLimiter != nil {
+		srv.SetRateLimiter(config.rateLimiter)
+	}
+	if config.authKeys != nil {
+		srv.SetAuthKeys(config.authKeys)
+	}
+	if config.batchLimits != nil {
+		srv.SetBatchLimits(config.batchLimits)
+	}
+	if config.tracer != nil {
+		srv.SetRequestTracer(config.tracer)
+	}
 	h.httpConfig = config
 	h.httpHandler.Store(&rpcHandler{
 		Handler: NewHTTPHandlerStack(srv, config.CorsAllowedOrigins, config.Vhosts, config.jwtSecret),
@@ -327,6 +347,18 @@ func (h *httpServer) enableWS(apis []rpc.API, config wsConfig) error {
 	if err := RegisterApis(apis, config.Modules, srv); err != nil {
 		return err
 	}
+	if config.rateLimiter != nil {
+		srv.SetRateLimiter(config.rateLimiter)
+	}
+	if config.authKeys != nil {
+		srv.SetAuthKeys(config.authKeys)
+	}
+	if config.batchLimits != nil {
+		srv.SetBatchLimits(config.batchLimits)
+	}
+	if config.tracer != nil {
+		srv.SetRequestTracer(config.tracer)
+	}
 	h.wsConfig = config
 	h.wsHandler.Store(&rpcHandler{
 		Handler: NewWSHandlerStack(srv.WebsocketHandler(config.Origins), config.jwtSecret),
@@ -567,6 +599,7 @@ func newGzipHandler(next http.Handler) http.Handler {
 type ipcServer struct {
 	log      log.Logger
 	endpoint string
+	tracer   *rpc.RequestTracer // optional tracer of calls
 
 	mu       sync.Mutex
 	listener net.Listener
@@ -590,6 +623,9 @@ func (is *ipcServer) start(apis []rpc.API) error {
 		is.log.Warn("IPC opening failed", "url", is.endpoint, "error", err)
 		return err
 	}
+	if is.tracer != nil {
+		srv.SetRequestTracer(is.tracer)
+	}
 	is.log.Info("IPC endpoint opened", "url", is.endpoint)
 	is.listener, is.srv = listener, srv
 	return nil
@@ -104,6 +104,7 @@ type Peer struct {
 	running map[string]*protoRW
 	log     log.Logger
 	created mclock.AbsTime
+	traffic *trafficStats // Traffic per sub-protocol message type
 
 	wg       sync.WaitGroup
 	protoErr chan error
@@ -226,6 +227,7 @@ func newPeer(log log.Logger, conn *conn, protocols []Protocol) *Peer {
 		rw:       conn,
 		running:  protomap,
 		created:  mclock.Now(),
+		traffic:  newTrafficStats(),
 		disc:     make(chan DiscReason),
 		protoErr: make(chan error, len(protomap)+1), // protocols + pingLoop
 		closed:   make(chan struct{}),
@@ -347,6 +349,8 @@ func (p *Peer) handle(msg Msg) error {
 			metrics.GetOrRegisterMeter(m, nil).Mark(int64(msg.meterSize))
 			metrics.GetOrRegisterMeter(m+"/packets", nil).Mark(1)
 		}
+		p.traffic.ingress(proto.cap(), msg.Code-proto.offset, msg.Size)
+
 		select {
 		case proto.in <- msg:
 			return nil
@@ -401,6 +405,7 @@ func (p *Peer) startProtocols(writeStart <-chan struct{}, writeErr chan<- error)
 		proto.closed = p.closed
 		proto.wstart = writeStart
 		proto.werr = writeErr
+		proto.traffic = p.traffic
 		var rw MsgReadWriter = proto
 		if p.events != nil {
 			rw = newMsgEventer(rw, p.events, p.ID(), proto.Name, p.Info().Network.RemoteAddress, p.Info().Network.LocalAddress)
@@ -433,12 +438,13 @@ func (p *Peer) getProto(code uint64) (*protoRW, error) {
 
 type protoRW struct {
 	Protocol
-	in     chan Msg        // receives read messages
-	closed <-chan struct{} // receives when peer is shutting down
-	wstart <-chan struct{} // receives when write may start
-	werr   chan<- error    // for write results
-	offset uint64
-	w      MsgWriter
+	in      chan Msg        // receives read messages
+	closed  <-chan struct{} // receives when peer is shutting down
+	wstart  <-chan struct{} // receives when write may start
+	werr    chan<- error    // for write results
+	offset  uint64
+	w       MsgWriter
+	traffic *trafficStats // accumulates the traffic of the peer
 }
 
 func (rw *protoRW) WriteMsg(msg Msg) (err error) {
@@ -452,6 +458,7 @@ func (rw *protoRW) WriteMsg(msg Msg) (err error) {
 
 	select {
 	case <-rw.wstart:
+		rw.traffic.egress(msg.meterCap, msg.meterCode, msg.Size)
 		err = rw.w.WriteMsg(msg)
 		// Report write status back to Peer.run. It will initiate
 		// shutdown if the error is non-nil and unblock the next write
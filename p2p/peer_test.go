@@ -142,6 +142,40 @@ func TestPeerProtoReadMsg(t *testing.T) {
 	}
 }
 
+func TestPeerTrafficStats(t *testing.T) {
+	done := make(chan struct{})
+	proto := Protocol{
+		Name:    "a",
+		Version: 1,
+		Length:  5,
+		Run: func(peer *Peer, rw MsgReadWriter) error {
+			if err := ExpectMsg(rw, 2, []uint{1}); err != nil {
+				t.Error(err)
+			}
+			if err := SendItems(rw, 3, uint(2), uint(3)); err != nil {
+				t.Error(err)
+			}
+			<-done
+			return nil
+		},
+	}
+	closer, rw, peer, _ := testPeer([]Protocol{proto})
+	defer closer()
+	defer close(done)
+
+	Send(rw, baseProtocolLength+2, []uint{1})
+	if err := ExpectMsg(rw, baseProtocolLength+3, []uint{2, 3}); err != nil {
+		t.Fatal(err)
+	}
+	traffic := peer.Stats().Traffic
+	if have, want := traffic["a/1/0x02"], (MsgTraffic{InPackets: 1, InBytes: 2}); have != want {
+		t.Errorf("ingress traffic mismatch: have %+v, want %+v", have, want)
+	}
+	if have, want := traffic["a/1/0x03"], (MsgTraffic{OutPackets: 1, OutBytes: 3}); have != want {
+		t.Errorf("egress traffic mismatch: have %+v, want %+v", have, want)
+	}
+}
+
 func TestPeerProtoEncodeMsg(t *testing.T) {
 	proto := Protocol{
 		Name:   "a",
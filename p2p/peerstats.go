@@ -0,0 +1,115 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package p2p
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+)
+
+// MsgTraffic is the traffic of a single message type exchanged with a peer.
+// Sizes are of the uncompressed message payloads.
+type MsgTraffic struct {
+	InPackets  uint64 `json:"inPackets"`
+	InBytes    uint64 `json:"inBytes"`
+	OutPackets uint64 `json:"outPackets"`
+	OutBytes   uint64 `json:"outBytes"`
+}
+
+// trafficStats accumulates the traffic of a peer per sub-protocol message type.
+type trafficStats struct {
+	lock sync.Mutex
+	msgs map[string]*MsgTraffic // Traffic keyed by protocol/version/code
+}
+
+func newTrafficStats() *trafficStats {
+	return &trafficStats{msgs: make(map[string]*MsgTraffic)}
+}
+
+// msg returns the traffic of a message type, creating it if needed. It assumes
+// the lock is held.
+func (s *trafficStats) msg(cap Cap, code uint64) *MsgTraffic {
+	key := fmt.Sprintf("%s/%d/%#02x", cap.Name, cap.Version, code)
+	msg := s.msgs[key]
+	if msg == nil {
+		msg = new(MsgTraffic)
+		s.msgs[key] = msg
+	}
+	return msg
+}
+
+// ingress records an inbound message.
+func (s *trafficStats) ingress(cap Cap, code uint64, size uint32) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	msg := s.msg(cap, code)
+	msg.InPackets++
+	msg.InBytes += uint64(size)
+}
+
+// egress records an outbound message.
+func (s *trafficStats) egress(cap Cap, code uint64, size uint32) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	msg := s.msg(cap, code)
+	msg.OutPackets++
+	msg.OutBytes += uint64(size)
+}
+
+// snapshot returns a copy of the accumulated traffic.
+func (s *trafficStats) snapshot() map[string]MsgTraffic {
+	msgs := make(map[string]MsgTraffic)
+	if s == nil {
+		return msgs
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for key, msg := range s.msgs {
+		msgs[key] = *msg
+	}
+	return msgs
+}
+
+// PeerStats represents the rolling statistics of a connected peer: the traffic
+// per message type since the connection was established and the sub-protocol
+// specific metadata, such as block propagation latencies.
+type PeerStats struct {
+	ID        string                 `json:"id"`        // Unique node identifier
+	Name      string                 `json:"name"`      // Name of the node, including client type, version, OS, custom data
+	Connected uint64                 `json:"connected"` // Seconds since the connection was established
+	Traffic   map[string]MsgTraffic  `json:"traffic"`   // Traffic keyed by protocol/version/code
+	Protocols map[string]interface{} `json:"protocols"` // Sub-protocol specific metadata fields
+}
+
+// Stats gathers and returns the rolling statistics of a peer.
+func (p *Peer) Stats() *PeerStats {
+	return &PeerStats{
+		ID:        p.ID().String(),
+		Name:      p.Fullname(),
+		Connected: uint64(time.Duration(mclock.Now()-p.created) / time.Second),
+		Traffic:   p.traffic.snapshot(),
+		Protocols: p.Info().Protocols,
+	}
+}
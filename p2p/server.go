@@ -1125,3 +1125,15 @@ func (srv *Server) PeersInfo() []*PeerInfo {
 	}
 	return infos
 }
+
+// PeersStats returns the rolling statistics of the connected remote nodes.
+func (srv *Server) PeersStats() []*PeerStats {
+	stats := make([]*PeerStats, 0, srv.PeerCount())
+	for _, peer := range srv.Peers() {
+		if peer != nil {
+			stats = append(stats, peer.Stats())
+		}
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].ID < stats[j].ID })
+	return stats
+}
@@ -14,6 +14,7 @@ package params
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"math/big"
 
@@ -409,11 +410,42 @@ type ChainConfig struct {
 	// even without having seen the TTD locally (safer long term).
 	TerminalTotalDifficultyPassed bool `json:"terminalTotalDifficultyPassed,omitempty"`
 
+	// FeeMarket lists the changes of the EIP-1559 fee market parameters, ordered
+	// by activation block. The defaults apply before the first one.
+	FeeMarket []*FeeMarketConfig `json:"feeMarket,omitempty"`
+
+	// PayoutSplit splits the rewards of the blocks of a coinbase among other
+	// recipients from an activation block onwards (ethash only).
+	PayoutSplit *PayoutSplitConfig `json:"payoutSplit,omitempty"`
+
 	// Various consensus engines
 	Ethash *EthashConfig `json:"ethash,omitempty"`
 	Clique *CliqueConfig `json:"clique,omitempty"`
 }
 
+// FeeMarketConfig are the EIP-1559 fee market parameters in effect from a block
+// onwards.
+type FeeMarketConfig struct {
+	Block                    *big.Int `json:"block"`                    // Block the parameters take effect at
+	ElasticityMultiplier     uint64   `json:"elasticityMultiplier"`     // Ratio of the gas limit to the gas target
+	BaseFeeChangeDenominator uint64   `json:"baseFeeChangeDenominator"` // Inverse of the maximum base fee change between blocks
+}
+
+// PayoutSplitConfig transfers shares of the block and uncle inclusion rewards and
+// of the priority fees of the blocks mined to a coinbase to other recipients.
+// Any other value credited to the coinbase, e.g. direct transfers, is not split.
+type PayoutSplitConfig struct {
+	Block    *big.Int       `json:"block"`    // Block the split takes effect at
+	Coinbase common.Address `json:"coinbase"` // Coinbase whose rewards are split
+	Shares   []PayoutShare  `json:"shares"`   // Recipients, the remainder stays with the coinbase
+}
+
+// PayoutShare is a recipient of a percentage of the split rewards.
+type PayoutShare struct {
+	Address common.Address `json:"address"`
+	Percent uint64         `json:"percent"`
+}
+
 // EthashConfig is the consensus engine configs for proof-of-work based sealing.
 type EthashConfig struct{}
 
@@ -658,7 +690,10 @@ func (c *ChainConfig) CheckConfigForkOrder() error {
 			lastFork = cur
 		}
 	}
-	return nil
+	if err := c.checkFeeMarket(); err != nil {
+		return err
+	}
+	return c.checkPayoutSplit()
 }
 
 func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, headNumber *big.Int, headTimestamp uint64) *ConfigCompatError {
@@ -726,19 +761,127 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, headNumber *big.Int,
 	if isForkTimestampIncompatible(c.PragueTime, newcfg.PragueTime, headTimestamp) {
 		return newTimestampCompatError("Prague fork timestamp", c.PragueTime, newcfg.PragueTime)
 	}
+	if incompatible, stored, new := isFeeMarketIncompatible(c.FeeMarket, newcfg.FeeMarket, headNumber); incompatible {
+		return newBlockCompatError("Fee market parameters", stored, new)
+	}
+	if incompatible, stored, new := isPayoutSplitIncompatible(c.PayoutSplit, newcfg.PayoutSplit, headNumber); incompatible {
+		return newBlockCompatError("Payout split", stored, new)
+	}
 	return nil
 }
 
-// BaseFeeChangeDenominator bounds the amount the base fee can change between blocks.
-func (c *ChainConfig) BaseFeeChangeDenominator() uint64 {
+// BaseFeeChangeDenominator bounds the amount the base fee can change between
+// blocks, as of the given block.
+func (c *ChainConfig) BaseFeeChangeDenominator(num *big.Int) uint64 {
+	if market := c.feeMarket(num); market != nil {
+		return market.BaseFeeChangeDenominator
+	}
 	return DefaultBaseFeeChangeDenominator
 }
 
-// ElasticityMultiplier bounds the maximum gas limit an EIP-1559 block may have.
-func (c *ChainConfig) ElasticityMultiplier() uint64 {
+// ElasticityMultiplier bounds the maximum gas limit an EIP-1559 block may have,
+// as of the given block.
+func (c *ChainConfig) ElasticityMultiplier(num *big.Int) uint64 {
+	if market := c.feeMarket(num); market != nil {
+		return market.ElasticityMultiplier
+	}
 	return DefaultElasticityMultiplier
 }
 
+// feeMarket returns the fee market parameters in effect at the given block, nil
+// if the defaults are.
+func (c *ChainConfig) feeMarket(num *big.Int) *FeeMarketConfig {
+	for i := len(c.FeeMarket) - 1; i >= 0; i-- {
+		if isBlockForked(c.FeeMarket[i].Block, num) {
+			return c.FeeMarket[i]
+		}
+	}
+	return nil
+}
+
+// checkFeeMarket verifies the fee market parameter changes are ordered by block
+// and usable.
+func (c *ChainConfig) checkFeeMarket() error {
+	for i, market := range c.FeeMarket {
+		if market == nil || market.Block == nil {
+			return fmt.Errorf("fee market change %d without activation block", i)
+		}
+		if i > 0 && market.Block.Cmp(c.FeeMarket[i-1].Block) <= 0 {
+			return fmt.Errorf("unsupported fee market ordering: change at block %v follows block %v", market.Block, c.FeeMarket[i-1].Block)
+		}
+		if market.ElasticityMultiplier == 0 || market.BaseFeeChangeDenominator == 0 {
+			return fmt.Errorf("invalid fee market parameters at block %v: zero elasticity multiplier or base fee change denominator", market.Block)
+		}
+	}
+	return nil
+}
+
+// isFeeMarketIncompatible returns the activation blocks of the first fee market
+// change differing between two configs, if one of them is in effect at head.
+func isFeeMarketIncompatible(s1, s2 []*FeeMarketConfig, head *big.Int) (bool, *big.Int, *big.Int) {
+	for i := 0; i < len(s1) || i < len(s2); i++ {
+		var m1, m2 FeeMarketConfig
+		if i < len(s1) {
+			m1 = *s1[i]
+		}
+		if i < len(s2) {
+			m2 = *s2[i]
+		}
+		if configBlockEqual(m1.Block, m2.Block) && m1.ElasticityMultiplier == m2.ElasticityMultiplier && m1.BaseFeeChangeDenominator == m2.BaseFeeChangeDenominator {
+			continue
+		}
+		return isBlockForked(m1.Block, head) || isBlockForked(m2.Block, head), m1.Block, m2.Block
+	}
+	return false, nil, nil
+}
+
+// IsPayoutSplit returns whether the payout split is in effect at num.
+func (c *ChainConfig) IsPayoutSplit(num *big.Int) bool {
+	return c.PayoutSplit != nil && isBlockForked(c.PayoutSplit.Block, num)
+}
+
+// checkPayoutSplit verifies the payout split has an activation block and its
+// shares do not exceed the rewards.
+func (c *ChainConfig) checkPayoutSplit() error {
+	split := c.PayoutSplit
+	if split == nil {
+		return nil
+	}
+	if split.Block == nil {
+		return errors.New("payout split without activation block")
+	}
+	var total uint64
+	for _, share := range split.Shares {
+		if share.Percent == 0 {
+			return fmt.Errorf("invalid payout split: zero share of %v", share.Address)
+		}
+		if total += share.Percent; total > 100 {
+			return errors.New("invalid payout split: shares exceed 100 percent")
+		}
+	}
+	return nil
+}
+
+// isPayoutSplitIncompatible returns the activation blocks of two payout splits
+// if they differ and one of them is in effect at head.
+func isPayoutSplitIncompatible(s1, s2 *PayoutSplitConfig, head *big.Int) (bool, *big.Int, *big.Int) {
+	var p1, p2 PayoutSplitConfig
+	if s1 != nil {
+		p1 = *s1
+	}
+	if s2 != nil {
+		p2 = *s2
+	}
+	equal := configBlockEqual(p1.Block, p2.Block) && p1.Coinbase == p2.Coinbase && len(p1.Shares) == len(p2.Shares)
+	for i := 0; equal && i < len(p1.Shares); i++ {
+		equal = p1.Shares[i] == p2.Shares[i]
+	}
+	if equal {
+		return false, nil, nil
+	}
+	return isBlockForked(p1.Block, head) || isBlockForked(p2.Block, head), p1.Block, p2.Block
+}
+
 // isForkBlockIncompatible returns true if a fork scheduled at block s1 cannot be
 // rescheduled to block s2 because head is already past the fork.
 func isForkBlockIncompatible(s1, s2, head *big.Int) bool {
@@ -18,6 +18,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/math"
 )
 
@@ -105,6 +106,46 @@ func TestCheckCompatible(t *testing.T) {
 				RewindToTime: 9,
 			},
 		},
+		{
+			stored:    &ChainConfig{FeeMarket: []*FeeMarketConfig{{Block: big.NewInt(20), ElasticityMultiplier: 4, BaseFeeChangeDenominator: 8}}},
+			new:       &ChainConfig{FeeMarket: []*FeeMarketConfig{{Block: big.NewInt(30), ElasticityMultiplier: 4, BaseFeeChangeDenominator: 8}}},
+			headBlock: 19,
+			wantErr:   nil,
+		},
+		{
+			stored:    &ChainConfig{FeeMarket: []*FeeMarketConfig{{Block: big.NewInt(20), ElasticityMultiplier: 4, BaseFeeChangeDenominator: 8}}},
+			new:       &ChainConfig{FeeMarket: []*FeeMarketConfig{{Block: big.NewInt(20), ElasticityMultiplier: 3, BaseFeeChangeDenominator: 8}}},
+			headBlock: 25,
+			wantErr: &ConfigCompatError{
+				What:          "Fee market parameters",
+				StoredBlock:   big.NewInt(20),
+				NewBlock:      big.NewInt(20),
+				RewindToBlock: 19,
+			},
+		},
+		{
+			stored:    &ChainConfig{FeeMarket: []*FeeMarketConfig{{Block: big.NewInt(20), ElasticityMultiplier: 4, BaseFeeChangeDenominator: 8}}},
+			new:       &ChainConfig{FeeMarket: []*FeeMarketConfig{{Block: big.NewInt(20), ElasticityMultiplier: 4, BaseFeeChangeDenominator: 8}, {Block: big.NewInt(40), ElasticityMultiplier: 2, BaseFeeChangeDenominator: 16}}},
+			headBlock: 25,
+			wantErr:   nil,
+		},
+		{
+			stored:    &ChainConfig{PayoutSplit: &PayoutSplitConfig{Block: big.NewInt(20), Shares: []PayoutShare{{Address: common.Address{1}, Percent: 50}}}},
+			new:       &ChainConfig{PayoutSplit: &PayoutSplitConfig{Block: big.NewInt(30), Shares: []PayoutShare{{Address: common.Address{1}, Percent: 50}}}},
+			headBlock: 19,
+			wantErr:   nil,
+		},
+		{
+			stored:    &ChainConfig{PayoutSplit: &PayoutSplitConfig{Block: big.NewInt(20), Shares: []PayoutShare{{Address: common.Address{1}, Percent: 50}}}},
+			new:       &ChainConfig{PayoutSplit: &PayoutSplitConfig{Block: big.NewInt(20), Shares: []PayoutShare{{Address: common.Address{1}, Percent: 40}}}},
+			headBlock: 25,
+			wantErr: &ConfigCompatError{
+				What:          "Payout split",
+				StoredBlock:   big.NewInt(20),
+				NewBlock:      big.NewInt(20),
+				RewindToBlock: 19,
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -115,6 +156,69 @@ func TestCheckCompatible(t *testing.T) {
 	}
 }
 
+func TestFeeMarket(t *testing.T) {
+	c := &ChainConfig{FeeMarket: []*FeeMarketConfig{
+		{Block: big.NewInt(10), ElasticityMultiplier: 4, BaseFeeChangeDenominator: 16},
+		{Block: big.NewInt(20), ElasticityMultiplier: 3, BaseFeeChangeDenominator: 32},
+	}}
+	for _, test := range []struct {
+		number              int64
+		elasticity, denomin uint64
+	}{
+		{0, DefaultElasticityMultiplier, DefaultBaseFeeChangeDenominator},
+		{9, DefaultElasticityMultiplier, DefaultBaseFeeChangeDenominator},
+		{10, 4, 16},
+		{19, 4, 16},
+		{20, 3, 32},
+		{1000, 3, 32},
+	} {
+		num := big.NewInt(test.number)
+		if have := c.ElasticityMultiplier(num); have != test.elasticity {
+			t.Errorf("block %d: elasticity multiplier mismatch: have %d, want %d", test.number, have, test.elasticity)
+		}
+		if have := c.BaseFeeChangeDenominator(num); have != test.denomin {
+			t.Errorf("block %d: base fee change denominator mismatch: have %d, want %d", test.number, have, test.denomin)
+		}
+	}
+	if err := c.CheckConfigForkOrder(); err != nil {
+		t.Errorf("valid fee market rejected: %v", err)
+	}
+	invalid := [][]*FeeMarketConfig{
+		{{ElasticityMultiplier: 2, BaseFeeChangeDenominator: 8}},
+		{{Block: big.NewInt(10), ElasticityMultiplier: 0, BaseFeeChangeDenominator: 8}},
+		{{Block: big.NewInt(10), ElasticityMultiplier: 2, BaseFeeChangeDenominator: 0}},
+		{{Block: big.NewInt(10), ElasticityMultiplier: 2, BaseFeeChangeDenominator: 8}, {Block: big.NewInt(10), ElasticityMultiplier: 3, BaseFeeChangeDenominator: 8}},
+	}
+	for i, market := range invalid {
+		if err := (&ChainConfig{FeeMarket: market}).CheckConfigForkOrder(); err == nil {
+			t.Errorf("invalid fee market %d accepted", i)
+		}
+	}
+}
+
+func TestPayoutSplit(t *testing.T) {
+	c := &ChainConfig{PayoutSplit: &PayoutSplitConfig{
+		Block:  big.NewInt(10),
+		Shares: []PayoutShare{{Address: common.Address{1}, Percent: 70}, {Address: common.Address{2}, Percent: 30}},
+	}}
+	if c.IsPayoutSplit(big.NewInt(9)) || !c.IsPayoutSplit(big.NewInt(10)) {
+		t.Errorf("payout split activation mismatch")
+	}
+	if err := c.CheckConfigForkOrder(); err != nil {
+		t.Errorf("valid payout split rejected: %v", err)
+	}
+	invalid := []*PayoutSplitConfig{
+		{Shares: []PayoutShare{{Address: common.Address{1}, Percent: 10}}},
+		{Block: big.NewInt(10), Shares: []PayoutShare{{Address: common.Address{1}, Percent: 0}}},
+		{Block: big.NewInt(10), Shares: []PayoutShare{{Address: common.Address{1}, Percent: 60}, {Address: common.Address{2}, Percent: 41}}},
+	}
+	for i, split := range invalid {
+		if err := (&ChainConfig{PayoutSplit: split}).CheckConfigForkOrder(); err == nil {
+			t.Errorf("invalid payout split %d accepted", i)
+		}
+	}
+}
+
 func TestConfigRules(t *testing.T) {
 	c := &ChainConfig{
 		ShanghaiTime: newUint64(500),
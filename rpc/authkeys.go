@@ -0,0 +1,87 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// APIKeyHeader is the HTTP header carrying the API key of HTTP requests and
+// WebSocket handshakes.
+const APIKeyHeader = "X-Api-Key"
+
+// unauthorizedError is returned for calls not permitted for the API key used.
+type unauthorizedError struct{ method string }
+
+func (e *unauthorizedError) ErrorCode() int { return errcodeDefault }
+
+func (e *unauthorizedError) Error() string {
+	return fmt.Sprintf("unauthorized to call %s", e.method)
+}
+
+// AuthKeys are the API keys permitted to call methods over HTTP, WebSocket and
+// the gRPC gateway, each with the namespaces or methods it may call. Calls
+// without a permitted key are rejected, calls via IPC or in-process are never
+// checked.
+type AuthKeys struct {
+	keys map[string]map[string]bool // Key -> permitted namespaces and methods
+}
+
+// LoadAuthKeys loads the API keys from a JSON file mapping each key to the
+// namespaces or methods it may call, e.g.
+//
+//	{"<key>": ["eth_getWork", "eth_submitWork"], "<admin key>": ["eth", "ethash", "debug"]}
+func LoadAuthKeys(path string) (*AuthKeys, error) {
+	blob, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var perms map[string][]string
+	if err := json.Unmarshal(blob, &perms); err != nil {
+		return nil, fmt.Errorf("invalid auth keys file: %v", err)
+	}
+	return NewAuthKeys(perms), nil
+}
+
+// NewAuthKeys creates the API keys permitting the given namespaces or methods
+// per key.
+func NewAuthKeys(perms map[string][]string) *AuthKeys {
+	keys := make(map[string]map[string]bool, len(perms))
+	for key, allowed := range perms {
+		keys[key] = make(map[string]bool, len(allowed))
+		for _, name := range allowed {
+			keys[key][name] = true
+		}
+	}
+	return &AuthKeys{keys: keys}
+}
+
+// Allowed reports whether a call of the method over the given connection is
+// permitted. Unsubscribing is permitted along with subscribing.
+func (a *AuthKeys) Allowed(method string, info PeerInfo) bool {
+	if info.Transport != "http" && info.Transport != "ws" && info.Transport != "grpc" {
+		return true
+	}
+	perms := a.keys[info.HTTP.APIKey]
+	if perms == nil {
+		return false
+	}
+	if strings.HasSuffix(method, unsubscribeMethodSuffix) {
+		method = strings.TrimSuffix(method, unsubscribeMethodSuffix) + subscribeMethodSuffix
+	}
+	namespace, _, _ := strings.Cut(method, serviceMethodSeparator)
+	return perms[namespace] || perms[method]
+}
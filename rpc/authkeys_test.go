@@ -0,0 +1,65 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package rpc
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Tests that API keys restrict the methods callable over HTTP to the permitted
+// namespaces and methods.
+func TestAuthKeysHTTP(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	if err := os.WriteFile(path, []byte(`{"miner": ["test_echo"], "admin": ["test", "rpc"]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := LoadAuthKeys(path)
+	if err != nil {
+		t.Fatalf("failed to load keys: %v", err)
+	}
+	server := newTestServer()
+	defer server.Stop()
+	server.SetAuthKeys(keys)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	call := func(key, method string, args ...interface{}) error {
+		client, err := DialOptions(context.Background(), ts.URL, WithHeader(APIKeyHeader, key))
+		if err != nil {
+			t.Fatalf("failed to dial: %v", err)
+		}
+		defer client.Close()
+		return client.Call(nil, method, args...)
+	}
+	echo := []interface{}{"x", 1, &echoArgs{S: "y"}}
+	if err := call("miner", "test_echo", echo...); err != nil {
+		t.Errorf("permitted method rejected: %v", err)
+	}
+	if err := call("miner", "test_null"); err == nil {
+		t.Error("method outside the permissions accepted")
+	}
+	if err := call("admin", "test_null"); err != nil {
+		t.Errorf("method of permitted namespace rejected: %v", err)
+	}
+	if err := call("unknown", "test_echo", echo...); err == nil {
+		t.Error("unknown key accepted")
+	}
+	// In-process calls are never checked
+	if err := DialInProc(server).Call(nil, "test_null"); err != nil {
+		t.Errorf("in-process call rejected: %v", err)
+	}
+}
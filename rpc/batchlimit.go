@@ -0,0 +1,104 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package rpc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// BatchLimits bounds the cost of the calls of a batch request. Every method has
+// a weight, one unless configured otherwise, and the weights of the calls of a
+// batch may not exceed the budget. Over budget batches are rejected entirely,
+// or in partial mode executed up to the first call exceeding the budget.
+type BatchLimits struct {
+	Budget  uint64            // Maximum total weight of the calls of a batch
+	Weights map[string]uint64 // Weights by method or namespace wildcard, e.g. "debug_*"
+	Partial bool              // Execute the calls within budget instead of rejecting the batch
+}
+
+// ParseBatchWeights parses a comma separated list of method=weight entries, e.g.
+// "debug_traceBlockByNumber=100,debug_*=20". A namespace wildcard weighs all the
+// methods of the namespace without a weight of their own.
+func ParseBatchWeights(spec string) (map[string]uint64, error) {
+	weights := make(map[string]uint64)
+	for _, entry := range strings.Split(spec, ",") {
+		method, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || method == "" {
+			return nil, fmt.Errorf("invalid batch weight %q", entry)
+		}
+		weight, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid weight in %q", entry)
+		}
+		weights[method] = weight
+	}
+	return weights, nil
+}
+
+// weight returns the cost of a call of the method.
+func (l *BatchLimits) weight(method string) uint64 {
+	if weight, ok := l.Weights[method]; ok {
+		return weight
+	}
+	namespace, _, _ := strings.Cut(method, serviceMethodSeparator)
+	if weight, ok := l.Weights[namespace+serviceMethodSeparator+"*"]; ok {
+		return weight
+	}
+	return 1
+}
+
+// allowed returns the number of leading calls of a batch to execute, the rest
+// being rejected, along with the total cost of the batch.
+func (l *BatchLimits) allowed(calls []*jsonrpcMessage) (int, uint64) {
+	var (
+		cost    uint64
+		allowed = -1
+	)
+	for i, msg := range calls {
+		cost += l.weight(msg.Method)
+		if cost > l.Budget && allowed < 0 {
+			allowed = i
+		}
+	}
+	switch {
+	case allowed < 0:
+		return len(calls), cost
+	case l.Partial:
+		return allowed, cost
+	default:
+		return 0, cost
+	}
+}
+
+// batchCostData is the error data of calls rejected for the cost of their batch.
+type batchCostData struct {
+	Cost   uint64 `json:"cost"`   // Total weight of the calls of the batch
+	Budget uint64 `json:"budget"` // Maximum total weight allowed
+}
+
+// batchCostError is returned for the calls of a batch over budget.
+type batchCostError struct {
+	cost, budget uint64
+}
+
+func (e *batchCostError) ErrorCode() int { return errcodeLimitExceeded }
+
+func (e *batchCostError) Error() string {
+	return fmt.Sprintf("batch cost %d exceeds budget %d", e.cost, e.budget)
+}
+
+func (e *batchCostError) ErrorData() interface{} {
+	return batchCostData{Cost: e.cost, Budget: e.budget}
+}
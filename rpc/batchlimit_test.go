@@ -0,0 +1,89 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package rpc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParseBatchWeights(t *testing.T) {
+	weights, err := ParseBatchWeights("test_echo=5, test_*=2")
+	if err != nil {
+		t.Fatalf("failed to parse weights: %v", err)
+	}
+	limits := &BatchLimits{Weights: weights}
+	for method, want := range map[string]uint64{"test_echo": 5, "test_sleep": 2, "rpc_modules": 1} {
+		if have := limits.weight(method); have != want {
+			t.Errorf("%s: weight mismatch: have %d, want %d", method, have, want)
+		}
+	}
+	for _, spec := range []string{"", "test_echo", "=1", "test_echo=x", "test_echo=-1"} {
+		if _, err := ParseBatchWeights(spec); err == nil {
+			t.Errorf("spec %q: expected error", spec)
+		}
+	}
+}
+
+// Tests that batches over budget are rejected over HTTP, entirely or past the
+// budget in partial mode.
+func TestBatchLimitsHTTP(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	batch := func() []*jsonrpcMessage {
+		var calls []string
+		for i := 0; i < 4; i++ {
+			calls = append(calls, `{"jsonrpc":"2.0","id":`+strconv.Itoa(i)+`,"method":"test_echo","params":["x",1]}`)
+		}
+		resp, err := http.Post(ts.URL, "application/json", strings.NewReader("["+strings.Join(calls, ",")+"]"))
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		defer resp.Body.Close()
+
+		var msgs []*jsonrpcMessage
+		if err := json.NewDecoder(resp.Body).Decode(&msgs); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(msgs) != 4 {
+			t.Fatalf("response count mismatch: have %d, want 4", len(msgs))
+		}
+		return msgs
+	}
+	check := func(msgs []*jsonrpcMessage, served int) {
+		t.Helper()
+		for i, msg := range msgs {
+			if i < served && msg.Error != nil {
+				t.Errorf("call %d within budget rejected: %v", i, msg.Error)
+			}
+			if i >= served && (msg.Error == nil || msg.Error.Code != errcodeLimitExceeded) {
+				t.Errorf("call %d over budget not rejected: %+v", i, msg.Error)
+			}
+		}
+	}
+	server.SetBatchLimits(&BatchLimits{Budget: 8, Weights: map[string]uint64{"test_*": 2}})
+	check(batch(), 4)
+
+	server.SetBatchLimits(&BatchLimits{Budget: 5, Weights: map[string]uint64{"test_*": 2}})
+	check(batch(), 0)
+
+	server.SetBatchLimits(&BatchLimits{Budget: 5, Weights: map[string]uint64{"test_*": 2}, Partial: true})
+	check(batch(), 2)
+}
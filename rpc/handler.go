@@ -184,7 +184,15 @@ func (h *handler) handleBatch(msgs []*jsonrpcMessage) {
 			timer      *time.Timer
 			cancel     context.CancelFunc
 			callBuffer = &batchCallBuffer{calls: calls, resp: make([]*jsonrpcMessage, 0, len(calls))}
+			allowed    = len(calls)
+			costErr    *batchCostError
 		)
+		if limits := h.reg.batchLimits(); limits != nil {
+			var cost uint64
+			if allowed, cost = limits.allowed(calls); allowed < len(calls) {
+				costErr = &batchCostError{cost: cost, budget: limits.Budget}
+			}
+		}
 
 		cp.ctx, cancel = context.WithCancel(cp.ctx)
 		defer cancel()
@@ -199,7 +207,7 @@ func (h *handler) handleBatch(msgs []*jsonrpcMessage) {
 			})
 		}
 
-		for {
+		for i := 0; ; i++ {
 			// No need to handle rest of calls if timed out.
 			if cp.ctx.Err() != nil {
 				break
@@ -208,7 +216,15 @@ func (h *handler) handleBatch(msgs []*jsonrpcMessage) {
 			if msg == nil {
 				break
 			}
-			resp := h.handleCallMsg(cp, msg)
+			var resp *jsonrpcMessage
+			switch {
+			case i >= allowed && msg.isCall():
+				resp = msg.errorResponse(costErr)
+			case i >= allowed && msg.isNotification():
+				// Over budget notifications are dropped silently
+			default:
+				resp = h.handleCallMsg(cp, msg)
+			}
 			callBuffer.pushResponse(resp)
 		}
 		if timer != nil {
@@ -414,6 +430,9 @@ func (h *handler) handleCallMsg(ctx *callProc, msg *jsonrpcMessage) *jsonrpcMess
 		return nil
 	case msg.isCall():
 		resp := h.handleCall(ctx, msg)
+		if tracer := h.reg.requestTracer(); tracer != nil {
+			tracer.record(msg.Method, PeerInfoFromContext(ctx.ctx), time.Since(start), len(msg.Params), len(resp.Result), resp.Error)
+		}
 		var ctx []interface{}
 		ctx = append(ctx, "reqid", idForLog{msg.ID}, "duration", time.Since(start))
 		if resp.Error != nil {
@@ -435,6 +454,15 @@ func (h *handler) handleCallMsg(ctx *callProc, msg *jsonrpcMessage) *jsonrpcMess
 
 // handleCall processes method calls.
 func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage {
+	info := PeerInfoFromContext(cp.ctx)
+	if keys := h.reg.authorizer(); keys != nil && !keys.Allowed(msg.Method, info) {
+		return msg.errorResponse(&unauthorizedError{method: msg.Method})
+	}
+	if limiter := h.reg.rateLimiter(); limiter != nil {
+		if wait, ok := limiter.Allow(msg.Method, info); !ok {
+			return msg.errorResponse(&rateLimitedError{method: msg.Method, retryAfter: wait})
+		}
+	}
 	if msg.isSubscribe() {
 		return h.handleSubscribe(cp, msg)
 	}
@@ -257,6 +257,9 @@ func newHTTPServerConn(r *http.Request, w http.ResponseWriter) ServerCodec {
 	conn := &httpServerConn{Reader: body, Writer: w, r: r}
 
 	encoder := func(v any, isErrorResponse bool) error {
+		if wait := retryAfter(v); wait > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(wait))
+		}
 		if !isErrorResponse {
 			return json.NewEncoder(conn).Encode(v)
 		}
@@ -322,6 +325,7 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	connInfo.HTTP.Host = r.Host
 	connInfo.HTTP.Origin = r.Header.Get("Origin")
 	connInfo.HTTP.UserAgent = r.Header.Get("User-Agent")
+	connInfo.HTTP.APIKey = r.Header.Get(APIKeyHeader)
 	ctx := r.Context()
 	ctx = context.WithValue(ctx, peerInfoContextKey{}, connInfo)
 
@@ -0,0 +1,198 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package rpc
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// errcodeLimitExceeded is the error code of calls exceeding a rate limit.
+	errcodeLimitExceeded = -32005
+
+	// rateLimitPruneInterval is the interval at which idle clients are forgotten.
+	rateLimitPruneInterval = time.Minute
+)
+
+// RateLimit is the sustained rate and burst of calls allowed per client.
+type RateLimit struct {
+	Rate  float64 // Calls per second
+	Burst int     // Calls allowed at once
+}
+
+// ParseRateLimits parses a comma separated list of method=rate[:burst] limits,
+// e.g. "eth_getWork=10:20,ethash_*=5". A namespace wildcard limits all methods
+// of the namespace not limited on their own, sharing one allowance. The burst
+// defaults to the rate, rounded up.
+func ParseRateLimits(spec string) (map[string]RateLimit, error) {
+	limits := make(map[string]RateLimit)
+	for _, entry := range strings.Split(spec, ",") {
+		method, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || method == "" {
+			return nil, fmt.Errorf("invalid rate limit %q", entry)
+		}
+		rateStr, burstStr, hasBurst := strings.Cut(value, ":")
+		limit, err := strconv.ParseFloat(rateStr, 64)
+		if err != nil || limit <= 0 {
+			return nil, fmt.Errorf("invalid rate in %q", entry)
+		}
+		burst := int(math.Ceil(limit))
+		if hasBurst {
+			if burst, err = strconv.Atoi(burstStr); err != nil || burst <= 0 {
+				return nil, fmt.Errorf("invalid burst in %q", entry)
+			}
+		}
+		limits[method] = RateLimit{Rate: limit, Burst: burst}
+	}
+	return limits, nil
+}
+
+// rateKey identifies the allowance of a client for a limited method or namespace.
+type rateKey struct {
+	limit  string
+	client string
+}
+
+// rateBucket is the allowance of a client.
+type rateBucket struct {
+	limiter *rate.Limiter
+	last    time.Time
+}
+
+// RateLimiter limits the rate of RPC method calls per client, identified by its
+// API key if any, or the IP address the call originates from. Calls without a
+// remote address, e.g. via IPC or in-process, are not limited.
+type RateLimiter struct {
+	limits map[string]RateLimit
+
+	lock    sync.Mutex
+	buckets map[rateKey]*rateBucket
+	pruned  time.Time
+}
+
+// NewRateLimiter creates a rate limiter enforcing the given limits by method.
+func NewRateLimiter(limits map[string]RateLimit) *RateLimiter {
+	return &RateLimiter{
+		limits:  limits,
+		buckets: make(map[rateKey]*rateBucket),
+		pruned:  time.Now(),
+	}
+}
+
+// Allow reports whether a client may call the method now, returning the time
+// to wait before retrying otherwise.
+func (l *RateLimiter) Allow(method string, info PeerInfo) (time.Duration, bool) {
+	if info.RemoteAddr == "" {
+		return 0, true
+	}
+	client := "ip:" + info.RemoteAddr
+	if host, _, err := net.SplitHostPort(info.RemoteAddr); err == nil {
+		client = "ip:" + host
+	}
+	if info.HTTP.APIKey != "" {
+		client = "key:" + info.HTTP.APIKey
+	}
+	name := method
+	limit, ok := l.limits[name]
+	if !ok {
+		namespace, _, _ := strings.Cut(method, serviceMethodSeparator)
+		name = namespace + serviceMethodSeparator + "*"
+		if limit, ok = l.limits[name]; !ok {
+			return 0, true
+		}
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.pruned) > rateLimitPruneInterval {
+		l.prune(now)
+	}
+	key := rateKey{limit: name, client: client}
+	bucket := l.buckets[key]
+	if bucket == nil {
+		bucket = &rateBucket{limiter: rate.NewLimiter(rate.Limit(limit.Rate), limit.Burst)}
+		l.buckets[key] = bucket
+	}
+	bucket.last = now
+
+	reservation := bucket.limiter.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return delay, false
+	}
+	return 0, true
+}
+
+// prune forgets the clients whose allowance has fully refilled, as a new one
+// would be no different.
+func (l *RateLimiter) prune(now time.Time) {
+	for key, bucket := range l.buckets {
+		limit := l.limits[key.limit]
+		if now.Sub(bucket.last).Seconds() > float64(limit.Burst)/limit.Rate {
+			delete(l.buckets, key)
+		}
+	}
+	l.pruned = now
+}
+
+// rateLimitedData is the error data of rate limited calls.
+type rateLimitedData struct {
+	RetryAfter int `json:"retryAfter"` // Seconds to wait before retrying
+}
+
+// rateLimitedError is returned for calls exceeding the rate limit of their method.
+type rateLimitedError struct {
+	method     string
+	retryAfter time.Duration
+}
+
+func (e *rateLimitedError) ErrorCode() int { return errcodeLimitExceeded }
+
+func (e *rateLimitedError) Error() string {
+	return fmt.Sprintf("rate limit exceeded for %s", e.method)
+}
+
+func (e *rateLimitedError) ErrorData() interface{} {
+	return rateLimitedData{RetryAfter: int(math.Ceil(e.retryAfter.Seconds()))}
+}
+
+// retryAfter returns the longest time in seconds to wait before retrying the
+// rate limited calls among the responses, zero if none were rate limited.
+func retryAfter(v interface{}) int {
+	var msgs []*jsonrpcMessage
+	switch v := v.(type) {
+	case *jsonrpcMessage:
+		msgs = []*jsonrpcMessage{v}
+	case []*jsonrpcMessage:
+		msgs = v
+	}
+	var wait int
+	for _, msg := range msgs {
+		if msg.Error == nil || msg.Error.Code != errcodeLimitExceeded {
+			continue
+		}
+		if data, ok := msg.Error.Data.(rateLimitedData); ok && data.RetryAfter > wait {
+			wait = data.RetryAfter
+		}
+	}
+	return wait
+}
@@ -0,0 +1,91 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package rpc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseRateLimits(t *testing.T) {
+	limits, err := ParseRateLimits("test_echo=0.5:3, test_*=10")
+	if err != nil {
+		t.Fatalf("failed to parse limits: %v", err)
+	}
+	if have := limits["test_echo"]; have != (RateLimit{Rate: 0.5, Burst: 3}) {
+		t.Errorf("method limit mismatch: have %+v", have)
+	}
+	if have := limits["test_*"]; have != (RateLimit{Rate: 10, Burst: 10}) {
+		t.Errorf("namespace limit mismatch: have %+v", have)
+	}
+	for _, spec := range []string{"", "test_echo", "=1", "test_echo=0", "test_echo=x", "test_echo=1:0"} {
+		if _, err := ParseRateLimits(spec); err == nil {
+			t.Errorf("spec %q: expected error", spec)
+		}
+	}
+}
+
+// Tests that calls over the limit are rejected over HTTP with the time to wait
+// in the error data and the Retry-After header, per method and namespace.
+func TestRateLimitHTTP(t *testing.T) {
+	limits, _ := ParseRateLimits("test_echo=0.001:2,test_*=0.001:1")
+
+	server := newTestServer()
+	defer server.Stop()
+	server.SetRateLimiter(NewRateLimiter(limits))
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	call := func(method string) (*jsonrpcMessage, http.Header) {
+		body := `{"jsonrpc":"2.0","id":1,"method":"` + method + `","params":["x",1,{"S":"y"}]}`
+		resp, err := http.Post(ts.URL, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		defer resp.Body.Close()
+
+		var msg jsonrpcMessage
+		if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return &msg, resp.Header
+	}
+	for i := 0; i < 2; i++ {
+		if msg, _ := call("test_echo"); msg.Error != nil {
+			t.Fatalf("call %d within the burst rejected: %v", i, msg.Error)
+		}
+	}
+	msg, header := call("test_echo")
+	if msg.Error == nil || msg.Error.Code != errcodeLimitExceeded {
+		t.Fatalf("call over the limit not rejected: %+v", msg.Error)
+	}
+	if header.Get("Retry-After") == "" {
+		t.Error("missing Retry-After header")
+	}
+	if data, ok := msg.Error.Data.(map[string]interface{}); !ok || data["retryAfter"] == nil {
+		t.Errorf("missing retry time in error data: %v", msg.Error.Data)
+	}
+	// Other methods of the namespace share the wildcard allowance
+	if msg, _ := call("test_echoWithCtx"); msg.Error != nil && msg.Error.Code == errcodeLimitExceeded {
+		t.Fatalf("first call of the namespace rejected: %v", msg.Error)
+	}
+	if msg, _ := call("test_echoWithCtx"); msg.Error == nil || msg.Error.Code != errcodeLimitExceeded {
+		t.Fatalf("namespace call over the limit not rejected: %+v", msg.Error)
+	}
+	if msg, _ := call("rpc_modules"); msg.Error != nil && msg.Error.Code == errcodeLimitExceeded {
+		t.Fatalf("unlimited method rejected: %v", msg.Error)
+	}
+}
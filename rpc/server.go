@@ -69,6 +69,37 @@ func (s *Server) RegisterName(name string, receiver interface{}) error {
 	return s.services.registerName(name, receiver)
 }
 
+// SetRateLimiter limits the rate of calls served per method and client. A nil
+// limiter removes all limits.
+func (s *Server) SetRateLimiter(limiter *RateLimiter) {
+	s.services.mu.Lock()
+	defer s.services.mu.Unlock()
+	s.services.limiter = limiter
+}
+
+// SetRequestTracer records the calls served with the given tracer. A nil tracer
+// stops tracing.
+func (s *Server) SetRequestTracer(tracer *RequestTracer) {
+	s.services.mu.Lock()
+	defer s.services.mu.Unlock()
+	s.services.tracer = tracer
+}
+
+// SetBatchLimits bounds the cost of batch requests. Nil limits remove the bound.
+func (s *Server) SetBatchLimits(limits *BatchLimits) {
+	s.services.mu.Lock()
+	defer s.services.mu.Unlock()
+	s.services.batch = limits
+}
+
+// SetAuthKeys restricts calls over HTTP and WebSocket to the permissions of the
+// API keys. Nil keys remove the restriction.
+func (s *Server) SetAuthKeys(keys *AuthKeys) {
+	s.services.mu.Lock()
+	defer s.services.mu.Unlock()
+	s.services.authKeys = keys
+}
+
 // ServeCodec reads incoming requests from codec, calls the appropriate callback and writes
 // the response back using the given codec. It will block until the codec is closed or the
 // server is stopped. In either case the codec is closed.
@@ -187,6 +218,7 @@ type PeerInfo struct {
 		UserAgent string
 		Origin    string
 		Host      string
+		APIKey    string // API key of the client, see AuthKeys
 	}
 }
 
@@ -34,6 +34,10 @@ var (
 type serviceRegistry struct {
 	mu       sync.Mutex
 	services map[string]service
+	limiter  *RateLimiter   // Rate limiter of calls served, nil if unlimited
+	authKeys *AuthKeys      // API keys permitted to call methods, nil if unrestricted
+	tracer   *RequestTracer // Tracer of calls served, nil if not traced
+	batch    *BatchLimits   // Cost limits of batch requests, nil if unlimited
 }
 
 // service represents a registered object.
@@ -98,6 +102,34 @@ func (r *serviceRegistry) callback(method string) *callback {
 	return r.services[elem[0]].callbacks[elem[1]]
 }
 
+// rateLimiter returns the rate limiter of the calls served, nil if unlimited.
+func (r *serviceRegistry) rateLimiter() *RateLimiter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.limiter
+}
+
+// requestTracer returns the tracer of the calls served, nil if not traced.
+func (r *serviceRegistry) requestTracer() *RequestTracer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.tracer
+}
+
+// batchLimits returns the cost limits of batch requests, nil if unlimited.
+func (r *serviceRegistry) batchLimits() *BatchLimits {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.batch
+}
+
+// authorizer returns the API keys permitted to call methods, nil if unrestricted.
+func (r *serviceRegistry) authorizer() *AuthKeys {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.authKeys
+}
+
 // subscription returns a subscription callback in the given service.
 func (r *serviceRegistry) subscription(service, name string) *callback {
 	r.mu.Lock()
@@ -137,6 +137,14 @@ func (n *Notifier) Notify(id ID, data interface{}) error {
 	return nil
 }
 
+// Disconnect closes the RPC connection of the subscription, dropping subscribers
+// unable to keep up with their notifications.
+func (n *Notifier) Disconnect() {
+	if conn, ok := n.h.conn.(interface{ close() }); ok {
+		conn.close()
+	}
+}
+
 // Closed returns a channel that is closed when the RPC connection is closed.
 // Deprecated: use subscription error channel
 func (n *Notifier) Closed() <-chan interface{} {
@@ -0,0 +1,148 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package rpc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// tracerSamples is the number of most recent call durations kept per method to
+// compute the latency percentiles from.
+const tracerSamples = 1024
+
+// MethodStats are the call statistics of an RPC method.
+type MethodStats struct {
+	Calls  uint64  `json:"calls"`
+	Errors uint64  `json:"errors"`
+	P50    float64 `json:"p50"` // Milliseconds, over the most recent calls
+	P95    float64 `json:"p95"`
+	P99    float64 `json:"p99"`
+}
+
+// methodTrace is the record of the calls of a method.
+type methodTrace struct {
+	calls   uint64
+	errors  uint64
+	samples []time.Duration // Ring of the most recent call durations
+	next    int             // Position of the next sample in the ring
+}
+
+// RequestTracer records the latency of the calls served per method and logs
+// them, either all of them or only the ones slower than a threshold.
+type RequestTracer struct {
+	logCalls bool
+	slow     time.Duration
+
+	lock    sync.Mutex
+	methods map[string]*methodTrace
+}
+
+// NewRequestTracer creates a tracer of the calls served. If logCalls is set every
+// call is logged, otherwise only the ones taking longer than slow, if non-zero.
+func NewRequestTracer(logCalls bool, slow time.Duration) *RequestTracer {
+	return &RequestTracer{
+		logCalls: logCalls,
+		slow:     slow,
+		methods:  make(map[string]*methodTrace),
+	}
+}
+
+// record accounts a served call and logs it if requested.
+func (t *RequestTracer) record(method string, info PeerInfo, elapsed time.Duration, reqSize, respSize int, err *jsonError) {
+	t.lock.Lock()
+	trace := t.methods[method]
+	if trace == nil {
+		trace = &methodTrace{samples: make([]time.Duration, 0, tracerSamples)}
+		t.methods[method] = trace
+	}
+	trace.calls++
+	if err != nil {
+		trace.errors++
+	}
+	if len(trace.samples) < tracerSamples {
+		trace.samples = append(trace.samples, elapsed)
+	} else {
+		trace.samples[trace.next] = elapsed
+	}
+	trace.next = (trace.next + 1) % tracerSamples
+	t.lock.Unlock()
+
+	slow := t.slow > 0 && elapsed >= t.slow
+	if !t.logCalls && !slow {
+		return
+	}
+	ctx := []interface{}{"method", method, "duration", elapsed, "reqsize", reqSize, "respsize", respSize, "transport", info.Transport, "client", clientLabel(info)}
+	if info.HTTP.UserAgent != "" {
+		ctx = append(ctx, "agent", info.HTTP.UserAgent)
+	}
+	if err != nil {
+		ctx = append(ctx, "err", err.Message)
+	}
+	if slow {
+		log.Warn("Slow RPC call", ctx...)
+	} else {
+		log.Info("RPC call", ctx...)
+	}
+}
+
+// Stats returns the call statistics of every method called so far.
+func (t *RequestTracer) Stats() map[string]*MethodStats {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	stats := make(map[string]*MethodStats, len(t.methods))
+	for method, trace := range t.methods {
+		samples := append([]time.Duration{}, trace.samples...)
+		sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+
+		stats[method] = &MethodStats{
+			Calls:  trace.calls,
+			Errors: trace.errors,
+			P50:    percentile(samples, 50),
+			P95:    percentile(samples, 95),
+			P99:    percentile(samples, 99),
+		}
+	}
+	return stats
+}
+
+// percentile returns the given percentile of sorted durations in milliseconds.
+func percentile(sorted []time.Duration, p int) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	return float64(sorted[(len(sorted)-1)*p/100]) / float64(time.Millisecond)
+}
+
+// clientLabel identifies the client of a call in logs: by a digest of its API
+// key if any, so that keys don't leak into logs, or by its IP address.
+func clientLabel(info PeerInfo) string {
+	if info.HTTP.APIKey != "" {
+		digest := sha256.Sum256([]byte(info.HTTP.APIKey))
+		return "key:" + hex.EncodeToString(digest[:4])
+	}
+	if host, _, err := net.SplitHostPort(info.RemoteAddr); err == nil {
+		return host
+	}
+	if info.RemoteAddr != "" {
+		return info.RemoteAddr
+	}
+	return info.Transport
+}
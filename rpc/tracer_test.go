@@ -0,0 +1,83 @@
+// Copyright 2023 Bitnet
+// This file is part of the Bitnet library.
+//
+// This software is provided "as is", without warranty of any kind,
+// express or implied, including but not limited to the warranties
+// of merchantability, fitness for a particular purpose and
+// noninfringement. In no even shall the authors or copyright
+// holders be liable for any claim, damages, or other liability,
+// whether in an action of contract, tort or otherwise, arising
+// from, out of or in connection with the software or the use or
+// other dealings in the software.
+
+package rpc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRequestTracerStats(t *testing.T) {
+	tracer := NewRequestTracer(false, 0)
+	for i := 1; i <= 100; i++ {
+		tracer.record("test_echo", PeerInfo{}, time.Duration(i)*time.Millisecond, 0, 0, nil)
+	}
+	tracer.record("test_fail", PeerInfo{}, time.Millisecond, 0, 0, &jsonError{Message: "failed"})
+
+	stats := tracer.Stats()
+	if have := stats["test_echo"]; have == nil || have.Calls != 100 || have.Errors != 0 || have.P50 != 50 || have.P95 != 95 || have.P99 != 99 {
+		t.Errorf("echo stats mismatch: %+v", have)
+	}
+	if have := stats["test_fail"]; have == nil || have.Calls != 1 || have.Errors != 1 {
+		t.Errorf("failure stats mismatch: %+v", have)
+	}
+	// Percentiles only cover the most recent calls
+	for i := 0; i < tracerSamples; i++ {
+		tracer.record("test_echo", PeerInfo{}, time.Second, 0, 0, nil)
+	}
+	if have := tracer.Stats()["test_echo"]; have.Calls != 100+tracerSamples || have.P50 != 1000 {
+		t.Errorf("echo stats mismatch after rotation: %+v", have)
+	}
+}
+
+// Tests that calls served by a traced server are recorded.
+func TestRequestTracerServer(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	tracer := NewRequestTracer(true, time.Nanosecond)
+	server.SetRequestTracer(tracer)
+
+	client := DialInProc(server)
+	defer client.Close()
+
+	var result echoResult
+	if err := client.CallContext(context.Background(), &result, "test_echo", "x", 1); err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	client.CallContext(context.Background(), nil, "test_returnError")
+
+	stats := tracer.Stats()
+	if have := stats["test_echo"]; have == nil || have.Calls != 1 || have.Errors != 0 {
+		t.Errorf("echo stats mismatch: %+v", have)
+	}
+	if have := stats["test_returnError"]; have == nil || have.Calls != 1 || have.Errors != 1 {
+		t.Errorf("error stats mismatch: %+v", have)
+	}
+}
+
+func TestClientLabel(t *testing.T) {
+	var info PeerInfo
+	info.Transport = "ipc"
+	if have := clientLabel(info); have != "ipc" {
+		t.Errorf("ipc label mismatch: %s", have)
+	}
+	info.Transport, info.RemoteAddr = "http", "10.0.0.1:4321"
+	if have := clientLabel(info); have != "10.0.0.1" {
+		t.Errorf("ip label mismatch: %s", have)
+	}
+	info.HTTP.APIKey = "secret"
+	if have := clientLabel(info); have != "key:2bb80d53" {
+		t.Errorf("key label mismatch: %s", have)
+	}
+}